@@ -42,14 +42,36 @@ import (
 )
 
 var (
-	dumpRegcap      = flag.Bool("regcap", false, "Print module capabilities JSON to stdout")
-	configFilename  = flag.String("config", "config.json", "Location of the configuration JSON file")
-	jsonInputFile   = flag.String("json", "", "Location of a vector-set input file")
-	uploadInputFile = flag.String("upload", "", "Location of a JSON results file to upload")
-	runFlag         = flag.String("run", "", "Name of primitive to run tests for")
-	fetchFlag       = flag.String("fetch", "", "Name of primitive to fetch vectors for")
-	expectedOutFlag = flag.String("expected-out", "", "Name of a file to write the expected results to")
-	wrapperPath     = flag.String("wrapper", "modulewrapper", "Path to the wrapper binary")
+	dumpRegcap       = flag.Bool("regcap", false, "Print module capabilities JSON to stdout")
+	listAlgorithms   = flag.Bool("list-algorithms", false, "Print the algorithms that acvptool can test, and what it supports of each, as JSON to stdout")
+	configFilename   = flag.String("config", "config.json", "Location of the configuration JSON file")
+	jsonInputFile    = flag.String("json", "", "Location of a vector-set input file")
+	jsonOutputFile   = flag.String("out", "", "Location to write the results of -json to, instead of stdout")
+	expectedFile     = flag.String("expected", "", "Location of the expected results for -json, to check the results against")
+	streamFlag       = flag.Bool("stream", false, "Process the test groups of -json as they are read, rather than reading the whole file first")
+	validateFlag     = flag.Bool("validate", false, "Check the structure of the vector sets in -json, without running the wrapper")
+	partialFlag      = flag.Bool("partial", false, "If a vector set in -json fails, still write the results of the test groups before the failure")
+	sortFlag         = flag.Bool("sort", false, "Sort the test cases of each test group in the results of -json by tcId")
+	progressFlag     = flag.Bool("progress", false, "Show how many test cases of each vector set have been answered on stderr")
+	uploadInputFile  = flag.String("upload", "", "Location of a JSON results file to upload")
+	runFlag          = flag.String("run", "", "Name of primitive to run tests for")
+	fetchFlag        = flag.String("fetch", "", "Name of primitive to fetch vectors for")
+	expectedOutFlag  = flag.String("expected-out", "", "Name of a file to write the expected results to")
+	wrapperPath      = flag.String("wrapper", "modulewrapper", "Path to the wrapper binary")
+	timeoutFlag      = flag.Duration("timeout", 0, "How long the wrapper may take to respond to each command, or zero for no limit")
+	workersFlag      = flag.Int("workers", 1, "Number of copies of the wrapper to run, with the test groups of each vector set split between them")
+	traceFlag        = flag.String("trace", "", "Name of a file to write a trace of every wrapper command to, as newline-delimited JSON")
+	traceDataFlag    = flag.Bool("trace-data", false, "Include the arguments and results of each command, which may be secret, in the trace")
+	compressFlag     = flag.Bool("compress", false, "Compress large messages to and from the wrapper, if it supports that")
+	retriesFlag      = flag.Int("retries", 0, "Number of times to resend a command that the wrapper reports as a transient failure")
+	maxInFlightFlag  = flag.Int("max-in-flight", 0, "Maximum number of commands sent to each wrapper before their responses are read, or zero for the default")
+	retryBackoffFlag = flag.Duration("retry-backoff", 100*time.Millisecond, "How long to wait before the first retry of a command, doubling for each later retry")
+	maxMessageFlag   = flag.Int("max-message-size", 0, "Maximum size, in bytes, of any message or output in a vector set, or zero for no limit")
+	byteOrderFlag    = flag.String("byte-order", "little", "Byte order, \"little\" or \"big\", of the counts and lengths that frame messages to and from the wrapper, for legacy wrappers")
+	jsonErrorsFlag   = flag.Bool("json-errors", false, "If processing -json fails, write the error to stderr as a JSON object with the algorithm, tgId, tcId, phase and message")
+	restartsFlag     = flag.Int("restarts", 0, "Number of times to restart the wrapper if it crashes, processing the vector set that it was working on again from the start")
+	parallelSetsFlag = flag.Int("parallel-vector-sets", 1, "Number of vector sets of a -run session to process at once, each with its own copies of the wrapper")
+	checkpointFlag   = flag.String("checkpoint", "", "Location of a file recording the progress of a -run session, which resumes the session if it already exists")
 )
 
 type Config struct {
@@ -197,32 +219,129 @@ func looksLikeVectorSetHeader(element json.RawMessage) bool {
 }
 
 // processFile reads a file containing vector sets, at least in the format
-// preferred by our lab, and writes the results to stdout.
-func processFile(filename string, supportedAlgos []map[string]any, middle Middle) error {
-	jsonBytes, err := os.ReadFile(filename)
+// preferred by our lab, and writes the results to w. The algorithm of each
+// vector set is taken from its "algorithm" field. If middle returns partial
+// results for a failed vector set, the results so far are written to w before
+// the error is returned.
+func processFile(filename string, supportedAlgos []map[string]any, middle Middle, w io.Writer) error {
+	header, elements, err := readVectorSets(filename)
 	if err != nil {
 		return err
 	}
 
-	var elements []json.RawMessage
+	algos := supportedAlgorithms(supportedAlgos)
+
+	var result bytes.Buffer
+	result.WriteString("[")
+
+	if header != nil {
+		headerBytes, err := json.MarshalIndent(header, "", "    ")
+		if err != nil {
+			return err
+		}
+		result.Write(headerBytes)
+		result.WriteString(",")
+	}
+
+	for i, element := range elements {
+		var commonFields struct {
+			Algo string `json:"algorithm"`
+			ID   uint64 `json:"vsId"`
+		}
+		if err := json.Unmarshal(element, &commonFields); err != nil {
+			return fmt.Errorf("failed to extract common fields from vector set #%d", i+1)
+		}
+
+		algo := commonFields.Algo
+		if _, ok := algos[algo]; !ok {
+			return fmt.Errorf("vector set #%d contains unsupported algorithm %q", i+1, algo)
+		}
+
+		replyGroups, err := middle.Process(algo, element)
+		if err != nil {
+			var partial *subprocess.PartialResultsError
+			if errors.As(err, &partial) {
+				// Write what there is, for debugging, but still fail.
+				if i != 0 {
+					result.WriteString(",")
+				}
+				if err := writeVectorSetResult(&result, commonFields.ID, algo, partial.Results); err != nil {
+					return err
+				}
+				result.WriteString("]\n")
+				w.Write(result.Bytes())
+			}
+			return &vectorSetError{i + 1, algo, err}
+		}
+
+		if i != 0 {
+			result.WriteString(",")
+		}
+		if err := writeVectorSetResult(&result, commonFields.ID, algo, replyGroups); err != nil {
+			return err
+		}
+	}
+
+	result.WriteString("]\n")
+	_, err = w.Write(result.Bytes())
+	return err
+}
+
+// readVectorSets reads a file in the format that processFile accepts and
+// returns its header, if any, and its vector sets, of which there is at least
+// one.
+func readVectorSets(filename string) (header json.RawMessage, elements []json.RawMessage, err error) {
+	jsonBytes, err := os.ReadFile(filename)
+	if err != nil {
+		return nil, nil, err
+	}
+
 	if err := json.Unmarshal(jsonBytes, &elements); err != nil {
-		return err
+		return nil, nil, err
 	}
 
 	// There must be at least one element in the file.
 	if len(elements) < 1 {
-		return errors.New("JSON input is empty")
+		return nil, nil, errors.New("JSON input is empty")
 	}
 
-	var header json.RawMessage
 	if looksLikeVectorSetHeader(elements[0]) {
 		header, elements = elements[0], elements[1:]
 		if len(elements) == 0 {
-			return errors.New("JSON input is empty")
+			return nil, nil, errors.New("JSON input is empty")
 		}
 	}
+	return header, elements, nil
+}
 
-	// Build a map of which algorithms our Middle supports.
+// validateFile checks the structure of the vector sets in a file that
+// processFile accepts, without a module, and reports every problem to w. It
+// returns the number of problems found. See subprocess.Validate.
+func validateFile(filename string, w io.Writer) (int, error) {
+	_, elements, err := readVectorSets(filename)
+	if err != nil {
+		return 0, err
+	}
+
+	var numErrs int
+	for i, element := range elements {
+		var commonFields struct {
+			Algo string `json:"algorithm"`
+		}
+		if err := json.Unmarshal(element, &commonFields); err != nil {
+			return numErrs, fmt.Errorf("failed to extract common fields from vector set #%d", i+1)
+		}
+		for _, err := range subprocess.Validate(commonFields.Algo, element) {
+			fmt.Fprintf(w, "vector set #%d (%s): %s\n", i+1, commonFields.Algo, err)
+			numErrs++
+		}
+	}
+	return numErrs, nil
+}
+
+// supportedAlgorithms returns the set of algorithm names in the configuration
+// of a Middle.
+func supportedAlgorithms(supportedAlgos []map[string]any) map[string]struct{} {
 	algos := make(map[string]struct{})
 	for _, supportedAlgo := range supportedAlgos {
 		algoInterface, ok := supportedAlgo["algorithm"]
@@ -235,58 +354,104 @@ func processFile(filename string, supportedAlgos []map[string]any, middle Middle
 		}
 		algos[algo] = struct{}{}
 	}
+	return algos
+}
+
+func writeVectorSetResult(w *bytes.Buffer, id uint64, algo string, replyGroups any) error {
+	group := map[string]any{
+		"vsId":       id,
+		"testGroups": replyGroups,
+		"algorithm":  algo,
+	}
+	replyBytes, err := json.MarshalIndent(group, "", "    ")
+	if err != nil {
+		return err
+	}
+	w.Write(replyBytes)
+	return nil
+}
+
+// processFileStream is like processFile but decodes the file as it is read,
+// passing each test group to middle as soon as it has been decoded, so that
+// the whole file never has to be in memory. The fields of each vector set
+// must precede its test groups. See subprocess.ProcessStream.
+func processFileStream(filename string, supportedAlgos []map[string]any, middle Middle, w io.Writer) error {
+	f, err := os.Open(filename)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	algos := supportedAlgorithms(supportedAlgos)
+	// algorithm is that of the vector set being processed, once known.
+	var algorithm string
+	process := func(algo string, vectorSet []byte) (any, error) {
+		algorithm = algo
+		if _, ok := algos[algo]; !ok {
+			return nil, fmt.Errorf("unsupported algorithm %q", algo)
+		}
+		return middle.Process(algo, vectorSet)
+	}
+
+	decoder := json.NewDecoder(f)
+	if token, err := decoder.Token(); err != nil {
+		return err
+	} else if token != json.Delim('[') {
+		return errors.New("JSON input is not an array")
+	}
 
 	var result bytes.Buffer
 	result.WriteString("[")
 
-	if header != nil {
-		headerBytes, err := json.MarshalIndent(header, "", "    ")
+	var numVectorSets int
+	for i := 0; decoder.More(); i++ {
+		algorithm = ""
+		fields, replyGroups, err := subprocess.ProcessStream(decoder, process)
+		if err != nil {
+			return &vectorSetError{numVectorSets + 1, algorithm, err}
+		}
+
+		fieldBytes, err := json.Marshal(fields)
 		if err != nil {
 			return err
 		}
-		result.Write(headerBytes)
-		result.WriteString(",")
-	}
+		if i == 0 && looksLikeVectorSetHeader(fieldBytes) {
+			headerBytes, err := json.MarshalIndent(fields, "", "    ")
+			if err != nil {
+				return err
+			}
+			result.Write(headerBytes)
+			result.WriteString(",")
+			continue
+		}
 
-	for i, element := range elements {
 		var commonFields struct {
 			Algo string `json:"algorithm"`
 			ID   uint64 `json:"vsId"`
 		}
-		if err := json.Unmarshal(element, &commonFields); err != nil {
-			return fmt.Errorf("failed to extract common fields from vector set #%d", i+1)
-		}
-
-		algo := commonFields.Algo
-		if _, ok := algos[algo]; !ok {
-			return fmt.Errorf("vector set #%d contains unsupported algorithm %q", i+1, algo)
+		if err := json.Unmarshal(fieldBytes, &commonFields); err != nil {
+			return fmt.Errorf("failed to extract common fields from vector set #%d", numVectorSets+1)
 		}
-
-		replyGroups, err := middle.Process(algo, element)
-		if err != nil {
-			return fmt.Errorf("while processing vector set #%d: %s", i+1, err)
+		if _, ok := algos[commonFields.Algo]; !ok {
+			return fmt.Errorf("vector set #%d contains unsupported algorithm %q", numVectorSets+1, commonFields.Algo)
 		}
 
-		group := map[string]any{
-			"vsId":       commonFields.ID,
-			"testGroups": replyGroups,
-			"algorithm":  algo,
+		if numVectorSets != 0 {
+			result.WriteString(",")
 		}
-		replyBytes, err := json.MarshalIndent(group, "", "    ")
-		if err != nil {
+		if err := writeVectorSetResult(&result, commonFields.ID, commonFields.Algo, replyGroups); err != nil {
 			return err
 		}
+		numVectorSets++
+	}
 
-		if i != 0 {
-			result.WriteString(",")
-		}
-		result.Write(replyBytes)
+	if numVectorSets == 0 {
+		return errors.New("JSON input is empty")
 	}
 
 	result.WriteString("]\n")
-	os.Stdout.Write(result.Bytes())
-
-	return nil
+	_, err = w.Write(result.Bytes())
+	return err
 }
 
 // getVectorsWithRetry fetches the given url from the server and parses it as a
@@ -363,6 +528,46 @@ func uploadResult(server *acvp.Server, setURL string, resultData []byte) error {
 	return nil
 }
 
+// runVectorSet fetches the vector set at setURL, processes it with middle and
+// returns the results to upload, after checking their structure.
+func runVectorSet(server *acvp.Server, setURL string, middle Middle) ([]byte, error) {
+	log.Printf("Fetching test vectors %q", setURL)
+
+	vectors, vectorsBytes, err := getVectorsWithRetry(server, trimLeadingSlash(setURL))
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch vector set %q: %s", setURL, err)
+	}
+
+	replyGroups, err := middle.Process(vectors.Algo, vectorsBytes)
+	if err != nil {
+		return nil, fmt.Errorf("failed to process vector set %q: %s", setURL, err)
+	}
+
+	headerBytes, err := json.Marshal(acvp.Vectors{
+		ID:   vectors.ID,
+		Algo: vectors.Algo,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal result: %s", err)
+	}
+
+	var resultBuf bytes.Buffer
+	resultBuf.Write(headerBytes[:len(headerBytes)-1])
+	resultBuf.WriteString(`,"testGroups":`)
+	replyBytes, err := json.Marshal(replyGroups)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal result: %s", err)
+	}
+	resultBuf.Write(replyBytes)
+	resultBuf.WriteString("}")
+
+	if err := checkResultSchema(vectorsBytes, resultBuf.Bytes()); err != nil {
+		return nil, fmt.Errorf("results of vector set %q are malformed: %s", setURL, err)
+	}
+
+	return resultBuf.Bytes(), nil
+}
+
 func connect(config *Config, sessionTokensCacheDir string) (*acvp.Server, error) {
 	if len(config.TOTPSecret) == 0 {
 		return nil, errors.New("config file missing TOTPSecret")
@@ -507,6 +712,12 @@ func uploadFromFile(file string, config *Config, sessionTokensCacheDir string) {
 		log.Fatalf("have %d URLs from header, but only %d result groups", len(header.VectorSetURLs), numGroups)
 	}
 
+	for _, result := range input[1:] {
+		if err := checkResultSchema(nil, result); err != nil {
+			log.Fatalf("Results are malformed: %s", err)
+		}
+	}
+
 	server, err := connect(config, sessionTokensCacheDir)
 	if err != nil {
 		log.Fatal(err)
@@ -526,14 +737,182 @@ func uploadFromFile(file string, config *Config, sessionTokensCacheDir string) {
 	}
 }
 
+// newMiddle starts the number of wrappers given by -workers. More than one
+// is combined into a subprocess.Pool. If -trace is given, the wrappers'
+// commands are traced to that file.
+func newMiddle(traceFile io.Writer) (Middle, error) {
+	if *workersFlag < 1 {
+		return nil, fmt.Errorf("-workers must be at least one, not %d", *workersFlag)
+	}
+	if *retriesFlag < 0 {
+		return nil, fmt.Errorf("-retries must not be negative, not %d", *retriesFlag)
+	}
+	var byteOrder binary.ByteOrder
+	switch *byteOrderFlag {
+	case "little":
+		byteOrder = binary.LittleEndian
+	case "big":
+		byteOrder = binary.BigEndian
+	default:
+		return nil, fmt.Errorf("-byte-order must be \"little\" or \"big\", not %q", *byteOrderFlag)
+	}
+
+	if *workersFlag == 1 {
+		middle, err := subprocess.NewWithTimeout(*wrapperPath, *timeoutFlag)
+		if err != nil {
+			return nil, err
+		}
+		middle.SetByteOrder(byteOrder)
+		middle.SetRetryPolicy(*retriesFlag, *retryBackoffFlag)
+		middle.SetMaxInFlight(*maxInFlightFlag)
+		middle.SetMaxMessageSize(*maxMessageFlag)
+		if traceFile != nil {
+			middle.Trace(traceFile, *traceDataFlag)
+		}
+		return middle, nil
+	}
+
+	var workers []*subprocess.Subprocess
+	for i := 0; i < *workersFlag; i++ {
+		worker, err := subprocess.NewWithTimeout(*wrapperPath, *timeoutFlag)
+		if err != nil {
+			for _, w := range workers {
+				w.Close()
+			}
+			return nil, err
+		}
+		worker.SetByteOrder(byteOrder)
+		worker.SetRetryPolicy(*retriesFlag, *retryBackoffFlag)
+		worker.SetMaxInFlight(*maxInFlightFlag)
+		worker.SetMaxMessageSize(*maxMessageFlag)
+		workers = append(workers, worker)
+	}
+	pool, err := subprocess.NewPool(workers)
+	if err != nil {
+		return nil, err
+	}
+	if traceFile != nil {
+		pool.Trace(traceFile, *traceDataFlag)
+	}
+	return pool, nil
+}
+
+// configureMiddle applies the flags that change how middle processes vector
+// sets.
+func configureMiddle(middle Middle) error {
+	if *compressFlag {
+		compressor, ok := middle.(interface{ EnableCompression() error })
+		if !ok {
+			return errors.New("-compress is not supported by this middle")
+		}
+		if err := compressor.EnableCompression(); err != nil {
+			return fmt.Errorf("failed to enable compression: %s", err)
+		}
+	}
+	if *partialFlag {
+		partialer, ok := middle.(interface{ EnablePartialResults() })
+		if !ok {
+			return errors.New("-partial is not supported by this middle")
+		}
+		partialer.EnablePartialResults()
+	}
+	if *sortFlag {
+		sorter, ok := middle.(interface{ EnableSortedResults() })
+		if !ok {
+			return errors.New("-sort is not supported by this middle")
+		}
+		sorter.EnableSortedResults()
+	}
+	if *progressFlag {
+		progresser, ok := middle.(interface{ SetProgress(subprocess.ProgressFunc) })
+		if !ok {
+			return errors.New("-progress is not supported by this middle")
+		}
+		progresser.SetProgress(printProgress)
+	}
+	return nil
+}
+
+// printProgress overwrites a line on stderr with the progress of a vector
+// set, and finishes the line once the vector set is complete.
+func printProgress(algorithm string, completed, total int) {
+	fmt.Fprintf(os.Stderr, "\r%s: %d/%d test cases", algorithm, completed, total)
+	if completed == total {
+		fmt.Fprintln(os.Stderr)
+	}
+}
+
+// printAlgorithms writes the capabilities of acvptool's handlers to w as
+// indented JSON. Unlike -regcap, this doesn't need a module.
+func printAlgorithms(w io.Writer) error {
+	algorithmsJSON, err := json.MarshalIndent(subprocess.Algorithms(), "", "  ")
+	if err != nil {
+		return err
+	}
+	_, err = fmt.Fprintf(w, "%s\n", algorithmsJSON)
+	return err
+}
+
 func main() {
 	flag.Parse()
 
-	middle, err := subprocess.New(*wrapperPath)
+	if *listAlgorithms {
+		if err := printAlgorithms(os.Stdout); err != nil {
+			log.Fatalf("failed to list algorithms: %s", err)
+		}
+		return
+	}
+
+	if *validateFlag {
+		if len(*jsonInputFile) == 0 {
+			log.Fatalf("-validate can only be used with -json")
+		}
+		numErrs, err := validateFile(*jsonInputFile, os.Stderr)
+		if err != nil {
+			log.Fatalf("failed to validate input file: %s", err)
+		}
+		if numErrs > 0 {
+			log.Fatalf("found %d problems in %s", numErrs, *jsonInputFile)
+		}
+		log.Printf("no problems found in %s", *jsonInputFile)
+		return
+	}
+
+	if *restartsFlag < 0 {
+		log.Fatalf("-restarts must not be negative, not %d", *restartsFlag)
+	}
+	if *parallelSetsFlag < 1 {
+		log.Fatalf("-parallel-vector-sets must be at least one, not %d", *parallelSetsFlag)
+	}
+	if *parallelSetsFlag > 1 && (len(*runFlag) == 0 || *progressFlag) {
+		log.Fatalf("-parallel-vector-sets can only be used with -run, and not with -progress")
+	}
+	if len(*checkpointFlag) > 0 && len(*runFlag) == 0 {
+		log.Fatalf("-checkpoint can only be used with -run")
+	}
+
+	// The trace file is shared by any restarted wrappers, so that it covers
+	// the crashes, and by the wrappers of concurrent vector sets.
+	var traceFile io.Writer
+	if len(*traceFlag) > 0 {
+		f, err := os.Create(*traceFlag)
+		if err != nil {
+			log.Fatalf("failed to create trace file: %s", err)
+		}
+		defer f.Close()
+		traceFile = f
+		if *parallelSetsFlag > 1 {
+			// The middles of concurrent vector sets share the file.
+			traceFile = subprocess.NewLockedWriter(f)
+		}
+	}
+
+	middle, err := newMiddle(traceFile)
 	if err != nil {
 		log.Fatalf("failed to initialise middle: %s", err)
 	}
-	defer middle.Close()
+	// A restartingMiddle may replace middle.
+	defer func() { middle.Close() }()
 
 	configBytes, err := middle.Config()
 	if err != nil {
@@ -579,10 +958,85 @@ func main() {
 		return
 	}
 
+	if len(*jsonOutputFile) > 0 && len(*jsonInputFile) == 0 {
+		log.Fatalf("-out can only be used with -json")
+	}
+	if len(*expectedFile) > 0 && len(*jsonInputFile) == 0 {
+		log.Fatalf("-expected can only be used with -json")
+	}
+	if *partialFlag && (len(*jsonInputFile) == 0 || *streamFlag) {
+		log.Fatalf("-partial can only be used with -json, and not with -stream")
+	}
+	if *sortFlag && len(*jsonInputFile) == 0 {
+		log.Fatalf("-sort can only be used with -json")
+	}
+	if *jsonErrorsFlag && len(*jsonInputFile) == 0 {
+		log.Fatalf("-json-errors can only be used with -json")
+	}
+
+	if err := configureMiddle(middle); err != nil {
+		log.Fatalf("%s", err)
+	}
+	// startMiddle starts another Middle, configured in the same way as the
+	// first, for restarts and for concurrent vector sets.
+	startMiddle := func() (Middle, error) {
+		middle, err := newMiddle(traceFile)
+		if err != nil {
+			return nil, err
+		}
+		if _, err := middle.Config(); err != nil {
+			middle.Close()
+			return nil, err
+		}
+		if err := configureMiddle(middle); err != nil {
+			middle.Close()
+			return nil, err
+		}
+		return middle, nil
+	}
+	if *restartsFlag > 0 {
+		middle = &restartingMiddle{middle, startMiddle, *restartsFlag}
+	}
+
 	if len(*jsonInputFile) > 0 {
-		if err := processFile(*jsonInputFile, supportedAlgos, middle); err != nil {
+		var out io.Writer = os.Stdout
+		var outFile *os.File
+		if len(*jsonOutputFile) > 0 {
+			if outFile, err = os.Create(*jsonOutputFile); err != nil {
+				log.Fatalf("failed to create output file: %s", err)
+			}
+			out = outFile
+		}
+		var results bytes.Buffer
+		process := processFile
+		if *streamFlag {
+			process = processFileStream
+		}
+		if err := process(*jsonInputFile, supportedAlgos, middle, io.MultiWriter(out, &results)); err != nil {
+			if *jsonErrorsFlag {
+				if err := writeErrorReport(os.Stderr, err); err != nil {
+					log.Fatalf("failed to write error: %s", err)
+				}
+				os.Exit(1)
+			}
 			log.Fatalf("failed to process input file: %s", err)
 		}
+		if outFile != nil {
+			if err := outFile.Close(); err != nil {
+				log.Fatalf("failed to write output file: %s", err)
+			}
+		}
+		if len(*expectedFile) > 0 {
+			expected, err := os.ReadFile(*expectedFile)
+			if err != nil {
+				log.Fatalf("failed to read expected results: %s", err)
+			}
+			n, err := checkExpected(results.Bytes(), expected)
+			if err != nil {
+				log.Fatalf("results do not match %s: %s", *expectedFile, err)
+			}
+			log.Printf("all %d test cases match %s", n, *expectedFile)
+		}
 		return
 	}
 
@@ -685,26 +1139,61 @@ func main() {
 		return
 	}
 
-	requestBytes, err := json.Marshal(acvp.TestSession{
-		IsSample:    true,
-		Publishable: false,
-		Algorithms:  algorithms,
-	})
-	if err != nil {
-		log.Fatalf("Failed to serialise JSON: %s", err)
+	var cp *checkpoint
+	if len(*checkpointFlag) > 0 {
+		if cp, err = loadCheckpoint(*checkpointFlag); err != nil {
+			log.Fatal(err)
+		}
+		if cp != nil && cp.Run != *runFlag {
+			log.Fatalf("checkpoint %q is of a session for -run %q, not %q", *checkpointFlag, cp.Run, *runFlag)
+		}
 	}
 
 	var result acvp.TestSession
-	if err := server.Post(&result, "acvp/v1/testSessions", requestBytes); err != nil {
-		log.Fatalf("Request to create test session failed: %s", err)
-	}
+	var url string
+	if cp != nil {
+		url = cp.SessionURL
+		result.VectorSetURLs = cp.VectorSetURLs
+		if len(cp.AccessToken) > 0 {
+			server.PrefixTokens[url] = cp.AccessToken
+		}
+		log.Printf("Resuming test session %q, with %d of %d vector sets uploaded", url, len(cp.Uploaded), len(cp.VectorSetURLs))
+	} else {
+		requestBytes, err := json.Marshal(acvp.TestSession{
+			IsSample:    true,
+			Publishable: false,
+			Algorithms:  algorithms,
+		})
+		if err != nil {
+			log.Fatalf("Failed to serialise JSON: %s", err)
+		}
 
-	url := trimLeadingSlash(result.URL)
-	log.Printf("Created test session %q", url)
-	if token := result.AccessToken; len(token) > 0 {
-		server.PrefixTokens[url] = token
-		if len(sessionTokensCacheDir) > 0 {
-			os.WriteFile(filepath.Join(sessionTokensCacheDir, neturl.PathEscape(url))+".token", []byte(token), 0600)
+		if err := server.Post(&result, "acvp/v1/testSessions", requestBytes); err != nil {
+			log.Fatalf("Request to create test session failed: %s", err)
+		}
+
+		url = trimLeadingSlash(result.URL)
+		log.Printf("Created test session %q", url)
+		if token := result.AccessToken; len(token) > 0 {
+			server.PrefixTokens[url] = token
+			if len(sessionTokensCacheDir) > 0 {
+				os.WriteFile(filepath.Join(sessionTokensCacheDir, neturl.PathEscape(url))+".token", []byte(token), 0600)
+			}
+		}
+
+		if len(*checkpointFlag) > 0 {
+			cp = &checkpoint{
+				Run:           *runFlag,
+				SessionURL:    url,
+				AccessToken:   result.AccessToken,
+				VectorSetURLs: result.VectorSetURLs,
+			}
+			if err := cp.save(*checkpointFlag); err != nil {
+				log.Printf("Failed to write checkpoint: %s", err)
+				log.Printf("Deleting test set")
+				server.Delete(url)
+				os.Exit(1)
+			}
 		}
 	}
 
@@ -717,84 +1206,94 @@ func main() {
 			VectorSetURLs: result.VectorSetURLs,
 			Time:          time.Now().Format(time.RFC3339),
 		})
-	}
 
-	for _, setURL := range result.VectorSetURLs {
-		log.Printf("Fetching test vectors %q", setURL)
+		for _, setURL := range result.VectorSetURLs {
+			log.Printf("Fetching test vectors %q", setURL)
 
-		vectors, vectorsBytes, err := getVectorsWithRetry(server, trimLeadingSlash(setURL))
-		if err != nil {
-			log.Fatalf("Failed to fetch vector set %q: %s", setURL, err)
-		}
+			_, vectorsBytes, err := getVectorsWithRetry(server, trimLeadingSlash(setURL))
+			if err != nil {
+				log.Fatalf("Failed to fetch vector set %q: %s", setURL, err)
+			}
 
-		if len(*fetchFlag) > 0 {
 			os.Stdout.WriteString(",\n")
 			os.Stdout.Write(vectorsBytes)
-		}
 
-		if expectedOut != nil {
-			log.Printf("Fetching expected results")
+			if expectedOut != nil {
+				log.Printf("Fetching expected results")
 
-			_, expectedResultsBytes, err := getVectorsWithRetry(server, trimLeadingSlash(setURL)+"/expected")
-			if err != nil {
-				log.Fatalf("Failed to fetch expected results: %s", err)
-			}
+				_, expectedResultsBytes, err := getVectorsWithRetry(server, trimLeadingSlash(setURL)+"/expected")
+				if err != nil {
+					log.Fatalf("Failed to fetch expected results: %s", err)
+				}
 
-			expectedOut.WriteString(",")
-			expectedOut.Write(expectedResultsBytes)
+				expectedOut.WriteString(",")
+				expectedOut.Write(expectedResultsBytes)
+			}
 		}
 
-		if len(*fetchFlag) > 0 {
-			continue
-		}
+		io.WriteString(fetchOutputTee, "]\n")
+		return
+	}
 
-		replyGroups, err := middle.Process(vectors.Algo, vectorsBytes)
-		if err != nil {
-			log.Printf("Failed: %s", err)
+	// failSession stops after a failure. A checkpointed session is kept so
+	// that it can be resumed.
+	failSession := func(err error) {
+		log.Printf("%s", err)
+		if cp != nil {
+			log.Printf("Keeping test set, which -checkpoint %s will resume", *checkpointFlag)
+		} else {
 			log.Printf("Deleting test set")
 			server.Delete(url)
-			os.Exit(1)
 		}
+		os.Exit(1)
+	}
 
-		headerBytes, err := json.Marshal(acvp.Vectors{
-			ID:   vectors.ID,
-			Algo: vectors.Algo,
-		})
-		if err != nil {
-			log.Printf("Failed to marshal result: %s", err)
-			log.Printf("Deleting test set")
-			server.Delete(url)
-			os.Exit(1)
-		}
+	setURLs := result.VectorSetURLs
+	if cp != nil {
+		setURLs = cp.remaining()
+	}
 
-		var resultBuf bytes.Buffer
-		resultBuf.Write(headerBytes[:len(headerBytes)-1])
-		resultBuf.WriteString(`,"testGroups":`)
-		replyBytes, err := json.Marshal(replyGroups)
+	// Each concurrent vector set has its own Middle, the first of which is
+	// the one started above.
+	middles := []Middle{middle}
+	for len(middles) < *parallelSetsFlag && len(middles) < len(setURLs) {
+		extra, err := startMiddle()
 		if err != nil {
-			log.Printf("Failed to marshal result: %s", err)
-			log.Printf("Deleting test set")
-			server.Delete(url)
-			os.Exit(1)
+			failSession(fmt.Errorf("failed to initialise middle: %s", err))
 		}
-		resultBuf.Write(replyBytes)
-		resultBuf.WriteString("}")
-
-		if err := uploadResult(server, setURL, resultBuf.Bytes()); err != nil {
-			log.Printf("Deleting test set")
-			server.Delete(url)
-			log.Fatal(err)
+		if *restartsFlag > 0 {
+			extra = &restartingMiddle{extra, startMiddle, *restartsFlag}
 		}
+		defer extra.Close()
+		middles = append(middles, extra)
 	}
 
-	if len(*fetchFlag) > 0 {
-		io.WriteString(fetchOutputTee, "]\n")
-		return
+	err = processVectorSets(len(setURLs), middles, func(i int, middle Middle) ([]byte, error) {
+		return runVectorSet(server, setURLs[i], middle)
+	}, func(i int, resultBytes []byte) error {
+		if err := uploadResult(server, setURLs[i], resultBytes); err != nil {
+			return err
+		}
+		if cp == nil {
+			return nil
+		}
+		return cp.markUploaded(*checkpointFlag, setURLs[i])
+	})
+	if err != nil {
+		failSession(err)
 	}
 
-	if ok, err := getResultsWithRetry(server, url); err != nil {
+	ok, err := getResultsWithRetry(server, url)
+	if err != nil {
 		log.Fatal(err)
-	} else if !ok {
+	}
+	// The session is complete, so there is nothing left to resume.
+	if cp != nil {
+		if err := os.Remove(*checkpointFlag); err != nil {
+			log.Printf("Failed to remove checkpoint: %s", err)
+		}
+	}
+	if !ok {
 		os.Exit(1)
 	}
 }
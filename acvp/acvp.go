@@ -29,6 +29,7 @@ import (
 	"os"
 	"reflect"
 	"strings"
+	"sync"
 	"time"
 )
 
@@ -50,6 +51,9 @@ type Server struct {
 	client   *http.Client
 	prefix   string
 	totpFunc func() string
+	// tokensMu is held while looking up and refreshing PrefixTokens, as
+	// vector sets may be fetched and uploaded concurrently.
+	tokensMu sync.Mutex
 }
 
 // NewServer returns a fresh Server instance representing the ACVP server at
@@ -244,6 +248,9 @@ func expired(tokenStr string) bool {
 }
 
 func (server *Server) getToken(endPoint string) (string, error) {
+	server.tokensMu.Lock()
+	defer server.tokensMu.Unlock()
+
 	for path, token := range server.PrefixTokens {
 		if endPoint != path && !strings.HasPrefix(endPoint, path+"/") {
 			continue
@@ -365,15 +372,20 @@ func (query Query) toURLParams() string {
 var NotFound = errors.New("acvp: HTTP code 404")
 
 func (server *Server) newRequestWithToken(method, endpoint string, body io.Reader) (*http.Request, error) {
-	token, err := server.getToken(endpoint)
-	if err != nil {
-		return nil, err
+	// Logging in doesn't use a token, and getToken logs in to refresh
+	// expired ones, so it isn't called for that.
+	var token string
+	if endpoint != loginEndpoint {
+		var err error
+		if token, err = server.getToken(endpoint); err != nil {
+			return nil, err
+		}
 	}
 	req, err := http.NewRequest(method, server.prefix+endpoint, body)
 	if err != nil {
 		return nil, err
 	}
-	if len(token) != 0 && endpoint != loginEndpoint {
+	if len(token) != 0 {
 		req.Header.Add("Authorization", "Bearer "+token)
 	}
 	return req, nil
@@ -0,0 +1,181 @@
+// Copyright (c) 2026, Google Inc.
+//
+// Permission to use, copy, modify, and/or distribute this software for any
+// purpose with or without fee is hereby granted, provided that the above
+// copyright notice and this permission notice appear in all copies.
+//
+// THE SOFTWARE IS PROVIDED "AS IS" AND THE AUTHOR DISCLAIMS ALL WARRANTIES
+// WITH REGARD TO THIS SOFTWARE INCLUDING ALL IMPLIED WARRANTIES OF
+// MERCHANTABILITY AND FITNESS. IN NO EVENT SHALL THE AUTHOR BE LIABLE FOR ANY
+// SPECIAL, DIRECT, INDIRECT, OR CONSEQUENTIAL DAMAGES OR ANY DAMAGES
+// WHATSOEVER RESULTING FROM LOSS OF USE, DATA OR PROFITS, WHETHER IN AN ACTION
+// OF CONTRACT, NEGLIGENCE OR OTHER TORTIOUS ACTION, ARISING OUT OF OR IN
+// CONNECTION WITH THE USE OR PERFORMANCE OF THIS SOFTWARE.
+
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"flag"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/cpu/acvptool/subprocess"
+)
+
+var updateGolden = flag.Bool("update", false, "If true then write updated golden files")
+
+// startTestModuleWrapper builds and runs testmodulewrapper, returning it and
+// its configuration.
+func startTestModuleWrapper(t *testing.T) (*subprocess.Subprocess, []map[string]any) {
+	goTool, err := exec.LookPath("go")
+	if err != nil {
+		t.Skip("go tool not found to build testmodulewrapper")
+	}
+	wrapper := filepath.Join(t.TempDir(), "testmodulewrapper")
+	if out, err := exec.Command(goTool, "build", "-o", wrapper, "./testmodulewrapper").CombinedOutput(); err != nil {
+		t.Fatalf("failed to build testmodulewrapper: %s\n%s", err, out)
+	}
+
+	middle, err := subprocess.New(wrapper)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(middle.Close)
+
+	configBytes, err := middle.Config()
+	if err != nil {
+		t.Fatal(err)
+	}
+	var supportedAlgos []map[string]any
+	if err := json.Unmarshal(configBytes, &supportedAlgos); err != nil {
+		t.Fatal(err)
+	}
+	return middle, supportedAlgos
+}
+
+// TestProcessFileGolden answers a saved vector set with testmodulewrapper and
+// compares the results against a golden file.
+func TestProcessFileGolden(t *testing.T) {
+	middle, supportedAlgos := startTestModuleWrapper(t)
+
+	var out bytes.Buffer
+	if err := processFile(filepath.Join("testdata", "cSHAKE-128.json"), supportedAlgos, middle, &out); err != nil {
+		t.Fatal(err)
+	}
+
+	var streamed bytes.Buffer
+	if err := processFileStream(filepath.Join("testdata", "cSHAKE-128.json"), supportedAlgos, middle, &streamed); err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(streamed.Bytes(), out.Bytes()) {
+		t.Errorf("streamed results differ. Got:\n%s", streamed.Bytes())
+	}
+
+	golden := filepath.Join("testdata", "cSHAKE-128.response.json")
+	if *updateGolden {
+		if err := os.WriteFile(golden, out.Bytes(), 0644); err != nil {
+			t.Fatal(err)
+		}
+		return
+	}
+	want, err := os.ReadFile(golden)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(out.Bytes(), want) {
+		t.Errorf("results differ from %s. Got:\n%s", golden, out.Bytes())
+	}
+}
+
+func TestCheckExpected(t *testing.T) {
+	middle, supportedAlgos := startTestModuleWrapper(t)
+
+	var results bytes.Buffer
+	if err := processFile(filepath.Join("testdata", "cSHAKE-128.json"), supportedAlgos, middle, &results); err != nil {
+		t.Fatal(err)
+	}
+
+	golden, err := os.ReadFile(filepath.Join("testdata", "cSHAKE-128.response.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n, err := checkExpected(results.Bytes(), golden); err != nil || n != 3 {
+		t.Errorf("checking against the golden file checked %d test cases and returned %v", n, err)
+	}
+
+	// The expected digest of test case 2 has been changed.
+	expected, err := os.ReadFile(filepath.Join("testdata", "cSHAKE-128.expected.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	n, err := checkExpected(results.Bytes(), expected)
+	if err == nil {
+		t.Fatal("mismatch was not detected")
+	}
+	if msg := err.Error(); !strings.Contains(msg, "test case 1/2: md is c5221d50") {
+		t.Errorf("mismatch reported as %q", msg)
+	}
+	if n != 1 {
+		t.Errorf("%d test cases were checked before the mismatch, want 1", n)
+	}
+}
+
+func TestProcessFilePartial(t *testing.T) {
+	middle, supportedAlgos := startTestModuleWrapper(t)
+	middle.EnablePartialResults()
+
+	// The customization of the second group isn't valid hex.
+	input := filepath.Join(t.TempDir(), "input.json")
+	if err := os.WriteFile(input, []byte(`[{"vsId": 1, "algorithm": "cSHAKE-128", "testGroups": [
+		{"tgId": 1, "testType": "AFT", "tests": [{"tcId": 1, "len": 8, "msg": "00", "outLen": 64, "functionName": "", "customization": ""}]},
+		{"tgId": 2, "testType": "AFT", "hexCustomization": true, "tests": [{"tcId": 2, "len": 8, "msg": "00", "outLen": 64, "functionName": "", "customizationHex": "zz"}]}
+	]}]`), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	var out bytes.Buffer
+	if err := processFile(input, supportedAlgos, middle, &out); err == nil {
+		t.Fatal("processFile succeeded despite a bad customization")
+	}
+
+	var results []struct {
+		Groups []struct {
+			ID uint64 `json:"tgId"`
+		} `json:"testGroups"`
+	}
+	if err := json.Unmarshal(out.Bytes(), &results); err != nil {
+		t.Fatalf("partial output is not valid JSON: %s\n%s", err, out.Bytes())
+	}
+	if len(results) != 1 || len(results[0].Groups) != 1 || results[0].Groups[0].ID != 1 {
+		t.Errorf("got partial output:\n%s", out.Bytes())
+	}
+}
+
+func TestPrintAlgorithms(t *testing.T) {
+	var out bytes.Buffer
+	if err := printAlgorithms(&out); err != nil {
+		t.Fatal(err)
+	}
+	var algorithms []subprocess.AlgorithmCapabilities
+	if err := json.Unmarshal(out.Bytes(), &algorithms); err != nil {
+		t.Fatalf("output isn't a list of algorithms: %s", err)
+	}
+
+	var cShake *subprocess.AlgorithmCapabilities
+	for i := range algorithms {
+		if algorithms[i].Algorithm == "cSHAKE-128" {
+			cShake = &algorithms[i]
+		}
+	}
+	if cShake == nil {
+		t.Fatalf("cSHAKE-128 missing from %s", out.Bytes())
+	}
+	if xof, ok := cShake.Capabilities["xof"]; !ok || xof != true {
+		t.Errorf("cSHAKE-128 has capabilities %v, want xof to be true", cShake.Capabilities)
+	}
+}
@@ -0,0 +1,109 @@
+// Copyright (c) 2026, Google Inc.
+//
+// Permission to use, copy, modify, and/or distribute this software for any
+// purpose with or without fee is hereby granted, provided that the above
+// copyright notice and this permission notice appear in all copies.
+//
+// THE SOFTWARE IS PROVIDED "AS IS" AND THE AUTHOR DISCLAIMS ALL WARRANTIES
+// WITH REGARD TO THIS SOFTWARE INCLUDING ALL IMPLIED WARRANTIES OF
+// MERCHANTABILITY AND FITNESS. IN NO EVENT SHALL THE AUTHOR BE LIABLE FOR ANY
+// SPECIAL, DIRECT, INDIRECT, OR CONSEQUENTIAL DAMAGES OR ANY DAMAGES
+// WHATSOEVER RESULTING FROM LOSS OF USE, DATA OR PROFITS, WHETHER IN AN ACTION
+// OF CONTRACT, NEGLIGENCE OR OTHER TORTIOUS ACTION, ARISING OUT OF OR IN
+// CONNECTION WITH THE USE OR PERFORMANCE OF THIS SOFTWARE.
+
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+)
+
+// checkpoint records the progress of a -run session so that, if acvptool is
+// interrupted, it can resume the session rather than start a new one. The
+// session and vector sets are identified by their URLs, which contain the
+// session ID and the vsIds.
+type checkpoint struct {
+	// Run is the value of -run that the session was created for.
+	Run         string `json:"run"`
+	SessionURL  string `json:"sessionUrl"`
+	AccessToken string `json:"accessToken,omitempty"`
+	// VectorSetURLs are those of the whole session, in order.
+	VectorSetURLs []string `json:"vectorSetUrls"`
+	// Uploaded are the vector sets whose results have been uploaded.
+	Uploaded []string `json:"uploaded"`
+}
+
+// loadCheckpoint reads the checkpoint in filename. If there is no such file,
+// it returns nil and no error.
+func loadCheckpoint(filename string) (*checkpoint, error) {
+	contents, err := os.ReadFile(filename)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var c checkpoint
+	if err := json.Unmarshal(contents, &c); err != nil {
+		return nil, fmt.Errorf("failed to parse checkpoint %q: %s", filename, err)
+	}
+	if len(c.SessionURL) == 0 {
+		return nil, fmt.Errorf("checkpoint %q has no session", filename)
+	}
+	for _, setURL := range c.Uploaded {
+		if !slices.Contains(c.VectorSetURLs, setURL) {
+			return nil, fmt.Errorf("checkpoint %q has uploaded vector set %q, which isn't in session %q", filename, setURL, c.SessionURL)
+		}
+	}
+	return &c, nil
+}
+
+// save writes the checkpoint to filename. The file is replaced in one step,
+// so that an interruption leaves either the old checkpoint or the new one.
+// It may contain the session's access token, so only the user can read it.
+func (c *checkpoint) save(filename string) error {
+	contents, err := json.MarshalIndent(c, "", "  ")
+	if err != nil {
+		return err
+	}
+	tmp, err := os.CreateTemp(filepath.Dir(filename), filepath.Base(filename)+".*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := tmp.Write(append(contents, '\n')); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), filename)
+}
+
+// markUploaded records that the results of the vector set at setURL have
+// been uploaded, and saves the checkpoint to filename.
+func (c *checkpoint) markUploaded(filename, setURL string) error {
+	c.Uploaded = append(c.Uploaded, setURL)
+	if err := c.save(filename); err != nil {
+		return fmt.Errorf("failed to write checkpoint: %s", err)
+	}
+	return nil
+}
+
+// remaining returns the vector sets of the session that haven't been
+// uploaded, in order.
+func (c *checkpoint) remaining() []string {
+	var ret []string
+	for _, setURL := range c.VectorSetURLs {
+		if !slices.Contains(c.Uploaded, setURL) {
+			ret = append(ret, setURL)
+		}
+	}
+	return ret
+}
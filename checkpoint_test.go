@@ -0,0 +1,109 @@
+// Copyright (c) 2026, Google Inc.
+//
+// Permission to use, copy, modify, and/or distribute this software for any
+// purpose with or without fee is hereby granted, provided that the above
+// copyright notice and this permission notice appear in all copies.
+//
+// THE SOFTWARE IS PROVIDED "AS IS" AND THE AUTHOR DISCLAIMS ALL WARRANTIES
+// WITH REGARD TO THIS SOFTWARE INCLUDING ALL IMPLIED WARRANTIES OF
+// MERCHANTABILITY AND FITNESS. IN NO EVENT SHALL THE AUTHOR BE LIABLE FOR ANY
+// SPECIAL, DIRECT, INDIRECT, OR CONSEQUENTIAL DAMAGES OR ANY DAMAGES
+// WHATSOEVER RESULTING FROM LOSS OF USE, DATA OR PROFITS, WHETHER IN AN ACTION
+// OF CONTRACT, NEGLIGENCE OR OTHER TORTIOUS ACTION, ARISING OUT OF OR IN
+// CONNECTION WITH THE USE OR PERFORMANCE OF THIS SOFTWARE.
+
+package main
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestCheckpointResume(t *testing.T) {
+	filename := filepath.Join(t.TempDir(), "checkpoint.json")
+	setURLs := []string{
+		"acvp/v1/testSessions/1/vectorSets/10",
+		"acvp/v1/testSessions/1/vectorSets/11",
+		"acvp/v1/testSessions/1/vectorSets/12",
+	}
+	cp := &checkpoint{Run: "SHA2-256", SessionURL: "acvp/v1/testSessions/1", AccessToken: "token", VectorSetURLs: setURLs}
+	if err := cp.save(filename); err != nil {
+		t.Fatal(err)
+	}
+
+	// run processes the vector sets that remain in the checkpoint, as main
+	// does, and is interrupted when uploading the results of interruptAt.
+	run := func(interruptAt string) (processed []string, err error) {
+		cp, err := loadCheckpoint(filename)
+		if err != nil {
+			return nil, err
+		}
+		remaining := cp.remaining()
+		err = processVectorSets(len(remaining), []Middle{new(sessionMiddle)}, func(i int, middle Middle) ([]byte, error) {
+			processed = append(processed, remaining[i])
+			return nil, nil
+		}, func(i int, result []byte) error {
+			if remaining[i] == interruptAt {
+				return errors.New("interrupted")
+			}
+			return cp.markUploaded(filename, remaining[i])
+		})
+		return processed, err
+	}
+
+	if _, err := run(setURLs[2]); err == nil {
+		t.Fatal("session wasn't interrupted")
+	}
+	processed, err := run("")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !slices.Equal(processed, setURLs[2:]) {
+		t.Errorf("resumed session processed %q, want only %q", processed, setURLs[2])
+	}
+
+	cp, err = loadCheckpoint(filename)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !slices.Equal(cp.Uploaded, setURLs) || len(cp.remaining()) != 0 || cp.AccessToken != "token" {
+		t.Errorf("final checkpoint is %+v", cp)
+	}
+	// The checkpoint holds the session's access token.
+	info, err := os.Stat(filename)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if mode := info.Mode().Perm(); mode != 0600 {
+		t.Errorf("checkpoint has mode %v, want 0600", mode)
+	}
+}
+
+func TestLoadCheckpoint(t *testing.T) {
+	dir := t.TempDir()
+	if cp, err := loadCheckpoint(filepath.Join(dir, "missing.json")); cp != nil || err != nil {
+		t.Errorf("got %v, %v for a missing checkpoint, want neither", cp, err)
+	}
+
+	for _, tc := range []struct {
+		name, contents, want string
+	}{
+		{"not JSON", "{", "failed to parse"},
+		{"no session", `{"vectorSetUrls": []}`, "has no session"},
+		{"unknown vector set", `{"sessionUrl": "acvp/v1/testSessions/1", "vectorSetUrls": ["acvp/v1/testSessions/1/vectorSets/10"], "uploaded": ["acvp/v1/testSessions/2/vectorSets/20"]}`, "isn't in session"},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			filename := filepath.Join(dir, "checkpoint.json")
+			if err := os.WriteFile(filename, []byte(tc.contents), 0600); err != nil {
+				t.Fatal(err)
+			}
+			if _, err := loadCheckpoint(filename); err == nil || !strings.Contains(err.Error(), tc.want) {
+				t.Errorf("got error %v, want one containing %q", err, tc.want)
+			}
+		})
+	}
+}
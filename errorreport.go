@@ -0,0 +1,93 @@
+// Copyright (c) 2026, Google Inc.
+//
+// Permission to use, copy, modify, and/or distribute this software for any
+// purpose with or without fee is hereby granted, provided that the above
+// copyright notice and this permission notice appear in all copies.
+//
+// THE SOFTWARE IS PROVIDED "AS IS" AND THE AUTHOR DISCLAIMS ALL WARRANTIES
+// WITH REGARD TO THIS SOFTWARE INCLUDING ALL IMPLIED WARRANTIES OF
+// MERCHANTABILITY AND FITNESS. IN NO EVENT SHALL THE AUTHOR BE LIABLE FOR ANY
+// SPECIAL, DIRECT, INDIRECT, OR CONSEQUENTIAL DAMAGES OR ANY DAMAGES
+// WHATSOEVER RESULTING FROM LOSS OF USE, DATA OR PROFITS, WHETHER IN AN ACTION
+// OF CONTRACT, NEGLIGENCE OR OTHER TORTIOUS ACTION, ARISING OUT OF OR IN
+// CONNECTION WITH THE USE OR PERFORMANCE OF THIS SOFTWARE.
+
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+
+	"github.com/cpu/acvptool/subprocess"
+)
+
+// phaseProcess is the phase of an errorReport for an error that can't be
+// attributed to a test case, such as from a handler that doesn't say which
+// test case failed, or a problem with the vector set as a whole.
+const phaseProcess = "process"
+
+// vectorSetError is an error from processing a vector set of an input file.
+type vectorSetError struct {
+	// index is the position of the vector set in the file, from one.
+	index     int
+	algorithm string
+	err       error
+}
+
+func (e *vectorSetError) Error() string {
+	return fmt.Sprintf("while processing vector set #%d: %s", e.index, e.err)
+}
+
+func (e *vectorSetError) Unwrap() error {
+	return e.err
+}
+
+// errorReport is the JSON form of an error, which -json-errors writes to
+// stderr so that CI systems can parse failures. The IDs are omitted if the
+// error can't be attributed to a test case.
+type errorReport struct {
+	Algorithm string `json:"algorithm,omitempty"`
+	GroupID   uint64 `json:"tgId,omitempty"`
+	TestID    uint64 `json:"tcId,omitempty"`
+	// Phase is one of the subprocess.Phase constants, or phaseProcess.
+	Phase   string `json:"phase"`
+	Message string `json:"message"`
+}
+
+// newErrorReport returns the report of err, taking the algorithm and IDs from
+// the subprocess.TestCaseError or vectorSetError that it wraps, if any.
+func newErrorReport(err error) *errorReport {
+	report := &errorReport{Phase: phaseProcess, Message: err.Error()}
+
+	var vectorSet *vectorSetError
+	if errors.As(err, &vectorSet) {
+		report.Algorithm = vectorSet.algorithm
+		report.Message = vectorSet.err.Error()
+	}
+	if errors.Is(err, subprocess.ErrModuleCrashed) {
+		report.Phase = subprocess.PhaseModule
+	}
+
+	var testCase *subprocess.TestCaseError
+	if errors.As(err, &testCase) {
+		report.Algorithm = testCase.Algorithm
+		report.GroupID = testCase.GroupID
+		report.TestID = testCase.TestID
+		report.Phase = testCase.Phase
+		report.Message = testCase.Err.Error()
+	}
+
+	return report
+}
+
+// writeErrorReport writes the report of err to w as a line of JSON.
+func writeErrorReport(w io.Writer, err error) error {
+	reportBytes, marshalErr := json.Marshal(newErrorReport(err))
+	if marshalErr != nil {
+		return marshalErr
+	}
+	_, writeErr := w.Write(append(reportBytes, '\n'))
+	return writeErr
+}
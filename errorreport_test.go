@@ -0,0 +1,71 @@
+// Copyright (c) 2026, Google Inc.
+//
+// Permission to use, copy, modify, and/or distribute this software for any
+// purpose with or without fee is hereby granted, provided that the above
+// copyright notice and this permission notice appear in all copies.
+//
+// THE SOFTWARE IS PROVIDED "AS IS" AND THE AUTHOR DISCLAIMS ALL WARRANTIES
+// WITH REGARD TO THIS SOFTWARE INCLUDING ALL IMPLIED WARRANTIES OF
+// MERCHANTABILITY AND FITNESS. IN NO EVENT SHALL THE AUTHOR BE LIABLE FOR ANY
+// SPECIAL, DIRECT, INDIRECT, OR CONSEQUENTIAL DAMAGES OR ANY DAMAGES
+// WHATSOEVER RESULTING FROM LOSS OF USE, DATA OR PROFITS, WHETHER IN AN ACTION
+// OF CONTRACT, NEGLIGENCE OR OTHER TORTIOUS ACTION, ARISING OUT OF OR IN
+// CONNECTION WITH THE USE OR PERFORMANCE OF THIS SOFTWARE.
+
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/cpu/acvptool/subprocess"
+)
+
+func TestErrorReport(t *testing.T) {
+	middle, supportedAlgos := startTestModuleWrapper(t)
+
+	// The second group has hex customizations but test case 2 has a string
+	// one.
+	input := filepath.Join(t.TempDir(), "input.json")
+	if err := os.WriteFile(input, []byte(`[{"vsId": 1, "algorithm": "cSHAKE-128", "testGroups": [
+		{"tgId": 1, "testType": "AFT", "tests": [{"tcId": 1, "len": 8, "msg": "00", "outLen": 64, "functionName": "", "customization": ""}]},
+		{"tgId": 2, "testType": "AFT", "hexCustomization": true, "tests": [{"tcId": 2, "len": 8, "msg": "00", "outLen": 64, "functionName": "", "customization": "cust"}]}
+	]}]`), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	var out bytes.Buffer
+	err := processFile(input, supportedAlgos, middle, &out)
+	if err == nil {
+		t.Fatal("processFile succeeded despite conflicting customizations")
+	}
+
+	var report bytes.Buffer
+	if err := writeErrorReport(&report, err); err != nil {
+		t.Fatal(err)
+	}
+	var got errorReport
+	if err := json.Unmarshal(report.Bytes(), &got); err != nil {
+		t.Fatalf("report is not valid JSON: %s\n%s", err, report.Bytes())
+	}
+	if got.Algorithm != "cSHAKE-128" || got.GroupID != 2 || got.TestID != 2 || got.Phase != subprocess.PhaseInput {
+		t.Errorf("got report %s", report.Bytes())
+	}
+	if !strings.HasPrefix(got.Message, "failed to decode customization: hex customization expected") {
+		t.Errorf("report has message %q", got.Message)
+	}
+
+	// Errors that don't name a test case only give what is known.
+	report.Reset()
+	if err := writeErrorReport(&report, &vectorSetError{1, "SHA2-256", errors.New("oops")}); err != nil {
+		t.Fatal(err)
+	}
+	if want := `{"algorithm":"SHA2-256","phase":"process","message":"oops"}` + "\n"; report.String() != want {
+		t.Errorf("got report %s, want %s", report.Bytes(), want)
+	}
+}
@@ -0,0 +1,192 @@
+// Copyright (c) 2026, Google Inc.
+//
+// Permission to use, copy, modify, and/or distribute this software for any
+// purpose with or without fee is hereby granted, provided that the above
+// copyright notice and this permission notice appear in all copies.
+//
+// THE SOFTWARE IS PROVIDED "AS IS" AND THE AUTHOR DISCLAIMS ALL WARRANTIES
+// WITH REGARD TO THIS SOFTWARE INCLUDING ALL IMPLIED WARRANTIES OF
+// MERCHANTABILITY AND FITNESS. IN NO EVENT SHALL THE AUTHOR BE LIABLE FOR ANY
+// SPECIAL, DIRECT, INDIRECT, OR CONSEQUENTIAL DAMAGES OR ANY DAMAGES
+// WHATSOEVER RESULTING FROM LOSS OF USE, DATA OR PROFITS, WHETHER IN AN ACTION
+// OF CONTRACT, NEGLIGENCE OR OTHER TORTIOUS ACTION, ARISING OUT OF OR IN
+// CONNECTION WITH THE USE OR PERFORMANCE OF THIS SOFTWARE.
+
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+)
+
+// checkExpected compares results, as written by processFile, against the
+// expected results for the same vector sets, as fetched with -expected-out.
+// Vector sets, test groups and test cases are matched by their IDs and every
+// field of the expected results must appear in the results with the same
+// value. Strings are compared case-insensitively because the ACVP server
+// writes hex in upper case. It returns the number of test cases checked, or
+// an error describing the first difference.
+func checkExpected(results, expected []byte) (int, error) {
+	resultSets, err := parseResultSets(results)
+	if err != nil {
+		return 0, fmt.Errorf("failed to parse results: %s", err)
+	}
+	expectedSets, err := parseResultSets(expected)
+	if err != nil {
+		return 0, fmt.Errorf("failed to parse expected results: %s", err)
+	}
+
+	var checked int
+	for _, s := range expectedSets {
+		expectedSet := s.(map[string]any)
+		vsID := expectedSet["vsId"]
+		resultSet := findByID(resultSets, "vsId", vsID)
+		if resultSet == nil {
+			return checked, fmt.Errorf("no results for vector set %v", vsID)
+		}
+
+		expectedGroups, _ := expectedSet["testGroups"].([]any)
+		resultGroups, _ := resultSet["testGroups"].([]any)
+		for _, g := range expectedGroups {
+			expectedGroup, ok := g.(map[string]any)
+			if !ok {
+				return checked, fmt.Errorf("vector set %v has a test group that isn't an object", vsID)
+			}
+			tgID := expectedGroup["tgId"]
+			resultGroup := findByID(resultGroups, "tgId", tgID)
+			if resultGroup == nil {
+				return checked, fmt.Errorf("vector set %v: no results for test group %v", vsID, tgID)
+			}
+
+			for _, key := range sortedKeys(expectedGroup) {
+				if key == "tgId" || key == "tests" {
+					continue
+				}
+				if diff := diffValues(key, resultGroup[key], expectedGroup[key]); len(diff) > 0 {
+					return checked, fmt.Errorf("vector set %v, test group %v: %s", vsID, tgID, diff)
+				}
+			}
+
+			expectedTests, _ := expectedGroup["tests"].([]any)
+			resultTests, _ := resultGroup["tests"].([]any)
+			for _, t := range expectedTests {
+				expectedTest, ok := t.(map[string]any)
+				if !ok {
+					return checked, fmt.Errorf("vector set %v: test group %v has a test case that isn't an object", vsID, tgID)
+				}
+				tcID := expectedTest["tcId"]
+				resultTest := findByID(resultTests, "tcId", tcID)
+				if resultTest == nil {
+					return checked, fmt.Errorf("vector set %v: no results for test case %v/%v", vsID, tgID, tcID)
+				}
+				if diff := diffValues("", resultTest, expectedTest); len(diff) > 0 {
+					return checked, fmt.Errorf("vector set %v, test case %v/%v: %s", vsID, tgID, tcID, diff)
+				}
+				checked++
+			}
+		}
+	}
+
+	return checked, nil
+}
+
+// parseResultSets parses a file of results, skipping any header.
+func parseResultSets(data []byte) ([]any, error) {
+	var elements []json.RawMessage
+	if err := json.Unmarshal(data, &elements); err != nil {
+		return nil, err
+	}
+
+	var ret []any
+	for _, element := range elements {
+		if looksLikeVectorSetHeader(element) {
+			continue
+		}
+		// Numbers are kept as strings so that large IDs are compared exactly.
+		decoder := json.NewDecoder(bytes.NewReader(element))
+		decoder.UseNumber()
+		var set map[string]any
+		if err := decoder.Decode(&set); err != nil {
+			return nil, err
+		}
+		ret = append(ret, set)
+	}
+	return ret, nil
+}
+
+// findByID returns the first object in objects whose idField has the value id.
+func findByID(objects []any, idField string, id any) map[string]any {
+	for _, o := range objects {
+		if object, ok := o.(map[string]any); ok && reflect.DeepEqual(object[idField], id) {
+			return object
+		}
+	}
+	return nil
+}
+
+func sortedKeys(m map[string]any) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// diffValues describes the first difference between got and want, which are
+// decoded JSON, or returns the empty string if every part of want is in got.
+// The description names the field at path.
+func diffValues(path string, got, want any) string {
+	if got == nil && want != nil {
+		return fmt.Sprintf("%s is missing", path)
+	}
+
+	switch want := want.(type) {
+	case map[string]any:
+		gotMap, ok := got.(map[string]any)
+		if !ok {
+			return fmt.Sprintf("%s is %v, expected an object", path, got)
+		}
+		for _, key := range sortedKeys(want) {
+			fieldPath := key
+			if len(path) > 0 {
+				fieldPath = path + "." + key
+			}
+			if diff := diffValues(fieldPath, gotMap[key], want[key]); len(diff) > 0 {
+				return diff
+			}
+		}
+		return ""
+
+	case []any:
+		gotSlice, ok := got.([]any)
+		if !ok {
+			return fmt.Sprintf("%s is %v, expected an array", path, got)
+		}
+		if len(gotSlice) != len(want) {
+			return fmt.Sprintf("%s has %d elements, expected %d", path, len(gotSlice), len(want))
+		}
+		for i := range want {
+			if diff := diffValues(fmt.Sprintf("%s[%d]", path, i), gotSlice[i], want[i]); len(diff) > 0 {
+				return diff
+			}
+		}
+		return ""
+
+	case string:
+		if gotString, ok := got.(string); ok && strings.EqualFold(gotString, want) {
+			return ""
+		}
+
+	default:
+		if reflect.DeepEqual(got, want) {
+			return ""
+		}
+	}
+
+	return fmt.Sprintf("%s is %v, expected %v", path, got, want)
+}
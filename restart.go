@@ -0,0 +1,51 @@
+// Copyright (c) 2026, Google Inc.
+//
+// Permission to use, copy, modify, and/or distribute this software for any
+// purpose with or without fee is hereby granted, provided that the above
+// copyright notice and this permission notice appear in all copies.
+//
+// THE SOFTWARE IS PROVIDED "AS IS" AND THE AUTHOR DISCLAIMS ALL WARRANTIES
+// WITH REGARD TO THIS SOFTWARE INCLUDING ALL IMPLIED WARRANTIES OF
+// MERCHANTABILITY AND FITNESS. IN NO EVENT SHALL THE AUTHOR BE LIABLE FOR ANY
+// SPECIAL, DIRECT, INDIRECT, OR CONSEQUENTIAL DAMAGES OR ANY DAMAGES
+// WHATSOEVER RESULTING FROM LOSS OF USE, DATA OR PROFITS, WHETHER IN AN ACTION
+// OF CONTRACT, NEGLIGENCE OR OTHER TORTIOUS ACTION, ARISING OUT OF OR IN
+// CONNECTION WITH THE USE OR PERFORMANCE OF THIS SOFTWARE.
+
+package main
+
+import (
+	"errors"
+	"fmt"
+	"log"
+
+	"github.com/cpu/acvptool/subprocess"
+)
+
+// restartingMiddle is a Middle that, if the wrapper crashes while a vector set
+// is being processed, starts a new Middle and processes the vector set again
+// from the start. It does so at most restarts times in total.
+type restartingMiddle struct {
+	Middle
+	// start returns a new Middle, configured in the same way as the first.
+	start    func() (Middle, error)
+	restarts int
+}
+
+func (r *restartingMiddle) Process(algorithm string, vectorSet []byte) (any, error) {
+	for {
+		result, err := r.Middle.Process(algorithm, vectorSet)
+		if err == nil || r.restarts == 0 || !errors.Is(err, subprocess.ErrModuleCrashed) {
+			return result, err
+		}
+		r.restarts--
+		log.Printf("%s; restarting the wrapper", err)
+
+		middle, startErr := r.start()
+		if startErr != nil {
+			return nil, fmt.Errorf("%w, and restarting it failed: %s", err, startErr)
+		}
+		r.Middle.Close()
+		r.Middle = middle
+	}
+}
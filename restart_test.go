@@ -0,0 +1,86 @@
+// Copyright (c) 2026, Google Inc.
+//
+// Permission to use, copy, modify, and/or distribute this software for any
+// purpose with or without fee is hereby granted, provided that the above
+// copyright notice and this permission notice appear in all copies.
+//
+// THE SOFTWARE IS PROVIDED "AS IS" AND THE AUTHOR DISCLAIMS ALL WARRANTIES
+// WITH REGARD TO THIS SOFTWARE INCLUDING ALL IMPLIED WARRANTIES OF
+// MERCHANTABILITY AND FITNESS. IN NO EVENT SHALL THE AUTHOR BE LIABLE FOR ANY
+// SPECIAL, DIRECT, INDIRECT, OR CONSEQUENTIAL DAMAGES OR ANY DAMAGES
+// WHATSOEVER RESULTING FROM LOSS OF USE, DATA OR PROFITS, WHETHER IN AN ACTION
+// OF CONTRACT, NEGLIGENCE OR OTHER TORTIOUS ACTION, ARISING OUT OF OR IN
+// CONNECTION WITH THE USE OR PERFORMANCE OF THIS SOFTWARE.
+
+package main
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/cpu/acvptool/subprocess"
+)
+
+// crashingMiddle fails its first crashes calls to Process as though the
+// wrapper had crashed.
+type crashingMiddle struct {
+	crashes int
+	closed  bool
+}
+
+func (c *crashingMiddle) Close()                  { c.closed = true }
+func (c *crashingMiddle) Config() ([]byte, error) { return []byte("[]"), nil }
+
+func (c *crashingMiddle) Process(algorithm string, vectorSet []byte) (any, error) {
+	if c.crashes > 0 {
+		c.crashes--
+		return nil, fmt.Errorf("%s: %w", algorithm, subprocess.ErrModuleCrashed)
+	}
+	return "ok", nil
+}
+
+func TestRestartingMiddle(t *testing.T) {
+	first := &crashingMiddle{crashes: 1}
+	var started []*crashingMiddle
+	r := &restartingMiddle{first, func() (Middle, error) {
+		// The first restart crashes again, but the second works.
+		m := &crashingMiddle{crashes: 1 - len(started)}
+		started = append(started, m)
+		return m, nil
+	}, 2}
+
+	result, err := r.Process("SHA2-256", nil)
+	if err != nil || result != "ok" {
+		t.Fatalf("got %v, %v after restarts", result, err)
+	}
+	if len(started) != 2 || !first.closed || !started[0].closed || started[1].closed {
+		t.Errorf("got %d restarts, want two that close the crashed wrappers", len(started))
+	}
+
+	// Each restart is counted against the limit, across vector sets.
+	started[1].crashes = 1
+	if _, err := r.Process("SHA2-256", nil); !errors.Is(err, subprocess.ErrModuleCrashed) {
+		t.Errorf("got error %v once out of restarts, want a crash", err)
+	}
+	if len(started) != 2 {
+		t.Errorf("restarted %d times, want no more than 2", len(started))
+	}
+}
+
+func TestRestartingMiddleOtherErrors(t *testing.T) {
+	r := &restartingMiddle{&crashingMiddle{}, func() (Middle, error) {
+		t.Fatal("restarted after an error that isn't a crash")
+		return nil, nil
+	}, 1}
+	if _, err := r.Process("SHA2-256", []byte("x")); err != nil {
+		t.Fatal(err)
+	}
+
+	failing := &restartingMiddle{&crashingMiddle{crashes: 1}, func() (Middle, error) {
+		return nil, errors.New("no wrapper")
+	}, 1}
+	if _, err := failing.Process("SHA2-256", nil); !errors.Is(err, subprocess.ErrModuleCrashed) {
+		t.Errorf("got error %v when restarting failed, want the crash", err)
+	}
+}
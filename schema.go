@@ -0,0 +1,204 @@
+// Copyright (c) 2026, Google Inc.
+//
+// Permission to use, copy, modify, and/or distribute this software for any
+// purpose with or without fee is hereby granted, provided that the above
+// copyright notice and this permission notice appear in all copies.
+//
+// THE SOFTWARE IS PROVIDED "AS IS" AND THE AUTHOR DISCLAIMS ALL WARRANTIES
+// WITH REGARD TO THIS SOFTWARE INCLUDING ALL IMPLIED WARRANTIES OF
+// MERCHANTABILITY AND FITNESS. IN NO EVENT SHALL THE AUTHOR BE LIABLE FOR ANY
+// SPECIAL, DIRECT, INDIRECT, OR CONSEQUENTIAL DAMAGES OR ANY DAMAGES
+// WHATSOEVER RESULTING FROM LOSS OF USE, DATA OR PROFITS, WHETHER IN AN ACTION
+// OF CONTRACT, NEGLIGENCE OR OTHER TORTIOUS ACTION, ARISING OUT OF OR IN
+// CONNECTION WITH THE USE OR PERFORMANCE OF THIS SOFTWARE.
+
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"slices"
+	"strings"
+)
+
+// testCaseSchema lists the fields of the result of a test case, other than
+// tcId, which every result has.
+type testCaseSchema struct {
+	required []string
+	optional []string
+}
+
+// resultSchema maps the test types of an algorithm to the schema of their
+// test case results.
+type resultSchema map[string]testCaseSchema
+
+var (
+	hashResultSchema = resultSchema{
+		"AFT": {required: []string{"md"}},
+		"MCT": {required: []string{"resultsArray"}},
+	}
+	shakeResultSchema = resultSchema{
+		"AFT": {required: []string{"md"}},
+		"VOT": {required: []string{"md"}},
+		"MCT": {required: []string{"resultsArray"}},
+	}
+	cShakeResultSchema = resultSchema{
+		"AFT": {required: []string{"md", "outLen"}},
+		"MCT": {required: []string{"resultsArray"}},
+	}
+	kmacResultSchema = resultSchema{
+		"AFT": {required: []string{"mac"}},
+		"MVT": {required: []string{"testPassed"}},
+		"MCT": {required: []string{"resultsArray"}},
+	}
+)
+
+// resultSchemas gives the schemas of the algorithms whose results are checked
+// before they are uploaded. Results for other algorithms aren't checked.
+var resultSchemas = map[string]resultSchema{
+	"SHA-1":        hashResultSchema,
+	"SHA2-224":     hashResultSchema,
+	"SHA2-256":     hashResultSchema,
+	"SHA2-384":     hashResultSchema,
+	"SHA2-512":     hashResultSchema,
+	"SHA2-512/224": hashResultSchema,
+	"SHA2-512/256": hashResultSchema,
+	"SHA3-224":     hashResultSchema,
+	"SHA3-256":     hashResultSchema,
+	"SHA3-384":     hashResultSchema,
+	"SHA3-512":     hashResultSchema,
+	"SHAKE-128":    shakeResultSchema,
+	"SHAKE-256":    shakeResultSchema,
+	"cSHAKE-128":   cShakeResultSchema,
+	"cSHAKE-256":   cShakeResultSchema,
+	"KMAC-128":     kmacResultSchema,
+	"KMAC-256":     kmacResultSchema,
+}
+
+// missingAndStray returns the required fields that test lacks and the fields
+// that it has but s doesn't allow.
+func (s testCaseSchema) missingAndStray(test map[string]json.RawMessage) (missing, stray []string) {
+	for _, field := range s.required {
+		if _, ok := test[field]; !ok {
+			missing = append(missing, field)
+		}
+	}
+	for field := range test {
+		if field != "tcId" && !slices.Contains(s.required, field) && !slices.Contains(s.optional, field) {
+			stray = append(stray, field)
+		}
+	}
+	slices.Sort(stray)
+	return missing, stray
+}
+
+// checkResultSchema checks that result, the results of a vector set as they
+// are uploaded, has the fields that the schema of its algorithm requires and
+// no others, to catch handler bugs before the ACVP server sees them. The test
+// type of each group is taken from vectorSet, the vector set that was
+// processed. If vectorSet is nil, a test case only has to match the schema of
+// one of the test types of the algorithm.
+func checkResultSchema(vectorSet, result []byte) error {
+	var parsed struct {
+		Algorithm string `json:"algorithm"`
+		Groups    []struct {
+			ID    uint64                       `json:"tgId"`
+			Tests []map[string]json.RawMessage `json:"tests"`
+		} `json:"testGroups"`
+	}
+	if err := json.Unmarshal(result, &parsed); err != nil {
+		return fmt.Errorf("failed to parse results: %s", err)
+	}
+	schema, ok := resultSchemas[parsed.Algorithm]
+	if !ok {
+		return nil
+	}
+
+	testTypes := make(map[uint64]string)
+	if vectorSet != nil {
+		var input struct {
+			Groups []struct {
+				ID   uint64 `json:"tgId"`
+				Type string `json:"testType"`
+			} `json:"testGroups"`
+		}
+		if err := json.Unmarshal(vectorSet, &input); err != nil {
+			return fmt.Errorf("failed to parse vector set: %s", err)
+		}
+		for _, group := range input.Groups {
+			testTypes[group.ID] = group.Type
+		}
+	}
+
+	for _, group := range parsed.Groups {
+		for _, test := range group.Tests {
+			var id uint64
+			if err := json.Unmarshal(test["tcId"], &id); err != nil {
+				return fmt.Errorf("%s test group %d has a test case without a valid tcId", parsed.Algorithm, group.ID)
+			}
+
+			var missing, stray []string
+			if vectorSet != nil {
+				testType, ok := testTypes[group.ID]
+				if !ok {
+					return fmt.Errorf("%s test group %d isn't in the vector set", parsed.Algorithm, group.ID)
+				}
+				testSchema, ok := schema[testType]
+				if !ok {
+					// This test type isn't described, so it isn't checked.
+					continue
+				}
+				missing, stray = testSchema.missingAndStray(test)
+			} else {
+				missing, stray = closestMatch(schema, test)
+			}
+
+			if len(missing) > 0 {
+				return fmt.Errorf("%s test case %d/%d is missing %s", parsed.Algorithm, group.ID, id, quoteFields(missing))
+			}
+			if len(stray) > 0 {
+				return fmt.Errorf("%s test case %d/%d has unexpected %s", parsed.Algorithm, group.ID, id, quoteFields(stray))
+			}
+		}
+	}
+
+	return nil
+}
+
+// closestMatch returns the problems of test against whichever test type of
+// schema it matches best: with the fewest missing fields, and then the fewest
+// stray ones. Test types are tried in sorted order so that the result is
+// deterministic.
+func closestMatch(schema resultSchema, test map[string]json.RawMessage) (missing, stray []string) {
+	first := true
+	for _, testType := range sortedTestTypes(schema) {
+		m, s := schema[testType].missingAndStray(test)
+		if first || len(m) < len(missing) || len(m) == len(missing) && len(s) < len(stray) {
+			missing, stray = m, s
+			first = false
+		}
+	}
+	return missing, stray
+}
+
+func sortedTestTypes(schema resultSchema) []string {
+	var ret []string
+	for testType := range schema {
+		ret = append(ret, testType)
+	}
+	slices.Sort(ret)
+	return ret
+}
+
+// quoteFields formats field names for an error, such as `field "md"` or
+// `fields "md", "outLen"`.
+func quoteFields(fields []string) string {
+	quoted := make([]string, len(fields))
+	for i, field := range fields {
+		quoted[i] = fmt.Sprintf("%q", field)
+	}
+	if len(fields) == 1 {
+		return "field " + quoted[0]
+	}
+	return "fields " + strings.Join(quoted, ", ")
+}
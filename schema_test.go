@@ -0,0 +1,92 @@
+// Copyright (c) 2026, Google Inc.
+//
+// Permission to use, copy, modify, and/or distribute this software for any
+// purpose with or without fee is hereby granted, provided that the above
+// copyright notice and this permission notice appear in all copies.
+//
+// THE SOFTWARE IS PROVIDED "AS IS" AND THE AUTHOR DISCLAIMS ALL WARRANTIES
+// WITH REGARD TO THIS SOFTWARE INCLUDING ALL IMPLIED WARRANTIES OF
+// MERCHANTABILITY AND FITNESS. IN NO EVENT SHALL THE AUTHOR BE LIABLE FOR ANY
+// SPECIAL, DIRECT, INDIRECT, OR CONSEQUENTIAL DAMAGES OR ANY DAMAGES
+// WHATSOEVER RESULTING FROM LOSS OF USE, DATA OR PROFITS, WHETHER IN AN ACTION
+// OF CONTRACT, NEGLIGENCE OR OTHER TORTIOUS ACTION, ARISING OUT OF OR IN
+// CONNECTION WITH THE USE OR PERFORMANCE OF THIS SOFTWARE.
+
+package main
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestCheckResultSchema(t *testing.T) {
+	// The golden results match the schema of their vector set.
+	_, vectorSets, err := readVectorSets(filepath.Join("testdata", "cSHAKE-128.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	goldenBytes, err := os.ReadFile(filepath.Join("testdata", "cSHAKE-128.response.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var golden []json.RawMessage
+	if err := json.Unmarshal(goldenBytes, &golden); err != nil {
+		t.Fatal(err)
+	}
+	if err := checkResultSchema(vectorSets[0], golden[1]); err != nil {
+		t.Errorf("golden results were rejected: %s", err)
+	}
+	if err := checkResultSchema(nil, golden[1]); err != nil {
+		t.Errorf("golden results were rejected without their vector set: %s", err)
+	}
+
+	const vectorSet = `{"algorithm": "cSHAKE-128", "testGroups": [
+		{"tgId": 1, "testType": "AFT", "tests": [{"tcId": 1}]},
+		{"tgId": 2, "testType": "MCT", "tests": [{"tcId": 2}]}
+	]}`
+	for _, test := range []struct {
+		name, groups, want string
+	}{
+		{
+			"AFT without outLen",
+			`[{"tgId": 1, "tests": [{"tcId": 1, "md": "aa"}]}]`,
+			`cSHAKE-128 test case 1/1 is missing field "outLen"`,
+		},
+		{
+			"MCT without resultsArray",
+			`[{"tgId": 2, "tests": [{"tcId": 2, "md": "aa", "outLen": 8}]}]`,
+			`cSHAKE-128 test case 2/2 is missing field "resultsArray"`,
+		},
+		{
+			"stray field",
+			`[{"tgId": 1, "tests": [{"tcId": 1, "md": "aa", "outLen": 8, "testPassed": true}]}]`,
+			`cSHAKE-128 test case 1/1 has unexpected field "testPassed"`,
+		},
+		{
+			"unknown group",
+			`[{"tgId": 3, "tests": [{"tcId": 3, "md": "aa", "outLen": 8}]}]`,
+			"cSHAKE-128 test group 3 isn't in the vector set",
+		},
+	} {
+		result := `{"vsId": 1, "algorithm": "cSHAKE-128", "testGroups": ` + test.groups + `}`
+		err := checkResultSchema([]byte(vectorSet), []byte(result))
+		if err == nil || !strings.Contains(err.Error(), test.want) {
+			t.Errorf("%s: got %v, want an error containing %q", test.name, err, test.want)
+		}
+	}
+
+	// Without the vector set, a result is compared with the closest test type.
+	result := `{"vsId": 1, "algorithm": "cSHAKE-128", "testGroups": [{"tgId": 1, "tests": [{"tcId": 1, "md": "aa"}]}]}`
+	if err := checkResultSchema(nil, []byte(result)); err == nil || !strings.Contains(err.Error(), `missing field "outLen"`) {
+		t.Errorf("got %v for an AFT result without outLen", err)
+	}
+
+	// Algorithms without a schema aren't checked.
+	result = `{"vsId": 1, "algorithm": "ACVP-AES-ECB", "testGroups": [{"tgId": 1, "tests": [{"tcId": 1, "anything": 1}]}]}`
+	if err := checkResultSchema(nil, []byte(result)); err != nil {
+		t.Errorf("results of an algorithm without a schema were rejected: %s", err)
+	}
+}
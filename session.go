@@ -0,0 +1,101 @@
+// Copyright (c) 2026, Google Inc.
+//
+// Permission to use, copy, modify, and/or distribute this software for any
+// purpose with or without fee is hereby granted, provided that the above
+// copyright notice and this permission notice appear in all copies.
+//
+// THE SOFTWARE IS PROVIDED "AS IS" AND THE AUTHOR DISCLAIMS ALL WARRANTIES
+// WITH REGARD TO THIS SOFTWARE INCLUDING ALL IMPLIED WARRANTIES OF
+// MERCHANTABILITY AND FITNESS. IN NO EVENT SHALL THE AUTHOR BE LIABLE FOR ANY
+// SPECIAL, DIRECT, INDIRECT, OR CONSEQUENTIAL DAMAGES OR ANY DAMAGES
+// WHATSOEVER RESULTING FROM LOSS OF USE, DATA OR PROFITS, WHETHER IN AN ACTION
+// OF CONTRACT, NEGLIGENCE OR OTHER TORTIOUS ACTION, ARISING OUT OF OR IN
+// CONNECTION WITH THE USE OR PERFORMANCE OF THIS SOFTWARE.
+
+package main
+
+import (
+	"errors"
+	"sync"
+)
+
+// processVectorSets processes the n vector sets of a session, each with one
+// of middles, so that as many vector sets are processed at once as there are
+// middles. process is called for vector set i with the middle to use, and
+// returns its results. upload is given the results of each vector set in
+// order, from a single goroutine, as soon as they and those of the earlier
+// vector sets are ready. Once either fails, no more vector sets are started
+// and the first error is returned after the ones in progress finish.
+func processVectorSets(n int, middles []Middle, process func(i int, middle Middle) ([]byte, error), upload func(i int, result []byte) error) error {
+	type outcome struct {
+		result []byte
+		err    error
+	}
+	outcomes := make([]chan outcome, n)
+	for i := range outcomes {
+		outcomes[i] = make(chan outcome, 1)
+	}
+
+	// halt records the first error and stops any more vector sets from
+	// being started.
+	var firstErr error
+	var haltOnce sync.Once
+	stop := make(chan struct{})
+	halt := func(err error) {
+		haltOnce.Do(func() {
+			firstErr = err
+			close(stop)
+		})
+	}
+
+	next := make(chan int)
+	go func() {
+		defer close(next)
+		for i := 0; i < n; i++ {
+			select {
+			case next <- i:
+			case <-stop:
+				return
+			}
+		}
+	}()
+
+	var wg sync.WaitGroup
+	for _, middle := range middles {
+		wg.Add(1)
+		go func(middle Middle) {
+			defer wg.Done()
+			for i := range next {
+				select {
+				case <-stop:
+					outcomes[i] <- outcome{nil, errVectorSetSkipped}
+					continue
+				default:
+				}
+				result, err := process(i, middle)
+				if err != nil {
+					halt(err)
+				}
+				outcomes[i] <- outcome{result, err}
+			}
+		}(middle)
+	}
+
+	for i := 0; i < n; i++ {
+		o := <-outcomes[i]
+		err := o.err
+		if err == nil {
+			err = upload(i, o.result)
+		}
+		if err != nil {
+			halt(err)
+			break
+		}
+	}
+	wg.Wait()
+	return firstErr
+}
+
+// errVectorSetSkipped is the outcome of a vector set that wasn't started
+// because an earlier one had failed.
+var errVectorSetSkipped = errors.New("vector set skipped after an earlier failure")
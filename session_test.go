@@ -0,0 +1,109 @@
+// Copyright (c) 2026, Google Inc.
+//
+// Permission to use, copy, modify, and/or distribute this software for any
+// purpose with or without fee is hereby granted, provided that the above
+// copyright notice and this permission notice appear in all copies.
+//
+// THE SOFTWARE IS PROVIDED "AS IS" AND THE AUTHOR DISCLAIMS ALL WARRANTIES
+// WITH REGARD TO THIS SOFTWARE INCLUDING ALL IMPLIED WARRANTIES OF
+// MERCHANTABILITY AND FITNESS. IN NO EVENT SHALL THE AUTHOR BE LIABLE FOR ANY
+// SPECIAL, DIRECT, INDIRECT, OR CONSEQUENTIAL DAMAGES OR ANY DAMAGES
+// WHATSOEVER RESULTING FROM LOSS OF USE, DATA OR PROFITS, WHETHER IN AN ACTION
+// OF CONTRACT, NEGLIGENCE OR OTHER TORTIOUS ACTION, ARISING OUT OF OR IN
+// CONNECTION WITH THE USE OR PERFORMANCE OF THIS SOFTWARE.
+
+package main
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// sessionMiddle answers each vector set with its name, and fails if it is
+// given a second vector set before the first is finished.
+type sessionMiddle struct {
+	busy      atomic.Bool
+	processed atomic.Int32
+}
+
+func (s *sessionMiddle) Close()                  {}
+func (s *sessionMiddle) Config() ([]byte, error) { return []byte("[]"), nil }
+
+func (s *sessionMiddle) Process(algorithm string, vectorSet []byte) (any, error) {
+	if !s.busy.CompareAndSwap(false, true) {
+		return nil, errors.New("middle used by two vector sets at once")
+	}
+	defer s.busy.Store(false)
+	s.processed.Add(1)
+	return algorithm, nil
+}
+
+func TestProcessVectorSets(t *testing.T) {
+	middles := []*sessionMiddle{new(sessionMiddle), new(sessionMiddle)}
+	// The first vector set only finishes once the second has, so the two
+	// must be processed at once, and their results are ready out of order.
+	secondDone := make(chan struct{})
+	process := func(i int, middle Middle) ([]byte, error) {
+		if i == 0 {
+			select {
+			case <-secondDone:
+			case <-time.After(10 * time.Second):
+				return nil, errors.New("second vector set wasn't processed alongside the first")
+			}
+		}
+		result, err := middle.Process(fmt.Sprintf("set %d", i), nil)
+		if i == 1 {
+			close(secondDone)
+		}
+		if err != nil {
+			return nil, err
+		}
+		return []byte(result.(string)), nil
+	}
+
+	var uploaded []string
+	err := processVectorSets(3, []Middle{middles[0], middles[1]}, process, func(i int, result []byte) error {
+		if want := fmt.Sprintf("set %d", i); string(result) != want {
+			return fmt.Errorf("vector set %d has result %q, want %q", i, result, want)
+		}
+		uploaded = append(uploaded, string(result))
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := []string{"set 0", "set 1", "set 2"}; !slices.Equal(uploaded, want) {
+		t.Errorf("uploaded %q, want %q", uploaded, want)
+	}
+	if n0, n1 := middles[0].processed.Load(), middles[1].processed.Load(); n0+n1 != 3 || n0 == 0 || n1 == 0 {
+		t.Errorf("middles processed %d and %d vector sets, want three between both", n0, n1)
+	}
+}
+
+func TestProcessVectorSetsError(t *testing.T) {
+	var processed atomic.Int32
+	var uploaded []int
+	err := processVectorSets(3, []Middle{new(sessionMiddle)}, func(i int, middle Middle) ([]byte, error) {
+		processed.Add(1)
+		if i == 1 {
+			return nil, errors.New("failed")
+		}
+		return nil, nil
+	}, func(i int, result []byte) error {
+		uploaded = append(uploaded, i)
+		return nil
+	})
+	if err == nil || err.Error() != "failed" {
+		t.Errorf("got error %v, want the failure of the second vector set", err)
+	}
+	if !slices.Equal(uploaded, []int{0}) {
+		t.Errorf("uploaded %v, want only the first vector set", uploaded)
+	}
+	if n := processed.Load(); n > 2 {
+		t.Errorf("processed %d vector sets, want none after the failure", n)
+	}
+}
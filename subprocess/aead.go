@@ -18,6 +18,7 @@ import (
 	"encoding/hex"
 	"encoding/json"
 	"fmt"
+	"slices"
 )
 
 // aead implements an ACVP algorithm by making requests to the subprocess
@@ -25,6 +26,34 @@ import (
 type aead struct {
 	algo                    string
 	tagMergedWithCiphertext bool
+	// nonceBits, if non-zero, is the only nonce length that the algorithm
+	// supports.
+	nonceBits int
+	// authOnly is set for GMAC, which only authenticates the additional
+	// data. Tests have no payload, and the responses carry only the tag or
+	// the result of verifying it.
+	authOnly bool
+}
+
+// randNonceBits is the length of the nonces that the module generates in
+// groups whose nonces are internal. They are appended to the ciphertext and
+// tag when sealing, and given in the same way when opening.
+const randNonceBits = 96
+
+// aeadTagBits gives the tag lengths that each AEAD supports. Key wrapping has
+// no tag, so isn't listed.
+var aeadTagBits = map[string][]int{
+	// SP 800-38D, section 5.2.1.2. The 32- and 64-bit tags are only for the
+	// applications of appendix C, but are tested all the same. A short tag
+	// is the leftmost bits of the full one, so the module must be told the
+	// length to verify only those.
+	"AES-GCM": {32, 64, 96, 104, 112, 120, 128},
+	// RFC 8452, section 4.
+	"AES-GCM-SIV": {128},
+	// SP 800-38C, appendix A.1.
+	"AES-CCM": {32, 48, 64, 80, 96, 112, 128},
+	// RFC 8439, section 2.8.
+	"ChaCha20-Poly1305": {128},
 }
 
 type aeadVectorSet struct {
@@ -37,15 +66,27 @@ type aeadTestGroup struct {
 	Direction   string `json:"direction"`
 	KeyBits     int    `json:"keyLen"`
 	TagBits     int    `json:"tagLen"`
+	IVBits      int    `json:"ivLen"`
+	KWCipher    string `json:"kwCipher"`
 	NonceSource string `json:"ivGen"`
-	Tests       []struct {
-		ID            uint64 `json:"tcId"`
-		PlaintextHex  string `json:"pt"`
-		CiphertextHex string `json:"ct"`
-		IVHex         string `json:"iv"`
-		KeyHex        string `json:"key"`
-		AADHex        string `json:"aad"`
-		TagHex        string `json:"tag"`
+	// NonceMode is the construction of internally generated nonces, which
+	// is either "8.2.1" (deterministic) or "8.2.2" (random) from SP
+	// 800-38D, section 8.2.
+	NonceMode string `json:"ivGenMode"`
+	// LargeData groups give a pattern as the plaintext of each test, which
+	// is repeated to the payload length. See expandLargeData.
+	LargeData bool `json:"largeData"`
+	Tests     []struct {
+		ID            uint64  `json:"tcId"`
+		PayloadBits   *uint64 `json:"payloadLen"`
+		PlaintextHex  string  `json:"pt"`
+		CiphertextHex string  `json:"ct"`
+		IVHex         string  `json:"iv"`
+		KeyHex        string  `json:"key"`
+		// AADHex is nil when the test has no additional data, which some
+		// modules treat differently from empty additional data.
+		AADHex *string `json:"aad"`
+		TagHex string  `json:"tag"`
 	} `json:"tests"`
 }
 
@@ -99,10 +140,40 @@ func (a *aead) Process(vectorSet []byte, m Transactable) (any, error) {
 			return nil, fmt.Errorf("test group %d has unknown nonce source %q", group.ID, group.NonceSource)
 		}
 
+		// The module's randnonce commands generate random, 96-bit nonces,
+		// which is the construction of SP 800-38D, section 8.2.2. Older
+		// vector sets don't give the construction.
+		if randnonce {
+			if group.NonceMode != "8.2.2" && len(group.NonceMode) != 0 {
+				return nil, fmt.Errorf("test group %d has unsupported nonce construction %q", group.ID, group.NonceMode)
+			}
+			if group.IVBits != 0 && group.IVBits != randNonceBits {
+				return nil, fmt.Errorf("test group %d specifies a %d-bit nonce, but internally generated nonces are %d bits", group.ID, group.IVBits, randNonceBits)
+			}
+		} else if len(group.NonceMode) != 0 {
+			return nil, fmt.Errorf("test group %d has nonce construction %q, but the nonce is external", group.ID, group.NonceMode)
+		}
+
+		// Key wrapping may be specified with the inverse of the block cipher
+		// (i.e. AES decryption) as the wrapping function. See SP 800-38F,
+		// section 5.1.
+		var inverse bool
+		switch group.KWCipher {
+		case "inverse":
+			inverse = true
+		case "cipher", "":
+			inverse = false
+		default:
+			return nil, fmt.Errorf("test group %d has unknown key-wrap cipher %q", group.ID, group.KWCipher)
+		}
+
 		op := a.algo
 		if randnonce {
 			op += "-randnonce"
 		}
+		if inverse {
+			op += "-inverse"
+		}
 		if encrypt {
 			op += "/seal"
 		} else {
@@ -119,8 +190,32 @@ func (a *aead) Process(vectorSet []byte, m Transactable) (any, error) {
 		}
 		tagBytes := group.TagBits / 8
 
+		// A zero-length tag would leave the module to decide what opening
+		// means, so only the lengths that the algorithm defines are sent.
+		if tagBits, ok := aeadTagBits[a.algo]; ok && !slices.Contains(tagBits, group.TagBits) {
+			return nil, fmt.Errorf("test group %d has tag length %d, which is not valid for %s", group.ID, group.TagBits, a.algo)
+		}
+
+		if a.nonceBits != 0 && group.IVBits != 0 && group.IVBits != a.nonceBits {
+			return nil, fmt.Errorf("test group %d specifies a %d-bit nonce, but only %d-bit nonces are supported", group.ID, group.IVBits, a.nonceBits)
+		}
+
+		// Ciphertexts can't be described by a pattern, so only encryption
+		// is tested with large data.
+		if group.LargeData && !encrypt {
+			return nil, fmt.Errorf("test group %d is a large-data decryption group, which is not supported", group.ID)
+		}
+
+		if a.algo == "AES-CCM" {
+			// See SP 800-38C, appendix A.1.
+			if group.IVBits != 0 && (group.IVBits < 56 || group.IVBits > 104) {
+				return nil, fmt.Errorf("test group %d has nonce length %d, which is not valid for CCM", group.ID, group.IVBits)
+			}
+		}
+
 		for _, test := range group.Tests {
 			test := test
+			m := withTestCase(m, a.algo, group.ID, test.ID)
 
 			if len(test.KeyHex) != keyBytes*2 {
 				return nil, fmt.Errorf("test case %d/%d contains key %q of length %d, but expected %d-bit key", group.ID, test.ID, test.KeyHex, len(test.KeyHex), group.KeyBits)
@@ -135,10 +230,31 @@ func (a *aead) Process(vectorSet []byte, m Transactable) (any, error) {
 			if err != nil {
 				return nil, fmt.Errorf("failed to decode nonce in test case %d/%d: %s", group.ID, test.ID, err)
 			}
+			if a.nonceBits != 0 && len(nonce)*8 != a.nonceBits {
+				return nil, fmt.Errorf("test case %d/%d contains a %d-bit nonce, but only %d-bit nonces are supported", group.ID, test.ID, len(nonce)*8, a.nonceBits)
+			}
+			// When opening, the nonce that the module generated is given
+			// back to it.
+			if randnonce && encrypt && len(nonce) != 0 {
+				return nil, fmt.Errorf("test case %d/%d contains a nonce, but the module should generate it", group.ID, test.ID)
+			}
+			if randnonce && !encrypt && len(nonce)*8 != randNonceBits {
+				return nil, fmt.Errorf("test case %d/%d contains a %d-bit nonce, but internally generated nonces are %d bits", group.ID, test.ID, len(nonce)*8, randNonceBits)
+			}
+			if !randnonce && group.IVBits != 0 && len(nonce)*8 != group.IVBits {
+				return nil, fmt.Errorf("test case %d/%d contains a %d-bit nonce, but the group specifies %d bits", group.ID, test.ID, len(nonce)*8, group.IVBits)
+			}
 
-			aad, err := hex.DecodeString(test.AADHex)
-			if err != nil {
-				return nil, fmt.Errorf("failed to decode aad in test case %d/%d: %s", group.ID, test.ID, err)
+			// Both empty and absent additional data are sent as an empty,
+			// non-nil argument. Key wrapping has no additional data at all.
+			aad := []byte{}
+			if test.AADHex != nil {
+				if !a.hasAAD() {
+					return nil, fmt.Errorf("test case %d/%d has additional data, which %s doesn't use", group.ID, test.ID, a.algo)
+				}
+				if aad, err = hex.DecodeString(*test.AADHex); err != nil {
+					return nil, fmt.Errorf("failed to decode aad in test case %d/%d: %s", group.ID, test.ID, err)
+				}
 			}
 
 			var inputHex, otherHex string
@@ -156,6 +272,20 @@ func (a *aead) Process(vectorSet []byte, m Transactable) (any, error) {
 			if err != nil {
 				return nil, fmt.Errorf("failed to decode hex in test case %d/%d: %s", group.ID, test.ID, err)
 			}
+			// The module sees GMAC as GCM with an empty payload, so, when
+			// opening, the ciphertext that it is given is only the tag.
+			if a.authOnly && len(input) != 0 {
+				return nil, fmt.Errorf("GMAC test case %d/%d has a payload, but only additional data can be authenticated", group.ID, test.ID)
+			}
+
+			if group.LargeData {
+				if test.PayloadBits == nil {
+					return nil, fmt.Errorf("large-data test case %d/%d has no payload length", group.ID, test.ID)
+				}
+				if input, err = expandLargeData(input, *test.PayloadBits); err != nil {
+					return nil, fmt.Errorf("test case %d/%d: %s", group.ID, test.ID, err)
+				}
+			}
 
 			var tag []byte
 			if a.tagMergedWithCiphertext {
@@ -193,12 +323,21 @@ func (a *aead) Process(vectorSet []byte, m Transactable) (any, error) {
 						ciphertext := result[0]
 						if randnonce {
 							var nonce []byte
-							ciphertext, nonce = splitOffRight(ciphertext, 12)
+							if len(ciphertext) < tagBytes+randNonceBits/8 {
+								return fmt.Errorf("ciphertext from subprocess for test case %d/%d is too short to contain the tag and nonce", group.ID, test.ID)
+							}
+							ciphertext, nonce = splitOffRight(ciphertext, randNonceBits/8)
 							testResp.NonceHex = hex.EncodeToString(nonce)
 						}
 						ciphertext, tag := splitOffRight(ciphertext, tagBytes)
-						ciphertextHex := hex.EncodeToString(ciphertext)
-						testResp.CiphertextHex = &ciphertextHex
+						if a.authOnly {
+							if len(ciphertext) != 0 {
+								return fmt.Errorf("GMAC of test case %d/%d returned %d bytes of ciphertext as well as the tag", group.ID, test.ID, len(ciphertext))
+							}
+						} else {
+							ciphertextHex := hex.EncodeToString(ciphertext)
+							testResp.CiphertextHex = &ciphertextHex
+						}
 						testResp.TagHex = hex.EncodeToString(tag)
 					}
 					response.Tests = append(response.Tests, testResp)
@@ -216,7 +355,11 @@ func (a *aead) Process(vectorSet []byte, m Transactable) (any, error) {
 					}
 					passed := result[0][0] == 1
 					testResp.Passed = &passed
-					if passed {
+					if a.authOnly {
+						if len(result[1]) != 0 {
+							return fmt.Errorf("GMAC of test case %d/%d returned %d bytes of plaintext", group.ID, test.ID, len(result[1]))
+						}
+					} else if passed {
 						plaintextHex := hex.EncodeToString(result[1])
 						testResp.PlaintextHex = &plaintextHex
 					}
@@ -238,6 +381,11 @@ func (a *aead) Process(vectorSet []byte, m Transactable) (any, error) {
 	return ret, nil
 }
 
+// hasAAD returns whether the algorithm authenticates additional data.
+func (a *aead) hasAAD() bool {
+	return a.algo != "AES-KW" && a.algo != "AES-KWP"
+}
+
 func splitOffRight(in []byte, suffixSize int) ([]byte, []byte) {
 	if len(in) < suffixSize {
 		panic("input too small to split")
@@ -0,0 +1,482 @@
+// Copyright (c) 2026, Google Inc.
+//
+// Permission to use, copy, modify, and/or distribute this software for any
+// purpose with or without fee is hereby granted, provided that the above
+// copyright notice and this permission notice appear in all copies.
+//
+// THE SOFTWARE IS PROVIDED "AS IS" AND THE AUTHOR DISCLAIMS ALL WARRANTIES
+// WITH REGARD TO THIS SOFTWARE INCLUDING ALL IMPLIED WARRANTIES OF
+// MERCHANTABILITY AND FITNESS. IN NO EVENT SHALL THE AUTHOR BE LIABLE FOR ANY
+// SPECIAL, DIRECT, INDIRECT, OR CONSEQUENTIAL DAMAGES OR ANY DAMAGES
+// WHATSOEVER RESULTING FROM LOSS OF USE, DATA OR PROFITS, WHETHER IN AN ACTION
+// OF CONTRACT, NEGLIGENCE OR OTHER TORTIOUS ACTION, ARISING OUT OF OR IN
+// CONNECTION WITH THE USE OR PERFORMANCE OF THIS SOFTWARE.
+
+package subprocess
+
+import (
+	"bytes"
+	"crypto/aes"
+	"crypto/cipher"
+	"encoding/binary"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/cpu/acvptool/subprocess/internal/mockmodule"
+)
+
+// keyWrapResponder wraps by prepending an eight-byte integrity check value
+// and unwraps by removing it, failing if it's missing.
+func keyWrapResponder(cmd string, args [][]byte) ([][]byte, error) {
+	input := args[2]
+	switch cmd {
+	case "AES-KW/seal", "AES-KWP-inverse/seal":
+		return [][]byte{append(make([]byte, 8), input...)}, nil
+	case "AES-KW/open", "AES-KWP-inverse/open":
+		for _, b := range input[:8] {
+			if b != 0 {
+				return [][]byte{{0}, nil}, nil
+			}
+		}
+		return [][]byte{{1}, input[8:]}, nil
+	}
+	panic("unexpected command " + cmd)
+}
+
+func TestKeyWrap(t *testing.T) {
+	tests := []struct {
+		algo     string
+		kwCipher string
+		pt       string
+		validCT  string
+	}{
+		// KW operates on whole semiblocks.
+		{"AES-KW", "cipher", "00112233445566778899aabbccddeeff", "000000000000000000112233445566778899aabbccddeeff"},
+		// KWP accepts arbitrary lengths.
+		{"AES-KWP", "inverse", "0011223344", "00000000000000000011223344"},
+	}
+
+	for _, test := range tests {
+		vectorSet := `{"testGroups": [{
+			"tgId": 1, "testType": "AFT", "direction": "encrypt", "keyLen": 128, "kwCipher": "` + test.kwCipher + `",
+			"tests": [{"tcId": 1, "key": "000102030405060708090a0b0c0d0e0f", "pt": "` + test.pt + `"}]
+		}, {
+			"tgId": 2, "testType": "AFT", "direction": "decrypt", "keyLen": 128, "kwCipher": "` + test.kwCipher + `",
+			"tests": [
+				{"tcId": 2, "key": "000102030405060708090a0b0c0d0e0f", "ct": "` + test.validCT + `"},
+				{"tcId": 3, "key": "000102030405060708090a0b0c0d0e0f", "ct": "ff` + test.validCT[2:] + `"}
+			]
+		}]}`
+
+		a := &aead{test.algo, false, 0, false}
+		result, err := a.Process([]byte(vectorSet), &fakeTransactable{respond: keyWrapResponder})
+		if err != nil {
+			t.Fatalf("%s: %s", test.algo, err)
+		}
+
+		groups := result.([]aeadTestGroupResponse)
+		if len(groups) != 2 {
+			t.Fatalf("%s: got %d groups, want 2", test.algo, len(groups))
+		}
+		if ct := groups[0].Tests[0].CiphertextHex; ct == nil || *ct != test.validCT {
+			t.Errorf("%s: wrapped key is wrong", test.algo)
+		}
+
+		unwrapped := groups[1].Tests[0]
+		if unwrapped.Passed == nil || !*unwrapped.Passed || unwrapped.PlaintextHex == nil || *unwrapped.PlaintextHex != test.pt {
+			out, _ := json.Marshal(unwrapped)
+			t.Errorf("%s: valid unwrap gave %s", test.algo, out)
+		}
+		failed := groups[1].Tests[1]
+		if failed.Passed == nil || *failed.Passed || failed.PlaintextHex != nil {
+			out, _ := json.Marshal(failed)
+			t.Errorf("%s: invalid unwrap gave %s", test.algo, out)
+		}
+	}
+}
+
+// chachaResponder seals by appending a fixed tag and opens by checking it.
+func chachaResponder(cmd string, args [][]byte) ([][]byte, error) {
+	tag := bytes.Repeat([]byte{0x5a}, 16)
+	input := args[2]
+	switch cmd {
+	case "ChaCha20-Poly1305/seal":
+		return [][]byte{append(append([]byte{}, input...), tag...)}, nil
+	case "ChaCha20-Poly1305/open":
+		ciphertext, gotTag := splitOffRight(input, 16)
+		if !bytes.Equal(gotTag, tag) {
+			return [][]byte{{0}, nil}, nil
+		}
+		return [][]byte{{1}, ciphertext}, nil
+	}
+	panic("unexpected command " + cmd)
+}
+
+func TestChaCha20Poly1305(t *testing.T) {
+	key := strings.Repeat("00", 32)
+	nonce := strings.Repeat("00", 12)
+	tag := strings.Repeat("5a", 16)
+	badTag := "5b" + tag[2:]
+	vectorSet := `{"testGroups": [{
+		"tgId": 1, "testType": "AFT", "direction": "encrypt", "keyLen": 256, "ivLen": 96, "tagLen": 128,
+		"tests": [{"tcId": 1, "key": "` + key + `", "iv": "` + nonce + `", "aad": "", "pt": ""}]
+	}, {
+		"tgId": 2, "testType": "AFT", "direction": "decrypt", "keyLen": 256, "ivLen": 96, "tagLen": 128,
+		"tests": [
+			{"tcId": 2, "key": "` + key + `", "iv": "` + nonce + `", "aad": "", "ct": "", "tag": "` + tag + `"},
+			{"tcId": 3, "key": "` + key + `", "iv": "` + nonce + `", "aad": "00", "ct": "0011", "tag": "` + badTag + `"}
+		]
+	}]}`
+
+	a := &aead{"ChaCha20-Poly1305", false, 96, false}
+	result, err := a.Process([]byte(vectorSet), &fakeTransactable{respond: chachaResponder})
+	if err != nil {
+		t.Fatal(err)
+	}
+	groups := result.([]aeadTestGroupResponse)
+
+	sealed := groups[0].Tests[0]
+	if sealed.CiphertextHex == nil || *sealed.CiphertextHex != "" || sealed.TagHex != tag {
+		out, _ := json.Marshal(sealed)
+		t.Errorf("seal of empty plaintext gave %s", out)
+	}
+
+	opened := groups[1].Tests[0]
+	if opened.Passed == nil || !*opened.Passed || opened.PlaintextHex == nil || *opened.PlaintextHex != "" {
+		out, _ := json.Marshal(opened)
+		t.Errorf("open of empty ciphertext gave %s", out)
+	}
+
+	corrupted := groups[1].Tests[1]
+	if corrupted.Passed == nil || *corrupted.Passed || corrupted.PlaintextHex != nil {
+		out, _ := json.Marshal(corrupted)
+		t.Errorf("open with corrupted tag gave %s", out)
+	}
+
+	wrongNonce := strings.Replace(vectorSet, `"iv": "`+nonce, `"iv": "`+nonce+"00", 1)
+	if _, err := a.Process([]byte(wrongNonce), &fakeTransactable{respond: chachaResponder}); err == nil {
+		t.Error("104-bit nonce was accepted")
+	}
+}
+
+// ccmResponder seals by appending a tag of the requested length and opens by
+// checking that the tag is all zeros.
+func ccmResponder(cmd string, args [][]byte) ([][]byte, error) {
+	tagLen := int(binary.LittleEndian.Uint32(args[0]))
+	input := args[2]
+	switch cmd {
+	case "AES-CCM/seal":
+		return [][]byte{append(append([]byte{}, input...), make([]byte, tagLen)...)}, nil
+	case "AES-CCM/open":
+		plaintext, tag := splitOffRight(input, tagLen)
+		if !bytes.Equal(tag, make([]byte, tagLen)) {
+			return [][]byte{{0}, nil}, nil
+		}
+		return [][]byte{{1}, plaintext}, nil
+	}
+	panic("unexpected command " + cmd)
+}
+
+func TestCCMLengths(t *testing.T) {
+	key := strings.Repeat("00", 16)
+	for tagBits := 32; tagBits <= 128; tagBits += 16 {
+		for nonceBits := 56; nonceBits <= 104; nonceBits += 8 {
+			nonce := strings.Repeat("00", nonceBits/8)
+			tag := strings.Repeat("00", tagBits/8)
+			vectorSet := fmt.Sprintf(`{"testGroups": [{
+				"tgId": 1, "testType": "AFT", "direction": "encrypt", "keyLen": 128, "ivLen": %d, "tagLen": %d,
+				"tests": [{"tcId": 1, "key": "%s", "iv": "%s", "aad": "", "pt": "0011"}]
+			}, {
+				"tgId": 2, "testType": "AFT", "direction": "decrypt", "keyLen": 128, "ivLen": %[1]d, "tagLen": %[2]d,
+				"tests": [{"tcId": 2, "key": "%[3]s", "iv": "%[4]s", "aad": "", "ct": "0011%[5]s"},
+				          {"tcId": 3, "key": "%[3]s", "iv": "%[4]s", "aad": "", "ct": "0011ff%[6]s"}]
+			}]}`, nonceBits, tagBits, key, nonce, tag, tag[2:])
+
+			m := &fakeTransactable{respond: ccmResponder}
+			result, err := (&aead{"AES-CCM", true, 0, false}).Process([]byte(vectorSet), m)
+			if err != nil {
+				t.Fatalf("tag %d, nonce %d: %s", tagBits, nonceBits, err)
+			}
+
+			for _, call := range m.calls {
+				if !bytes.Equal(call.args[0], uint32le(uint32(tagBits/8))) || len(call.args[3]) != nonceBits/8 {
+					t.Errorf("tag %d, nonce %d: %s sent tag length %x and nonce %x", tagBits, nonceBits, call.cmd, call.args[0], call.args[3])
+				}
+			}
+
+			groups := result.([]aeadTestGroupResponse)
+			if ct := groups[0].Tests[0].CiphertextHex; ct == nil || *ct != "0011"+tag {
+				t.Errorf("tag %d, nonce %d: wrong ciphertext", tagBits, nonceBits)
+			}
+			if pt := groups[1].Tests[0].PlaintextHex; pt == nil || *pt != "0011" {
+				t.Errorf("tag %d, nonce %d: wrong plaintext", tagBits, nonceBits)
+			}
+			if failed := groups[1].Tests[1]; failed.Passed == nil || *failed.Passed || failed.PlaintextHex != nil {
+				t.Errorf("tag %d, nonce %d: corrupted tag was accepted", tagBits, nonceBits)
+			}
+		}
+	}
+
+	for _, lengths := range [][2]int{{40, 96}, {144, 96}, {128, 48}, {128, 112}} {
+		vectorSet := fmt.Sprintf(`{"testGroups": [{"tgId": 1, "testType": "AFT", "direction": "encrypt", "keyLen": 128, "tagLen": %d, "ivLen": %d, "tests": []}]}`, lengths[0], lengths[1])
+		if _, err := (&aead{"AES-CCM", true, 0, false}).Process([]byte(vectorSet), &fakeTransactable{respond: ccmResponder}); err == nil {
+			t.Errorf("tag length %d and nonce length %d were accepted", lengths[0], lengths[1])
+		}
+	}
+}
+
+// gcmResponder implements AES-GCM with crypto/cipher.
+func gcmResponder(cmd string, args [][]byte) ([][]byte, error) {
+	block, err := aes.NewCipher(args[1])
+	if err != nil {
+		return nil, err
+	}
+	gcm, err := cipher.NewGCMWithTagSize(block, int(binary.LittleEndian.Uint32(args[0])))
+	if err != nil {
+		return nil, err
+	}
+	switch cmd {
+	case "AES-GCM/seal":
+		return [][]byte{gcm.Seal(nil, args[3], args[2], args[4])}, nil
+	case "AES-GCM/open":
+		plaintext, err := gcm.Open(nil, args[3], args[2], args[4])
+		if err != nil {
+			return [][]byte{{0}, nil}, nil
+		}
+		return [][]byte{{1}, plaintext}, nil
+	default:
+		return nil, fmt.Errorf("unexpected command %q", cmd)
+	}
+}
+
+func TestGMAC(t *testing.T) {
+	const key = "000102030405060708090a0b0c0d0e0f"
+	const iv = "101112131415161718191a1b"
+	const aad = "feedfacedeadbeeffeedfacedeadbeefabaddad2"
+	block, err := aes.NewCipher(mustDecodeHex(t, key))
+	if err != nil {
+		t.Fatal(err)
+	}
+	gcm, err := cipher.NewGCM(block)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tag := hex.EncodeToString(gcm.Seal(nil, mustDecodeHex(t, iv), nil, mustDecodeHex(t, aad)))
+	badTag := "00" + tag[2:]
+
+	vectorSet := `{"testGroups": [{
+	"tgId": 1, "testType": "AFT", "direction": "encrypt", "keyLen": 128, "tagLen": 128, "ivLen": 96, "ivGen": "external",
+	"tests": [{"tcId": 1, "key": "` + key + `", "iv": "` + iv + `", "aad": "` + aad + `"}]
+}, {
+	"tgId": 2, "testType": "AFT", "direction": "decrypt", "keyLen": 128, "tagLen": 128, "ivLen": 96, "ivGen": "external",
+	"tests": [
+		{"tcId": 2, "key": "` + key + `", "iv": "` + iv + `", "aad": "` + aad + `", "tag": "` + tag + `"},
+		{"tcId": 3, "key": "` + key + `", "iv": "` + iv + `", "aad": "` + aad + `", "tag": "` + badTag + `"}
+	]
+}]}`
+
+	gmac := &aead{"AES-GCM", false, 0, true}
+	result, err := gmac.Process([]byte(vectorSet), &fakeTransactable{respond: gcmResponder})
+	if err != nil {
+		t.Fatal(err)
+	}
+	out, err := json.Marshal(result)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := `[{"tgId":1,"tests":[{"tcId":1,"tag":"` + tag + `"}]},{"tgId":2,"tests":[{"tcId":2,"testPassed":true},{"tcId":3,"testPassed":false}]}]`
+	if string(out) != want {
+		t.Errorf("got %s, want %s", out, want)
+	}
+
+	withPayload := strings.Replace(vectorSet, `"aad": "`+aad+`"}]`, `"aad": "`+aad+`", "pt": "00"}]`, 1)
+	if _, err := gmac.Process([]byte(withPayload), &fakeTransactable{respond: gcmResponder}); err == nil {
+		t.Error("GMAC test case with a payload was accepted")
+	}
+}
+
+func TestAEADAbsentAAD(t *testing.T) {
+	const key = "000102030405060708090a0b0c0d0e0f"
+	const iv = "101112131415161718191a1b"
+	vectorSet := `{"testGroups": [{
+	"tgId": 1, "testType": "AFT", "direction": "encrypt", "keyLen": 128, "tagLen": 128, "ivLen": 96, "ivGen": "external",
+	"tests": [
+		{"tcId": 1, "key": "` + key + `", "iv": "` + iv + `", "aad": ""},
+		{"tcId": 2, "key": "` + key + `", "iv": "` + iv + `"}
+	]
+}]}`
+
+	m := &fakeTransactable{respond: gcmResponder}
+	gmac := &aead{"AES-GCM", false, 0, true}
+	result, err := gmac.Process([]byte(vectorSet), m)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(m.calls) != 2 {
+		t.Fatalf("got %d calls, want 2", len(m.calls))
+	}
+	for i, call := range m.calls {
+		if args := call.args; len(args) != 5 || args[4] == nil || len(args[4]) != 0 {
+			t.Errorf("additional data of test case %d was sent as %q", i+1, args)
+		}
+	}
+	tests := result.([]aeadTestGroupResponse)[0].Tests
+	if tests[0].TagHex != tests[1].TagHex {
+		t.Errorf("got tags %s and %s for empty and absent additional data", tests[0].TagHex, tests[1].TagHex)
+	}
+
+	// Key wrapping has no additional data and rejects tests that give it.
+	vectorSet = `{"testGroups": [{
+	"tgId": 1, "testType": "AFT", "direction": "encrypt", "keyLen": 128,
+	"tests": [{"tcId": 1, "key": "` + key + `", "pt": "00112233445566778899aabbccddeeff"}]
+}]}`
+	m = &fakeTransactable{respond: keyWrapResponder}
+	if _, err := (&aead{"AES-KW", false, 0, false}).Process([]byte(vectorSet), m); err != nil {
+		t.Fatal(err)
+	}
+	if len(m.calls) != 1 || len(m.calls[0].args) != 5 {
+		t.Errorf("unexpected calls %v", m.calls)
+	}
+	withAAD := strings.Replace(vectorSet, `"pt"`, `"aad": "00", "pt"`, 1)
+	if _, err := (&aead{"AES-KW", false, 0, false}).Process([]byte(withAAD), &fakeTransactable{respond: keyWrapResponder}); err == nil {
+		t.Error("key wrap with additional data was accepted")
+	}
+}
+
+func TestGCMInternalNonce(t *testing.T) {
+	const key = "000102030405060708090a0b0c0d0e0f"
+	nonce := bytes.Repeat([]byte{0x42}, 12)
+	var sealedWith [][]byte
+	m := &fakeTransactable{respond: func(cmd string, args [][]byte) ([][]byte, error) {
+		if cmd != "AES-GCM-randnonce/seal" {
+			return nil, fmt.Errorf("unexpected command %q", cmd)
+		}
+		sealedWith = args
+		result, err := gcmResponder("AES-GCM/seal", [][]byte{args[0], args[1], args[2], nonce, args[4]})
+		if err != nil {
+			return nil, err
+		}
+		return [][]byte{append(result[0], nonce...)}, nil
+	}}
+
+	vectorSet := `{"testGroups": [{
+	"tgId": 1, "testType": "AFT", "direction": "encrypt", "keyLen": 128, "tagLen": 128, "ivLen": 96, "ivGen": "internal", "ivGenMode": "8.2.2",
+	"tests": [{"tcId": 1, "key": "` + key + `", "pt": "00112233", "aad": ""}]
+}]}`
+	gcm := &aead{"AES-GCM", false, 0, false}
+	result, err := gcm.Process([]byte(vectorSet), m)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(sealedWith[3]) != 0 {
+		t.Errorf("module was given nonce %x", sealedWith[3])
+	}
+
+	resp := result.([]aeadTestGroupResponse)[0].Tests[0]
+	if want := hex.EncodeToString(nonce); resp.NonceHex != want {
+		t.Errorf("response has nonce %q, want the module's nonce %s", resp.NonceHex, want)
+	}
+	sealed, err := gcmResponder("AES-GCM/seal", [][]byte{uint32le(16), mustDecodeHex(t, key), mustDecodeHex(t, "00112233"), nonce, nil})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resp.CiphertextHex == nil || *resp.CiphertextHex+resp.TagHex != hex.EncodeToString(sealed[0]) {
+		t.Errorf("response has ciphertext %v and tag %s, want %x", resp.CiphertextHex, resp.TagHex, sealed[0])
+	}
+
+	deterministic := strings.Replace(vectorSet, "8.2.2", "8.2.1", 1)
+	if _, err := gcm.Process([]byte(deterministic), m); err == nil {
+		t.Error("deterministic nonce construction was accepted")
+	}
+}
+
+func TestGCMShortTag(t *testing.T) {
+	const key = "000102030405060708090a0b0c0d0e0f"
+	const iv = "101112131415161718191a1b"
+	const pt = "00112233445566778899"
+	const aad = "feedface"
+	block, err := aes.NewCipher(mustDecodeHex(t, key))
+	if err != nil {
+		t.Fatal(err)
+	}
+	gcm, err := cipher.NewGCM(block)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// A 32-bit tag is the first four bytes of the full one.
+	sealed := gcm.Seal(nil, mustDecodeHex(t, iv), mustDecodeHex(t, pt), mustDecodeHex(t, aad))
+	ct := hex.EncodeToString(sealed[:len(pt)/2])
+	shortTag := sealed[len(pt)/2 : len(pt)/2+4]
+	tag := hex.EncodeToString(shortTag)
+	badTag := hex.EncodeToString(append([]byte{shortTag[0] ^ 1}, shortTag[1:]...))
+
+	vectorSet := `{"testGroups": [{
+	"tgId": 1, "testType": "AFT", "direction": "encrypt", "keyLen": 128, "tagLen": 32, "ivLen": 96,
+	"tests": [{"tcId": 1, "key": "` + key + `", "iv": "` + iv + `", "aad": "` + aad + `", "pt": "` + pt + `"}]
+}, {
+	"tgId": 2, "testType": "AFT", "direction": "decrypt", "keyLen": 128, "tagLen": 32, "ivLen": 96,
+	"tests": [
+		{"tcId": 2, "key": "` + key + `", "iv": "` + iv + `", "aad": "` + aad + `", "ct": "` + ct + `", "tag": "` + tag + `"},
+		{"tcId": 3, "key": "` + key + `", "iv": "` + iv + `", "aad": "` + aad + `", "ct": "` + ct + `", "tag": "` + badTag + `"}
+	]
+}]}`
+
+	m := &fakeTransactable{respond: func(cmd string, args [][]byte) ([][]byte, error) {
+		results := 1
+		if cmd == "AES-GCM/open" {
+			results = 2
+		}
+		return mockmodule.New().Transact(cmd, results, args...)
+	}}
+	result, err := (&aead{"AES-GCM", false, 0, false}).Process([]byte(vectorSet), m)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, call := range m.calls {
+		if !bytes.Equal(call.args[0], uint32le(4)) {
+			t.Errorf("%s was given tag length %x, want 4 bytes", call.cmd, call.args[0])
+		}
+	}
+	out, err := json.Marshal(result)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := `[{"tgId":1,"tests":[{"tcId":1,"ct":"` + ct + `","tag":"` + tag + `"}]},{"tgId":2,"tests":[{"tcId":2,"pt":"` + pt + `","testPassed":true},{"tcId":3,"testPassed":false}]}]`
+	if string(out) != want {
+		t.Errorf("got %s, want %s", out, want)
+	}
+}
+
+func TestAEADTagLengths(t *testing.T) {
+	for _, test := range []struct {
+		algo    string
+		tagBits int
+	}{
+		{"AES-GCM", 0},
+		{"AES-GCM", 80},
+		{"AES-GCM", 136},
+		{"AES-CCM", 0},
+		{"AES-GCM-SIV", 0},
+		{"ChaCha20-Poly1305", 0},
+		{"ChaCha20-Poly1305", 96},
+	} {
+		for _, direction := range []string{"encrypt", "decrypt"} {
+			vectorSet := fmt.Sprintf(`{"testGroups": [{
+				"tgId": 7, "testType": "AFT", "direction": "%s", "keyLen": 128, "tagLen": %d, "ivLen": 96,
+				"tests": [{"tcId": 1, "key": "000102030405060708090a0b0c0d0e0f", "iv": "000000000000000000000000", "aad": "", "ct": ""}]
+			}]}`, direction, test.tagBits)
+			m := &fakeTransactable{}
+			_, err := (&aead{test.algo, false, 0, false}).Process([]byte(vectorSet), m)
+			want := fmt.Sprintf("test group 7 has tag length %d", test.tagBits)
+			if err == nil || !strings.Contains(err.Error(), want) {
+				t.Errorf("%s %s with %d-bit tag: got error %v, want one containing %q", test.algo, direction, test.tagBits, err, want)
+			}
+			if len(m.calls) != 0 {
+				t.Errorf("%s %s with %d-bit tag: module was called", test.algo, direction, test.tagBits)
+			}
+		}
+	}
+}
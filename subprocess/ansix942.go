@@ -0,0 +1,184 @@
+// Copyright (c) 2026, Google Inc.
+//
+// Permission to use, copy, modify, and/or distribute this software for any
+// purpose with or without fee is hereby granted, provided that the above
+// copyright notice and this permission notice appear in all copies.
+//
+// THE SOFTWARE IS PROVIDED "AS IS" AND THE AUTHOR DISCLAIMS ALL WARRANTIES
+// WITH REGARD TO THIS SOFTWARE INCLUDING ALL IMPLIED WARRANTIES OF
+// MERCHANTABILITY AND FITNESS. IN NO EVENT SHALL THE AUTHOR BE LIABLE FOR ANY
+// SPECIAL, DIRECT, INDIRECT, OR CONSEQUENTIAL DAMAGES OR ANY DAMAGES
+// WHATSOEVER RESULTING FROM LOSS OF USE, DATA OR PROFITS, WHETHER IN AN ACTION
+// OF CONTRACT, NEGLIGENCE OR OTHER TORTIOUS ACTION, ARISING OUT OF OR IN
+// CONNECTION WITH THE USE OR PERFORMANCE OF THIS SOFTWARE.
+
+package subprocess
+
+import (
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+)
+
+// The following structures reflect the JSON of ACVP ANS X9.42 KDF tests. See
+// https://pages.nist.gov/ACVP/draft-hammett-acvp-kdf-ansix942.html
+
+type ansiX942TestVectorSet struct {
+	Groups []ansiX942TestGroup `json:"testGroups"`
+}
+
+type ansiX942TestGroup struct {
+	ID      uint64 `json:"tgId"`
+	Type    string `json:"testType"`
+	KDFType string `json:"kdfType"`
+	HashAlg string `json:"hashAlg"`
+	OID     string `json:"oid"`
+	KeyBits uint32 `json:"keyLen"`
+	Tests   []struct {
+		ID              uint64 `json:"tcId"`
+		ZZHex           string `json:"zz"`
+		OtherInfoHex    string `json:"otherInfo"`
+		PartyUInfoHex   string `json:"partyUInfo"`
+		PartyVInfoHex   string `json:"partyVInfo"`
+		SuppPubInfoHex  string `json:"suppPubInfo"`
+		SuppPrivInfoHex string `json:"suppPrivInfo"`
+	} `json:"tests"`
+}
+
+type ansiX942TestGroupResponse struct {
+	ID    uint64                 `json:"tgId"`
+	Tests []ansiX942TestResponse `json:"tests"`
+}
+
+type ansiX942TestResponse struct {
+	ID         uint64 `json:"tcId"`
+	DerivedKey string `json:"derivedKey"`
+}
+
+// ansiX942KeyWrapOIDs maps the key-wrap algorithms that ACVP names in X9.42
+// groups to the DER encoding, including tag and length, of their OIDs.
+var ansiX942KeyWrapOIDs = map[string][]byte{
+	// 1.2.840.113549.1.9.16.3.6, id-alg-CMS3DESwrap
+	"TDES": {0x06, 0x0b, 0x2a, 0x86, 0x48, 0x86, 0xf7, 0x0d, 0x01, 0x09, 0x10, 0x03, 0x06},
+	// 2.16.840.1.101.3.4.1.5, id-aes128-wrap
+	"AES-128-KW": {0x06, 0x09, 0x60, 0x86, 0x48, 0x01, 0x65, 0x03, 0x04, 0x01, 0x05},
+	// 2.16.840.1.101.3.4.1.25, id-aes192-wrap
+	"AES-192-KW": {0x06, 0x09, 0x60, 0x86, 0x48, 0x01, 0x65, 0x03, 0x04, 0x01, 0x19},
+	// 2.16.840.1.101.3.4.1.45, id-aes256-wrap
+	"AES-256-KW": {0x06, 0x09, 0x60, 0x86, 0x48, 0x01, 0x65, 0x03, 0x04, 0x01, 0x2d},
+}
+
+// ansiX942 implements the ANS X9.42 KDF. In the DER variant each block of
+// keying material is the hash of ZZ followed by a DER-encoded OtherInfo
+// structure, which contains the key-wrap OID, a counter, and the optional
+// party and supplementary info. Since the counter changes with each block, the
+// components are given to the module, which builds OtherInfo itself. In the
+// concatenation variant, the module is given the other info as a single,
+// opaque string.
+type ansiX942 struct{}
+
+func (a *ansiX942) Process(vectorSet []byte, m Transactable) (any, error) {
+	var parsed ansiX942TestVectorSet
+	if err := json.Unmarshal(vectorSet, &parsed); err != nil {
+		return nil, err
+	}
+
+	var ret []ansiX942TestGroupResponse
+	for _, group := range parsed.Groups {
+		group := group
+
+		if group.Type != "AFT" {
+			return nil, fmt.Errorf("test group %d had unexpected test type: %q", group.ID, group.Type)
+		}
+
+		switch group.HashAlg {
+		case "SHA-1", "SHA2-224", "SHA2-256", "SHA2-384", "SHA2-512", "SHA2-512/224", "SHA2-512/256", "SHA3-224", "SHA3-256", "SHA3-384", "SHA3-512":
+			break
+		default:
+			return nil, fmt.Errorf("test group %d has unsupported hash %q", group.ID, group.HashAlg)
+		}
+
+		var der bool
+		switch group.KDFType {
+		case "DER":
+			der = true
+		case "concatenation":
+			der = false
+		default:
+			return nil, fmt.Errorf("test group %d has unknown KDF type %q", group.ID, group.KDFType)
+		}
+
+		var oid []byte
+		if der {
+			var ok bool
+			if oid, ok = ansiX942KeyWrapOIDs[group.OID]; !ok {
+				return nil, fmt.Errorf("test group %d has unknown OID %q", group.ID, group.OID)
+			}
+		}
+
+		if group.KeyBits == 0 || group.KeyBits%8 != 0 {
+			return nil, fmt.Errorf("test group %d has key length %d - fractional bytes not supported", group.ID, group.KeyBits)
+		}
+		outBytes := int(group.KeyBits / 8)
+
+		response := ansiX942TestGroupResponse{
+			ID: group.ID,
+		}
+
+		for _, test := range group.Tests {
+			test := test
+			m := withTestCase(m, "kdf-components", group.ID, test.ID)
+
+			var fields [][]byte
+			for _, field := range []struct {
+				name string
+				hex  string
+			}{
+				{"zz", test.ZZHex},
+				{"otherInfo", test.OtherInfoHex},
+				{"partyUInfo", test.PartyUInfoHex},
+				{"partyVInfo", test.PartyVInfoHex},
+				{"suppPubInfo", test.SuppPubInfoHex},
+				{"suppPrivInfo", test.SuppPrivInfoHex},
+			} {
+				value, err := hex.DecodeString(field.hex)
+				if err != nil {
+					return nil, fmt.Errorf("failed to decode %s hex in test case %d/%d: %s", field.name, group.ID, test.ID, err)
+				}
+				fields = append(fields, value)
+			}
+			zz, otherInfo := fields[0], fields[1]
+
+			var cmd string
+			var args [][]byte
+			if der {
+				cmd = "ANSIX9.42/DER/" + group.HashAlg
+				args = [][]byte{uint32le(uint32(outBytes)), zz, oid, fields[2], fields[3], fields[4], fields[5]}
+			} else {
+				cmd = "ANSIX9.42/concatenation/" + group.HashAlg
+				args = [][]byte{uint32le(uint32(outBytes)), zz, otherInfo}
+			}
+
+			m.TransactAsync(cmd, 1, args, func(result [][]byte) error {
+				if len(result[0]) != outBytes {
+					return fmt.Errorf("%s operation returned %d bytes but wanted %d", cmd, len(result[0]), outBytes)
+				}
+				response.Tests = append(response.Tests, ansiX942TestResponse{
+					ID:         test.ID,
+					DerivedKey: hex.EncodeToString(result[0]),
+				})
+				return nil
+			})
+		}
+
+		m.Barrier(func() {
+			ret = append(ret, response)
+		})
+	}
+
+	if err := m.Flush(); err != nil {
+		return nil, err
+	}
+
+	return ret, nil
+}
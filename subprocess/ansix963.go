@@ -0,0 +1,129 @@
+// Copyright (c) 2026, Google Inc.
+//
+// Permission to use, copy, modify, and/or distribute this software for any
+// purpose with or without fee is hereby granted, provided that the above
+// copyright notice and this permission notice appear in all copies.
+//
+// THE SOFTWARE IS PROVIDED "AS IS" AND THE AUTHOR DISCLAIMS ALL WARRANTIES
+// WITH REGARD TO THIS SOFTWARE INCLUDING ALL IMPLIED WARRANTIES OF
+// MERCHANTABILITY AND FITNESS. IN NO EVENT SHALL THE AUTHOR BE LIABLE FOR ANY
+// SPECIAL, DIRECT, INDIRECT, OR CONSEQUENTIAL DAMAGES OR ANY DAMAGES
+// WHATSOEVER RESULTING FROM LOSS OF USE, DATA OR PROFITS, WHETHER IN AN ACTION
+// OF CONTRACT, NEGLIGENCE OR OTHER TORTIOUS ACTION, ARISING OUT OF OR IN
+// CONNECTION WITH THE USE OR PERFORMANCE OF THIS SOFTWARE.
+
+package subprocess
+
+import (
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+)
+
+// The following structures reflect the JSON of ACVP ANS X9.63 KDF tests. See
+// https://pages.nist.gov/ACVP/draft-celi-acvp-kdf-ansix963.html
+
+type ansiX963TestVectorSet struct {
+	Groups []ansiX963TestGroup `json:"testGroups"`
+}
+
+type ansiX963TestGroup struct {
+	ID             uint64 `json:"tgId"`
+	Type           string `json:"testType"`
+	HashAlg        string `json:"hashAlg"`
+	FieldSize      uint32 `json:"fieldSize"`
+	SharedInfoBits uint32 `json:"sharedInfoLength"`
+	KeyDataBits    uint32 `json:"keyDataLength"`
+	Tests          []struct {
+		ID            uint64 `json:"tcId"`
+		ZHex          string `json:"z"`
+		SharedInfoHex string `json:"sharedInfo"`
+	} `json:"tests"`
+}
+
+type ansiX963TestGroupResponse struct {
+	ID    uint64                 `json:"tgId"`
+	Tests []ansiX963TestResponse `json:"tests"`
+}
+
+type ansiX963TestResponse struct {
+	ID         uint64 `json:"tcId"`
+	KeyDataHex string `json:"keyData"`
+}
+
+// ansiX963 implements the ANS X9.63 KDF, in which each block of keying
+// material is the hash of Z, a 32-bit big-endian counter and the shared info.
+type ansiX963 struct{}
+
+func (a *ansiX963) Process(vectorSet []byte, m Transactable) (any, error) {
+	var parsed ansiX963TestVectorSet
+	if err := json.Unmarshal(vectorSet, &parsed); err != nil {
+		return nil, err
+	}
+
+	var ret []ansiX963TestGroupResponse
+	for _, group := range parsed.Groups {
+		group := group
+
+		// Only the AFT test type is specified for ANS X9.63:
+		// https://pages.nist.gov/ACVP/draft-celi-acvp-kdf-ansix963.html#name-test-types
+		if group.Type != "AFT" {
+			return nil, fmt.Errorf("test group %d had unexpected test type: %q", group.ID, group.Type)
+		}
+
+		switch group.HashAlg {
+		case "SHA2-224", "SHA2-256", "SHA2-384", "SHA2-512", "SHA2-512/224", "SHA2-512/256", "SHA3-224", "SHA3-256", "SHA3-384", "SHA3-512":
+			break
+		default:
+			return nil, fmt.Errorf("test group %d has unsupported hash %q", group.ID, group.HashAlg)
+		}
+
+		if group.KeyDataBits == 0 || group.KeyDataBits%8 != 0 {
+			return nil, fmt.Errorf("test group %d has key data length %d - fractional bytes not supported", group.ID, group.KeyDataBits)
+		}
+		outBytes := int(group.KeyDataBits / 8)
+
+		response := ansiX963TestGroupResponse{
+			ID: group.ID,
+		}
+
+		for _, test := range group.Tests {
+			test := test
+			m := withTestCase(m, "kdf-components", group.ID, test.ID)
+
+			z, err := hex.DecodeString(test.ZHex)
+			if err != nil {
+				return nil, fmt.Errorf("failed to decode Z hex in test case %d/%d: %s", group.ID, test.ID, err)
+			}
+			sharedInfo, err := hex.DecodeString(test.SharedInfoHex)
+			if err != nil {
+				return nil, fmt.Errorf("failed to decode shared info hex in test case %d/%d: %s", group.ID, test.ID, err)
+			}
+			if uint32(len(sharedInfo))*8 != group.SharedInfoBits {
+				return nil, fmt.Errorf("test case %d/%d has %d bytes of shared info but the group specifies %d bits", group.ID, test.ID, len(sharedInfo), group.SharedInfoBits)
+			}
+
+			cmd := "ANSIX9.63/" + group.HashAlg
+			m.TransactAsync(cmd, 1, [][]byte{uint32le(uint32(outBytes)), z, sharedInfo}, func(result [][]byte) error {
+				if len(result[0]) != outBytes {
+					return fmt.Errorf("%s operation returned %d bytes but wanted %d", cmd, len(result[0]), outBytes)
+				}
+				response.Tests = append(response.Tests, ansiX963TestResponse{
+					ID:         test.ID,
+					KeyDataHex: hex.EncodeToString(result[0]),
+				})
+				return nil
+			})
+		}
+
+		m.Barrier(func() {
+			ret = append(ret, response)
+		})
+	}
+
+	if err := m.Flush(); err != nil {
+		return nil, err
+	}
+
+	return ret, nil
+}
@@ -0,0 +1,117 @@
+// Copyright (c) 2026, Google Inc.
+//
+// Permission to use, copy, modify, and/or distribute this software for any
+// purpose with or without fee is hereby granted, provided that the above
+// copyright notice and this permission notice appear in all copies.
+//
+// THE SOFTWARE IS PROVIDED "AS IS" AND THE AUTHOR DISCLAIMS ALL WARRANTIES
+// WITH REGARD TO THIS SOFTWARE INCLUDING ALL IMPLIED WARRANTIES OF
+// MERCHANTABILITY AND FITNESS. IN NO EVENT SHALL THE AUTHOR BE LIABLE FOR ANY
+// SPECIAL, DIRECT, INDIRECT, OR CONSEQUENTIAL DAMAGES OR ANY DAMAGES
+// WHATSOEVER RESULTING FROM LOSS OF USE, DATA OR PROFITS, WHETHER IN AN ACTION
+// OF CONTRACT, NEGLIGENCE OR OTHER TORTIOUS ACTION, ARISING OUT OF OR IN
+// CONNECTION WITH THE USE OR PERFORMANCE OF THIS SOFTWARE.
+
+package subprocess
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/hex"
+	"testing"
+)
+
+// ansiX963Responder implements the ANS X9.63 KDF with SHA-256.
+func ansiX963Responder(cmd string, args [][]byte) ([][]byte, error) {
+	if cmd != "ANSIX9.63/SHA2-256" {
+		panic("unexpected command " + cmd)
+	}
+	outLen := int(binary.LittleEndian.Uint32(args[0]))
+	z, sharedInfo := args[1], args[2]
+
+	var out []byte
+	for counter := uint32(1); len(out) < outLen; counter++ {
+		h := sha256.New()
+		h.Write(z)
+		h.Write(binary.BigEndian.AppendUint32(nil, counter))
+		h.Write(sharedInfo)
+		out = h.Sum(out)
+	}
+	return [][]byte{out[:outLen]}, nil
+}
+
+func TestANSIX963MultiBlock(t *testing.T) {
+	const vectorSet = `{"algorithm": "kdf-components", "mode": "ansix9.63", "testGroups": [{
+		"tgId": 1, "testType": "AFT", "hashAlg": "SHA2-256", "fieldSize": 256,
+		"sharedInfoLength": 16, "keyDataLength": 640,
+		"tests": [{"tcId": 1, "z": "0a0b", "sharedInfo": "5151"}]
+	}]}`
+
+	m := &fakeTransactable{respond: ansiX963Responder}
+	result, err := new(ansiX963).Process([]byte(vectorSet), m)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if args := m.calls[0].args; !bytes.Equal(args[0], uint32le(80)) || !bytes.Equal(args[2], []byte{0x51, 0x51}) {
+		t.Errorf("unexpected arguments: %x", args)
+	}
+
+	// Key data is 80 bytes, so it spans three SHA-256 blocks, the last of
+	// which is truncated.
+	var want []byte
+	for counter := byte(1); counter <= 3; counter++ {
+		block := sha256.Sum256([]byte{0x0a, 0x0b, 0, 0, 0, counter, 0x51, 0x51})
+		want = append(want, block[:]...)
+	}
+	if got := result.([]ansiX963TestGroupResponse)[0].Tests[0].KeyDataHex; got != hex.EncodeToString(want[:80]) {
+		t.Errorf("got %s, want %x", got, want[:80])
+	}
+}
+
+func TestANSIX942DER(t *testing.T) {
+	const vectorSet = `{"algorithm": "kdf-components", "mode": "ansix9.42", "testGroups": [{
+		"tgId": 1, "testType": "AFT", "kdfType": "DER", "hashAlg": "SHA2-256", "oid": "AES-128-KW", "keyLen": 1024,
+		"tests": [{"tcId": 1, "zz": "0a0b", "partyUInfo": "01", "partyVInfo": "02", "suppPubInfo": "03", "suppPrivInfo": ""}]
+	}]}`
+
+	m := &fakeTransactable{respond: func(cmd string, args [][]byte) ([][]byte, error) {
+		return [][]byte{make([]byte, binary.LittleEndian.Uint32(args[0]))}, nil
+	}}
+	result, err := new(ansiX942).Process([]byte(vectorSet), m)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	call := m.calls[0]
+	if call.cmd != "ANSIX9.42/DER/SHA2-256" {
+		t.Errorf("command was %q", call.cmd)
+	}
+	oid, _ := hex.DecodeString("0609608648016503040105")
+	want := [][]byte{uint32le(128), {0x0a, 0x0b}, oid, {0x01}, {0x02}, {0x03}, {}}
+	for i, arg := range call.args {
+		if !bytes.Equal(arg, want[i]) {
+			t.Errorf("argument %d is %x, want %x", i, arg, want[i])
+		}
+	}
+
+	if key := result.([]ansiX942TestGroupResponse)[0].Tests[0].DerivedKey; len(key) != 256 {
+		t.Errorf("derived key is %d hex digits long", len(key))
+	}
+
+	concat := bytes.Replace([]byte(vectorSet), []byte(`"DER"`), []byte(`"concatenation"`), 1)
+	concat = bytes.Replace(concat, []byte(`"zz": "0a0b"`), []byte(`"zz": "0a0b", "otherInfo": "abcd"`), 1)
+	m.calls = nil
+	if _, err := new(ansiX942).Process(concat, m); err != nil {
+		t.Fatal(err)
+	}
+	if args := m.calls[0].args; m.calls[0].cmd != "ANSIX9.42/concatenation/SHA2-256" || len(args) != 3 || !bytes.Equal(args[2], []byte{0xab, 0xcd}) {
+		t.Errorf("unexpected concatenation call: %+v", m.calls[0])
+	}
+
+	unknownOID := bytes.Replace([]byte(vectorSet), []byte(`"AES-128-KW"`), []byte(`"AES-512-KW"`), 1)
+	if _, err := new(ansiX942).Process(unknownOID, m); err == nil {
+		t.Error("unknown OID was accepted")
+	}
+}
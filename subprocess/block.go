@@ -15,10 +15,13 @@
 package subprocess
 
 import (
+	"encoding/binary"
 	"encoding/hex"
 	"encoding/json"
 	"fmt"
+	"math"
 	"math/bits"
+	"strings"
 )
 
 // aesKeyShuffle is the "AES Monte Carlo Key Shuffle" from the ACVP
@@ -50,7 +53,7 @@ func aesKeyShuffle(key, result, prevResult []byte) {
 
 // iterateAES implements the "AES Monte Carlo Test - ECB mode" from the ACVP
 // specification.
-func iterateAES(transact func(n int, args ...[]byte) ([][]byte, error), encrypt bool, key, input, iv []byte) (mctResults []blockCipherMCTResult) {
+func iterateAES(transact func(n int, args ...[]byte) ([][]byte, error), encrypt bool, key, input, iv []byte) (mctResults []blockCipherMCTResult, err error) {
 	for i := 0; i < 100; i++ {
 		var iteration blockCipherMCTResult
 		iteration.KeyHex = hex.EncodeToString(key)
@@ -62,7 +65,7 @@ func iterateAES(transact func(n int, args ...[]byte) ([][]byte, error), encrypt
 
 		results, err := transact(2, key, input, uint32le(1000))
 		if err != nil {
-			panic(err)
+			return nil, err
 		}
 		input = results[0]
 		prevResult := results[1]
@@ -77,12 +80,14 @@ func iterateAES(transact func(n int, args ...[]byte) ([][]byte, error), encrypt
 		mctResults = append(mctResults, iteration)
 	}
 
-	return mctResults
+	return mctResults, nil
 }
 
 // iterateAESCBC implements the "AES Monte Carlo Test - CBC mode" from the ACVP
-// specification.
-func iterateAESCBC(transact func(n int, args ...[]byte) ([][]byte, error), encrypt bool, key, input, iv []byte) (mctResults []blockCipherMCTResult) {
+// specification. The CFB128 and OFB tests chain their outer iterations in the
+// same way: the last output becomes the IV and the one before it the next
+// input. They differ only in the inner loop, which the module runs.
+func iterateAESCBC(transact func(n int, args ...[]byte) ([][]byte, error), encrypt bool, key, input, iv []byte) (mctResults []blockCipherMCTResult, err error) {
 	for i := 0; i < 100; i++ {
 		var iteration blockCipherMCTResult
 		iteration.KeyHex = hex.EncodeToString(key)
@@ -96,7 +101,7 @@ func iterateAESCBC(transact func(n int, args ...[]byte) ([][]byte, error), encry
 
 		results, err := transact(2, key, input, iv, uint32le(1000))
 		if err != nil {
-			panic("block operation failed")
+			return nil, err
 		}
 
 		result := results[0]
@@ -116,7 +121,71 @@ func iterateAESCBC(transact func(n int, args ...[]byte) ([][]byte, error), encry
 		mctResults = append(mctResults, iteration)
 	}
 
-	return mctResults
+	return mctResults, nil
+}
+
+// cfbHistoryBytes is the length of the output history that modules return
+// from AES-CFB1 and AES-CFB8 Monte Carlo tests: the last 256 bits of output,
+// which is enough for the longest key.
+const cfbHistoryBytes = 32
+
+// iterateAESCFBSegments returns an implementation of "AES Monte Carlo Test -
+// CFB1" or "AES Monte Carlo Test - CFB8" from the ACVP specification, for
+// segments of segmentBits bits. Unlike the other modes, the key, the IV and
+// the next input are taken from the last few hundred bits of output, so the
+// module returns those as well as the final result. For CFB1, outputs are
+// packed from the most significant bit.
+func iterateAESCFBSegments(segmentBits int) func(transact func(n int, args ...[]byte) ([][]byte, error), encrypt bool, key, input, iv []byte) ([]blockCipherMCTResult, error) {
+	return func(transact func(n int, args ...[]byte) ([][]byte, error), encrypt bool, key, input, iv []byte) (mctResults []blockCipherMCTResult, err error) {
+		for i := 0; i < 100; i++ {
+			var iteration blockCipherMCTResult
+			iteration.KeyHex = hex.EncodeToString(key)
+			if encrypt {
+				iteration.PlaintextHex = hex.EncodeToString(input)
+			} else {
+				iteration.CiphertextHex = hex.EncodeToString(input)
+			}
+			iteration.IVHex = hex.EncodeToString(iv)
+
+			args := [][]byte{key, input, iv, uint32le(1000)}
+			if segmentBits == 1 {
+				args = append(args, uint32le(1))
+			}
+			results, err := transact(2, args...)
+			if err != nil {
+				return nil, err
+			}
+			result, history := results[0], results[1]
+			if len(history) != cfbHistoryBytes {
+				return nil, fmt.Errorf("module returned %d bytes of output history, but %d are needed", len(history), cfbHistoryBytes)
+			}
+
+			if encrypt {
+				iteration.CiphertextHex = hex.EncodeToString(result)
+			} else {
+				iteration.PlaintextHex = hex.EncodeToString(result)
+			}
+
+			// The key is XORed with as much of the most recent output as
+			// it is long, the IV is the last block of output, and the
+			// next input is the segment before that block.
+			recent := history[len(history)-len(key):]
+			for j := range key {
+				key[j] ^= recent[j]
+			}
+			iv = append([]byte(nil), history[len(history)-16:]...)
+			previous := history[len(history)-17]
+			if segmentBits == 1 {
+				input = []byte{(previous & 1) << 7}
+			} else {
+				input = []byte{previous}
+			}
+
+			mctResults = append(mctResults, iteration)
+		}
+
+		return mctResults, nil
+	}
 }
 
 // xorKeyWithOddParityLSB XORs value into key while setting the LSB of each bit
@@ -142,7 +211,7 @@ func keyShuffle3DES(key, result, prevResult, prevPrevResult []byte) {
 
 // iterate3DES implements "TDES Monte Carlo Test - ECB mode" from the ACVP
 // specification.
-func iterate3DES(transact func(n int, args ...[]byte) ([][]byte, error), encrypt bool, key, input, iv []byte) (mctResults []blockCipherMCTResult) {
+func iterate3DES(transact func(n int, args ...[]byte) ([][]byte, error), encrypt bool, key, input, iv []byte) (mctResults []blockCipherMCTResult, err error) {
 	for i := 0; i < 400; i++ {
 		var iteration blockCipherMCTResult
 		keyHex := hex.EncodeToString(key)
@@ -158,7 +227,7 @@ func iterate3DES(transact func(n int, args ...[]byte) ([][]byte, error), encrypt
 
 		results, err := transact(3, key, input, uint32le(10000))
 		if err != nil {
-			panic("block operation failed")
+			return nil, err
 		}
 		result := results[0]
 		prevResult := results[1]
@@ -175,12 +244,12 @@ func iterate3DES(transact func(n int, args ...[]byte) ([][]byte, error), encrypt
 		input = result
 	}
 
-	return mctResults
+	return mctResults, nil
 }
 
 // iterate3DESCBC implements "TDES Monte Carlo Test - CBC mode" from the ACVP
 // specification.
-func iterate3DESCBC(transact func(n int, args ...[]byte) ([][]byte, error), encrypt bool, key, input, iv []byte) (mctResults []blockCipherMCTResult) {
+func iterate3DESCBC(transact func(n int, args ...[]byte) ([][]byte, error), encrypt bool, key, input, iv []byte) (mctResults []blockCipherMCTResult, err error) {
 	for i := 0; i < 400; i++ {
 		var iteration blockCipherMCTResult
 		keyHex := hex.EncodeToString(key)
@@ -197,7 +266,7 @@ func iterate3DESCBC(transact func(n int, args ...[]byte) ([][]byte, error), encr
 
 		results, err := transact(3, key, input, iv, uint32le(10000))
 		if err != nil {
-			panic("block operation failed")
+			return nil, err
 		}
 
 		result := results[0]
@@ -223,7 +292,52 @@ func iterate3DESCBC(transact func(n int, args ...[]byte) ([][]byte, error), encr
 		mctResults = append(mctResults, iteration)
 	}
 
-	return mctResults
+	return mctResults, nil
+}
+
+// iterate3DESFeedback implements "TDES Monte Carlo Test - OFB mode" and "TDES
+// Monte Carlo Test - CFB64 mode" from the ACVP specification. Both modes chain
+// the outer iterations in the same way whatever the direction: the final
+// output becomes the IV and the output before it becomes the next input.
+func iterate3DESFeedback(transact func(n int, args ...[]byte) ([][]byte, error), encrypt bool, key, input, iv []byte) (mctResults []blockCipherMCTResult, err error) {
+	for i := 0; i < 400; i++ {
+		var iteration blockCipherMCTResult
+		keyHex := hex.EncodeToString(key)
+		iteration.Key1Hex = keyHex[:16]
+		iteration.Key2Hex = keyHex[16:32]
+		iteration.Key3Hex = keyHex[32:]
+
+		if encrypt {
+			iteration.PlaintextHex = hex.EncodeToString(input)
+		} else {
+			iteration.CiphertextHex = hex.EncodeToString(input)
+		}
+		iteration.IVHex = hex.EncodeToString(iv)
+
+		results, err := transact(3, key, input, iv, uint32le(10000))
+		if err != nil {
+			return nil, err
+		}
+
+		result := results[0]
+		prevResult := results[1]
+		prevPrevResult := results[2]
+
+		if encrypt {
+			iteration.CiphertextHex = hex.EncodeToString(result)
+		} else {
+			iteration.PlaintextHex = hex.EncodeToString(result)
+		}
+
+		keyShuffle3DES(key, result, prevResult, prevPrevResult)
+
+		input = prevResult
+		iv = result
+
+		mctResults = append(mctResults, iteration)
+	}
+
+	return mctResults, nil
 }
 
 // blockCipher implements an ACVP algorithm by making requests to the subprocess
@@ -237,7 +351,37 @@ type blockCipher struct {
 	numResults              int
 	inputsAreBlockMultiples bool
 	hasIV                   bool
-	mctFunc                 func(transact func(n int, args ...[]byte) ([][]byte, error), encrypt bool, key, input, iv []byte) (result []blockCipherMCTResult)
+	mctFunc                 func(transact func(n int, args ...[]byte) ([][]byte, error), encrypt bool, key, input, iv []byte) (result []blockCipherMCTResult, err error)
+}
+
+// ciphertextStealing returns true if b is one of the CBC-CS modes from the
+// addendum to SP 800-38A.
+func (b *blockCipher) ciphertextStealing() bool {
+	return strings.HasPrefix(b.algo, "AES-CBC-CS")
+}
+
+// bitOriented returns true if b is AES-CFB1, where each segment is one bit
+// and so the input need not be a whole number of bytes.
+func (b *blockCipher) bitOriented() bool {
+	return b.algo == "AES-CFB1"
+}
+
+// initialCounter returns the initial counter for an AES-CTR counter test with
+// an input of inputLen bytes. If overflow is set, the counter wraps around
+// half way through the input.
+func initialCounter(inputLen, blockSize int, overflow bool) []byte {
+	counter := make([]byte, blockSize)
+	numBlocks := (inputLen + blockSize - 1) / blockSize
+	if !overflow || numBlocks < 2 {
+		return counter
+	}
+
+	// Set the counter to -(numBlocks/2), in two's complement.
+	for i := range counter {
+		counter[i] = 0xff
+	}
+	binary.BigEndian.PutUint64(counter[blockSize-8:], -uint64(numBlocks/2))
+	return counter
 }
 
 type blockCipherVectorSet struct {
@@ -249,6 +393,14 @@ type blockCipherTestGroup struct {
 	Type      string `json:"testType"`
 	Direction string `json:"direction"`
 	KeyBits   int    `json:"keylen"`
+	// Incremental and Overflow are set in AES-CTR groups. Counters that
+	// decrease aren't supported. Overflow groups make the counter wrap
+	// around part way through the message.
+	Incremental *bool `json:"incremental"`
+	Overflow    bool  `json:"overflow"`
+	// LargeData groups give a pattern as the input of each test, which is
+	// repeated to the payload length. See expandLargeData.
+	LargeData bool `json:"largeData"`
 	Tests     []struct {
 		ID            uint64  `json:"tcId"`
 		InputBits     *uint64 `json:"payloadLen"`
@@ -319,10 +471,16 @@ func (b *blockCipher) Process(vectorSet []byte, m Transactable) (any, error) {
 			op = b.algo + "/decrypt"
 		}
 
-		var mct bool
+		var mct, counterTest bool
 		switch group.Type {
-		case "AFT", "CTR":
+		case "AFT":
+			mct = false
+		case "CTR":
+			if b.algo != "AES-CTR" {
+				return nil, fmt.Errorf("test group %d has type CTR which is unsupported for %q", group.ID, op)
+			}
 			mct = false
+			counterTest = true
 		case "MCT":
 			if b.mctFunc == nil {
 				return nil, fmt.Errorf("test group %d has type MCT which is unsupported for %q", group.ID, op)
@@ -332,6 +490,14 @@ func (b *blockCipher) Process(vectorSet []byte, m Transactable) (any, error) {
 			return nil, fmt.Errorf("test group %d has unknown type %q", group.ID, group.Type)
 		}
 
+		if group.LargeData && mct {
+			return nil, fmt.Errorf("test group %d is a large-data MCT group, which is not supported", group.ID)
+		}
+
+		if group.Incremental != nil && !*group.Incremental {
+			return nil, fmt.Errorf("test group %d uses a decrementing counter, which is not supported", group.ID)
+		}
+
 		if group.KeyBits == 0 {
 			// 3DES tests fail to set this parameter.
 			group.KeyBits = 192
@@ -342,12 +508,12 @@ func (b *blockCipher) Process(vectorSet []byte, m Transactable) (any, error) {
 		}
 		keyBytes := group.KeyBits / 8
 
-		transact := func(n int, args ...[]byte) ([][]byte, error) {
-			return m.Transact(op, n, args...)
-		}
-
 		for _, test := range group.Tests {
 			test := test
+			m := withTestCase(m, b.algo, group.ID, test.ID)
+			transact := func(n int, args ...[]byte) ([][]byte, error) {
+				return m.Transact(op, n, args...)
+			}
 
 			if len(test.KeyHex) == 0 && len(test.Key1Hex) > 0 {
 				// 3DES encodes the key differently.
@@ -370,11 +536,13 @@ func (b *blockCipher) Process(vectorSet []byte, m Transactable) (any, error) {
 				inputHex = test.CiphertextHex
 			}
 
-			if test.InputBits != nil {
-				if *test.InputBits%8 != 0 {
+			if test.InputBits != nil && !group.LargeData {
+				if *test.InputBits%8 != 0 && !b.bitOriented() {
 					return nil, fmt.Errorf("input to test case %d/%d is not a whole number of bytes", group.ID, test.ID)
 				}
-				if inputBits := 4 * uint64(len(inputHex)); *test.InputBits != inputBits {
+				// Bit-oriented inputs are padded to a whole number of
+				// bytes.
+				if inputBits := 4 * uint64(len(inputHex)); (*test.InputBits+7)/8*8 != inputBits {
 					return nil, fmt.Errorf("input to test case %d/%d is %q (%d bits), but %d bits is specified", group.ID, test.ID, inputHex, inputBits, *test.InputBits)
 				}
 			}
@@ -383,13 +551,44 @@ func (b *blockCipher) Process(vectorSet []byte, m Transactable) (any, error) {
 			if err != nil {
 				return nil, fmt.Errorf("failed to decode hex in test case %d/%d: %s", group.ID, test.ID, err)
 			}
+			inputBits := 8 * uint64(len(input))
+			if test.InputBits != nil && !group.LargeData {
+				inputBits = *test.InputBits
+			}
+			if partialBits := inputBits % 8; partialBits != 0 {
+				input[len(input)-1] &= 0xff << (8 - partialBits)
+			}
+
+			if group.LargeData {
+				if test.InputBits == nil {
+					return nil, fmt.Errorf("large-data test case %d/%d has no payload length", group.ID, test.ID)
+				}
+				if input, err = expandLargeData(input, *test.InputBits); err != nil {
+					return nil, fmt.Errorf("test case %d/%d: %s", group.ID, test.ID, err)
+				}
+				inputBits = *test.InputBits
+			}
+			if b.bitOriented() && inputBits > math.MaxUint32 {
+				return nil, fmt.Errorf("input to test case %d/%d is %d bits, which is too long", group.ID, test.ID, inputBits)
+			}
 
 			if b.inputsAreBlockMultiples && len(input)%b.blockSize != 0 {
 				return nil, fmt.Errorf("test case %d/%d has input of length %d, but expected multiple of %d", group.ID, test.ID, len(input), b.blockSize)
 			}
+			// Ciphertext stealing needs at least one whole block to steal
+			// from. The module handles a short final block, and decides
+			// the order of the final two blocks from the variant in the
+			// command name.
+			if b.ciphertextStealing() && len(input) < b.blockSize {
+				return nil, fmt.Errorf("test case %d/%d has input of length %d, but %s needs at least %d bytes", group.ID, test.ID, len(input), b.algo, b.blockSize)
+			}
 
 			var iv []byte
-			if b.hasIV {
+			if counterTest && len(test.IVHex) == 0 {
+				// The module's choice of counters is being tested, but the
+				// protocol always passes an initial counter, so pick one.
+				iv = initialCounter(len(input), b.blockSize, group.Overflow)
+			} else if b.hasIV {
 				if iv, err = hex.DecodeString(test.IVHex); err != nil {
 					return nil, fmt.Errorf("failed to decode hex in test case %d/%d: %s", group.ID, test.ID, err)
 				}
@@ -406,8 +605,21 @@ func (b *blockCipher) Process(vectorSet []byte, m Transactable) (any, error) {
 				} else {
 					args = [][]byte{key, input, uint32le(1)}
 				}
+				if b.bitOriented() {
+					args = append(args, uint32le(uint32(inputBits)))
+				}
 
+				// The module increments the counter of AES-CTR, carrying
+				// across the whole block so that it wraps around in
+				// overflow groups.
 				m.TransactAsync(op, b.numResults, args, func(result [][]byte) error {
+					if len(result[0]) != len(input) {
+						return fmt.Errorf("%s operation returned %d bytes for test case %d/%d, but the input is %d bytes", op, len(result[0]), group.ID, test.ID, len(input))
+					}
+					// Only as many bits as the input has are the result.
+					if partialBits := inputBits % 8; partialBits != 0 {
+						result[0][len(result[0])-1] &= 0xff << (8 - partialBits)
+					}
 					if encrypt {
 						testResp.CiphertextHex = hex.EncodeToString(result[0])
 					} else {
@@ -417,7 +629,11 @@ func (b *blockCipher) Process(vectorSet []byte, m Transactable) (any, error) {
 					return nil
 				})
 			} else {
-				testResp.MCTResults = b.mctFunc(transact, encrypt, key, input, iv)
+				mctResults, err := b.mctFunc(transact, encrypt, key, input, iv)
+				if err != nil {
+					return nil, fmt.Errorf("%s MCT operation failed for test case %d/%d: %s", b.algo, group.ID, test.ID, err)
+				}
+				testResp.MCTResults = mctResults
 				response.Tests = append(response.Tests, testResp)
 			}
 		}
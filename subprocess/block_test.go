@@ -0,0 +1,526 @@
+// Copyright (c) 2026, Google Inc.
+//
+// Permission to use, copy, modify, and/or distribute this software for any
+// purpose with or without fee is hereby granted, provided that the above
+// copyright notice and this permission notice appear in all copies.
+//
+// THE SOFTWARE IS PROVIDED "AS IS" AND THE AUTHOR DISCLAIMS ALL WARRANTIES
+// WITH REGARD TO THIS SOFTWARE INCLUDING ALL IMPLIED WARRANTIES OF
+// MERCHANTABILITY AND FITNESS. IN NO EVENT SHALL THE AUTHOR BE LIABLE FOR ANY
+// SPECIAL, DIRECT, INDIRECT, OR CONSEQUENTIAL DAMAGES OR ANY DAMAGES
+// WHATSOEVER RESULTING FROM LOSS OF USE, DATA OR PROFITS, WHETHER IN AN ACTION
+// OF CONTRACT, NEGLIGENCE OR OTHER TORTIOUS ACTION, ARISING OUT OF OR IN
+// CONNECTION WITH THE USE OR PERFORMANCE OF THIS SOFTWARE.
+
+package subprocess
+
+import (
+	"bytes"
+	"crypto/aes"
+	"crypto/cipher"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"strings"
+	"testing"
+
+	"github.com/cpu/acvptool/subprocess/internal/mockmodule"
+)
+
+func TestTDESFeedbackMCT(t *testing.T) {
+	result := []byte{0x00, 0x01, 0x02, 0x03, 0x04, 0x05, 0x06, 0x07}
+	prevResult := []byte{0x10, 0x11, 0x12, 0x13, 0x14, 0x15, 0x16, 0x17}
+	prevPrevResult := []byte{0xf0, 0xf1, 0xf2, 0xf3, 0xf4, 0xf5, 0xf6, 0xf7}
+
+	var calls [][][]byte
+	transact := func(n int, args ...[]byte) ([][]byte, error) {
+		calls = append(calls, args)
+		return [][]byte{result, prevResult, prevPrevResult}, nil
+	}
+
+	// Every byte of the initial key has odd parity.
+	key := bytes.Repeat([]byte{0x01}, 24)
+	input := bytes.Repeat([]byte{0xaa}, 8)
+	iv := bytes.Repeat([]byte{0xbb}, 8)
+	mctResults, err := iterate3DESFeedback(transact, true, key, input, iv)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(mctResults) != 400 {
+		t.Fatalf("got %d outer iterations, want 400", len(mctResults))
+	}
+
+	// Each third of the key is XORed with one of the last three outputs and
+	// then has its parity fixed.
+	second := mctResults[1]
+	if want := "0101020204040707"; second.Key1Hex != want {
+		t.Errorf("first key part is %s, want %s", second.Key1Hex, want)
+	}
+	if want := "1010131315151616"; second.Key2Hex != want {
+		t.Errorf("second key part is %s, want %s", second.Key2Hex, want)
+	}
+	if want := "f1f1f2f2f4f4f7f7"; second.Key3Hex != want {
+		t.Errorf("third key part is %s, want %s", second.Key3Hex, want)
+	}
+
+	if !bytes.Equal(calls[1][1], prevResult) || !bytes.Equal(calls[1][2], result) {
+		t.Errorf("second iteration has input %x and IV %x", calls[1][1], calls[1][2])
+	}
+	if second.IVHex != hex.EncodeToString(result) {
+		t.Errorf("second iteration reported IV %s", second.IVHex)
+	}
+}
+
+// aesCTRResponder implements AES-CTR with the Go standard library, whose
+// counter is the whole block.
+func aesCTRResponder(cmd string, args [][]byte) ([][]byte, error) {
+	block, err := aes.NewCipher(args[0])
+	if err != nil {
+		return nil, err
+	}
+	out := make([]byte, len(args[1]))
+	cipher.NewCTR(block, args[2]).XORKeyStream(out, args[1])
+	return [][]byte{out}, nil
+}
+
+var aesCTR = &blockCipher{"AES-CTR", 16, 1, false, true, nil}
+
+const ctrKey = "000102030405060708090a0b0c0d0e0f"
+
+func ctrVectorSet(testType string, overflow bool, pt, iv string) string {
+	return fmt.Sprintf(`{"testGroups": [{
+	"tgId": 1, "testType": %q, "direction": "encrypt", "keylen": 128, "incremental": true, "overflow": %t,
+	"tests": [{"tcId": 1, "payloadLen": %d, "pt": %q, "iv": %q, "key": %q}]
+}]}`, testType, overflow, len(pt)*4, pt, iv, ctrKey)
+}
+
+// ctrCiphertext returns the ciphertext of a single test in result.
+func ctrCiphertext(t *testing.T, result any) []byte {
+	resultBytes, err := json.Marshal(result)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var groups []struct {
+		Tests []struct {
+			CiphertextHex string `json:"ct"`
+		} `json:"tests"`
+	}
+	if err := json.Unmarshal(resultBytes, &groups); err != nil {
+		t.Fatal(err)
+	}
+	ct, err := hex.DecodeString(groups[0].Tests[0].CiphertextHex)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return ct
+}
+
+func TestAESCTRPartialBlock(t *testing.T) {
+	m := &fakeTransactable{respond: aesCTRResponder}
+	result, err := aesCTR.Process([]byte(ctrVectorSet("AFT", false, "6b", strings.Repeat("00", 16))), m)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ct := ctrCiphertext(t, result); len(ct) != 1 {
+		t.Errorf("got %d bytes of ciphertext for 1 byte of plaintext", len(ct))
+	}
+}
+
+func TestAESCTROverflow(t *testing.T) {
+	// The counter wraps around after the first of three blocks, the last of
+	// which is partial.
+	pt := strings.Repeat("ab", 40)
+	iv := strings.Repeat("ff", 16)
+	m := &fakeTransactable{respond: aesCTRResponder}
+	result, err := aesCTR.Process([]byte(ctrVectorSet("AFT", true, pt, iv)), m)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// The second block is encrypted with a counter of zero.
+	block, err := aes.NewCipher(mustDecodeHex(t, ctrKey))
+	if err != nil {
+		t.Fatal(err)
+	}
+	keystream := make([]byte, 16)
+	block.Encrypt(keystream, make([]byte, 16))
+	ct := ctrCiphertext(t, result)
+	for i, b := range ct[16:32] {
+		if b^0xab != keystream[i] {
+			t.Fatalf("second block is %x, which was not encrypted with a zero counter", ct[16:32])
+		}
+	}
+}
+
+func TestAESCTRCounterTest(t *testing.T) {
+	// Counter tests don't give an IV, so the tool picks one that makes the
+	// counter wrap around in overflow groups.
+	pt := strings.Repeat("00", 10*16)
+	m := &fakeTransactable{respond: aesCTRResponder}
+	if _, err := aesCTR.Process([]byte(ctrVectorSet("CTR", true, pt, "")), m); err != nil {
+		t.Fatal(err)
+	}
+
+	counter := new(big.Int).SetBytes(m.calls[0].args[2])
+	last := new(big.Int).Add(counter, big.NewInt(9))
+	if last.BitLen() <= 128 {
+		t.Errorf("initial counter %x doesn't wrap around within 10 blocks", m.calls[0].args[2])
+	}
+}
+
+func TestAESCTRWrongLength(t *testing.T) {
+	m := &fakeTransactable{respond: func(cmd string, args [][]byte) ([][]byte, error) {
+		return [][]byte{make([]byte, 16)}, nil
+	}}
+	if _, err := aesCTR.Process([]byte(ctrVectorSet("AFT", false, "6b", strings.Repeat("00", 16))), m); err == nil {
+		t.Error("ciphertext longer than the plaintext was accepted")
+	}
+}
+
+// cbcCSResponder implements the ciphertext-stealing variant of AES-CBC given
+// by the command name.
+func cbcCSResponder(cmd string, args [][]byte) ([][]byte, error) {
+	block, err := aes.NewCipher(args[0])
+	if err != nil {
+		return nil, err
+	}
+	input, iv := args[1], args[2]
+	if len(input) <= aes.BlockSize {
+		out := make([]byte, len(input))
+		if strings.HasSuffix(cmd, "/encrypt") {
+			cipher.NewCBCEncrypter(block, iv).CryptBlocks(out, input)
+		} else {
+			cipher.NewCBCDecrypter(block, iv).CryptBlocks(out, input)
+		}
+		return [][]byte{out}, nil
+	}
+
+	overhang := len(input) % aes.BlockSize
+	if overhang == 0 {
+		overhang = aes.BlockSize
+	}
+	swap := strings.HasPrefix(cmd, "AES-CBC-CS3/") || (strings.HasPrefix(cmd, "AES-CBC-CS2/") && overhang != aes.BlockSize)
+	prefixLen := len(input) - aes.BlockSize - overhang
+
+	if strings.HasSuffix(cmd, "/encrypt") {
+		padded := make([]byte, prefixLen+2*aes.BlockSize)
+		copy(padded, input)
+		cipher.NewCBCEncrypter(block, iv).CryptBlocks(padded, padded)
+		penultimate := padded[prefixLen : prefixLen+overhang]
+		last := padded[prefixLen+aes.BlockSize:]
+		out := append([]byte{}, padded[:prefixLen]...)
+		if swap {
+			out = append(append(out, last...), penultimate...)
+		} else {
+			out = append(append(out, penultimate...), last...)
+		}
+		return [][]byte{out}, nil
+	}
+
+	var penultimate, last []byte
+	if swap {
+		last, penultimate = input[prefixLen:prefixLen+aes.BlockSize], input[prefixLen+aes.BlockSize:]
+	} else {
+		penultimate, last = input[prefixLen:prefixLen+overhang], input[prefixLen+overhang:]
+	}
+	decryptedLast := make([]byte, aes.BlockSize)
+	block.Decrypt(decryptedLast, last)
+	ciphertext := append(append([]byte{}, input[:prefixLen]...), penultimate...)
+	ciphertext = append(ciphertext, decryptedLast[overhang:]...)
+	out := make([]byte, len(ciphertext), len(input))
+	cipher.NewCBCDecrypter(block, iv).CryptBlocks(out, ciphertext)
+	for i := 0; i < overhang; i++ {
+		out = append(out, decryptedLast[i]^ciphertext[prefixLen+i])
+	}
+	return [][]byte{out}, nil
+}
+
+func cbcCSVectorSet(direction, input string) string {
+	field := "pt"
+	if direction == "decrypt" {
+		field = "ct"
+	}
+	return fmt.Sprintf(`{"testGroups": [{
+	"tgId": 1, "testType": "AFT", "direction": %q, "keylen": 128,
+	"tests": [{"tcId": 1, "payloadLen": %d, %q: %q, "iv": %q, "key": "636869636b656e207465726979616b69"}]
+}]}`, direction, len(input)*4, field, input, strings.Repeat("00", 16))
+}
+
+func TestAESCBCCS(t *testing.T) {
+	// The CS3 ciphertexts are test vectors from OpenSSL. CS2 orders the
+	// blocks in the same way when the input isn't a whole number of blocks,
+	// and CS1 leaves the truncated penultimate block first.
+	pt17 := "4920776f756c64206c696b652074686520"
+	pt31 := "4920776f756c64206c696b65207468652047656e6572616c20476175277320"
+	tests := []struct {
+		variant    string
+		plaintext  string
+		ciphertext string
+	}{
+		{"CS1", pt17, "97c6353568f2bf8cb4d8a580362da7ff7f"},
+		{"CS2", pt17, "c6353568f2bf8cb4d8a580362da7ff7f97"},
+		{"CS3", pt17, "c6353568f2bf8cb4d8a580362da7ff7f97"},
+		{"CS1", pt31, "97687268d6ecccc0c07b25e25ecfe5fc00783e0efdb2c1d445d4c8eff7ed22"},
+		{"CS2", pt31, "fc00783e0efdb2c1d445d4c8eff7ed2297687268d6ecccc0c07b25e25ecfe5"},
+		{"CS3", pt31, "fc00783e0efdb2c1d445d4c8eff7ed2297687268d6ecccc0c07b25e25ecfe5"},
+	}
+
+	for _, test := range tests {
+		algo := "AES-CBC-" + test.variant
+		cbcCS := &blockCipher{algo, 16, 1, false, true, iterateAESCBC}
+
+		m := &fakeTransactable{respond: cbcCSResponder}
+		result, err := cbcCS.Process([]byte(cbcCSVectorSet("encrypt", test.plaintext)), m)
+		if err != nil {
+			t.Fatalf("%s encrypting %d bytes: %s", algo, len(test.plaintext)/2, err)
+		}
+		if m.calls[0].cmd != algo+"/encrypt" {
+			t.Errorf("%s sent command %q", algo, m.calls[0].cmd)
+		}
+		if got := hex.EncodeToString(ctrCiphertext(t, result)); got != test.ciphertext {
+			t.Errorf("%s encrypting %d bytes gave %s, want %s", algo, len(test.plaintext)/2, got, test.ciphertext)
+		}
+
+		m = &fakeTransactable{respond: cbcCSResponder}
+		result, err = cbcCS.Process([]byte(cbcCSVectorSet("decrypt", test.ciphertext)), m)
+		if err != nil {
+			t.Fatalf("%s decrypting %d bytes: %s", algo, len(test.ciphertext)/2, err)
+		}
+		resultBytes, err := json.Marshal(result)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !strings.Contains(string(resultBytes), `"pt":"`+test.plaintext+`"`) {
+			t.Errorf("%s decrypting %d bytes gave %s, want plaintext %s", algo, len(test.ciphertext)/2, resultBytes, test.plaintext)
+		}
+	}
+}
+
+func TestAESCBCCSShortInput(t *testing.T) {
+	cbcCS := &blockCipher{"AES-CBC-CS1", 16, 1, false, true, iterateAESCBC}
+	m := &fakeTransactable{respond: cbcCSResponder}
+	if _, err := cbcCS.Process([]byte(cbcCSVectorSet("encrypt", strings.Repeat("00", 15))), m); err == nil {
+		t.Error("input shorter than a block was accepted")
+	}
+	if len(m.calls) != 0 {
+		t.Errorf("%d commands were sent for an invalid input", len(m.calls))
+	}
+}
+
+func mustDecodeHex(t *testing.T, s string) []byte {
+	b, err := hex.DecodeString(s)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return b
+}
+
+func cfbVectorSet(direction string, payloadBits int, input string) string {
+	field := "pt"
+	if direction == "decrypt" {
+		field = "ct"
+	}
+	return fmt.Sprintf(`{"testGroups": [{
+	"tgId": 1, "testType": "AFT", "direction": %q, "keylen": 128,
+	"tests": [{"tcId": 1, "payloadLen": %d, %q: %q, "iv": "000102030405060708090a0b0c0d0e0f", "key": "2b7e151628aed2a6abf7158809cf4f3c"}]
+}]}`, direction, payloadBits, field, input)
+}
+
+func TestAESCFB(t *testing.T) {
+	// The full-length vectors are from SP 800-38A, appendix F.3. CFB1
+	// output bits only depend on the bits before them, so a prefix of a
+	// ciphertext is the ciphertext of the prefix.
+	for _, tc := range []struct {
+		algo        string
+		payloadBits int
+		plaintext   string
+		ciphertext  string
+	}{
+		{"ACVP-AES-CFB8", 144, "6bc1bee22e409f96e93d7e117393172aae2d", "3b79424c9c0dd436bace9e0ed4586a4f32b9"},
+		{"ACVP-AES-CFB1", 16, "6bc1", "68b3"},
+		{"ACVP-AES-CFB1", 13, "6bc0", "68b0"},
+		{"ACVP-AES-CFB1", 1, "00", "00"},
+	} {
+		for _, direction := range []string{"encrypt", "decrypt"} {
+			input, want := tc.plaintext, tc.ciphertext
+			if direction == "decrypt" {
+				input, want = tc.ciphertext, tc.plaintext
+			}
+
+			m := &fakeTransactable{respond: mockResponder}
+			result, err := Primitives()[tc.algo].Process([]byte(cfbVectorSet(direction, tc.payloadBits, input)), m)
+			if err != nil {
+				t.Fatalf("%s %s of %d bits: %s", tc.algo, direction, tc.payloadBits, err)
+			}
+
+			call := m.calls[0]
+			wantArgs := 4
+			if tc.algo == "ACVP-AES-CFB1" {
+				wantArgs = 5
+			}
+			if len(call.args) != wantArgs {
+				t.Fatalf("%s %s sent %d arguments, want %d", tc.algo, direction, len(call.args), wantArgs)
+			}
+			if wantArgs == 5 && !bytes.Equal(call.args[4], uint32le(uint32(tc.payloadBits))) {
+				t.Errorf("%s %s sent bit length %x, want %d", tc.algo, direction, call.args[4], tc.payloadBits)
+			}
+
+			resultBytes, err := json.Marshal(result)
+			if err != nil {
+				t.Fatal(err)
+			}
+			field := "ct"
+			if direction == "decrypt" {
+				field = "pt"
+			}
+			if wantJSON := fmt.Sprintf(`%q:%q`, field, want); !strings.Contains(string(resultBytes), wantJSON) {
+				t.Errorf("%s %s of %d bits gave %s, want %s", tc.algo, direction, tc.payloadBits, resultBytes, wantJSON)
+			}
+		}
+	}
+}
+
+func TestAESCFB1UnusedBits(t *testing.T) {
+	// The bits after the payload are cleared before the input is sent to
+	// the module.
+	m := &fakeTransactable{respond: mockResponder}
+	if _, err := Primitives()["ACVP-AES-CFB1"].Process([]byte(cfbVectorSet("encrypt", 13, "6bc7")), m); err != nil {
+		t.Fatal(err)
+	}
+	if got := hex.EncodeToString(m.calls[0].args[1]); got != "6bc0" {
+		t.Errorf("module was given %s, want 6bc0", got)
+	}
+
+	// But the hex must still be the right length.
+	if _, err := Primitives()["ACVP-AES-CFB1"].Process([]byte(cfbVectorSet("encrypt", 13, "6b")), m); err == nil {
+		t.Error("13-bit payload in one byte was accepted")
+	}
+	if _, err := Primitives()["ACVP-AES-CFB8"].Process([]byte(cfbVectorSet("encrypt", 13, "6bc0")), m); err == nil {
+		t.Error("CFB8 accepted a payload that isn't a whole number of bytes")
+	}
+}
+
+func TestAESCFBSegmentMCT(t *testing.T) {
+	history := make([]byte, cfbHistoryBytes)
+	for i := range history {
+		history[i] = byte(i)
+	}
+
+	for _, tc := range []struct {
+		segmentBits int
+		nextInput   string
+	}{
+		// The segment before the last block of output is history[15], or,
+		// for CFB1, its least significant bit.
+		{8, "0f"},
+		{1, "80"},
+	} {
+		m := &fakeTransactable{respond: func(cmd string, args [][]byte) ([][]byte, error) {
+			return [][]byte{{0xaa}, history}, nil
+		}}
+		key := mustDecodeHex(t, "2b7e151628aed2a6abf7158809cf4f3c")
+		results, err := iterateAESCFBSegments(tc.segmentBits)(func(n int, args ...[]byte) ([][]byte, error) {
+			return m.Transact("AES-CFB/encrypt", n, args...)
+		}, true, key, []byte{0}, make([]byte, 16))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(results) != 100 {
+			t.Fatalf("got %d results, want 100", len(results))
+		}
+
+		// The key is XORed with the last 128 bits of output, which also
+		// become the IV.
+		second := results[1]
+		if want := "3b6f07053cbbc4b1b3ee0f9315d25123"; second.KeyHex != want {
+			t.Errorf("CFB%d: second key is %s, want %s", tc.segmentBits, second.KeyHex, want)
+		}
+		if want := hex.EncodeToString(history[16:]); second.IVHex != want {
+			t.Errorf("CFB%d: second IV is %s, want %s", tc.segmentBits, second.IVHex, want)
+		}
+		if second.PlaintextHex != tc.nextInput {
+			t.Errorf("CFB%d: second input is %s, want %s", tc.segmentBits, second.PlaintextHex, tc.nextInput)
+		}
+		if wantArgs := map[int]int{8: 4, 1: 5}[tc.segmentBits]; len(m.calls[0].args) != wantArgs {
+			t.Errorf("CFB%d: sent %d arguments, want %d", tc.segmentBits, len(m.calls[0].args), wantArgs)
+		}
+	}
+}
+
+func TestAESOFBMCT(t *testing.T) {
+	const keyHex = "000102030405060708090a0b0c0d0e0f"
+	const ptHex = "00112233445566778899aabbccddeeff"
+	const ivHex = "f0e0d0c0b0a090807060504030201000"
+	vectorSet := fmt.Sprintf(`{"testGroups": [{
+	"tgId": 1, "testType": "MCT", "direction": "encrypt", "keylen": 128,
+	"tests": [{"tcId": 1, "key": %q, "pt": %q, "iv": %q}]
+}]}`, keyHex, ptHex, ivHex)
+
+	m := &fakeTransactable{respond: func(cmd string, args [][]byte) ([][]byte, error) {
+		return mockmodule.New().Transact(cmd, 2, args...)
+	}}
+	result, err := Primitives()["ACVP-AES-OFB"].Process([]byte(vectorSet), m)
+	if err != nil {
+		t.Fatal(err)
+	}
+	mctResults := result.([]blockCipherTestGroupResponse)[0].Tests[0].MCTResults
+	if len(mctResults) != 100 {
+		t.Fatalf("got %d outer iterations, want 100", len(mctResults))
+	}
+
+	// Compute the first outer iteration from its definition: the keystream
+	// blocks are successive encryptions of the IV, and after the first two
+	// inner iterations each input is the ciphertext from two iterations
+	// before.
+	key, _ := hex.DecodeString(keyHex)
+	pt, _ := hex.DecodeString(ptHex)
+	iv, _ := hex.DecodeString(ivHex)
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	keystream := iv
+	var ct [1000][]byte
+	for j := range ct {
+		next := make([]byte, aes.BlockSize)
+		block.Encrypt(next, keystream)
+		keystream = next
+
+		input := pt
+		switch {
+		case j == 1:
+			input = iv
+		case j > 1:
+			input = ct[j-2]
+		}
+		ct[j] = make([]byte, aes.BlockSize)
+		for i := range input {
+			ct[j][i] = input[i] ^ keystream[i]
+		}
+	}
+
+	first, second := mctResults[0], mctResults[1]
+	if first.KeyHex != keyHex || first.PlaintextHex != ptHex || first.IVHex != ivHex {
+		t.Errorf("first iteration has inputs %+v", first)
+	}
+	if want := hex.EncodeToString(ct[999]); first.CiphertextHex != want {
+		t.Errorf("first iteration has ciphertext %s, want %s", first.CiphertextHex, want)
+	}
+
+	// A 128-bit key is XORed with the last ciphertext, which is also the next
+	// IV, and the ciphertext before it is the next plaintext.
+	nextKey := make([]byte, len(key))
+	for i := range key {
+		nextKey[i] = key[i] ^ ct[999][i]
+	}
+	if want := hex.EncodeToString(nextKey); second.KeyHex != want {
+		t.Errorf("second iteration has key %s, want %s", second.KeyHex, want)
+	}
+	if want := hex.EncodeToString(ct[999]); second.IVHex != want {
+		t.Errorf("second iteration has IV %s, want %s", second.IVHex, want)
+	}
+	if want := hex.EncodeToString(ct[998]); second.PlaintextHex != want {
+		t.Errorf("second iteration has plaintext %s, want %s", second.PlaintextHex, want)
+	}
+}
@@ -0,0 +1,67 @@
+// Copyright (c) 2026, Google Inc.
+//
+// Permission to use, copy, modify, and/or distribute this software for any
+// purpose with or without fee is hereby granted, provided that the above
+// copyright notice and this permission notice appear in all copies.
+//
+// THE SOFTWARE IS PROVIDED "AS IS" AND THE AUTHOR DISCLAIMS ALL WARRANTIES
+// WITH REGARD TO THIS SOFTWARE INCLUDING ALL IMPLIED WARRANTIES OF
+// MERCHANTABILITY AND FITNESS. IN NO EVENT SHALL THE AUTHOR BE LIABLE FOR ANY
+// SPECIAL, DIRECT, INDIRECT, OR CONSEQUENTIAL DAMAGES OR ANY DAMAGES
+// WHATSOEVER RESULTING FROM LOSS OF USE, DATA OR PROFITS, WHETHER IN AN ACTION
+// OF CONTRACT, NEGLIGENCE OR OTHER TORTIOUS ACTION, ARISING OUT OF OR IN
+// CONNECTION WITH THE USE OR PERFORMANCE OF THIS SOFTWARE.
+
+package subprocess
+
+import (
+	"sort"
+)
+
+// AlgorithmCapabilities describes what the handler for an algorithm accepts.
+// It reflects only the checks that the handler makes: the module may support
+// less, which is what its registration is for.
+type AlgorithmCapabilities struct {
+	Algorithm string `json:"algorithm"`
+	// Modes are the values of the vector set's "mode" that are supported,
+	// for algorithms that have modes.
+	Modes     []string `json:"modes,omitempty"`
+	TestTypes []string `json:"testTypes,omitempty"`
+	// Capabilities holds any other properties of the handler, named after
+	// the matching ACVP registration fields where there are some.
+	Capabilities map[string]any `json:"capabilities,omitempty"`
+}
+
+// describer is implemented by handlers that can describe their capabilities.
+// The Algorithm field of the result is filled in from the registry.
+type describer interface {
+	describe() AlgorithmCapabilities
+}
+
+// Algorithms returns an entry for every algorithm that has a handler, sorted
+// by name. Handlers that don't describe themselves only have a name.
+func Algorithms() []AlgorithmCapabilities {
+	var ret []AlgorithmCapabilities
+	for name, prim := range Primitives() {
+		var caps AlgorithmCapabilities
+		if d, ok := prim.(describer); ok {
+			caps = d.describe()
+		}
+		caps.Algorithm = name
+		ret = append(ret, caps)
+	}
+	sort.Slice(ret, func(i, j int) bool { return ret[i].Algorithm < ret[j].Algorithm })
+	return ret
+}
+
+// sortedKeys returns the keys of m whose values are true, in order.
+func sortedKeys(m map[string]bool) []string {
+	var ret []string
+	for key, ok := range m {
+		if ok {
+			ret = append(ret, key)
+		}
+	}
+	sort.Strings(ret)
+	return ret
+}
@@ -0,0 +1,48 @@
+// Copyright (c) 2026, Google Inc.
+//
+// Permission to use, copy, modify, and/or distribute this software for any
+// purpose with or without fee is hereby granted, provided that the above
+// copyright notice and this permission notice appear in all copies.
+//
+// THE SOFTWARE IS PROVIDED "AS IS" AND THE AUTHOR DISCLAIMS ALL WARRANTIES
+// WITH REGARD TO THIS SOFTWARE INCLUDING ALL IMPLIED WARRANTIES OF
+// MERCHANTABILITY AND FITNESS. IN NO EVENT SHALL THE AUTHOR BE LIABLE FOR ANY
+// SPECIAL, DIRECT, INDIRECT, OR CONSEQUENTIAL DAMAGES OR ANY DAMAGES
+// WHATSOEVER RESULTING FROM LOSS OF USE, DATA OR PROFITS, WHETHER IN AN ACTION
+// OF CONTRACT, NEGLIGENCE OR OTHER TORTIOUS ACTION, ARISING OUT OF OR IN
+// CONNECTION WITH THE USE OR PERFORMANCE OF THIS SOFTWARE.
+
+package subprocess
+
+import (
+	"reflect"
+	"sort"
+	"testing"
+)
+
+func TestAlgorithms(t *testing.T) {
+	algorithms := Algorithms()
+	if len(algorithms) != len(Primitives()) {
+		t.Errorf("got %d algorithms, but %d are registered", len(algorithms), len(Primitives()))
+	}
+	if !sort.SliceIsSorted(algorithms, func(i, j int) bool { return algorithms[i].Algorithm < algorithms[j].Algorithm }) {
+		t.Error("algorithms aren't sorted by name")
+	}
+
+	for _, caps := range algorithms {
+		if caps.Algorithm != "ECDSA" {
+			continue
+		}
+		if want := []string{"keyGen", "keyVer", "sigGen", "sigVer"}; !reflect.DeepEqual(caps.Modes, want) {
+			t.Errorf("ECDSA has modes %v, want %v", caps.Modes, want)
+		}
+		if want := []string{"P-224", "P-256", "P-384", "P-521"}; !reflect.DeepEqual(caps.Capabilities["curve"], want) {
+			t.Errorf("ECDSA has curves %v, want %v", caps.Capabilities["curve"], want)
+		}
+		if want := []string{"1.0"}; !reflect.DeepEqual(caps.Capabilities["revisions"], want) {
+			t.Errorf("ECDSA has revisions %v, want %v", caps.Capabilities["revisions"], want)
+		}
+		return
+	}
+	t.Error("ECDSA missing")
+}
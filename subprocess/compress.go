@@ -0,0 +1,163 @@
+// Copyright (c) 2026, Google Inc.
+//
+// Permission to use, copy, modify, and/or distribute this software for any
+// purpose with or without fee is hereby granted, provided that the above
+// copyright notice and this permission notice appear in all copies.
+//
+// THE SOFTWARE IS PROVIDED "AS IS" AND THE AUTHOR DISCLAIMS ALL WARRANTIES
+// WITH REGARD TO THIS SOFTWARE INCLUDING ALL IMPLIED WARRANTIES OF
+// MERCHANTABILITY AND FITNESS. IN NO EVENT SHALL THE AUTHOR BE LIABLE FOR ANY
+// SPECIAL, DIRECT, INDIRECT, OR CONSEQUENTIAL DAMAGES OR ANY DAMAGES
+// WHATSOEVER RESULTING FROM LOSS OF USE, DATA OR PROFITS, WHETHER IN AN ACTION
+// OF CONTRACT, NEGLIGENCE OR OTHER TORTIOUS ACTION, ARISING OUT OF OR IN
+// CONNECTION WITH THE USE OR PERFORMANCE OF THIS SOFTWARE.
+
+package subprocess
+
+import (
+	"bytes"
+	"compress/flate"
+	"context"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+)
+
+// Once compression has been enabled, every message in either direction
+// starts with one of these flags.
+const (
+	// messageUncompressed is followed by a message in the usual format.
+	messageUncompressed = 0
+	// messageDeflate is followed by the 32-bit, little-endian length of a
+	// DEFLATE (RFC 1951) stream and then the stream, which decompresses to
+	// a message in the usual format.
+	messageDeflate = 1
+)
+
+// compressionThreshold is the length below which messages are sent
+// uncompressed, because compressing them would save little.
+const compressionThreshold = 1024
+
+// maxCompressedLength limits the size of a compressed message. Results are
+// limited to 1GiB when decompressed anyway.
+const maxCompressedLength = 1 << 30
+
+// EnableCompression has m and the modulewrapper compress large messages to
+// each other. The modulewrapper must include "deflate" in the features of
+// the acvptool entry of its configuration, so Config must be called first.
+// EnableCompression sends the command "compression/deflate", which has no
+// arguments or results. Every later request, and every result after the
+// response to that command, starts with a flag. Call EnableCompression
+// before any other commands are outstanding.
+func (m *Subprocess) EnableCompression() error {
+	if !m.supportsDeflate {
+		return errors.New("modulewrapper does not support compression")
+	}
+
+	// This doesn't use transactAsync because a flush, which must already
+	// be compressed, has to follow the command immediately.
+	const cmd = "compression/deflate"
+	done := make(chan struct{})
+	if err := m.enqueueRead(context.Background(), pendingRead{callback: func([][]byte) error {
+		m.compressResults = true
+		close(done)
+		return nil
+	}, cmd: cmd}); err != nil {
+		return err
+	}
+	if err := m.writeCommand(cmd, nil); err != nil {
+		return err
+	}
+	m.compressRequests = true
+	if err := m.flush(); err != nil {
+		return err
+	}
+
+	select {
+	case <-done:
+		return nil
+	case <-m.aborted:
+		return m.abortErr
+	}
+}
+
+// writeMessage writes msg, which is in the usual format, to the
+// modulewrapper.
+func (m *Subprocess) writeMessage(msg []byte) error {
+	if m.compressRequests {
+		var err error
+		if msg, err = encodeMessage(msg, m.byteOrder); err != nil {
+			return err
+		}
+	}
+	if _, err := m.stdin.Write(msg); err != nil {
+		if crashed := moduleCrashed(err); crashed != nil {
+			return m.abort(crashed)
+		}
+		return err
+	}
+	return nil
+}
+
+// encodeMessage adds the flag to msg, compressing it if it's large enough
+// that that is worthwhile. The length of compressed data is in the given byte
+// order.
+func encodeMessage(msg []byte, order binary.ByteOrder) ([]byte, error) {
+	if len(msg) >= compressionThreshold {
+		var buf bytes.Buffer
+		buf.Write([]byte{messageDeflate, 0, 0, 0, 0})
+		w, err := flate.NewWriter(&buf, flate.BestSpeed)
+		if err != nil {
+			return nil, err
+		}
+		if _, err := w.Write(msg); err != nil {
+			return nil, err
+		}
+		if err := w.Close(); err != nil {
+			return nil, err
+		}
+
+		// Random data, such as signatures, doesn't compress.
+		if compressedLen := buf.Len() - 5; compressedLen < len(msg) {
+			ret := buf.Bytes()
+			order.PutUint32(ret[1:], uint32(compressedLen))
+			return ret, nil
+		}
+	}
+
+	return append([]byte{messageUncompressed}, msg...), nil
+}
+
+// decodeMessage reads the flag, and any compressed data, at the start of a
+// message from r and returns a Reader for the message in the usual format.
+// The length of compressed data is in the given byte order.
+func decodeMessage(r io.Reader, order binary.ByteOrder) (io.Reader, error) {
+	var flag [1]byte
+	if _, err := io.ReadFull(r, flag[:]); err != nil {
+		return nil, err
+	}
+
+	switch flag[0] {
+	case messageUncompressed:
+		return r, nil
+
+	case messageDeflate:
+		var lengthBytes [4]byte
+		if _, err := io.ReadFull(r, lengthBytes[:]); err != nil {
+			return nil, err
+		}
+		length := order.Uint32(lengthBytes[:])
+		if length > maxCompressedLength {
+			return nil, fmt.Errorf("compressed message too large (%d bytes)", length)
+		}
+		compressed := make([]byte, length)
+		if _, err := io.ReadFull(r, compressed); err != nil {
+			return nil, err
+		}
+		return flate.NewReader(bytes.NewReader(compressed)), nil
+
+	default:
+		return nil, fmt.Errorf("unknown message flag %d", flag[0])
+	}
+}
@@ -0,0 +1,194 @@
+// Copyright (c) 2026, Google Inc.
+//
+// Permission to use, copy, modify, and/or distribute this software for any
+// purpose with or without fee is hereby granted, provided that the above
+// copyright notice and this permission notice appear in all copies.
+//
+// THE SOFTWARE IS PROVIDED "AS IS" AND THE AUTHOR DISCLAIMS ALL WARRANTIES
+// WITH REGARD TO THIS SOFTWARE INCLUDING ALL IMPLIED WARRANTIES OF
+// MERCHANTABILITY AND FITNESS. IN NO EVENT SHALL THE AUTHOR BE LIABLE FOR ANY
+// SPECIAL, DIRECT, INDIRECT, OR CONSEQUENTIAL DAMAGES OR ANY DAMAGES
+// WHATSOEVER RESULTING FROM LOSS OF USE, DATA OR PROFITS, WHETHER IN AN ACTION
+// OF CONTRACT, NEGLIGENCE OR OTHER TORTIOUS ACTION, ARISING OUT OF OR IN
+// CONNECTION WITH THE USE OR PERFORMANCE OF THIS SOFTWARE.
+
+package subprocess
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"math/rand"
+	"strings"
+	"sync/atomic"
+	"testing"
+)
+
+// countingWriter and countingReader count the bytes that pass through them.
+type countingWriter struct {
+	io.WriteCloser
+	n *atomic.Int64
+}
+
+func (c countingWriter) Write(b []byte) (int, error) {
+	n, err := c.WriteCloser.Write(b)
+	c.n.Add(int64(n))
+	return n, err
+}
+
+type countingReader struct {
+	io.ReadCloser
+	n *atomic.Int64
+}
+
+func (c countingReader) Read(b []byte) (int, error) {
+	n, err := c.ReadCloser.Read(b)
+	c.n.Add(int64(n))
+	return n, err
+}
+
+// compressingModule runs respond as a module that supports compression, and
+// returns a Subprocess connected to it and a count of the bytes that pass
+// between them.
+func compressingModule(t testing.TB, respond func(cmd string, args [][]byte) ([][]byte, error)) (*Subprocess, *atomic.Int64) {
+	in, out := pipeModuleIO(t, func(cmd string, args [][]byte) ([][]byte, error) {
+		if cmd == "getConfig" {
+			return [][]byte{[]byte(`[{"algorithm": "acvptool", "features": ["batch", "deflate"]}]`)}, nil
+		}
+		return respond(cmd, args)
+	})
+	wireBytes := new(atomic.Int64)
+	m := NewWithIO(nil, countingWriter{in, wireBytes}, countingReader{out, wireBytes})
+	if _, err := m.Config(); err != nil {
+		t.Fatal(err)
+	}
+	return m, wireBytes
+}
+
+func echo(cmd string, args [][]byte) ([][]byte, error) {
+	return args, nil
+}
+
+func TestCompressionRoundTrip(t *testing.T) {
+	m, wireBytes := compressingModule(t, echo)
+	defer m.Close()
+	if err := m.EnableCompression(); err != nil {
+		t.Fatal(err)
+	}
+
+	// A large, compressible payload and a small one that is sent as is.
+	large := []byte(strings.Repeat("ACVP test vector ", 1<<16))
+	small := []byte("small")
+	incompressible := make([]byte, 1<<16)
+	rand.New(rand.NewSource(1)).Read(incompressible)
+
+	before := wireBytes.Load()
+	result, err := m.Transact("echo", 3, large, small, incompressible)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(result[0], large) || !bytes.Equal(result[1], small) || !bytes.Equal(result[2], incompressible) {
+		t.Error("payload changed in transit")
+	}
+	payloadLen := int64(len(large) + len(small) + len(incompressible))
+	if sent := wireBytes.Load() - before; sent > payloadLen {
+		t.Errorf("%d bytes were sent for a %d-byte payload in each direction", sent, payloadLen)
+	}
+
+	// Small messages, such as flushes, still work.
+	if result, err := m.Transact("echo", 1, small); err != nil || !bytes.Equal(result[0], small) {
+		t.Errorf("small message returned %q, %v", result, err)
+	}
+}
+
+func TestCompressionUnsupported(t *testing.T) {
+	m := pipeModule(t, 0, echo)
+	defer m.Close()
+	if err := m.EnableCompression(); err == nil {
+		t.Error("compression was enabled without the modulewrapper supporting it")
+	}
+}
+
+func TestEncodeMessage(t *testing.T) {
+	for _, msg := range [][]byte{
+		nil,
+		[]byte("short"),
+		bytes.Repeat([]byte{0}, 1<<20),
+	} {
+		encoded, err := encodeMessage(msg, binary.LittleEndian)
+		if err != nil {
+			t.Fatal(err)
+		}
+		r, err := decodeMessage(bytes.NewReader(encoded), binary.LittleEndian)
+		if err != nil {
+			t.Fatal(err)
+		}
+		decoded, err := io.ReadAll(r)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !bytes.Equal(decoded, msg) {
+			t.Errorf("%d-byte message decoded to %d bytes", len(msg), len(decoded))
+		}
+	}
+
+	if _, err := decodeMessage(bytes.NewReader([]byte{2}), binary.LittleEndian); err == nil {
+		t.Error("unknown flag was accepted")
+	}
+}
+
+// slhdsaSigGenVectorSet returns a deterministic SLH-DSA-SHAKE-256f sigGen
+// vector set with random messages.
+func slhdsaSigGenVectorSet() []byte {
+	rng := rand.New(rand.NewSource(1))
+	var vectorSet strings.Builder
+	vectorSet.WriteString(`{"algorithm": "SLH-DSA", "mode": "sigGen", "testGroups": [{"tgId": 1, "testType": "AFT",
+		"parameterSet": "SLH-DSA-SHAKE-256f", "deterministic": true, "signatureInterface": "external", "preHash": "pure", "tests": [`)
+	for i := 0; i < 20; i++ {
+		if i > 0 {
+			vectorSet.WriteString(",")
+		}
+		sk := make([]byte, 128)
+		msg := make([]byte, 4096)
+		rng.Read(sk)
+		rng.Read(msg)
+		fmt.Fprintf(&vectorSet, `{"tcId": %d, "sk": "%x", "message": "%x", "context": ""}`, i+1, sk, msg)
+	}
+	vectorSet.WriteString("]}]}")
+	return []byte(vectorSet.String())
+}
+
+// slhdsaSigner returns random SLH-DSA-SHAKE-256f signatures, which, like real
+// ones, don't compress.
+func slhdsaSigner() func(cmd string, args [][]byte) ([][]byte, error) {
+	rng := rand.New(rand.NewSource(2))
+	return func(cmd string, args [][]byte) ([][]byte, error) {
+		sig := make([]byte, 49856)
+		rng.Read(sig)
+		return [][]byte{sig}, nil
+	}
+}
+
+func benchmarkSLHDSASigGen(b *testing.B, compress bool) {
+	m, wireBytes := compressingModule(b, slhdsaSigner())
+	defer m.Close()
+	if compress {
+		if err := m.EnableCompression(); err != nil {
+			b.Fatal(err)
+		}
+	}
+	vectorSet := slhdsaSigGenVectorSet()
+
+	b.ResetTimer()
+	before := wireBytes.Load()
+	for i := 0; i < b.N; i++ {
+		if _, err := m.Process("SLH-DSA", vectorSet); err != nil {
+			b.Fatal(err)
+		}
+	}
+	b.ReportMetric(float64(wireBytes.Load()-before)/float64(b.N), "wire-bytes/op")
+}
+
+func BenchmarkSLHDSASigGenUncompressed(b *testing.B) { benchmarkSLHDSASigGen(b, false) }
+func BenchmarkSLHDSASigGenCompressed(b *testing.B)   { benchmarkSLHDSASigGen(b, true) }
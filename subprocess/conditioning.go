@@ -0,0 +1,186 @@
+// Copyright (c) 2026, Google Inc.
+//
+// Permission to use, copy, modify, and/or distribute this software for any
+// purpose with or without fee is hereby granted, provided that the above
+// copyright notice and this permission notice appear in all copies.
+//
+// THE SOFTWARE IS PROVIDED "AS IS" AND THE AUTHOR DISCLAIMS ALL WARRANTIES
+// WITH REGARD TO THIS SOFTWARE INCLUDING ALL IMPLIED WARRANTIES OF
+// MERCHANTABILITY AND FITNESS. IN NO EVENT SHALL THE AUTHOR BE LIABLE FOR ANY
+// SPECIAL, DIRECT, INDIRECT, OR CONSEQUENTIAL DAMAGES OR ANY DAMAGES
+// WHATSOEVER RESULTING FROM LOSS OF USE, DATA OR PROFITS, WHETHER IN AN ACTION
+// OF CONTRACT, NEGLIGENCE OR OTHER TORTIOUS ACTION, ARISING OUT OF OR IN
+// CONNECTION WITH THE USE OR PERFORMANCE OF THIS SOFTWARE.
+
+package subprocess
+
+import (
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+)
+
+// The following structures reflect the JSON of ACVP SP 800-90B conditioning
+// component tests. See
+// https://pages.nist.gov/ACVP/draft-hammett-acvp-cond-comp.html
+//
+// The mode of the vector set selects the vetted conditioning function of
+// SP 800-90B, section 3.1.5.1.1.
+
+type conditioningTestVectorSet struct {
+	Groups []conditioningTestGroup `json:"testGroups"`
+	Mode   string                  `json:"mode"`
+}
+
+type conditioningTestGroup struct {
+	ID   uint64 `json:"tgId"`
+	Type string `json:"testType"`
+	// HashAlg is the hash function of the HMAC and Hash_DF modes.
+	HashAlg string `json:"hashAlg"`
+	// KeyBits is the AES key length of the CMAC, CBC-MAC and BlockCipher_DF
+	// modes.
+	KeyBits    uint32 `json:"keyLen"`
+	OutputBits uint32 `json:"outLen"`
+	// NarrowestWidth is the narrowest internal width, nw, of the
+	// component. It only matters when assessing the entropy of the output,
+	// so it isn't sent to the module.
+	NarrowestWidth uint32 `json:"narrowestInternalWidth"`
+	Tests          []struct {
+		ID          uint64 `json:"tcId"`
+		KeyHex      string `json:"key"`
+		PayloadHex  string `json:"payload"`
+		PayloadBits uint64 `json:"payloadLen"`
+	} `json:"tests"`
+}
+
+type conditioningTestGroupResponse struct {
+	ID    uint64                     `json:"tgId"`
+	Tests []conditioningTestResponse `json:"tests"`
+}
+
+type conditioningTestResponse struct {
+	ID         uint64 `json:"tcId"`
+	DataOutHex string `json:"dataOut"`
+}
+
+// conditioning implements an ACVP algorithm by making requests to the
+// subprocess to run SP 800-90B conditioning components.
+type conditioning struct {
+	primitives map[string]Primitive // for looking up the size of hashes
+}
+
+func (c *conditioning) Process(vectorSet []byte, m Transactable) (any, error) {
+	var parsed conditioningTestVectorSet
+	if err := json.Unmarshal(vectorSet, &parsed); err != nil {
+		return nil, err
+	}
+
+	var ret []conditioningTestGroupResponse
+	for _, group := range parsed.Groups {
+		group := group
+		response := conditioningTestGroupResponse{ID: group.ID}
+
+		if group.Type != "AFT" {
+			return nil, fmt.Errorf("unknown test type %q in test group %d", group.Type, group.ID)
+		}
+		if group.OutputBits == 0 || group.OutputBits%8 != 0 {
+			return nil, fmt.Errorf("test group %d has unsupported output length %d", group.ID, group.OutputBits)
+		}
+		outBytes := group.OutputBits / 8
+
+		var hashSize uint32
+		switch parsed.Mode {
+		case "HMAC", "Hash_DF":
+			hash, ok := c.primitives[group.HashAlg].(*hashPrimitive)
+			if !ok {
+				return nil, fmt.Errorf("unknown hash %q in test group %d", group.HashAlg, group.ID)
+			}
+			hashSize = uint32(hash.size)
+		case "CMAC", "CBC-MAC", "BlockCipher_DF":
+			if group.KeyBits != 128 && group.KeyBits != 192 && group.KeyBits != 256 {
+				return nil, fmt.Errorf("test group %d has unsupported AES key length %d", group.ID, group.KeyBits)
+			}
+		default:
+			return nil, fmt.Errorf("unknown conditioning component %q", parsed.Mode)
+		}
+
+		// The MAC-based components output a single MAC, while the
+		// derivation functions are limited by SP 800-90A, section 10.3.
+		var maxOutBytes uint32
+		var cmd string
+		switch parsed.Mode {
+		case "HMAC":
+			cmd = "Conditioning/HMAC/" + group.HashAlg
+			maxOutBytes = hashSize
+		case "CMAC":
+			cmd = "Conditioning/CMAC/AES"
+			maxOutBytes = 16
+		case "CBC-MAC":
+			cmd = "Conditioning/CBC-MAC/AES"
+			maxOutBytes = 16
+		case "Hash_DF":
+			cmd = "Conditioning/Hash_DF/" + group.HashAlg
+			maxOutBytes = 255 * hashSize
+		case "BlockCipher_DF":
+			cmd = "Conditioning/BlockCipher_DF/AES"
+			maxOutBytes = 512 / 8
+		}
+		isMAC := parsed.Mode == "HMAC" || parsed.Mode == "CMAC" || parsed.Mode == "CBC-MAC"
+		if (isMAC && outBytes != maxOutBytes) || outBytes > maxOutBytes {
+			return nil, fmt.Errorf("test group %d requests %d bits of output, which %s doesn't support", group.ID, group.OutputBits, parsed.Mode)
+		}
+
+		for _, test := range group.Tests {
+			test := test
+			m := withTestCase(m, "ConditioningComponent", group.ID, test.ID)
+
+			if uint64(len(test.PayloadHex))*4 != test.PayloadBits {
+				return nil, fmt.Errorf("test case %d/%d contains hex payload of length %d but specifies a bit length of %d", group.ID, test.ID, len(test.PayloadHex), test.PayloadBits)
+			}
+			payload, err := hex.DecodeString(test.PayloadHex)
+			if err != nil {
+				return nil, fmt.Errorf("failed to decode payload in test case %d/%d: %s", group.ID, test.ID, err)
+			}
+
+			var args [][]byte
+			switch parsed.Mode {
+			case "HMAC", "CMAC", "CBC-MAC":
+				key, err := hex.DecodeString(test.KeyHex)
+				if err != nil {
+					return nil, fmt.Errorf("failed to decode key in test case %d/%d: %s", group.ID, test.ID, err)
+				}
+				if parsed.Mode != "HMAC" && uint32(len(key))*8 != group.KeyBits {
+					return nil, fmt.Errorf("test case %d/%d has a %d-byte key but the group uses %d-bit keys", group.ID, test.ID, len(key), group.KeyBits)
+				}
+				args = [][]byte{key, payload}
+			case "Hash_DF":
+				args = [][]byte{payload, uint32le(outBytes)}
+			case "BlockCipher_DF":
+				// Block_Cipher_df uses a fixed key, so only its length is
+				// sent.
+				args = [][]byte{uint32le(group.KeyBits / 8), payload, uint32le(outBytes)}
+			}
+
+			m.TransactAsync(cmd, 1, args, func(result [][]byte) error {
+				if len(result[0]) != int(outBytes) {
+					return fmt.Errorf("%s returned %d bytes for test case %d/%d but wanted %d", cmd, len(result[0]), group.ID, test.ID, outBytes)
+				}
+				response.Tests = append(response.Tests, conditioningTestResponse{
+					ID:         test.ID,
+					DataOutHex: hex.EncodeToString(result[0]),
+				})
+				return nil
+			})
+		}
+
+		m.Barrier(func() {
+			ret = append(ret, response)
+		})
+	}
+
+	if err := m.Flush(); err != nil {
+		return nil, err
+	}
+
+	return ret, nil
+}
@@ -0,0 +1,79 @@
+// Copyright (c) 2026, Google Inc.
+//
+// Permission to use, copy, modify, and/or distribute this software for any
+// purpose with or without fee is hereby granted, provided that the above
+// copyright notice and this permission notice appear in all copies.
+//
+// THE SOFTWARE IS PROVIDED "AS IS" AND THE AUTHOR DISCLAIMS ALL WARRANTIES
+// WITH REGARD TO THIS SOFTWARE INCLUDING ALL IMPLIED WARRANTIES OF
+// MERCHANTABILITY AND FITNESS. IN NO EVENT SHALL THE AUTHOR BE LIABLE FOR ANY
+// SPECIAL, DIRECT, INDIRECT, OR CONSEQUENTIAL DAMAGES OR ANY DAMAGES
+// WHATSOEVER RESULTING FROM LOSS OF USE, DATA OR PROFITS, WHETHER IN AN ACTION
+// OF CONTRACT, NEGLIGENCE OR OTHER TORTIOUS ACTION, ARISING OUT OF OR IN
+// CONNECTION WITH THE USE OR PERFORMANCE OF THIS SOFTWARE.
+
+package subprocess
+
+import (
+	"encoding/hex"
+	"fmt"
+	"testing"
+)
+
+// conditioningResponder returns the known outputs of RFC 4231, test case 2,
+// and RFC 4493, example 2.
+func conditioningResponder(cmd string, args [][]byte) ([][]byte, error) {
+	var key, payload, out string
+	switch cmd {
+	case "Conditioning/HMAC/SHA2-256":
+		key = hex.EncodeToString([]byte("Jefe"))
+		payload = hex.EncodeToString([]byte("what do ya want for nothing?"))
+		out = "5bdcc146bf60754e6a042426089575c75a003f089d2739839dec58b964ec3843"
+	case "Conditioning/CMAC/AES":
+		key = "2b7e151628aed2a6abf7158809cf4f3c"
+		payload = "6bc1bee22e409f96e93d7e117393172a"
+		out = "070a16b46b4d4144f79bdd9dd04a287c"
+	default:
+		return nil, fmt.Errorf("unexpected command %q", cmd)
+	}
+	if len(args) != 2 || hex.EncodeToString(args[0]) != key || hex.EncodeToString(args[1]) != payload {
+		return nil, fmt.Errorf("unexpected arguments to %q: %x", cmd, args)
+	}
+	result, _ := hex.DecodeString(out)
+	return [][]byte{result}, nil
+}
+
+func TestConditioning(t *testing.T) {
+	c := &conditioning{map[string]Primitive{"SHA2-256": &hashPrimitive{"SHA2-256", 32}}}
+
+	for _, test := range []struct {
+		mode, group, key, payload, out string
+	}{
+		{"HMAC", `"hashAlg": "SHA2-256", "outLen": 256`, hex.EncodeToString([]byte("Jefe")),
+			hex.EncodeToString([]byte("what do ya want for nothing?")), "5bdcc146bf60754e6a042426089575c75a003f089d2739839dec58b964ec3843"},
+		{"CMAC", `"keyLen": 128, "outLen": 128`, "2b7e151628aed2a6abf7158809cf4f3c",
+			"6bc1bee22e409f96e93d7e117393172a", "070a16b46b4d4144f79bdd9dd04a287c"},
+	} {
+		vectorSet := fmt.Sprintf(`{"algorithm": "ConditioningComponent", "mode": %q, "testGroups": [{
+			"tgId": 1, "testType": "AFT", %s, "narrowestInternalWidth": 128,
+			"tests": [{"tcId": 1, "key": %q, "payload": %q, "payloadLen": %d}]
+		}]}`, test.mode, test.group, test.key, test.payload, len(test.payload)*4)
+
+		result, err := c.Process([]byte(vectorSet), &fakeTransactable{respond: conditioningResponder})
+		if err != nil {
+			t.Fatalf("%s: %s", test.mode, err)
+		}
+		if out := result.([]conditioningTestGroupResponse)[0].Tests[0].DataOutHex; out != test.out {
+			t.Errorf("%s: got %s, want %s", test.mode, out, test.out)
+		}
+	}
+
+	// A MAC's output can't be stretched or truncated.
+	const tooLong = `{"algorithm": "ConditioningComponent", "mode": "CMAC", "testGroups": [{
+		"tgId": 1, "testType": "AFT", "keyLen": 128, "outLen": 256,
+		"tests": [{"tcId": 1, "key": "2b7e151628aed2a6abf7158809cf4f3c", "payload": "00", "payloadLen": 8}]
+	}]}`
+	if _, err := c.Process([]byte(tooLong), &fakeTransactable{respond: conditioningResponder}); err == nil {
+		t.Error("256-bit CMAC output was accepted")
+	}
+}
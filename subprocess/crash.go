@@ -0,0 +1,58 @@
+// Copyright (c) 2026, Google Inc.
+//
+// Permission to use, copy, modify, and/or distribute this software for any
+// purpose with or without fee is hereby granted, provided that the above
+// copyright notice and this permission notice appear in all copies.
+//
+// THE SOFTWARE IS PROVIDED "AS IS" AND THE AUTHOR DISCLAIMS ALL WARRANTIES
+// WITH REGARD TO THIS SOFTWARE INCLUDING ALL IMPLIED WARRANTIES OF
+// MERCHANTABILITY AND FITNESS. IN NO EVENT SHALL THE AUTHOR BE LIABLE FOR ANY
+// SPECIAL, DIRECT, INDIRECT, OR CONSEQUENTIAL DAMAGES OR ANY DAMAGES
+// WHATSOEVER RESULTING FROM LOSS OF USE, DATA OR PROFITS, WHETHER IN AN ACTION
+// OF CONTRACT, NEGLIGENCE OR OTHER TORTIOUS ACTION, ARISING OUT OF OR IN
+// CONNECTION WITH THE USE OR PERFORMANCE OF THIS SOFTWARE.
+
+package subprocess
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"syscall"
+)
+
+// ErrModuleCrashed is matched by the errors from processing a vector set if
+// the modulewrapper exits, or closes its pipes, before answering every
+// command. The Subprocess can't be used again after that, but the vector set
+// can be processed from the start with a new one.
+var ErrModuleCrashed = errors.New("modulewrapper exited unexpectedly")
+
+// moduleCrashed returns an error that matches ErrModuleCrashed if err, from
+// reading or writing the pipes to the modulewrapper, means that it has gone
+// away, and nil otherwise.
+func moduleCrashed(err error) error {
+	if errors.Is(err, io.EOF) || errors.Is(err, io.ErrUnexpectedEOF) ||
+		errors.Is(err, io.ErrClosedPipe) || errors.Is(err, syscall.EPIPE) {
+		return fmt.Errorf("%w: %s", ErrModuleCrashed, err)
+	}
+	return nil
+}
+
+// crashedError is an error from a handler of a Subprocess that has crashed.
+// Handlers may have formatted the underlying error as a string, so it matches
+// ErrModuleCrashed itself.
+type crashedError struct {
+	err error
+}
+
+func (e *crashedError) Error() string {
+	return e.err.Error()
+}
+
+func (e *crashedError) Unwrap() error {
+	return e.err
+}
+
+func (e *crashedError) Is(target error) bool {
+	return target == ErrModuleCrashed
+}
@@ -0,0 +1,233 @@
+// Copyright (c) 2026, Google Inc.
+//
+// Permission to use, copy, modify, and/or distribute this software for any
+// purpose with or without fee is hereby granted, provided that the above
+// copyright notice and this permission notice appear in all copies.
+//
+// THE SOFTWARE IS PROVIDED "AS IS" AND THE AUTHOR DISCLAIMS ALL WARRANTIES
+// WITH REGARD TO THIS SOFTWARE INCLUDING ALL IMPLIED WARRANTIES OF
+// MERCHANTABILITY AND FITNESS. IN NO EVENT SHALL THE AUTHOR BE LIABLE FOR ANY
+// SPECIAL, DIRECT, INDIRECT, OR CONSEQUENTIAL DAMAGES OR ANY DAMAGES
+// WHATSOEVER RESULTING FROM LOSS OF USE, DATA OR PROFITS, WHETHER IN AN ACTION
+// OF CONTRACT, NEGLIGENCE OR OTHER TORTIOUS ACTION, ARISING OUT OF OR IN
+// CONNECTION WITH THE USE OR PERFORMANCE OF THIS SOFTWARE.
+
+package subprocess
+
+import (
+	"encoding/binary"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+)
+
+// The following structures reflect the JSON of ACVP cSHAKE tests. See
+// https://pages.nist.gov/ACVP/draft-celi-acvp-cshake.html#name-test-vectors
+
+type cShakeTestVectorSet struct {
+	Groups []cShakeTestGroup `json:"testGroups"`
+}
+
+type cShakeTestGroup struct {
+	ID               uint64 `json:"tgId"`
+	Type             string `json:"testType"`
+	HexCustomization bool   `json:"hexCustomization"`
+	MaxOutLenBits    uint32 `json:"maxOutLen"`
+	MinOutLenBits    uint32 `json:"minOutLen"`
+	OutLenIncrement  uint32 `json:"outLenIncrement"`
+	Tests            []struct {
+		ID               uint64 `json:"tcId"`
+		BitLength        uint64 `json:"len"`
+		BitOutLength     uint32 `json:"outLen"`
+		MsgHex           string `json:"msg"`
+		FunctionName     string `json:"functionName"`
+		FunctionNameHex  string `json:"hexFunctionName"`
+		Customization    string `json:"customization"`
+		CustomizationHex string `json:"customizationHex"`
+	} `json:"tests"`
+}
+
+type cShakeTestGroupResponse struct {
+	ID    uint64               `json:"tgId"`
+	Tests []cShakeTestResponse `json:"tests"`
+}
+
+type cShakeTestResponse struct {
+	ID         uint64         `json:"tcId"`
+	DigestHex  string         `json:"md,omitempty"`
+	OutputLen  uint32         `json:"outLen,omitempty"`
+	MCTResults []xofMCTResult `json:"resultsArray,omitempty"`
+}
+
+// cShake implements an ACVP algorithm by making requests to the subprocess to
+// hash strings with cSHAKE.
+type cShake struct {
+	// algo is the ACVP name for this algorithm and also the command name
+	// given to the subprocess.
+	algo string
+}
+
+// decodeFunctionName returns the function name for a test case. Like the
+// customization string, ACVP may send it either as a plain string or, for
+// names that aren't printable, in hex under a separate field. The decoded
+// bytes are passed to the module unchanged.
+func decodeFunctionName(functionName, functionNameHex string) ([]byte, error) {
+	if len(functionNameHex) == 0 {
+		return []byte(functionName), nil
+	}
+	if len(functionName) != 0 {
+		return nil, fmt.Errorf("both string function name %q and hex function name %q given", functionName, functionNameHex)
+	}
+	return hex.DecodeString(functionNameHex)
+}
+
+// checkMCTIteration returns an error if the output of iteration i of a
+// cSHAKE or KMAC Monte Carlo test, or the output length that the module chose
+// for the next iteration, is outside of the group's range. Lengths are in
+// bytes. An empty output would otherwise become the empty message of the next
+// iteration, which some modules mishandle.
+func checkMCTIteration(i int, output, nextOutLen []byte, minOutLen, maxOutLen uint32) error {
+	if len(output) == 0 || uint32(len(output)) < minOutLen || uint32(len(output)) > maxOutLen {
+		return fmt.Errorf("iteration %d returned a %d-byte output, outside of %d-%d bytes", i, len(output), minOutLen, maxOutLen)
+	}
+	if len(nextOutLen) != 4 {
+		return fmt.Errorf("iteration %d returned a %d-byte output length", i, len(nextOutLen))
+	}
+	if next := binary.LittleEndian.Uint32(nextOutLen); next < minOutLen || next > maxOutLen {
+		return fmt.Errorf("iteration %d returned output length %d for the next iteration, outside of %d-%d bytes", i, next, minOutLen, maxOutLen)
+	}
+	return nil
+}
+
+func (h *cShake) describe() AlgorithmCapabilities {
+	return AlgorithmCapabilities{
+		TestTypes: []string{"AFT", "MCT"},
+		Capabilities: map[string]any{
+			"xof":              true,
+			"hexCustomization": []bool{false, true},
+		},
+	}
+}
+
+func (h *cShake) Process(vectorSet []byte, m Transactable) (any, error) {
+	var parsed cShakeTestVectorSet
+	if err := json.Unmarshal(vectorSet, &parsed); err != nil {
+		return nil, err
+	}
+
+	var ret []cShakeTestGroupResponse
+	// See
+	// https://pages.nist.gov/ACVP/draft-celi-acvp-cshake.html#name-test-types
+	// for details about the tests.
+	for _, group := range parsed.Groups {
+		group := group
+		response := cShakeTestGroupResponse{
+			ID: group.ID,
+		}
+
+		for _, test := range group.Tests {
+			test := test
+			m := withTestCase(m, h.algo, group.ID, test.ID)
+
+			if uint64(len(test.MsgHex))*4 != test.BitLength {
+				return nil, fmt.Errorf("test case %d/%d contains hex message of length %d but specifies a bit length of %d", group.ID, test.ID, len(test.MsgHex), test.BitLength)
+			}
+			msg, err := hex.DecodeString(test.MsgHex)
+			if err != nil {
+				return nil, fmt.Errorf("failed to decode hex in test case %d/%d: %s", group.ID, test.ID, err)
+			}
+
+			if test.BitOutLength%8 != 0 {
+				return nil, fmt.Errorf("test case %d/%d has bit length %d - fractional bytes not supported", group.ID, test.ID, test.BitOutLength)
+			}
+
+			functionName, err := decodeFunctionName(test.FunctionName, test.FunctionNameHex)
+			if err != nil {
+				return nil, testCaseInputError(h.algo, group.ID, test.ID, fmt.Errorf("failed to decode function name: %w", err))
+			}
+
+			customization, err := decodeCustomization(group.HexCustomization, test.Customization, test.CustomizationHex)
+			if err != nil {
+				return nil, testCaseInputError(h.algo, group.ID, test.ID, fmt.Errorf("failed to decode customization: %w", err))
+			}
+
+			switch group.Type {
+			case "AFT":
+				if err := checkOutLen(test.BitOutLength, group.MinOutLenBits, group.MaxOutLenBits, group.OutLenIncrement); err != nil {
+					return nil, fmt.Errorf("test case %d/%d: %s", group.ID, test.ID, err)
+				}
+				outBytes := int(test.BitOutLength / 8)
+				m.TransactAsync(h.algo, 1, [][]byte{msg, uint32le(test.BitOutLength / 8), functionName, customization}, func(result [][]byte) error {
+					if len(result[0]) != outBytes {
+						return fmt.Errorf("%s operation returned %d bytes but wanted %d", h.algo, len(result[0]), outBytes)
+					}
+					response.Tests = append(response.Tests, cShakeTestResponse{
+						ID:        test.ID,
+						DigestHex: hex.EncodeToString(result[0]),
+						OutputLen: uint32(len(result[0]) * 8),
+					})
+					return nil
+				})
+			case "MCT":
+				testResponse := cShakeTestResponse{ID: test.ID}
+
+				if group.MinOutLenBits%8 != 0 {
+					return nil, fmt.Errorf("MCT test group %d has min output length %d - fractional bytes not supported", group.ID, group.MinOutLenBits)
+				}
+				if group.MaxOutLenBits%8 != 0 {
+					return nil, fmt.Errorf("MCT test group %d has max output length %d - fractional bytes not supported", group.ID, group.MaxOutLenBits)
+				}
+				if group.OutLenIncrement%8 != 0 {
+					return nil, fmt.Errorf("MCT test group %d has output length increment %d - fractional bytes not supported", group.ID, group.OutLenIncrement)
+				}
+				// As for SHAKE, the next output length is taken from the
+				// last 16 bits of each output.
+				if group.MinOutLenBits < 16 || group.MinOutLenBits > group.MaxOutLenBits {
+					return nil, fmt.Errorf("MCT test group %d has invalid output lengths %d-%d", group.ID, group.MinOutLenBits, group.MaxOutLenBits)
+				}
+
+				digest := msg
+				minOutLenBytes := uint32le(group.MinOutLenBits / 8)
+				maxOutLenBytes := uint32le(group.MaxOutLenBits / 8)
+				outLenIncrementBytes := uint32le(group.OutLenIncrement / 8)
+				outputLenBytes := uint32le(group.MaxOutLenBits / 8)
+
+				// The module runs the inner loop and returns the customization
+				// string for the next iteration, which is derived from the
+				// previous output. The function name is fixed for the whole
+				// test and is empty for cSHAKE itself, but it is sent so that
+				// modules reusing this command for other customizable XOFs can
+				// tell them apart.
+				for i := 0; i < 100; i++ {
+					args := [][]byte{digest, minOutLenBytes, maxOutLenBytes, outputLenBytes, outLenIncrementBytes, functionName, customization}
+					result, err := m.Transact(h.algo+"/MCT", 3, args...)
+					if err != nil {
+						return nil, fmt.Errorf("%s MCT operation failed for test case %d/%d: %w", h.algo, group.ID, test.ID, err)
+					}
+					if err := checkMCTIteration(i, result[0], result[1], group.MinOutLenBits/8, group.MaxOutLenBits/8); err != nil {
+						return nil, fmt.Errorf("%s MCT operation for test case %d/%d: %s", h.algo, group.ID, test.ID, err)
+					}
+
+					digest = result[0]
+					outputLenBytes = uint32le(binary.LittleEndian.Uint32(result[1]))
+					customization = result[2]
+					testResponse.MCTResults = append(testResponse.MCTResults, newXOFMCTResult(digest))
+				}
+
+				response.Tests = append(response.Tests, testResponse)
+			default:
+				return nil, fmt.Errorf("test group %d has unknown type %q", group.ID, group.Type)
+			}
+		}
+
+		m.Barrier(func() {
+			ret = append(ret, response)
+		})
+	}
+
+	if err := m.Flush(); err != nil {
+		return nil, err
+	}
+
+	return ret, nil
+}
@@ -0,0 +1,281 @@
+// Copyright (c) 2026, Google Inc.
+//
+// Permission to use, copy, modify, and/or distribute this software for any
+// purpose with or without fee is hereby granted, provided that the above
+// copyright notice and this permission notice appear in all copies.
+//
+// THE SOFTWARE IS PROVIDED "AS IS" AND THE AUTHOR DISCLAIMS ALL WARRANTIES
+// WITH REGARD TO THIS SOFTWARE INCLUDING ALL IMPLIED WARRANTIES OF
+// MERCHANTABILITY AND FITNESS. IN NO EVENT SHALL THE AUTHOR BE LIABLE FOR ANY
+// SPECIAL, DIRECT, INDIRECT, OR CONSEQUENTIAL DAMAGES OR ANY DAMAGES
+// WHATSOEVER RESULTING FROM LOSS OF USE, DATA OR PROFITS, WHETHER IN AN ACTION
+// OF CONTRACT, NEGLIGENCE OR OTHER TORTIOUS ACTION, ARISING OUT OF OR IN
+// CONNECTION WITH THE USE OR PERFORMANCE OF THIS SOFTWARE.
+
+package subprocess
+
+import (
+	"bytes"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/cpu/acvptool/subprocess/internal/mockmodule"
+	"golang.org/x/crypto/sha3"
+)
+
+const cShakeMCTVectorSet = `{"testGroups": [{
+	"tgId": 1,
+	"testType": "MCT",
+	"minOutLen": 16,
+	"maxOutLen": 64,
+	"outLenIncrement": 8,
+	"tests": [{"tcId": 1, "len": 32, "msg": "00010203", "functionName": "fn", "customization": ""}]
+}]}`
+
+// cShakeMCTResponder answers cSHAKE MCT commands by echoing a fixed digest,
+// the requested output length and the customization string.
+func cShakeMCTResponder(cmd string, args [][]byte) ([][]byte, error) {
+	return [][]byte{bytes.Repeat([]byte{0xaa}, 8), args[3], args[6]}, nil
+}
+
+func TestCShakeMCTFunctionName(t *testing.T) {
+	m := &fakeTransactable{respond: cShakeMCTResponder}
+	h := &cShake{"cSHAKE-128"}
+	if _, err := h.Process([]byte(cShakeMCTVectorSet), m); err != nil {
+		t.Fatal(err)
+	}
+
+	if len(m.calls) != 100 {
+		t.Fatalf("got %d MCT calls, want 100", len(m.calls))
+	}
+	for i, call := range m.calls {
+		if call.cmd != "cSHAKE-128/MCT" {
+			t.Errorf("call %d: got command %q", i, call.cmd)
+		}
+		if len(call.args) != 7 || string(call.args[5]) != "fn" {
+			t.Errorf("call %d: function name missing from args %x", i, call.args)
+		}
+	}
+}
+
+func TestCShakeMCTTransactFailure(t *testing.T) {
+	const failAt = 42
+	m := &fakeTransactable{}
+	m.respond = func(cmd string, args [][]byte) ([][]byte, error) {
+		if len(m.calls) == failAt {
+			return nil, errors.New("module exited")
+		}
+		return cShakeMCTResponder(cmd, args)
+	}
+
+	h := &cShake{"cSHAKE-128"}
+	if _, err := h.Process([]byte(cShakeMCTVectorSet), m); err == nil {
+		t.Fatal("Process succeeded despite a failed MCT transaction")
+	}
+	if len(m.calls) != failAt {
+		t.Errorf("got %d MCT calls, want processing to stop after %d", len(m.calls), failAt)
+	}
+}
+
+func TestCShakeAFTOutLenRange(t *testing.T) {
+	for _, tc := range []struct {
+		name   string
+		outLen int
+		ok     bool
+	}{
+		{"minimum", 16, true},
+		{"aligned", 40, true},
+		{"maximum", 64, true},
+		{"below minimum", 8, false},
+		{"above maximum", 72, false},
+		{"not aligned", 32, false},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			vectorSet := fmt.Sprintf(`{"testGroups": [{
+	"tgId": 1,
+	"testType": "AFT",
+	"minOutLen": 16,
+	"maxOutLen": 64,
+	"outLenIncrement": 24,
+	"tests": [{"tcId": 1, "len": 32, "msg": "00010203", "outLen": %d, "functionName": "", "customization": ""}]
+}]}`, tc.outLen)
+
+			m := &fakeTransactable{respond: func(cmd string, args [][]byte) ([][]byte, error) {
+				return [][]byte{make([]byte, tc.outLen/8)}, nil
+			}}
+			h := &cShake{"cSHAKE-128"}
+			_, err := h.Process([]byte(vectorSet), m)
+			if tc.ok {
+				if err != nil {
+					t.Fatal(err)
+				}
+				if len(m.calls) != 1 {
+					t.Errorf("got %d calls, want 1", len(m.calls))
+				}
+				return
+			}
+			if err == nil {
+				t.Fatalf("Process accepted an output length of %d", tc.outLen)
+			}
+			if len(m.calls) != 0 {
+				t.Errorf("got %d calls, want none for an invalid output length", len(m.calls))
+			}
+		})
+	}
+}
+
+func TestCShakeAFTResultLength(t *testing.T) {
+	// The module returns one byte fewer than the requested 64 bits.
+	m := &fakeTransactable{respond: func(cmd string, args [][]byte) ([][]byte, error) {
+		return [][]byte{make([]byte, 7)}, nil
+	}}
+	vectorSet := strings.Replace(cShakeAFTVectorSet, `"outLen": 32`, `"outLen": 64`, 1)
+	_, err := (&cShake{"cSHAKE-128"}).Process([]byte(vectorSet), m)
+	if err == nil || !strings.Contains(err.Error(), "returned 7 bytes but wanted 8") {
+		t.Errorf("short result reported as %v", err)
+	}
+}
+
+// TestCShakeKnownAnswers runs cSHAKE AFTs against the mock module and checks
+// the digests against the samples in NIST's cSHAKE example values.
+func TestCShakeKnownAnswers(t *testing.T) {
+	var long strings.Builder
+	for i := 0; i < 200; i++ {
+		fmt.Fprintf(&long, "%02x", i)
+	}
+
+	for _, tc := range []struct {
+		algo   string
+		msgHex string
+		outLen int
+		want   string
+	}{
+		{"cSHAKE-128", "00010203", 256, "c1c36925b6409a04f1b504fcbca9d82b4017277cb5ed2b2065fc1d3814d5aaf5"},
+		{"cSHAKE-128", long.String(), 256, "c5221d50e4f822d96a2e8881a961420f294b7b24fe3d2094baed2c6524cc166b"},
+		{"cSHAKE-256", "00010203", 512, "d008828e2b80ac9d2218ffee1d070c48b8e4c87bff32c9699d5b6896eee0edd164020e2be0560858d9c00c037e34a96937c561a74c412bb4c746469527281c8c"},
+		{"cSHAKE-256", long.String(), 512, "07dc27b11e51fbac75bc7b3c1d983e8b4b85fb1defaf218912ac86430273091727f42b17ed1df63e8ec118f04b23633c1dfb1574c8fb55cb45da8e25afb092bb"},
+	} {
+		vectorSet := fmt.Sprintf(`{"testGroups": [{
+			"tgId": 1,
+			"testType": "AFT",
+			"minOutLen": 16,
+			"maxOutLen": 512,
+			"outLenIncrement": 8,
+			"tests": [{"tcId": 1, "len": %d, "outLen": %d, "msg": %q, "functionName": "", "customization": "Email Signature"}]
+		}]}`, len(tc.msgHex)*4, tc.outLen, tc.msgHex)
+
+		h := &cShake{tc.algo}
+		result, err := h.Process([]byte(vectorSet), mockmodule.New())
+		if err != nil {
+			t.Fatalf("%s: %s", tc.algo, err)
+		}
+		groups := result.([]cShakeTestGroupResponse)
+		if len(groups) != 1 || len(groups[0].Tests) != 1 {
+			t.Fatalf("%s: got results %+v", tc.algo, groups)
+		}
+		if got := groups[0].Tests[0].DigestHex; got != tc.want {
+			t.Errorf("%s of %d-byte message: got %s, want %s", tc.algo, len(tc.msgHex)/2, got, tc.want)
+		}
+	}
+}
+
+// mockResponder answers commands with a mock module, so that a
+// fakeTransactable can record the arguments that a handler sends.
+func mockResponder(cmd string, args [][]byte) ([][]byte, error) {
+	return mockmodule.New().Transact(cmd, 1, args...)
+}
+
+func TestCShakeEmptyMessage(t *testing.T) {
+	customized := sha3.NewCShake128(nil, []byte("Email Signature"))
+	customizedDigest := make([]byte, 32)
+	customized.Read(customizedDigest)
+
+	for _, tc := range []struct {
+		name          string
+		customization string
+		want          string
+	}{
+		// With no function name or customization, cSHAKE is SHAKE, so this
+		// is SHAKE128 of the empty string.
+		{"without customization", "", "7f9c2ba4e88f827d616045507605853ed73b8093f6efbc88eb1a6eacfa66ef26"},
+		{"with customization", "Email Signature", hex.EncodeToString(customizedDigest)},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			vectorSet := fmt.Sprintf(`{"testGroups": [{
+	"tgId": 1,
+	"testType": "AFT",
+	"minOutLen": 16,
+	"maxOutLen": 256,
+	"outLenIncrement": 8,
+	"tests": [{"tcId": 1, "len": 0, "outLen": 256, "msg": "", "functionName": "", "customization": %q}]
+}]}`, tc.customization)
+
+			m := &fakeTransactable{respond: mockResponder}
+			result, err := (&cShake{"cSHAKE-128"}).Process([]byte(vectorSet), m)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(m.calls) != 1 {
+				t.Fatalf("got %d calls, want 1", len(m.calls))
+			}
+			// The message is present but empty, rather than missing.
+			if msg := m.calls[0].args[0]; msg == nil || len(msg) != 0 {
+				t.Errorf("module was given message %#v, want an empty one", msg)
+			}
+			if got := result.([]cShakeTestGroupResponse)[0].Tests[0].DigestHex; got != tc.want {
+				t.Errorf("got digest %s, want %s", got, tc.want)
+			}
+		})
+	}
+}
+
+func TestCShakeMCTOutputLength(t *testing.T) {
+	for _, tc := range []struct {
+		name    string
+		respond func(i int, args [][]byte) [][]byte
+		wantErr string
+	}{
+		{
+			"empty digest",
+			func(i int, args [][]byte) [][]byte {
+				if i == 42 {
+					return [][]byte{nil, args[3], args[6]}
+				}
+				return [][]byte{bytes.Repeat([]byte{0xaa}, 8), args[3], args[6]}
+			},
+			"iteration 42 returned a 0-byte output",
+		},
+		{
+			"next length too short",
+			func(i int, args [][]byte) [][]byte {
+				return [][]byte{bytes.Repeat([]byte{0xaa}, 8), uint32le(1), args[6]}
+			},
+			"iteration 0 returned output length 1 for the next iteration",
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			m := &fakeTransactable{}
+			m.respond = func(cmd string, args [][]byte) ([][]byte, error) {
+				return tc.respond(len(m.calls)-1, args), nil
+			}
+			_, err := (&cShake{"cSHAKE-128"}).Process([]byte(cShakeMCTVectorSet), m)
+			if err == nil || !strings.Contains(err.Error(), tc.wantErr) {
+				t.Fatalf("got error %v, want one containing %q", err, tc.wantErr)
+			}
+		})
+	}
+}
+
+func TestCShakeMCTMinOutLen(t *testing.T) {
+	vectorSet := strings.Replace(cShakeMCTVectorSet, `"minOutLen": 16`, `"minOutLen": 0`, 1)
+	m := &fakeTransactable{respond: cShakeMCTResponder}
+	if _, err := (&cShake{"cSHAKE-128"}).Process([]byte(vectorSet), m); err == nil || !strings.Contains(err.Error(), "invalid output lengths 0-64") {
+		t.Fatalf("got error %v, want one for the output lengths", err)
+	}
+	if len(m.calls) != 0 {
+		t.Errorf("got %d calls, want none", len(m.calls))
+	}
+}
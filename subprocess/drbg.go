@@ -73,6 +73,15 @@ type drbg struct {
 	modes map[string]bool // the supported underlying primitives for the DRBG
 }
 
+// ctrDRBGSeedBits gives the seed length of CTR_DRBG for each block cipher. See
+// SP 800-90A, table 3. Without a derivation function, the entropy input must
+// be exactly this long.
+var ctrDRBGSeedBits = map[string]uint64{
+	"AES-128": 256,
+	"AES-192": 320,
+	"AES-256": 384,
+}
+
 func (d *drbg) Process(vectorSet []byte, m Transactable) (any, error) {
 	var parsed drbgTestVectorSet
 	if err := json.Unmarshal(vectorSet, &parsed); err != nil {
@@ -97,8 +106,22 @@ func (d *drbg) Process(vectorSet []byte, m Transactable) (any, error) {
 			return nil, fmt.Errorf("Test group %d requests %d-bit outputs, but fractional-bytes are not supported", group.ID, group.RetBits)
 		}
 
+		// Only CTR_DRBG can be used without a derivation function, so only
+		// its commands take a final argument saying whether to use one.
+		var derFunc [][]byte
+		if d.algo == "ctrDRBG" {
+			var useDerFunc uint32
+			if group.UseDerivationFunction {
+				useDerFunc = 1
+			} else if seedBits := ctrDRBGSeedBits[group.Mode]; group.EntropyBits != seedBits {
+				return nil, fmt.Errorf("test group %d has %d-bit entropy input, but %s without a derivation function requires the %d-bit seed length", group.ID, group.EntropyBits, group.Mode, seedBits)
+			}
+			derFunc = [][]byte{uint32le(useDerFunc)}
+		}
+
 		for _, test := range group.Tests {
 			test := test
+			m := withTestCase(m, d.algo, group.ID, test.ID)
 
 			ent, err := extractField(test.EntropyHex, group.EntropyBits)
 			if err != nil {
@@ -151,6 +174,8 @@ func (d *drbg) Process(vectorSet []byte, m Transactable) (any, error) {
 				args = [][]byte{outLenBytes[:], ent, perso, a1, a2, nonce}
 			}
 
+			args = append(args, derFunc...)
+
 			m.TransactAsync(cmd, 1, args, func(result [][]byte) error {
 				if l := uint64(len(result[0])); l != outLen {
 					return fmt.Errorf("wrong length DRBG result: %d bytes but wanted %d", l, outLen)
@@ -0,0 +1,136 @@
+// Copyright (c) 2026, Google Inc.
+//
+// Permission to use, copy, modify, and/or distribute this software for any
+// purpose with or without fee is hereby granted, provided that the above
+// copyright notice and this permission notice appear in all copies.
+//
+// THE SOFTWARE IS PROVIDED "AS IS" AND THE AUTHOR DISCLAIMS ALL WARRANTIES
+// WITH REGARD TO THIS SOFTWARE INCLUDING ALL IMPLIED WARRANTIES OF
+// MERCHANTABILITY AND FITNESS. IN NO EVENT SHALL THE AUTHOR BE LIABLE FOR ANY
+// SPECIAL, DIRECT, INDIRECT, OR CONSEQUENTIAL DAMAGES OR ANY DAMAGES
+// WHATSOEVER RESULTING FROM LOSS OF USE, DATA OR PROFITS, WHETHER IN AN ACTION
+// OF CONTRACT, NEGLIGENCE OR OTHER TORTIOUS ACTION, ARISING OUT OF OR IN
+// CONNECTION WITH THE USE OR PERFORMANCE OF THIS SOFTWARE.
+
+package subprocess
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+	"testing"
+)
+
+func TestHashDRBGInputOrder(t *testing.T) {
+	const other = `[{"intendedUse": "%s", "additionalInput": "a1", "entropyInput": "%s"},
+			{"intendedUse": "generate", "additionalInput": "a2", "entropyInput": "%s"},
+			{"intendedUse": "generate", "additionalInput": "a3", "entropyInput": ""}]`
+
+	tests := []struct {
+		name        string
+		predResist  bool
+		reseed      bool
+		otherInputs string
+		wantCmd     string
+		wantArgs    [][]byte
+	}{
+		{
+			name:       "prediction resistance",
+			predResist: true,
+			otherInputs: `[{"intendedUse": "generate", "additionalInput": "a1", "entropyInput": "e1"},
+				{"intendedUse": "generate", "additionalInput": "a2", "entropyInput": "e2"}]`,
+			wantCmd:  "hashDRBG-pr/SHA2-256",
+			wantArgs: [][]byte{uint32le(4), {0xe0}, {0x50}, {0xa1}, {0xe1}, {0xa2}, {0xe2}, {0x0e}},
+		},
+		{
+			name:        "reseed",
+			reseed:      true,
+			otherInputs: fmt.Sprintf(other, "reSeed", "e1", ""),
+			wantCmd:     "hashDRBG-reseed/SHA2-256",
+			wantArgs:    [][]byte{uint32le(4), {0xe0}, {0x50}, {0xa1}, {0xe1}, {0xa2}, {0xa3}, {0x0e}},
+		},
+	}
+
+	for _, test := range tests {
+		vectorSet := fmt.Sprintf(`{"testGroups": [{
+			"tgId": 1, "mode": "SHA2-256", "predResistance": %t, "reSeed": %t,
+			"entropyInputLen": 8, "nonceLen": 8, "persoStringLen": 8, "additionalInputLen": 8, "returnedBitsLen": 32,
+			"tests": [{"tcId": 1, "entropyInput": "e0", "nonce": "0e", "persoString": "50", "otherInput": %s}]
+		}]}`, test.predResist, test.reseed, test.otherInputs)
+
+		m := &fakeTransactable{respond: func(cmd string, args [][]byte) ([][]byte, error) {
+			return [][]byte{make([]byte, 4)}, nil
+		}}
+		d := &drbg{"hashDRBG", map[string]bool{"SHA2-256": true}}
+		if _, err := d.Process([]byte(vectorSet), m); err != nil {
+			t.Fatalf("%s: %s", test.name, err)
+		}
+
+		if len(m.calls) != 1 {
+			t.Fatalf("%s: got %d calls, want 1", test.name, len(m.calls))
+		}
+		call := m.calls[0]
+		if call.cmd != test.wantCmd {
+			t.Errorf("%s: got command %q, want %q", test.name, call.cmd, test.wantCmd)
+		}
+		if len(call.args) != len(test.wantArgs) {
+			t.Fatalf("%s: got %d args, want %d", test.name, len(call.args), len(test.wantArgs))
+		}
+		for i := range call.args {
+			if !bytes.Equal(call.args[i], test.wantArgs[i]) {
+				t.Errorf("%s: argument %d is %x, want %x", test.name, i, call.args[i], test.wantArgs[i])
+			}
+		}
+	}
+}
+
+func TestCTRDRBGDerivationFunction(t *testing.T) {
+	const vectorSetFmt = `{"testGroups": [{
+		"tgId": 1, "mode": "AES-128", "derFunc": %t, "predResistance": false, "reSeed": true,
+		"entropyInputLen": %d, "nonceLen": 0, "persoStringLen": 0, "additionalInputLen": 8, "returnedBitsLen": 32,
+		"tests": [{"tcId": 1, "entropyInput": "%s", "nonce": "", "persoString": "", "otherInput": [
+			{"intendedUse": "reSeed", "additionalInput": "a1", "entropyInput": "%[3]s"},
+			{"intendedUse": "generate", "additionalInput": "a2", "entropyInput": ""},
+			{"intendedUse": "generate", "additionalInput": "a3", "entropyInput": ""}
+		]}]
+	}]}`
+	respond := func(cmd string, args [][]byte) ([][]byte, error) {
+		return [][]byte{make([]byte, 4)}, nil
+	}
+	d := &drbg{"ctrDRBG", map[string]bool{"AES-128": true}}
+
+	// Without a derivation function the entropy input must be the 256-bit
+	// seed length.
+	short := fmt.Sprintf(vectorSetFmt, false, 128, strings.Repeat("e0", 16))
+	if _, err := d.Process([]byte(short), &fakeTransactable{respond: respond}); err == nil {
+		t.Error("128-bit entropy input was accepted without a derivation function")
+	}
+
+	for _, derFunc := range []bool{false, true} {
+		entropy := strings.Repeat("e0", 32)
+		m := &fakeTransactable{respond: respond}
+		if _, err := d.Process([]byte(fmt.Sprintf(vectorSetFmt, derFunc, 256, entropy)), m); err != nil {
+			t.Fatalf("derFunc %t: %s", derFunc, err)
+		}
+
+		call := m.calls[0]
+		if call.cmd != "ctrDRBG-reseed/AES-128" {
+			t.Errorf("derFunc %t: got command %q", derFunc, call.cmd)
+		}
+		// The reseed inputs precede the two generate inputs, and the
+		// derivation function flag comes last.
+		var useDerFunc uint32
+		if derFunc {
+			useDerFunc = 1
+		}
+		wantArgs := [][]byte{uint32le(4), bytes.Repeat([]byte{0xe0}, 32), {}, {0xa1}, bytes.Repeat([]byte{0xe0}, 32), {0xa2}, {0xa3}, {}, uint32le(useDerFunc)}
+		if len(call.args) != len(wantArgs) {
+			t.Fatalf("derFunc %t: got %d arguments, want %d", derFunc, len(call.args), len(wantArgs))
+		}
+		for i := range wantArgs {
+			if !bytes.Equal(call.args[i], wantArgs[i]) {
+				t.Errorf("derFunc %t: argument %d is %x, want %x", derFunc, i, call.args[i], wantArgs[i])
+			}
+		}
+	}
+}
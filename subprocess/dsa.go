@@ -0,0 +1,333 @@
+// Copyright (c) 2026, Google Inc.
+//
+// Permission to use, copy, modify, and/or distribute this software for any
+// purpose with or without fee is hereby granted, provided that the above
+// copyright notice and this permission notice appear in all copies.
+//
+// THE SOFTWARE IS PROVIDED "AS IS" AND THE AUTHOR DISCLAIMS ALL WARRANTIES
+// WITH REGARD TO THIS SOFTWARE INCLUDING ALL IMPLIED WARRANTIES OF
+// MERCHANTABILITY AND FITNESS. IN NO EVENT SHALL THE AUTHOR BE LIABLE FOR ANY
+// SPECIAL, DIRECT, INDIRECT, OR CONSEQUENTIAL DAMAGES OR ANY DAMAGES
+// WHATSOEVER RESULTING FROM LOSS OF USE, DATA OR PROFITS, WHETHER IN AN ACTION
+// OF CONTRACT, NEGLIGENCE OR OTHER TORTIOUS ACTION, ARISING OUT OF OR IN
+// CONNECTION WITH THE USE OR PERFORMANCE OF THIS SOFTWARE.
+
+package subprocess
+
+import (
+	"encoding/binary"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+)
+
+// The following structures reflect the JSON of ACVP DSA tests. See
+// https://pages.nist.gov/ACVP/draft-fussell-acvp-dsa.html#name-test-vectors
+
+type dsaTestVectorSet struct {
+	Groups []dsaTestGroup `json:"testGroups"`
+	Mode   string         `json:"mode"`
+}
+
+type dsaTestGroup struct {
+	ID     uint64 `json:"tgId"`
+	Type   string `json:"testType"`
+	L      uint32 `json:"l"`
+	N      uint32 `json:"n"`
+	Hash   string `json:"hashAlg"`
+	PQMode string `json:"pqMode"`
+	GMode  string `json:"gMode"`
+	PHex   string `json:"p"`
+	QHex   string `json:"q"`
+	GHex   string `json:"g"`
+	Tests  []struct {
+		ID            uint64 `json:"tcId"`
+		PHex          string `json:"p"`
+		QHex          string `json:"q"`
+		GHex          string `json:"g"`
+		DomainSeedHex string `json:"domainSeed"`
+		Counter       uint32 `json:"counter"`
+		PCounter      uint32 `json:"pCounter"`
+		QCounter      uint32 `json:"qCounter"`
+		IndexHex      string `json:"index"`
+		MsgHex        string `json:"message"`
+		YHex          string `json:"y"`
+		RHex          string `json:"r"`
+		SHex          string `json:"s"`
+	} `json:"tests"`
+}
+
+type dsaTestGroupResponse struct {
+	ID    uint64            `json:"tgId"`
+	PHex  string            `json:"p,omitempty"`
+	QHex  string            `json:"q,omitempty"`
+	GHex  string            `json:"g,omitempty"`
+	YHex  string            `json:"y,omitempty"`
+	Tests []dsaTestResponse `json:"tests"`
+}
+
+type dsaTestResponse struct {
+	ID            uint64  `json:"tcId"`
+	PHex          string  `json:"p,omitempty"`
+	QHex          string  `json:"q,omitempty"`
+	GHex          string  `json:"g,omitempty"`
+	DomainSeedHex string  `json:"domainSeed,omitempty"`
+	Counter       *uint32 `json:"counter,omitempty"`
+	PCounter      *uint32 `json:"pCounter,omitempty"`
+	QCounter      *uint32 `json:"qCounter,omitempty"`
+	XHex          string  `json:"x,omitempty"`
+	YHex          string  `json:"y,omitempty"`
+	RHex          string  `json:"r,omitempty"`
+	SHex          string  `json:"s,omitempty"`
+	Passed        *bool   `json:"testPassed,omitempty"`
+}
+
+// dsa implements an ACVP algorithm by making requests to the subprocess to
+// generate and verify DSA domain parameters, keys and signatures.
+type dsa struct{}
+
+// dsaParameterSizes are the (L, N) pairs permitted by FIPS 186-4, section
+// 4.2, excluding the 1024-bit size that is no longer approved.
+var dsaParameterSizes = map[[2]uint32]bool{
+	{2048, 224}: true,
+	{2048, 256}: true,
+	{3072, 256}: true,
+}
+
+// decodeCounter parses a 32-bit, little-endian counter returned by the
+// subprocess.
+func decodeCounter(b []byte) (*uint32, error) {
+	if len(b) != 4 {
+		return nil, fmt.Errorf("counter from subprocess is %d bytes long, but should be four", len(b))
+	}
+	c := binary.LittleEndian.Uint32(b)
+	return &c, nil
+}
+
+// decodeValidity parses a single-byte validity flag returned by the
+// subprocess.
+func decodeValidity(b []byte) (*bool, error) {
+	if len(b) != 1 || (b[0]&0xfe) != 0 {
+		return nil, fmt.Errorf("invalid validity result from subprocess: %x", b)
+	}
+	passed := b[0] == 1
+	return &passed, nil
+}
+
+func (d *dsa) Process(vectorSet []byte, m Transactable) (any, error) {
+	var parsed dsaTestVectorSet
+	if err := json.Unmarshal(vectorSet, &parsed); err != nil {
+		return nil, err
+	}
+
+	var ret []dsaTestGroupResponse
+	for _, group := range parsed.Groups {
+		group := group
+		response := dsaTestGroupResponse{
+			ID: group.ID,
+		}
+
+		if !dsaParameterSizes[[2]uint32{group.L, group.N}] {
+			return nil, fmt.Errorf("test group %d has unsupported parameter sizes L=%d, N=%d", group.ID, group.L, group.N)
+		}
+		l, n := uint32le(group.L), uint32le(group.N)
+
+		for _, test := range group.Tests {
+			test := test
+			m := withTestCase(m, "DSA", group.ID, test.ID)
+			testResp := dsaTestResponse{ID: test.ID}
+
+			var hexFields [][]byte
+			for _, field := range []struct {
+				name string
+				hex  string
+			}{
+				{"p", test.PHex},
+				{"q", test.QHex},
+				{"g", test.GHex},
+				{"domain seed", test.DomainSeedHex},
+				{"index", test.IndexHex},
+				{"message", test.MsgHex},
+				{"y", test.YHex},
+				{"r", test.RHex},
+				{"s", test.SHex},
+			} {
+				value, err := hex.DecodeString(field.hex)
+				if err != nil {
+					return nil, fmt.Errorf("failed to decode %s in test case %d/%d: %s", field.name, group.ID, test.ID, err)
+				}
+				hexFields = append(hexFields, value)
+			}
+			p, q, g, seed, index, msg, y, r, s := hexFields[0], hexFields[1], hexFields[2], hexFields[3], hexFields[4], hexFields[5], hexFields[6], hexFields[7], hexFields[8]
+
+			switch parsed.Mode {
+			case "pqgGen":
+				if group.Type != "GDT" {
+					return nil, fmt.Errorf("unknown test type %q in pqgGen test group %d", group.Type, group.ID)
+				}
+
+				switch {
+				case group.PQMode == "probable":
+					m.TransactAsync("DSA/pqgGen/probable", 4, [][]byte{l, n, []byte(group.Hash)}, func(result [][]byte) error {
+						counter, err := decodeCounter(result[3])
+						if err != nil {
+							return err
+						}
+						testResp.PHex = hex.EncodeToString(result[0])
+						testResp.QHex = hex.EncodeToString(result[1])
+						testResp.DomainSeedHex = hex.EncodeToString(result[2])
+						testResp.Counter = counter
+						response.Tests = append(response.Tests, testResp)
+						return nil
+					})
+				case group.PQMode == "provable":
+					m.TransactAsync("DSA/pqgGen/provable", 5, [][]byte{l, n, []byte(group.Hash)}, func(result [][]byte) error {
+						pCounter, err := decodeCounter(result[3])
+						if err != nil {
+							return err
+						}
+						qCounter, err := decodeCounter(result[4])
+						if err != nil {
+							return err
+						}
+						testResp.PHex = hex.EncodeToString(result[0])
+						testResp.QHex = hex.EncodeToString(result[1])
+						testResp.DomainSeedHex = hex.EncodeToString(result[2])
+						testResp.PCounter = pCounter
+						testResp.QCounter = qCounter
+						response.Tests = append(response.Tests, testResp)
+						return nil
+					})
+				case group.GMode == "unverifiable":
+					m.TransactAsync("DSA/pqgGen/unverifiable", 1, [][]byte{p, q}, func(result [][]byte) error {
+						testResp.GHex = hex.EncodeToString(result[0])
+						response.Tests = append(response.Tests, testResp)
+						return nil
+					})
+				case group.GMode == "canonical":
+					m.TransactAsync("DSA/pqgGen/canonical", 1, [][]byte{[]byte(group.Hash), p, q, seed, index}, func(result [][]byte) error {
+						testResp.GHex = hex.EncodeToString(result[0])
+						response.Tests = append(response.Tests, testResp)
+						return nil
+					})
+				default:
+					return nil, fmt.Errorf("test group %d has unknown pqMode %q and gMode %q", group.ID, group.PQMode, group.GMode)
+				}
+
+			case "pqgVer":
+				if group.Type != "GDT" {
+					return nil, fmt.Errorf("unknown test type %q in pqgVer test group %d", group.Type, group.ID)
+				}
+
+				var cmd string
+				var args [][]byte
+				switch {
+				case group.PQMode == "probable":
+					cmd = "DSA/pqgVer/probable"
+					args = [][]byte{[]byte(group.Hash), p, q, seed, uint32le(test.Counter)}
+				case group.PQMode == "provable":
+					cmd = "DSA/pqgVer/provable"
+					args = [][]byte{[]byte(group.Hash), p, q, seed, uint32le(test.PCounter), uint32le(test.QCounter)}
+				case group.GMode == "unverifiable":
+					cmd = "DSA/pqgVer/unverifiable"
+					args = [][]byte{p, q, g}
+				case group.GMode == "canonical":
+					cmd = "DSA/pqgVer/canonical"
+					args = [][]byte{[]byte(group.Hash), p, q, g, seed, index}
+				default:
+					return nil, fmt.Errorf("test group %d has unknown pqMode %q and gMode %q", group.ID, group.PQMode, group.GMode)
+				}
+
+				m.TransactAsync(cmd, 1, args, func(result [][]byte) error {
+					passed, err := decodeValidity(result[0])
+					if err != nil {
+						return err
+					}
+					testResp.Passed = passed
+					response.Tests = append(response.Tests, testResp)
+					return nil
+				})
+
+			case "keyGen":
+				if group.Type != "AFT" {
+					return nil, fmt.Errorf("unknown test type %q in keyGen test group %d", group.Type, group.ID)
+				}
+
+				m.TransactAsync("DSA/keyGen", 5, [][]byte{l, n}, func(result [][]byte) error {
+					if len(response.PHex) == 0 {
+						response.PHex = hex.EncodeToString(result[0])
+						response.QHex = hex.EncodeToString(result[1])
+						response.GHex = hex.EncodeToString(result[2])
+					} else if response.PHex != hex.EncodeToString(result[0]) {
+						return fmt.Errorf("module wrapper returned different DSA domain parameters for the same keyGen configuration")
+					}
+					testResp.XHex = hex.EncodeToString(result[3])
+					testResp.YHex = hex.EncodeToString(result[4])
+					response.Tests = append(response.Tests, testResp)
+					return nil
+				})
+
+			case "sigGen":
+				if group.Type != "AFT" {
+					return nil, fmt.Errorf("unknown test type %q in sigGen test group %d", group.Type, group.ID)
+				}
+
+				m.TransactAsync("DSA/sigGen/"+group.Hash, 6, [][]byte{l, n, msg}, func(result [][]byte) error {
+					if len(response.PHex) == 0 {
+						response.PHex = hex.EncodeToString(result[0])
+						response.QHex = hex.EncodeToString(result[1])
+						response.GHex = hex.EncodeToString(result[2])
+						response.YHex = hex.EncodeToString(result[3])
+					} else if response.YHex != hex.EncodeToString(result[3]) {
+						return fmt.Errorf("module wrapper returned different DSA keys for the same sigGen configuration")
+					}
+					testResp.RHex = hex.EncodeToString(result[4])
+					testResp.SHex = hex.EncodeToString(result[5])
+					response.Tests = append(response.Tests, testResp)
+					return nil
+				})
+
+			case "sigVer":
+				if group.Type != "AFT" {
+					return nil, fmt.Errorf("unknown test type %q in sigVer test group %d", group.Type, group.ID)
+				}
+
+				var domain [][]byte
+				for _, field := range []struct {
+					name string
+					hex  string
+				}{{"p", group.PHex}, {"q", group.QHex}, {"g", group.GHex}} {
+					value, err := hex.DecodeString(field.hex)
+					if err != nil {
+						return nil, fmt.Errorf("failed to decode %s in test group %d: %s", field.name, group.ID, err)
+					}
+					domain = append(domain, value)
+				}
+
+				args := [][]byte{domain[0], domain[1], domain[2], y, msg, r, s}
+				m.TransactAsync("DSA/sigVer/"+group.Hash, 1, args, func(result [][]byte) error {
+					passed, err := decodeValidity(result[0])
+					if err != nil {
+						return err
+					}
+					testResp.Passed = passed
+					response.Tests = append(response.Tests, testResp)
+					return nil
+				})
+
+			default:
+				return nil, fmt.Errorf("invalid mode %q in DSA vector set", parsed.Mode)
+			}
+		}
+
+		m.Barrier(func() {
+			ret = append(ret, response)
+		})
+	}
+
+	if err := m.Flush(); err != nil {
+		return nil, err
+	}
+
+	return ret, nil
+}
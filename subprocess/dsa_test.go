@@ -0,0 +1,92 @@
+// Copyright (c) 2026, Google Inc.
+//
+// Permission to use, copy, modify, and/or distribute this software for any
+// purpose with or without fee is hereby granted, provided that the above
+// copyright notice and this permission notice appear in all copies.
+//
+// THE SOFTWARE IS PROVIDED "AS IS" AND THE AUTHOR DISCLAIMS ALL WARRANTIES
+// WITH REGARD TO THIS SOFTWARE INCLUDING ALL IMPLIED WARRANTIES OF
+// MERCHANTABILITY AND FITNESS. IN NO EVENT SHALL THE AUTHOR BE LIABLE FOR ANY
+// SPECIAL, DIRECT, INDIRECT, OR CONSEQUENTIAL DAMAGES OR ANY DAMAGES
+// WHATSOEVER RESULTING FROM LOSS OF USE, DATA OR PROFITS, WHETHER IN AN ACTION
+// OF CONTRACT, NEGLIGENCE OR OTHER TORTIOUS ACTION, ARISING OUT OF OR IN
+// CONNECTION WITH THE USE OR PERFORMANCE OF THIS SOFTWARE.
+
+package subprocess
+
+import (
+	"bytes"
+	"testing"
+)
+
+// dsaResponder accepts domain parameters only when the counter is 7 and
+// signatures only when r equals s.
+func dsaResponder(cmd string, args [][]byte) ([][]byte, error) {
+	switch cmd {
+	case "DSA/pqgVer/probable":
+		return [][]byte{{boolToByte(bytes.Equal(args[4], uint32le(7)))}}, nil
+	case "DSA/sigVer/SHA2-256":
+		return [][]byte{{boolToByte(bytes.Equal(args[5], args[6]))}}, nil
+	}
+	panic("unexpected command " + cmd)
+}
+
+func TestDSAPQGVer(t *testing.T) {
+	const vectorSet = `{"algorithm": "DSA", "mode": "pqgVer", "testGroups": [{
+		"tgId": 1, "testType": "GDT", "l": 2048, "n": 256, "hashAlg": "SHA2-256", "pqMode": "probable",
+		"tests": [
+			{"tcId": 1, "p": "0b", "q": "05", "domainSeed": "aa", "counter": 7},
+			{"tcId": 2, "p": "0b", "q": "05", "domainSeed": "aa", "counter": 8}
+		]
+	}]}`
+
+	m := &fakeTransactable{respond: dsaResponder}
+	result, err := new(dsa).Process([]byte(vectorSet), m)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	args := m.calls[0].args
+	if !bytes.Equal(args[0], []byte("SHA2-256")) || !bytes.Equal(args[1], []byte{0x0b}) || !bytes.Equal(args[3], []byte{0xaa}) {
+		t.Errorf("unexpected pqgVer arguments: %x", args)
+	}
+
+	tests := result.([]dsaTestGroupResponse)[0].Tests
+	if tests[0].Passed == nil || !*tests[0].Passed {
+		t.Errorf("valid domain parameters were rejected: %+v", tests[0])
+	}
+	if tests[1].Passed == nil || *tests[1].Passed {
+		t.Errorf("invalid domain parameters were accepted: %+v", tests[1])
+	}
+
+	badSizes := `{"algorithm": "DSA", "mode": "pqgVer", "testGroups": [{
+		"tgId": 1, "testType": "GDT", "l": 1024, "n": 160, "hashAlg": "SHA-1", "pqMode": "probable",
+		"tests": [{"tcId": 1, "p": "0b", "q": "05", "domainSeed": "aa", "counter": 7}]
+	}]}`
+	if _, err := new(dsa).Process([]byte(badSizes), &fakeTransactable{respond: dsaResponder}); err == nil {
+		t.Error("group with L=1024, N=160 was accepted")
+	}
+}
+
+func TestDSASigVerFailure(t *testing.T) {
+	const vectorSet = `{"algorithm": "DSA", "mode": "sigVer", "testGroups": [{
+		"tgId": 1, "testType": "AFT", "l": 3072, "n": 256, "hashAlg": "SHA2-256",
+		"p": "0b", "q": "05", "g": "02",
+		"tests": [{"tcId": 1, "message": "0102", "y": "03", "r": "01", "s": "02"}]
+	}]}`
+
+	m := &fakeTransactable{respond: dsaResponder}
+	result, err := new(dsa).Process([]byte(vectorSet), m)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if args := m.calls[0].args; len(args) != 7 || !bytes.Equal(args[2], []byte{0x02}) || !bytes.Equal(args[3], []byte{0x03}) {
+		t.Errorf("unexpected sigVer arguments: %x", args)
+	}
+
+	test := result.([]dsaTestGroupResponse)[0].Tests[0]
+	if test.Passed == nil || *test.Passed {
+		t.Errorf("invalid signature was accepted: %+v", test)
+	}
+}
@@ -36,6 +36,7 @@ type ecdsaTestGroup struct {
 	SecretGenerationMode string `json:"secretGenerationMode,omitempty"`
 	HashAlgo             string `json:"hashAlg,omitEmpty"`
 	ComponentTest        bool   `json:"componentTest"`
+	Deterministic        bool   `json:"deterministic"`
 	Tests                []struct {
 		ID     uint64 `json:"tcId"`
 		QxHex  string `json:"qx,omitempty"`
@@ -70,7 +71,22 @@ type ecdsa struct {
 	// given to the subprocess to hash with this hash function.
 	algo       string
 	curves     map[string]bool // supported curve names
-	primitives map[string]primitive
+	primitives map[string]Primitive
+	// fips1864 is set for the "1.0" revision of ECDSA, which is from FIPS
+	// 186-4 and so has no deterministic signatures. Later revisions follow
+	// FIPS 186-5.
+	fips1864 bool
+}
+
+func (e *ecdsa) describe() AlgorithmCapabilities {
+	modes := []string{"keyGen", "keyVer", "sigGen", "sigVer"}
+	if e.algo == "DetECDSA" {
+		modes = []string{"sigGen"}
+	}
+	return AlgorithmCapabilities{
+		Modes:        modes,
+		Capabilities: map[string]any{"curve": sortedKeys(e.curves)},
+	}
 }
 
 func (e *ecdsa) Process(vectorSet []byte, m Transactable) (any, error) {
@@ -101,6 +117,7 @@ func (e *ecdsa) Process(vectorSet []byte, m Transactable) (any, error) {
 
 		for _, test := range group.Tests {
 			test := test
+			m := withTestCase(m, e.algo, group.ID, test.ID)
 
 			var testResp ecdsaTestResponse
 			testResp.ID = test.ID
@@ -127,6 +144,9 @@ func (e *ecdsa) Process(vectorSet []byte, m Transactable) (any, error) {
 				if err != nil {
 					return nil, fmt.Errorf("failed to decode qy in test case %d/%d: %s", group.ID, test.ID, err)
 				}
+				// The module decides whether the point is valid, so the
+				// coordinates are passed on exactly as given, even if they
+				// are longer than the field.
 				m.TransactAsync(e.algo+"/"+"keyVer", 1, [][]byte{[]byte(group.Curve), qx, qy}, func(result [][]byte) error {
 					// result[0] should be a single byte: zero if false, one if true
 					switch {
@@ -144,7 +164,14 @@ func (e *ecdsa) Process(vectorSet []byte, m Transactable) (any, error) {
 				})
 
 			case "sigGen":
-				if group.ComponentTest && parsed.Algorithm == "DetECDSA" {
+				// ECDSA groups may also request deterministic signatures (FIPS
+				// 186-5, section 6.3.2), which are then generated exactly as
+				// for DetECDSA vector sets.
+				deterministic := e.algo == "DetECDSA" || group.Deterministic
+				if deterministic && e.fips1864 {
+					return nil, fmt.Errorf("test group %d requests deterministic signatures, which FIPS 186-4 doesn't specify", group.ID)
+				}
+				if group.ComponentTest && deterministic {
 					return nil, fmt.Errorf("DetECDSA does not support component tests")
 				}
 
@@ -154,6 +181,28 @@ func (e *ecdsa) Process(vectorSet []byte, m Transactable) (any, error) {
 					return nil, fmt.Errorf("unsupported hash algorithm %q in test group %d", group.HashAlgo, group.ID)
 				}
 
+				msg, err := hex.DecodeString(test.MsgHex)
+				if err != nil {
+					return nil, fmt.Errorf("failed to decode message hex in test case %d/%d: %s", group.ID, test.ID, err)
+				}
+				op := e.algo + "/" + "sigGen"
+				if deterministic {
+					op = "DetECDSA/sigGen"
+				}
+				// The message of a component test is already a digest, which
+				// the module signs without hashing it again. If it is longer
+				// than the order of the curve, the module uses its leftmost
+				// bits, as for any ECDSA signature.
+				if group.ComponentTest {
+					if len(msg) != h.size {
+						return nil, fmt.Errorf("test case %d/%d contains message %q of length %d, but expected length %d", group.ID, test.ID, test.MsgHex, len(msg), h.size)
+					}
+					op += "/componentTest"
+				}
+
+				// The key for the group is only generated once the test case
+				// has been checked, because Validate can't see past a
+				// synchronous command.
 				if len(sigGenPrivateKey) == 0 {
 					// Ask the subprocess to generate a key for this test group.
 					cmd := e.algo + "/keyGen"
@@ -169,18 +218,6 @@ func (e *ecdsa) Process(vectorSet []byte, m Transactable) (any, error) {
 					response.QxHex = hex.EncodeToString(result[1])
 					response.QyHex = hex.EncodeToString(result[2])
 				}
-
-				msg, err := hex.DecodeString(test.MsgHex)
-				if err != nil {
-					return nil, fmt.Errorf("failed to decode message hex in test case %d/%d: %s", group.ID, test.ID, err)
-				}
-				op := e.algo + "/" + "sigGen"
-				if group.ComponentTest {
-					if len(msg) != h.size {
-						return nil, fmt.Errorf("test case %d/%d contains message %q of length %d, but expected length %d", group.ID, test.ID, test.MsgHex, len(msg), h.size)
-					}
-					op += "/componentTest"
-				}
 				m.TransactAsync(op, 2, [][]byte{[]byte(group.Curve), sigGenPrivateKey, []byte(group.HashAlgo), msg}, func(result [][]byte) error {
 					testResp.RHex = hex.EncodeToString(result[0])
 					testResp.SHex = hex.EncodeToString(result[1])
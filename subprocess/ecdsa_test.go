@@ -0,0 +1,177 @@
+// Copyright (c) 2026, Google Inc.
+//
+// Permission to use, copy, modify, and/or distribute this software for any
+// purpose with or without fee is hereby granted, provided that the above
+// copyright notice and this permission notice appear in all copies.
+//
+// THE SOFTWARE IS PROVIDED "AS IS" AND THE AUTHOR DISCLAIMS ALL WARRANTIES
+// WITH REGARD TO THIS SOFTWARE INCLUDING ALL IMPLIED WARRANTIES OF
+// MERCHANTABILITY AND FITNESS. IN NO EVENT SHALL THE AUTHOR BE LIABLE FOR ANY
+// SPECIAL, DIRECT, INDIRECT, OR CONSEQUENTIAL DAMAGES OR ANY DAMAGES
+// WHATSOEVER RESULTING FROM LOSS OF USE, DATA OR PROFITS, WHETHER IN AN ACTION
+// OF CONTRACT, NEGLIGENCE OR OTHER TORTIOUS ACTION, ARISING OUT OF OR IN
+// CONNECTION WITH THE USE OR PERFORMANCE OF THIS SOFTWARE.
+
+package subprocess
+
+import (
+	"bytes"
+	goecdsa "crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/sha256"
+	"crypto/sha512"
+	"fmt"
+	"math/big"
+	"testing"
+
+	"github.com/cpu/acvptool/subprocess/internal/mockmodule"
+)
+
+// ecdsaDeterministicResponder derives "signatures" from the key and message
+// alone, as RFC 6979 does, so that signing is repeatable.
+func ecdsaDeterministicResponder(cmd string, args [][]byte) ([][]byte, error) {
+	switch cmd {
+	case "ECDSA/keyGen":
+		return [][]byte{{0xd0}, {0x0a}, {0x0b}}, nil
+	case "DetECDSA/sigGen":
+		h := sha256.Sum256(append(append([]byte{}, args[1]...), args[3]...))
+		return [][]byte{h[:16], h[16:]}, nil
+	}
+	panic("unexpected command " + cmd)
+}
+
+func TestECDSADeterministicSigGen(t *testing.T) {
+	const vectorSet = `{"algorithm": "ECDSA", "mode": "sigGen", "testGroups": [{
+		"tgId": 1, "curve": "P-256", "hashAlg": "SHA2-256", "deterministic": true,
+		"tests": [{"tcId": 1, "message": "0102"}, {"tcId": 2, "message": "0102"}]
+	}]}`
+
+	primitives := map[string]Primitive{"SHA2-256": &hashPrimitive{"SHA2-256", 32}}
+	e := &ecdsa{"ECDSA", map[string]bool{"P-256": true}, primitives, false}
+	m := &fakeTransactable{respond: ecdsaDeterministicResponder}
+	result, err := e.Process([]byte(vectorSet), m)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, call := range m.calls[1:] {
+		if call.cmd != "DetECDSA/sigGen" {
+			t.Errorf("deterministic group was signed with %q", call.cmd)
+		}
+	}
+
+	tests := result.([]ecdsaTestGroupResponse)[0].Tests
+	if len(tests[0].RHex) == 0 || len(tests[0].SHex) == 0 {
+		t.Fatalf("signature lacks r or s: %+v", tests[0])
+	}
+	if tests[0].RHex != tests[1].RHex || tests[0].SHex != tests[1].SHex {
+		t.Errorf("signatures of the same message differ: %+v and %+v", tests[0], tests[1])
+	}
+
+	componentTest := `{"algorithm": "ECDSA", "mode": "sigGen", "testGroups": [{
+		"tgId": 1, "curve": "P-256", "hashAlg": "SHA2-256", "deterministic": true, "componentTest": true,
+		"tests": [{"tcId": 1, "message": "0102"}]
+	}]}`
+	if _, err := e.Process([]byte(componentTest), &fakeTransactable{respond: ecdsaDeterministicResponder}); err == nil {
+		t.Error("deterministic component test was accepted")
+	}
+}
+
+func TestECDSAKeyVer(t *testing.T) {
+	params := elliptic.P256().Params()
+	offCurveY := new(big.Int).Add(params.Gy, big.NewInt(1))
+	// Reduced modulo p, this is the generator, so a module that reduces
+	// coordinates instead of rejecting them would accept it.
+	unreducedX := new(big.Int).Add(params.Gx, params.P)
+
+	for _, tc := range []struct {
+		name   string
+		qx, qy *big.Int
+		passed bool
+	}{
+		{"generator", params.Gx, params.Gy, true},
+		{"point at infinity", big.NewInt(0), big.NewInt(0), false},
+		{"x not less than p", unreducedX, params.Gy, false},
+		{"off the curve", params.Gx, offCurveY, false},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			vectorSet := fmt.Sprintf(`{"algorithm": "ECDSA", "mode": "keyVer", "testGroups": [{
+	"tgId": 1,
+	"curve": "P-256",
+	"tests": [{"tcId": 1, "qx": "%x", "qy": "%x"}]
+}]}`, tc.qx.Bytes(), tc.qy.Bytes())
+
+			m := &fakeTransactable{respond: mockResponder}
+			result, err := Primitives()["ECDSA"].Process([]byte(vectorSet), m)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(m.calls) != 1 || m.calls[0].cmd != "ECDSA/keyVer" {
+				t.Fatalf("got calls %v, want one keyVer command", m.calls)
+			}
+			if got := new(big.Int).SetBytes(m.calls[0].args[1]); got.Cmp(tc.qx) != 0 {
+				t.Errorf("module was given x %x, want %x", got, tc.qx)
+			}
+			passed := result.([]ecdsaTestGroupResponse)[0].Tests[0].Passed
+			if passed == nil || *passed != tc.passed {
+				t.Errorf("got testPassed %v, want %t", passed, tc.passed)
+			}
+		})
+	}
+}
+
+func TestECDSAComponentSigGen(t *testing.T) {
+	// The SHA2-512 digest is longer than the order of P-224 and is truncated
+	// when signing, not by the handler.
+	digest := sha512.Sum512([]byte("message"))
+	vectorSet := fmt.Sprintf(`{"algorithm": "ECDSA", "mode": "sigGen", "testGroups": [{
+		"tgId": 1, "curve": "P-224", "hashAlg": "SHA2-512", "componentTest": true,
+		"tests": [{"tcId": 1, "message": "%x"}]
+	}]}`, digest)
+
+	m := &fakeTransactable{respond: func(cmd string, args [][]byte) ([][]byte, error) {
+		numResults := 2
+		if cmd == "ECDSA/keyGen" {
+			numResults = 3
+		}
+		return mockmodule.New().Transact(cmd, numResults, args...)
+	}}
+	result, err := Primitives()["ECDSA"].Process([]byte(vectorSet), m)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	call := m.calls[1]
+	if call.cmd != "ECDSA/sigGen/componentTest" || !bytes.Equal(call.args[3], digest[:]) {
+		t.Fatalf("signed with %q and message %x", call.cmd, call.args[3])
+	}
+
+	group := result.([]ecdsaTestGroupResponse)[0]
+	pub := &goecdsa.PublicKey{Curve: elliptic.P224(), X: fromHex(t, group.QxHex), Y: fromHex(t, group.QyHex)}
+	test := group.Tests[0]
+	r, s := fromHex(t, test.RHex), fromHex(t, test.SHex)
+	if !goecdsa.Verify(pub, digest[:], r, s) {
+		t.Error("signature doesn't verify with the given digest")
+	}
+	rehashed := sha512.Sum512(digest[:])
+	if goecdsa.Verify(pub, rehashed[:], r, s) {
+		t.Error("the digest was hashed again before signing")
+	}
+
+	short := fmt.Sprintf(`{"algorithm": "ECDSA", "mode": "sigGen", "testGroups": [{
+		"tgId": 1, "curve": "P-224", "hashAlg": "SHA2-512", "componentTest": true,
+		"tests": [{"tcId": 1, "message": "%x"}]
+	}]}`, digest[:32])
+	if _, err := Primitives()["ECDSA"].Process([]byte(short), m); err == nil {
+		t.Error("component test with a digest of the wrong length was accepted")
+	}
+}
+
+// fromHex decodes a big-endian number in hex.
+func fromHex(t *testing.T, s string) *big.Int {
+	n, ok := new(big.Int).SetString(s, 16)
+	if !ok {
+		t.Fatalf("bad hex number %q", s)
+	}
+	return n
+}
@@ -89,6 +89,7 @@ func (e *eddsa) Process(vectorSet []byte, m Transactable) (any, error) {
 		var sigGenPrivKeySeed []byte
 		for _, test := range group.Tests {
 			test := test
+			m := withTestCase(m, e.algo, group.ID, test.ID)
 
 			var testResp eddsaTestResponse
 			testResp.ID = test.ID
@@ -134,16 +135,6 @@ func (e *eddsa) Process(vectorSet []byte, m Transactable) (any, error) {
 					return nil, fmt.Errorf("unknown test type %q in keyGen test group %d", group.Type, group.ID)
 				}
 
-				if len(sigGenPrivKeySeed) == 0 {
-					result, err := m.Transact(e.algo+"/keyGen", 2, []byte(group.Curve))
-					if err != nil {
-						return nil, fmt.Errorf("key generation failed for test case %d/%d: %s", group.ID, test.ID, err)
-					}
-
-					sigGenPrivKeySeed = result[0]
-					response.QHex = hex.EncodeToString(result[1])
-				}
-
 				msg, err := hex.DecodeString(test.MsgHex)
 				if err != nil {
 					return nil, fmt.Errorf("failed to decode message hex in test case %d/%d: %s", group.ID, test.ID, err)
@@ -154,6 +145,11 @@ func (e *eddsa) Process(vectorSet []byte, m Transactable) (any, error) {
 					prehash = []byte{1}
 				}
 				var context []byte
+				// RFC 8032 allows contexts of up to 255 bytes for Ed25519ctx,
+				// Ed25519ph and both variants of Ed448.
+				if test.ContextLength > 255 {
+					return nil, fmt.Errorf("context length %d exceeds the maximum of 255 in test case %d/%d", test.ContextLength, group.ID, test.ID)
+				}
 				if test.ContextHex != "" {
 					if uint64(len(test.ContextHex)) != test.ContextLength*2 {
 						return nil, fmt.Errorf("context hex length %d does not match context length %d in test case %d/%d", len(test.ContextHex), test.ContextLength, group.ID, test.ID)
@@ -164,6 +160,17 @@ func (e *eddsa) Process(vectorSet []byte, m Transactable) (any, error) {
 					}
 				}
 
+				// As for ECDSA, the key is generated after the checks.
+				if len(sigGenPrivKeySeed) == 0 {
+					result, err := m.Transact(e.algo+"/keyGen", 2, []byte(group.Curve))
+					if err != nil {
+						return nil, fmt.Errorf("key generation failed for test case %d/%d: %s", group.ID, test.ID, err)
+					}
+
+					sigGenPrivKeySeed = result[0]
+					response.QHex = hex.EncodeToString(result[1])
+				}
+
 				args := [][]byte{[]byte(group.Curve), sigGenPrivKeySeed, msg, prehash, context}
 				m.TransactAsync(e.algo+"/sigGen", 1, args, func(result [][]byte) error {
 					testResp.SignatureHex = hex.EncodeToString(result[0])
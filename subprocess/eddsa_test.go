@@ -0,0 +1,92 @@
+// Copyright (c) 2026, Google Inc.
+//
+// Permission to use, copy, modify, and/or distribute this software for any
+// purpose with or without fee is hereby granted, provided that the above
+// copyright notice and this permission notice appear in all copies.
+//
+// THE SOFTWARE IS PROVIDED "AS IS" AND THE AUTHOR DISCLAIMS ALL WARRANTIES
+// WITH REGARD TO THIS SOFTWARE INCLUDING ALL IMPLIED WARRANTIES OF
+// MERCHANTABILITY AND FITNESS. IN NO EVENT SHALL THE AUTHOR BE LIABLE FOR ANY
+// SPECIAL, DIRECT, INDIRECT, OR CONSEQUENTIAL DAMAGES OR ANY DAMAGES
+// WHATSOEVER RESULTING FROM LOSS OF USE, DATA OR PROFITS, WHETHER IN AN ACTION
+// OF CONTRACT, NEGLIGENCE OR OTHER TORTIOUS ACTION, ARISING OUT OF OR IN
+// CONNECTION WITH THE USE OR PERFORMANCE OF THIS SOFTWARE.
+
+package subprocess
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+	"testing"
+)
+
+// eddsaResponder generates a fixed key, "signs" by echoing the message and
+// accepts exactly those signatures.
+func eddsaResponder(cmd string, args [][]byte) ([][]byte, error) {
+	switch cmd {
+	case "EDDSA/keyGen":
+		return [][]byte{{0xd0}, {0x0a}}, nil
+	case "EDDSA/sigGen":
+		return [][]byte{args[2]}, nil
+	case "EDDSA/sigVer":
+		return [][]byte{{boolToByte(bytes.Equal(args[1], args[3]))}}, nil
+	}
+	panic("unexpected command " + cmd)
+}
+
+func boolToByte(b bool) byte {
+	if b {
+		return 1
+	}
+	return 0
+}
+
+func TestEDDSA(t *testing.T) {
+	e := &eddsa{"EDDSA", map[string]bool{"ED-25519": true, "ED-448": true}}
+
+	for _, curve := range []string{"ED-25519", "ED-448"} {
+		keyGen := `{"mode": "keyGen", "testGroups": [{"tgId": 1, "testType": "AFT", "curve": "` + curve + `", "tests": [{"tcId": 1}]}]}`
+		result, err := e.Process([]byte(keyGen), &fakeTransactable{respond: eddsaResponder})
+		if err != nil {
+			t.Fatalf("%s keyGen: %s", curve, err)
+		}
+		if test := result.([]eddsaTestGroupResponse)[0].Tests[0]; test.DHex != "d0" || test.QHex != "0a" {
+			t.Errorf("%s keyGen: got %+v", curve, test)
+		}
+
+		sigGen := `{"mode": "sigGen", "testGroups": [{"tgId": 1, "testType": "AFT", "curve": "` + curve + `", "prehash": true,
+			"tests": [{"tcId": 1, "message": "0102", "context": "abcd", "contextLength": 2}]}]}`
+		m := &fakeTransactable{respond: eddsaResponder}
+		result, err = e.Process([]byte(sigGen), m)
+		if err != nil {
+			t.Fatalf("%s sigGen: %s", curve, err)
+		}
+		group := result.([]eddsaTestGroupResponse)[0]
+		if group.QHex != "0a" || group.Tests[0].SignatureHex != "0102" {
+			t.Errorf("%s sigGen: got %+v", curve, group)
+		}
+		sign := m.calls[1]
+		if string(sign.args[0]) != curve || !bytes.Equal(sign.args[3], []byte{1}) || !bytes.Equal(sign.args[4], []byte{0xab, 0xcd}) {
+			t.Errorf("%s sigGen: got args %x", curve, sign.args)
+		}
+
+		sigVer := `{"mode": "sigVer", "testGroups": [{"tgId": 1, "testType": "AFT", "curve": "` + curve + `",
+			"tests": [{"tcId": 1, "message": "0102", "q": "0a", "signature": "0102"},
+			          {"tcId": 2, "message": "0102", "q": "0a", "signature": "0103"}]}]}`
+		result, err = e.Process([]byte(sigVer), &fakeTransactable{respond: eddsaResponder})
+		if err != nil {
+			t.Fatalf("%s sigVer: %s", curve, err)
+		}
+		tests := result.([]eddsaTestGroupResponse)[0].Tests
+		if tests[0].Passed == nil || !*tests[0].Passed || tests[1].Passed == nil || *tests[1].Passed {
+			t.Errorf("%s sigVer: got %+v", curve, tests)
+		}
+	}
+
+	longContext := fmt.Sprintf(`{"mode": "sigGen", "testGroups": [{"tgId": 1, "testType": "AFT", "curve": "ED-448",
+		"tests": [{"tcId": 1, "message": "0102", "context": "%s", "contextLength": 256}]}]}`, strings.Repeat("00", 256))
+	if _, err := e.Process([]byte(longContext), &fakeTransactable{respond: eddsaResponder}); err == nil {
+		t.Error("256-byte context was accepted")
+	}
+}
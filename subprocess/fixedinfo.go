@@ -0,0 +1,72 @@
+// Copyright (c) 2026, Google Inc.
+//
+// Permission to use, copy, modify, and/or distribute this software for any
+// purpose with or without fee is hereby granted, provided that the above
+// copyright notice and this permission notice appear in all copies.
+//
+// THE SOFTWARE IS PROVIDED "AS IS" AND THE AUTHOR DISCLAIMS ALL WARRANTIES
+// WITH REGARD TO THIS SOFTWARE INCLUDING ALL IMPLIED WARRANTIES OF
+// MERCHANTABILITY AND FITNESS. IN NO EVENT SHALL THE AUTHOR BE LIABLE FOR ANY
+// SPECIAL, DIRECT, INDIRECT, OR CONSEQUENTIAL DAMAGES OR ANY DAMAGES
+// WHATSOEVER RESULTING FROM LOSS OF USE, DATA OR PROFITS, WHETHER IN AN ACTION
+// OF CONTRACT, NEGLIGENCE OR OTHER TORTIOUS ACTION, ARISING OUT OF OR IN
+// CONNECTION WITH THE USE OR PERFORMANCE OF THIS SOFTWARE.
+
+package subprocess
+
+import (
+	"encoding/binary"
+	"encoding/hex"
+	"fmt"
+	"strings"
+)
+
+// fixedInfoValues holds the values that the pieces of a fixed info pattern
+// refer to. Hex values are only decoded if the pattern uses them.
+type fixedInfoValues struct {
+	partyU, partyV *hkdfPartyInfo
+	algorithmIDHex string
+	labelHex       string
+	contextHex     string
+	// outBits is the length of the derived keying material, in bits.
+	outBits uint32
+}
+
+// fixedInfo serialises the pieces of pattern, which are separated by "||", in
+// order, as in SP 800-56C, section 4.1. Party info is the party's ID followed
+// by any ephemeral data, a party ID is the ID alone, and "l" is the output
+// length in bits as a 32-bit, big-endian number.
+func fixedInfo(pattern string, values *fixedInfoValues) ([]byte, error) {
+	var ret []byte
+	for _, piece := range strings.Split(pattern, "||") {
+		var value []byte
+		var err error
+		switch {
+		case piece == "uPartyInfo":
+			value, err = values.partyU.data()
+		case piece == "vPartyInfo":
+			value, err = values.partyV.data()
+		case piece == "uPartyId":
+			value, err = hex.DecodeString(values.partyU.IDHex)
+		case piece == "vPartyId":
+			value, err = hex.DecodeString(values.partyV.IDHex)
+		case piece == "algorithmId":
+			value, err = hex.DecodeString(values.algorithmIDHex)
+		case piece == "label":
+			value, err = hex.DecodeString(values.labelHex)
+		case piece == "context":
+			value, err = hex.DecodeString(values.contextHex)
+		case piece == "l":
+			value = binary.BigEndian.AppendUint32(nil, values.outBits)
+		case strings.HasPrefix(piece, "literal[") && strings.HasSuffix(piece, "]"):
+			value, err = hex.DecodeString(piece[len("literal[") : len(piece)-1])
+		default:
+			return nil, fmt.Errorf("unknown fixed info piece %q", piece)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to decode fixed info piece %q: %s", piece, err)
+		}
+		ret = append(ret, value...)
+	}
+	return ret, nil
+}
@@ -0,0 +1,77 @@
+// Copyright (c) 2026, Google Inc.
+//
+// Permission to use, copy, modify, and/or distribute this software for any
+// purpose with or without fee is hereby granted, provided that the above
+// copyright notice and this permission notice appear in all copies.
+//
+// THE SOFTWARE IS PROVIDED "AS IS" AND THE AUTHOR DISCLAIMS ALL WARRANTIES
+// WITH REGARD TO THIS SOFTWARE INCLUDING ALL IMPLIED WARRANTIES OF
+// MERCHANTABILITY AND FITNESS. IN NO EVENT SHALL THE AUTHOR BE LIABLE FOR ANY
+// SPECIAL, DIRECT, INDIRECT, OR CONSEQUENTIAL DAMAGES OR ANY DAMAGES
+// WHATSOEVER RESULTING FROM LOSS OF USE, DATA OR PROFITS, WHETHER IN AN ACTION
+// OF CONTRACT, NEGLIGENCE OR OTHER TORTIOUS ACTION, ARISING OUT OF OR IN
+// CONNECTION WITH THE USE OR PERFORMANCE OF THIS SOFTWARE.
+
+package subprocess
+
+import (
+	"encoding/hex"
+	"testing"
+)
+
+func TestFixedInfo(t *testing.T) {
+	values := &fixedInfoValues{
+		partyU:         &hkdfPartyInfo{IDHex: "0102", ExtraHex: "03"},
+		partyV:         &hkdfPartyInfo{IDHex: "0405"},
+		algorithmIDHex: "a1",
+		labelHex:       "b2",
+		contextHex:     "c3",
+		outBits:        256,
+	}
+
+	for _, tc := range []struct {
+		pattern string
+		want    string
+	}{
+		{"uPartyInfo", "010203"},
+		{"vPartyInfo", "0405"},
+		{"uPartyId", "0102"},
+		{"vPartyId", "0405"},
+		{"algorithmId", "a1"},
+		{"label", "b2"},
+		{"context", "c3"},
+		{"l", "00000100"},
+		{"literal[cafe]", "cafe"},
+		{"literal[]", ""},
+		{"literal[cafe]||uPartyInfo||vPartyId||algorithmId||context||l", "cafe0102030405a1c300000100"},
+	} {
+		got, err := fixedInfo(tc.pattern, values)
+		if err != nil {
+			t.Errorf("%s: %s", tc.pattern, err)
+			continue
+		}
+		if gotHex := hex.EncodeToString(got); gotHex != tc.want {
+			t.Errorf("%s: got %s, want %s", tc.pattern, gotHex, tc.want)
+		}
+	}
+}
+
+func TestFixedInfoErrors(t *testing.T) {
+	values := &fixedInfoValues{
+		partyU:     &hkdfPartyInfo{IDHex: "01"},
+		partyV:     &hkdfPartyInfo{IDHex: "02"},
+		contextHex: "zz",
+	}
+
+	for _, pattern := range []string{
+		"uPartyInfo||salt",
+		"literal[cafe",
+		"literal[abc]",
+		"context",
+		"uPartyInfo|vPartyInfo",
+	} {
+		if _, err := fixedInfo(pattern, values); err == nil {
+			t.Errorf("%s: no error", pattern)
+		}
+	}
+}
@@ -0,0 +1,205 @@
+// Copyright (c) 2026, Google Inc.
+//
+// Permission to use, copy, modify, and/or distribute this software for any
+// purpose with or without fee is hereby granted, provided that the above
+// copyright notice and this permission notice appear in all copies.
+//
+// THE SOFTWARE IS PROVIDED "AS IS" AND THE AUTHOR DISCLAIMS ALL WARRANTIES
+// WITH REGARD TO THIS SOFTWARE INCLUDING ALL IMPLIED WARRANTIES OF
+// MERCHANTABILITY AND FITNESS. IN NO EVENT SHALL THE AUTHOR BE LIABLE FOR ANY
+// SPECIAL, DIRECT, INDIRECT, OR CONSEQUENTIAL DAMAGES OR ANY DAMAGES
+// WHATSOEVER RESULTING FROM LOSS OF USE, DATA OR PROFITS, WHETHER IN AN ACTION
+// OF CONTRACT, NEGLIGENCE OR OTHER TORTIOUS ACTION, ARISING OUT OF OR IN
+// CONNECTION WITH THE USE OR PERFORMANCE OF THIS SOFTWARE.
+
+package subprocess
+
+import (
+	"encoding/binary"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+// The following structures reflect the JSON of ACVP format-preserving
+// encryption tests. See
+// https://pages.nist.gov/ACVP/draft-celi-acvp-fpe.html#name-test-vectors
+
+type fpeTestVectorSet struct {
+	Groups []fpeTestGroup `json:"testGroups"`
+}
+
+type fpeTestGroup struct {
+	ID        uint64 `json:"tgId"`
+	Type      string `json:"testType"`
+	Direction string `json:"direction"`
+	KeyBits   int    `json:"keyLen"`
+	Radix     uint32 `json:"radix"`
+	Alphabet  string `json:"alphabet"`
+	Tests     []struct {
+		ID            uint64 `json:"tcId"`
+		KeyHex        string `json:"key"`
+		TweakHex      string `json:"tweak"`
+		PlaintextStr  string `json:"pt"`
+		CiphertextStr string `json:"ct"`
+	} `json:"tests"`
+}
+
+type fpeTestGroupResponse struct {
+	ID    uint64            `json:"tgId"`
+	Tests []fpeTestResponse `json:"tests"`
+}
+
+type fpeTestResponse struct {
+	ID            uint64 `json:"tcId"`
+	PlaintextStr  string `json:"pt,omitempty"`
+	CiphertextStr string `json:"ct,omitempty"`
+}
+
+// fpe implements an ACVP algorithm by making requests to the subprocess to
+// encrypt and decrypt numeral strings with FF1 or FF3-1.
+type fpe struct {
+	algo string
+	// tweakBits, if non-zero, is the only tweak length that the mode
+	// supports.
+	tweakBits int
+}
+
+// encodeNumerals converts a string over alphabet into the numerals that the
+// subprocess expects: each character's index in the alphabet, as a 32-bit,
+// little-endian value.
+func encodeNumerals(s, alphabet string) ([]byte, error) {
+	var ret []byte
+	for _, c := range s {
+		i := strings.IndexRune(alphabet, c)
+		if i < 0 {
+			return nil, fmt.Errorf("character %q is not in the alphabet %q", c, alphabet)
+		}
+		ret = append(ret, uint32le(uint32(i))...)
+	}
+	return ret, nil
+}
+
+// decodeNumerals is the inverse of encodeNumerals.
+func decodeNumerals(numerals []byte, alphabet string) (string, error) {
+	if len(numerals)%4 != 0 {
+		return "", fmt.Errorf("numeral string of %d bytes is not a multiple of four", len(numerals))
+	}
+	symbols := []rune(alphabet)
+	var ret strings.Builder
+	for len(numerals) > 0 {
+		i := binary.LittleEndian.Uint32(numerals)
+		if i >= uint32(len(symbols)) {
+			return "", fmt.Errorf("numeral %d is out of range for radix %d", i, len(symbols))
+		}
+		ret.WriteRune(symbols[i])
+		numerals = numerals[4:]
+	}
+	return ret.String(), nil
+}
+
+func (f *fpe) Process(vectorSet []byte, m Transactable) (any, error) {
+	var parsed fpeTestVectorSet
+	if err := json.Unmarshal(vectorSet, &parsed); err != nil {
+		return nil, err
+	}
+
+	var ret []fpeTestGroupResponse
+	for _, group := range parsed.Groups {
+		group := group
+		response := fpeTestGroupResponse{
+			ID: group.ID,
+		}
+
+		if group.Type != "AFT" {
+			return nil, fmt.Errorf("test group %d has unknown type %q", group.ID, group.Type)
+		}
+
+		var encrypt bool
+		switch group.Direction {
+		case "encrypt":
+			encrypt = true
+		case "decrypt":
+			encrypt = false
+		default:
+			return nil, fmt.Errorf("test group %d has unknown direction %q", group.ID, group.Direction)
+		}
+		op := f.algo + "/" + group.Direction
+
+		// SP 800-38G, section 5.2 bounds the radix for both modes.
+		if group.Radix < 2 || group.Radix > 1<<16 {
+			return nil, fmt.Errorf("test group %d has radix %d, which is outside the range [2, 65536]", group.ID, group.Radix)
+		}
+		alphabet := group.Alphabet
+		if len(alphabet) == 0 {
+			return nil, fmt.Errorf("test group %d has no alphabet", group.ID)
+		}
+		if n := len([]rune(alphabet)); uint32(n) != group.Radix {
+			return nil, fmt.Errorf("test group %d has an alphabet of %d characters but a radix of %d", group.ID, n, group.Radix)
+		}
+		radix := uint32le(group.Radix)
+
+		if group.KeyBits%8 != 0 || group.KeyBits < 0 {
+			return nil, fmt.Errorf("test group %d contains non-byte-multiple key length %d", group.ID, group.KeyBits)
+		}
+		keyBytes := group.KeyBits / 8
+
+		for _, test := range group.Tests {
+			test := test
+			m := withTestCase(m, f.algo, group.ID, test.ID)
+
+			if len(test.KeyHex) != keyBytes*2 {
+				return nil, fmt.Errorf("test case %d/%d contains key %q of length %d, but expected %d-bit key", group.ID, test.ID, test.KeyHex, len(test.KeyHex), group.KeyBits)
+			}
+			key, err := hex.DecodeString(test.KeyHex)
+			if err != nil {
+				return nil, fmt.Errorf("failed to decode key in test case %d/%d: %s", group.ID, test.ID, err)
+			}
+
+			tweak, err := hex.DecodeString(test.TweakHex)
+			if err != nil {
+				return nil, fmt.Errorf("failed to decode tweak in test case %d/%d: %s", group.ID, test.ID, err)
+			}
+			if f.tweakBits != 0 && len(tweak)*8 != f.tweakBits {
+				return nil, fmt.Errorf("test case %d/%d contains a %d-bit tweak, but %s requires %d bits", group.ID, test.ID, len(tweak)*8, f.algo, f.tweakBits)
+			}
+
+			input := test.PlaintextStr
+			if !encrypt {
+				input = test.CiphertextStr
+			}
+			numerals, err := encodeNumerals(input, alphabet)
+			if err != nil {
+				return nil, fmt.Errorf("failed to decode input in test case %d/%d: %s", group.ID, test.ID, err)
+			}
+
+			m.TransactAsync(op, 1, [][]byte{key, radix, tweak, numerals}, func(result [][]byte) error {
+				output, err := decodeNumerals(result[0], alphabet)
+				if err != nil {
+					return fmt.Errorf("invalid result from subprocess for test case %d/%d: %s", group.ID, test.ID, err)
+				}
+
+				testResponse := fpeTestResponse{ID: test.ID}
+				if encrypt {
+					testResponse.CiphertextStr = output
+				} else {
+					testResponse.PlaintextStr = output
+				}
+
+				response.Tests = append(response.Tests, testResponse)
+				return nil
+			})
+		}
+
+		m.Barrier(func() {
+			ret = append(ret, response)
+		})
+	}
+
+	if err := m.Flush(); err != nil {
+		return nil, err
+	}
+
+	return ret, nil
+}
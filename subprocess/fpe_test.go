@@ -0,0 +1,91 @@
+// Copyright (c) 2026, Google Inc.
+//
+// Permission to use, copy, modify, and/or distribute this software for any
+// purpose with or without fee is hereby granted, provided that the above
+// copyright notice and this permission notice appear in all copies.
+//
+// THE SOFTWARE IS PROVIDED "AS IS" AND THE AUTHOR DISCLAIMS ALL WARRANTIES
+// WITH REGARD TO THIS SOFTWARE INCLUDING ALL IMPLIED WARRANTIES OF
+// MERCHANTABILITY AND FITNESS. IN NO EVENT SHALL THE AUTHOR BE LIABLE FOR ANY
+// SPECIAL, DIRECT, INDIRECT, OR CONSEQUENTIAL DAMAGES OR ANY DAMAGES
+// WHATSOEVER RESULTING FROM LOSS OF USE, DATA OR PROFITS, WHETHER IN AN ACTION
+// OF CONTRACT, NEGLIGENCE OR OTHER TORTIOUS ACTION, ARISING OUT OF OR IN
+// CONNECTION WITH THE USE OR PERFORMANCE OF THIS SOFTWARE.
+
+package subprocess
+
+import (
+	"bytes"
+	"encoding/binary"
+	"testing"
+)
+
+// fpeReverseResponder "encrypts" and "decrypts" by reversing the numeral
+// string, after checking the radix it was given.
+func fpeReverseResponder(cmd string, args [][]byte) ([][]byte, error) {
+	if radix := binary.LittleEndian.Uint32(args[1]); radix != 10 {
+		panic("unexpected radix")
+	}
+	in := args[3]
+	var out []byte
+	for i := len(in) - 4; i >= 0; i -= 4 {
+		out = append(out, in[i:i+4]...)
+	}
+	return [][]byte{out}, nil
+}
+
+func TestFPE(t *testing.T) {
+	tests := []struct {
+		f        *fpe
+		tweakHex string
+	}{
+		{&fpe{"AES-FF1", 0}, "00010203040506070809"},
+		{&fpe{"AES-FF3-1", 56}, "00010203040506"},
+	}
+
+	for _, test := range tests {
+		vectorSet := `{"testGroups": [{
+			"tgId": 1, "testType": "AFT", "direction": "encrypt", "keyLen": 128, "radix": 10, "alphabet": "0123456789",
+			"tests": [{"tcId": 1, "key": "000102030405060708090a0b0c0d0e0f", "tweak": "` + test.tweakHex + `", "pt": "0123456789"}]
+		}, {
+			"tgId": 2, "testType": "AFT", "direction": "decrypt", "keyLen": 128, "radix": 10, "alphabet": "0123456789",
+			"tests": [{"tcId": 2, "key": "000102030405060708090a0b0c0d0e0f", "tweak": "` + test.tweakHex + `", "ct": "1234"}]
+		}]}`
+
+		m := &fakeTransactable{respond: fpeReverseResponder}
+		result, err := test.f.Process([]byte(vectorSet), m)
+		if err != nil {
+			t.Fatalf("%s: %s", test.f.algo, err)
+		}
+
+		if m.calls[0].cmd != test.f.algo+"/encrypt" || m.calls[1].cmd != test.f.algo+"/decrypt" {
+			t.Errorf("%s: unexpected commands %q and %q", test.f.algo, m.calls[0].cmd, m.calls[1].cmd)
+		}
+		if want := append(uint32le(1), uint32le(2)...); !bytes.Equal(m.calls[1].args[3][:8], want) {
+			t.Errorf("%s: numerals were encoded as %x", test.f.algo, m.calls[1].args[3])
+		}
+
+		groups := result.([]fpeTestGroupResponse)
+		if got := groups[0].Tests[0].CiphertextStr; got != "9876543210" {
+			t.Errorf("%s: got ciphertext %q", test.f.algo, got)
+		}
+		if got := groups[1].Tests[0].PlaintextStr; got != "4321" {
+			t.Errorf("%s: got plaintext %q", test.f.algo, got)
+		}
+	}
+}
+
+func TestFPEBadParameters(t *testing.T) {
+	for _, vectorSet := range []string{
+		// Radix too small.
+		`{"testGroups": [{"tgId": 1, "testType": "AFT", "direction": "encrypt", "keyLen": 128, "radix": 1, "alphabet": "0", "tests": []}]}`,
+		// FF3-1 tweaks must be 56 bits.
+		`{"testGroups": [{"tgId": 1, "testType": "AFT", "direction": "encrypt", "keyLen": 128, "radix": 10, "alphabet": "0123456789",
+			"tests": [{"tcId": 1, "key": "000102030405060708090a0b0c0d0e0f", "tweak": "0001020304050607", "pt": "0123"}]}]}`,
+	} {
+		m := &fakeTransactable{respond: fpeReverseResponder}
+		if _, err := (&fpe{"AES-FF3-1", 56}).Process([]byte(vectorSet), m); err == nil {
+			t.Errorf("no error for %s", vectorSet)
+		}
+	}
+}
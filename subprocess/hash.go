@@ -18,6 +18,8 @@ import (
 	"encoding/hex"
 	"encoding/json"
 	"fmt"
+	"math"
+	"strings"
 )
 
 // The following structures reflect the JSON of ACVP hash tests. See
@@ -28,9 +30,13 @@ type hashTestVectorSet struct {
 }
 
 type hashTestGroup struct {
-	ID    uint64 `json:"tgId"`
-	Type  string `json:"testType"`
-	Tests []struct {
+	ID   uint64 `json:"tgId"`
+	Type string `json:"testType"`
+	// MCTVersion is "standard", or absent, for MCTs whose seed is as long
+	// as the digest and "alternate" for those whose seed may have any
+	// length.
+	MCTVersion string `json:"mctVersion"`
+	Tests      []struct {
 		ID        uint64 `json:"tcId"`
 		BitLength uint64 `json:"len"`
 		MsgHex    string `json:"msg"`
@@ -62,6 +68,17 @@ type hashPrimitive struct {
 	size int
 }
 
+func (h *hashPrimitive) describe() AlgorithmCapabilities {
+	return AlgorithmCapabilities{
+		TestTypes: []string{"AFT", "MCT"},
+		Capabilities: map[string]any{
+			"digestSize":  h.size * 8,
+			"mctVersion":  []string{"standard", "alternate"},
+			"bitOriented": true,
+		},
+	}
+}
+
 func (h *hashPrimitive) Process(vectorSet []byte, m Transactable) (any, error) {
 	var parsed hashTestVectorSet
 	if err := json.Unmarshal(vectorSet, &parsed); err != nil {
@@ -80,19 +97,40 @@ func (h *hashPrimitive) Process(vectorSet []byte, m Transactable) (any, error) {
 
 		for _, test := range group.Tests {
 			test := test
+			m := withTestCase(m, h.algo, group.ID, test.ID)
 
-			if uint64(len(test.MsgHex))*4 != test.BitLength {
+			// Bit-oriented tests give messages that aren't a whole number
+			// of bytes. The hex is padded to a whole number of bytes, with
+			// the message in the most significant bits of the last one.
+			if uint64(len(test.MsgHex)) != 2*((test.BitLength+7)/8) {
 				return nil, fmt.Errorf("test case %d/%d contains hex message of length %d but specifies a bit length of %d", group.ID, test.ID, len(test.MsgHex), test.BitLength)
 			}
 			msg, err := hex.DecodeString(test.MsgHex)
 			if err != nil {
 				return nil, fmt.Errorf("failed to decode hex in test case %d/%d: %s", group.ID, test.ID, err)
 			}
+			partialBits := test.BitLength % 8
+			if partialBits != 0 {
+				msg[len(msg)-1] &= 0xff << (8 - partialBits)
+			}
 
 			// http://usnistgov.github.io/ACVP/artifacts/draft-celi-acvp-sha-00.html#rfc.section.3
 			switch group.Type {
 			case "AFT":
-				m.TransactAsync(h.algo, 1, [][]byte{msg}, func(result [][]byte) error {
+				// The exact length is only sent when it's needed, so that
+				// modules without support for bit-oriented messages see
+				// the same commands as before.
+				cmd, args := h.algo, [][]byte{msg}
+				if partialBits != 0 {
+					if test.BitLength > math.MaxUint32 {
+						return nil, fmt.Errorf("test case %d/%d has a message of %d bits, which is too long", group.ID, test.ID, test.BitLength)
+					}
+					cmd, args = h.algo+"/bits", [][]byte{msg, uint32le(uint32(test.BitLength))}
+				}
+				m.TransactAsync(cmd, 1, args, func(result [][]byte) error {
+					if len(result[0]) != h.size {
+						return fmt.Errorf("%s returned a %d-byte digest, but the digest length is %d", cmd, len(result[0]), h.size)
+					}
 					response.Tests = append(response.Tests, hashTestResponse{
 						ID:        test.ID,
 						DigestHex: hex.EncodeToString(result[0]),
@@ -101,21 +139,48 @@ func (h *hashPrimitive) Process(vectorSet []byte, m Transactable) (any, error) {
 				})
 
 			case "MCT":
-				if len(msg) != h.size {
-					return nil, fmt.Errorf("MCT test case %d/%d contains message of length %d but the digest length is %d", group.ID, test.ID, len(msg), h.size)
+				if partialBits != 0 {
+					return nil, fmt.Errorf("MCT test case %d/%d has a seed of %d bits, which is not a whole number of bytes", group.ID, test.ID, test.BitLength)
+				}
+				switch group.MCTVersion {
+				case "", "standard":
+					if len(msg) != h.size {
+						return nil, fmt.Errorf("MCT test case %d/%d contains message of length %d but the digest length is %d", group.ID, test.ID, len(msg), h.size)
+					}
+				case "alternate":
+					// Only the SHA-3 MCT, in which each message is the
+					// previous digest, can be adapted to other lengths
+					// without the module knowing the MCT version.
+					if !strings.HasPrefix(h.algo, "SHA3-") {
+						return nil, fmt.Errorf("test group %d uses the alternate MCT, which is not supported for %s", group.ID, h.algo)
+					}
+					if len(msg) == 0 {
+						return nil, fmt.Errorf("MCT test case %d/%d has an empty seed", group.ID, test.ID)
+					}
+				default:
+					return nil, fmt.Errorf("test group %d has unknown MCT version %q", group.ID, group.MCTVersion)
 				}
 
 				testResponse := hashTestResponse{ID: test.ID}
 
-				digest := msg
+				seed := msg
 				for i := 0; i < 100; i++ {
-					result, err := m.Transact(h.algo+"/MCT", 1, digest)
+					result, err := m.Transact(h.algo+"/MCT", 1, seed)
 					if err != nil {
-						panic(h.algo + " hash operation failed: " + err.Error())
+						return nil, fmt.Errorf("%s MCT operation failed for test case %d/%d: %s", h.algo, group.ID, test.ID, err)
 					}
 
-					digest = result[0]
+					digest := result[0]
+					if len(digest) != h.size {
+						return nil, fmt.Errorf("%s MCT operation for test case %d/%d returned a %d-byte digest, but the digest length is %d", h.algo, group.ID, test.ID, len(digest), h.size)
+					}
 					testResponse.MCTResults = append(testResponse.MCTResults, hashMCTResult{hex.EncodeToString(digest)})
+					// In the alternate MCT, the next message is the digest
+					// truncated, or padded with zeros, to the length of
+					// the initial seed. In the standard MCT, this has no
+					// effect.
+					seed = make([]byte, len(msg))
+					copy(seed, digest)
 				}
 
 				response.Tests = append(response.Tests, testResponse)
@@ -0,0 +1,211 @@
+// Copyright (c) 2026, Google Inc.
+//
+// Permission to use, copy, modify, and/or distribute this software for any
+// purpose with or without fee is hereby granted, provided that the above
+// copyright notice and this permission notice appear in all copies.
+//
+// THE SOFTWARE IS PROVIDED "AS IS" AND THE AUTHOR DISCLAIMS ALL WARRANTIES
+// WITH REGARD TO THIS SOFTWARE INCLUDING ALL IMPLIED WARRANTIES OF
+// MERCHANTABILITY AND FITNESS. IN NO EVENT SHALL THE AUTHOR BE LIABLE FOR ANY
+// SPECIAL, DIRECT, INDIRECT, OR CONSEQUENTIAL DAMAGES OR ANY DAMAGES
+// WHATSOEVER RESULTING FROM LOSS OF USE, DATA OR PROFITS, WHETHER IN AN ACTION
+// OF CONTRACT, NEGLIGENCE OR OTHER TORTIOUS ACTION, ARISING OUT OF OR IN
+// CONNECTION WITH THE USE OR PERFORMANCE OF THIS SOFTWARE.
+
+package subprocess
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+	"testing"
+
+	"golang.org/x/crypto/sha3"
+)
+
+// sha3256MCTResponder runs the inner loop of the SHA3-256 MCT, hashing the
+// previous digest truncated or padded to the length of the seed.
+func sha3256MCTResponder(cmd string, args [][]byte) ([][]byte, error) {
+	md := args[0]
+	for i := 0; i < 1000; i++ {
+		msg := make([]byte, len(args[0]))
+		copy(msg, md)
+		digest := sha3.Sum256(msg)
+		md = digest[:]
+	}
+	return [][]byte{md}, nil
+}
+
+func TestSHA3AlternateMCT(t *testing.T) {
+	for _, seedLen := range []int{20, 32, 40} {
+		t.Run(fmt.Sprintf("%d", seedLen), func(t *testing.T) {
+			vectorSet := fmt.Sprintf(`{"testGroups": [{
+	"tgId": 1,
+	"testType": "MCT",
+	"mctVersion": "alternate",
+	"tests": [{"tcId": 1, "len": %d, "msg": "%x"}]
+}]}`, seedLen*8, bytes.Repeat([]byte{0x5a}, seedLen))
+
+			m := &fakeTransactable{respond: sha3256MCTResponder}
+			if _, err := (&hashPrimitive{"SHA3-256", 32}).Process([]byte(vectorSet), m); err != nil {
+				t.Fatal(err)
+			}
+			if len(m.calls) != 100 {
+				t.Fatalf("got %d MCT calls, want 100", len(m.calls))
+			}
+			for i := 1; i < len(m.calls); i++ {
+				prev, _ := sha3256MCTResponder("", m.calls[i-1].args)
+				want := make([]byte, seedLen)
+				copy(want, prev[0])
+				if !bytes.Equal(m.calls[i].args[0], want) {
+					t.Fatalf("call %d: got seed %x, want %x", i, m.calls[i].args[0], want)
+				}
+			}
+		})
+	}
+}
+
+func TestAlternateMCTUnsupported(t *testing.T) {
+	vectorSet := `{"testGroups": [{
+	"tgId": 1,
+	"testType": "MCT",
+	"mctVersion": "alternate",
+	"tests": [{"tcId": 1, "len": 16, "msg": "0001"}]
+}]}`
+	m := &fakeTransactable{respond: sha256Responder(0)}
+	if _, err := (&hashPrimitive{"SHA2-256", 32}).Process([]byte(vectorSet), m); err == nil {
+		t.Fatal("alternate SHA2-256 MCT was accepted")
+	}
+}
+
+func TestSHABitOriented(t *testing.T) {
+	tests := []struct {
+		bitLength uint32
+		msgHex    string
+		// wantMsg has the unused bits of the last byte cleared.
+		wantMsg []byte
+	}{
+		{9, "abff", []byte{0xab, 0x80}},
+		{15, "abff", []byte{0xab, 0xfe}},
+		{1, "ff", []byte{0x80}},
+		{7, "ff", []byte{0xfe}},
+	}
+
+	for _, test := range tests {
+		vectorSet := fmt.Sprintf(`{"testGroups": [{
+	"tgId": 1,
+	"testType": "AFT",
+	"tests": [{"tcId": 1, "len": %d, "msg": %q}]
+}]}`, test.bitLength, test.msgHex)
+
+		m := &fakeTransactable{respond: func(cmd string, args [][]byte) ([][]byte, error) {
+			return [][]byte{make([]byte, 32)}, nil
+		}}
+		if _, err := (&hashPrimitive{"SHA2-256", 32}).Process([]byte(vectorSet), m); err != nil {
+			t.Fatalf("%d-bit message: %s", test.bitLength, err)
+		}
+		call := m.calls[0]
+		if call.cmd != "SHA2-256/bits" {
+			t.Errorf("%d-bit message was sent with command %q", test.bitLength, call.cmd)
+		}
+		if len(call.args) != 2 || !bytes.Equal(call.args[0], test.wantMsg) || !bytes.Equal(call.args[1], uint32le(test.bitLength)) {
+			t.Errorf("%d-bit message was sent as %x, want %x and its length", test.bitLength, call.args, test.wantMsg)
+		}
+	}
+}
+
+func TestSHAByteOriented(t *testing.T) {
+	// Messages that are a whole number of bytes use the original command.
+	vectorSet := `{"testGroups": [{"tgId": 1, "testType": "AFT", "tests": [{"tcId": 1, "len": 16, "msg": "abcd"}]}]}`
+	m := &fakeTransactable{respond: func(cmd string, args [][]byte) ([][]byte, error) {
+		return [][]byte{make([]byte, 32)}, nil
+	}}
+	if _, err := (&hashPrimitive{"SHA2-256", 32}).Process([]byte(vectorSet), m); err != nil {
+		t.Fatal(err)
+	}
+	if call := m.calls[0]; call.cmd != "SHA2-256" || len(call.args) != 1 {
+		t.Errorf("byte-oriented message was sent with command %q and %d arguments", call.cmd, len(call.args))
+	}
+
+	// The hex must still cover exactly the bits of the message.
+	tooLong := `{"testGroups": [{"tgId": 1, "testType": "AFT", "tests": [{"tcId": 1, "len": 7, "msg": "abcd"}]}]}`
+	if _, err := (&hashPrimitive{"SHA2-256", 32}).Process([]byte(tooLong), m); err == nil {
+		t.Error("hex longer than the bit length was accepted")
+	}
+}
+
+func TestTruncatedSHA512(t *testing.T) {
+	const vectorSet = `{"testGroups": [{"tgId": 1, "testType": "AFT", "tests": [
+		{"tcId": 1, "len": 24, "msg": "616263"},
+		{"tcId": 2, "len": 0, "msg": ""}
+	]}]}`
+	// FIPS 180-4 examples, and SHA-256 of the same messages, which uses a
+	// different IV and so must not be confused with SHA-512/256.
+	for _, test := range []struct {
+		algo string
+		want []string
+	}{
+		{"SHA2-512/224", []string{"4634270f707b6a54daae7530460842e20e37ed265ceee9a43e8924aa", "6ed0dd02806fa89e25de060c19d3ac86cabb87d6a0ddd05c333b84f4"}},
+		{"SHA2-512/256", []string{"53048e2681941ef99b2e29b76b4c7dabe4c2d0c634fc6d46e0e2f13107e7af23", "c672b8d1ef56ed28ab87c3622c5114069bdd3ad7b8f9737498d0c01ecef0967a"}},
+		{"SHA2-256", []string{"ba7816bf8f01cfea414140de5dae2223b00361a396177a9cb410ff61f20015ad", "e3b0c44298fc1c149afbf4c8996fb92427ae41e4649b934ca495991b7852b855"}},
+	} {
+		m := &fakeTransactable{respond: mockResponder}
+		result, err := Primitives()[test.algo].Process([]byte(vectorSet), m)
+		if err != nil {
+			t.Fatalf("%s: %s", test.algo, err)
+		}
+		for i, call := range m.calls {
+			if call.cmd != test.algo {
+				t.Errorf("%s: call %d used command %q", test.algo, i, call.cmd)
+			}
+		}
+		tests := result.([]hashTestGroupResponse)[0].Tests
+		for i, want := range test.want {
+			if got := tests[i].DigestHex; got != want {
+				t.Errorf("%s: test case %d: got %s, want %s", test.algo, i+1, got, want)
+			}
+		}
+	}
+}
+
+func TestTruncatedSHA512MCT(t *testing.T) {
+	seed := strings.Repeat("5a", 32)
+	vectorSet := `{"testGroups": [{"tgId": 1, "testType": "MCT", "tests": [{"tcId": 1, "len": 256, "msg": "` + seed + `"}]}]}`
+	mct := func(algo string) []hashMCTResult {
+		m := &fakeTransactable{respond: mockResponder}
+		result, err := Primitives()[algo].Process([]byte(vectorSet), m)
+		if err != nil {
+			t.Fatalf("%s: %s", algo, err)
+		}
+		for i, call := range m.calls {
+			if call.cmd != algo+"/MCT" {
+				t.Fatalf("%s: call %d used command %q", algo, i, call.cmd)
+			}
+		}
+		return result.([]hashTestGroupResponse)[0].Tests[0].MCTResults
+	}
+
+	truncated, sha256 := mct("SHA2-512/256"), mct("SHA2-256")
+	if len(truncated) != 100 {
+		t.Fatalf("got %d MCT results, want 100", len(truncated))
+	}
+	if truncated[0] == sha256[0] {
+		t.Error("SHA2-512/256 MCT gave the same result as SHA2-256")
+	}
+}
+
+func TestHashDigestLength(t *testing.T) {
+	// A module that answers SHA2-512/224 with an untruncated digest.
+	m := &fakeTransactable{respond: func(cmd string, args [][]byte) ([][]byte, error) {
+		return mockResponder(strings.Replace(cmd, "SHA2-512/224", "SHA2-512", 1), args)
+	}}
+	for _, vectorSet := range []string{
+		`{"testGroups": [{"tgId": 1, "testType": "AFT", "tests": [{"tcId": 1, "len": 0, "msg": ""}]}]}`,
+		`{"testGroups": [{"tgId": 1, "testType": "MCT", "tests": [{"tcId": 1, "len": 224, "msg": "` + strings.Repeat("00", 28) + `"}]}]}`,
+	} {
+		_, err := Primitives()["SHA2-512/224"].Process([]byte(vectorSet), m)
+		if err == nil || !strings.Contains(err.Error(), "returned a 64-byte digest, but the digest length is 28") {
+			t.Errorf("got error %v, want one about the digest length", err)
+		}
+	}
+}
@@ -26,6 +26,7 @@ import (
 
 type hkdfTestVectorSet struct {
 	Groups []hkdfTestGroup `json:"testGroups"`
+	Mode   string          `json:"mode"`
 }
 
 type hkdfTestGroup struct {
@@ -53,7 +54,6 @@ type hkdfConfiguration struct {
 
 func (c *hkdfConfiguration) extract() (outBytes uint32, hashName string, err error) {
 	if c.Type != "hkdf" ||
-		c.FixedInfoPattern != "uPartyInfo||vPartyInfo" ||
 		c.FixedInputEncoding != "concatenation" ||
 		c.OutputBits%8 != 0 {
 		return 0, "", fmt.Errorf("KDA not configured for HKDF: %#v", c)
@@ -63,14 +63,22 @@ func (c *hkdfConfiguration) extract() (outBytes uint32, hashName string, err err
 }
 
 type hkdfParameters struct {
-	SaltHex string `json:"salt"`
-	KeyHex  string `json:"z"`
+	// SaltHex is nil if the test has no salt, in which case the default
+	// salt of the KDF is used.
+	SaltHex        *string `json:"salt"`
+	KeyHex         string  `json:"z"`
+	AlgorithmIDHex string  `json:"algorithmId"`
+	LabelHex       string  `json:"label"`
+	ContextHex     string  `json:"context"`
 }
 
+// extract returns the key and salt of a test. The salt is nil if there is
+// none, and empty but not nil if it is present and empty.
 func (p *hkdfParameters) extract() (key, salt []byte, err error) {
-	salt, err = hex.DecodeString(p.SaltHex)
-	if err != nil {
-		return nil, nil, err
+	if p.SaltHex != nil {
+		if salt, err = hex.DecodeString(*p.SaltHex); err != nil {
+			return nil, nil, err
+		}
 	}
 
 	key, err = hex.DecodeString(p.KeyHex)
@@ -81,6 +89,18 @@ func (p *hkdfParameters) extract() (key, salt []byte, err error) {
 	return key, salt, nil
 }
 
+// fixedInfoValues returns the values that a fixed info pattern can refer to.
+func (p *hkdfParameters) fixedInfoValues(outBits uint32, partyU, partyV *hkdfPartyInfo) *fixedInfoValues {
+	return &fixedInfoValues{
+		partyU:         partyU,
+		partyV:         partyV,
+		algorithmIDHex: p.AlgorithmIDHex,
+		labelHex:       p.LabelHex,
+		contextHex:     p.ContextHex,
+		outBits:        outBits,
+	}
+}
+
 type hkdfPartyInfo struct {
 	IDHex    string `json:"partyId"`
 	ExtraHex string `json:"ephemeralData"`
@@ -114,7 +134,11 @@ type hkdfTestResponse struct {
 	Passed *bool  `json:"testPassed,omitempty"`
 }
 
-type hkdf struct{}
+// hkdf implements the HKDF mode of the KDA algorithm, as well as delegating
+// to the other KDA modes.
+type hkdf struct {
+	primitives map[string]Primitive // for looking up the size of hashes
+}
 
 func (k *hkdf) Process(vectorSet []byte, m Transactable) (any, error) {
 	var parsed hkdfTestVectorSet
@@ -122,6 +146,10 @@ func (k *hkdf) Process(vectorSet []byte, m Transactable) (any, error) {
 		return nil, err
 	}
 
+	if parsed.Mode == "TwoStep" {
+		return processKDATwoStep(vectorSet, m)
+	}
+
 	var respGroups []hkdfTestGroupResponse
 	for _, group := range parsed.Groups {
 		group := group
@@ -142,21 +170,34 @@ func (k *hkdf) Process(vectorSet []byte, m Transactable) (any, error) {
 			return nil, err
 		}
 
+		hash, ok := k.primitives[hashName].(*hashPrimitive)
+		if !ok {
+			return nil, fmt.Errorf("unknown hash %q in test group %d", hashName, group.ID)
+		}
+		// RFC 5869, section 2.3.
+		if outBytes > 255*uint32(hash.size) {
+			return nil, fmt.Errorf("test group %d requests %d bytes of output but HKDF with %s is limited to %d", group.ID, outBytes, hashName, 255*hash.size)
+		}
+
 		for _, test := range group.Tests {
 			test := test
+			m := withTestCase(m, "KDA", group.ID, test.ID)
 			testResp := hkdfTestResponse{ID: test.ID}
 
 			key, salt, err := test.Params.extract()
 			if err != nil {
 				return nil, err
 			}
-			uData, err := test.PartyU.data()
+			info, err := fixedInfo(group.Config.FixedInfoPattern, test.Params.fixedInfoValues(group.Config.OutputBits, &test.PartyU, &test.PartyV))
 			if err != nil {
-				return nil, err
+				return nil, fmt.Errorf("test case %d/%d: %s", group.ID, test.ID, err)
 			}
-			vData, err := test.PartyV.data()
-			if err != nil {
-				return nil, err
+
+			// An absent salt is a string of zeros as long as the hash output
+			// (RFC 5869, section 2.2). An empty one is the same, as HMAC
+			// pads its key with zeros, so both are sent as the default.
+			if len(salt) == 0 {
+				salt = make([]byte, hash.size)
 			}
 
 			var expected []byte
@@ -167,10 +208,6 @@ func (k *hkdf) Process(vectorSet []byte, m Transactable) (any, error) {
 				}
 			}
 
-			info := make([]byte, 0, len(uData)+len(vData))
-			info = append(info, uData...)
-			info = append(info, vData...)
-
 			m.TransactAsync("HKDF/"+hashName, 1, [][]byte{key, salt, info, uint32le(outBytes)}, func(result [][]byte) error {
 				if len(result[0]) != int(outBytes) {
 					return fmt.Errorf("HKDF operation resulted in %d bytes but wanted %d", len(result[0]), outBytes)
@@ -0,0 +1,126 @@
+// Copyright (c) 2026, Google Inc.
+//
+// Permission to use, copy, modify, and/or distribute this software for any
+// purpose with or without fee is hereby granted, provided that the above
+// copyright notice and this permission notice appear in all copies.
+//
+// THE SOFTWARE IS PROVIDED "AS IS" AND THE AUTHOR DISCLAIMS ALL WARRANTIES
+// WITH REGARD TO THIS SOFTWARE INCLUDING ALL IMPLIED WARRANTIES OF
+// MERCHANTABILITY AND FITNESS. IN NO EVENT SHALL THE AUTHOR BE LIABLE FOR ANY
+// SPECIAL, DIRECT, INDIRECT, OR CONSEQUENTIAL DAMAGES OR ANY DAMAGES
+// WHATSOEVER RESULTING FROM LOSS OF USE, DATA OR PROFITS, WHETHER IN AN ACTION
+// OF CONTRACT, NEGLIGENCE OR OTHER TORTIOUS ACTION, ARISING OUT OF OR IN
+// CONNECTION WITH THE USE OR PERFORMANCE OF THIS SOFTWARE.
+
+package subprocess
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/binary"
+	"strings"
+	"testing"
+)
+
+// hkdfResponder implements HKDF-SHA256 per RFC 5869.
+func hkdfResponder(cmd string, args [][]byte) ([][]byte, error) {
+	if cmd != "HKDF/SHA2-256" {
+		panic("unexpected command " + cmd)
+	}
+	key, salt, info := args[0], args[1], args[2]
+	outLen := int(binary.LittleEndian.Uint32(args[3]))
+
+	extract := hmac.New(sha256.New, salt)
+	extract.Write(key)
+	prk := extract.Sum(nil)
+
+	var out, t []byte
+	for i := byte(1); len(out) < outLen; i++ {
+		expand := hmac.New(sha256.New, prk)
+		expand.Write(t)
+		expand.Write(info)
+		expand.Write([]byte{i})
+		t = expand.Sum(nil)
+		out = append(out, t...)
+	}
+	return [][]byte{out[:outLen]}, nil
+}
+
+func hkdfVectorSet(outBits, pattern string) string {
+	return `{"algorithm": "KDA", "mode": "HKDF", "testGroups": [{
+		"tgId": 1, "testType": "AFT",
+		"kdfConfiguration": {"kdfType": "hkdf", "l": ` + outBits + `, "hmacAlg": "SHA2-256",
+			"fixedInfoPattern": "` + pattern + `", "fixedInfoEncoding": "concatenation"},
+		"tests": [{
+			"tcId": 1,
+			"kdfParameter": {"kdfType": "hkdf", "salt": "", "z": "0b0b0b0b0b0b0b0b0b0b0b0b0b0b0b0b0b0b0b0b0b0b"},
+			"fixedInfoPartyU": {"partyId": ""}, "fixedInfoPartyV": {"partyId": ""}
+		}]
+	}]}`
+}
+
+func TestHKDFEmptySaltMultiBlock(t *testing.T) {
+	// RFC 5869, appendix A.3.
+	m := &fakeTransactable{respond: hkdfResponder}
+	h := &hkdf{map[string]Primitive{"SHA2-256": &hashPrimitive{"SHA2-256", 32}}}
+	result, err := h.Process([]byte(hkdfVectorSet("336", "uPartyInfo||vPartyInfo")), m)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if salt := m.calls[0].args[1]; !bytes.Equal(salt, make([]byte, 32)) {
+		t.Errorf("empty salt was sent as %x", salt)
+	}
+
+	const want = "8da4e775a563c18f715f802a063c5a31b8a11f5c5ee1879ec3454e5f3c738d2d9d201395faa4b61a96c8"
+	if got := result.([]hkdfTestGroupResponse)[0].Tests[0].KeyOut; got != want {
+		t.Errorf("got %s, want %s", got, want)
+	}
+}
+
+func TestHKDFFixedInfoAndLimit(t *testing.T) {
+	h := &hkdf{map[string]Primitive{"SHA2-256": &hashPrimitive{"SHA2-256", 32}}}
+
+	m := &fakeTransactable{respond: hkdfResponder}
+	if _, err := h.Process([]byte(hkdfVectorSet("256", "l||literal[0102]||uPartyInfo")), m); err != nil {
+		t.Fatal(err)
+	}
+	if info := m.calls[0].args[2]; !bytes.Equal(info, []byte{0, 0, 1, 0, 1, 2}) {
+		t.Errorf("fixed info is %x", info)
+	}
+
+	// 255*32 bytes is the most that HKDF-SHA256 can produce.
+	if _, err := h.Process([]byte(hkdfVectorSet("65280", "uPartyInfo")), &fakeTransactable{respond: hkdfResponder}); err != nil {
+		t.Errorf("maximum output length was rejected: %s", err)
+	}
+	if _, err := h.Process([]byte(hkdfVectorSet("65288", "uPartyInfo")), &fakeTransactable{respond: hkdfResponder}); err == nil {
+		t.Error("output longer than 255*HashLen was accepted")
+	}
+}
+
+func TestHKDFZeroLengthInputs(t *testing.T) {
+	// An empty key and info, with the salt absent or empty.
+	vectorSet := strings.Replace(hkdfVectorSet("256", "uPartyInfo"), `"z": "0b0b0b0b0b0b0b0b0b0b0b0b0b0b0b0b0b0b0b0b0b0b"`, `"z": ""`, 1)
+	absentSalt := strings.Replace(vectorSet, `"salt": "", `, "", 1)
+	h := &hkdf{map[string]Primitive{"SHA2-256": &hashPrimitive{"SHA2-256", 32}}}
+
+	for name, vectorSet := range map[string]string{"empty salt": vectorSet, "absent salt": absentSalt} {
+		m := &fakeTransactable{respond: hkdfResponder}
+		result, err := h.Process([]byte(vectorSet), m)
+		if err != nil {
+			t.Fatalf("%s: %s", name, err)
+		}
+		args := m.calls[0].args
+		if len(args[0]) != 0 || len(args[2]) != 0 {
+			t.Errorf("%s: empty key and info were sent as %x and %x", name, args[0], args[2])
+		}
+		if !bytes.Equal(args[1], make([]byte, 32)) {
+			t.Errorf("%s: salt was sent as %x, want the default", name, args[1])
+		}
+		const want = "eb70f01dede9afafa449eee1b1286504e1f62388b3f7dd4f956697b0e828fe18"
+		if got := result.([]hkdfTestGroupResponse)[0].Tests[0].KeyOut; got != want {
+			t.Errorf("%s: got %s, want %s", name, got, want)
+		}
+	}
+}
@@ -15,10 +15,10 @@
 package subprocess
 
 import (
+	"crypto/subtle"
 	"encoding/hex"
 	"encoding/json"
 	"fmt"
-	"strconv"
 )
 
 // The following structures reflect the JSON of ACVP HMAC tests. See
@@ -29,15 +29,19 @@ type hmacTestVectorSet struct {
 }
 
 type hmacTestGroup struct {
-	ID      uint64 `json:"tgId"`
-	Type    string `json:"testType"`
-	MsgBits int    `json:"msgLen"`
-	KeyBits int    `json:"keyLen"` // maximum possible value is 524288
-	MACBits int    `json:"macLen"` // maximum possible value is 512
-	Tests   []struct {
+	ID   uint64 `json:"tgId"`
+	Type string `json:"testType"`
+	// Direction is "ver" for groups whose tests give a MAC to be checked.
+	// Otherwise the MAC is generated.
+	Direction string `json:"direction"`
+	MsgBits   int    `json:"msgLen"`
+	KeyBits   int    `json:"keyLen"` // maximum possible value is 524288
+	MACBits   int    `json:"macLen"` // maximum possible value is 512
+	Tests     []struct {
 		ID     uint64 `json:"tcId"`
 		KeyHex string `json:"key"`
 		MsgHex string `json:"msg"`
+		MACHex string `json:"mac"`
 	} `json:"tests"`
 }
 
@@ -49,8 +53,17 @@ type hmacTestGroupResponse struct {
 type hmacTestResponse struct {
 	ID     uint64 `json:"tcId"`
 	MACHex string `json:"mac,omitempty"`
+	Passed *bool  `json:"testPassed,omitempty"`
 }
 
+// The ranges of key and MAC lengths, in bits, that the ACVP HMAC spec allows.
+// The MAC may be no longer than the hash output.
+const (
+	hmacMinKeyBits = 8
+	hmacMaxKeyBits = 524288
+	hmacMinMACBits = 32
+)
+
 // hmacPrimitive implements an ACVP algorithm by making requests to the
 // subprocess to HMAC strings with the given key.
 type hmacPrimitive struct {
@@ -61,19 +74,19 @@ type hmacPrimitive struct {
 }
 
 // hmac uses the subprocess to compute HMAC and returns the result.
-func (h *hmacPrimitive) hmac(msg []byte, key []byte, outBits int, m Transactable) []byte {
+func (h *hmacPrimitive) hmac(msg []byte, key []byte, outBits int, m Transactable) ([]byte, error) {
 	if outBits%8 != 0 {
-		panic("fractional-byte output length requested: " + strconv.Itoa(outBits))
+		return nil, fmt.Errorf("fractional-byte output length requested: %d", outBits)
 	}
 	outBytes := outBits / 8
 	result, err := m.Transact(h.algo, 1, msg, key)
 	if err != nil {
-		panic("HMAC operation failed: " + err.Error())
+		return nil, fmt.Errorf("HMAC operation failed: %s", err)
 	}
 	if l := len(result[0]); l < outBytes {
-		panic(fmt.Sprintf("HMAC result too short: %d bytes but wanted %d", l, outBytes))
+		return nil, fmt.Errorf("HMAC result too short: %d bytes but wanted %d", l, outBytes)
 	}
-	return result[0][:outBytes]
+	return result[0][:outBytes], nil
 }
 
 func (h *hmacPrimitive) Process(vectorSet []byte, m Transactable) (any, error) {
@@ -94,13 +107,29 @@ func (h *hmacPrimitive) Process(vectorSet []byte, m Transactable) (any, error) {
 		if group.MACBits > h.mdLen*8 {
 			return nil, fmt.Errorf("test group %d specifies MAC length should be %d, but maximum possible length is %d", group.ID, group.MACBits, h.mdLen*8)
 		}
+		if group.MACBits < hmacMinMACBits {
+			return nil, fmt.Errorf("test group %d specifies MAC length should be %d, but minimum possible length is %d", group.ID, group.MACBits, hmacMinMACBits)
+		}
 		if group.MACBits%8 != 0 {
 			return nil, fmt.Errorf("fractional-byte HMAC output length requested: %d", group.MACBits)
 		}
+		if group.KeyBits < hmacMinKeyBits || group.KeyBits > hmacMaxKeyBits {
+			return nil, fmt.Errorf("test group %d specifies a %d-bit key, but keys must be between %d and %d bits", group.ID, group.KeyBits, hmacMinKeyBits, hmacMaxKeyBits)
+		}
 		outBytes := group.MACBits / 8
 
+		var verify bool
+		switch group.Direction {
+		case "", "gen":
+		case "ver":
+			verify = true
+		default:
+			return nil, fmt.Errorf("unknown test direction %q in test group %d", group.Direction, group.ID)
+		}
+
 		for _, test := range group.Tests {
 			test := test
+			m := withTestCase(m, h.algo, group.ID, test.ID)
 
 			if len(test.MsgHex)*4 != group.MsgBits {
 				return nil, fmt.Errorf("test case %d/%d contains hex message of length %d but specifies a bit length of %d", group.ID, test.ID, len(test.MsgHex), group.MsgBits)
@@ -118,16 +147,33 @@ func (h *hmacPrimitive) Process(vectorSet []byte, m Transactable) (any, error) {
 				return nil, fmt.Errorf("failed to decode key in test case %d/%d: %s", group.ID, test.ID, err)
 			}
 
+			var expectedMAC []byte
+			if verify {
+				if len(test.MACHex)*4 != group.MACBits {
+					return nil, fmt.Errorf("test case %d/%d contains hex MAC of length %d but specifies a bit length of %d", group.ID, test.ID, len(test.MACHex), group.MACBits)
+				}
+				if expectedMAC, err = hex.DecodeString(test.MACHex); err != nil {
+					return nil, fmt.Errorf("failed to decode MAC in test case %d/%d: %s", group.ID, test.ID, err)
+				}
+			}
+
+			// The module always returns the full MAC, which is truncated
+			// here, so verification needs no separate command.
 			m.TransactAsync(h.algo, 1, [][]byte{msg, key}, func(result [][]byte) error {
 				if l := len(result[0]); l < outBytes {
 					return fmt.Errorf("HMAC result too short: %d bytes but wanted %d", l, outBytes)
 				}
+				mac := result[0][:outBytes]
 
 				// https://pages.nist.gov/ACVP/draft-fussell-acvp-mac.html#name-test-vectors
-				response.Tests = append(response.Tests, hmacTestResponse{
-					ID:     test.ID,
-					MACHex: hex.EncodeToString(result[0][:outBytes]),
-				})
+				testResp := hmacTestResponse{ID: test.ID}
+				if verify {
+					passed := subtle.ConstantTimeCompare(mac, expectedMAC) == 1
+					testResp.Passed = &passed
+				} else {
+					testResp.MACHex = hex.EncodeToString(mac)
+				}
+				response.Tests = append(response.Tests, testResp)
 				return nil
 			})
 		}
@@ -0,0 +1,127 @@
+// Copyright (c) 2026, Google Inc.
+//
+// Permission to use, copy, modify, and/or distribute this software for any
+// purpose with or without fee is hereby granted, provided that the above
+// copyright notice and this permission notice appear in all copies.
+//
+// THE SOFTWARE IS PROVIDED "AS IS" AND THE AUTHOR DISCLAIMS ALL WARRANTIES
+// WITH REGARD TO THIS SOFTWARE INCLUDING ALL IMPLIED WARRANTIES OF
+// MERCHANTABILITY AND FITNESS. IN NO EVENT SHALL THE AUTHOR BE LIABLE FOR ANY
+// SPECIAL, DIRECT, INDIRECT, OR CONSEQUENTIAL DAMAGES OR ANY DAMAGES
+// WHATSOEVER RESULTING FROM LOSS OF USE, DATA OR PROFITS, WHETHER IN AN ACTION
+// OF CONTRACT, NEGLIGENCE OR OTHER TORTIOUS ACTION, ARISING OUT OF OR IN
+// CONNECTION WITH THE USE OR PERFORMANCE OF THIS SOFTWARE.
+
+package subprocess
+
+import (
+	"crypto/hmac"
+	"crypto/sha1"
+	"crypto/sha256"
+	"crypto/sha512"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"hash"
+	"strings"
+	"testing"
+
+	"golang.org/x/crypto/sha3"
+)
+
+var hmacHashes = map[string]func() hash.Hash{
+	"HMAC-SHA-1":        sha1.New,
+	"HMAC-SHA2-256":     sha256.New,
+	"HMAC-SHA2-512/224": sha512.New512_224,
+	"HMAC-SHA3-384":     sha3.New384,
+}
+
+// hmacResponder computes HMACs with the hash named by the command.
+func hmacResponder(cmd string, args [][]byte) ([][]byte, error) {
+	newHash, ok := hmacHashes[cmd]
+	if !ok {
+		return nil, fmt.Errorf("unexpected command %q", cmd)
+	}
+	mac := hmac.New(newHash, args[1])
+	mac.Write(args[0])
+	return [][]byte{mac.Sum(nil)}, nil
+}
+
+// hmacVectorSet returns a vector set with a generation group and a
+// verification group, each of one test, for the given MAC length.
+func hmacVectorSet(macBits int, claimedMAC string) string {
+	return fmt.Sprintf(`{"testGroups": [
+		{"tgId": 1, "testType": "AFT", "keyLen": 64, "msgLen": 16, "macLen": %d, "tests": [
+			{"tcId": 1, "key": "0001020304050607", "msg": "abcd"}
+		]},
+		{"tgId": 2, "testType": "AFT", "direction": "ver", "keyLen": 64, "msgLen": 16, "macLen": %d, "tests": [
+			{"tcId": 2, "key": "0001020304050607", "msg": "abcd", "mac": %q}
+		]}
+	]}`, macBits, macBits, claimedMAC)
+}
+
+func TestHMAC(t *testing.T) {
+	key, _ := hex.DecodeString("0001020304050607")
+	for name, newHash := range hmacHashes {
+		mac := hmac.New(newHash, key)
+		mac.Write([]byte{0xab, 0xcd})
+		full := mac.Sum(nil)
+
+		// The full length, and a truncation to 32 bits.
+		for _, macBytes := range []int{len(full), 4} {
+			t.Run(fmt.Sprintf("%s/%d", name, macBytes*8), func(t *testing.T) {
+				want := hex.EncodeToString(full[:macBytes])
+				prim := &hmacPrimitive{name, len(full)}
+				m := &fakeTransactable{respond: hmacResponder}
+				result, err := prim.Process([]byte(hmacVectorSet(macBytes*8, want)), m)
+				if err != nil {
+					t.Fatal(err)
+				}
+
+				resultBytes, err := json.Marshal(result)
+				if err != nil {
+					t.Fatal(err)
+				}
+				expected := fmt.Sprintf(`[{"tgId":1,"tests":[{"tcId":1,"mac":"%s"}]},{"tgId":2,"tests":[{"tcId":2,"testPassed":true}]}]`, want)
+				if string(resultBytes) != expected {
+					t.Errorf("got %s, want %s", resultBytes, expected)
+				}
+			})
+		}
+	}
+}
+
+func TestHMACVerifyRejected(t *testing.T) {
+	prim := &hmacPrimitive{"HMAC-SHA2-256", 32}
+	m := &fakeTransactable{respond: hmacResponder}
+	result, err := prim.Process([]byte(hmacVectorSet(32, "00000000")), m)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	resultBytes, err := json.Marshal(result)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(resultBytes), `{"tcId":2,"testPassed":false}`) {
+		t.Errorf("wrong MAC was not rejected: %s", resultBytes)
+	}
+}
+
+func TestHMACInvalidLengths(t *testing.T) {
+	prim := &hmacPrimitive{"HMAC-SHA-1", 20}
+	for _, vectorSet := range []string{
+		// MAC longer than the digest.
+		`{"testGroups": [{"tgId": 1, "keyLen": 64, "msgLen": 0, "macLen": 168, "tests": []}]}`,
+		// MAC shorter than 32 bits.
+		`{"testGroups": [{"tgId": 1, "keyLen": 64, "msgLen": 0, "macLen": 24, "tests": []}]}`,
+		// No key.
+		`{"testGroups": [{"tgId": 1, "keyLen": 0, "msgLen": 0, "macLen": 160, "tests": []}]}`,
+		// Key longer than 524288 bits.
+		`{"testGroups": [{"tgId": 1, "keyLen": 524296, "msgLen": 0, "macLen": 160, "tests": []}]}`,
+	} {
+		if _, err := prim.Process([]byte(vectorSet), &fakeTransactable{respond: hmacResponder}); err == nil {
+			t.Errorf("invalid vector set was accepted: %s", vectorSet)
+		}
+	}
+}
@@ -0,0 +1,80 @@
+// Copyright (c) 2026, Google Inc.
+//
+// Permission to use, copy, modify, and/or distribute this software for any
+// purpose with or without fee is hereby granted, provided that the above
+// copyright notice and this permission notice appear in all copies.
+//
+// THE SOFTWARE IS PROVIDED "AS IS" AND THE AUTHOR DISCLAIMS ALL WARRANTIES
+// WITH REGARD TO THIS SOFTWARE INCLUDING ALL IMPLIED WARRANTIES OF
+// MERCHANTABILITY AND FITNESS. IN NO EVENT SHALL THE AUTHOR BE LIABLE FOR ANY
+// SPECIAL, DIRECT, INDIRECT, OR CONSEQUENTIAL DAMAGES OR ANY DAMAGES
+// WHATSOEVER RESULTING FROM LOSS OF USE, DATA OR PROFITS, WHETHER IN AN ACTION
+// OF CONTRACT, NEGLIGENCE OR OTHER TORTIOUS ACTION, ARISING OUT OF OR IN
+// CONNECTION WITH THE USE OR PERFORMANCE OF THIS SOFTWARE.
+
+package subprocess
+
+import (
+	"encoding/json"
+	"fmt"
+)
+
+// idChecker finds test groups, and test cases within a group, that share an
+// ID. Handlers answer every test case that they're given, so duplicates would
+// otherwise become responses that the ACVP server can't match to its tests.
+type idChecker struct {
+	groups map[uint64]bool
+}
+
+// checkGroup returns an error if group has the same tgId as a group that was
+// checked before, or has two test cases with the same tcId. Groups that
+// don't have the usual structure are left to their handlers.
+func (c *idChecker) checkGroup(group json.RawMessage) error {
+	var parsed struct {
+		ID    *uint64 `json:"tgId"`
+		Tests []struct {
+			ID *uint64 `json:"tcId"`
+		} `json:"tests"`
+	}
+	if err := json.Unmarshal(group, &parsed); err != nil || parsed.ID == nil {
+		return nil
+	}
+
+	if c.groups == nil {
+		c.groups = make(map[uint64]bool)
+	}
+	if c.groups[*parsed.ID] {
+		return fmt.Errorf("vector set contains more than one test group with tgId %d", *parsed.ID)
+	}
+	c.groups[*parsed.ID] = true
+
+	tests := make(map[uint64]bool)
+	for _, test := range parsed.Tests {
+		if test.ID == nil {
+			continue
+		}
+		if tests[*test.ID] {
+			return fmt.Errorf("test group %d contains more than one test case with tcId %d", *parsed.ID, *test.ID)
+		}
+		tests[*test.ID] = true
+	}
+	return nil
+}
+
+// checkIDs returns an error naming the first duplicate tgId, or tcId within a
+// group, in vectorSet.
+func checkIDs(vectorSet []byte) error {
+	var parsed struct {
+		Groups []json.RawMessage `json:"testGroups"`
+	}
+	if err := json.Unmarshal(vectorSet, &parsed); err != nil {
+		return nil
+	}
+	var c idChecker
+	for _, group := range parsed.Groups {
+		if err := c.checkGroup(group); err != nil {
+			return err
+		}
+	}
+	return nil
+}
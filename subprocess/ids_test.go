@@ -0,0 +1,76 @@
+// Copyright (c) 2026, Google Inc.
+//
+// Permission to use, copy, modify, and/or distribute this software for any
+// purpose with or without fee is hereby granted, provided that the above
+// copyright notice and this permission notice appear in all copies.
+//
+// THE SOFTWARE IS PROVIDED "AS IS" AND THE AUTHOR DISCLAIMS ALL WARRANTIES
+// WITH REGARD TO THIS SOFTWARE INCLUDING ALL IMPLIED WARRANTIES OF
+// MERCHANTABILITY AND FITNESS. IN NO EVENT SHALL THE AUTHOR BE LIABLE FOR ANY
+// SPECIAL, DIRECT, INDIRECT, OR CONSEQUENTIAL DAMAGES OR ANY DAMAGES
+// WHATSOEVER RESULTING FROM LOSS OF USE, DATA OR PROFITS, WHETHER IN AN ACTION
+// OF CONTRACT, NEGLIGENCE OR OTHER TORTIOUS ACTION, ARISING OUT OF OR IN
+// CONNECTION WITH THE USE OR PERFORMANCE OF THIS SOFTWARE.
+
+package subprocess
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+)
+
+func TestCheckIDs(t *testing.T) {
+	for _, tc := range []struct {
+		vectorSet string
+		wantErr   string
+	}{
+		{`{"testGroups": [{"tgId": 1, "tests": [{"tcId": 1}, {"tcId": 2}]}, {"tgId": 2, "tests": [{"tcId": 1}]}]}`, ""},
+		{`{"testGroups": [{"tgId": 1, "tests": [{"tcId": 1}, {"tcId": 1}]}]}`, "test group 1 contains more than one test case with tcId 1"},
+		{`{"testGroups": [{"tgId": 7, "tests": []}, {"tgId": 7, "tests": []}]}`, "more than one test group with tgId 7"},
+		// Vector sets without the usual structure are left to handlers.
+		{`{"testGroups": {"tgId": 1}}`, ""},
+		{`{"testGroups": [{"tests": [{"tcId": 1}, {"tcId": 1}]}]}`, ""},
+	} {
+		err := checkIDs([]byte(tc.vectorSet))
+		switch {
+		case tc.wantErr == "" && err != nil:
+			t.Errorf("%s: unexpected error %s", tc.vectorSet, err)
+		case tc.wantErr != "" && (err == nil || !strings.Contains(err.Error(), tc.wantErr)):
+			t.Errorf("%s: got error %v, want one containing %q", tc.vectorSet, err, tc.wantErr)
+		}
+	}
+}
+
+func TestRunDuplicateTestCase(t *testing.T) {
+	vectorSet := `{"testGroups": [{"tgId": 1, "testType": "AFT", "tests": [
+		{"tcId": 1, "len": 8, "msg": "00", "outLen": 16, "functionName": "", "customization": ""},
+		{"tcId": 1, "len": 8, "msg": "01", "outLen": 16, "functionName": "", "customization": ""}
+	]}]}`
+
+	m := &fakeTransactable{respond: cShakeStreamResponder}
+	_, err := Run("cSHAKE-128", []byte(vectorSet), m)
+	if err == nil || !strings.Contains(err.Error(), "test group 1 contains more than one test case with tcId 1") {
+		t.Fatalf("got error %v, want one naming the duplicate", err)
+	}
+	if len(m.calls) != 0 {
+		t.Errorf("%d commands were sent for a rejected vector set", len(m.calls))
+	}
+}
+
+func TestProcessStreamDuplicateGroup(t *testing.T) {
+	vectorSet := `{"algorithm": "cSHAKE-128", "testGroups": [
+		{"tgId": 1, "testType": "AFT", "tests": [{"tcId": 1, "len": 8, "msg": "00", "outLen": 16, "functionName": "", "customization": ""}]},
+		{"tgId": 1, "testType": "AFT", "tests": [{"tcId": 2, "len": 8, "msg": "01", "outLen": 16, "functionName": "", "customization": ""}]}
+	]}`
+
+	m := &fakeTransactable{respond: cShakeStreamResponder}
+	dec := json.NewDecoder(strings.NewReader(vectorSet))
+	_, _, err := ProcessStream(dec, func(algorithm string, vectorSet []byte) (any, error) {
+		result, err := Run(algorithm, vectorSet, m)
+		return json.RawMessage(result), err
+	})
+	if err == nil || !strings.Contains(err.Error(), "more than one test group with tgId 1") {
+		t.Fatalf("got error %v, want one naming the duplicate group", err)
+	}
+}
@@ -0,0 +1,175 @@
+// Copyright (c) 2026, Google Inc.
+//
+// Permission to use, copy, modify, and/or distribute this software for any
+// purpose with or without fee is hereby granted, provided that the above
+// copyright notice and this permission notice appear in all copies.
+//
+// THE SOFTWARE IS PROVIDED "AS IS" AND THE AUTHOR DISCLAIMS ALL WARRANTIES
+// WITH REGARD TO THIS SOFTWARE INCLUDING ALL IMPLIED WARRANTIES OF
+// MERCHANTABILITY AND FITNESS. IN NO EVENT SHALL THE AUTHOR BE LIABLE FOR ANY
+// SPECIAL, DIRECT, INDIRECT, OR CONSEQUENTIAL DAMAGES OR ANY DAMAGES
+// WHATSOEVER RESULTING FROM LOSS OF USE, DATA OR PROFITS, WHETHER IN AN ACTION
+// OF CONTRACT, NEGLIGENCE OR OTHER TORTIOUS ACTION, ARISING OUT OF OR IN
+// CONNECTION WITH THE USE OR PERFORMANCE OF THIS SOFTWARE.
+
+package subprocess
+
+import (
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+)
+
+// The following structures reflect the JSON of ACVP IKEv2 KDF tests. See
+// https://pages.nist.gov/ACVP/draft-hammett-acvp-kdf-ikev2.html
+
+type ikev2TestVectorSet struct {
+	Groups []ikev2TestGroup `json:"testGroups"`
+}
+
+type ikev2TestGroup struct {
+	ID      uint64 `json:"tgId"`
+	Type    string `json:"testType"`
+	HashAlg string `json:"hashAlg"`
+	DKMBits uint32 `json:"derivedKeyingMaterialLength"`
+	// Rekey indicates that, in addition to the initial derivation, the
+	// tests exercise the derivations that use the SK_d of the initial IKE SA
+	// and a new DH shared secret.
+	Rekey bool `json:"rekey"`
+	Tests []struct {
+		ID         uint64 `json:"tcId"`
+		NInitHex   string `json:"nInit"`
+		NRespHex   string `json:"nResp"`
+		GirHex     string `json:"gir"`
+		GirNewHex  string `json:"girNew"`
+		SPIInitHex string `json:"spiInit"`
+		SPIRespHex string `json:"spiResp"`
+	} `json:"tests"`
+}
+
+type ikev2TestGroupResponse struct {
+	ID    uint64              `json:"tgId"`
+	Tests []ikev2TestResponse `json:"tests"`
+}
+
+type ikev2TestResponse struct {
+	ID            uint64 `json:"tcId"`
+	SKeySeedHex   string `json:"sKeySeed"`
+	DKMHex        string `json:"derivedKeyingMaterial"`
+	DKMChildHex   string `json:"derivedKeyingMaterialChild,omitempty"`
+	DKMDHHex      string `json:"derivedKeyingMaterialDh,omitempty"`
+	SKeySeedReHex string `json:"sKeySeedReKey,omitempty"`
+}
+
+// ikev2 implements the IKEv2 KDF of RFC 7296, section 2.14, by asking the
+// subprocess for SKEYSEED and the keying material expanded from it. The SK_d
+// needed for rekeying is the first PRF-output-sized part of that keying
+// material, and SKEYSEED is one PRF output long.
+type ikev2 struct{}
+
+func (k *ikev2) Process(vectorSet []byte, m Transactable) (any, error) {
+	var parsed ikev2TestVectorSet
+	if err := json.Unmarshal(vectorSet, &parsed); err != nil {
+		return nil, err
+	}
+
+	var ret []ikev2TestGroupResponse
+	for _, group := range parsed.Groups {
+		group := group
+
+		// Only the AFT test type is specified for IKEv2:
+		// https://pages.nist.gov/ACVP/draft-hammett-acvp-kdf-ikev2.html#name-test-types
+		if group.Type != "AFT" {
+			return nil, fmt.Errorf("test group %d had unexpected test type: %q", group.ID, group.Type)
+		}
+
+		switch group.HashAlg {
+		case "SHA-1", "SHA2-224", "SHA2-256", "SHA2-384", "SHA2-512":
+			break
+		default:
+			return nil, fmt.Errorf("test group %d has unsupported hash %q", group.ID, group.HashAlg)
+		}
+
+		if group.DKMBits == 0 || group.DKMBits%8 != 0 {
+			return nil, fmt.Errorf("test group %d has derived keying material length %d - fractional bytes not supported", group.ID, group.DKMBits)
+		}
+		dkmBytes := int(group.DKMBits / 8)
+		dkmLen := uint32le(group.DKMBits / 8)
+
+		response := ikev2TestGroupResponse{
+			ID: group.ID,
+		}
+
+		for _, test := range group.Tests {
+			test := test
+			m := withTestCase(m, "kdf-components", group.ID, test.ID)
+
+			var fields [][]byte
+			for _, field := range []struct {
+				name string
+				hex  string
+			}{
+				{"nInit", test.NInitHex},
+				{"nResp", test.NRespHex},
+				{"gir", test.GirHex},
+				{"girNew", test.GirNewHex},
+				{"spiInit", test.SPIInitHex},
+				{"spiResp", test.SPIRespHex},
+			} {
+				value, err := hex.DecodeString(field.hex)
+				if err != nil {
+					return nil, fmt.Errorf("failed to decode %s hex in test case %d/%d: %s", field.name, group.ID, test.ID, err)
+				}
+				fields = append(fields, value)
+			}
+			nInit, nResp, gir, girNew, spiInit, spiResp := fields[0], fields[1], fields[2], fields[3], fields[4], fields[5]
+
+			if group.Rekey && len(girNew) == 0 {
+				return nil, fmt.Errorf("rekey test case %d/%d is missing girNew", group.ID, test.ID)
+			}
+
+			cmd := "IKEv2/" + group.HashAlg
+			result, err := m.Transact(cmd, 2, dkmLen, nInit, nResp, gir, spiInit, spiResp)
+			if err != nil {
+				return nil, fmt.Errorf("%s operation failed for test case %d/%d: %s", cmd, group.ID, test.ID, err)
+			}
+			sKeySeed, dkm := result[0], result[1]
+			if len(dkm) != dkmBytes || len(sKeySeed) > len(dkm) {
+				return nil, fmt.Errorf("%s returned %d bytes of keying material and a %d-byte SKEYSEED for test case %d/%d, but %d bytes were requested", cmd, len(dkm), len(sKeySeed), group.ID, test.ID, dkmBytes)
+			}
+
+			resp := ikev2TestResponse{
+				ID:          test.ID,
+				SKeySeedHex: hex.EncodeToString(sKeySeed),
+				DKMHex:      hex.EncodeToString(dkm),
+			}
+
+			if !group.Rekey {
+				response.Tests = append(response.Tests, resp)
+				continue
+			}
+
+			skD := dkm[:len(sKeySeed)]
+			m.TransactAsync(cmd+"/rekey", 3, [][]byte{dkmLen, nInit, nResp, girNew, skD}, func(result [][]byte) error {
+				if len(result[0]) != dkmBytes || len(result[1]) != dkmBytes {
+					return fmt.Errorf("%s/rekey returned %d and %d bytes of keying material for test case %d/%d, but %d bytes were requested", cmd, len(result[0]), len(result[1]), group.ID, test.ID, dkmBytes)
+				}
+				resp.DKMChildHex = hex.EncodeToString(result[0])
+				resp.DKMDHHex = hex.EncodeToString(result[1])
+				resp.SKeySeedReHex = hex.EncodeToString(result[2])
+				response.Tests = append(response.Tests, resp)
+				return nil
+			})
+		}
+
+		m.Barrier(func() {
+			ret = append(ret, response)
+		})
+	}
+
+	if err := m.Flush(); err != nil {
+		return nil, err
+	}
+
+	return ret, nil
+}
@@ -0,0 +1,95 @@
+// Copyright (c) 2026, Google Inc.
+//
+// Permission to use, copy, modify, and/or distribute this software for any
+// purpose with or without fee is hereby granted, provided that the above
+// copyright notice and this permission notice appear in all copies.
+//
+// THE SOFTWARE IS PROVIDED "AS IS" AND THE AUTHOR DISCLAIMS ALL WARRANTIES
+// WITH REGARD TO THIS SOFTWARE INCLUDING ALL IMPLIED WARRANTIES OF
+// MERCHANTABILITY AND FITNESS. IN NO EVENT SHALL THE AUTHOR BE LIABLE FOR ANY
+// SPECIAL, DIRECT, INDIRECT, OR CONSEQUENTIAL DAMAGES OR ANY DAMAGES
+// WHATSOEVER RESULTING FROM LOSS OF USE, DATA OR PROFITS, WHETHER IN AN ACTION
+// OF CONTRACT, NEGLIGENCE OR OTHER TORTIOUS ACTION, ARISING OUT OF OR IN
+// CONNECTION WITH THE USE OR PERFORMANCE OF THIS SOFTWARE.
+
+package subprocess
+
+import (
+	"bytes"
+	"encoding/binary"
+	"testing"
+)
+
+// ikev2Responder returns a four-byte SKEYSEED and keying material that counts
+// up from one, so that SK_d is 01020304.
+func ikev2Responder(cmd string, args [][]byte) ([][]byte, error) {
+	dkm := make([]byte, binary.LittleEndian.Uint32(args[0]))
+	for i := range dkm {
+		dkm[i] = byte(i + 1)
+	}
+	switch cmd {
+	case "IKEv2/SHA2-256":
+		return [][]byte{{0x5e, 0xed, 0x5e, 0xed}, dkm}, nil
+	case "IKEv2/SHA2-256/rekey":
+		return [][]byte{dkm, dkm, args[4]}, nil
+	}
+	panic("unexpected command " + cmd)
+}
+
+func ikev2VectorSet(rekey string) string {
+	return `{"algorithm": "kdf-components", "mode": "ikev2", "testGroups": [{
+		"tgId": 1, "testType": "AFT", "hashAlg": "SHA2-256", "derivedKeyingMaterialLength": 64` + rekey + `,
+		"tests": [{"tcId": 1, "nInit": "01", "nResp": "02", "gir": "03", "girNew": "04", "spiInit": "05", "spiResp": "06"}]
+	}]}`
+}
+
+func TestIKEv2Initial(t *testing.T) {
+	m := &fakeTransactable{respond: ikev2Responder}
+	result, err := (&kdfComponents{map[string]Primitive{"ikev2": &ikev2{}}}).Process([]byte(ikev2VectorSet("")), m)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(m.calls) != 1 {
+		t.Fatalf("got %d calls, want 1", len(m.calls))
+	}
+	want := [][]byte{uint32le(8), {1}, {2}, {3}, {5}, {6}}
+	for i, arg := range m.calls[0].args {
+		if !bytes.Equal(arg, want[i]) {
+			t.Errorf("argument %d is %x, want %x", i, arg, want[i])
+		}
+	}
+
+	test := result.([]ikev2TestGroupResponse)[0].Tests[0]
+	if test.SKeySeedHex != "5eed5eed" || test.DKMHex != "0102030405060708" || len(test.SKeySeedReHex) != 0 {
+		t.Errorf("unexpected response: %+v", test)
+	}
+}
+
+func TestIKEv2Rekey(t *testing.T) {
+	m := &fakeTransactable{respond: ikev2Responder}
+	result, err := new(ikev2).Process([]byte(ikev2VectorSet(`, "rekey": true`)), m)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(m.calls) != 2 || m.calls[1].cmd != "IKEv2/SHA2-256/rekey" {
+		t.Fatalf("unexpected calls: %+v", m.calls)
+	}
+	want := [][]byte{uint32le(8), {1}, {2}, {4}, {1, 2, 3, 4}}
+	for i, arg := range m.calls[1].args {
+		if !bytes.Equal(arg, want[i]) {
+			t.Errorf("rekey argument %d is %x, want %x", i, arg, want[i])
+		}
+	}
+
+	test := result.([]ikev2TestGroupResponse)[0].Tests[0]
+	if test.DKMChildHex != "0102030405060708" || test.DKMDHHex != "0102030405060708" || test.SKeySeedReHex != "01020304" {
+		t.Errorf("unexpected response: %+v", test)
+	}
+
+	noGirNew := bytes.Replace([]byte(ikev2VectorSet(`, "rekey": true`)), []byte(`"girNew": "04", `), nil, 1)
+	if _, err := new(ikev2).Process(noGirNew, &fakeTransactable{respond: ikev2Responder}); err == nil {
+		t.Error("rekey test without girNew was accepted")
+	}
+}
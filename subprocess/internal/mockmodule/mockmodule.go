@@ -0,0 +1,805 @@
+// Copyright (c) 2026, Google Inc.
+//
+// Permission to use, copy, modify, and/or distribute this software for any
+// purpose with or without fee is hereby granted, provided that the above
+// copyright notice and this permission notice appear in all copies.
+//
+// THE SOFTWARE IS PROVIDED "AS IS" AND THE AUTHOR DISCLAIMS ALL WARRANTIES
+// WITH REGARD TO THIS SOFTWARE INCLUDING ALL IMPLIED WARRANTIES OF
+// MERCHANTABILITY AND FITNESS. IN NO EVENT SHALL THE AUTHOR BE LIABLE FOR ANY
+// SPECIAL, DIRECT, INDIRECT, OR CONSEQUENTIAL DAMAGES OR ANY DAMAGES
+// WHATSOEVER RESULTING FROM LOSS OF USE, DATA OR PROFITS, WHETHER IN AN ACTION
+// OF CONTRACT, NEGLIGENCE OR OTHER TORTIOUS ACTION, ARISING OUT OF OR IN
+// CONNECTION WITH THE USE OR PERFORMANCE OF THIS SOFTWARE.
+
+// Package mockmodule answers module commands in-process with Go's own
+// cryptography, so that handlers can be tested against real results without
+// running a module wrapper. A Module satisfies subprocess.Transactable.
+//
+// Only single operations are implemented: Monte Carlo commands, and AES with
+// more than one iteration, are rejected, except for those of the hashes and of
+// AES-OFB.
+package mockmodule
+
+import (
+	"bytes"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/des"
+	"crypto/ecdh"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha1"
+	"crypto/sha256"
+	"crypto/sha512"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"hash"
+	"math/big"
+	"strings"
+
+	"golang.org/x/crypto/sha3"
+)
+
+// Handler computes the results of a command from its arguments.
+type Handler func(args [][]byte) ([][]byte, error)
+
+// Module answers commands synchronously from a table of handlers. Errors from
+// asynchronous commands, or from their callbacks, are held until Flush.
+type Module struct {
+	handlers map[string]Handler
+	err      error
+}
+
+var hashes = map[string]func() hash.Hash{
+	"SHA-1":        sha1.New,
+	"SHA2-224":     sha256.New224,
+	"SHA2-256":     sha256.New,
+	"SHA2-384":     sha512.New384,
+	"SHA2-512":     sha512.New,
+	"SHA2-512/224": sha512.New512_224,
+	"SHA2-512/256": sha512.New512_256,
+	"SHA3-224":     sha3.New224,
+	"SHA3-256":     sha3.New256,
+	"SHA3-384":     sha3.New384,
+	"SHA3-512":     sha3.New512,
+}
+
+var ecdhCurves = map[string]ecdh.Curve{
+	"P-256": ecdh.P256(),
+	"P-384": ecdh.P384(),
+	"P-521": ecdh.P521(),
+}
+
+var curves = map[string]elliptic.Curve{
+	"P-224": elliptic.P224(),
+	"P-256": elliptic.P256(),
+	"P-384": elliptic.P384(),
+	"P-521": elliptic.P521(),
+}
+
+// hashMCT runs the inner loop of the Monte Carlo test of a hash. For SHA-3
+// each digest is of the previous one, truncated or padded to the length of
+// the seed. For SHA-1 and SHA-2 each is of the three before it. See
+// https://pages.nist.gov/ACVP/draft-celi-acvp-sha.html#name-monte-carlo-tests-for-sha-1
+// and https://pages.nist.gov/ACVP/draft-celi-acvp-sha3.html#name-monte-carlo-tests-for-sha3.
+func hashMCT(newHash func() hash.Hash, seed []byte, isSHA3 bool) []byte {
+	if isSHA3 {
+		md := seed
+		for i := 0; i < 1000; i++ {
+			msg := make([]byte, len(seed))
+			copy(msg, md)
+			h := newHash()
+			h.Write(msg)
+			md = h.Sum(nil)
+		}
+		return md
+	}
+
+	a, b, c := seed, seed, seed
+	for i := 0; i < 1000; i++ {
+		h := newHash()
+		h.Write(a)
+		h.Write(b)
+		h.Write(c)
+		a, b, c = b, c, h.Sum(nil)
+	}
+	return c
+}
+
+// New returns a Module that answers the hash (including Monte Carlo), SHAKE,
+// cSHAKE, KMAC, HMAC, AES-ECB, AES-CBC, AES-CFB1, AES-CFB8, AES-CTR, AES-OFB
+// (including Monte Carlo), AES-GCM, ECDSA key generation and verification and
+// ECDSA component signing commands, and ECDH on P-256, P-384 and P-521.
+func New() *Module {
+	m := &Module{handlers: make(map[string]Handler)}
+
+	for name, newHash := range hashes {
+		newHash := newHash
+		m.handlers[name] = func(args [][]byte) ([][]byte, error) {
+			if err := checkArgs(args, 1); err != nil {
+				return nil, err
+			}
+			h := newHash()
+			h.Write(args[0])
+			return [][]byte{h.Sum(nil)}, nil
+		}
+		isSHA3 := strings.HasPrefix(name, "SHA3-")
+		m.handlers[name+"/MCT"] = func(args [][]byte) ([][]byte, error) {
+			if err := checkArgs(args, 1); err != nil {
+				return nil, err
+			}
+			return [][]byte{hashMCT(newHash, args[0], isSHA3)}, nil
+		}
+		m.handlers["HMAC-"+name] = func(args [][]byte) ([][]byte, error) {
+			if err := checkArgs(args, 2); err != nil {
+				return nil, err
+			}
+			h := hmac.New(newHash, args[1])
+			h.Write(args[0])
+			return [][]byte{h.Sum(nil)}, nil
+		}
+	}
+
+	for _, size := range []int{128, 256} {
+		size := size
+		newShake := func() sha3.ShakeHash { return sha3.NewShake256() }
+		newCShake := sha3.NewCShake256
+		if size == 128 {
+			newShake = func() sha3.ShakeHash { return sha3.NewShake128() }
+			newCShake = sha3.NewCShake128
+		}
+
+		shake := func(args [][]byte) ([][]byte, error) {
+			if err := checkArgs(args, 2); err != nil {
+				return nil, err
+			}
+			outLen, err := length(args[1])
+			if err != nil {
+				return nil, err
+			}
+			h := newShake()
+			h.Write(args[0])
+			return [][]byte{read(h, outLen)}, nil
+		}
+		m.handlers[fmt.Sprintf("SHAKE-%d", size)] = shake
+		m.handlers[fmt.Sprintf("SHAKE-%d/VOT", size)] = shake
+
+		m.handlers[fmt.Sprintf("cSHAKE-%d", size)] = func(args [][]byte) ([][]byte, error) {
+			if err := checkArgs(args, 4); err != nil {
+				return nil, err
+			}
+			outLen, err := length(args[1])
+			if err != nil {
+				return nil, err
+			}
+			h := newCShake(args[2], args[3])
+			h.Write(args[0])
+			return [][]byte{read(h, outLen)}, nil
+		}
+
+		// KMAC is cSHAKE with the function name "KMAC", as in SP 800-185,
+		// section 4.3.
+		rate := 200 - size/4
+		kmac := func(key, msg, customization []byte, outLen int, xof bool) []byte {
+			h := newCShake([]byte("KMAC"), customization)
+			h.Write(bytepad(append(leftEncode(uint64(len(key))*8), key...), rate))
+			h.Write(msg)
+			if xof {
+				h.Write(rightEncode(0))
+			} else {
+				h.Write(rightEncode(uint64(outLen) * 8))
+			}
+			return read(h, outLen)
+		}
+		m.handlers[fmt.Sprintf("KMAC-%d", size)] = func(args [][]byte) ([][]byte, error) {
+			if err := checkArgs(args, 5); err != nil {
+				return nil, err
+			}
+			outLen, err := length(args[2])
+			if err != nil {
+				return nil, err
+			}
+			return [][]byte{kmac(args[0], args[1], args[3], outLen, isSet(args[4]))}, nil
+		}
+		m.handlers[fmt.Sprintf("KMAC-%d/verify", size)] = func(args [][]byte) ([][]byte, error) {
+			if err := checkArgs(args, 5); err != nil {
+				return nil, err
+			}
+			mac := kmac(args[0], args[1], args[3], len(args[2]), isSet(args[4]))
+			return [][]byte{boolean(hmac.Equal(mac, args[2]))}, nil
+		}
+	}
+
+	for _, dir := range []string{"encrypt", "decrypt"} {
+		encrypt := dir == "encrypt"
+		m.handlers["AES/"+dir] = func(args [][]byte) ([][]byte, error) {
+			block, input, err := aesArgs(args, 3)
+			if err != nil {
+				return nil, err
+			}
+			if len(input)%aes.BlockSize != 0 {
+				return nil, fmt.Errorf("input of %d bytes is not a whole number of blocks", len(input))
+			}
+			out := make([]byte, len(input))
+			for i := 0; i < len(input); i += aes.BlockSize {
+				if encrypt {
+					block.Encrypt(out[i:], input[i:])
+				} else {
+					block.Decrypt(out[i:], input[i:])
+				}
+			}
+			return [][]byte{out, input}, nil
+		}
+		m.handlers["AES-CBC/"+dir] = func(args [][]byte) ([][]byte, error) {
+			block, input, err := aesArgs(args, 4)
+			if err != nil {
+				return nil, err
+			}
+			if len(input)%aes.BlockSize != 0 {
+				return nil, fmt.Errorf("input of %d bytes is not a whole number of blocks", len(input))
+			}
+			if len(args[2]) != aes.BlockSize {
+				return nil, fmt.Errorf("IV is %d bytes long", len(args[2]))
+			}
+			out := make([]byte, len(input))
+			if encrypt {
+				cipher.NewCBCEncrypter(block, args[2]).CryptBlocks(out, input)
+			} else {
+				cipher.NewCBCDecrypter(block, args[2]).CryptBlocks(out, input)
+			}
+			return [][]byte{out, args[2]}, nil
+		}
+		m.handlers["AES-OFB/"+dir] = func(args [][]byte) ([][]byte, error) {
+			if err := checkArgs(args, 4); err != nil {
+				return nil, err
+			}
+			iterations, err := length(args[3])
+			if err != nil {
+				return nil, err
+			}
+			block, err := aes.NewCipher(args[0])
+			if err != nil {
+				return nil, err
+			}
+			if len(args[1])%aes.BlockSize != 0 {
+				return nil, fmt.Errorf("input of %d bytes is not a whole number of blocks", len(args[1]))
+			}
+			if len(args[2]) != aes.BlockSize {
+				return nil, fmt.Errorf("IV is %d bytes long", len(args[2]))
+			}
+			result, prevResult := ofbIterate(block, args[1], args[2], iterations)
+			return [][]byte{result, prevResult}, nil
+		}
+		m.handlers["AES-CFB8/"+dir] = func(args [][]byte) ([][]byte, error) {
+			block, input, err := aesArgs(args, 4)
+			if err != nil {
+				return nil, err
+			}
+			if len(args[2]) != aes.BlockSize {
+				return nil, fmt.Errorf("IV is %d bytes long", len(args[2]))
+			}
+			return [][]byte{cfb(block, args[2], input, 8, len(input), encrypt)}, nil
+		}
+		m.handlers["AES-CFB1/"+dir] = func(args [][]byte) ([][]byte, error) {
+			if err := checkArgs(args, 5); err != nil {
+				return nil, err
+			}
+			block, input, err := aesArgs(args[:4], 4)
+			if err != nil {
+				return nil, err
+			}
+			if len(args[2]) != aes.BlockSize {
+				return nil, fmt.Errorf("IV is %d bytes long", len(args[2]))
+			}
+			inputBits, err := length(args[4])
+			if err != nil {
+				return nil, err
+			}
+			if (inputBits+7)/8 != len(input) {
+				return nil, fmt.Errorf("%d-bit input is %d bytes long", inputBits, len(input))
+			}
+			return [][]byte{cfb(block, args[2], input, 1, inputBits, encrypt)}, nil
+		}
+		m.handlers["AES-CTR/"+dir] = func(args [][]byte) ([][]byte, error) {
+			block, input, err := aesArgs(args, 4)
+			if err != nil {
+				return nil, err
+			}
+			if len(args[2]) != aes.BlockSize {
+				return nil, fmt.Errorf("initial counter is %d bytes long", len(args[2]))
+			}
+			out := make([]byte, len(input))
+			cipher.NewCTR(block, args[2]).XORKeyStream(out, input)
+			return [][]byte{out}, nil
+		}
+	}
+
+	m.handlers["AES-GCM/seal"] = func(args [][]byte) ([][]byte, error) {
+		gcm, err := gcmArgs(args)
+		if err != nil {
+			return nil, err
+		}
+		return [][]byte{gcm.Seal(nil, args[3], args[2], args[4])}, nil
+	}
+	m.handlers["AES-GCM/open"] = func(args [][]byte) ([][]byte, error) {
+		gcm, err := gcmArgs(args)
+		if err != nil {
+			return nil, err
+		}
+		plaintext, err := gcm.Open(nil, args[3], args[2], args[4])
+		if err != nil {
+			return [][]byte{boolean(false), nil}, nil
+		}
+		return [][]byte{boolean(true), plaintext}, nil
+	}
+
+	cbcMACs := map[string]func(key []byte) (cbcMACCipher, error){
+		"CBC-MAC-AES": func(key []byte) (cbcMACCipher, error) {
+			block, err := aes.NewCipher(key)
+			return cbcMACCipher{block, nil}, err
+		},
+		"RetailMAC-TDES": func(key []byte) (cbcMACCipher, error) {
+			if len(key) != 16 {
+				return cbcMACCipher{}, fmt.Errorf("Retail MAC key is %d bytes long", len(key))
+			}
+			k1, err := des.NewCipher(key[:8])
+			if err != nil {
+				return cbcMACCipher{}, err
+			}
+			k2, err := des.NewCipher(key[8:])
+			return cbcMACCipher{k1, k2}, err
+		},
+	}
+	for name, newCipher := range cbcMACs {
+		newCipher := newCipher
+		mac := func(key, msg, method []byte) ([]byte, error) {
+			c, err := newCipher(key)
+			if err != nil {
+				return nil, err
+			}
+			padding, err := length(method)
+			if err != nil {
+				return nil, err
+			}
+			return c.mac(msg, padding)
+		}
+		m.handlers[name] = func(args [][]byte) ([][]byte, error) {
+			if err := checkArgs(args, 4); err != nil {
+				return nil, err
+			}
+			outLen, err := length(args[0])
+			if err != nil {
+				return nil, err
+			}
+			tag, err := mac(args[1], args[2], args[3])
+			if err != nil {
+				return nil, err
+			}
+			if outLen > len(tag) {
+				return nil, fmt.Errorf("%d-byte MAC requested from a %d-byte block", outLen, len(tag))
+			}
+			return [][]byte{tag[:outLen]}, nil
+		}
+		m.handlers[name+"/verify"] = func(args [][]byte) ([][]byte, error) {
+			if err := checkArgs(args, 4); err != nil {
+				return nil, err
+			}
+			tag, err := mac(args[0], args[1], args[3])
+			if err != nil {
+				return nil, err
+			}
+			ok := len(args[2]) <= len(tag) && hmac.Equal(tag[:len(args[2])], args[2])
+			return [][]byte{boolean(ok)}, nil
+		}
+	}
+
+	m.handlers["ECDSA/keyVer"] = func(args [][]byte) ([][]byte, error) {
+		if err := checkArgs(args, 3); err != nil {
+			return nil, err
+		}
+		curve, ok := curves[string(args[0])]
+		if !ok {
+			return nil, fmt.Errorf("unknown curve %q", args[0])
+		}
+		return [][]byte{boolean(validPoint(curve, args[1], args[2]))}, nil
+	}
+
+	m.handlers["ECDSA/keyGen"] = func(args [][]byte) ([][]byte, error) {
+		if err := checkArgs(args, 1); err != nil {
+			return nil, err
+		}
+		curve, ok := curves[string(args[0])]
+		if !ok {
+			return nil, fmt.Errorf("unknown curve %q", args[0])
+		}
+		priv, err := ecdsa.GenerateKey(curve, rand.Reader)
+		if err != nil {
+			return nil, err
+		}
+		n := (curve.Params().BitSize + 7) / 8
+		return [][]byte{priv.D.FillBytes(make([]byte, n)), priv.X.FillBytes(make([]byte, n)), priv.Y.FillBytes(make([]byte, n))}, nil
+	}
+
+	// The message of a component test is already the digest, which is
+	// signed as is. Like any ECDSA signature, only as many of its leftmost
+	// bits as the order of the curve has are used (FIPS 186-5, section
+	// 6.4.1), which Sign does.
+	m.handlers["ECDSA/sigGen/componentTest"] = func(args [][]byte) ([][]byte, error) {
+		if err := checkArgs(args, 4); err != nil {
+			return nil, err
+		}
+		curve, ok := curves[string(args[0])]
+		if !ok {
+			return nil, fmt.Errorf("unknown curve %q", args[0])
+		}
+		priv := &ecdsa.PrivateKey{PublicKey: ecdsa.PublicKey{Curve: curve}, D: new(big.Int).SetBytes(args[1])}
+		priv.X, priv.Y = curve.ScalarBaseMult(args[1])
+		r, s, err := ecdsa.Sign(rand.Reader, priv, args[3])
+		if err != nil {
+			return nil, err
+		}
+		return [][]byte{r.Bytes(), s.Bytes()}, nil
+	}
+
+	for name, curve := range ecdhCurves {
+		curve := curve
+		m.handlers["ECDH/"+name] = func(args [][]byte) ([][]byte, error) {
+			if len(args) != 3 && len(args) != 4 {
+				return nil, fmt.Errorf("expected 3 or 4 arguments but got %d", len(args))
+			}
+			return ecdhSharedSecret(curve, args)
+		}
+	}
+
+	return m
+}
+
+// ecdhSharedSecret answers an ECDH command, generating a key pair if the
+// private key, args[2], is empty, and hashing the shared secret with the hash
+// named by args[3], if given.
+func ecdhSharedSecret(curve ecdh.Curve, args [][]byte) ([][]byte, error) {
+	var priv *ecdh.PrivateKey
+	var err error
+	if len(args[2]) == 0 {
+		priv, err = curve.GenerateKey(rand.Reader)
+	} else {
+		priv, err = curve.NewPrivateKey(args[2])
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	// Uncompressed points are 0x04 followed by the two coordinates.
+	coordLen := (len(priv.PublicKey().Bytes()) - 1) / 2
+	peer, err := curve.NewPublicKey(append(append([]byte{4}, leftPad(args[0], coordLen)...), leftPad(args[1], coordLen)...))
+	if err != nil {
+		return nil, err
+	}
+	z, err := priv.ECDH(peer)
+	if err != nil {
+		return nil, err
+	}
+	if len(args) == 4 {
+		newHash, ok := hashes[string(args[3])]
+		if !ok {
+			return nil, fmt.Errorf("unknown hash %q", args[3])
+		}
+		h := newHash()
+		h.Write(z)
+		z = h.Sum(nil)
+	}
+
+	pub := priv.PublicKey().Bytes()[1:]
+	return [][]byte{pub[:coordLen], pub[coordLen:], z}, nil
+}
+
+// leftPad returns b with leading zeros to make it n bytes long, if it's
+// shorter.
+func leftPad(b []byte, n int) []byte {
+	if len(b) >= n {
+		return b
+	}
+	return append(make([]byte, n-len(b)), b...)
+}
+
+// validPoint reports whether (x, y) is a valid public key, as in SP 800-56A,
+// section 5.6.2.3.3. The NIST curves have prime order, so every point on the
+// curve other than the point at infinity, which is encoded as (0, 0), is in
+// the right subgroup.
+func validPoint(curve elliptic.Curve, xBytes, yBytes []byte) bool {
+	x := new(big.Int).SetBytes(xBytes)
+	y := new(big.Int).SetBytes(yBytes)
+	p := curve.Params().P
+	if x.Sign() == 0 && y.Sign() == 0 {
+		return false
+	}
+	if x.Cmp(p) >= 0 || y.Cmp(p) >= 0 {
+		return false
+	}
+	return curve.IsOnCurve(x, y)
+}
+
+// Handle sets the handler for cmd, replacing any existing one. It lets tests
+// add commands that the Module doesn't implement, or answer wrongly on
+// purpose.
+func (m *Module) Handle(cmd string, h Handler) {
+	m.handlers[cmd] = h
+}
+
+func (m *Module) Transact(cmd string, expectedResults int, args ...[]byte) ([][]byte, error) {
+	h, ok := m.handlers[cmd]
+	if !ok {
+		return nil, fmt.Errorf("unknown command %q", cmd)
+	}
+	result, err := h(args)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %s", cmd, err)
+	}
+	if len(result) != expectedResults {
+		return nil, fmt.Errorf("expected %d results from %q but got %d", expectedResults, cmd, len(result))
+	}
+	return result, nil
+}
+
+func (m *Module) TransactAsync(cmd string, expectedResults int, args [][]byte, callback func([][]byte) error) {
+	result, err := m.Transact(cmd, expectedResults, args...)
+	if err == nil {
+		err = callback(result)
+	}
+	if err != nil && m.err == nil {
+		m.err = err
+	}
+}
+
+func (m *Module) Barrier(callback func()) error {
+	callback()
+	return nil
+}
+
+// Flush returns the first error from an asynchronous command since the last
+// call to Flush.
+func (m *Module) Flush() error {
+	err := m.err
+	m.err = nil
+	return err
+}
+
+func checkArgs(args [][]byte, n int) error {
+	if len(args) != n {
+		return fmt.Errorf("expected %d arguments but got %d", n, len(args))
+	}
+	return nil
+}
+
+// length decodes a 32-bit, little-endian output length.
+func length(arg []byte) (int, error) {
+	if len(arg) != 4 {
+		return 0, fmt.Errorf("length argument is %d bytes long", len(arg))
+	}
+	return int(binary.LittleEndian.Uint32(arg)), nil
+}
+
+func isSet(arg []byte) bool {
+	return len(arg) == 1 && arg[0] == 1
+}
+
+func boolean(b bool) []byte {
+	if b {
+		return []byte{1}
+	}
+	return []byte{0}
+}
+
+func read(h sha3.ShakeHash, n int) []byte {
+	out := make([]byte, n)
+	h.Read(out)
+	return out
+}
+
+// ofbIterate runs the inner loop of the "AES Monte Carlo Test - OFB mode"
+// from the ACVP specification and returns the last two outputs. The keystream
+// continues from one iteration to the next, and the input of each iteration is
+// the output from two iterations earlier, or the IV for the second. OFB
+// decryption is the same operation, so this serves for both directions. A
+// single iteration is a plain OFB operation, and the IV is returned as the
+// previous output.
+func ofbIterate(block cipher.Block, input, iv []byte, iterations int) (result, prevResult []byte) {
+	stream := cipher.NewOFB(block, iv)
+	result = iv
+	for j := 0; j < iterations; j++ {
+		out := make([]byte, len(input))
+		stream.XORKeyStream(out, input)
+		input, prevResult, result = result, result, out
+	}
+	return result, prevResult
+}
+
+func aesArgs(args [][]byte, n int) (cipher.Block, []byte, error) {
+	if err := checkArgs(args, n); err != nil {
+		return nil, nil, err
+	}
+	if iterations := args[n-1]; !bytes.Equal(iterations, []byte{1, 0, 0, 0}) {
+		return nil, nil, fmt.Errorf("only single iterations are supported, not %x", iterations)
+	}
+	block, err := aes.NewCipher(args[0])
+	if err != nil {
+		return nil, nil, err
+	}
+	return block, args[1], nil
+}
+
+func gcmArgs(args [][]byte) (cipher.AEAD, error) {
+	if err := checkArgs(args, 5); err != nil {
+		return nil, err
+	}
+	tagLen, err := length(args[0])
+	if err != nil {
+		return nil, err
+	}
+	block, err := aes.NewCipher(args[1])
+	if err != nil {
+		return nil, err
+	}
+	if tagLen == 16 {
+		return cipher.NewGCMWithNonceSize(block, len(args[3]))
+	}
+	// The standard library only supports 96-bit nonces with short tags.
+	if len(args[3]) != 12 {
+		return nil, fmt.Errorf("%d-byte nonce with a %d-byte tag is not supported", len(args[3]), tagLen)
+	}
+	if tagLen < 12 {
+		gcm, err := cipher.NewGCM(block)
+		if err != nil {
+			return nil, err
+		}
+		return &truncatedGCM{gcm, block, tagLen}, nil
+	}
+	return cipher.NewGCMWithTagSize(block, tagLen)
+}
+
+// truncatedGCM is GCM with a tag shorter than the standard library allows.
+// Such tags are the leftmost bytes of the full tag (SP 800-38D, section 7.1).
+// Only 96-bit nonces are supported.
+type truncatedGCM struct {
+	cipher.AEAD
+	block  cipher.Block
+	tagLen int
+}
+
+func (g *truncatedGCM) Overhead() int { return g.tagLen }
+
+func (g *truncatedGCM) Seal(dst, nonce, plaintext, additionalData []byte) []byte {
+	sealed := g.AEAD.Seal(nil, nonce, plaintext, additionalData)
+	return append(dst, sealed[:len(plaintext)+g.tagLen]...)
+}
+
+func (g *truncatedGCM) Open(dst, nonce, ciphertext, additionalData []byte) ([]byte, error) {
+	if len(ciphertext) < g.tagLen {
+		return nil, errors.New("ciphertext is shorter than the tag")
+	}
+	ciphertext, tag := ciphertext[:len(ciphertext)-g.tagLen], ciphertext[len(ciphertext)-g.tagLen:]
+	// Decrypt with the counter blocks that follow J0 = nonce || 1, and then
+	// seal the result again to find the full tag.
+	counter := append(bytes.Clone(nonce), 0, 0, 0, 2)
+	plaintext := make([]byte, len(ciphertext))
+	cipher.NewCTR(g.block, counter).XORKeyStream(plaintext, ciphertext)
+	sealed := g.AEAD.Seal(nil, nonce, plaintext, additionalData)
+	if !hmac.Equal(sealed[len(ciphertext):len(ciphertext)+g.tagLen], tag) {
+		return nil, errors.New("message authentication failed")
+	}
+	return append(dst, plaintext...), nil
+}
+
+// cbcMACCipher computes ISO/IEC 9797-1 MACs. Without a second key it is MAC
+// algorithm 1, the last block of a CBC encryption with a zero IV. With one,
+// it is MAC algorithm 3, the Retail MAC, in which the last block is also
+// decrypted with the second key and encrypted again with the first.
+type cbcMACCipher struct {
+	block, final cipher.Block
+}
+
+func (c cbcMACCipher) mac(msg []byte, paddingMethod int) ([]byte, error) {
+	size := c.block.BlockSize()
+	var padded []byte
+	switch paddingMethod {
+	case 1:
+		// Zeros, to at least one block.
+		padded = bytes.Clone(msg)
+		if len(padded) == 0 || len(padded)%size != 0 {
+			padded = append(padded, make([]byte, size-len(padded)%size)...)
+		}
+	case 2:
+		// A one bit and then zeros.
+		padded = append(bytes.Clone(msg), 0x80)
+		if len(padded)%size != 0 {
+			padded = append(padded, make([]byte, size-len(padded)%size)...)
+		}
+	case 3:
+		// A block with the message length in bits, and then zeros, only if
+		// needed.
+		padded = make([]byte, size)
+		binary.BigEndian.PutUint64(padded[size-8:], uint64(len(msg))*8)
+		padded = append(padded, msg...)
+		if len(padded)%size != 0 {
+			padded = append(padded, make([]byte, size-len(padded)%size)...)
+		}
+	default:
+		return nil, fmt.Errorf("unknown padding method %d", paddingMethod)
+	}
+
+	out := make([]byte, len(padded))
+	cipher.NewCBCEncrypter(c.block, make([]byte, size)).CryptBlocks(out, padded)
+	tag := out[len(out)-size:]
+	if c.final != nil {
+		c.final.Decrypt(tag, tag)
+		c.block.Encrypt(tag, tag)
+	}
+	return tag, nil
+}
+
+// cfb processes the first n segments of input, each of segmentBits bits,
+// with AES-CFB, as in SP 800-38A, section 6.3. Only 1- and 8-bit segments are
+// supported, and bits are numbered from the most significant.
+func cfb(block cipher.Block, iv, input []byte, segmentBits, n int, encrypt bool) []byte {
+	out := make([]byte, len(input))
+	register := bytes.Clone(iv)
+	keystream := make([]byte, aes.BlockSize)
+	for i := 0; i < n; i++ {
+		block.Encrypt(keystream, register)
+
+		var in, result byte
+		if segmentBits == 8 {
+			in = input[i]
+			result = in ^ keystream[0]
+			out[i] = result
+		} else {
+			shift := 7 - i%8
+			in = (input[i/8] >> shift) & 1
+			result = in ^ keystream[0]>>7
+			out[i/8] |= result << shift
+		}
+
+		// The ciphertext segment is shifted into the register.
+		feedback := result
+		if !encrypt {
+			feedback = in
+		}
+		if segmentBits == 8 {
+			copy(register, register[1:])
+			register[len(register)-1] = feedback
+		} else {
+			for j := 0; j < len(register)-1; j++ {
+				register[j] = register[j]<<1 | register[j+1]>>7
+			}
+			register[len(register)-1] = register[len(register)-1]<<1 | feedback
+		}
+	}
+	return out
+}
+
+// The following encodings are from SP 800-185, section 2.3.
+
+func leftEncode(x uint64) []byte {
+	var buf [9]byte
+	binary.BigEndian.PutUint64(buf[1:], x)
+	i := 1
+	for i < 8 && buf[i] == 0 {
+		i++
+	}
+	buf[i-1] = byte(9 - i)
+	return buf[i-1:]
+}
+
+func rightEncode(x uint64) []byte {
+	encoded := leftEncode(x)
+	return append(encoded[1:], encoded[0])
+}
+
+func bytepad(x []byte, w int) []byte {
+	padded := append(leftEncode(uint64(w)), x...)
+	return append(padded, make([]byte, (w-len(padded)%w)%w)...)
+}
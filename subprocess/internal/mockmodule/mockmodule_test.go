@@ -0,0 +1,104 @@
+// Copyright (c) 2026, Google Inc.
+//
+// Permission to use, copy, modify, and/or distribute this software for any
+// purpose with or without fee is hereby granted, provided that the above
+// copyright notice and this permission notice appear in all copies.
+//
+// THE SOFTWARE IS PROVIDED "AS IS" AND THE AUTHOR DISCLAIMS ALL WARRANTIES
+// WITH REGARD TO THIS SOFTWARE INCLUDING ALL IMPLIED WARRANTIES OF
+// MERCHANTABILITY AND FITNESS. IN NO EVENT SHALL THE AUTHOR BE LIABLE FOR ANY
+// SPECIAL, DIRECT, INDIRECT, OR CONSEQUENTIAL DAMAGES OR ANY DAMAGES
+// WHATSOEVER RESULTING FROM LOSS OF USE, DATA OR PROFITS, WHETHER IN AN ACTION
+// OF CONTRACT, NEGLIGENCE OR OTHER TORTIOUS ACTION, ARISING OUT OF OR IN
+// CONNECTION WITH THE USE OR PERFORMANCE OF THIS SOFTWARE.
+
+package mockmodule
+
+import (
+	"bytes"
+	"encoding/hex"
+	"errors"
+	"testing"
+)
+
+func mustDecodeHex(s string) []byte {
+	b, err := hex.DecodeString(s)
+	if err != nil {
+		panic(err)
+	}
+	return b
+}
+
+func TestKnownAnswers(t *testing.T) {
+	key := make([]byte, 32)
+	for i := range key {
+		key[i] = 0x40 + byte(i)
+	}
+
+	for _, tc := range []struct {
+		cmd  string
+		args [][]byte
+		want string
+	}{
+		// FIPS 180-4 example values.
+		{"SHA2-256", [][]byte{[]byte("abc")}, "ba7816bf8f01cfea414140de5dae2223b00361a396177a9cb410ff61f20015ad"},
+		// SP 800-185 KMAC example values, sample 1.
+		{"KMAC-128", [][]byte{key, {0, 1, 2, 3}, {32, 0, 0, 0}, nil, {0}}, "e5780b0d3ea6f7d3a429c5706aa43a00fadbd7d49628839e3187243f456ee14e"},
+	} {
+		result, err := New().Transact(tc.cmd, 1, tc.args...)
+		if err != nil {
+			t.Fatalf("%s: %s", tc.cmd, err)
+		}
+		if got := hex.EncodeToString(result[0]); got != tc.want {
+			t.Errorf("%s: got %s, want %s", tc.cmd, got, tc.want)
+		}
+	}
+}
+
+func TestGCM(t *testing.T) {
+	m := New()
+	key := make([]byte, 16)
+	nonce := make([]byte, 12)
+	plaintext := []byte("plaintext")
+	aad := []byte("aad")
+
+	sealed, err := m.Transact("AES-GCM/seal", 1, []byte{16, 0, 0, 0}, key, plaintext, nonce, aad)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	result, err := m.Transact("AES-GCM/open", 2, []byte{16, 0, 0, 0}, key, sealed[0], nonce, aad)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(result[0], []byte{1}) || !bytes.Equal(result[1], plaintext) {
+		t.Errorf("open returned %x, want success and %x", result, plaintext)
+	}
+
+	sealed[0][0] ^= 1
+	result, err = m.Transact("AES-GCM/open", 2, []byte{16, 0, 0, 0}, key, sealed[0], nonce, aad)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(result[0], []byte{0}) {
+		t.Errorf("open of a modified ciphertext returned %x, want failure", result)
+	}
+}
+
+func TestAsyncErrors(t *testing.T) {
+	m := New()
+	m.TransactAsync("SHA2-256", 1, [][]byte{nil}, func([][]byte) error {
+		return errors.New("callback failed")
+	})
+	m.TransactAsync("NO-SUCH-COMMAND", 1, nil, func([][]byte) error {
+		t.Error("callback called for an unknown command")
+		return nil
+	})
+
+	if err := m.Flush(); err == nil || err.Error() != "callback failed" {
+		t.Errorf("got Flush error %v, want the first callback's error", err)
+	}
+	if err := m.Flush(); err != nil {
+		t.Errorf("second Flush returned %v", err)
+	}
+}
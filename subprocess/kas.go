@@ -31,6 +31,7 @@ type kasTestGroup struct {
 	Curve  string    `json:"domainParameterGenerationMode"`
 	Role   string    `json:"kasRole"`
 	Scheme string    `json:"scheme"`
+	HashZ  string    `json:"hashFunctionZ"`
 	Tests  []kasTest `json:"tests"`
 }
 
@@ -46,6 +47,7 @@ type kasTest struct {
 	StaticPrivateKeyHex string `json:"staticPrivateIut"`
 
 	ResultHex string `json:"z"`
+	HashZHex  string `json:"hashZIut"`
 }
 
 type kasTestGroupResponse struct {
@@ -63,9 +65,43 @@ type kasTestResponse struct {
 	StaticYHex string `json:"staticPublicIutY,omitempty"`
 
 	ResultHex string `json:"z,omitempty"`
+	HashZHex  string `json:"hashZIut,omitempty"`
 	Passed    *bool  `json:"testPassed,omitempty"`
 }
 
+// kasSSCCurves are the curves that KAS-ECC-SSC groups may use. The module
+// reports an error for any that it doesn't support.
+var kasSSCCurves = map[string]bool{
+	"P-224": true, "P-256": true, "P-384": true, "P-521": true,
+	"K-233": true, "K-283": true, "K-409": true, "K-571": true,
+	"B-233": true, "B-283": true, "B-409": true, "B-571": true,
+}
+
+// kasTestTypeAndRole checks the test type and role of a KAS-ECC or
+// KAS-ECC-SSC group and returns whether its tests give the IUT's private key.
+func kasTestTypeAndRole(testType, role string) (privateKeyGiven bool, err error) {
+	switch testType {
+	case "AFT":
+		privateKeyGiven = false
+	case "VAL":
+		privateKeyGiven = true
+	default:
+		return false, fmt.Errorf("unknown test type %q", testType)
+	}
+
+	switch role {
+	case "initiator", "responder":
+		break
+	default:
+		return false, fmt.Errorf("unknown role %q", role)
+	}
+	return privateKeyGiven, nil
+}
+
+// kas implements KAS-ECC-SSC, the shared secret computation of KAS-ECC. The
+// module computes Z, or, when the group names a hashFunctionZ, the hash of Z,
+// with the ECDH command. For validity tests the result is compared with the
+// claimed one here.
 type kas struct{}
 
 func (k *kas) Process(vectorSet []byte, m Transactable) (any, error) {
@@ -82,30 +118,15 @@ func (k *kas) Process(vectorSet []byte, m Transactable) (any, error) {
 			ID: group.ID,
 		}
 
-		var privateKeyGiven bool
-		switch group.Type {
-		case "AFT":
-			privateKeyGiven = false
-		case "VAL":
-			privateKeyGiven = true
-		default:
-			return nil, fmt.Errorf("unknown test type %q", group.Type)
+		privateKeyGiven, err := kasTestTypeAndRole(group.Type, group.Role)
+		if err != nil {
+			return nil, err
 		}
 
-		switch group.Curve {
-		case "P-224", "P-256", "P-384", "P-521":
-			break
-		default:
+		if !kasSSCCurves[group.Curve] {
 			return nil, fmt.Errorf("unknown curve %q", group.Curve)
 		}
 
-		switch group.Role {
-		case "initiator", "responder":
-			break
-		default:
-			return nil, fmt.Errorf("unknown role %q", group.Role)
-		}
-
 		var useStaticNamedFields bool
 		switch group.Scheme {
 		case "ephemeralUnified":
@@ -118,9 +139,16 @@ func (k *kas) Process(vectorSet []byte, m Transactable) (any, error) {
 		}
 
 		method := "ECDH/" + group.Curve
+		// The hash name is only sent when there is one, so that modules
+		// without support for hashing Z see the same commands as before.
+		var hashZ [][]byte
+		if len(group.HashZ) != 0 {
+			hashZ = [][]byte{[]byte(group.HashZ)}
+		}
 
 		for _, test := range group.Tests {
 			test := test
+			m := withTestCase(m, "KAS-ECC-SSC", group.ID, test.ID)
 
 			var xHex, yHex, privateKeyHex string
 			if useStaticNamedFields {
@@ -153,12 +181,19 @@ func (k *kas) Process(vectorSet []byte, m Transactable) (any, error) {
 					return nil, err
 				}
 
-				expectedOutput, err := hex.DecodeString(test.ResultHex)
+				expectedHex := test.ResultHex
+				if len(group.HashZ) != 0 {
+					expectedHex = test.HashZHex
+				}
+				if len(expectedHex) == 0 {
+					return nil, fmt.Errorf("%d/%d is missing the claimed result", group.ID, test.ID)
+				}
+				expectedOutput, err := hex.DecodeString(expectedHex)
 				if err != nil {
 					return nil, err
 				}
 
-				m.TransactAsync(method, 3, [][]byte{peerX, peerY, privateKey}, func(result [][]byte) error {
+				m.TransactAsync(method, 3, append([][]byte{peerX, peerY, privateKey}, hashZ...), func(result [][]byte) error {
 					ok := bytes.Equal(result[2], expectedOutput)
 					response.Tests = append(response.Tests, kasTestResponse{
 						ID:     test.ID,
@@ -167,10 +202,12 @@ func (k *kas) Process(vectorSet []byte, m Transactable) (any, error) {
 					return nil
 				})
 			} else {
-				m.TransactAsync(method, 3, [][]byte{peerX, peerY, nil}, func(result [][]byte) error {
-					testResponse := kasTestResponse{
-						ID:        test.ID,
-						ResultHex: hex.EncodeToString(result[2]),
+				m.TransactAsync(method, 3, append([][]byte{peerX, peerY, nil}, hashZ...), func(result [][]byte) error {
+					testResponse := kasTestResponse{ID: test.ID}
+					if len(group.HashZ) != 0 {
+						testResponse.HashZHex = hex.EncodeToString(result[2])
+					} else {
+						testResponse.ResultHex = hex.EncodeToString(result[2])
 					}
 
 					if useStaticNamedFields {
@@ -0,0 +1,145 @@
+// Copyright (c) 2026, Google Inc.
+//
+// Permission to use, copy, modify, and/or distribute this software for any
+// purpose with or without fee is hereby granted, provided that the above
+// copyright notice and this permission notice appear in all copies.
+//
+// THE SOFTWARE IS PROVIDED "AS IS" AND THE AUTHOR DISCLAIMS ALL WARRANTIES
+// WITH REGARD TO THIS SOFTWARE INCLUDING ALL IMPLIED WARRANTIES OF
+// MERCHANTABILITY AND FITNESS. IN NO EVENT SHALL THE AUTHOR BE LIABLE FOR ANY
+// SPECIAL, DIRECT, INDIRECT, OR CONSEQUENTIAL DAMAGES OR ANY DAMAGES
+// WHATSOEVER RESULTING FROM LOSS OF USE, DATA OR PROFITS, WHETHER IN AN ACTION
+// OF CONTRACT, NEGLIGENCE OR OTHER TORTIOUS ACTION, ARISING OUT OF OR IN
+// CONNECTION WITH THE USE OR PERFORMANCE OF THIS SOFTWARE.
+
+package subprocess
+
+import (
+	"crypto/ecdh"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/cpu/acvptool/subprocess/internal/mockmodule"
+)
+
+// ecdhResponder answers ECDH commands, which have three results, with the mock
+// module.
+func ecdhResponder(cmd string, args [][]byte) ([][]byte, error) {
+	return mockmodule.New().Transact(cmd, 3, args...)
+}
+
+// kasSSCVectorSet returns a KAS-ECC-SSC vector set with a single P-256 test in
+// the given group, whose peer's key is peer.
+func kasSSCVectorSet(testType, hashZ string, peer *ecdh.PublicKey, extra string) string {
+	point := peer.Bytes()[1:]
+	return fmt.Sprintf(`{"algorithm": "KAS-ECC-SSC", "testGroups": [{
+		"tgId": 1, "testType": %q, "domainParameterGenerationMode": "P-256", "kasRole": "initiator",
+		"scheme": "ephemeralUnified", "hashFunctionZ": %q,
+		"tests": [{"tcId": 1, "ephemeralPublicServerX": "%x", "ephemeralPublicServerY": "%x"%s}]
+	}]}`, testType, hashZ, point[:32], point[32:], extra)
+}
+
+func TestKASSSCAFT(t *testing.T) {
+	peer, err := ecdh.P256().GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, hashZ := range []string{"", "SHA2-256"} {
+		m := &fakeTransactable{respond: ecdhResponder}
+		result, err := new(kas).Process([]byte(kasSSCVectorSet("AFT", hashZ, peer.PublicKey(), "")), m)
+		if err != nil {
+			t.Fatalf("hashFunctionZ %q: %s", hashZ, err)
+		}
+		if args := m.calls[0].args; len(hashZ) == 0 && len(args) != 3 || len(hashZ) != 0 && (len(args) != 4 || string(args[3]) != hashZ) {
+			t.Errorf("hashFunctionZ %q: ECDH called with %d arguments", hashZ, len(args))
+		}
+
+		test := result.([]kasTestGroupResponse)[0].Tests[0]
+		iutPoint, err := hex.DecodeString("04" + test.EphemeralXHex + test.EphemeralYHex)
+		if err != nil {
+			t.Fatal(err)
+		}
+		iut, err := ecdh.P256().NewPublicKey(iutPoint)
+		if err != nil {
+			t.Fatalf("hashFunctionZ %q: bad IUT public key: %s", hashZ, err)
+		}
+		z, err := peer.ECDH(iut)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if len(hashZ) == 0 {
+			if test.ResultHex != hex.EncodeToString(z) || len(test.HashZHex) != 0 {
+				t.Errorf("unexpected response: %+v", test)
+			}
+		} else {
+			hash := sha256.Sum256(z)
+			if test.HashZHex != hex.EncodeToString(hash[:]) || len(test.ResultHex) != 0 {
+				t.Errorf("unexpected response with hashFunctionZ %q: %+v", hashZ, test)
+			}
+		}
+	}
+}
+
+func TestKASSSCVAL(t *testing.T) {
+	peer, err := ecdh.P256().GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	iut, err := ecdh.P256().GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	z, err := iut.ECDH(peer.PublicKey())
+	if err != nil {
+		t.Fatal(err)
+	}
+	hash := sha256.Sum256(z)
+	wrongHash := sha256.Sum256(hash[:])
+
+	for _, test := range []struct {
+		claimed string
+		want    bool
+	}{
+		{hex.EncodeToString(hash[:]), true},
+		{hex.EncodeToString(wrongHash[:]), false},
+	} {
+		extra := fmt.Sprintf(`, "ephemeralPrivateIut": "%x", "hashZIut": %q`, iut.Bytes(), test.claimed)
+		m := &fakeTransactable{respond: ecdhResponder}
+		result, err := new(kas).Process([]byte(kasSSCVectorSet("VAL", "SHA2-256", peer.PublicKey(), extra)), m)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if passed := result.([]kasTestGroupResponse)[0].Tests[0].Passed; passed == nil || *passed != test.want {
+			t.Errorf("claimed hash %s: got %v, want %v", test.claimed, passed, test.want)
+		}
+	}
+
+	extra := fmt.Sprintf(`, "ephemeralPrivateIut": "%x", "z": "%x"`, iut.Bytes(), z)
+	if _, err := new(kas).Process([]byte(kasSSCVectorSet("VAL", "SHA2-256", peer.PublicKey(), extra)), &fakeTransactable{respond: ecdhResponder}); err == nil || !strings.Contains(err.Error(), "missing the claimed result") {
+		t.Errorf("VAL test without hashZIut gave %v", err)
+	}
+}
+
+func TestKASSSCBinaryCurve(t *testing.T) {
+	const vectorSet = `{"algorithm": "KAS-ECC-SSC", "testGroups": [{
+		"tgId": 1, "testType": "AFT", "domainParameterGenerationMode": "K-233", "kasRole": "initiator",
+		"scheme": "ephemeralUnified",
+		"tests": [{"tcId": 1, "ephemeralPublicServerX": "c1", "ephemeralPublicServerY": "c2"}]
+	}]}`
+
+	m := &fakeTransactable{respond: func(cmd string, args [][]byte) ([][]byte, error) {
+		return [][]byte{{0x0a}, {0x0b}, {0x0c}}, nil
+	}}
+	if _, err := new(kas).Process([]byte(vectorSet), m); err != nil {
+		t.Fatal(err)
+	}
+	if cmd := m.calls[0].cmd; cmd != "ECDH/K-233" {
+		t.Errorf("K-233 group sent %q", cmd)
+	}
+}
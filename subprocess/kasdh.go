@@ -26,15 +26,19 @@ type kasDHVectorSet struct {
 }
 
 type kasDHTestGroup struct {
-	ID     uint64      `json:"tgId"`
-	Type   string      `json:"testType"`
-	Role   string      `json:"kasRole"`
-	Mode   string      `json:"kasMode"`
-	Scheme string      `json:"scheme"`
-	PHex   string      `json:"p"`
-	QHex   string      `json:"q"`
-	GHex   string      `json:"g"`
-	Tests  []kasDHTest `json:"tests"`
+	ID     uint64 `json:"tgId"`
+	Type   string `json:"testType"`
+	Role   string `json:"kasRole"`
+	Mode   string `json:"kasMode"`
+	Scheme string `json:"scheme"`
+	// SafePrimeGroup is either one of the SP 800-56A rev3 safe-prime
+	// groups, in which case p, q and g are omitted, or one of the FIPS
+	// 186-type modes "FB" and "FC", which give p, q and g explicitly.
+	SafePrimeGroup string      `json:"domainParameterGenerationMode"`
+	PHex           string      `json:"p"`
+	QHex           string      `json:"q"`
+	GHex           string      `json:"g"`
+	Tests          []kasDHTest `json:"tests"`
 }
 
 type kasDHTest struct {
@@ -57,7 +61,9 @@ type kasDHTestResponse struct {
 	Passed         *bool  `json:"testPassed,omitempty"`
 }
 
-type kasDH struct{}
+type kasDH struct {
+	algo string
+}
 
 func (k *kasDH) Process(vectorSet []byte, m Transactable) (any, error) {
 	var parsed kasDHVectorSet
@@ -94,24 +100,30 @@ func (k *kasDH) Process(vectorSet []byte, m Transactable) (any, error) {
 			return nil, fmt.Errorf("unknown scheme %q", group.Scheme)
 		}
 
-		p, err := hex.DecodeString(group.PHex)
-		if err != nil {
-			return nil, err
-		}
+		var p, q, g []byte
+		var err error
+		if len(group.PHex) == 0 && len(group.QHex) == 0 && len(group.GHex) == 0 {
+			if p, q, g, err = safePrimeParameters(group.SafePrimeGroup); err != nil {
+				return nil, err
+			}
+		} else {
+			if p, err = hex.DecodeString(group.PHex); err != nil {
+				return nil, err
+			}
 
-		q, err := hex.DecodeString(group.QHex)
-		if err != nil {
-			return nil, err
-		}
+			if q, err = hex.DecodeString(group.QHex); err != nil {
+				return nil, err
+			}
 
-		g, err := hex.DecodeString(group.GHex)
-		if err != nil {
-			return nil, err
+			if g, err = hex.DecodeString(group.GHex); err != nil {
+				return nil, err
+			}
 		}
 
 		const method = "FFDH"
 		for _, test := range group.Tests {
 			test := test
+			m := withTestCase(m, k.algo, group.ID, test.ID)
 
 			if len(test.PeerPublicHex) == 0 {
 				return nil, fmt.Errorf("%d/%d is missing peer's key", group.ID, test.ID)
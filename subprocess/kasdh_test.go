@@ -0,0 +1,101 @@
+// Copyright (c) 2026, Google Inc.
+//
+// Permission to use, copy, modify, and/or distribute this software for any
+// purpose with or without fee is hereby granted, provided that the above
+// copyright notice and this permission notice appear in all copies.
+//
+// THE SOFTWARE IS PROVIDED "AS IS" AND THE AUTHOR DISCLAIMS ALL WARRANTIES
+// WITH REGARD TO THIS SOFTWARE INCLUDING ALL IMPLIED WARRANTIES OF
+// MERCHANTABILITY AND FITNESS. IN NO EVENT SHALL THE AUTHOR BE LIABLE FOR ANY
+// SPECIAL, DIRECT, INDIRECT, OR CONSEQUENTIAL DAMAGES OR ANY DAMAGES
+// WHATSOEVER RESULTING FROM LOSS OF USE, DATA OR PROFITS, WHETHER IN AN ACTION
+// OF CONTRACT, NEGLIGENCE OR OTHER TORTIOUS ACTION, ARISING OUT OF OR IN
+// CONNECTION WITH THE USE OR PERFORMANCE OF THIS SOFTWARE.
+
+package subprocess
+
+import (
+	"bytes"
+	"math/big"
+	"testing"
+)
+
+// ffdhResponder returns a fixed local public key and a "shared secret" that is
+// the peer's public value.
+func ffdhResponder(cmd string, args [][]byte) ([][]byte, error) {
+	if cmd != "FFDH" {
+		panic("unexpected command " + cmd)
+	}
+	return [][]byte{{0x0a}, args[3]}, nil
+}
+
+func TestKASFFCSafePrimeGroup(t *testing.T) {
+	const vectorSet = `{"algorithm": "KAS-FFC", "testGroups": [{
+		"tgId": 1, "testType": "AFT", "kasRole": "initiator", "scheme": "dhEphem",
+		"domainParameterGenerationMode": "ffdhe2048",
+		"tests": [{"tcId": 1, "ephemeralPublicServer": "c1"}]
+	}]}`
+
+	m := &fakeTransactable{respond: ffdhResponder}
+	result, err := new(kasDH).Process([]byte(vectorSet), m)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	args := m.calls[0].args
+	p, q := new(big.Int).SetBytes(args[0]), new(big.Int).SetBytes(args[1])
+	if p.BitLen() != 2048 || !p.ProbablyPrime(8) {
+		t.Errorf("p is not a 2048-bit prime: %x", args[0])
+	}
+	if q.Lsh(q, 1).Add(q, big.NewInt(1)).Cmp(p) != 0 {
+		t.Errorf("q is not (p-1)/2: %x", args[1])
+	}
+	if !bytes.Equal(args[2], []byte{2}) {
+		t.Errorf("g is %x, but should be two", args[2])
+	}
+
+	test := result.([]kasDHTestGroupResponse)[0].Tests[0]
+	if test.LocalPublicHex != "0a" || test.ResultHex != "c1" {
+		t.Errorf("unexpected response: %+v", test)
+	}
+
+	for _, mode := range []string{"ffdhe1024", "FB"} {
+		vectorSet := `{"algorithm": "KAS-FFC", "testGroups": [{
+			"tgId": 1, "testType": "AFT", "kasRole": "initiator", "scheme": "dhEphem",
+			"domainParameterGenerationMode": "` + mode + `",
+			"tests": [{"tcId": 1, "ephemeralPublicServer": "c1"}]
+		}]}`
+		if _, err := new(kasDH).Process([]byte(vectorSet), &fakeTransactable{respond: ffdhResponder}); err == nil {
+			t.Errorf("group with mode %q and no domain parameters was accepted", mode)
+		}
+	}
+}
+
+func TestKASFFCExplicitParameters(t *testing.T) {
+	const vectorSet = `{"algorithm": "KAS-FFC", "testGroups": [{
+		"tgId": 1, "testType": "VAL", "kasRole": "responder", "scheme": "dhEphem",
+		"domainParameterGenerationMode": "FB", "p": "17", "q": "0b", "g": "02",
+		"tests": [
+			{"tcId": 1, "ephemeralPublicServer": "c1", "ephemeralPrivateIut": "03", "ephemeralPublicIut": "0a", "z": "c1"},
+			{"tcId": 2, "ephemeralPublicServer": "c1", "ephemeralPrivateIut": "03", "ephemeralPublicIut": "0a", "z": "ff"}
+		]
+	}]}`
+
+	m := &fakeTransactable{respond: ffdhResponder}
+	result, err := new(kasDH).Process([]byte(vectorSet), m)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if args := m.calls[0].args; !bytes.Equal(args[0], []byte{0x17}) || !bytes.Equal(args[1], []byte{0x0b}) || !bytes.Equal(args[4], []byte{0x03}) {
+		t.Errorf("unexpected FFDH arguments: %x", args)
+	}
+
+	tests := result.([]kasDHTestGroupResponse)[0].Tests
+	if tests[0].Passed == nil || !*tests[0].Passed {
+		t.Errorf("matching result was rejected: %+v", tests[0])
+	}
+	if tests[1].Passed == nil || *tests[1].Passed {
+		t.Errorf("mismatched result was accepted: %+v", tests[1])
+	}
+}
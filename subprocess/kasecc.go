@@ -0,0 +1,197 @@
+// Copyright (c) 2026, Google Inc.
+//
+// Permission to use, copy, modify, and/or distribute this software for any
+// purpose with or without fee is hereby granted, provided that the above
+// copyright notice and this permission notice appear in all copies.
+//
+// THE SOFTWARE IS PROVIDED "AS IS" AND THE AUTHOR DISCLAIMS ALL WARRANTIES
+// WITH REGARD TO THIS SOFTWARE INCLUDING ALL IMPLIED WARRANTIES OF
+// MERCHANTABILITY AND FITNESS. IN NO EVENT SHALL THE AUTHOR BE LIABLE FOR ANY
+// SPECIAL, DIRECT, INDIRECT, OR CONSEQUENTIAL DAMAGES OR ANY DAMAGES
+// WHATSOEVER RESULTING FROM LOSS OF USE, DATA OR PROFITS, WHETHER IN AN ACTION
+// OF CONTRACT, NEGLIGENCE OR OTHER TORTIOUS ACTION, ARISING OUT OF OR IN
+// CONNECTION WITH THE USE OR PERFORMANCE OF THIS SOFTWARE.
+
+package subprocess
+
+import (
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+)
+
+type kasECCVectorSet struct {
+	Groups []kasECCTestGroup `json:"testGroups"`
+}
+
+type kasECCTestGroup struct {
+	ID     uint64       `json:"tgId"`
+	Type   string       `json:"testType"`
+	Curve  string       `json:"domainParameterGenerationMode"`
+	Role   string       `json:"kasRole"`
+	Scheme string       `json:"scheme"`
+	HashZ  string       `json:"hashFunctionZ"`
+	Tests  []kasECCTest `json:"tests"`
+}
+
+type kasECCTest struct {
+	ID uint64 `json:"tcId"`
+
+	PeerXHex string `json:"ephemeralPublicServerX"`
+	PeerYHex string `json:"ephemeralPublicServerY"`
+
+	PrivateKeyHex string `json:"ephemeralPrivateIut"`
+	XHex          string `json:"ephemeralPublicIutX"`
+	YHex          string `json:"ephemeralPublicIutY"`
+
+	ResultHex string `json:"z"`
+	HashZHex  string `json:"hashZIut"`
+}
+
+type kasECCTestGroupResponse struct {
+	ID    uint64               `json:"tgId"`
+	Tests []kasECCTestResponse `json:"tests"`
+}
+
+type kasECCTestResponse struct {
+	ID uint64 `json:"tcId"`
+
+	XHex string `json:"ephemeralPublicIutX,omitempty"`
+	YHex string `json:"ephemeralPublicIutY,omitempty"`
+
+	ResultHex string `json:"z,omitempty"`
+	HashZHex  string `json:"hashZIut,omitempty"`
+	Passed    *bool  `json:"testPassed,omitempty"`
+}
+
+// kasECC implements the ephemeral unified scheme of KAS-ECC. The module
+// reports either the shared secret Z or, when the group names a
+// hashFunctionZ, the hash of Z. Unlike KAS-ECC-SSC, for validity tests the
+// claimed result is passed to the module, which decides whether it matches.
+type kasECC struct{}
+
+func (k *kasECC) Process(vectorSet []byte, m Transactable) (any, error) {
+	var parsed kasECCVectorSet
+	if err := json.Unmarshal(vectorSet, &parsed); err != nil {
+		return nil, err
+	}
+
+	// See https://pages.nist.gov/ACVP/draft-fussell-acvp-kas-ecc.html#name-test-vectors
+	var ret []kasECCTestGroupResponse
+	for _, group := range parsed.Groups {
+		group := group
+		response := kasECCTestGroupResponse{
+			ID: group.ID,
+		}
+
+		privateKeyGiven, err := kasTestTypeAndRole(group.Type, group.Role)
+		if err != nil {
+			return nil, err
+		}
+
+		switch group.Curve {
+		case "P-256", "P-384", "P-521":
+			break
+		default:
+			return nil, fmt.Errorf("unknown curve %q", group.Curve)
+		}
+
+		if group.Scheme != "ephemeralUnified" {
+			return nil, fmt.Errorf("unknown scheme %q", group.Scheme)
+		}
+
+		method := "KAS-ECC/" + group.Curve
+		hashZ := []byte(group.HashZ)
+
+		for _, test := range group.Tests {
+			test := test
+			m := withTestCase(m, "KAS-ECC", group.ID, test.ID)
+
+			if len(test.PeerXHex) == 0 || len(test.PeerYHex) == 0 {
+				return nil, fmt.Errorf("%d/%d is missing peer's point", group.ID, test.ID)
+			}
+
+			peerX, err := hex.DecodeString(test.PeerXHex)
+			if err != nil {
+				return nil, err
+			}
+
+			peerY, err := hex.DecodeString(test.PeerYHex)
+			if err != nil {
+				return nil, err
+			}
+
+			if (len(test.PrivateKeyHex) != 0) != privateKeyGiven {
+				return nil, fmt.Errorf("%d/%d incorrect private key presence", group.ID, test.ID)
+			}
+
+			if privateKeyGiven {
+				privateKey, err := hex.DecodeString(test.PrivateKeyHex)
+				if err != nil {
+					return nil, err
+				}
+
+				x, err := hex.DecodeString(test.XHex)
+				if err != nil {
+					return nil, err
+				}
+
+				y, err := hex.DecodeString(test.YHex)
+				if err != nil {
+					return nil, err
+				}
+
+				claimedHex := test.ResultHex
+				if len(group.HashZ) != 0 {
+					claimedHex = test.HashZHex
+				}
+				if len(claimedHex) == 0 {
+					return nil, fmt.Errorf("%d/%d is missing the claimed result", group.ID, test.ID)
+				}
+				claimed, err := hex.DecodeString(claimedHex)
+				if err != nil {
+					return nil, err
+				}
+
+				m.TransactAsync(method+"/VAL", 1, [][]byte{peerX, peerY, privateKey, x, y, hashZ, claimed}, func(result [][]byte) error {
+					if len(result[0]) != 1 || (result[0][0]&0xfe) != 0 {
+						return fmt.Errorf("invalid validity result from subprocess: %x", result[0])
+					}
+					ok := result[0][0] == 1
+					response.Tests = append(response.Tests, kasECCTestResponse{
+						ID:     test.ID,
+						Passed: &ok,
+					})
+					return nil
+				})
+			} else {
+				m.TransactAsync(method, 3, [][]byte{peerX, peerY, hashZ}, func(result [][]byte) error {
+					testResponse := kasECCTestResponse{
+						ID:   test.ID,
+						XHex: hex.EncodeToString(result[0]),
+						YHex: hex.EncodeToString(result[1]),
+					}
+
+					if len(group.HashZ) != 0 {
+						testResponse.HashZHex = hex.EncodeToString(result[2])
+					} else {
+						testResponse.ResultHex = hex.EncodeToString(result[2])
+					}
+
+					response.Tests = append(response.Tests, testResponse)
+					return nil
+				})
+			}
+		}
+
+		m.Barrier(func() {
+			ret = append(ret, response)
+		})
+	}
+
+	if err := m.Flush(); err != nil {
+		return nil, err
+	}
+
+	return ret, nil
+}
@@ -0,0 +1,96 @@
+// Copyright (c) 2026, Google Inc.
+//
+// Permission to use, copy, modify, and/or distribute this software for any
+// purpose with or without fee is hereby granted, provided that the above
+// copyright notice and this permission notice appear in all copies.
+//
+// THE SOFTWARE IS PROVIDED "AS IS" AND THE AUTHOR DISCLAIMS ALL WARRANTIES
+// WITH REGARD TO THIS SOFTWARE INCLUDING ALL IMPLIED WARRANTIES OF
+// MERCHANTABILITY AND FITNESS. IN NO EVENT SHALL THE AUTHOR BE LIABLE FOR ANY
+// SPECIAL, DIRECT, INDIRECT, OR CONSEQUENTIAL DAMAGES OR ANY DAMAGES
+// WHATSOEVER RESULTING FROM LOSS OF USE, DATA OR PROFITS, WHETHER IN AN ACTION
+// OF CONTRACT, NEGLIGENCE OR OTHER TORTIOUS ACTION, ARISING OUT OF OR IN
+// CONNECTION WITH THE USE OR PERFORMANCE OF THIS SOFTWARE.
+
+package subprocess
+
+import (
+	"bytes"
+	"testing"
+)
+
+// kasECCResponder returns a fixed public key and a "shared secret" that is the
+// peer's X coordinate. Validation succeeds when the claimed result equals the
+// peer's X coordinate.
+func kasECCResponder(cmd string, args [][]byte) ([][]byte, error) {
+	switch cmd {
+	case "KAS-ECC/P-256":
+		return [][]byte{{0x0a}, {0x0b}, args[0]}, nil
+	case "KAS-ECC/P-256/VAL":
+		return [][]byte{{boolToByte(bytes.Equal(args[6], args[0]))}}, nil
+	}
+	panic("unexpected command " + cmd)
+}
+
+func TestKASECCAFT(t *testing.T) {
+	const vectorSet = `{"algorithm": "KAS-ECC", "testGroups": [{
+		"tgId": 1, "testType": "AFT", "domainParameterGenerationMode": "P-256", "kasRole": "initiator",
+		"scheme": "ephemeralUnified", "hashFunctionZ": "SHA2-256",
+		"tests": [{"tcId": 1, "ephemeralPublicServerX": "c1", "ephemeralPublicServerY": "c2"}]
+	}]}`
+
+	m := &fakeTransactable{respond: kasECCResponder}
+	result, err := new(kasECC).Process([]byte(vectorSet), m)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if args := m.calls[0].args; string(args[2]) != "SHA2-256" {
+		t.Errorf("hash name passed as %q", args[2])
+	}
+
+	test := result.([]kasECCTestGroupResponse)[0].Tests[0]
+	if test.XHex != "0a" || test.YHex != "0b" || test.HashZHex != "c1" || len(test.ResultHex) != 0 {
+		t.Errorf("unexpected response: %+v", test)
+	}
+
+	badCurve := `{"algorithm": "KAS-ECC", "testGroups": [{
+		"tgId": 1, "testType": "AFT", "domainParameterGenerationMode": "P-224", "kasRole": "initiator",
+		"scheme": "ephemeralUnified",
+		"tests": [{"tcId": 1, "ephemeralPublicServerX": "c1", "ephemeralPublicServerY": "c2"}]
+	}]}`
+	if _, err := new(kasECC).Process([]byte(badCurve), &fakeTransactable{respond: kasECCResponder}); err == nil {
+		t.Error("P-224 group was accepted")
+	}
+}
+
+func TestKASECCVAL(t *testing.T) {
+	const vectorSet = `{"algorithm": "KAS-ECC", "testGroups": [{
+		"tgId": 1, "testType": "VAL", "domainParameterGenerationMode": "P-256", "kasRole": "responder",
+		"scheme": "ephemeralUnified",
+		"tests": [
+			{"tcId": 1, "ephemeralPublicServerX": "c1", "ephemeralPublicServerY": "c2",
+			 "ephemeralPrivateIut": "d0", "ephemeralPublicIutX": "0a", "ephemeralPublicIutY": "0b", "z": "c1"},
+			{"tcId": 2, "ephemeralPublicServerX": "c1", "ephemeralPublicServerY": "c2",
+			 "ephemeralPrivateIut": "d0", "ephemeralPublicIutX": "0a", "ephemeralPublicIutY": "0b", "z": "ff"}
+		]
+	}]}`
+
+	m := &fakeTransactable{respond: kasECCResponder}
+	result, err := new(kasECC).Process([]byte(vectorSet), m)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if args := m.calls[0].args; len(args) != 7 || !bytes.Equal(args[2], []byte{0xd0}) || len(args[5]) != 0 {
+		t.Errorf("unexpected VAL arguments: %x", args)
+	}
+
+	tests := result.([]kasECCTestGroupResponse)[0].Tests
+	if tests[0].Passed == nil || !*tests[0].Passed {
+		t.Errorf("matching result was rejected: %+v", tests[0])
+	}
+	if tests[1].Passed == nil || *tests[1].Passed {
+		t.Errorf("mismatched result was accepted: %+v", tests[1])
+	}
+}
@@ -0,0 +1,220 @@
+// Copyright (c) 2026, Google Inc.
+//
+// Permission to use, copy, modify, and/or distribute this software for any
+// purpose with or without fee is hereby granted, provided that the above
+// copyright notice and this permission notice appear in all copies.
+//
+// THE SOFTWARE IS PROVIDED "AS IS" AND THE AUTHOR DISCLAIMS ALL WARRANTIES
+// WITH REGARD TO THIS SOFTWARE INCLUDING ALL IMPLIED WARRANTIES OF
+// MERCHANTABILITY AND FITNESS. IN NO EVENT SHALL THE AUTHOR BE LIABLE FOR ANY
+// SPECIAL, DIRECT, INDIRECT, OR CONSEQUENTIAL DAMAGES OR ANY DAMAGES
+// WHATSOEVER RESULTING FROM LOSS OF USE, DATA OR PROFITS, WHETHER IN AN ACTION
+// OF CONTRACT, NEGLIGENCE OR OTHER TORTIOUS ACTION, ARISING OUT OF OR IN
+// CONNECTION WITH THE USE OR PERFORMANCE OF THIS SOFTWARE.
+
+package subprocess
+
+import (
+	"bytes"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+)
+
+type kasIFCVectorSet struct {
+	Groups []kasIFCTestGroup `json:"testGroups"`
+}
+
+type kasIFCTestGroup struct {
+	ID        uint64 `json:"tgId"`
+	Type      string `json:"testType"`
+	Scheme    string `json:"scheme"`
+	Role      string `json:"kasRole"`
+	Modulo    int    `json:"modulo"`
+	OutBits   uint32 `json:"l"`
+	KDFConfig struct {
+		Type        string `json:"kdfType"`
+		AuxFunction string `json:"auxFunction"`
+	} `json:"kdfConfiguration"`
+	Tests []kasIFCTest `json:"tests"`
+}
+
+type kasIFCTest struct {
+	ID uint64 `json:"tcId"`
+
+	ServerNHex string `json:"serverN"`
+	ServerEHex string `json:"serverE"`
+	ServerCHex string `json:"serverC"`
+
+	IUTNHex string `json:"iutN"`
+	IUTDHex string `json:"iutD"`
+	IUTZHex string `json:"iutZ"`
+	IUTCHex string `json:"iutC"`
+
+	DKMHex string `json:"dkm"`
+}
+
+type kasIFCTestGroupResponse struct {
+	ID    uint64               `json:"tgId"`
+	Tests []kasIFCTestResponse `json:"tests"`
+}
+
+type kasIFCTestResponse struct {
+	ID      uint64 `json:"tcId"`
+	IUTCHex string `json:"iutC,omitempty"`
+	DKMHex  string `json:"dkm,omitempty"`
+	Passed  *bool  `json:"testPassed,omitempty"`
+}
+
+// kasIFCAuxFunctions are the hash functions that may be used with the
+// one-step KDF of SP 800-56C.
+var kasIFCAuxFunctions = map[string]bool{
+	"SHA2-224": true,
+	"SHA2-256": true,
+	"SHA2-384": true,
+	"SHA2-512": true,
+	"SHA3-224": true,
+	"SHA3-256": true,
+	"SHA3-384": true,
+	"SHA3-512": true,
+}
+
+// kasIFC implements the KAS1 and KAS2 schemes of SP 800-56B. In the
+// party-U (initiator) role of KAS1, the module encrypts a Z to the server's
+// RSA key. In the party-V (responder) role, it decrypts the server's
+// ciphertext with its own key. KAS2 does both. Either way, Z is passed
+// through the one-step KDF to produce the derived keying material.
+type kasIFC struct{}
+
+func (k *kasIFC) Process(vectorSet []byte, m Transactable) (any, error) {
+	var parsed kasIFCVectorSet
+	if err := json.Unmarshal(vectorSet, &parsed); err != nil {
+		return nil, err
+	}
+
+	// See https://pages.nist.gov/ACVP/draft-hammett-acvp-kas-ifc.html
+	var ret []kasIFCTestGroupResponse
+	for _, group := range parsed.Groups {
+		group := group
+		response := kasIFCTestGroupResponse{
+			ID: group.ID,
+		}
+
+		var validate bool
+		switch group.Type {
+		case "AFT":
+			validate = false
+		case "VAL":
+			validate = true
+		default:
+			return nil, fmt.Errorf("unknown test type %q", group.Type)
+		}
+
+		var scheme string
+		switch group.Scheme {
+		case "KAS1-basic":
+			scheme = "KAS1"
+		case "KAS2-basic":
+			scheme = "KAS2"
+		default:
+			return nil, fmt.Errorf("unknown scheme %q", group.Scheme)
+		}
+
+		// The party that encrypts a Z to its peer needs the peer's public
+		// key, and the party that decrypts one needs its own private key.
+		var encrypts, decrypts bool
+		switch group.Role {
+		case "initiator":
+			encrypts, decrypts = true, scheme == "KAS2"
+		case "responder":
+			encrypts, decrypts = scheme == "KAS2", true
+		default:
+			return nil, fmt.Errorf("unknown role %q", group.Role)
+		}
+
+		switch group.Modulo {
+		case 2048, 3072, 4096:
+			break
+		default:
+			return nil, fmt.Errorf("test group %d has unsupported modulus size %d", group.ID, group.Modulo)
+		}
+
+		if group.KDFConfig.Type != "oneStep" {
+			return nil, fmt.Errorf("test group %d has unsupported KDF type %q", group.ID, group.KDFConfig.Type)
+		}
+		if !kasIFCAuxFunctions[group.KDFConfig.AuxFunction] {
+			return nil, fmt.Errorf("test group %d has unsupported KDF auxiliary function %q", group.ID, group.KDFConfig.AuxFunction)
+		}
+
+		if group.OutBits == 0 || group.OutBits%8 != 0 {
+			return nil, fmt.Errorf("test group %d has invalid keying material length %d", group.ID, group.OutBits)
+		}
+		outBytes := uint32le(group.OutBits / 8)
+
+		method := "KAS-IFC/" + scheme + "/" + group.Role
+
+		for _, test := range group.Tests {
+			test := test
+			m := withTestCase(m, "KAS-IFC", group.ID, test.ID)
+
+			var fields [][]byte
+			for _, field := range []struct {
+				name     string
+				hex      string
+				required bool
+			}{
+				{"serverN", test.ServerNHex, encrypts},
+				{"serverE", test.ServerEHex, encrypts},
+				{"serverC", test.ServerCHex, decrypts},
+				{"iutN", test.IUTNHex, decrypts},
+				{"iutD", test.IUTDHex, decrypts},
+				{"iutZ", test.IUTZHex, validate && encrypts},
+				{"iutC", test.IUTCHex, validate && encrypts},
+				{"dkm", test.DKMHex, validate},
+			} {
+				if field.required && len(field.hex) == 0 {
+					return nil, fmt.Errorf("%d/%d is missing %s", group.ID, test.ID, field.name)
+				}
+				value, err := hex.DecodeString(field.hex)
+				if err != nil {
+					return nil, fmt.Errorf("failed to decode %s in test case %d/%d: %s", field.name, group.ID, test.ID, err)
+				}
+				fields = append(fields, value)
+			}
+			serverN, serverE, serverC, iutN, iutD, iutZ, iutC, dkm := fields[0], fields[1], fields[2], fields[3], fields[4], fields[5], fields[6], fields[7]
+
+			args := [][]byte{[]byte(group.KDFConfig.AuxFunction), outBytes, serverN, serverE, serverC, iutN, iutD, iutZ}
+			m.TransactAsync(method, 2, args, func(result [][]byte) error {
+				if encrypts == (len(result[0]) == 0) {
+					return fmt.Errorf("%s returned a ciphertext of %d bytes", method, len(result[0]))
+				}
+
+				if validate {
+					ok := bytes.Equal(result[1], dkm) && bytes.Equal(result[0], iutC)
+					response.Tests = append(response.Tests, kasIFCTestResponse{
+						ID:     test.ID,
+						Passed: &ok,
+					})
+					return nil
+				}
+
+				response.Tests = append(response.Tests, kasIFCTestResponse{
+					ID:      test.ID,
+					IUTCHex: hex.EncodeToString(result[0]),
+					DKMHex:  hex.EncodeToString(result[1]),
+				})
+				return nil
+			})
+		}
+
+		m.Barrier(func() {
+			ret = append(ret, response)
+		})
+	}
+
+	if err := m.Flush(); err != nil {
+		return nil, err
+	}
+
+	return ret, nil
+}
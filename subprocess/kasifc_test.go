@@ -0,0 +1,110 @@
+// Copyright (c) 2026, Google Inc.
+//
+// Permission to use, copy, modify, and/or distribute this software for any
+// purpose with or without fee is hereby granted, provided that the above
+// copyright notice and this permission notice appear in all copies.
+//
+// THE SOFTWARE IS PROVIDED "AS IS" AND THE AUTHOR DISCLAIMS ALL WARRANTIES
+// WITH REGARD TO THIS SOFTWARE INCLUDING ALL IMPLIED WARRANTIES OF
+// MERCHANTABILITY AND FITNESS. IN NO EVENT SHALL THE AUTHOR BE LIABLE FOR ANY
+// SPECIAL, DIRECT, INDIRECT, OR CONSEQUENTIAL DAMAGES OR ANY DAMAGES
+// WHATSOEVER RESULTING FROM LOSS OF USE, DATA OR PROFITS, WHETHER IN AN ACTION
+// OF CONTRACT, NEGLIGENCE OR OTHER TORTIOUS ACTION, ARISING OUT OF OR IN
+// CONNECTION WITH THE USE OR PERFORMANCE OF THIS SOFTWARE.
+
+package subprocess
+
+import (
+	"bytes"
+	"testing"
+)
+
+// kasIFCResponder "encrypts" Z by prefixing it with the peer's modulus and
+// derives keying material by reversing the combined Zs.
+func kasIFCResponder(cmd string, args [][]byte) ([][]byte, error) {
+	var c, z []byte
+	switch cmd {
+	case "KAS-IFC/KAS1/initiator":
+		if z = args[7]; len(z) == 0 {
+			z = []byte{0x77}
+		}
+		c = append(append([]byte{}, args[2]...), z...)
+	case "KAS-IFC/KAS1/responder":
+		z = bytes.TrimPrefix(args[4], args[5])
+	default:
+		panic("unexpected command " + cmd)
+	}
+
+	dkm := make([]byte, len(z))
+	for i := range z {
+		dkm[len(z)-1-i] = z[i]
+	}
+	return [][]byte{c, dkm}, nil
+}
+
+func TestKASIFCInitiator(t *testing.T) {
+	const vectorSet = `{"algorithm": "KAS-IFC", "testGroups": [{
+		"tgId": 1, "testType": "AFT", "scheme": "KAS1-basic", "kasRole": "initiator", "modulo": 2048, "l": 512,
+		"kdfConfiguration": {"kdfType": "oneStep", "auxFunction": "SHA2-256"},
+		"tests": [{"tcId": 1, "serverN": "c1", "serverE": "010001"}]
+	}, {
+		"tgId": 2, "testType": "VAL", "scheme": "KAS1-basic", "kasRole": "initiator", "modulo": 2048, "l": 512,
+		"kdfConfiguration": {"kdfType": "oneStep", "auxFunction": "SHA2-256"},
+		"tests": [
+			{"tcId": 2, "serverN": "c1", "serverE": "010001", "iutZ": "0102", "iutC": "c10102", "dkm": "0201"},
+			{"tcId": 3, "serverN": "c1", "serverE": "010001", "iutZ": "0102", "iutC": "c10102", "dkm": "0102"}
+		]
+	}]}`
+
+	m := &fakeTransactable{respond: kasIFCResponder}
+	result, err := new(kasIFC).Process([]byte(vectorSet), m)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if args := m.calls[0].args; string(args[0]) != "SHA2-256" || !bytes.Equal(args[1], uint32le(64)) || len(args[6]) != 0 {
+		t.Errorf("unexpected arguments: %x", args)
+	}
+
+	groups := result.([]kasIFCTestGroupResponse)
+	if test := groups[0].Tests[0]; test.IUTCHex != "c177" || test.DKMHex != "77" {
+		t.Errorf("unexpected AFT response: %+v", test)
+	}
+	if test := groups[1].Tests[0]; test.Passed == nil || !*test.Passed {
+		t.Errorf("matching keying material was rejected: %+v", test)
+	}
+	if test := groups[1].Tests[1]; test.Passed == nil || *test.Passed {
+		t.Errorf("mismatched keying material was accepted: %+v", test)
+	}
+}
+
+func TestKASIFCResponder(t *testing.T) {
+	const vectorSet = `{"algorithm": "KAS-IFC", "testGroups": [{
+		"tgId": 1, "testType": "AFT", "scheme": "KAS1-basic", "kasRole": "responder", "modulo": 3072, "l": 256,
+		"kdfConfiguration": {"kdfType": "oneStep", "auxFunction": "SHA3-256"},
+		"tests": [{"tcId": 1, "serverC": "d10a0b", "iutN": "d1", "iutD": "03"}]
+	}]}`
+
+	m := &fakeTransactable{respond: kasIFCResponder}
+	result, err := new(kasIFC).Process([]byte(vectorSet), m)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	test := result.([]kasIFCTestGroupResponse)[0].Tests[0]
+	if len(test.IUTCHex) != 0 || test.DKMHex != "0b0a" {
+		t.Errorf("unexpected response: %+v", test)
+	}
+
+	for _, change := range []struct{ from, to string }{
+		{`"modulo": 3072`, `"modulo": 1024`},
+		{`"oneStep"`, `"twoStep"`},
+		{`"SHA3-256"`, `"SHA-1"`},
+		{`, "iutD": "03"`, ``},
+	} {
+		vectorSet := bytes.Replace([]byte(vectorSet), []byte(change.from), []byte(change.to), 1)
+		if _, err := new(kasIFC).Process(vectorSet, &fakeTransactable{respond: kasIFCResponder}); err == nil {
+			t.Errorf("vector set with %q replaced by %q was accepted", change.from, change.to)
+		}
+	}
+}
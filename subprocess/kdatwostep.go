@@ -0,0 +1,202 @@
+// Copyright (c) 2026, Google Inc.
+//
+// Permission to use, copy, modify, and/or distribute this software for any
+// purpose with or without fee is hereby granted, provided that the above
+// copyright notice and this permission notice appear in all copies.
+//
+// THE SOFTWARE IS PROVIDED "AS IS" AND THE AUTHOR DISCLAIMS ALL WARRANTIES
+// WITH REGARD TO THIS SOFTWARE INCLUDING ALL IMPLIED WARRANTIES OF
+// MERCHANTABILITY AND FITNESS. IN NO EVENT SHALL THE AUTHOR BE LIABLE FOR ANY
+// SPECIAL, DIRECT, INDIRECT, OR CONSEQUENTIAL DAMAGES OR ANY DAMAGES
+// WHATSOEVER RESULTING FROM LOSS OF USE, DATA OR PROFITS, WHETHER IN AN ACTION
+// OF CONTRACT, NEGLIGENCE OR OTHER TORTIOUS ACTION, ARISING OUT OF OR IN
+// CONNECTION WITH THE USE OR PERFORMANCE OF THIS SOFTWARE.
+
+package subprocess
+
+import (
+	"bytes"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+// The following structures reflect the JSON of ACVP KDA two-step tests. See
+// https://pages.nist.gov/ACVP/draft-hammett-acvp-kas-kdf-twostep.html
+
+type kdaTwoStepTestVectorSet struct {
+	Groups []kdaTwoStepTestGroup `json:"testGroups"`
+}
+
+type kdaTwoStepTestGroup struct {
+	ID     uint64                  `json:"tgId"`
+	Type   string                  `json:"testType"` // AFT or VAL
+	Config kdaTwoStepConfiguration `json:"kdfConfiguration"`
+	Tests  []kdaTwoStepTest        `json:"tests"`
+}
+
+type kdaTwoStepTest struct {
+	ID          uint64               `json:"tcId"`
+	Params      kdaTwoStepParameters `json:"kdfParameter"`
+	PartyU      hkdfPartyInfo        `json:"fixedInfoPartyU"`
+	PartyV      hkdfPartyInfo        `json:"fixedInfoPartyV"`
+	ExpectedHex string               `json:"dkm"`
+}
+
+type kdaTwoStepConfiguration struct {
+	Type               string `json:"kdfType"`
+	OutputBits         uint32 `json:"l"`
+	MACMode            string `json:"macMode"`
+	KDFMode            string `json:"kdfMode"`
+	CounterLocation    string `json:"counterLocation"`
+	CounterBits        uint32 `json:"counterLen"`
+	FixedInfoPattern   string `json:"fixedInfoPattern"`
+	FixedInputEncoding string `json:"fixedInfoEncoding"`
+}
+
+type kdaTwoStepParameters struct {
+	hkdfParameters
+	IVHex string `json:"iv"`
+}
+
+// kdaTwoStepMACs are the MACs that may be used for the extraction step, and
+// the length in bytes of the default salt of each, which is all zeros. For
+// HMAC that is the block length of the hash and for CMAC the length of the
+// AES key (SP 800-56C, section 5.1). For KMAC it is as in the one-step KDF
+// (SP 800-56C, section 4.1).
+var kdaTwoStepMACs = map[string]int{
+	"CMAC-AES128":       16,
+	"CMAC-AES192":       24,
+	"CMAC-AES256":       32,
+	"HMAC-SHA-1":        64,
+	"HMAC-SHA2-224":     64,
+	"HMAC-SHA2-256":     64,
+	"HMAC-SHA2-384":     128,
+	"HMAC-SHA2-512":     128,
+	"HMAC-SHA2-512/224": 128,
+	"HMAC-SHA2-512/256": 128,
+	"HMAC-SHA3-224":     144,
+	"HMAC-SHA3-256":     136,
+	"HMAC-SHA3-384":     104,
+	"HMAC-SHA3-512":     72,
+	"KMAC-128":          164,
+	"KMAC-256":          132,
+}
+
+func (c *kdaTwoStepConfiguration) check() error {
+	if c.Type != "twoStep" {
+		return fmt.Errorf("KDA not configured for two-step KDF: %#v", c)
+	}
+	if _, ok := kdaTwoStepMACs[c.MACMode]; !ok {
+		return fmt.Errorf("unsupported MAC %q for two-step KDF", c.MACMode)
+	}
+	switch c.KDFMode {
+	case "counter", "feedback", "double pipeline iteration":
+		break
+	default:
+		return fmt.Errorf("unsupported two-step KDF mode %q", c.KDFMode)
+	}
+	if c.FixedInputEncoding != "concatenation" {
+		return fmt.Errorf("unsupported fixed info encoding %q", c.FixedInputEncoding)
+	}
+	if c.OutputBits == 0 || c.OutputBits%8 != 0 {
+		return fmt.Errorf("%d bit output: fractional bytes not supported", c.OutputBits)
+	}
+	if c.CounterBits%8 != 0 || c.CounterBits > 32 {
+		return fmt.Errorf("unsupported counter length %d", c.CounterBits)
+	}
+	return nil
+}
+
+// processKDATwoStep handles KDA vector sets for the two-step
+// (extract-then-expand) KDF of SP 800-56C. The extraction MAC and the
+// SP 800-108 expansion mode are taken from the group configuration.
+func processKDATwoStep(vectorSet []byte, m Transactable) (any, error) {
+	var parsed kdaTwoStepTestVectorSet
+	if err := json.Unmarshal(vectorSet, &parsed); err != nil {
+		return nil, err
+	}
+
+	var respGroups []hkdfTestGroupResponse
+	for _, group := range parsed.Groups {
+		group := group
+		groupResp := hkdfTestGroupResponse{ID: group.ID}
+
+		var isValidationTest bool
+		switch group.Type {
+		case "VAL":
+			isValidationTest = true
+		case "AFT":
+			isValidationTest = false
+		default:
+			return nil, fmt.Errorf("unknown test type %q", group.Type)
+		}
+
+		if err := group.Config.check(); err != nil {
+			return nil, fmt.Errorf("test group %d: %s", group.ID, err)
+		}
+		outBytes := group.Config.OutputBits / 8
+
+		for _, test := range group.Tests {
+			test := test
+			m := withTestCase(m, "KDA", group.ID, test.ID)
+			testResp := hkdfTestResponse{ID: test.ID}
+
+			key, salt, err := test.Params.extract()
+			if err != nil {
+				return nil, err
+			}
+			// Only an absent salt is replaced by the default. An empty one
+			// is sent as it is, as for KMAC it differs from the default, but
+			// for CMAC it isn't a valid key.
+			if salt == nil {
+				salt = make([]byte, kdaTwoStepMACs[group.Config.MACMode])
+			} else if len(salt) == 0 && strings.HasPrefix(group.Config.MACMode, "CMAC-") {
+				return nil, fmt.Errorf("test case %d/%d has an empty salt, which isn't a valid %s key", group.ID, test.ID, group.Config.MACMode)
+			}
+			iv, err := hex.DecodeString(test.Params.IVHex)
+			if err != nil {
+				return nil, err
+			}
+			info, err := fixedInfo(group.Config.FixedInfoPattern, test.Params.fixedInfoValues(group.Config.OutputBits, &test.PartyU, &test.PartyV))
+			if err != nil {
+				return nil, fmt.Errorf("test case %d/%d: %s", group.ID, test.ID, err)
+			}
+
+			var expected []byte
+			if isValidationTest {
+				expected, err = hex.DecodeString(test.ExpectedHex)
+				if err != nil {
+					return nil, err
+				}
+			}
+
+			args := [][]byte{key, salt, info, uint32le(outBytes), []byte(group.Config.KDFMode), []byte(group.Config.CounterLocation), uint32le(group.Config.CounterBits), iv}
+			m.TransactAsync("KDA/TwoStep/"+group.Config.MACMode, 1, args, func(result [][]byte) error {
+				if len(result[0]) != int(outBytes) {
+					return fmt.Errorf("two-step KDF operation resulted in %d bytes but wanted %d", len(result[0]), outBytes)
+				}
+				if isValidationTest {
+					passed := bytes.Equal(expected, result[0])
+					testResp.Passed = &passed
+				} else {
+					testResp.KeyOut = hex.EncodeToString(result[0])
+				}
+
+				groupResp.Tests = append(groupResp.Tests, testResp)
+				return nil
+			})
+		}
+
+		m.Barrier(func() {
+			respGroups = append(respGroups, groupResp)
+		})
+	}
+
+	if err := m.Flush(); err != nil {
+		return nil, err
+	}
+
+	return respGroups, nil
+}
@@ -0,0 +1,117 @@
+// Copyright (c) 2026, Google Inc.
+//
+// Permission to use, copy, modify, and/or distribute this software for any
+// purpose with or without fee is hereby granted, provided that the above
+// copyright notice and this permission notice appear in all copies.
+//
+// THE SOFTWARE IS PROVIDED "AS IS" AND THE AUTHOR DISCLAIMS ALL WARRANTIES
+// WITH REGARD TO THIS SOFTWARE INCLUDING ALL IMPLIED WARRANTIES OF
+// MERCHANTABILITY AND FITNESS. IN NO EVENT SHALL THE AUTHOR BE LIABLE FOR ANY
+// SPECIAL, DIRECT, INDIRECT, OR CONSEQUENTIAL DAMAGES OR ANY DAMAGES
+// WHATSOEVER RESULTING FROM LOSS OF USE, DATA OR PROFITS, WHETHER IN AN ACTION
+// OF CONTRACT, NEGLIGENCE OR OTHER TORTIOUS ACTION, ARISING OUT OF OR IN
+// CONNECTION WITH THE USE OR PERFORMANCE OF THIS SOFTWARE.
+
+package subprocess
+
+import (
+	"bytes"
+	"encoding/hex"
+	"testing"
+)
+
+// kdaTwoStepResponder "derives" keying material by repeating the fixed info
+// until the output length is reached.
+func kdaTwoStepResponder(cmd string, args [][]byte) ([][]byte, error) {
+	out := bytes.Repeat(args[2], int(args[3][0]))[:args[3][0]]
+	return [][]byte{out}, nil
+}
+
+func TestKDATwoStepCounterMode(t *testing.T) {
+	for _, mac := range []string{"HMAC-SHA2-256", "KMAC-128"} {
+		vectorSet := `{"algorithm": "KDA", "mode": "TwoStep", "revision": "Sp800-56Cr2", "testGroups": [{
+			"tgId": 1, "testType": "AFT",
+			"kdfConfiguration": {
+				"kdfType": "twoStep", "l": 64, "macMode": "` + mac + `", "kdfMode": "counter",
+				"counterLocation": "before fixed data", "counterLen": 32,
+				"fixedInfoPattern": "literal[cafe]||uPartyInfo||vPartyInfo||l", "fixedInfoEncoding": "concatenation"
+			},
+			"tests": [{
+				"tcId": 1,
+				"kdfParameter": {"kdfType": "twoStep", "salt": "5a", "z": "2a"},
+				"fixedInfoPartyU": {"partyId": "01", "ephemeralData": "02"},
+				"fixedInfoPartyV": {"partyId": "03"}
+			}]
+		}]}`
+
+		m := &fakeTransactable{respond: kdaTwoStepResponder}
+		result, err := new(hkdf).Process([]byte(vectorSet), m)
+		if err != nil {
+			t.Fatalf("%s: %s", mac, err)
+		}
+
+		call := m.calls[0]
+		if call.cmd != "KDA/TwoStep/"+mac {
+			t.Errorf("%s: command was %q", mac, call.cmd)
+		}
+		wantInfo, _ := hex.DecodeString("cafe01020300000040")
+		if !bytes.Equal(call.args[2], wantInfo) {
+			t.Errorf("%s: fixed info is %x, but should be %x", mac, call.args[2], wantInfo)
+		}
+		if string(call.args[4]) != "counter" || string(call.args[5]) != "before fixed data" || !bytes.Equal(call.args[6], uint32le(32)) {
+			t.Errorf("%s: unexpected expansion arguments: %q", mac, call.args[4:])
+		}
+
+		test := result.([]hkdfTestGroupResponse)[0].Tests[0]
+		if test.KeyOut != "cafe010203000000" {
+			t.Errorf("%s: got dkm %q", mac, test.KeyOut)
+		}
+	}
+}
+
+func TestKDATwoStepDefaultSalt(t *testing.T) {
+	vectorSet := func(mac, salt string) string {
+		return `{"algorithm": "KDA", "mode": "TwoStep", "revision": "Sp800-56Cr2", "testGroups": [{
+			"tgId": 1, "testType": "AFT",
+			"kdfConfiguration": {
+				"kdfType": "twoStep", "l": 64, "macMode": "` + mac + `", "kdfMode": "feedback",
+				"counterLocation": "after fixed data", "counterLen": 8,
+				"fixedInfoPattern": "literal[cafe]", "fixedInfoEncoding": "concatenation"
+			},
+			"tests": [{
+				"tcId": 1,
+				"kdfParameter": {"kdfType": "twoStep", ` + salt + `"z": "2a", "iv": ""},
+				"fixedInfoPartyU": {"partyId": "01"},
+				"fixedInfoPartyV": {"partyId": "03"}
+			}]
+		}]}`
+	}
+
+	for _, test := range []struct {
+		mac, salt string
+		want      []byte
+	}{
+		// An absent salt is the default for the MAC.
+		{"HMAC-SHA2-256", "", make([]byte, 64)},
+		{"HMAC-SHA3-256", "", make([]byte, 136)},
+		{"CMAC-AES192", "", make([]byte, 24)},
+		{"KMAC-128", "", make([]byte, 164)},
+		// A present salt is sent as it is, even if empty.
+		{"KMAC-128", `"salt": "", `, []byte{}},
+		{"HMAC-SHA2-256", `"salt": "5a", `, []byte{0x5a}},
+	} {
+		m := &fakeTransactable{respond: kdaTwoStepResponder}
+		if _, err := new(hkdf).Process([]byte(vectorSet(test.mac, test.salt)), m); err != nil {
+			t.Errorf("%s with %q: %s", test.mac, test.salt, err)
+			continue
+		}
+		if salt := m.calls[0].args[1]; !bytes.Equal(salt, test.want) {
+			t.Errorf("%s with %q: salt was sent as %x, want %x", test.mac, test.salt, salt, test.want)
+		}
+	}
+
+	m := &fakeTransactable{respond: kdaTwoStepResponder}
+	if _, err := new(hkdf).Process([]byte(vectorSet("CMAC-AES128", `"salt": "", `)), m); err == nil || len(m.calls) != 0 {
+		t.Error("empty CMAC salt was accepted")
+	}
+}
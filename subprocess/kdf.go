@@ -42,7 +42,12 @@ type kdfTestGroup struct {
 	Tests []struct {
 		ID       uint64 `json:"tcId"`
 		Key      string `json:"keyIn"`
+		IV       string `json:"iv"`
 		Deferred bool   `json:"deferred"`
+		// BreakLocation is the bit offset within the fixed data at which
+		// the counter is placed when the counter location is
+		// "middle fixed data".
+		BreakLocation uint32 `json:"breakLocation"`
 	}
 }
 
@@ -75,20 +80,34 @@ func (k *kdfPrimitive) Process(vectorSet []byte, m Transactable) (any, error) {
 			return nil, fmt.Errorf("%d bit key in test group %d: fractional bytes not supported", group.OutputBits, group.ID)
 		}
 
-		if group.KDFMode != "counter" && group.KDFMode != "feedback" {
-			// double-pipeline mode is not useful.
+		var cmd string
+		var locations map[string]bool
+		switch group.KDFMode {
+		case "counter":
+			cmd = "KDF-counter"
+			locations = map[string]bool{"before fixed data": true, "after fixed data": true, "middle fixed data": true}
+		case "feedback":
+			cmd = "KDF-feedback"
+			locations = map[string]bool{"none": true, "before fixed data": true, "after fixed data": true, "before iterator": true}
+		case "double pipeline iteration":
+			cmd = "KDF-pipeline"
+			locations = map[string]bool{"none": true, "before fixed data": true, "after fixed data": true, "before iterator": true}
+		default:
 			return nil, fmt.Errorf("KDF mode %q not supported", group.KDFMode)
 		}
 
-		if group.KDFMode == "feedback" && !group.ZeroIV {
-			return nil, fmt.Errorf("feedback mode with non-zero IV not supported")
+		if !locations[group.CounterLocation] {
+			return nil, fmt.Errorf("counter location %q not supported in %s mode", group.CounterLocation, group.KDFMode)
 		}
 
-		switch group.CounterLocation {
-		case "after fixed data", "before fixed data":
-			break
-		default:
-			return nil, fmt.Errorf("Label location %q not supported", group.CounterLocation)
+		// In feedback and pipeline modes the counter is optional, but
+		// otherwise it is a whole number of bytes.
+		if group.CounterLocation == "none" {
+			if group.CounterBits != 0 {
+				return nil, fmt.Errorf("test group %d has a %d-bit counter but no counter location", group.ID, group.CounterBits)
+			}
+		} else if group.CounterBits == 0 || group.CounterBits%8 != 0 || group.CounterBits > 32 {
+			return nil, fmt.Errorf("test group %d has unsupported counter length %d", group.ID, group.CounterBits)
 		}
 
 		counterBits := uint32le(group.CounterBits)
@@ -96,6 +115,7 @@ func (k *kdfPrimitive) Process(vectorSet []byte, m Transactable) (any, error) {
 
 		for _, test := range group.Tests {
 			test := test
+			m := withTestCase(m, "KDF", group.ID, test.ID)
 			testResp := kdfTestResponse{ID: test.ID}
 
 			var key []byte
@@ -110,12 +130,23 @@ func (k *kdfPrimitive) Process(vectorSet []byte, m Transactable) (any, error) {
 				}
 			}
 
-			// Make the call to the crypto module.
-			cmd := "KDF-counter"
-			if group.KDFMode == "feedback" {
-				cmd = "KDF-feedback"
+			// The arguments common to all modes are followed by those that
+			// only some groups need, so that modules without support for
+			// them see the same requests as before.
+			args := [][]byte{outputBytes, []byte(group.MACMode), []byte(group.CounterLocation), key, counterBits}
+			if group.CounterLocation == "middle fixed data" {
+				args = append(args, uint32le(test.BreakLocation))
 			}
-			m.TransactAsync(cmd, 3, [][]byte{outputBytes, []byte(group.MACMode), []byte(group.CounterLocation), key, counterBits}, func(result [][]byte) error {
+			if group.KDFMode == "feedback" && !group.ZeroIV {
+				iv, err := hex.DecodeString(test.IV)
+				if err != nil {
+					return nil, fmt.Errorf("failed to decode IV in test case %d/%d: %v", group.ID, test.ID, err)
+				}
+				args = append(args, iv)
+			}
+
+			// Make the call to the crypto module.
+			m.TransactAsync(cmd, 3, args, func(result [][]byte) error {
 				testResp.ID = test.ID
 				if test.Deferred {
 					testResp.KeyIn = hex.EncodeToString(result[0])
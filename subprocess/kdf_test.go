@@ -0,0 +1,109 @@
+// Copyright (c) 2026, Google Inc.
+//
+// Permission to use, copy, modify, and/or distribute this software for any
+// purpose with or without fee is hereby granted, provided that the above
+// copyright notice and this permission notice appear in all copies.
+//
+// THE SOFTWARE IS PROVIDED "AS IS" AND THE AUTHOR DISCLAIMS ALL WARRANTIES
+// WITH REGARD TO THIS SOFTWARE INCLUDING ALL IMPLIED WARRANTIES OF
+// MERCHANTABILITY AND FITNESS. IN NO EVENT SHALL THE AUTHOR BE LIABLE FOR ANY
+// SPECIAL, DIRECT, INDIRECT, OR CONSEQUENTIAL DAMAGES OR ANY DAMAGES
+// WHATSOEVER RESULTING FROM LOSS OF USE, DATA OR PROFITS, WHETHER IN AN ACTION
+// OF CONTRACT, NEGLIGENCE OR OTHER TORTIOUS ACTION, ARISING OUT OF OR IN
+// CONNECTION WITH THE USE OR PERFORMANCE OF THIS SOFTWARE.
+
+package subprocess
+
+import (
+	"bytes"
+	"fmt"
+	"testing"
+)
+
+// kdfResponder echoes the key and returns fixed data of 0xfd and derived key
+// bytes of 0xdd.
+func kdfResponder(cmd string, args [][]byte) ([][]byte, error) {
+	return [][]byte{args[3], {0xfd}, {0xdd, 0xdd}}, nil
+}
+
+func TestKDFModesAndCounterLocations(t *testing.T) {
+	for _, tc := range []struct {
+		mode, location string
+		counterBits    int
+		extra          string
+		cmd            string
+		wantExtra      [][]byte
+	}{
+		{"counter", "before fixed data", 32, "", "KDF-counter", nil},
+		{"counter", "after fixed data", 8, "", "KDF-counter", nil},
+		{"counter", "middle fixed data", 16, `, "breakLocation": 40`, "KDF-counter", [][]byte{uint32le(40)}},
+		{"feedback", "none", 0, `, "iv": "1112"`, "KDF-feedback", [][]byte{{0x11, 0x12}}},
+		{"feedback", "before fixed data", 32, `, "iv": "1112"`, "KDF-feedback", [][]byte{{0x11, 0x12}}},
+		{"feedback", "after fixed data", 24, `, "iv": "1112"`, "KDF-feedback", [][]byte{{0x11, 0x12}}},
+		{"feedback", "before iterator", 32, `, "iv": "1112"`, "KDF-feedback", [][]byte{{0x11, 0x12}}},
+		{"double pipeline iteration", "none", 0, "", "KDF-pipeline", nil},
+		{"double pipeline iteration", "before fixed data", 32, "", "KDF-pipeline", nil},
+		{"double pipeline iteration", "after fixed data", 32, "", "KDF-pipeline", nil},
+		{"double pipeline iteration", "before iterator", 8, "", "KDF-pipeline", nil},
+	} {
+		name := tc.mode + "/" + tc.location
+		vectorSet := fmt.Sprintf(`{"algorithm": "KDF", "testGroups": [{
+			"tgId": 1, "kdfMode": %q, "macMode": "HMAC-SHA2-256", "counterLocation": %q,
+			"keyOutLength": 16, "counterLength": %d, "zeroLengthIv": false,
+			"tests": [{"tcId": 1, "keyIn": "0a0b"%s}]
+		}]}`, tc.mode, tc.location, tc.counterBits, tc.extra)
+
+		m := &fakeTransactable{respond: kdfResponder}
+		result, err := new(kdfPrimitive).Process([]byte(vectorSet), m)
+		if err != nil {
+			t.Errorf("%s: %s", name, err)
+			continue
+		}
+
+		call := m.calls[0]
+		if call.cmd != tc.cmd {
+			t.Errorf("%s: command was %q, want %q", name, call.cmd, tc.cmd)
+		}
+		if string(call.args[2]) != tc.location || !bytes.Equal(call.args[4], uint32le(uint32(tc.counterBits))) {
+			t.Errorf("%s: counter was sent as %q, %x", name, call.args[2], call.args[4])
+		}
+		if extra := call.args[5:]; len(extra) != len(tc.wantExtra) {
+			t.Errorf("%s: got extra arguments %x, want %x", name, extra, tc.wantExtra)
+		} else {
+			for i := range extra {
+				if !bytes.Equal(extra[i], tc.wantExtra[i]) {
+					t.Errorf("%s: got extra arguments %x, want %x", name, extra, tc.wantExtra)
+				}
+			}
+		}
+
+		test := result.([]kdfTestGroupResponse)[0].Tests[0]
+		if test.FixedData != "fd" || test.KeyOut != "dddd" {
+			t.Errorf("%s: unexpected response %+v", name, test)
+		}
+	}
+}
+
+func TestKDFInvalidCounters(t *testing.T) {
+	for _, tc := range []struct {
+		mode, location string
+		counterBits    int
+	}{
+		{"counter", "none", 0},
+		{"counter", "before iterator", 32},
+		{"feedback", "middle fixed data", 32},
+		{"feedback", "none", 8},
+		{"counter", "before fixed data", 0},
+		{"counter", "before fixed data", 12},
+		{"counter", "before fixed data", 64},
+	} {
+		vectorSet := fmt.Sprintf(`{"algorithm": "KDF", "testGroups": [{
+			"tgId": 1, "kdfMode": %q, "macMode": "HMAC-SHA2-256", "counterLocation": %q,
+			"keyOutLength": 16, "counterLength": %d, "zeroLengthIv": true,
+			"tests": [{"tcId": 1, "keyIn": "0a0b"}]
+		}]}`, tc.mode, tc.location, tc.counterBits)
+		if _, err := new(kdfPrimitive).Process([]byte(vectorSet), &fakeTransactable{respond: kdfResponder}); err == nil {
+			t.Errorf("%s mode with %d-bit counter %s was accepted", tc.mode, tc.counterBits, tc.location)
+		}
+	}
+}
@@ -0,0 +1,42 @@
+// Copyright (c) 2026, Google Inc.
+//
+// Permission to use, copy, modify, and/or distribute this software for any
+// purpose with or without fee is hereby granted, provided that the above
+// copyright notice and this permission notice appear in all copies.
+//
+// THE SOFTWARE IS PROVIDED "AS IS" AND THE AUTHOR DISCLAIMS ALL WARRANTIES
+// WITH REGARD TO THIS SOFTWARE INCLUDING ALL IMPLIED WARRANTIES OF
+// MERCHANTABILITY AND FITNESS. IN NO EVENT SHALL THE AUTHOR BE LIABLE FOR ANY
+// SPECIAL, DIRECT, INDIRECT, OR CONSEQUENTIAL DAMAGES OR ANY DAMAGES
+// WHATSOEVER RESULTING FROM LOSS OF USE, DATA OR PROFITS, WHETHER IN AN ACTION
+// OF CONTRACT, NEGLIGENCE OR OTHER TORTIOUS ACTION, ARISING OUT OF OR IN
+// CONNECTION WITH THE USE OR PERFORMANCE OF THIS SOFTWARE.
+
+package subprocess
+
+import (
+	"encoding/json"
+	"fmt"
+)
+
+// kdfComponents dispatches the vector sets of the ACVP kdf-components
+// algorithm, which bundles several unrelated protocol KDFs, to the handler for
+// their mode.
+type kdfComponents struct {
+	modes map[string]Primitive
+}
+
+func (k *kdfComponents) Process(vectorSet []byte, m Transactable) (any, error) {
+	var parsed struct {
+		Mode string `json:"mode"`
+	}
+	if err := json.Unmarshal(vectorSet, &parsed); err != nil {
+		return nil, err
+	}
+
+	handler, ok := k.modes[parsed.Mode]
+	if !ok {
+		return nil, fmt.Errorf("unexpected mode: %q", parsed.Mode)
+	}
+	return handler.Process(vectorSet, m)
+}
@@ -20,8 +20,12 @@ import (
 	"fmt"
 )
 
-// The following structures reflect the JSON of CMAC-AES tests. See
-// https://pages.nist.gov/ACVP/draft-fussell-acvp-mac.html#name-test-vectors
+// The following structures reflect the JSON of CMAC-AES and CMAC-TDES tests.
+// See https://pages.nist.gov/ACVP/draft-fussell-acvp-mac.html#name-test-vectors
+//
+// CBC-MAC-AES, ISO/IEC 9797-1 MAC algorithm 1 with AES, and RetailMAC-TDES, MAC
+// algorithm 3 with two DES keys, use the same format with a paddingMethod
+// in each group.
 
 type keyedMACTestVectorSet struct {
 	Groups []keyedMACTestGroup `json:"testGroups"`
@@ -34,11 +38,22 @@ type keyedMACTestGroup struct {
 	MsgBits   uint32 `json:"msgLen"`
 	KeyBits   uint32 `json:"keyLen"`
 	MACBits   uint32 `json:"macLen"`
-	Tests     []struct {
+	// KeyingOption is given for TDES: 1 for three distinct keys and 2 for
+	// two-key TDES, in which the third key is the first.
+	KeyingOption int `json:"keyingOption"`
+	// PaddingMethod is the ISO/IEC 9797-1 padding method, from one to three,
+	// of CBC-MAC groups.
+	PaddingMethod int `json:"paddingMethod"`
+	Tests         []struct {
 		ID     uint64 `json:"tcId"`
 		KeyHex string `json:"key"`
 		MsgHex string `json:"message"`
 		MACHex string `json:"mac"`
+
+		// TDES tests serialise the key differently.
+		Key1Hex string `json:"key1"`
+		Key2Hex string `json:"key2"`
+		Key3Hex string `json:"key3"`
 	}
 }
 
@@ -55,6 +70,14 @@ type keyedMACTestResponse struct {
 
 type keyedMACPrimitive struct {
 	algo string
+	// blockSize is the block size of the cipher in bytes, which is the
+	// longest possible MAC.
+	blockSize int
+	// keySizes is the set of supported key lengths in bytes.
+	keySizes map[int]bool
+	// padded is set for the CBC-MACs, whose groups give the padding method.
+	// It is passed to the module as a final argument.
+	padded bool
 }
 
 func (k *keyedMACPrimitive) Process(vectorSet []byte, m Transactable) (any, error) {
@@ -77,6 +100,9 @@ func (k *keyedMACPrimitive) Process(vectorSet []byte, m Transactable) (any, erro
 		if group.MACBits%8 != 0 {
 			return nil, fmt.Errorf("%d bit MAC in test group %d: fractional bytes not supported", group.KeyBits, group.ID)
 		}
+		if group.MACBits == 0 || int(group.MACBits/8) > k.blockSize {
+			return nil, fmt.Errorf("%d bit MAC in test group %d: %s MACs are between 8 and %d bits", group.MACBits, group.ID, k.algo, k.blockSize*8)
+		}
 
 		var generate bool
 		switch group.Direction {
@@ -88,16 +114,42 @@ func (k *keyedMACPrimitive) Process(vectorSet []byte, m Transactable) (any, erro
 			return nil, fmt.Errorf("unknown test direction %q in test group %d", group.Direction, group.ID)
 		}
 
+		if k.padded {
+			if group.PaddingMethod < 1 || group.PaddingMethod > 3 {
+				return nil, fmt.Errorf("test group %d has unknown padding method %d", group.ID, group.PaddingMethod)
+			}
+		} else if group.PaddingMethod != 0 {
+			return nil, fmt.Errorf("test group %d has a padding method, which %s doesn't use", group.ID, k.algo)
+		}
+		// The padding method is only sent for the CBC-MACs, so that CMAC
+		// commands are unchanged.
+		var padding [][]byte
+		if k.padded {
+			padding = [][]byte{uint32le(uint32(group.PaddingMethod))}
+		}
+
 		outputBytes := uint32le(group.MACBits / 8)
 
 		for _, test := range group.Tests {
 			test := test
+			m := withTestCase(m, k.algo, group.ID, test.ID)
 			respTest := keyedMACTestResponse{ID: test.ID}
 
-			// Validate input.
-			if keyBits := uint32(len(test.KeyHex)) * 4; keyBits != group.KeyBits {
+			if len(test.KeyHex) == 0 && len(test.Key1Hex) > 0 {
+				if group.KeyingOption == 2 && test.Key3Hex != test.Key1Hex {
+					return nil, fmt.Errorf("test case %d/%d uses two-key TDES but the third key differs from the first", group.ID, test.ID)
+				}
+				test.KeyHex = test.Key1Hex + test.Key2Hex + test.Key3Hex
+			}
+
+			// Validate input. Only AES groups give the key length.
+			keyBits := uint32(len(test.KeyHex)) * 4
+			if group.KeyBits != 0 && keyBits != group.KeyBits {
 				return nil, fmt.Errorf("test case %d/%d contains key of length %d bits, but expected %d-bit value", group.ID, test.ID, keyBits, group.KeyBits)
 			}
+			if !k.keySizes[len(test.KeyHex)/2] {
+				return nil, fmt.Errorf("test case %d/%d contains %d-bit key, which %s does not support", group.ID, test.ID, keyBits, k.algo)
+			}
 			if msgBits := uint32(len(test.MsgHex)) * 4; msgBits != group.MsgBits {
 				return nil, fmt.Errorf("test case %d/%d contains message of length %d bits, but expected %d-bit value", group.ID, test.ID, msgBits, group.MsgBits)
 			}
@@ -126,7 +178,7 @@ func (k *keyedMACPrimitive) Process(vectorSet []byte, m Transactable) (any, erro
 			if generate {
 				expectedNumBytes := int(group.MACBits / 8)
 
-				m.TransactAsync(k.algo, 1, [][]byte{outputBytes, key, msg}, func(result [][]byte) error {
+				m.TransactAsync(k.algo, 1, append([][]byte{outputBytes, key, msg}, padding...), func(result [][]byte) error {
 					calculatedMAC := result[0]
 					if len(calculatedMAC) != expectedNumBytes {
 						return fmt.Errorf("%s operation returned incorrect length value", k.algo)
@@ -144,7 +196,7 @@ func (k *keyedMACPrimitive) Process(vectorSet []byte, m Transactable) (any, erro
 					return nil, fmt.Errorf("MACHex in test case %d/%d is %x, but should be %d bits", group.ID, test.ID, expectedMAC, group.MACBits)
 				}
 
-				m.TransactAsync(k.algo+"/verify", 1, [][]byte{key, msg, expectedMAC}, func(result [][]byte) error {
+				m.TransactAsync(k.algo+"/verify", 1, append([][]byte{key, msg, expectedMAC}, padding...), func(result [][]byte) error {
 					if len(result[0]) != 1 || (result[0][0]&0xfe) != 0 {
 						return fmt.Errorf("wrapper %s returned invalid success flag: %x", k.algo, result[0])
 					}
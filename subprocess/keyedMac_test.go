@@ -0,0 +1,187 @@
+// Copyright (c) 2026, Google Inc.
+//
+// Permission to use, copy, modify, and/or distribute this software for any
+// purpose with or without fee is hereby granted, provided that the above
+// copyright notice and this permission notice appear in all copies.
+//
+// THE SOFTWARE IS PROVIDED "AS IS" AND THE AUTHOR DISCLAIMS ALL WARRANTIES
+// WITH REGARD TO THIS SOFTWARE INCLUDING ALL IMPLIED WARRANTIES OF
+// MERCHANTABILITY AND FITNESS. IN NO EVENT SHALL THE AUTHOR BE LIABLE FOR ANY
+// SPECIAL, DIRECT, INDIRECT, OR CONSEQUENTIAL DAMAGES OR ANY DAMAGES
+// WHATSOEVER RESULTING FROM LOSS OF USE, DATA OR PROFITS, WHETHER IN AN ACTION
+// OF CONTRACT, NEGLIGENCE OR OTHER TORTIOUS ACTION, ARISING OUT OF OR IN
+// CONNECTION WITH THE USE OR PERFORMANCE OF THIS SOFTWARE.
+
+package subprocess
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/cpu/acvptool/subprocess/internal/mockmodule"
+)
+
+var (
+	cmacAES   = &keyedMACPrimitive{"CMAC-AES", 16, map[int]bool{16: true, 24: true, 32: true}, false}
+	cmacTDES  = &keyedMACPrimitive{"CMAC-TDES", 8, map[int]bool{24: true}, false}
+	cbcMACAES = &keyedMACPrimitive{"CBC-MAC-AES", 16, map[int]bool{16: true, 24: true, 32: true}, true}
+	retailMAC = &keyedMACPrimitive{"RetailMAC-TDES", 8, map[int]bool{16: true}, true}
+)
+
+func TestCMACGenerateTruncated(t *testing.T) {
+	vectorSet := `{"testGroups": [{
+		"tgId": 1, "testType": "AFT", "direction": "gen", "keyLen": 128, "msgLen": 16, "macLen": 64,
+		"tests": [{"tcId": 1, "key": "000102030405060708090a0b0c0d0e0f", "message": "0001"}]
+	}]}`
+
+	m := &fakeTransactable{respond: func(cmd string, args [][]byte) ([][]byte, error) {
+		return [][]byte{bytes.Repeat([]byte{0xaa}, int(args[0][0]))}, nil
+	}}
+	result, err := cmacAES.Process([]byte(vectorSet), m)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(m.calls) != 1 || m.calls[0].cmd != "CMAC-AES" || !bytes.Equal(m.calls[0].args[0], uint32le(8)) {
+		t.Fatalf("unexpected calls %v", m.calls)
+	}
+	resultBytes, _ := json.Marshal(result)
+	if want := `[{"tgId":1,"tests":[{"tcId":1,"mac":"aaaaaaaaaaaaaaaa"}]}]`; string(resultBytes) != want {
+		t.Errorf("got %s, want %s", resultBytes, want)
+	}
+
+	// A full-length MAC is not what was asked for.
+	m = &fakeTransactable{respond: func(cmd string, args [][]byte) ([][]byte, error) {
+		return [][]byte{make([]byte, 16)}, nil
+	}}
+	if _, err := cmacAES.Process([]byte(vectorSet), m); err == nil {
+		t.Error("untruncated MAC was accepted")
+	}
+}
+
+func TestCMACVerifyRejected(t *testing.T) {
+	// Two-key TDES, so the third key is the first.
+	vectorSet := `{"testGroups": [{
+		"tgId": 1, "testType": "AFT", "direction": "ver", "keyingOption": 2, "msgLen": 16, "macLen": 32,
+		"tests": [{"tcId": 1, "key1": "0101010101010101", "key2": "0202020202020202", "key3": "0101010101010101", "message": "0001", "mac": "01020304"}]
+	}]}`
+
+	m := &fakeTransactable{respond: func(cmd string, args [][]byte) ([][]byte, error) {
+		return [][]byte{{0}}, nil
+	}}
+	result, err := cmacTDES.Process([]byte(vectorSet), m)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(m.calls) != 1 || m.calls[0].cmd != "CMAC-TDES/verify" || len(m.calls[0].args[0]) != 24 {
+		t.Fatalf("unexpected calls %v", m.calls)
+	}
+	resultBytes, _ := json.Marshal(result)
+	if want := `[{"tgId":1,"tests":[{"tcId":1,"testPassed":false}]}]`; string(resultBytes) != want {
+		t.Errorf("got %s, want %s", resultBytes, want)
+	}
+}
+
+func TestCMACInvalidGroups(t *testing.T) {
+	for _, tc := range []struct {
+		name      string
+		prim      *keyedMACPrimitive
+		vectorSet string
+		want      string
+	}{
+		{
+			"MAC longer than block",
+			cmacTDES,
+			`{"testGroups": [{"tgId": 1, "direction": "gen", "msgLen": 0, "macLen": 72, "tests": []}]}`,
+			"MACs are between 8 and 64 bits",
+		},
+		{
+			"unsupported key size",
+			cmacAES,
+			`{"testGroups": [{"tgId": 1, "direction": "gen", "keyLen": 64, "msgLen": 0, "macLen": 64, "tests": [{"tcId": 1, "key": "0001020304050607", "message": ""}]}]}`,
+			"does not support",
+		},
+		{
+			"two-key TDES with three keys",
+			cmacTDES,
+			`{"testGroups": [{"tgId": 1, "direction": "gen", "keyingOption": 2, "msgLen": 0, "macLen": 64, "tests": [{"tcId": 1, "key1": "0101010101010101", "key2": "0202020202020202", "key3": "0303030303030303", "message": ""}]}]}`,
+			"third key differs",
+		},
+		{
+			"CBC-MAC without padding method",
+			cbcMACAES,
+			`{"testGroups": [{"tgId": 1, "direction": "gen", "msgLen": 0, "macLen": 64, "tests": []}]}`,
+			"unknown padding method 0",
+		},
+		{
+			"CMAC with padding method",
+			cmacAES,
+			`{"testGroups": [{"tgId": 1, "direction": "gen", "paddingMethod": 1, "msgLen": 0, "macLen": 64, "tests": []}]}`,
+			"has a padding method",
+		},
+		{
+			"Retail MAC longer than block",
+			retailMAC,
+			`{"testGroups": [{"tgId": 1, "direction": "gen", "paddingMethod": 1, "msgLen": 0, "macLen": 128, "tests": []}]}`,
+			"MACs are between 8 and 64 bits",
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			m := &fakeTransactable{respond: func(cmd string, args [][]byte) ([][]byte, error) {
+				t.Fatalf("unexpected command %q", cmd)
+				return nil, nil
+			}}
+			_, err := tc.prim.Process([]byte(tc.vectorSet), m)
+			if err == nil || !strings.Contains(err.Error(), tc.want) {
+				t.Errorf("got error %v, want one containing %q", err, tc.want)
+			}
+		})
+	}
+}
+
+func TestRetailMACGenerate(t *testing.T) {
+	// The message and keys are those of ISO/IEC 9797-1, annex B. With the
+	// first key alone, the MAC is f1d30f6849312ca4, as in FIPS 113.
+	vectorSet := `{"testGroups": [{
+		"tgId": 1, "testType": "AFT", "direction": "gen", "paddingMethod": 1, "msgLen": 224, "macLen": 64,
+		"tests": [{"tcId": 1, "key1": "0123456789abcdef", "key2": "fedcba9876543210", "message": "37363534333231204e6f77206973207468652074696d6520666f7220"}]
+	}]}`
+
+	m := &fakeTransactable{respond: func(cmd string, args [][]byte) ([][]byte, error) {
+		return mockmodule.New().Transact(cmd, 1, args...)
+	}}
+	result, err := retailMAC.Process([]byte(vectorSet), m)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(m.calls) != 1 || len(m.calls[0].args) != 4 || !bytes.Equal(m.calls[0].args[3], uint32le(1)) {
+		t.Fatalf("unexpected calls %v", m.calls)
+	}
+	resultBytes, _ := json.Marshal(result)
+	if want := `[{"tgId":1,"tests":[{"tcId":1,"mac":"ae4b45b1b527642f"}]}]`; string(resultBytes) != want {
+		t.Errorf("got %s, want %s", resultBytes, want)
+	}
+}
+
+func TestCBCMACVerifyRejected(t *testing.T) {
+	vectorSet := `{"testGroups": [{
+		"tgId": 1, "testType": "AFT", "direction": "ver", "paddingMethod": 2, "keyLen": 128, "msgLen": 24, "macLen": 64,
+		"tests": [{"tcId": 1, "key": "000102030405060708090a0b0c0d0e0f", "message": "000102", "mac": "0000000000000000"}]
+	}]}`
+
+	m := &fakeTransactable{respond: func(cmd string, args [][]byte) ([][]byte, error) {
+		return mockmodule.New().Transact(cmd, 1, args...)
+	}}
+	result, err := cbcMACAES.Process([]byte(vectorSet), m)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(m.calls) != 1 || m.calls[0].cmd != "CBC-MAC-AES/verify" || !bytes.Equal(m.calls[0].args[3], uint32le(2)) {
+		t.Fatalf("unexpected calls %v", m.calls)
+	}
+	resultBytes, _ := json.Marshal(result)
+	if want := `[{"tgId":1,"tests":[{"tcId":1,"testPassed":false}]}]`; string(resultBytes) != want {
+		t.Errorf("got %s, want %s", resultBytes, want)
+	}
+}
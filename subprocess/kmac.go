@@ -0,0 +1,275 @@
+// Copyright (c) 2026, Google Inc.
+//
+// Permission to use, copy, modify, and/or distribute this software for any
+// purpose with or without fee is hereby granted, provided that the above
+// copyright notice and this permission notice appear in all copies.
+//
+// THE SOFTWARE IS PROVIDED "AS IS" AND THE AUTHOR DISCLAIMS ALL WARRANTIES
+// WITH REGARD TO THIS SOFTWARE INCLUDING ALL IMPLIED WARRANTIES OF
+// MERCHANTABILITY AND FITNESS. IN NO EVENT SHALL THE AUTHOR BE LIABLE FOR ANY
+// SPECIAL, DIRECT, INDIRECT, OR CONSEQUENTIAL DAMAGES OR ANY DAMAGES
+// WHATSOEVER RESULTING FROM LOSS OF USE, DATA OR PROFITS, WHETHER IN AN ACTION
+// OF CONTRACT, NEGLIGENCE OR OTHER TORTIOUS ACTION, ARISING OUT OF OR IN
+// CONNECTION WITH THE USE OR PERFORMANCE OF THIS SOFTWARE.
+
+package subprocess
+
+import (
+	"encoding/binary"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+)
+
+// The following structures reflect the JSON of ACVP KMAC tests. See
+// https://pages.nist.gov/ACVP/draft-celi-acvp-kmac.html#name-test-vectors
+
+type kmacTestVectorSet struct {
+	Groups []kmacTestGroup `json:"testGroups"`
+}
+
+type kmacTestGroup struct {
+	ID               uint64 `json:"tgId"`
+	Type             string `json:"testType"`
+	XOF              bool   `json:"xof"`
+	HexCustomization bool   `json:"hexCustomization"`
+	MaxOutLenBits    uint32 `json:"maxOutLen"`
+	MinOutLenBits    uint32 `json:"minOutLen"`
+	OutLenIncrement  uint32 `json:"outLenIncrement"`
+	Tests            []struct {
+		ID               uint64 `json:"tcId"`
+		KeyHex           string `json:"key"`
+		KeyBits          uint64 `json:"keyLen"`
+		MsgHex           string `json:"msg"`
+		MsgBits          uint64 `json:"msgLen"`
+		MACHex           string `json:"mac"`
+		MACBits          uint32 `json:"macLen"`
+		Customization    string `json:"customization"`
+		CustomizationHex string `json:"customizationHex"`
+	} `json:"tests"`
+}
+
+type kmacTestGroupResponse struct {
+	ID    uint64             `json:"tgId"`
+	Tests []kmacTestResponse `json:"tests"`
+}
+
+type kmacTestResponse struct {
+	ID         uint64          `json:"tcId"`
+	MACHex     string          `json:"mac,omitempty"`
+	Passed     *bool           `json:"testPassed,omitempty"`
+	MCTResults []kmacMCTResult `json:"resultsArray,omitempty"`
+}
+
+type kmacMCTResult struct {
+	KeyHex  string `json:"key"`
+	MsgHex  string `json:"msg"`
+	MACHex  string `json:"mac"`
+	MACBits uint32 `json:"macLen"`
+}
+
+// kmac implements an ACVP algorithm by making requests to the subprocess to
+// compute and verify KMAC and KMACXOF values.
+type kmac struct {
+	// algo is the ACVP name for this algorithm and also the command name
+	// given to the subprocess.
+	algo string
+}
+
+// decodeCustomization returns the customization string for a test case. ACVP
+// sends the customization either as a plain string or, when the group sets
+// hexCustomization, as a hex string, and exactly one of the two forms is
+// expected.
+func decodeCustomization(hexCustomization bool, customization, customizationHex string) ([]byte, error) {
+	if hexCustomization {
+		if len(customization) != 0 {
+			return nil, fmt.Errorf("hex customization expected but string customization %q given", customization)
+		}
+		return hex.DecodeString(customizationHex)
+	}
+
+	if len(customizationHex) != 0 {
+		return nil, fmt.Errorf("string customization expected but hex customization %q given", customizationHex)
+	}
+	return []byte(customization), nil
+}
+
+func (k *kmac) describe() AlgorithmCapabilities {
+	return AlgorithmCapabilities{
+		TestTypes: []string{"AFT", "MVT", "MCT"},
+		Capabilities: map[string]any{
+			"xof":              []bool{false, true},
+			"hexCustomization": []bool{false, true},
+		},
+	}
+}
+
+func (k *kmac) Process(vectorSet []byte, m Transactable) (any, error) {
+	var parsed kmacTestVectorSet
+	if err := json.Unmarshal(vectorSet, &parsed); err != nil {
+		return nil, err
+	}
+
+	var ret []kmacTestGroupResponse
+	// See
+	// https://pages.nist.gov/ACVP/draft-celi-acvp-kmac.html#name-test-types
+	// for details about the tests.
+	for _, group := range parsed.Groups {
+		group := group
+		response := kmacTestGroupResponse{
+			ID: group.ID,
+		}
+
+		var generate, mct bool
+		switch group.Type {
+		case "AFT":
+			generate = true
+		case "MVT":
+			generate = false
+		case "MCT":
+			mct = true
+			if group.MinOutLenBits%8 != 0 || group.MaxOutLenBits%8 != 0 || group.OutLenIncrement%8 != 0 {
+				return nil, fmt.Errorf("MCT test group %d has output lengths %d-%d in steps of %d - fractional bytes not supported", group.ID, group.MinOutLenBits, group.MaxOutLenBits, group.OutLenIncrement)
+			}
+			// As for cSHAKE, the next output length is taken from the last
+			// 16 bits of each MAC.
+			if group.MinOutLenBits < 16 || group.MinOutLenBits > group.MaxOutLenBits {
+				return nil, fmt.Errorf("MCT test group %d has invalid output lengths %d-%d", group.ID, group.MinOutLenBits, group.MaxOutLenBits)
+			}
+		default:
+			return nil, fmt.Errorf("test group %d has unknown type %q", group.ID, group.Type)
+		}
+
+		xof := []byte{0}
+		if group.XOF {
+			xof = []byte{1}
+		}
+
+		for _, test := range group.Tests {
+			test := test
+			m := withTestCase(m, k.algo, group.ID, test.ID)
+
+			if uint64(len(test.KeyHex))*4 != test.KeyBits {
+				return nil, fmt.Errorf("test case %d/%d contains hex key of length %d but specifies a bit length of %d", group.ID, test.ID, len(test.KeyHex), test.KeyBits)
+			}
+			key, err := hex.DecodeString(test.KeyHex)
+			if err != nil {
+				return nil, fmt.Errorf("failed to decode key in test case %d/%d: %s", group.ID, test.ID, err)
+			}
+
+			if uint64(len(test.MsgHex))*4 != test.MsgBits {
+				return nil, fmt.Errorf("test case %d/%d contains hex message of length %d but specifies a bit length of %d", group.ID, test.ID, len(test.MsgHex), test.MsgBits)
+			}
+			msg, err := hex.DecodeString(test.MsgHex)
+			if err != nil {
+				return nil, fmt.Errorf("failed to decode message in test case %d/%d: %s", group.ID, test.ID, err)
+			}
+
+			if test.MACBits%8 != 0 {
+				return nil, fmt.Errorf("test case %d/%d has MAC length %d - fractional bytes not supported", group.ID, test.ID, test.MACBits)
+			}
+			if !mct {
+				if err := checkOutLen(test.MACBits, group.MinOutLenBits, group.MaxOutLenBits, group.OutLenIncrement); err != nil {
+					return nil, fmt.Errorf("test case %d/%d: %s", group.ID, test.ID, err)
+				}
+			}
+
+			customization, err := decodeCustomization(group.HexCustomization, test.Customization, test.CustomizationHex)
+			if err != nil {
+				return nil, testCaseInputError(k.algo, group.ID, test.ID, fmt.Errorf("failed to decode customization: %w", err))
+			}
+
+			if mct {
+				testResponse := kmacTestResponse{ID: test.ID}
+				minOutLenBytes := uint32le(group.MinOutLenBits / 8)
+				maxOutLenBytes := uint32le(group.MaxOutLenBits / 8)
+				outLenIncrementBytes := uint32le(group.OutLenIncrement / 8)
+				outputLenBytes := uint32le(group.MaxOutLenBits / 8)
+
+				// As with cSHAKE, the module runs the inner loop of each
+				// iteration and returns the values that seed the next one.
+				// In cSHAKE only the message (the previous digest) and the
+				// customization string carry over, but here the key also
+				// evolves: the module returns the next key, derived from the
+				// MAC and the previous key, and the next message and output
+				// length, derived from the MAC. The customization string is
+				// fixed for the whole test. Each result records the inputs to
+				// its iteration alongside the final MAC.
+				for i := 0; i < 100; i++ {
+					args := [][]byte{key, msg, minOutLenBytes, maxOutLenBytes, outputLenBytes, outLenIncrementBytes, customization, xof}
+					result, err := m.Transact(k.algo+"/MCT", 4, args...)
+					if err != nil {
+						return nil, fmt.Errorf("%s MCT operation failed for test case %d/%d: %w", k.algo, group.ID, test.ID, err)
+					}
+					if err := checkMCTIteration(i, result[0], result[3], group.MinOutLenBits/8, group.MaxOutLenBits/8); err != nil {
+						return nil, fmt.Errorf("%s MCT operation for test case %d/%d: %s", k.algo, group.ID, test.ID, err)
+					}
+
+					testResponse.MCTResults = append(testResponse.MCTResults, kmacMCTResult{
+						KeyHex:  hex.EncodeToString(key),
+						MsgHex:  hex.EncodeToString(msg),
+						MACHex:  hex.EncodeToString(result[0]),
+						MACBits: uint32(len(result[0]) * 8),
+					})
+					key = result[1]
+					msg = result[2]
+					outputLenBytes = uint32le(binary.LittleEndian.Uint32(result[3]))
+				}
+
+				response.Tests = append(response.Tests, testResponse)
+			} else if generate {
+				if len(test.MACHex) != 0 {
+					return nil, fmt.Errorf("test case %d/%d contains MAC but should not", group.ID, test.ID)
+				}
+
+				outBytes := int(test.MACBits / 8)
+				m.TransactAsync(k.algo, 1, [][]byte{key, msg, uint32le(test.MACBits / 8), customization, xof}, func(result [][]byte) error {
+					if len(result[0]) != outBytes {
+						return fmt.Errorf("%s operation returned %d bytes but wanted %d", k.algo, len(result[0]), outBytes)
+					}
+
+					response.Tests = append(response.Tests, kmacTestResponse{
+						ID:     test.ID,
+						MACHex: hex.EncodeToString(result[0]),
+					})
+					return nil
+				})
+			} else {
+				if uint32(len(test.MACHex))*4 != test.MACBits {
+					return nil, fmt.Errorf("test case %d/%d contains hex MAC of length %d but specifies a bit length of %d", group.ID, test.ID, len(test.MACHex), test.MACBits)
+				}
+				mac, err := hex.DecodeString(test.MACHex)
+				if err != nil {
+					return nil, fmt.Errorf("failed to decode MAC in test case %d/%d: %s", group.ID, test.ID, err)
+				}
+
+				// The claimed MAC is already truncated to macLen, so its
+				// length is the output length that the module must use. For
+				// KMAC, unlike KMACXOF, the length is an input to the MAC,
+				// so the module can't compare a prefix of a longer MAC.
+				m.TransactAsync(k.algo+"/verify", 1, [][]byte{key, msg, mac, customization, xof}, func(result [][]byte) error {
+					if len(result[0]) != 1 || (result[0][0]&0xfe) != 0 {
+						return fmt.Errorf("wrapper %s returned invalid success flag: %x", k.algo, result[0])
+					}
+
+					ok := result[0][0] == 1
+					response.Tests = append(response.Tests, kmacTestResponse{
+						ID:     test.ID,
+						Passed: &ok,
+					})
+					return nil
+				})
+			}
+		}
+
+		m.Barrier(func() {
+			ret = append(ret, response)
+		})
+	}
+
+	if err := m.Flush(); err != nil {
+		return nil, err
+	}
+
+	return ret, nil
+}
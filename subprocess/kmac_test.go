@@ -0,0 +1,260 @@
+// Copyright (c) 2026, Google Inc.
+//
+// Permission to use, copy, modify, and/or distribute this software for any
+// purpose with or without fee is hereby granted, provided that the above
+// copyright notice and this permission notice appear in all copies.
+//
+// THE SOFTWARE IS PROVIDED "AS IS" AND THE AUTHOR DISCLAIMS ALL WARRANTIES
+// WITH REGARD TO THIS SOFTWARE INCLUDING ALL IMPLIED WARRANTIES OF
+// MERCHANTABILITY AND FITNESS. IN NO EVENT SHALL THE AUTHOR BE LIABLE FOR ANY
+// SPECIAL, DIRECT, INDIRECT, OR CONSEQUENTIAL DAMAGES OR ANY DAMAGES
+// WHATSOEVER RESULTING FROM LOSS OF USE, DATA OR PROFITS, WHETHER IN AN ACTION
+// OF CONTRACT, NEGLIGENCE OR OTHER TORTIOUS ACTION, ARISING OUT OF OR IN
+// CONNECTION WITH THE USE OR PERFORMANCE OF THIS SOFTWARE.
+
+package subprocess
+
+import (
+	"bytes"
+	"crypto/subtle"
+	"encoding/binary"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"testing"
+
+	"golang.org/x/crypto/sha3"
+)
+
+const kmacMCTVectorSet = `{"testGroups": [{
+	"tgId": 1,
+	"testType": "MCT",
+	"xof": false,
+	"hexCustomization": false,
+	"minOutLen": 32,
+	"maxOutLen": 64,
+	"outLenIncrement": 8,
+	"tests": [{"tcId": 1, "key": "000102", "keyLen": 24, "msg": "0a0b", "msgLen": 16, "customization": "cust"}]
+}]}`
+
+// kmacMCTResponder returns a MAC of the requested length filled with the
+// first key byte, a key one byte longer than the last, a message one past
+// the last and an output length that alternates between its bounds.
+func kmacMCTResponder(cmd string, args [][]byte) ([][]byte, error) {
+	outLen := binary.LittleEndian.Uint32(args[4])
+	nextOutLen := args[2]
+	if outLen == binary.LittleEndian.Uint32(args[2]) {
+		nextOutLen = args[3]
+	}
+	nextKey := append(bytes.Clone(args[0]), byte(len(args[0])))
+	nextMsg := []byte{args[1][0] + 1}
+	return [][]byte{bytes.Repeat(args[0][:1], int(outLen)), nextKey, nextMsg, nextOutLen}, nil
+}
+
+func TestKMACMCT(t *testing.T) {
+	m := &fakeTransactable{respond: kmacMCTResponder}
+	result, err := (&kmac{"KMAC-128"}).Process([]byte(kmacMCTVectorSet), m)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(m.calls) != 100 {
+		t.Fatalf("got %d MCT calls, want 100", len(m.calls))
+	}
+	for i, call := range m.calls {
+		if call.cmd != "KMAC-128/MCT" || len(call.args) != 8 {
+			t.Fatalf("call %d: got command %q with %d args", i, call.cmd, len(call.args))
+		}
+		if string(call.args[6]) != "cust" || !bytes.Equal(call.args[7], []byte{0}) {
+			t.Errorf("call %d: customization %q and XOF flag %x not carried through", i, call.args[6], call.args[7])
+		}
+		if i == 0 {
+			if hex.EncodeToString(call.args[0]) != "000102" || hex.EncodeToString(call.args[1]) != "0a0b" ||
+				binary.LittleEndian.Uint32(call.args[4]) != 8 {
+				t.Errorf("first call has key %x, message %x, output length %x", call.args[0], call.args[1], call.args[4])
+			}
+			continue
+		}
+		// Each round is seeded from the results of the previous one.
+		prev, _ := kmacMCTResponder(m.calls[i-1].cmd, m.calls[i-1].args)
+		if !bytes.Equal(call.args[0], prev[1]) || !bytes.Equal(call.args[1], prev[2]) || !bytes.Equal(call.args[4], prev[3]) {
+			t.Errorf("call %d: key %x, message %x, output length %x don't follow from the previous round", i, call.args[0], call.args[1], call.args[4])
+		}
+	}
+
+	results := result.([]kmacTestGroupResponse)[0].Tests[0].MCTResults
+	if len(results) != 100 {
+		t.Fatalf("got %d MCT results, want 100", len(results))
+	}
+	if r := results[1]; r.KeyHex != "00010203" || r.MsgHex != "0b" || r.MACBits != 32 || r.MACHex != "00000000" {
+		t.Errorf("unexpected second result: %+v", r)
+	}
+}
+
+// leftEncode and rightEncode are from SP 800-185, section 2.3.1.
+func leftEncode(x uint64) []byte {
+	var buf [9]byte
+	binary.BigEndian.PutUint64(buf[1:], x)
+	i := 1
+	for i < 8 && buf[i] == 0 {
+		i++
+	}
+	buf[i-1] = byte(9 - i)
+	return buf[i-1:]
+}
+
+func rightEncode(x uint64) []byte {
+	encoded := leftEncode(x)
+	return append(encoded[1:], encoded[0])
+}
+
+// kmac128 computes KMAC128, or KMACXOF128 if xof is set, as in SP 800-185,
+// section 4.3.
+func kmac128(key, msg, customization []byte, outBytes int, xof bool) []byte {
+	const rate = 168
+	h := sha3.NewCShake128([]byte("KMAC"), customization)
+
+	encodedKey := append(leftEncode(uint64(len(key))*8), key...)
+	padded := append(leftEncode(rate), encodedKey...)
+	padded = append(padded, make([]byte, (rate-len(padded)%rate)%rate)...)
+	h.Write(padded)
+	h.Write(msg)
+	if xof {
+		h.Write(rightEncode(0))
+	} else {
+		h.Write(rightEncode(uint64(outBytes) * 8))
+	}
+
+	out := make([]byte, outBytes)
+	h.Read(out)
+	return out
+}
+
+// kmacVerifyResponder verifies KMAC-128 MACs at the length of the claimed
+// MAC.
+func kmacVerifyResponder(cmd string, args [][]byte) ([][]byte, error) {
+	if cmd != "KMAC-128/verify" {
+		return nil, fmt.Errorf("unexpected command %q", cmd)
+	}
+	key, msg, mac, customization, xof := args[0], args[1], args[2], args[3], args[4][0] == 1
+	computed := kmac128(key, msg, customization, len(mac), xof)
+	return [][]byte{{byte(subtle.ConstantTimeCompare(computed, mac))}}, nil
+}
+
+func TestKMACVerify(t *testing.T) {
+	key := []byte{0x40, 0x41, 0x42, 0x43}
+	msg := []byte{0x00, 0x01, 0x02, 0x03}
+	customization := []byte("cust")
+
+	// As a prefix of a longer output, a KMACXOF MAC is still valid at its
+	// shorter length.
+	xofMAC := kmac128(key, msg, customization, 32, true)[:8]
+	// A KMAC MAC can't be truncated in the same way, because the output
+	// length is an input to the MAC.
+	fullMAC := kmac128(key, msg, customization, 32, false)
+	flipped := bytes.Clone(fullMAC)
+	flipped[31] ^= 1
+
+	for _, tc := range []struct {
+		name   string
+		xof    bool
+		mac    []byte
+		passed bool
+	}{
+		{"exact match", false, fullMAC, true},
+		{"truncated XOF match", true, xofMAC, true},
+		{"truncated KMAC", false, fullMAC[:8], false},
+		{"one bit different", false, flipped, false},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			vectorSet := fmt.Sprintf(`{"testGroups": [{
+	"tgId": 1,
+	"testType": "MVT",
+	"xof": %t,
+	"minOutLen": 32,
+	"maxOutLen": 512,
+	"outLenIncrement": 8,
+	"tests": [{"tcId": 1, "key": "%x", "keyLen": 32, "msg": "%x", "msgLen": 32, "mac": "%x", "macLen": %d, "customization": "cust"}]
+}]}`, tc.xof, key, msg, tc.mac, len(tc.mac)*8)
+
+			m := &fakeTransactable{respond: kmacVerifyResponder}
+			result, err := (&kmac{"KMAC-128"}).Process([]byte(vectorSet), m)
+			if err != nil {
+				t.Fatal(err)
+			}
+			resultBytes, err := json.Marshal(result)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if want := fmt.Sprintf(`"testPassed":%t`, tc.passed); !strings.Contains(string(resultBytes), want) {
+				t.Errorf("got %s, want %s", resultBytes, want)
+			}
+		})
+	}
+}
+
+func TestKMACVerifyLengthMismatch(t *testing.T) {
+	// The MAC is shorter than macLen.
+	vectorSet := `{"testGroups": [{
+	"tgId": 1,
+	"testType": "MVT",
+	"tests": [{"tcId": 1, "key": "00", "keyLen": 8, "msg": "", "msgLen": 0, "mac": "0001", "macLen": 32, "customization": ""}]
+}]}`
+	m := &fakeTransactable{respond: kmacVerifyResponder}
+	if _, err := (&kmac{"KMAC-128"}).Process([]byte(vectorSet), m); err == nil {
+		t.Fatal("MAC shorter than macLen was accepted")
+	}
+	if len(m.calls) != 0 {
+		t.Errorf("got %d calls, want none", len(m.calls))
+	}
+}
+
+func TestKMACEmptyMessage(t *testing.T) {
+	key := []byte{0x40, 0x41, 0x42, 0x43}
+
+	for _, customization := range []string{"", "My Tagged Application"} {
+		t.Run(fmt.Sprintf("customization %q", customization), func(t *testing.T) {
+			vectorSet := fmt.Sprintf(`{"testGroups": [{
+	"tgId": 1,
+	"testType": "AFT",
+	"xof": false,
+	"minOutLen": 32,
+	"maxOutLen": 512,
+	"outLenIncrement": 8,
+	"tests": [{"tcId": 1, "key": "%x", "keyLen": 32, "msg": "", "msgLen": 0, "macLen": 256, "customization": %q}]
+}]}`, key, customization)
+
+			m := &fakeTransactable{respond: mockResponder}
+			result, err := (&kmac{"KMAC-128"}).Process([]byte(vectorSet), m)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(m.calls) != 1 {
+				t.Fatalf("got %d calls, want 1", len(m.calls))
+			}
+			if msg := m.calls[0].args[1]; msg == nil || len(msg) != 0 {
+				t.Errorf("module was given message %#v, want an empty one", msg)
+			}
+			want := hex.EncodeToString(kmac128(key, nil, []byte(customization), 32, false))
+			if got := result.([]kmacTestGroupResponse)[0].Tests[0].MACHex; got != want {
+				t.Errorf("got MAC %s, want %s", got, want)
+			}
+		})
+	}
+}
+
+func TestKMACMCTEmptyMAC(t *testing.T) {
+	m := &fakeTransactable{}
+	m.respond = func(cmd string, args [][]byte) ([][]byte, error) {
+		result, err := kmacMCTResponder(cmd, args)
+		if len(m.calls) == 10 {
+			result[0] = nil
+		}
+		return result, err
+	}
+	_, err := (&kmac{"KMAC-128"}).Process([]byte(kmacMCTVectorSet), m)
+	if err == nil || !strings.Contains(err.Error(), "iteration 9 returned a 0-byte output, outside of 4-8 bytes") {
+		t.Fatalf("got error %v, want one naming the iteration", err)
+	}
+}
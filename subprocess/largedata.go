@@ -0,0 +1,50 @@
+// Copyright (c) 2026, Google Inc.
+//
+// Permission to use, copy, modify, and/or distribute this software for any
+// purpose with or without fee is hereby granted, provided that the above
+// copyright notice and this permission notice appear in all copies.
+//
+// THE SOFTWARE IS PROVIDED "AS IS" AND THE AUTHOR DISCLAIMS ALL WARRANTIES
+// WITH REGARD TO THIS SOFTWARE INCLUDING ALL IMPLIED WARRANTIES OF
+// MERCHANTABILITY AND FITNESS. IN NO EVENT SHALL THE AUTHOR BE LIABLE FOR ANY
+// SPECIAL, DIRECT, INDIRECT, OR CONSEQUENTIAL DAMAGES OR ANY DAMAGES
+// WHATSOEVER RESULTING FROM LOSS OF USE, DATA OR PROFITS, WHETHER IN AN ACTION
+// OF CONTRACT, NEGLIGENCE OR OTHER TORTIOUS ACTION, ARISING OUT OF OR IN
+// CONNECTION WITH THE USE OR PERFORMANCE OF THIS SOFTWARE.
+
+package subprocess
+
+import (
+	"fmt"
+	"math"
+)
+
+// expandLargeData returns the payload of a test in a large-data group, which
+// is pattern repeated, and truncated, to payloadBits. Only the pattern appears
+// in the JSON so that multi-gigabyte payloads don't need multi-gigabyte
+// vector sets.
+//
+// The whole payload is returned because the protocol sends each argument as a
+// single length-prefixed string, so the length of each argument is limited to
+// 32 bits and there is no way to stream one to the module.
+func expandLargeData(pattern []byte, payloadBits uint64) ([]byte, error) {
+	if payloadBits%8 != 0 {
+		return nil, fmt.Errorf("payload of %d bits is not a whole number of bytes", payloadBits)
+	}
+	payloadLen := payloadBits / 8
+	if payloadLen > math.MaxUint32 {
+		return nil, fmt.Errorf("payload of %d bytes is too large to send to the module", payloadLen)
+	}
+	if len(pattern) == 0 && payloadLen > 0 {
+		return nil, fmt.Errorf("empty pattern for a payload of %d bytes", payloadLen)
+	}
+
+	// Doubling the filled prefix copies O(log n) times rather than once for
+	// each repetition of a short pattern.
+	payload := make([]byte, payloadLen)
+	filled := copy(payload, pattern)
+	for filled < len(payload) {
+		filled += copy(payload[filled:], payload[:filled])
+	}
+	return payload, nil
+}
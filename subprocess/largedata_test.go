@@ -0,0 +1,102 @@
+// Copyright (c) 2026, Google Inc.
+//
+// Permission to use, copy, modify, and/or distribute this software for any
+// purpose with or without fee is hereby granted, provided that the above
+// copyright notice and this permission notice appear in all copies.
+//
+// THE SOFTWARE IS PROVIDED "AS IS" AND THE AUTHOR DISCLAIMS ALL WARRANTIES
+// WITH REGARD TO THIS SOFTWARE INCLUDING ALL IMPLIED WARRANTIES OF
+// MERCHANTABILITY AND FITNESS. IN NO EVENT SHALL THE AUTHOR BE LIABLE FOR ANY
+// SPECIAL, DIRECT, INDIRECT, OR CONSEQUENTIAL DAMAGES OR ANY DAMAGES
+// WHATSOEVER RESULTING FROM LOSS OF USE, DATA OR PROFITS, WHETHER IN AN ACTION
+// OF CONTRACT, NEGLIGENCE OR OTHER TORTIOUS ACTION, ARISING OUT OF OR IN
+// CONNECTION WITH THE USE OR PERFORMANCE OF THIS SOFTWARE.
+
+package subprocess
+
+import (
+	"bytes"
+	"crypto/aes"
+	"crypto/cipher"
+	"fmt"
+	"testing"
+)
+
+func checkPattern(t *testing.T, payload, pattern []byte, wantLen int) {
+	t.Helper()
+	if len(payload) != wantLen {
+		t.Fatalf("payload is %d bytes, want %d", len(payload), wantLen)
+	}
+	for i, b := range payload {
+		if b != pattern[i%len(pattern)] {
+			t.Fatalf("byte %d of the payload is %02x, want %02x", i, b, pattern[i%len(pattern)])
+		}
+	}
+}
+
+func TestExpandLargeData(t *testing.T) {
+	// The pattern doesn't divide the payload length, so the last
+	// repetition is truncated.
+	pattern := []byte{0x01, 0x02, 0x03, 0x04, 0x05, 0x06, 0x07}
+	const payloadLen = 1 << 20
+	payload, err := expandLargeData(pattern, 8*payloadLen)
+	if err != nil {
+		t.Fatal(err)
+	}
+	checkPattern(t, payload, pattern, payloadLen)
+
+	if _, err := expandLargeData(pattern, 8*payloadLen+4); err == nil {
+		t.Error("fractional-byte payload was accepted")
+	}
+	if _, err := expandLargeData(nil, 8); err == nil {
+		t.Error("empty pattern was accepted")
+	}
+	if _, err := expandLargeData(pattern, 8<<32); err == nil {
+		t.Error("payload too large for the protocol was accepted")
+	}
+}
+
+func TestGCMLargeData(t *testing.T) {
+	vectorSet := fmt.Sprintf(`{"testGroups": [{
+	"tgId": 1, "testType": "AFT", "direction": "encrypt", "keyLen": 128, "tagLen": 128, "ivLen": 96, "ivGen": "external", "largeData": true,
+	"tests": [{"tcId": 1, "payloadLen": %d, "pt": "deadbeef", "key": "000102030405060708090a0b0c0d0e0f", "iv": "000000000000000000000000", "aad": ""}]
+}]}`, 8<<20)
+
+	m := &fakeTransactable{respond: func(cmd string, args [][]byte) ([][]byte, error) {
+		block, err := aes.NewCipher(args[1])
+		if err != nil {
+			return nil, err
+		}
+		gcm, err := cipher.NewGCM(block)
+		if err != nil {
+			return nil, err
+		}
+		return [][]byte{gcm.Seal(nil, args[3], args[2], args[4])}, nil
+	}}
+	gcm := &aead{"AES-GCM", false, 0, false}
+	if _, err := gcm.Process([]byte(vectorSet), m); err != nil {
+		t.Fatal(err)
+	}
+	checkPattern(t, m.calls[0].args[2], []byte{0xde, 0xad, 0xbe, 0xef}, 1<<20)
+
+	decrypt := bytes.Replace([]byte(vectorSet), []byte(`"encrypt"`), []byte(`"decrypt"`), 1)
+	if _, err := gcm.Process(decrypt, &fakeTransactable{respond: m.respond}); err == nil {
+		t.Error("large-data decryption group was accepted")
+	}
+}
+
+func TestCBCLargeData(t *testing.T) {
+	vectorSet := fmt.Sprintf(`{"testGroups": [{
+	"tgId": 1, "testType": "AFT", "direction": "encrypt", "keylen": 128, "largeData": true,
+	"tests": [{"tcId": 1, "payloadLen": %d, "pt": "00112233445566778899aabbccddeeff", "key": "000102030405060708090a0b0c0d0e0f", "iv": "000102030405060708090a0b0c0d0e0f"}]
+}]}`, 8<<20)
+
+	m := &fakeTransactable{respond: func(cmd string, args [][]byte) ([][]byte, error) {
+		return [][]byte{make([]byte, len(args[1])), make([]byte, 16)}, nil
+	}}
+	cbc := &blockCipher{"AES-CBC", 16, 2, true, true, iterateAESCBC}
+	if _, err := cbc.Process([]byte(vectorSet), m); err != nil {
+		t.Fatal(err)
+	}
+	checkPattern(t, m.calls[0].args[1], mustDecodeHex(t, "00112233445566778899aabbccddeeff"), 1<<20)
+}
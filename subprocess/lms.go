@@ -0,0 +1,217 @@
+// Copyright (c) 2026, Google Inc.
+//
+// Permission to use, copy, modify, and/or distribute this software for any
+// purpose with or without fee is hereby granted, provided that the above
+// copyright notice and this permission notice appear in all copies.
+//
+// THE SOFTWARE IS PROVIDED "AS IS" AND THE AUTHOR DISCLAIMS ALL WARRANTIES
+// WITH REGARD TO THIS SOFTWARE INCLUDING ALL IMPLIED WARRANTIES OF
+// MERCHANTABILITY AND FITNESS. IN NO EVENT SHALL THE AUTHOR BE LIABLE FOR ANY
+// SPECIAL, DIRECT, INDIRECT, OR CONSEQUENTIAL DAMAGES OR ANY DAMAGES
+// WHATSOEVER RESULTING FROM LOSS OF USE, DATA OR PROFITS, WHETHER IN AN ACTION
+// OF CONTRACT, NEGLIGENCE OR OTHER TORTIOUS ACTION, ARISING OUT OF OR IN
+// CONNECTION WITH THE USE OR PERFORMANCE OF THIS SOFTWARE.
+
+package subprocess
+
+import (
+	"encoding/binary"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+// The following structures reflect the JSON of ACVP LMS tests. See
+// https://pages.nist.gov/ACVP/draft-celi-acvp-lms.html
+
+type lmsTestVectorSet struct {
+	Groups []lmsTestGroup `json:"testGroups"`
+	Mode   string         `json:"mode"`
+}
+
+type lmsTestGroup struct {
+	ID      uint64 `json:"tgId"`
+	Type    string `json:"testType"`
+	LMSMode string `json:"lmsMode"`
+	OTSMode string `json:"lmOtsMode"`
+	Tests   []struct {
+		ID           uint64 `json:"tcId"`
+		PublicKeyHex string `json:"publicKey"`
+		MsgHex       string `json:"message"`
+		SignatureHex string `json:"signature"`
+	} `json:"tests"`
+}
+
+type lmsTestGroupResponse struct {
+	ID    uint64            `json:"tgId"`
+	Tests []lmsTestResponse `json:"tests"`
+}
+
+type lmsTestResponse struct {
+	ID     uint64 `json:"tcId"`
+	Passed bool   `json:"testPassed"`
+}
+
+// lmsParameters maps the names of LMS and LM-OTS parameter sets to their type
+// codes and hash output lengths. See RFC 8554, section 5.1, and
+// SP 800-208, section 4.
+var lmsParameters = map[string]struct {
+	typecode uint32
+	n        int
+}{
+	"LMS_SHA256_M32_H5":   {0x05, 32},
+	"LMS_SHA256_M32_H10":  {0x06, 32},
+	"LMS_SHA256_M32_H15":  {0x07, 32},
+	"LMS_SHA256_M32_H20":  {0x08, 32},
+	"LMS_SHA256_M32_H25":  {0x09, 32},
+	"LMS_SHA256_M24_H5":   {0x0a, 24},
+	"LMS_SHA256_M24_H10":  {0x0b, 24},
+	"LMS_SHA256_M24_H15":  {0x0c, 24},
+	"LMS_SHA256_M24_H20":  {0x0d, 24},
+	"LMS_SHA256_M24_H25":  {0x0e, 24},
+	"LMS_SHAKE_M32_H5":    {0x0f, 32},
+	"LMS_SHAKE_M32_H10":   {0x10, 32},
+	"LMS_SHAKE_M32_H15":   {0x11, 32},
+	"LMS_SHAKE_M32_H20":   {0x12, 32},
+	"LMS_SHAKE_M32_H25":   {0x13, 32},
+	"LMS_SHAKE_M24_H5":    {0x14, 24},
+	"LMS_SHAKE_M24_H10":   {0x15, 24},
+	"LMS_SHAKE_M24_H15":   {0x16, 24},
+	"LMS_SHAKE_M24_H20":   {0x17, 24},
+	"LMS_SHAKE_M24_H25":   {0x18, 24},
+	"LMOTS_SHA256_N32_W1": {0x01, 32},
+	"LMOTS_SHA256_N32_W2": {0x02, 32},
+	"LMOTS_SHA256_N32_W4": {0x03, 32},
+	"LMOTS_SHA256_N32_W8": {0x04, 32},
+	"LMOTS_SHA256_N24_W1": {0x05, 24},
+	"LMOTS_SHA256_N24_W2": {0x06, 24},
+	"LMOTS_SHA256_N24_W4": {0x07, 24},
+	"LMOTS_SHA256_N24_W8": {0x08, 24},
+	"LMOTS_SHAKE_N32_W1":  {0x09, 32},
+	"LMOTS_SHAKE_N32_W2":  {0x0a, 32},
+	"LMOTS_SHAKE_N32_W4":  {0x0b, 32},
+	"LMOTS_SHAKE_N32_W8":  {0x0c, 32},
+	"LMOTS_SHAKE_N24_W1":  {0x0d, 24},
+	"LMOTS_SHAKE_N24_W2":  {0x0e, 24},
+	"LMOTS_SHAKE_N24_W4":  {0x0f, 24},
+	"LMOTS_SHAKE_N24_W8":  {0x10, 24},
+}
+
+// lmsMaxLevels is the largest number of levels in an HSS tree. See RFC 8554,
+// section 6.
+const lmsMaxLevels = 8
+
+// lms implements an ACVP algorithm by making requests to the subprocess to
+// verify LMS and HSS signatures. Generating keys and signatures needs state
+// that can't be reused between tests, so only verification is supported.
+type lms struct{}
+
+// hssPublicKey checks that publicKey is a single-level LMS public key or an
+// HSS public key whose top-level tree uses the given parameters, and returns
+// it in HSS form, along with the number of levels.
+func hssPublicKey(publicKey []byte, lmsType, otsType uint32, n int) ([]byte, uint32, error) {
+	lmsLen := 4 + 4 + 16 + n
+	levels := uint32(1)
+	switch len(publicKey) {
+	case lmsLen:
+		publicKey = append(binary.BigEndian.AppendUint32(nil, 1), publicKey...)
+	case 4 + lmsLen:
+		levels = binary.BigEndian.Uint32(publicKey)
+		if levels < 1 || levels > lmsMaxLevels {
+			return nil, 0, fmt.Errorf("HSS public key has %d levels", levels)
+		}
+	default:
+		return nil, 0, fmt.Errorf("public key is %d bytes long", len(publicKey))
+	}
+
+	if got := binary.BigEndian.Uint32(publicKey[4:]); got != lmsType {
+		return nil, 0, fmt.Errorf("public key has LMS type %d, but the group uses %d", got, lmsType)
+	}
+	if got := binary.BigEndian.Uint32(publicKey[8:]); got != otsType {
+		return nil, 0, fmt.Errorf("public key has LM-OTS type %d, but the group uses %d", got, otsType)
+	}
+	return publicKey, levels, nil
+}
+
+func (l *lms) Process(vectorSet []byte, m Transactable) (any, error) {
+	var parsed lmsTestVectorSet
+	if err := json.Unmarshal(vectorSet, &parsed); err != nil {
+		return nil, err
+	}
+
+	if parsed.Mode != "sigVer" {
+		return nil, fmt.Errorf("LMS mode %q not supported", parsed.Mode)
+	}
+
+	var ret []lmsTestGroupResponse
+	for _, group := range parsed.Groups {
+		group := group
+		response := lmsTestGroupResponse{ID: group.ID}
+
+		if group.Type != "AFT" {
+			return nil, fmt.Errorf("unknown test type %q in sigVer test group %d", group.Type, group.ID)
+		}
+		lmsParams, ok := lmsParameters[group.LMSMode]
+		if !ok || !strings.HasPrefix(group.LMSMode, "LMS_") {
+			return nil, fmt.Errorf("unknown LMS mode %q in test group %d", group.LMSMode, group.ID)
+		}
+		otsParams, ok := lmsParameters[group.OTSMode]
+		if !ok || !strings.HasPrefix(group.OTSMode, "LMOTS_") {
+			return nil, fmt.Errorf("unknown LM-OTS mode %q in test group %d", group.OTSMode, group.ID)
+		}
+		if lmsParams.n != otsParams.n {
+			return nil, fmt.Errorf("test group %d uses %d-byte LMS hashes but %d-byte LM-OTS hashes", group.ID, lmsParams.n, otsParams.n)
+		}
+
+		for _, test := range group.Tests {
+			test := test
+			m := withTestCase(m, "LMS", group.ID, test.ID)
+
+			publicKey, err := hex.DecodeString(test.PublicKeyHex)
+			if err != nil {
+				return nil, fmt.Errorf("failed to decode public key in test case %d/%d: %s", group.ID, test.ID, err)
+			}
+			msg, err := hex.DecodeString(test.MsgHex)
+			if err != nil {
+				return nil, fmt.Errorf("failed to decode message in test case %d/%d: %s", group.ID, test.ID, err)
+			}
+			sig, err := hex.DecodeString(test.SignatureHex)
+			if err != nil {
+				return nil, fmt.Errorf("failed to decode signature in test case %d/%d: %s", group.ID, test.ID, err)
+			}
+
+			// An LMS signature is an HSS signature with no signed public keys.
+			// The signature may have been corrupted, so its structure is left
+			// for the module to check.
+			hssKey, levels, err := hssPublicKey(publicKey, lmsParams.typecode, otsParams.typecode, lmsParams.n)
+			if err != nil {
+				return nil, fmt.Errorf("test case %d/%d: %s", group.ID, test.ID, err)
+			}
+			if levels == 1 && len(hssKey) != len(publicKey) {
+				sig = append(binary.BigEndian.AppendUint32(nil, 0), sig...)
+			}
+
+			m.TransactAsync("LMS/sigVer", 1, [][]byte{hssKey, msg, sig}, func(result [][]byte) error {
+				if len(result[0]) != 1 || (result[0][0]&0xfe) != 0 {
+					return fmt.Errorf("signature verification returned unexpected result: %q", result[0])
+				}
+				response.Tests = append(response.Tests, lmsTestResponse{
+					ID:     test.ID,
+					Passed: result[0][0] == 1,
+				})
+				return nil
+			})
+		}
+
+		m.Barrier(func() {
+			ret = append(ret, response)
+		})
+	}
+
+	if err := m.Flush(); err != nil {
+		return nil, err
+	}
+
+	return ret, nil
+}
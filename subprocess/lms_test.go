@@ -0,0 +1,90 @@
+// Copyright (c) 2026, Google Inc.
+//
+// Permission to use, copy, modify, and/or distribute this software for any
+// purpose with or without fee is hereby granted, provided that the above
+// copyright notice and this permission notice appear in all copies.
+//
+// THE SOFTWARE IS PROVIDED "AS IS" AND THE AUTHOR DISCLAIMS ALL WARRANTIES
+// WITH REGARD TO THIS SOFTWARE INCLUDING ALL IMPLIED WARRANTIES OF
+// MERCHANTABILITY AND FITNESS. IN NO EVENT SHALL THE AUTHOR BE LIABLE FOR ANY
+// SPECIAL, DIRECT, INDIRECT, OR CONSEQUENTIAL DAMAGES OR ANY DAMAGES
+// WHATSOEVER RESULTING FROM LOSS OF USE, DATA OR PROFITS, WHETHER IN AN ACTION
+// OF CONTRACT, NEGLIGENCE OR OTHER TORTIOUS ACTION, ARISING OUT OF OR IN
+// CONNECTION WITH THE USE OR PERFORMANCE OF THIS SOFTWARE.
+
+package subprocess
+
+import (
+	"bytes"
+	"encoding/binary"
+	"encoding/hex"
+	"fmt"
+	"strings"
+	"testing"
+)
+
+// lmsTestKey returns an LMS_SHA256_M32_H10/LMOTS_SHA256_N32_W4 public key.
+func lmsTestKey() []byte {
+	key := binary.BigEndian.AppendUint32(nil, 6)
+	key = binary.BigEndian.AppendUint32(key, 3)
+	key = append(key, bytes.Repeat([]byte{0x1d}, 16)...)
+	return append(key, bytes.Repeat([]byte{0x7e}, 32)...)
+}
+
+func TestLMSSigVer(t *testing.T) {
+	hssKey := append(binary.BigEndian.AppendUint32(nil, 2), lmsTestKey()...)
+	// A two-level HSS signature is the number of signed public keys, the
+	// top-level signature of the lower tree's public key, that public key
+	// and the lower tree's signature of the message. The contents don't
+	// matter to the fake module.
+	hssSig := binary.BigEndian.AppendUint32(nil, 1)
+	hssSig = append(hssSig, bytes.Repeat([]byte{0x51}, 100)...)
+	hssSig = append(hssSig, lmsTestKey()...)
+	hssSig = append(hssSig, bytes.Repeat([]byte{0x52}, 100)...)
+	corrupted := bytes.Clone(hssSig)
+	corrupted[len(corrupted)-1] ^= 1
+	lmsSig := binary.BigEndian.AppendUint32(nil, 0)
+	lmsSig = append(lmsSig, bytes.Repeat([]byte{0x53}, 100)...)
+
+	valid := map[string]bool{string(hssSig): true, string(lmsSig): true}
+	respond := func(cmd string, args [][]byte) ([][]byte, error) {
+		if cmd != "LMS/sigVer" {
+			return nil, fmt.Errorf("unexpected command %q", cmd)
+		}
+		if !bytes.Equal(args[0][4:], lmsTestKey()) {
+			return nil, fmt.Errorf("unexpected public key %x", args[0])
+		}
+		return [][]byte{{boolToByte(valid[string(args[2])])}}, nil
+	}
+
+	vectorSet := `{"algorithm": "LMS", "mode": "sigVer", "testGroups": [{
+		"tgId": 1, "testType": "AFT", "lmsMode": "LMS_SHA256_M32_H10", "lmOtsMode": "LMOTS_SHA256_N32_W4",
+		"tests": [
+			{"tcId": 1, "publicKey": "` + hex.EncodeToString(hssKey) + `", "message": "aa", "signature": "` + hex.EncodeToString(hssSig) + `"},
+			{"tcId": 2, "publicKey": "` + hex.EncodeToString(hssKey) + `", "message": "aa", "signature": "` + hex.EncodeToString(corrupted) + `"},
+			{"tcId": 3, "publicKey": "` + hex.EncodeToString(lmsTestKey()) + `", "message": "aa", "signature": "` + hex.EncodeToString(lmsSig[4:]) + `"}
+		]
+	}]}`
+
+	m := &fakeTransactable{respond: respond}
+	result, err := new(lms).Process([]byte(vectorSet), m)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tests := result.([]lmsTestGroupResponse)[0].Tests
+	if len(tests) != 3 || !tests[0].Passed || tests[1].Passed || !tests[2].Passed {
+		t.Errorf("unexpected response: %+v", tests)
+	}
+	if levels := binary.BigEndian.Uint32(m.calls[2].args[0]); levels != 1 {
+		t.Errorf("LMS public key sent with %d levels", levels)
+	}
+
+	wrongMode := strings.Replace(vectorSet, "LMS_SHA256_M32_H10", "LMS_SHA256_M32_H5", 1)
+	if _, err := new(lms).Process([]byte(wrongMode), &fakeTransactable{respond: respond}); err == nil {
+		t.Error("public key with a different LMS type was accepted")
+	}
+	mixed := strings.Replace(vectorSet, "LMOTS_SHA256_N32_W4", "LMOTS_SHA256_N24_W4", 1)
+	if _, err := new(lms).Process([]byte(mixed), &fakeTransactable{respond: respond}); err == nil {
+		t.Error("group with mismatched hash lengths was accepted")
+	}
+}
@@ -0,0 +1,269 @@
+// Copyright (c) 2026, Google Inc.
+//
+// Permission to use, copy, modify, and/or distribute this software for any
+// purpose with or without fee is hereby granted, provided that the above
+// copyright notice and this permission notice appear in all copies.
+//
+// THE SOFTWARE IS PROVIDED "AS IS" AND THE AUTHOR DISCLAIMS ALL WARRANTIES
+// WITH REGARD TO THIS SOFTWARE INCLUDING ALL IMPLIED WARRANTIES OF
+// MERCHANTABILITY AND FITNESS. IN NO EVENT SHALL THE AUTHOR BE LIABLE FOR ANY
+// SPECIAL, DIRECT, INDIRECT, OR CONSEQUENTIAL DAMAGES OR ANY DAMAGES
+// WHATSOEVER RESULTING FROM LOSS OF USE, DATA OR PROFITS, WHETHER IN AN ACTION
+// OF CONTRACT, NEGLIGENCE OR OTHER TORTIOUS ACTION, ARISING OUT OF OR IN
+// CONNECTION WITH THE USE OR PERFORMANCE OF THIS SOFTWARE.
+
+package subprocess
+
+import (
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+)
+
+// The following structures reflect the JSON of ACVP ML-DSA tests. See
+// https://pages.nist.gov/ACVP/draft-celi-acvp-ml-dsa.html
+
+type mldsaTestVectorSet struct {
+	Groups []mldsaTestGroup `json:"testGroups"`
+	Mode   string           `json:"mode"`
+}
+
+type mldsaTestGroup struct {
+	ID           uint64 `json:"tgId"`
+	Type         string `json:"testType"`
+	ParameterSet string `json:"parameterSet"`
+	// Deterministic is set for sigGen groups where the signing randomness is
+	// all zeros, rather than given by the test.
+	Deterministic bool `json:"deterministic"`
+	// SignatureInterface is "external" (or absent) for the FIPS 204
+	// interface that takes a context string. The internal interface isn't
+	// supported.
+	SignatureInterface string `json:"signatureInterface"`
+	// PreHash is "pure" (or absent) for ML-DSA and "preHash" for
+	// HashML-DSA.
+	PreHash string `json:"preHash"`
+	PKHex   string `json:"pk,omitempty"`
+	Tests   []struct {
+		ID         uint64 `json:"tcId"`
+		SeedHex    string `json:"seed,omitempty"`
+		PKHex      string `json:"pk,omitempty"`
+		SKHex      string `json:"sk,omitempty"`
+		MsgHex     string `json:"message,omitempty"`
+		RandHex    string `json:"rnd,omitempty"`
+		ContextHex string `json:"context,omitempty"`
+		HashAlg    string `json:"hashAlg,omitempty"`
+		SigHex     string `json:"signature,omitempty"`
+	} `json:"tests"`
+}
+
+type mldsaTestGroupResponse struct {
+	ID    uint64              `json:"tgId"`
+	Tests []mldsaTestResponse `json:"tests"`
+}
+
+type mldsaTestResponse struct {
+	ID     uint64 `json:"tcId"`
+	PKHex  string `json:"pk,omitempty"`
+	SKHex  string `json:"sk,omitempty"`
+	SigHex string `json:"signature,omitempty"`
+	Passed *bool  `json:"testPassed,omitempty"` // using pointer so value is not omitted when it is false
+}
+
+// mldsaSizes gives the lengths, in bytes, of the public key, private key and
+// signature of each ML-DSA parameter set. See FIPS 204, section 4.
+var mldsaSizes = map[string]struct{ pk, sk, sig int }{
+	"ML-DSA-44": {1312, 2560, 2420},
+	"ML-DSA-65": {1952, 4032, 3309},
+	"ML-DSA-87": {2592, 4896, 4627},
+}
+
+// mldsaPreHashes is the set of hash functions that HashML-DSA may use.
+var mldsaPreHashes = map[string]bool{
+	"SHA2-224":     true,
+	"SHA2-256":     true,
+	"SHA2-384":     true,
+	"SHA2-512":     true,
+	"SHA2-512/224": true,
+	"SHA2-512/256": true,
+	"SHA3-224":     true,
+	"SHA3-256":     true,
+	"SHA3-384":     true,
+	"SHA3-512":     true,
+	"SHAKE-128":    true,
+	"SHAKE-256":    true,
+}
+
+// mldsa implements an ACVP algorithm by making requests to the subprocess to
+// generate ML-DSA keys and to generate and verify signatures.
+type mldsa struct{}
+
+func (m *mldsa) Process(vectorSet []byte, t Transactable) (any, error) {
+	var parsed mldsaTestVectorSet
+	if err := json.Unmarshal(vectorSet, &parsed); err != nil {
+		return nil, err
+	}
+
+	var ret []mldsaTestGroupResponse
+	for _, group := range parsed.Groups {
+		group := group
+		response := mldsaTestGroupResponse{ID: group.ID}
+
+		sizes, ok := mldsaSizes[group.ParameterSet]
+		if !ok {
+			return nil, fmt.Errorf("invalid parameter set %q in test group %d", group.ParameterSet, group.ID)
+		}
+		if group.SignatureInterface != "" && group.SignatureInterface != "external" {
+			return nil, fmt.Errorf("signature interface %q in test group %d not supported", group.SignatureInterface, group.ID)
+		}
+		var preHash bool
+		switch group.PreHash {
+		case "", "pure":
+		case "preHash":
+			preHash = true
+		default:
+			return nil, fmt.Errorf("unknown preHash value %q in test group %d", group.PreHash, group.ID)
+		}
+
+		for _, test := range group.Tests {
+			test := test
+			t := withTestCase(t, "ML-DSA", group.ID, test.ID)
+			testResp := mldsaTestResponse{ID: test.ID}
+
+			var context []byte
+			var hashAlg string
+			if parsed.Mode == "sigGen" || parsed.Mode == "sigVer" {
+				var err error
+				if context, err = hex.DecodeString(test.ContextHex); err != nil {
+					return nil, fmt.Errorf("failed to decode context in test case %d/%d: %s", group.ID, test.ID, err)
+				}
+				// FIPS 204, algorithms 2 to 5.
+				if len(context) > 255 {
+					return nil, fmt.Errorf("context length %d exceeds the maximum of 255 in test case %d/%d", len(context), group.ID, test.ID)
+				}
+				if preHash {
+					if !mldsaPreHashes[test.HashAlg] {
+						return nil, fmt.Errorf("unsupported pre-hash %q in test case %d/%d", test.HashAlg, group.ID, test.ID)
+					}
+					hashAlg = test.HashAlg
+				}
+			}
+
+			switch parsed.Mode {
+			case "keyGen":
+				if group.Type != "AFT" {
+					return nil, fmt.Errorf("unknown test type %q in keyGen test group %d", group.Type, group.ID)
+				}
+				seed, err := hex.DecodeString(test.SeedHex)
+				if err != nil {
+					return nil, fmt.Errorf("failed to decode seed in test case %d/%d: %s", group.ID, test.ID, err)
+				}
+				if len(seed) != 32 {
+					return nil, fmt.Errorf("test case %d/%d has a %d-byte seed", group.ID, test.ID, len(seed))
+				}
+
+				t.TransactAsync(group.ParameterSet+"/keyGen", 2, [][]byte{seed}, func(result [][]byte) error {
+					if len(result[0]) != sizes.pk || len(result[1]) != sizes.sk {
+						return fmt.Errorf("key generation for test case %d/%d returned a %d-byte pk and %d-byte sk", group.ID, test.ID, len(result[0]), len(result[1]))
+					}
+					testResp.PKHex = hex.EncodeToString(result[0])
+					testResp.SKHex = hex.EncodeToString(result[1])
+					response.Tests = append(response.Tests, testResp)
+					return nil
+				})
+
+			case "sigGen":
+				if group.Type != "AFT" {
+					return nil, fmt.Errorf("unknown test type %q in sigGen test group %d", group.Type, group.ID)
+				}
+				sk, err := hex.DecodeString(test.SKHex)
+				if err != nil {
+					return nil, fmt.Errorf("failed to decode sk in test case %d/%d: %s", group.ID, test.ID, err)
+				}
+				if len(sk) != sizes.sk {
+					return nil, fmt.Errorf("test case %d/%d has a %d-byte sk, but %s uses %d bytes", group.ID, test.ID, len(sk), group.ParameterSet, sizes.sk)
+				}
+				msg, err := hex.DecodeString(test.MsgHex)
+				if err != nil {
+					return nil, fmt.Errorf("failed to decode message in test case %d/%d: %s", group.ID, test.ID, err)
+				}
+
+				// Deterministic signing uses all-zero randomness, which the
+				// module supplies itself.
+				deterministic := []byte{0}
+				var rnd []byte
+				if group.Deterministic {
+					deterministic = []byte{1}
+					if len(test.RandHex) != 0 {
+						return nil, fmt.Errorf("randomness provided in deterministic test case %d/%d", group.ID, test.ID)
+					}
+				} else {
+					if rnd, err = hex.DecodeString(test.RandHex); err != nil {
+						return nil, fmt.Errorf("failed to decode rnd in test case %d/%d: %s", group.ID, test.ID, err)
+					}
+					if len(rnd) != 32 {
+						return nil, fmt.Errorf("test case %d/%d has %d bytes of randomness", group.ID, test.ID, len(rnd))
+					}
+				}
+
+				args := [][]byte{sk, msg, deterministic, rnd, context, []byte(hashAlg)}
+				t.TransactAsync(group.ParameterSet+"/sigGen", 1, args, func(result [][]byte) error {
+					if len(result[0]) != sizes.sig {
+						return fmt.Errorf("signing for test case %d/%d returned a %d-byte signature", group.ID, test.ID, len(result[0]))
+					}
+					testResp.SigHex = hex.EncodeToString(result[0])
+					response.Tests = append(response.Tests, testResp)
+					return nil
+				})
+
+			case "sigVer":
+				if group.Type != "AFT" {
+					return nil, fmt.Errorf("unknown test type %q in sigVer test group %d", group.Type, group.ID)
+				}
+				// Older revisions give a single pk for the whole group.
+				pkHex := test.PKHex
+				if len(pkHex) == 0 {
+					pkHex = group.PKHex
+				}
+				pk, err := hex.DecodeString(pkHex)
+				if err != nil {
+					return nil, fmt.Errorf("failed to decode pk in test case %d/%d: %s", group.ID, test.ID, err)
+				}
+				if len(pk) != sizes.pk {
+					return nil, fmt.Errorf("test case %d/%d has a %d-byte pk, but %s uses %d bytes", group.ID, test.ID, len(pk), group.ParameterSet, sizes.pk)
+				}
+				msg, err := hex.DecodeString(test.MsgHex)
+				if err != nil {
+					return nil, fmt.Errorf("failed to decode message in test case %d/%d: %s", group.ID, test.ID, err)
+				}
+				sig, err := hex.DecodeString(test.SigHex)
+				if err != nil {
+					return nil, fmt.Errorf("failed to decode signature in test case %d/%d: %s", group.ID, test.ID, err)
+				}
+
+				args := [][]byte{pk, msg, sig, context, []byte(hashAlg)}
+				t.TransactAsync(group.ParameterSet+"/sigVer", 1, args, func(result [][]byte) error {
+					if len(result[0]) != 1 || (result[0][0]&0xfe) != 0 {
+						return fmt.Errorf("signature verification returned unexpected result: %q", result[0])
+					}
+					passed := result[0][0] == 1
+					testResp.Passed = &passed
+					response.Tests = append(response.Tests, testResp)
+					return nil
+				})
+
+			default:
+				return nil, fmt.Errorf("invalid mode %q in ML-DSA vector set", parsed.Mode)
+			}
+		}
+
+		t.Barrier(func() {
+			ret = append(ret, response)
+		})
+	}
+
+	if err := t.Flush(); err != nil {
+		return nil, err
+	}
+
+	return ret, nil
+}
@@ -0,0 +1,125 @@
+// Copyright (c) 2026, Google Inc.
+//
+// Permission to use, copy, modify, and/or distribute this software for any
+// purpose with or without fee is hereby granted, provided that the above
+// copyright notice and this permission notice appear in all copies.
+//
+// THE SOFTWARE IS PROVIDED "AS IS" AND THE AUTHOR DISCLAIMS ALL WARRANTIES
+// WITH REGARD TO THIS SOFTWARE INCLUDING ALL IMPLIED WARRANTIES OF
+// MERCHANTABILITY AND FITNESS. IN NO EVENT SHALL THE AUTHOR BE LIABLE FOR ANY
+// SPECIAL, DIRECT, INDIRECT, OR CONSEQUENTIAL DAMAGES OR ANY DAMAGES
+// WHATSOEVER RESULTING FROM LOSS OF USE, DATA OR PROFITS, WHETHER IN AN ACTION
+// OF CONTRACT, NEGLIGENCE OR OTHER TORTIOUS ACTION, ARISING OUT OF OR IN
+// CONNECTION WITH THE USE OR PERFORMANCE OF THIS SOFTWARE.
+
+package subprocess
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+	"testing"
+)
+
+// mldsaResponder answers ML-DSA-44 commands with values of the right length.
+// Signatures are filled with the first byte of the randomness, or 0xde when
+// signing is deterministic, and verify when their first byte matches the
+// public key's.
+func mldsaResponder(cmd string, args [][]byte) ([][]byte, error) {
+	switch cmd {
+	case "ML-DSA-44/keyGen":
+		return [][]byte{bytes.Repeat(args[0][:1], 1312), bytes.Repeat(args[0][:1], 2560)}, nil
+	case "ML-DSA-44/sigGen":
+		if args[2][0] == 1 {
+			return [][]byte{bytes.Repeat([]byte{0xde}, 2420)}, nil
+		}
+		return [][]byte{bytes.Repeat(args[3][:1], 2420)}, nil
+	case "ML-DSA-44/sigVer":
+		return [][]byte{{boolToByte(args[2][0] == args[0][0])}}, nil
+	}
+	return nil, fmt.Errorf("unexpected command %q", cmd)
+}
+
+func TestMLDSAKeyGen(t *testing.T) {
+	vectorSet := `{"algorithm": "ML-DSA", "mode": "keyGen", "testGroups": [{
+		"tgId": 1, "testType": "AFT", "parameterSet": "ML-DSA-44",
+		"tests": [{"tcId": 1, "seed": "` + hexRepeat(0x05, 32) + `"}]
+	}]}`
+
+	result, err := new(mldsa).Process([]byte(vectorSet), &fakeTransactable{respond: mldsaResponder})
+	if err != nil {
+		t.Fatal(err)
+	}
+	test := result.([]mldsaTestGroupResponse)[0].Tests[0]
+	if test.PKHex != hexRepeat(0x05, 1312) || test.SKHex != hexRepeat(0x05, 2560) {
+		t.Errorf("unexpected response: %+v", test)
+	}
+
+	for _, parameterSet := range []string{"ML-DSA-65", "ML-DSA-33"} {
+		badSet := strings.Replace(vectorSet, "ML-DSA-44", parameterSet, 1)
+		if _, err := new(mldsa).Process([]byte(badSet), &fakeTransactable{respond: mldsaResponder}); err == nil {
+			t.Errorf("%s group with ML-DSA-44 results was accepted", parameterSet)
+		}
+	}
+}
+
+func TestMLDSASigGen(t *testing.T) {
+	vectorSet := `{"algorithm": "ML-DSA", "mode": "sigGen", "testGroups": [
+		{"tgId": 1, "testType": "AFT", "parameterSet": "ML-DSA-44", "deterministic": true,
+		 "signatureInterface": "external", "preHash": "pure",
+		 "tests": [{"tcId": 1, "sk": "` + hexRepeat(0x5e, 2560) + `", "message": "aa", "context": "c0"}]},
+		{"tgId": 2, "testType": "AFT", "parameterSet": "ML-DSA-44", "deterministic": false,
+		 "signatureInterface": "external", "preHash": "preHash",
+		 "tests": [{"tcId": 2, "sk": "` + hexRepeat(0x5e, 2560) + `", "message": "aa", "rnd": "` + hexRepeat(0x42, 32) + `",
+		            "context": "", "hashAlg": "SHA2-256"}]}
+	]}`
+
+	m := &fakeTransactable{respond: mldsaResponder}
+	result, err := new(mldsa).Process([]byte(vectorSet), m)
+	if err != nil {
+		t.Fatal(err)
+	}
+	groups := result.([]mldsaTestGroupResponse)
+	if sig := groups[0].Tests[0].SigHex; sig != hexRepeat(0xde, 2420) {
+		t.Errorf("deterministic signature is %s...", sig[:8])
+	}
+	if sig := groups[1].Tests[0].SigHex; sig != hexRepeat(0x42, 2420) {
+		t.Errorf("hedged signature is %s...", sig[:8])
+	}
+
+	if args := m.calls[0].args; len(args[3]) != 0 || !bytes.Equal(args[4], []byte{0xc0}) || len(args[5]) != 0 {
+		t.Errorf("deterministic, pure signing passed rnd %x, context %x, hash %q", args[3], args[4], args[5])
+	}
+	if args := m.calls[1].args; args[2][0] != 0 || string(args[5]) != "SHA2-256" {
+		t.Errorf("hedged HashML-DSA signing passed flag %x, hash %q", args[2], args[5])
+	}
+
+	longContext := strings.Replace(vectorSet, `"context": "c0"`, `"context": "`+hexRepeat(0xc0, 256)+`"`, 1)
+	if _, err := new(mldsa).Process([]byte(longContext), &fakeTransactable{respond: mldsaResponder}); err == nil {
+		t.Error("256-byte context was accepted")
+	}
+	badHash := strings.Replace(vectorSet, "SHA2-256", "SHA-1", 1)
+	if _, err := new(mldsa).Process([]byte(badHash), &fakeTransactable{respond: mldsaResponder}); err == nil {
+		t.Error("SHA-1 pre-hash was accepted")
+	}
+}
+
+func TestMLDSASigVer(t *testing.T) {
+	// The second signature has been tampered with.
+	vectorSet := `{"algorithm": "ML-DSA", "mode": "sigVer", "testGroups": [{
+		"tgId": 1, "testType": "AFT", "parameterSet": "ML-DSA-44", "preHash": "pure",
+		"tests": [
+			{"tcId": 1, "pk": "` + hexRepeat(0x11, 1312) + `", "message": "aa", "signature": "` + hexRepeat(0x11, 2420) + `"},
+			{"tcId": 2, "pk": "` + hexRepeat(0x11, 1312) + `", "message": "aa", "signature": "` + hexRepeat(0x12, 2420) + `"}
+		]
+	}]}`
+
+	result, err := new(mldsa).Process([]byte(vectorSet), &fakeTransactable{respond: mldsaResponder})
+	if err != nil {
+		t.Fatal(err)
+	}
+	tests := result.([]mldsaTestGroupResponse)[0].Tests
+	if len(tests) != 2 || tests[0].Passed == nil || !*tests[0].Passed || tests[1].Passed == nil || *tests[1].Passed {
+		t.Errorf("unexpected response: %+v", tests)
+	}
+}
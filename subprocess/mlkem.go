@@ -4,7 +4,6 @@ import (
 	"encoding/hex"
 	"encoding/json"
 	"fmt"
-	"strings"
 )
 
 // Common top-level structure to parse mode
@@ -64,6 +63,7 @@ type mlkemEncapDecapTestGroup struct {
 
 type mlkemEncapDecapTest struct {
 	ID uint64 `json:"tcId"`
+	DK string `json:"dk,omitempty"`
 	EK string `json:"ek,omitempty"`
 	M  string `json:"m,omitempty"`
 	C  string `json:"c,omitempty"`
@@ -80,6 +80,22 @@ type mlkemEncapDecapTestResponse struct {
 	K  string `json:"k,omitempty"`
 }
 
+// mlkemSizes gives the lengths, in bytes, of the encapsulation key,
+// decapsulation key and ciphertext of each ML-KEM parameter set. See FIPS 203,
+// section 8.
+var mlkemSizes = map[string]struct{ ek, dk, c int }{
+	"ML-KEM-512":  {800, 1632, 768},
+	"ML-KEM-768":  {1184, 2400, 1088},
+	"ML-KEM-1024": {1568, 3168, 1568},
+}
+
+// mlkemSharedSecretLen is the length of an ML-KEM shared secret.
+const mlkemSharedSecretLen = 32
+
+// mlkemSeedLen is the length of each of the d and z seeds of ML-KEM key
+// generation. See FIPS 203, algorithm 19.
+const mlkemSeedLen = 32
+
 type mlkem struct{}
 
 func (m *mlkem) Process(vectorSet []byte, t Transactable) (any, error) {
@@ -111,13 +127,14 @@ func (m *mlkem) processKeyGen(vectorSet []byte, t Transactable) (any, error) {
 			ID: group.ID,
 		}
 
-		if !strings.HasPrefix(group.ParameterSet, "ML-KEM-") {
+		sizes, ok := mlkemSizes[group.ParameterSet]
+		if !ok {
 			return nil, fmt.Errorf("invalid parameter set: %s", group.ParameterSet)
 		}
 		cmdName := group.ParameterSet + "/keyGen"
 
 		for _, test := range group.Tests {
-			// Concatenate d and z to form the seed
+			t := withTestCase(t, "ML-KEM", group.ID, test.ID)
 			dBytes, err := hex.DecodeString(test.D)
 			if err != nil {
 				return nil, fmt.Errorf("failed to decode d in test case %d/%d: %s",
@@ -129,6 +146,15 @@ func (m *mlkem) processKeyGen(vectorSet []byte, t Transactable) (any, error) {
 					group.ID, test.ID, err)
 			}
 
+			if len(dBytes) != mlkemSeedLen || len(zBytes) != mlkemSeedLen {
+				return nil, fmt.Errorf("test case %d/%d has a %d-byte d and %d-byte z, but both should be %d bytes",
+					group.ID, test.ID, len(dBytes), len(zBytes), mlkemSeedLen)
+			}
+
+			// Key generation is deterministic given both seeds. They are
+			// passed as a single 64-byte seed, d followed by z, which is how
+			// modules generally store ML-KEM private keys, and the module
+			// must return exactly the keys of FIPS 203, algorithm 16.
 			seed := make([]byte, len(dBytes)+len(zBytes))
 			copy(seed, dBytes)
 			copy(seed[len(dBytes):], zBytes)
@@ -139,6 +165,11 @@ func (m *mlkem) processKeyGen(vectorSet []byte, t Transactable) (any, error) {
 					group.ID, test.ID, err)
 			}
 
+			if len(result[0]) != sizes.ek || len(result[1]) != sizes.dk {
+				return nil, fmt.Errorf("key generation for test case %d/%d returned a %d-byte ek and %d-byte dk, but %s uses %d and %d bytes",
+					group.ID, test.ID, len(result[0]), len(result[1]), group.ParameterSet, sizes.ek, sizes.dk)
+			}
+
 			response.Tests = append(response.Tests, mlkemKeyGenTestResponse{
 				ID: test.ID,
 				EK: hex.EncodeToString(result[0]),
@@ -165,7 +196,8 @@ func (m *mlkem) processEncapDecap(vectorSet []byte, t Transactable) (any, error)
 			ID: group.ID,
 		}
 
-		if !strings.HasPrefix(group.ParameterSet, "ML-KEM-") {
+		sizes, ok := mlkemSizes[group.ParameterSet]
+		if !ok {
 			return nil, fmt.Errorf("invalid parameter set: %s", group.ParameterSet)
 		}
 
@@ -173,6 +205,7 @@ func (m *mlkem) processEncapDecap(vectorSet []byte, t Transactable) (any, error)
 		case "encapsulation":
 			cmdName := group.ParameterSet + "/encap"
 			for _, test := range group.Tests {
+				t := withTestCase(t, "ML-KEM", group.ID, test.ID)
 				ek, err := hex.DecodeString(test.EK)
 				if err != nil {
 					return nil, fmt.Errorf("failed to decode ek in test case %d/%d: %s",
@@ -191,6 +224,11 @@ func (m *mlkem) processEncapDecap(vectorSet []byte, t Transactable) (any, error)
 						group.ID, test.ID, err)
 				}
 
+				if len(result[0]) != sizes.c || len(result[1]) != mlkemSharedSecretLen {
+					return nil, fmt.Errorf("encapsulation for test case %d/%d returned a %d-byte ciphertext and %d-byte shared secret",
+						group.ID, test.ID, len(result[0]), len(result[1]))
+				}
+
 				response.Tests = append(response.Tests, mlkemEncapDecapTestResponse{
 					ID: test.ID,
 					C:  hex.EncodeToString(result[0]),
@@ -200,24 +238,48 @@ func (m *mlkem) processEncapDecap(vectorSet []byte, t Transactable) (any, error)
 
 		case "decapsulation":
 			cmdName := group.ParameterSet + "/decap"
-			dk, err := hex.DecodeString(group.DK)
+			groupDK, err := hex.DecodeString(group.DK)
 			if err != nil {
 				return nil, fmt.Errorf("failed to decode dk in group %d: %s",
 					group.ID, err)
 			}
 
 			for _, test := range group.Tests {
+				t := withTestCase(t, "ML-KEM", group.ID, test.ID)
+				// Older revisions give a single dk for the whole group, newer
+				// ones give one per test.
+				dk := groupDK
+				if len(test.DK) != 0 {
+					if dk, err = hex.DecodeString(test.DK); err != nil {
+						return nil, fmt.Errorf("failed to decode dk in test case %d/%d: %s",
+							group.ID, test.ID, err)
+					}
+				}
+				if len(dk) != sizes.dk {
+					return nil, fmt.Errorf("test case %d/%d has a %d-byte dk, but %s uses %d bytes",
+						group.ID, test.ID, len(dk), group.ParameterSet, sizes.dk)
+				}
+
 				c, err := hex.DecodeString(test.C)
 				if err != nil {
 					return nil, fmt.Errorf("failed to decode c in test case %d/%d: %s",
 						group.ID, test.ID, err)
 				}
 
+				// VAL tests include modified ciphertexts. With implicit
+				// rejection (FIPS 203, algorithm 18), decapsulating those
+				// must still succeed and give a pseudorandom shared secret,
+				// which the ACVP server checks. So a failure here is always
+				// an error, never a test result.
 				result, err := t.Transact(cmdName, 1, dk, c)
 				if err != nil {
 					return nil, fmt.Errorf("decapsulation failed for test case %d/%d: %s",
 						group.ID, test.ID, err)
 				}
+				if len(result[0]) != mlkemSharedSecretLen {
+					return nil, fmt.Errorf("decapsulation for test case %d/%d returned a %d-byte shared secret",
+						group.ID, test.ID, len(result[0]))
+				}
 
 				response.Tests = append(response.Tests, mlkemEncapDecapTestResponse{
 					ID: test.ID,
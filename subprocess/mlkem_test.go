@@ -0,0 +1,194 @@
+// Copyright (c) 2026, Google Inc.
+//
+// Permission to use, copy, modify, and/or distribute this software for any
+// purpose with or without fee is hereby granted, provided that the above
+// copyright notice and this permission notice appear in all copies.
+//
+// THE SOFTWARE IS PROVIDED "AS IS" AND THE AUTHOR DISCLAIMS ALL WARRANTIES
+// WITH REGARD TO THIS SOFTWARE INCLUDING ALL IMPLIED WARRANTIES OF
+// MERCHANTABILITY AND FITNESS. IN NO EVENT SHALL THE AUTHOR BE LIABLE FOR ANY
+// SPECIAL, DIRECT, INDIRECT, OR CONSEQUENTIAL DAMAGES OR ANY DAMAGES
+// WHATSOEVER RESULTING FROM LOSS OF USE, DATA OR PROFITS, WHETHER IN AN ACTION
+// OF CONTRACT, NEGLIGENCE OR OTHER TORTIOUS ACTION, ARISING OUT OF OR IN
+// CONNECTION WITH THE USE OR PERFORMANCE OF THIS SOFTWARE.
+
+package subprocess
+
+import (
+	"bytes"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+
+	"golang.org/x/crypto/sha3"
+)
+
+// mlkemResponder answers ML-KEM-512 commands with values of the right length.
+// Decapsulating a ciphertext that starts with 0xff models implicit rejection
+// by returning a different shared secret.
+func mlkemResponder(cmd string, args [][]byte) ([][]byte, error) {
+	switch cmd {
+	case "ML-KEM-512/keyGen":
+		return [][]byte{bytes.Repeat(args[0][:1], 800), bytes.Repeat(args[0][32:33], 1632)}, nil
+	case "ML-KEM-512/encap":
+		return [][]byte{bytes.Repeat(args[1][:1], 768), bytes.Repeat([]byte{0x4b}, 32)}, nil
+	case "ML-KEM-512/decap":
+		if args[1][0] == 0xff {
+			return [][]byte{bytes.Repeat([]byte{0x52}, 32)}, nil
+		}
+		return [][]byte{bytes.Repeat([]byte{0x4b}, 32)}, nil
+	}
+	return nil, fmt.Errorf("unexpected command %q", cmd)
+}
+
+func hexRepeat(b byte, n int) string {
+	return hex.EncodeToString(bytes.Repeat([]byte{b}, n))
+}
+
+func TestMLKEMKeyGen(t *testing.T) {
+	vectorSet := `{"algorithm": "ML-KEM", "mode": "keyGen", "testGroups": [{
+		"tgId": 1, "testType": "AFT", "parameterSet": "ML-KEM-512",
+		"tests": [{"tcId": 1, "d": "` + hexRepeat(0x01, 32) + `", "z": "` + hexRepeat(0x02, 32) + `"}]
+	}]}`
+
+	m := &fakeTransactable{respond: mlkemResponder}
+	result, err := new(mlkem).Process([]byte(vectorSet), m)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if seed := m.calls[0].args[0]; len(seed) != 64 || seed[0] != 0x01 || seed[32] != 0x02 {
+		t.Errorf("seed passed as %x", seed)
+	}
+	test := result.([]mlkemKeyGenTestGroupResponse)[0].Tests[0]
+	if test.EK != hexRepeat(0x01, 800) || test.DK != hexRepeat(0x02, 1632) {
+		t.Errorf("unexpected response: %+v", test)
+	}
+
+	for _, parameterSet := range []string{"ML-KEM-768", "ML-KEM-256"} {
+		badSet := strings.Replace(vectorSet, "ML-KEM-512", parameterSet, 1)
+		if _, err := new(mlkem).Process([]byte(badSet), &fakeTransactable{respond: mlkemResponder}); err == nil {
+			t.Errorf("%s group with ML-KEM-512 results was accepted", parameterSet)
+		}
+	}
+}
+
+// An ML-KEM-768 key pair from the seeds d = 00...1f and z = 20...3f,
+// computed with the FIPS 203 implementation of the Go standard library. The
+// decapsulation key is mlkem768KATDKPKE, then the encapsulation key, its
+// SHA3-256 hash and z.
+const (
+	mlkem768KATEK    = "298aa10d423c8dda069d02bc59e6cdf03a096b8b3da4cab9b80ca4a14907672ccef1ec4faf234a0bc5b7e9d473f2b3133b3b26a1d175cb67a7805919699c02f76531b99c5f89180704bb4ca4535c5b8972679c660a07c5e514b87009c862eb8f5157695efb3fc40a9def6b81c1cc02a249ae4f094ad0d9bd3485c1c1c68080520a7c8c632032cee738154e5c5176c07da56024776a430fe76eacf665a3f7b832102215bc82f10939c8355704336a8fac1d81e4bb0485aa5d7c74d6b59bbe5c5e972a0d8bac411b55b5d5557cd680a1a8f71b4eb86bc48c9a0509731a54bd9d7290b27963e4372dc9b199cfdcac0b01acd28a62395112e4c43648d622c48c8234d01440e8cc376c927f23a5afc9ac0474c662274e424525c8552ece3b3fe26516de901bc7d515bde89558e626c95c80b93342f8010004f39e6c6c94871c5e344cab3966c835f9a96a59afd31c40286b38b1c1a78470bab947518934453ce86736a919f1f5a6d510a86f5454fc3980cb5c765bd2bd5f7b36b1410d6635c8ceb47c4dda0d76a28eac939c71c3024804866c71626658442163c2c22117e50acefce6378a985652302a4ef0c2ce0cc716b7796e2b6b2e3777dfa1ac3da259a31b5a9b530f8cb638a81a62ac301849abaf95a7301bda30068909bfdb7e67dbccbb38a5551a25b1a3a0f685748ad5753d8880f0016c627486166384c5571fe2365900364d038311e2d875db366686932b5ec602430a369e87a6ef5c338786657825bd4c057aceb923eb0935e6905e63b4ced7f80857a773dd64b150d26612ea9ac12052db2017bf1843ccb4b3281b690dc728adfa85c00281b8e3c09287335f856b4fc2892f69a2f57921ada01914c40988662d57769662a786351b9b66493dab79594d986de2100d65ba0ff4ea58b81538d24a4435a258fac25404aa7f41f658b1385065e158dcb60115732720f40459aaac15e406953a90ac52997d1ccd070060efc65db9e653354467fad56ec713c86e7540c423acf2669f52fa6f4ac6888d871ef3e847c029a8aafbb92e17b24aa079b1f419ba6175b442afb11909d4a56b70a0335b28739218aa7c9348e2c3c2f3eb3d15a41e6417c0dd94bfeb21419b311a7bb13a180bbe833218a9a6b17447cc85f225859587a73077049acbcfd44d0f025438e15d1538270d586e1bf83192a9459cf63c0e972f85297679831ecf121509851cb8340f6f107b0fa1a0efd1b36a8189bc085c4f5cb784e553f41b918f80397ce1956f785bee377ca9aa8be6998ada30c26b7c3d8c6b55254cc96203b20c42aee0ac4e1ebb408e49a9e3f879d0ab0785eb7025425d1305a2299c015e120d163b0e19494ce57253d0246d182745cb8197ab7438b3c1bb7972bec5a306eba3567855c014699fef65ae54c770a0d85c18400cf642aedc660777ba4b138502bd5a7812f621f84a48296b98dd4322b6f15828b8a8f0e00a8ba44a53c3a8b143571b0740abd567daf1cde9c79c204b6d5e259d1766a31bbbcb4e6a05cf4502176b301c1c2f41247750157bcec85e809b30a4d60d7747cdd0f5b99aa8c826987517793aaa8080a0b124a8558df72bbe37b75f4edbb6be8216d6c633fb2b2280e25113d8695e43481c3eeb397eb192505229b67a201ea893c3e2cb32da8bc342fa4dea0578"
+	mlkem768KATDKPKE = "27d2a77f33756f61208ef113abe82595873d4abc730e5b5d679529bf6a4ceb6383427231a8612f41550515acba52e48ead8b942833bbe6865d13d14a79d2c5c3e07f0a056d8de7aadfcaba058c493c80b37cab8c562753bb3ba6b6ec8297f885eaa7540d530015a84406e55b1366b577e236ce58a26d8a1eb5a44d542323c2167d9bf4a47f985699ca05bae43b8dec617f02380a3890afd4b8c7ec7ede26553a025f3ce5bc5d7a62130304235cb1ad4836b566b5b863bd9bdb45a2844a7047b6c8d383e448525e040b4dc8a2b48c6c37c96d62d43f3fd88e2881c40a205c9e248f652b592781a779f86880f2a147b67863f391cc1a5a908c0095e07212291e2ef8a36eb9a9c0c6073225b34703a4af049382c47573da68fde9245ad444e31b1fbdb521f1f61f37bc0cef292067e670d28a1ffd904f6f1190a996918a13037a6cabf3c373bf8296cd37ab33ba7746809cc3f8ade1b3639bd57bfcc69650aaaf1de198fc4c0463299e52c461780cc428fc5d04a5c51850cba6c2a5274340675793dda09be44c29e6395c65f85d2a0a7c6df411e6911b1f2cb6c351cd2e875f51b638be776097e93e2f2b2f83da0beef4aa85ba9e763ab64502a0ca5222e9eab5b3b7088ed52060e8c8269b943a71ab0ae1c5b1b687d2e019cf8036bcf9bf6e7bac3aaa36e41660faa4540f2648cd93a189ec5c2dea70bacaaa4ffc906f90810ea1b67bf24f2c78cf6ba881aaea61c0652bff95b1bae4426d1773b9cc2ca82c21e38c636e3b1c523244986b0be8a83f5dd5cf2d54762fb3c5ebf59b8e885302b1ce47033edf760f4e029be40b6d566b19dd758acd5c7412878131244f90172c53f26663c21d905301d48baf91c917cc7779e9d8802cc10d89a3705099a2ad3a3a8896743c1144698093be257dacb66dc785228b912c8d965d14aa28342c3ac4a93fefa532b20945ddc1020139c14d638b908c4ddde9a0645b95b2e4414d40bb79f04413830f15a873c28bb7059c2741002015f20408f058e715b0bf995b5380b7dd325a056ab97e659a2be0cdf6c33731c683a634b771e8c92a139aee4bb0e49c7077321d42fc199f7c1f298ca625d223a5c263a03cc48159b7812665b78637e4e18720b2c29a6b99f42766a4cbc4dc508ba94ba83b89c3a5c78f8bb26bbd9b79beb8c8182490f5793ee5b96013b74b7e169e29d162f1315464ea7d72436d89b755161192c81cc2dd1c8b8bba795ef426ee1cc01c37aaa37b2cff8b0a378b47cbd0b4d49398cfc2712959699fa0bd8cd84666acc61f541b84fa96b9c854e4e75e9144addb44b8566a57dfbb545ce423c03346f2b2c1a91780d152a8de1a4d4c9cacde7392c996888cc2399c02c38b3353adf8acab283924da00a05b76e738c72c930d6cba09ae168990faa1fef2226e780861d416eff402f4f759fc648ab1f97100109087f96e4b148d2cb31e4805314ea0cd95fb023eac0d989474ba4201d7b41d26f5394b217eea5b34b71a8b37931c0e594271e0b7c733257240233e7ba735603e425a87dee77079e37cb28a21764594ce5350d8da2b62a07174943032ec89c98809c73b6423d30c1d283a766a64d89703c3d629b497828d48320c346210797a"
+)
+
+func TestMLKEMKeyGenKAT(t *testing.T) {
+	d, z := make([]byte, 32), make([]byte, 32)
+	for i := range d {
+		d[i] = byte(i)
+		z[i] = byte(32 + i)
+	}
+	ek := mustDecodeHex(t, mlkem768KATEK)
+	ekHash := sha3.Sum256(ek)
+	dk := append(append(append(mustDecodeHex(t, mlkem768KATDKPKE), ek...), ekHash[:]...), z...)
+
+	vectorSet := `{"algorithm": "ML-KEM", "mode": "keyGen", "testGroups": [{
+		"tgId": 1, "testType": "AFT", "parameterSet": "ML-KEM-768",
+		"tests": [{"tcId": 1, "d": "` + hex.EncodeToString(d) + `", "z": "` + hex.EncodeToString(z) + `"}]
+	}]}`
+
+	// The module only knows the key pair for the seeds in order.
+	m := &fakeTransactable{respond: func(cmd string, args [][]byte) ([][]byte, error) {
+		if cmd != "ML-KEM-768/keyGen" || len(args) != 1 || !bytes.Equal(args[0], append(d, z...)) {
+			return nil, fmt.Errorf("unexpected command %q with arguments %x", cmd, args)
+		}
+		return [][]byte{ek, dk}, nil
+	}}
+	result, err := new(mlkem).Process([]byte(vectorSet), m)
+	if err != nil {
+		t.Fatal(err)
+	}
+	test := result.([]mlkemKeyGenTestGroupResponse)[0].Tests[0]
+	if test.EK != mlkem768KATEK || test.DK != hex.EncodeToString(dk) {
+		t.Errorf("got ek %s and dk %s, want the known key pair", test.EK, test.DK)
+	}
+
+	dHex, zHex := hex.EncodeToString(d), hex.EncodeToString(z)
+	for _, bad := range []struct{ from, to string }{
+		{dHex, dHex[2:]},
+		{zHex, zHex + "00"},
+	} {
+		badSeed := strings.Replace(vectorSet, bad.from, bad.to, 1)
+		m := &fakeTransactable{respond: mlkemResponder}
+		if _, err := new(mlkem).Process([]byte(badSeed), m); err == nil || len(m.calls) != 0 {
+			t.Errorf("seed %s was accepted", bad.to)
+		}
+	}
+}
+
+func TestMLKEMEncap(t *testing.T) {
+	vectorSet := `{"algorithm": "ML-KEM", "mode": "encapDecap", "testGroups": [{
+		"tgId": 1, "testType": "AFT", "parameterSet": "ML-KEM-512", "function": "encapsulation",
+		"tests": [{"tcId": 1, "ek": "` + hexRepeat(0x0e, 800) + `", "m": "` + hexRepeat(0x0c, 32) + `"}]
+	}]}`
+
+	m := &fakeTransactable{respond: mlkemResponder}
+	result, err := new(mlkem).Process([]byte(vectorSet), m)
+	if err != nil {
+		t.Fatal(err)
+	}
+	test := result.([]mlkemEncapDecapTestGroupResponse)[0].Tests[0]
+	if test.C != hexRepeat(0x0c, 768) || test.K != hexRepeat(0x4b, 32) {
+		t.Errorf("unexpected response: %+v", test)
+	}
+}
+
+func TestMLKEMDecap(t *testing.T) {
+	// The second test has a modified ciphertext, as in VAL tests, and the
+	// module must still return a shared secret.
+	vectorSet := `{"algorithm": "ML-KEM", "mode": "encapDecap", "testGroups": [{
+		"tgId": 1, "testType": "VAL", "parameterSet": "ML-KEM-512", "function": "decapsulation",
+		"dk": "` + hexRepeat(0xdd, 1632) + `",
+		"tests": [
+			{"tcId": 1, "c": "` + hexRepeat(0x0c, 768) + `"},
+			{"tcId": 2, "c": "` + hexRepeat(0xff, 768) + `"},
+			{"tcId": 3, "dk": "` + hexRepeat(0xee, 1632) + `", "c": "` + hexRepeat(0x0c, 768) + `"}
+		]
+	}]}`
+
+	m := &fakeTransactable{respond: mlkemResponder}
+	result, err := new(mlkem).Process([]byte(vectorSet), m)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tests := result.([]mlkemEncapDecapTestGroupResponse)[0].Tests
+	if len(tests) != 3 || tests[0].K != hexRepeat(0x4b, 32) || tests[1].K != hexRepeat(0x52, 32) || len(tests[1].C) != 0 {
+		t.Errorf("unexpected response: %+v", tests)
+	}
+	if dk := m.calls[0].args[0]; dk[0] != 0xdd {
+		t.Errorf("group dk not used: %x", dk[:1])
+	}
+	if dk := m.calls[2].args[0]; dk[0] != 0xee {
+		t.Errorf("test dk not used: %x", dk[:1])
+	}
+
+	// A module that fails rather than implicitly rejecting is an error.
+	failing := func(cmd string, args [][]byte) ([][]byte, error) {
+		if args[1][0] == 0xff {
+			return nil, errors.New("invalid ciphertext")
+		}
+		return mlkemResponder(cmd, args)
+	}
+	if _, err := new(mlkem).Process([]byte(vectorSet), &fakeTransactable{respond: failing}); err == nil {
+		t.Error("failed decapsulation was accepted")
+	}
+
+	// As is one that returns a short shared secret.
+	short := func(cmd string, args [][]byte) ([][]byte, error) {
+		return [][]byte{make([]byte, 16)}, nil
+	}
+	if _, err := new(mlkem).Process([]byte(vectorSet), &fakeTransactable{respond: short}); err == nil {
+		t.Error("short shared secret was accepted")
+	}
+}
@@ -0,0 +1,310 @@
+// Copyright (c) 2026, Google Inc.
+//
+// Permission to use, copy, modify, and/or distribute this software for any
+// purpose with or without fee is hereby granted, provided that the above
+// copyright notice and this permission notice appear in all copies.
+//
+// THE SOFTWARE IS PROVIDED "AS IS" AND THE AUTHOR DISCLAIMS ALL WARRANTIES
+// WITH REGARD TO THIS SOFTWARE INCLUDING ALL IMPLIED WARRANTIES OF
+// MERCHANTABILITY AND FITNESS. IN NO EVENT SHALL THE AUTHOR BE LIABLE FOR ANY
+// SPECIAL, DIRECT, INDIRECT, OR CONSEQUENTIAL DAMAGES OR ANY DAMAGES
+// WHATSOEVER RESULTING FROM LOSS OF USE, DATA OR PROFITS, WHETHER IN AN ACTION
+// OF CONTRACT, NEGLIGENCE OR OTHER TORTIOUS ACTION, ARISING OUT OF OR IN
+// CONNECTION WITH THE USE OR PERFORMANCE OF THIS SOFTWARE.
+
+package subprocess
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"sort"
+	"sync"
+)
+
+// Pool is a middle layer that spreads the test groups of each vector set
+// over several Subprocesses, each running its own copy of the module, so that
+// independent groups are processed in parallel.
+type Pool struct {
+	workers []*Subprocess
+}
+
+// NewPool returns a Pool that uses the given Subprocesses. It takes ownership
+// of them and closes them when it is closed.
+func NewPool(workers []*Subprocess) (*Pool, error) {
+	if len(workers) == 0 {
+		return nil, errors.New("a pool needs at least one subprocess")
+	}
+	return &Pool{workers}, nil
+}
+
+// Close closes all the Subprocesses in the pool.
+func (p *Pool) Close() {
+	for _, w := range p.workers {
+		w.Close()
+	}
+}
+
+// Trace causes every Subprocess in the pool to trace its commands to w. See
+// Subprocess.Trace. Records from different Subprocesses are interleaved.
+func (p *Pool) Trace(w io.Writer, includeData bool) {
+	locked := NewLockedWriter(w)
+	for _, worker := range p.workers {
+		worker.Trace(locked, includeData)
+	}
+}
+
+// NewLockedWriter returns a Writer that serialises writes to w from several
+// goroutines, so that, for example, several Subprocesses can trace to the same
+// file.
+func NewLockedWriter(w io.Writer) io.Writer {
+	return &lockedWriter{w: w}
+}
+
+// lockedWriter serialises writes from several goroutines.
+type lockedWriter struct {
+	mu sync.Mutex
+	w  io.Writer
+}
+
+func (l *lockedWriter) Write(b []byte) (int, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.w.Write(b)
+}
+
+// Config returns the configuration of the first Subprocess, after checking
+// that every Subprocess in the pool reports the same configuration.
+func (p *Pool) Config() ([]byte, error) {
+	var ret []byte
+	for i, w := range p.workers {
+		config, err := w.Config()
+		if err != nil {
+			return nil, err
+		}
+		if i == 0 {
+			ret = config
+		} else if string(config) != string(ret) {
+			return nil, fmt.Errorf("subprocess %d reports a different configuration from the first", i)
+		}
+	}
+	return ret, nil
+}
+
+// RegisterHandler registers h with every Subprocess in the pool. See
+// Subprocess.RegisterHandler.
+func (p *Pool) RegisterHandler(name string, h Primitive) error {
+	for _, w := range p.workers {
+		if err := w.RegisterHandler(name, h); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// EnablePartialResults enables partial results on each worker. If several
+// workers fail, the error is that of the first, but the groups answered by
+// all of them are returned. See Subprocess.EnablePartialResults.
+func (p *Pool) EnablePartialResults() {
+	for _, w := range p.workers {
+		w.EnablePartialResults()
+	}
+}
+
+// EnableSortedResults enables sorted results on each worker. See
+// Subprocess.EnableSortedResults.
+func (p *Pool) EnableSortedResults() {
+	for _, w := range p.workers {
+		w.EnableSortedResults()
+	}
+}
+
+// SetMaxInFlight sets the limit on unanswered commands of every Subprocess
+// in the pool, so the limit applies to each copy of the module separately. See
+// Subprocess.SetMaxInFlight.
+func (p *Pool) SetMaxInFlight(n int) {
+	for _, w := range p.workers {
+		w.SetMaxInFlight(n)
+	}
+}
+
+// SetProgress sets the progress callback of every Subprocess in the pool. The
+// callback is made for the vector set as a whole, from several goroutines,
+// but never concurrently. See Subprocess.SetProgress.
+func (p *Pool) SetProgress(f ProgressFunc) {
+	for _, w := range p.workers {
+		w.SetProgress(f)
+	}
+}
+
+// EnableCompression enables compression for every Subprocess in the pool.
+// See Subprocess.EnableCompression.
+func (p *Pool) EnableCompression() error {
+	for _, w := range p.workers {
+		if err := w.EnableCompression(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Process runs a set of test vectors and returns the result, which is the
+// same as if a single Subprocess had processed them.
+func (p *Pool) Process(algorithm string, vectorSet []byte) (any, error) {
+	// The workers are configured alike, so the first one's checks and
+	// wrappers do for all of them. They share one tracker so that progress
+	// is reported for the vector set as a whole.
+	prim, progress, err := p.workers[0].prepare(algorithm, vectorSet)
+	if err != nil {
+		return nil, err
+	}
+	transactables := make([]Transactable, len(p.workers))
+	for i, w := range p.workers {
+		transactables[i] = progress.wrap(w)
+	}
+	ret, err := processParallel(prim, vectorSet, transactables)
+	if err != nil {
+		return nil, p.annotateAbort(algorithm, err)
+	}
+	progress.finished()
+	return ret, nil
+}
+
+// annotateAbort is like Subprocess.annotateAbort, for whichever worker, if
+// any, failed.
+func (p *Pool) annotateAbort(algorithm string, err error) error {
+	for _, w := range p.workers {
+		if w.isAborted() {
+			return w.annotateAbort(algorithm, err)
+		}
+	}
+	return err
+}
+
+// processParallel splits the test groups of vectorSet between the
+// Transactables in pool, runs prim on each share concurrently, and merges the
+// resulting groups back into the order of the input. Each group is handled by
+// a single Transactable, so the order of callbacks within a group is
+// unchanged.
+//
+// This relies on prim returning a list of responses to test groups, each with
+// a tgId, and on the groups being independent. That holds for every handler in
+// this package.
+func processParallel(prim Primitive, vectorSet []byte, pool []Transactable) (any, error) {
+	var parsed map[string]json.RawMessage
+	if err := json.Unmarshal(vectorSet, &parsed); err != nil {
+		return nil, err
+	}
+	var groups []json.RawMessage
+	if err := json.Unmarshal(parsed["testGroups"], &groups); err != nil {
+		return nil, fmt.Errorf("failed to parse test groups: %s", err)
+	}
+
+	// Record the position of each group so that the responses can be put
+	// back in order.
+	position := make(map[uint64]int)
+	for i, group := range groups {
+		var id struct {
+			ID uint64 `json:"tgId"`
+		}
+		if err := json.Unmarshal(group, &id); err != nil {
+			return nil, fmt.Errorf("failed to parse test group: %s", err)
+		}
+		if _, ok := position[id.ID]; ok {
+			return nil, fmt.Errorf("duplicate test group %d", id.ID)
+		}
+		position[id.ID] = i
+	}
+
+	numWorkers := len(pool)
+	if len(groups) < numWorkers {
+		numWorkers = len(groups)
+	}
+	if numWorkers <= 1 {
+		return prim.Process(vectorSet, pool[0])
+	}
+
+	// Deal the groups out in turn so that each worker gets a similar mix.
+	shares := make([][]byte, numWorkers)
+	for i := range shares {
+		var share []json.RawMessage
+		for j := i; j < len(groups); j += numWorkers {
+			share = append(share, groups[j])
+		}
+		shareGroups, err := json.Marshal(share)
+		if err != nil {
+			return nil, err
+		}
+		parsed["testGroups"] = shareGroups
+		if shares[i], err = json.Marshal(parsed); err != nil {
+			return nil, err
+		}
+	}
+
+	results := make([]any, numWorkers)
+	errs := make([]error, numWorkers)
+	var wg sync.WaitGroup
+	for i := range shares {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			results[i], errs[i] = prim.Process(shares[i], pool[i])
+		}(i)
+	}
+	wg.Wait()
+
+	type response struct {
+		position int
+		value    json.RawMessage
+	}
+	var responses []response
+	var partialErr error
+	for i, result := range results {
+		if errs[i] != nil {
+			// The groups that a share answered before failing are kept,
+			// along with the first error, if prim reports them.
+			var partial *PartialResultsError
+			if !errors.As(errs[i], &partial) {
+				return nil, errs[i]
+			}
+			if partialErr == nil {
+				partialErr = partial.Err
+			}
+			result = partial.Results
+		}
+		resultBytes, err := json.Marshal(result)
+		if err != nil {
+			return nil, err
+		}
+		var values []json.RawMessage
+		if err := json.Unmarshal(resultBytes, &values); err != nil {
+			return nil, fmt.Errorf("handler result can't be split into test groups: %s", err)
+		}
+		for _, value := range values {
+			var id struct {
+				ID *uint64 `json:"tgId"`
+			}
+			if err := json.Unmarshal(value, &id); err != nil || id.ID == nil {
+				return nil, errors.New("handler result contains a response without a tgId")
+			}
+			pos, ok := position[*id.ID]
+			if !ok {
+				return nil, fmt.Errorf("handler result contains unknown test group %d", *id.ID)
+			}
+			responses = append(responses, response{pos, value})
+		}
+	}
+	sort.Slice(responses, func(i, j int) bool {
+		return responses[i].position < responses[j].position
+	})
+
+	merged := make([]json.RawMessage, len(responses))
+	for i, r := range responses {
+		merged[i] = r.value
+	}
+	if partialErr != nil {
+		return nil, &PartialResultsError{Results: merged, Err: partialErr}
+	}
+	return merged, nil
+}
@@ -0,0 +1,144 @@
+// Copyright (c) 2026, Google Inc.
+//
+// Permission to use, copy, modify, and/or distribute this software for any
+// purpose with or without fee is hereby granted, provided that the above
+// copyright notice and this permission notice appear in all copies.
+//
+// THE SOFTWARE IS PROVIDED "AS IS" AND THE AUTHOR DISCLAIMS ALL WARRANTIES
+// WITH REGARD TO THIS SOFTWARE INCLUDING ALL IMPLIED WARRANTIES OF
+// MERCHANTABILITY AND FITNESS. IN NO EVENT SHALL THE AUTHOR BE LIABLE FOR ANY
+// SPECIAL, DIRECT, INDIRECT, OR CONSEQUENTIAL DAMAGES OR ANY DAMAGES
+// WHATSOEVER RESULTING FROM LOSS OF USE, DATA OR PROFITS, WHETHER IN AN ACTION
+// OF CONTRACT, NEGLIGENCE OR OTHER TORTIOUS ACTION, ARISING OUT OF OR IN
+// CONNECTION WITH THE USE OR PERFORMANCE OF THIS SOFTWARE.
+
+package subprocess
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+)
+
+// sha256Responder hashes messages with SHA-256, after an optional delay.
+func sha256Responder(delay time.Duration) func(string, [][]byte) ([][]byte, error) {
+	return func(cmd string, args [][]byte) ([][]byte, error) {
+		if cmd != "SHA2-256" {
+			return nil, fmt.Errorf("unexpected command %q", cmd)
+		}
+		time.Sleep(delay)
+		digest := sha256.Sum256(args[0])
+		return [][]byte{digest[:]}, nil
+	}
+}
+
+// sha256VectorSet returns a SHA2-256 vector set with numGroups groups, which
+// are given in descending tgId order, of testsPerGroup tests each.
+func sha256VectorSet(numGroups, testsPerGroup int) string {
+	var groups []string
+	for i := numGroups; i > 0; i-- {
+		var tests []string
+		for j := 0; j < testsPerGroup; j++ {
+			msg := hex.EncodeToString([]byte{byte(i), byte(j)})
+			tests = append(tests, fmt.Sprintf(`{"tcId": %d, "len": 16, "msg": %q}`, i*1000+j, msg))
+		}
+		groups = append(groups, fmt.Sprintf(`{"tgId": %d, "testType": "AFT", "tests": [%s]}`, i, strings.Join(tests, ",")))
+	}
+	return `{"algorithm": "SHA2-256", "testGroups": [` + strings.Join(groups, ",") + `]}`
+}
+
+func TestProcessParallelMatchesSequential(t *testing.T) {
+	vectorSet := []byte(sha256VectorSet(7, 5))
+	prim := &hashPrimitive{"SHA2-256", 32}
+
+	sequential, err := prim.Process(vectorSet, &fakeTransactable{respond: sha256Responder(0)})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want, err := json.Marshal(sequential)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	pool := []*fakeTransactable{{respond: sha256Responder(0)}, {respond: sha256Responder(0)}, {respond: sha256Responder(0)}}
+	transactables := []Transactable{pool[0], pool[1], pool[2]}
+	parallel, err := processParallel(prim, vectorSet, transactables)
+	if err != nil {
+		t.Fatal(err)
+	}
+	got, err := json.Marshal(parallel)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(got, want) {
+		t.Errorf("parallel result differs from sequential result:\n%s\n%s", got, want)
+	}
+
+	// Every worker was used, and each kept the order of its groups' tests.
+	for i, w := range pool {
+		if len(w.calls) == 0 {
+			t.Errorf("worker %d was not used", i)
+		}
+		for j := 1; j < len(w.calls); j++ {
+			prev, cur := w.calls[j-1].args[0], w.calls[j].args[0]
+			if prev[0] == cur[0] && prev[1]+1 != cur[1] {
+				t.Errorf("worker %d sent test %x after %x", i, cur, prev)
+			}
+		}
+	}
+}
+
+func TestPoolProcess(t *testing.T) {
+	var workers []*Subprocess
+	for i := 0; i < 3; i++ {
+		workers = append(workers, pipeModule(t, 0, sha256Responder(0)))
+	}
+	pool, err := NewPool(workers)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer pool.Close()
+
+	vectorSet := []byte(sha256VectorSet(4, 3))
+	sequential, err := workers[0].Process("SHA2-256", vectorSet)
+	if err != nil {
+		t.Fatal(err)
+	}
+	parallel, err := pool.Process("SHA2-256", vectorSet)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want, _ := json.MarshalIndent(sequential, "", "    ")
+	got, _ := json.MarshalIndent(parallel, "", "    ")
+	if !bytes.Equal(got, want) {
+		t.Errorf("pool result differs from sequential result:\n%s\n%s", got, want)
+	}
+}
+
+func benchmarkPool(b *testing.B, numWorkers int) {
+	var workers []*Subprocess
+	for i := 0; i < numWorkers; i++ {
+		workers = append(workers, pipeModule(b, 0, sha256Responder(100*time.Microsecond)))
+	}
+	pool, err := NewPool(workers)
+	if err != nil {
+		b.Fatal(err)
+	}
+	defer pool.Close()
+
+	vectorSet := []byte(sha256VectorSet(16, 8))
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if _, err := pool.Process("SHA2-256", vectorSet); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkPoolSequential(b *testing.B) { benchmarkPool(b, 1) }
+func BenchmarkPoolParallel4(b *testing.B)  { benchmarkPool(b, 4) }
@@ -0,0 +1,179 @@
+// Copyright (c) 2026, Google Inc.
+//
+// Permission to use, copy, modify, and/or distribute this software for any
+// purpose with or without fee is hereby granted, provided that the above
+// copyright notice and this permission notice appear in all copies.
+//
+// THE SOFTWARE IS PROVIDED "AS IS" AND THE AUTHOR DISCLAIMS ALL WARRANTIES
+// WITH REGARD TO THIS SOFTWARE INCLUDING ALL IMPLIED WARRANTIES OF
+// MERCHANTABILITY AND FITNESS. IN NO EVENT SHALL THE AUTHOR BE LIABLE FOR ANY
+// SPECIAL, DIRECT, INDIRECT, OR CONSEQUENTIAL DAMAGES OR ANY DAMAGES
+// WHATSOEVER RESULTING FROM LOSS OF USE, DATA OR PROFITS, WHETHER IN AN ACTION
+// OF CONTRACT, NEGLIGENCE OR OTHER TORTIOUS ACTION, ARISING OUT OF OR IN
+// CONNECTION WITH THE USE OR PERFORMANCE OF THIS SOFTWARE.
+
+package subprocess
+
+import (
+	"encoding/binary"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+)
+
+// The following structures reflect the JSON of ACVP ParallelHash tests. See
+// https://pages.nist.gov/ACVP/draft-celi-acvp-parallelhash.html#name-test-vectors
+
+type parallelHashTestVectorSet struct {
+	Groups []parallelHashTestGroup `json:"testGroups"`
+}
+
+type parallelHashTestGroup struct {
+	ID               uint64 `json:"tgId"`
+	Type             string `json:"testType"`
+	XOF              bool   `json:"xof"`
+	HexCustomization bool   `json:"hexCustomization"`
+	BlockSize        uint32 `json:"blockSize"`
+	MaxOutLenBits    uint32 `json:"maxOutLen"`
+	MinOutLenBits    uint32 `json:"minOutLen"`
+	OutLenIncrement  uint32 `json:"outLenIncrement"`
+	Tests            []struct {
+		ID               uint64 `json:"tcId"`
+		BitLength        uint64 `json:"len"`
+		BitOutLength     uint32 `json:"outLen"`
+		MsgHex           string `json:"msg"`
+		Customization    string `json:"customization"`
+		CustomizationHex string `json:"customizationHex"`
+	} `json:"tests"`
+}
+
+type parallelHashTestGroupResponse struct {
+	ID    uint64                     `json:"tgId"`
+	Tests []parallelHashTestResponse `json:"tests"`
+}
+
+type parallelHashTestResponse struct {
+	ID         uint64         `json:"tcId"`
+	DigestHex  string         `json:"md,omitempty"`
+	MCTResults []xofMCTResult `json:"resultsArray,omitempty"`
+}
+
+// parallelHash implements an ACVP algorithm by making requests to the
+// subprocess to hash strings with ParallelHash.
+type parallelHash struct {
+	// algo is the ACVP name for this algorithm and also the command name
+	// given to the subprocess.
+	algo string
+}
+
+func (h *parallelHash) Process(vectorSet []byte, m Transactable) (any, error) {
+	var parsed parallelHashTestVectorSet
+	if err := json.Unmarshal(vectorSet, &parsed); err != nil {
+		return nil, err
+	}
+
+	var ret []parallelHashTestGroupResponse
+	// See
+	// https://pages.nist.gov/ACVP/draft-celi-acvp-parallelhash.html#name-test-types
+	// for details about the tests.
+	for _, group := range parsed.Groups {
+		group := group
+		response := parallelHashTestGroupResponse{
+			ID: group.ID,
+		}
+
+		if group.BlockSize == 0 {
+			return nil, fmt.Errorf("test group %d has a zero block size", group.ID)
+		}
+		blockSize := uint32le(group.BlockSize)
+
+		xof := []byte{0}
+		if group.XOF {
+			xof = []byte{1}
+		}
+
+		for _, test := range group.Tests {
+			test := test
+			m := withTestCase(m, h.algo, group.ID, test.ID)
+
+			if uint64(len(test.MsgHex))*4 != test.BitLength {
+				return nil, fmt.Errorf("test case %d/%d contains hex message of length %d but specifies a bit length of %d", group.ID, test.ID, len(test.MsgHex), test.BitLength)
+			}
+			msg, err := hex.DecodeString(test.MsgHex)
+			if err != nil {
+				return nil, fmt.Errorf("failed to decode hex in test case %d/%d: %s", group.ID, test.ID, err)
+			}
+
+			if test.BitOutLength%8 != 0 {
+				return nil, fmt.Errorf("test case %d/%d has bit length %d - fractional bytes not supported", group.ID, test.ID, test.BitOutLength)
+			}
+
+			customization, err := decodeCustomization(group.HexCustomization, test.Customization, test.CustomizationHex)
+			if err != nil {
+				return nil, testCaseInputError(h.algo, group.ID, test.ID, fmt.Errorf("failed to decode customization: %w", err))
+			}
+
+			switch group.Type {
+			case "AFT":
+				if err := checkOutLen(test.BitOutLength, group.MinOutLenBits, group.MaxOutLenBits, group.OutLenIncrement); err != nil {
+					return nil, fmt.Errorf("test case %d/%d: %s", group.ID, test.ID, err)
+				}
+				outBytes := int(test.BitOutLength / 8)
+				m.TransactAsync(h.algo, 1, [][]byte{msg, blockSize, uint32le(test.BitOutLength / 8), customization, xof}, func(result [][]byte) error {
+					if len(result[0]) != outBytes {
+						return fmt.Errorf("%s operation returned %d bytes but wanted %d", h.algo, len(result[0]), outBytes)
+					}
+
+					response.Tests = append(response.Tests, parallelHashTestResponse{
+						ID:        test.ID,
+						DigestHex: hex.EncodeToString(result[0]),
+					})
+					return nil
+				})
+			case "MCT":
+				testResponse := parallelHashTestResponse{ID: test.ID}
+
+				if group.MinOutLenBits%8 != 0 {
+					return nil, fmt.Errorf("MCT test group %d has min output length %d - fractional bytes not supported", group.ID, group.MinOutLenBits)
+				}
+				if group.MaxOutLenBits%8 != 0 {
+					return nil, fmt.Errorf("MCT test group %d has max output length %d - fractional bytes not supported", group.ID, group.MaxOutLenBits)
+				}
+
+				digest := msg
+				minOutLenBytes := uint32le(group.MinOutLenBits / 8)
+				maxOutLenBytes := uint32le(group.MaxOutLenBits / 8)
+				outputLenBytes := uint32le(group.MaxOutLenBits / 8)
+
+				for i := 0; i < 100; i++ {
+					args := [][]byte{digest, minOutLenBytes, maxOutLenBytes, outputLenBytes, blockSize, customization, xof}
+					result, err := m.Transact(h.algo+"/MCT", 2, args...)
+					if err != nil {
+						return nil, fmt.Errorf("%s MCT operation failed for test case %d/%d: %w", h.algo, group.ID, test.ID, err)
+					}
+					if err := checkMCTIteration(i, result[0], result[1], group.MinOutLenBits/8, group.MaxOutLenBits/8); err != nil {
+						return nil, fmt.Errorf("%s MCT operation for test case %d/%d: %s", h.algo, group.ID, test.ID, err)
+					}
+
+					digest = result[0]
+					outputLenBytes = uint32le(binary.LittleEndian.Uint32(result[1]))
+					testResponse.MCTResults = append(testResponse.MCTResults, newXOFMCTResult(digest))
+				}
+
+				response.Tests = append(response.Tests, testResponse)
+			default:
+				return nil, fmt.Errorf("test group %d has unknown type %q", group.ID, group.Type)
+			}
+		}
+
+		m.Barrier(func() {
+			ret = append(ret, response)
+		})
+	}
+
+	if err := m.Flush(); err != nil {
+		return nil, err
+	}
+
+	return ret, nil
+}
@@ -0,0 +1,91 @@
+// Copyright (c) 2026, Google Inc.
+//
+// Permission to use, copy, modify, and/or distribute this software for any
+// purpose with or without fee is hereby granted, provided that the above
+// copyright notice and this permission notice appear in all copies.
+//
+// THE SOFTWARE IS PROVIDED "AS IS" AND THE AUTHOR DISCLAIMS ALL WARRANTIES
+// WITH REGARD TO THIS SOFTWARE INCLUDING ALL IMPLIED WARRANTIES OF
+// MERCHANTABILITY AND FITNESS. IN NO EVENT SHALL THE AUTHOR BE LIABLE FOR ANY
+// SPECIAL, DIRECT, INDIRECT, OR CONSEQUENTIAL DAMAGES OR ANY DAMAGES
+// WHATSOEVER RESULTING FROM LOSS OF USE, DATA OR PROFITS, WHETHER IN AN ACTION
+// OF CONTRACT, NEGLIGENCE OR OTHER TORTIOUS ACTION, ARISING OUT OF OR IN
+// CONNECTION WITH THE USE OR PERFORMANCE OF THIS SOFTWARE.
+
+package subprocess
+
+import (
+	"encoding/json"
+	"fmt"
+)
+
+// PartialResultsError is returned by Process, once EnablePartialResults has
+// been called, if a vector set fails part way through. Results holds the
+// responses to the test groups that were answered before the failure, which
+// may be none, and Err is the reason that processing stopped.
+type PartialResultsError struct {
+	Results []json.RawMessage
+	Err     error
+}
+
+func (e *PartialResultsError) Error() string {
+	return e.Err.Error()
+}
+
+func (e *PartialResultsError) Unwrap() error {
+	return e.Err
+}
+
+// EnablePartialResults causes Process to run the handler on each test group
+// of a vector set in turn, so that if one fails the responses to the groups
+// before it can be returned in a *PartialResultsError rather than being lost.
+// This is meant for debugging a module: commands are no longer pipelined
+// between test groups, so vector sets with many small groups are slower.
+func (m *Subprocess) EnablePartialResults() {
+	m.partialResults = true
+}
+
+// partialPrimitive runs prim on one test group at a time. See
+// EnablePartialResults.
+type partialPrimitive struct {
+	prim Primitive
+}
+
+func (p *partialPrimitive) Process(vectorSet []byte, t Transactable) (any, error) {
+	var parsed map[string]json.RawMessage
+	if err := json.Unmarshal(vectorSet, &parsed); err != nil {
+		return nil, err
+	}
+	var groups []json.RawMessage
+	if err := json.Unmarshal(parsed["testGroups"], &groups); err != nil {
+		return nil, fmt.Errorf("failed to parse test groups: %s", err)
+	}
+
+	results := []json.RawMessage{}
+	for _, group := range groups {
+		groupBytes, err := json.Marshal([]json.RawMessage{group})
+		if err != nil {
+			return nil, err
+		}
+		parsed["testGroups"] = groupBytes
+		single, err := json.Marshal(parsed)
+		if err != nil {
+			return nil, err
+		}
+
+		result, err := p.prim.Process(single, t)
+		if err != nil {
+			return nil, &PartialResultsError{Results: results, Err: err}
+		}
+		resultBytes, err := json.Marshal(result)
+		if err != nil {
+			return nil, err
+		}
+		var values []json.RawMessage
+		if err := json.Unmarshal(resultBytes, &values); err != nil {
+			return nil, fmt.Errorf("handler result can't be split into test groups: %s", err)
+		}
+		results = append(results, values...)
+	}
+	return results, nil
+}
@@ -0,0 +1,143 @@
+// Copyright (c) 2026, Google Inc.
+//
+// Permission to use, copy, modify, and/or distribute this software for any
+// purpose with or without fee is hereby granted, provided that the above
+// copyright notice and this permission notice appear in all copies.
+//
+// THE SOFTWARE IS PROVIDED "AS IS" AND THE AUTHOR DISCLAIMS ALL WARRANTIES
+// WITH REGARD TO THIS SOFTWARE INCLUDING ALL IMPLIED WARRANTIES OF
+// MERCHANTABILITY AND FITNESS. IN NO EVENT SHALL THE AUTHOR BE LIABLE FOR ANY
+// SPECIAL, DIRECT, INDIRECT, OR CONSEQUENTIAL DAMAGES OR ANY DAMAGES
+// WHATSOEVER RESULTING FROM LOSS OF USE, DATA OR PROFITS, WHETHER IN AN ACTION
+// OF CONTRACT, NEGLIGENCE OR OTHER TORTIOUS ACTION, ARISING OUT OF OR IN
+// CONNECTION WITH THE USE OR PERFORMANCE OF THIS SOFTWARE.
+
+package subprocess
+
+import (
+	"encoding/json"
+	"errors"
+	"reflect"
+	"testing"
+)
+
+// failingSHA256Responder is like sha256Responder, but fails for the messages
+// of test group failGroup of a sha256VectorSet.
+func failingSHA256Responder(failGroup byte) func(string, [][]byte) ([][]byte, error) {
+	return func(cmd string, args [][]byte) ([][]byte, error) {
+		if args[0][0] == failGroup {
+			return nil, errors.New("module failed")
+		}
+		return sha256Responder(0)(cmd, args)
+	}
+}
+
+// groupIDs returns the tgId of each response in results.
+func groupIDs(t *testing.T, results []json.RawMessage) []uint64 {
+	ids := []uint64{}
+	for _, result := range results {
+		var id struct {
+			ID uint64 `json:"tgId"`
+		}
+		if err := json.Unmarshal(result, &id); err != nil {
+			t.Fatal(err)
+		}
+		ids = append(ids, id.ID)
+	}
+	return ids
+}
+
+func TestPartialResults(t *testing.T) {
+	prim := &partialPrimitive{&hashPrimitive{"SHA2-256", 32}}
+	m := &fakeTransactable{respond: failingSHA256Responder(2)}
+	_, err := prim.Process([]byte(sha256VectorSet(4, 3)), m)
+
+	var partial *PartialResultsError
+	if !errors.As(err, &partial) {
+		t.Fatalf("got error %v, want partial results", err)
+	}
+	if got, want := groupIDs(t, partial.Results), []uint64{4, 3}; !reflect.DeepEqual(got, want) {
+		t.Errorf("got results for groups %v, want %v", got, want)
+	}
+	if partial.Err.Error() != "module failed" {
+		t.Errorf("got error %q", partial.Err)
+	}
+}
+
+func TestPartialResultsFirstGroup(t *testing.T) {
+	prim := &partialPrimitive{&hashPrimitive{"SHA2-256", 32}}
+	m := &fakeTransactable{respond: failingSHA256Responder(4)}
+	_, err := prim.Process([]byte(sha256VectorSet(4, 3)), m)
+
+	var partial *PartialResultsError
+	if !errors.As(err, &partial) {
+		t.Fatalf("got error %v, want partial results", err)
+	}
+	if len(partial.Results) != 0 {
+		t.Errorf("got %d results, want none", len(partial.Results))
+	}
+}
+
+func TestPartialResultsSuccess(t *testing.T) {
+	vectorSet := []byte(sha256VectorSet(4, 3))
+	prim := &hashPrimitive{"SHA2-256", 32}
+	want, err := prim.Process(vectorSet, &fakeTransactable{respond: sha256Responder(0)})
+	if err != nil {
+		t.Fatal(err)
+	}
+	got, err := (&partialPrimitive{prim}).Process(vectorSet, &fakeTransactable{respond: sha256Responder(0)})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	wantBytes, _ := json.Marshal(want)
+	gotBytes, _ := json.Marshal(got)
+	if string(gotBytes) != string(wantBytes) {
+		t.Errorf("got %s, want %s", gotBytes, wantBytes)
+	}
+}
+
+func TestPartialResultsParallel(t *testing.T) {
+	prim := &partialPrimitive{&hashPrimitive{"SHA2-256", 32}}
+	// Groups 4 and 2 go to the first worker and groups 3 and 1 to the
+	// second.
+	pool := []Transactable{
+		&fakeTransactable{respond: failingSHA256Responder(4)},
+		&fakeTransactable{respond: sha256Responder(0)},
+	}
+	_, err := processParallel(prim, []byte(sha256VectorSet(4, 3)), pool)
+
+	var partial *PartialResultsError
+	if !errors.As(err, &partial) {
+		t.Fatalf("got error %v, want partial results", err)
+	}
+	if got, want := groupIDs(t, partial.Results), []uint64{3, 1}; !reflect.DeepEqual(got, want) {
+		t.Errorf("got results for groups %v, want %v", got, want)
+	}
+}
+
+func TestSubprocessPartialResults(t *testing.T) {
+	// The message of the second group isn't valid hex, so the handler
+	// fails before sending it.
+	vectorSet := `{"algorithm": "SHA2-256", "testGroups": [
+		{"tgId": 1, "testType": "AFT", "tests": [{"tcId": 1, "len": 16, "msg": "0001"}]},
+		{"tgId": 2, "testType": "AFT", "tests": [{"tcId": 2, "len": 16, "msg": "zzzz"}]}
+	]}`
+
+	m := pipeModule(t, 0, sha256Responder(0))
+	if _, err := m.Process("SHA2-256", []byte(vectorSet)); err == nil {
+		t.Fatal("Process succeeded despite a bad message")
+	} else if errors.As(err, new(*PartialResultsError)) {
+		t.Fatal("got partial results without EnablePartialResults")
+	}
+
+	m.EnablePartialResults()
+	_, err := m.Process("SHA2-256", []byte(vectorSet))
+	var partial *PartialResultsError
+	if !errors.As(err, &partial) {
+		t.Fatalf("got error %v, want partial results", err)
+	}
+	if got, want := groupIDs(t, partial.Results), []uint64{1}; !reflect.DeepEqual(got, want) {
+		t.Errorf("got results for groups %v, want %v", got, want)
+	}
+}
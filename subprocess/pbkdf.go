@@ -80,11 +80,16 @@ func (p *pbkdf) Process(vectorSet []byte, m Transactable) (any, error) {
 
 		for _, test := range group.Tests {
 			test := test
+			m := withTestCase(m, "PBKDF", group.ID, test.ID)
 
 			if test.KeyLen < 8 {
 				return nil, fmt.Errorf("key length must be at least 8 bits in test case %d/%d", group.ID, test.ID)
 			}
+			if test.KeyLen%8 != 0 {
+				return nil, fmt.Errorf("key length %d in test case %d/%d - fractional bytes not supported", test.KeyLen, group.ID, test.ID)
+			}
 			keyLen := uint32le(test.KeyLen)
+			keyBytes := int(test.KeyLen / 8)
 
 			salt, err := hex.DecodeString(test.Salt)
 			if err != nil {
@@ -94,10 +99,16 @@ func (p *pbkdf) Process(vectorSet []byte, m Transactable) (any, error) {
 			if test.IterationCount < 1 {
 				return nil, fmt.Errorf("iteration count must be at least 1 in test case %d/%d", group.ID, test.ID)
 			}
+			// Iteration counts run to the millions, so they are sent as a
+			// 32-bit value, like all lengths, and not as a string.
 			iterationCount := uint32le(test.IterationCount)
 
 			msg := [][]byte{[]byte(group.HmacAlgo), keyLen, salt, []byte(test.Password), iterationCount}
 			m.TransactAsync("PBKDF", 1, msg, func(result [][]byte) error {
+				if len(result[0]) != keyBytes {
+					return fmt.Errorf("PBKDF operation returned %d bytes but wanted %d for test case %d/%d", len(result[0]), keyBytes, group.ID, test.ID)
+				}
+
 				response.Tests = append(response.Tests, pbkdfTestResponse{
 					ID:         test.ID,
 					DerivedKey: hex.EncodeToString(result[0]),
@@ -0,0 +1,92 @@
+// Copyright (c) 2026, Google Inc.
+//
+// Permission to use, copy, modify, and/or distribute this software for any
+// purpose with or without fee is hereby granted, provided that the above
+// copyright notice and this permission notice appear in all copies.
+//
+// THE SOFTWARE IS PROVIDED "AS IS" AND THE AUTHOR DISCLAIMS ALL WARRANTIES
+// WITH REGARD TO THIS SOFTWARE INCLUDING ALL IMPLIED WARRANTIES OF
+// MERCHANTABILITY AND FITNESS. IN NO EVENT SHALL THE AUTHOR BE LIABLE FOR ANY
+// SPECIAL, DIRECT, INDIRECT, OR CONSEQUENTIAL DAMAGES OR ANY DAMAGES
+// WHATSOEVER RESULTING FROM LOSS OF USE, DATA OR PROFITS, WHETHER IN AN ACTION
+// OF CONTRACT, NEGLIGENCE OR OTHER TORTIOUS ACTION, ARISING OUT OF OR IN
+// CONNECTION WITH THE USE OR PERFORMANCE OF THIS SOFTWARE.
+
+package subprocess
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha1"
+	"encoding/binary"
+	"testing"
+)
+
+// pbkdf2SHA1Responder implements PBKDF2 with HMAC-SHA-1, in bits, for
+// outputs of at most one block.
+func pbkdf2SHA1Responder(cmd string, args [][]byte) ([][]byte, error) {
+	if cmd != "PBKDF" || string(args[0]) != "SHA-1" {
+		panic("unexpected command " + cmd)
+	}
+	keyLen := binary.LittleEndian.Uint32(args[1]) / 8
+	salt, password := args[2], args[3]
+	iterations := binary.LittleEndian.Uint32(args[4])
+
+	mac := hmac.New(sha1.New, password)
+	mac.Write(salt)
+	mac.Write([]byte{0, 0, 0, 1})
+	u := mac.Sum(nil)
+	out := append([]byte{}, u...)
+	for i := uint32(1); i < iterations; i++ {
+		mac.Reset()
+		mac.Write(u)
+		u = mac.Sum(nil)
+		for j := range out {
+			out[j] ^= u[j]
+		}
+	}
+	return [][]byte{out[:keyLen]}, nil
+}
+
+func TestPBKDFSingleIteration(t *testing.T) {
+	// RFC 6070, first test vector.
+	const vectorSet = `{"algorithm": "PBKDF", "testGroups": [{
+		"tgId": 1, "testType": "AFT", "hmacAlg": "SHA-1",
+		"tests": [{"tcId": 1, "keyLen": 160, "salt": "73616c74", "password": "password", "iterationCount": 1}]
+	}]}`
+
+	result, err := new(pbkdf).Process([]byte(vectorSet), &fakeTransactable{respond: pbkdf2SHA1Responder})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	const want = "0c60c80f961f0e71f3a9b524af6012062fe037a6"
+	if got := result.([]pbkdfTestGroupResponse)[0].Tests[0].DerivedKey; got != want {
+		t.Errorf("got %s, want %s", got, want)
+	}
+}
+
+func TestPBKDFLargeIterationCount(t *testing.T) {
+	const vectorSet = `{"algorithm": "PBKDF", "testGroups": [{
+		"tgId": 1, "testType": "AFT", "hmacAlg": "SHA2-512",
+		"tests": [{"tcId": 1, "keyLen": 128, "salt": "00", "password": "pw", "iterationCount": 10000000}]
+	}]}`
+
+	m := &fakeTransactable{respond: func(cmd string, args [][]byte) ([][]byte, error) {
+		return [][]byte{make([]byte, 16)}, nil
+	}}
+	if _, err := new(pbkdf).Process([]byte(vectorSet), m); err != nil {
+		t.Fatal(err)
+	}
+
+	if count := m.calls[0].args[4]; !bytes.Equal(count, []byte{0x80, 0x96, 0x98, 0x00}) {
+		t.Errorf("iteration count was sent as %x", count)
+	}
+
+	m = &fakeTransactable{respond: func(cmd string, args [][]byte) ([][]byte, error) {
+		return [][]byte{make([]byte, 8)}, nil
+	}}
+	if _, err := new(pbkdf).Process([]byte(vectorSet), m); err == nil {
+		t.Error("short derived key was accepted")
+	}
+}
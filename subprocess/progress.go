@@ -0,0 +1,148 @@
+// Copyright (c) 2026, Google Inc.
+//
+// Permission to use, copy, modify, and/or distribute this software for any
+// purpose with or without fee is hereby granted, provided that the above
+// copyright notice and this permission notice appear in all copies.
+//
+// THE SOFTWARE IS PROVIDED "AS IS" AND THE AUTHOR DISCLAIMS ALL WARRANTIES
+// WITH REGARD TO THIS SOFTWARE INCLUDING ALL IMPLIED WARRANTIES OF
+// MERCHANTABILITY AND FITNESS. IN NO EVENT SHALL THE AUTHOR BE LIABLE FOR ANY
+// SPECIAL, DIRECT, INDIRECT, OR CONSEQUENTIAL DAMAGES OR ANY DAMAGES
+// WHATSOEVER RESULTING FROM LOSS OF USE, DATA OR PROFITS, WHETHER IN AN ACTION
+// OF CONTRACT, NEGLIGENCE OR OTHER TORTIOUS ACTION, ARISING OUT OF OR IN
+// CONNECTION WITH THE USE OR PERFORMANCE OF THIS SOFTWARE.
+
+package subprocess
+
+import (
+	"encoding/json"
+	"sync"
+)
+
+// ProgressFunc is called as the test cases of a vector set for algorithm
+// are answered, with the number answered so far and the number in the vector
+// set. See SetProgress.
+type ProgressFunc func(algorithm string, completed, total int)
+
+// SetProgress causes f to be called as test cases are answered, so that
+// long-running vector sets can show their progress. A test case is counted
+// once the module has answered all its commands, which is known when it
+// answers a command for the next test case, and the last is counted once the
+// whole vector set has been processed. Calls for a
+// vector set are made in order, but may come from a goroutine other than the
+// one calling Process. A nil f disables progress reporting.
+func (m *Subprocess) SetProgress(f ProgressFunc) {
+	m.progress = f
+}
+
+// progressTracker counts the answered test cases of a vector set.
+type progressTracker struct {
+	f         ProgressFunc
+	algorithm string
+	total     int
+
+	mu        sync.Mutex
+	completed int
+}
+
+// newProgressTracker returns a tracker for vectorSet, or nil if f is nil.
+func newProgressTracker(f ProgressFunc, algorithm string, vectorSet []byte) *progressTracker {
+	if f == nil {
+		return nil
+	}
+	var parsed struct {
+		Groups []struct {
+			Tests []json.RawMessage `json:"tests"`
+		} `json:"testGroups"`
+	}
+	// Handlers report malformed vector sets, so a parse failure here just
+	// leaves the total at zero.
+	json.Unmarshal(vectorSet, &parsed)
+	total := 0
+	for _, group := range parsed.Groups {
+		total += len(group.Tests)
+	}
+	return &progressTracker{f: f, algorithm: algorithm, total: total}
+}
+
+// commandCompleted records that a command for test case c has completed.
+// last holds the test case of the previous command completed through the same
+// Transactable, and is updated. Commands complete in the order in which they
+// were sent, and a test case may need several, so a test case is only known
+// to be answered once a command for a later one completes.
+func (p *progressTracker) commandCompleted(last **testCase, c *testCase) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if *last != nil && **last != *c && p.completed < p.total {
+		p.completed++
+		p.f(p.algorithm, p.completed, p.total)
+	}
+	*last = c
+}
+
+// finished reports any test cases that the handler didn't.
+func (p *progressTracker) finished() {
+	if p == nil {
+		return
+	}
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.completed < p.total {
+		p.completed = p.total
+		p.f(p.algorithm, p.completed, p.total)
+	}
+}
+
+// wrap returns a Transactable that sends commands to t and reports progress
+// to p. If p is nil, t is returned unchanged.
+func (p *progressTracker) wrap(t Transactable) Transactable {
+	if p == nil {
+		return t
+	}
+	return &progressTransactable{t: t, tracker: p}
+}
+
+// progressTransactable is a Transactable that counts the test cases answered
+// through it. Handlers label their commands with withTestCase, which passes
+// the label down to here.
+type progressTransactable struct {
+	t       Transactable
+	tracker *progressTracker
+	// last is the test case of the last command to complete. It is guarded
+	// by tracker.mu.
+	last *testCase
+}
+
+func (p *progressTransactable) Transact(cmd string, expectedResults int, args ...[]byte) ([][]byte, error) {
+	return p.t.Transact(cmd, expectedResults, args...)
+}
+
+func (p *progressTransactable) TransactAsync(cmd string, expectedResults int, args [][]byte, callback func([][]byte) error) {
+	p.t.TransactAsync(cmd, expectedResults, args, callback)
+}
+
+func (p *progressTransactable) transactLabelled(label *testCase, cmd string, expectedResults int, args ...[]byte) ([][]byte, error) {
+	result, err := p.t.Transact(cmd, expectedResults, args...)
+	if err == nil {
+		p.tracker.commandCompleted(&p.last, label)
+	}
+	return result, err
+}
+
+func (p *progressTransactable) transactAsyncLabelled(label *testCase, cmd string, expectedResults int, args [][]byte, callback func(result [][]byte) error) {
+	(&testCaseTransactable{p.t, label}).TransactAsync(cmd, expectedResults, args, func(result [][]byte) error {
+		if err := callback(result); err != nil {
+			return err
+		}
+		p.tracker.commandCompleted(&p.last, label)
+		return nil
+	})
+}
+
+func (p *progressTransactable) Barrier(callback func()) error {
+	return p.t.Barrier(callback)
+}
+
+func (p *progressTransactable) Flush() error {
+	return p.t.Flush()
+}
@@ -0,0 +1,133 @@
+// Copyright (c) 2026, Google Inc.
+//
+// Permission to use, copy, modify, and/or distribute this software for any
+// purpose with or without fee is hereby granted, provided that the above
+// copyright notice and this permission notice appear in all copies.
+//
+// THE SOFTWARE IS PROVIDED "AS IS" AND THE AUTHOR DISCLAIMS ALL WARRANTIES
+// WITH REGARD TO THIS SOFTWARE INCLUDING ALL IMPLIED WARRANTIES OF
+// MERCHANTABILITY AND FITNESS. IN NO EVENT SHALL THE AUTHOR BE LIABLE FOR ANY
+// SPECIAL, DIRECT, INDIRECT, OR CONSEQUENTIAL DAMAGES OR ANY DAMAGES
+// WHATSOEVER RESULTING FROM LOSS OF USE, DATA OR PROFITS, WHETHER IN AN ACTION
+// OF CONTRACT, NEGLIGENCE OR OTHER TORTIOUS ACTION, ARISING OUT OF OR IN
+// CONNECTION WITH THE USE OR PERFORMANCE OF THIS SOFTWARE.
+
+package subprocess
+
+import (
+	"encoding/binary"
+	"fmt"
+	"strings"
+	"sync"
+	"testing"
+)
+
+const cShakeMultiGroupVectorSet = `{"testGroups": [{
+	"tgId": 1,
+	"testType": "AFT",
+	"tests": [
+		{"tcId": 1, "len": 16, "msg": "0001", "outLen": 32, "functionName": "", "customization": ""},
+		{"tcId": 2, "len": 8, "msg": "02", "outLen": 64, "functionName": "", "customization": ""},
+		{"tcId": 3, "len": 0, "msg": "", "outLen": 16, "functionName": "", "customization": ""}
+	]
+}, {
+	"tgId": 2,
+	"testType": "MCT",
+	"minOutLen": 16,
+	"maxOutLen": 64,
+	"outLenIncrement": 8,
+	"tests": [{"tcId": 4, "len": 32, "msg": "00010203", "functionName": "", "customization": ""}]
+}]}`
+
+// progressCounter records the calls to a ProgressFunc.
+type progressCounter struct {
+	mu    sync.Mutex
+	calls []string
+}
+
+func (c *progressCounter) progress(algorithm string, completed, total int) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.calls = append(c.calls, fmt.Sprintf("%s %d/%d", algorithm, completed, total))
+}
+
+func TestProgressCShake(t *testing.T) {
+	m := pipeModule(t, 0, func(cmd string, args [][]byte) ([][]byte, error) {
+		if cmd == "cSHAKE-128/MCT" {
+			return cShakeMCTResponder(cmd, args)
+		}
+		return [][]byte{make([]byte, binary.LittleEndian.Uint32(args[1]))}, nil
+	})
+	defer m.Close()
+
+	var counter progressCounter
+	m.SetProgress(counter.progress)
+	if _, err := m.Process("cSHAKE-128", []byte(cShakeMultiGroupVectorSet)); err != nil {
+		t.Fatal(err)
+	}
+
+	// Each test is reported once the response for the next test arrives,
+	// and the MCT test once the vector set is done.
+	want := []string{"cSHAKE-128 1/4", "cSHAKE-128 2/4", "cSHAKE-128 3/4", "cSHAKE-128 4/4"}
+	if fmt.Sprint(counter.calls) != fmt.Sprint(want) {
+		t.Errorf("got progress %q, want %q", counter.calls, want)
+	}
+}
+
+func TestProgressMLKEM(t *testing.T) {
+	// ML-KEM keyGen waits for each result with Transact.
+	var tests []string
+	for i := 1; i <= 3; i++ {
+		tests = append(tests, fmt.Sprintf(`{"tcId": %d, "d": "%s", "z": "%s"}`, i, hexRepeat(byte(i), 32), hexRepeat(0x02, 32)))
+	}
+	vectorSet := `{"algorithm": "ML-KEM", "mode": "keyGen", "testGroups": [{
+		"tgId": 1, "testType": "AFT", "parameterSet": "ML-KEM-512", "tests": [` + strings.Join(tests, ",") + `]}]}`
+
+	m := pipeModule(t, 0, mlkemResponder)
+	defer m.Close()
+
+	var counter progressCounter
+	m.SetProgress(counter.progress)
+	if _, err := m.Process("ML-KEM", []byte(vectorSet)); err != nil {
+		t.Fatal(err)
+	}
+	want := []string{"ML-KEM 1/3", "ML-KEM 2/3", "ML-KEM 3/3"}
+	if fmt.Sprint(counter.calls) != fmt.Sprint(want) {
+		t.Errorf("got progress %q, want %q", counter.calls, want)
+	}
+}
+
+func TestProgressPool(t *testing.T) {
+	// The workers share the count for the vector set, but each tells its own
+	// test cases apart.
+	workers := []*Subprocess{pipeModule(t, 0, sha256Responder(0)), pipeModule(t, 0, sha256Responder(0))}
+	pool, err := NewPool(workers)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer pool.Close()
+
+	var counter progressCounter
+	pool.SetProgress(counter.progress)
+	if _, err := pool.Process("SHA2-256", []byte(sha256VectorSet(4, 3))); err != nil {
+		t.Fatal(err)
+	}
+	// Each worker has six test cases, the last of which is only counted
+	// once the vector set is done.
+	want := []string{"SHA2-256 1/12", "SHA2-256 2/12", "SHA2-256 3/12", "SHA2-256 4/12", "SHA2-256 5/12", "SHA2-256 6/12", "SHA2-256 7/12", "SHA2-256 8/12", "SHA2-256 9/12", "SHA2-256 10/12", "SHA2-256 12/12"}
+	if fmt.Sprint(counter.calls) != fmt.Sprint(want) {
+		t.Errorf("got progress %q, want %q", counter.calls, want)
+	}
+}
+
+func TestProgressDisabled(t *testing.T) {
+	m := pipeModule(t, 0, func(cmd string, args [][]byte) ([][]byte, error) {
+		return [][]byte{make([]byte, binary.LittleEndian.Uint32(args[1]))}, nil
+	})
+	defer m.Close()
+
+	m.SetProgress(nil)
+	if _, err := m.Process("cSHAKE-128", []byte(cShakeAFTVectorSet)); err != nil {
+		t.Fatal(err)
+	}
+}
@@ -0,0 +1,46 @@
+// Copyright (c) 2026, Google Inc.
+//
+// Permission to use, copy, modify, and/or distribute this software for any
+// purpose with or without fee is hereby granted, provided that the above
+// copyright notice and this permission notice appear in all copies.
+//
+// THE SOFTWARE IS PROVIDED "AS IS" AND THE AUTHOR DISCLAIMS ALL WARRANTIES
+// WITH REGARD TO THIS SOFTWARE INCLUDING ALL IMPLIED WARRANTIES OF
+// MERCHANTABILITY AND FITNESS. IN NO EVENT SHALL THE AUTHOR BE LIABLE FOR ANY
+// SPECIAL, DIRECT, INDIRECT, OR CONSEQUENTIAL DAMAGES OR ANY DAMAGES
+// WHATSOEVER RESULTING FROM LOSS OF USE, DATA OR PROFITS, WHETHER IN AN ACTION
+// OF CONTRACT, NEGLIGENCE OR OTHER TORTIOUS ACTION, ARISING OUT OF OR IN
+// CONNECTION WITH THE USE OR PERFORMANCE OF THIS SOFTWARE.
+
+package subprocess
+
+import (
+	"fmt"
+	"time"
+)
+
+// transientResultCount is sent by a modulewrapper in place of the number of
+// results to report a transient failure, such as a busy hardware module,
+// rather than a cryptographic result. It is followed by the 32-bit,
+// little-endian length of an error message and the message itself.
+const transientResultCount = 0xffffffff
+
+// transientError is a failure that the modulewrapper reported as transient.
+type transientError struct {
+	cmd     string
+	message string
+}
+
+func (e *transientError) Error() string {
+	return fmt.Sprintf("modulewrapper reported a transient failure of %q: %s", e.cmd, e.message)
+}
+
+// SetRetryPolicy causes m to resend a command up to maxRetries times if the
+// modulewrapper reports a transient failure, waiting backoff before the first
+// retry and doubling the wait each time. Resending has to keep commands in
+// order, so once retries are enabled m waits for each response before
+// sending the next command. Call SetRetryPolicy before using m.
+func (m *Subprocess) SetRetryPolicy(maxRetries int, backoff time.Duration) {
+	m.maxRetries = maxRetries
+	m.retryBackoff = backoff
+}
@@ -0,0 +1,110 @@
+// Copyright (c) 2026, Google Inc.
+//
+// Permission to use, copy, modify, and/or distribute this software for any
+// purpose with or without fee is hereby granted, provided that the above
+// copyright notice and this permission notice appear in all copies.
+//
+// THE SOFTWARE IS PROVIDED "AS IS" AND THE AUTHOR DISCLAIMS ALL WARRANTIES
+// WITH REGARD TO THIS SOFTWARE INCLUDING ALL IMPLIED WARRANTIES OF
+// MERCHANTABILITY AND FITNESS. IN NO EVENT SHALL THE AUTHOR BE LIABLE FOR ANY
+// SPECIAL, DIRECT, INDIRECT, OR CONSEQUENTIAL DAMAGES OR ANY DAMAGES
+// WHATSOEVER RESULTING FROM LOSS OF USE, DATA OR PROFITS, WHETHER IN AN ACTION
+// OF CONTRACT, NEGLIGENCE OR OTHER TORTIOUS ACTION, ARISING OUT OF OR IN
+// CONNECTION WITH THE USE OR PERFORMANCE OF THIS SOFTWARE.
+
+package subprocess
+
+import (
+	"encoding/binary"
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+)
+
+// flakyCShake answers cSHAKE commands with output of the requested length,
+// filled with the first byte of the message, but reports a transient failure
+// the first failures times that each message is seen.
+func flakyCShake(failures int) (respond func(cmd string, args [][]byte) ([][]byte, error), attempts map[string]int) {
+	attempts = make(map[string]int)
+	respond = func(cmd string, args [][]byte) ([][]byte, error) {
+		msg := string(args[0])
+		attempts[msg]++
+		if attempts[msg] <= failures {
+			return nil, &transientError{cmd, "device busy"}
+		}
+		result := make([]byte, binary.LittleEndian.Uint32(args[1]))
+		for i := range result {
+			result[i] = args[0][0]
+		}
+		return [][]byte{result}, nil
+	}
+	return respond, attempts
+}
+
+func TestRetry(t *testing.T) {
+	respond, attempts := flakyCShake(2)
+	m := pipeModule(t, 0, respond)
+	defer m.Close()
+	m.SetRetryPolicy(2, time.Millisecond)
+
+	result, err := m.Process("cSHAKE-128", []byte(cShakeAFTVectorSet))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var groups []struct {
+		Tests []struct {
+			ID uint64 `json:"tcId"`
+			MD string `json:"md"`
+		} `json:"tests"`
+	}
+	encoded, err := json.Marshal(result)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := json.Unmarshal(encoded, &groups); err != nil {
+		t.Fatal(err)
+	}
+	want := map[uint64]string{1: "00000000", 2: "0202020202020202"}
+	if len(groups) != 1 || len(groups[0].Tests) != len(want) {
+		t.Fatalf("unexpected result %s", encoded)
+	}
+	for _, test := range groups[0].Tests {
+		if test.MD != want[test.ID] {
+			t.Errorf("test case %d: got %s, want %s", test.ID, test.MD, want[test.ID])
+		}
+	}
+
+	for msg, n := range attempts {
+		if n != 3 {
+			t.Errorf("message %x was sent %d times, want 3", msg, n)
+		}
+	}
+}
+
+func TestRetryExhausted(t *testing.T) {
+	respond, attempts := flakyCShake(2)
+	m := pipeModule(t, 0, respond)
+	defer m.Close()
+	m.SetRetryPolicy(1, time.Millisecond)
+
+	_, err := m.Process("cSHAKE-128", []byte(cShakeAFTVectorSet))
+	if err == nil || !strings.Contains(err.Error(), "device busy") {
+		t.Fatalf("got error %v, want a transient failure", err)
+	}
+	// The first message is tried twice and then the subprocess gives up.
+	if len(attempts) != 1 || attempts["\x00\x01"] != 2 {
+		t.Errorf("unexpected attempts %v", attempts)
+	}
+}
+
+func TestTransientWithoutRetries(t *testing.T) {
+	respond, _ := flakyCShake(1)
+	m := pipeModule(t, 0, respond)
+	defer m.Close()
+
+	if _, err := m.Process("cSHAKE-128", []byte(cShakeAFTVectorSet)); err == nil || !strings.Contains(err.Error(), "transient") {
+		t.Fatalf("got error %v, want a transient failure", err)
+	}
+}
@@ -0,0 +1,68 @@
+// Copyright (c) 2026, Google Inc.
+//
+// Permission to use, copy, modify, and/or distribute this software for any
+// purpose with or without fee is hereby granted, provided that the above
+// copyright notice and this permission notice appear in all copies.
+//
+// THE SOFTWARE IS PROVIDED "AS IS" AND THE AUTHOR DISCLAIMS ALL WARRANTIES
+// WITH REGARD TO THIS SOFTWARE INCLUDING ALL IMPLIED WARRANTIES OF
+// MERCHANTABILITY AND FITNESS. IN NO EVENT SHALL THE AUTHOR BE LIABLE FOR ANY
+// SPECIAL, DIRECT, INDIRECT, OR CONSEQUENTIAL DAMAGES OR ANY DAMAGES
+// WHATSOEVER RESULTING FROM LOSS OF USE, DATA OR PROFITS, WHETHER IN AN ACTION
+// OF CONTRACT, NEGLIGENCE OR OTHER TORTIOUS ACTION, ARISING OUT OF OR IN
+// CONNECTION WITH THE USE OR PERFORMANCE OF THIS SOFTWARE.
+
+package subprocess
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+)
+
+// revisions dispatches the vector sets of an algorithm to the handler for
+// their revision, which is given by the top-level "revision" field, for
+// algorithms whose revisions differ in the shape or meaning of their test
+// groups. Revisions without their own handler, including vector sets that
+// don't give one, use fallback.
+type revisions struct {
+	fallback  Primitive
+	revisions map[string]Primitive
+}
+
+// describe describes the fallback handler, and adds the revisions that have
+// handlers of their own.
+func (r *revisions) describe() AlgorithmCapabilities {
+	var caps AlgorithmCapabilities
+	if d, ok := r.fallback.(describer); ok {
+		caps = d.describe()
+	}
+	if caps.Capabilities == nil {
+		caps.Capabilities = make(map[string]any)
+	}
+	var names []string
+	for name := range r.revisions {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	caps.Capabilities["revisions"] = names
+	return caps
+}
+
+func (r *revisions) Process(vectorSet []byte, m Transactable) (any, error) {
+	var parsed struct {
+		Revision string `json:"revision"`
+	}
+	if err := json.Unmarshal(vectorSet, &parsed); err != nil {
+		return nil, err
+	}
+
+	handler, ok := r.revisions[parsed.Revision]
+	if !ok {
+		handler = r.fallback
+	}
+	if handler == nil {
+		return nil, fmt.Errorf("unsupported revision %q", parsed.Revision)
+	}
+	return handler.Process(vectorSet, m)
+}
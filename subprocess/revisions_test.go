@@ -0,0 +1,75 @@
+// Copyright (c) 2026, Google Inc.
+//
+// Permission to use, copy, modify, and/or distribute this software for any
+// purpose with or without fee is hereby granted, provided that the above
+// copyright notice and this permission notice appear in all copies.
+//
+// THE SOFTWARE IS PROVIDED "AS IS" AND THE AUTHOR DISCLAIMS ALL WARRANTIES
+// WITH REGARD TO THIS SOFTWARE INCLUDING ALL IMPLIED WARRANTIES OF
+// MERCHANTABILITY AND FITNESS. IN NO EVENT SHALL THE AUTHOR BE LIABLE FOR ANY
+// SPECIAL, DIRECT, INDIRECT, OR CONSEQUENTIAL DAMAGES OR ANY DAMAGES
+// WHATSOEVER RESULTING FROM LOSS OF USE, DATA OR PROFITS, WHETHER IN AN ACTION
+// OF CONTRACT, NEGLIGENCE OR OTHER TORTIOUS ACTION, ARISING OUT OF OR IN
+// CONNECTION WITH THE USE OR PERFORMANCE OF THIS SOFTWARE.
+
+package subprocess
+
+import (
+	"strings"
+	"testing"
+)
+
+// namedPrimitive answers every vector set with its name.
+type namedPrimitive string
+
+func (n namedPrimitive) Process(vectorSet []byte, m Transactable) (any, error) {
+	return string(n), nil
+}
+
+func TestRevisions(t *testing.T) {
+	r := &revisions{
+		revisions: map[string]Primitive{
+			"1.0":       namedPrimitive("old"),
+			"FIPS186-5": namedPrimitive("new"),
+		},
+	}
+
+	for revision, want := range map[string]string{"1.0": "old", "FIPS186-5": "new"} {
+		result, err := r.Process([]byte(`{"algorithm": "ECDSA", "revision": "`+revision+`", "testGroups": []}`), &fakeTransactable{})
+		if err != nil {
+			t.Fatalf("revision %s: %s", revision, err)
+		}
+		if result != want {
+			t.Errorf("revision %s was handled by %q, want %q", revision, result, want)
+		}
+	}
+
+	if _, err := r.Process([]byte(`{"algorithm": "ECDSA", "revision": "2.0", "testGroups": []}`), &fakeTransactable{}); err == nil {
+		t.Error("unknown revision without a fallback was accepted")
+	}
+	r.fallback = namedPrimitive("fallback")
+	if result, err := r.Process([]byte(`{"algorithm": "ECDSA", "testGroups": []}`), &fakeTransactable{}); err != nil || result != "fallback" {
+		t.Errorf("vector set without a revision gave %v, %v", result, err)
+	}
+}
+
+func TestECDSARevisions(t *testing.T) {
+	const vectorSet = `{"algorithm": "ECDSA", "revision": "REVISION", "mode": "sigGen", "testGroups": [{
+		"tgId": 1, "curve": "P-256", "hashAlg": "SHA2-256", "deterministic": true,
+		"tests": [{"tcId": 1, "message": "0102"}]
+	}]}`
+	ecdsa := Primitives()["ECDSA"]
+
+	// FIPS 186-4 has no deterministic ECDSA, so only the later revision
+	// accepts the group.
+	m := &fakeTransactable{respond: ecdsaDeterministicResponder}
+	if _, err := ecdsa.Process([]byte(strings.Replace(vectorSet, "REVISION", "FIPS186-5", 1)), m); err != nil {
+		t.Fatal(err)
+	}
+	if cmd := m.calls[len(m.calls)-1].cmd; cmd != "DetECDSA/sigGen" {
+		t.Errorf("FIPS186-5 vector set sent %q", cmd)
+	}
+	if _, err := ecdsa.Process([]byte(strings.Replace(vectorSet, "REVISION", "1.0", 1)), &fakeTransactable{respond: ecdsaDeterministicResponder}); err == nil {
+		t.Error("deterministic group in a FIPS 186-4 vector set was accepted")
+	}
+}
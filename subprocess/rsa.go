@@ -15,6 +15,7 @@
 package subprocess
 
 import (
+	"bytes"
 	"encoding/hex"
 	"encoding/json"
 	"fmt"
@@ -36,6 +37,9 @@ type rsaKeyGenGroup struct {
 	ID          uint64          `json:"tgId"`
 	Type        string          `json:"testType"`
 	ModulusBits uint32          `json:"modulo"`
+	PrimeMethod string          `json:"randPQ"`
+	PubExpMode  string          `json:"pubExp"`
+	FixedPubExp string          `json:"fixedPubExp"`
 	Tests       []rsaKeyGenTest `json:"tests"`
 }
 
@@ -67,6 +71,7 @@ type rsaSigGenGroup struct {
 	SigType     string          `json:"sigType"`
 	ModulusBits uint32          `json:"modulo"`
 	Hash        string          `json:"hashAlg"`
+	SaltLen     uint32          `json:"saltLen"`
 	Tests       []rsaSigGenTest `json:"tests"`
 }
 
@@ -117,6 +122,74 @@ type rsaSigVerTestResponse struct {
 	Passed bool   `json:"testPassed"`
 }
 
+type rsaPrimitiveTestVectorSet struct {
+	Groups []rsaPrimitiveGroup `json:"testGroups"`
+}
+
+type rsaPrimitiveGroup struct {
+	ID          uint64             `json:"tgId"`
+	Type        string             `json:"testType"`
+	ModulusBits uint32             `json:"modulo"`
+	N           string             `json:"n"`
+	E           string             `json:"e"`
+	D           string             `json:"d"`
+	Tests       []rsaPrimitiveTest `json:"tests"`
+}
+
+// rsaPrimitiveTest is a test of either the decryption primitive, which takes a
+// ciphertext, or the signature primitive, which takes a message
+// representative.
+type rsaPrimitiveTest struct {
+	ID            uint64 `json:"tcId"`
+	CiphertextHex string `json:"ct"`
+	MessageHex    string `json:"message"`
+}
+
+type rsaPrimitiveTestGroupResponse struct {
+	ID    uint64                     `json:"tgId"`
+	Tests []rsaPrimitiveTestResponse `json:"tests"`
+}
+
+type rsaPrimitiveTestResponse struct {
+	ID           uint64 `json:"tcId"`
+	PlaintextHex string `json:"pt,omitempty"`
+	SignatureHex string `json:"signature,omitempty"`
+	Passed       bool   `json:"testPassed"`
+}
+
+type rsaOAEPTestVectorSet struct {
+	Groups []rsaOAEPGroup `json:"testGroups"`
+}
+
+type rsaOAEPGroup struct {
+	ID          uint64        `json:"tgId"`
+	Type        string        `json:"testType"`
+	ModulusBits uint32        `json:"modulo"`
+	Hash        string        `json:"hashAlg"`
+	MGFHash     string        `json:"mgfHashAlg"`
+	N           string        `json:"n"`
+	E           string        `json:"e"`
+	D           string        `json:"d"`
+	Tests       []rsaOAEPTest `json:"tests"`
+}
+
+type rsaOAEPTest struct {
+	ID            uint64 `json:"tcId"`
+	CiphertextHex string `json:"ct"`
+	LabelHex      string `json:"label"`
+}
+
+type rsaOAEPTestGroupResponse struct {
+	ID    uint64                `json:"tgId"`
+	Tests []rsaOAEPTestResponse `json:"tests"`
+}
+
+type rsaOAEPTestResponse struct {
+	ID           uint64  `json:"tcId"`
+	PlaintextHex *string `json:"pt,omitempty"`
+	Passed       bool    `json:"testPassed"`
+}
+
 func processKeyGen(vectorSet []byte, m Transactable) (any, error) {
 	var parsed rsaKeyGenTestVectorSet
 	if err := json.Unmarshal(vectorSet, &parsed); err != nil {
@@ -134,14 +207,47 @@ func processKeyGen(vectorSet []byte, m Transactable) (any, error) {
 			return nil, fmt.Errorf("RSA KeyGen test group has type %q, but only generation tests (%q) are supported", group.Type, expectedType)
 		}
 
+		switch group.ModulusBits {
+		case 2048, 3072, 4096:
+		default:
+			return nil, fmt.Errorf("RSA KeyGen test group %d has unsupported modulus size %d", group.ID, group.ModulusBits)
+		}
+
+		// These are the prime generation methods of FIPS 186-5, appendix A.1.
+		// The module generates primes with whichever it registered.
+		switch group.PrimeMethod {
+		case "B.3.2", "B.3.3", "B.3.4", "B.3.5", "B.3.6", "":
+		default:
+			return nil, fmt.Errorf("RSA KeyGen test group %d has unknown prime generation method %q", group.ID, group.PrimeMethod)
+		}
+
+		// With a fixed public exponent the module must generate keys using
+		// it.
+		var fixedE []byte
+		switch group.PubExpMode {
+		case "fixed":
+			var err error
+			if fixedE, err = hex.DecodeString(group.FixedPubExp); err != nil || len(fixedE) == 0 {
+				return nil, fmt.Errorf("RSA KeyGen test group %d has invalid fixed public exponent %q", group.ID, group.FixedPubExp)
+			}
+		case "random", "":
+		default:
+			return nil, fmt.Errorf("RSA KeyGen test group %d has unknown public exponent mode %q", group.ID, group.PubExpMode)
+		}
+
 		response := rsaKeyGenTestGroupResponse{
 			ID: group.ID,
 		}
 
 		for _, test := range group.Tests {
 			test := test
+			m := withTestCase(m, "RSA", group.ID, test.ID)
 
 			m.TransactAsync("RSA/keyGen", 5, [][]byte{uint32le(group.ModulusBits)}, func(result [][]byte) error {
+				if fixedE != nil && !bytes.Equal(bytes.TrimLeft(result[0], "\x00"), bytes.TrimLeft(fixedE, "\x00")) {
+					return fmt.Errorf("module wrapper returned public exponent %x for RSA KeyGen test case %d/%d, but the group requires %x", result[0], group.ID, test.ID, fixedE)
+				}
+
 				response.Tests = append(response.Tests, rsaKeyGenTestResponse{
 					ID: test.ID,
 					E:  hex.EncodeToString(result[0]),
@@ -189,15 +295,31 @@ func processSigGen(vectorSet []byte, m Transactable) (any, error) {
 
 		operation := "RSA/sigGen/" + group.Hash + "/" + group.SigType
 
+		// PSS signatures additionally take the salt length, in bytes.
+		var saltLen []byte
+		switch group.SigType {
+		case "pkcs1v1.5":
+		case "pss":
+			saltLen = uint32le(group.SaltLen)
+		default:
+			return nil, fmt.Errorf("RSA SigGen test group %d has unknown signature type %q", group.ID, group.SigType)
+		}
+
 		for _, test := range group.Tests {
 			test := test
+			m := withTestCase(m, "RSA", group.ID, test.ID)
 
 			msg, err := hex.DecodeString(test.MessageHex)
 			if err != nil {
 				return nil, fmt.Errorf("test case %d/%d contains invalid hex: %s", group.ID, test.ID, err)
 			}
 
-			m.TransactAsync(operation, 3, [][]byte{uint32le(group.ModulusBits), msg}, func(result [][]byte) error {
+			args := [][]byte{uint32le(group.ModulusBits), msg}
+			if saltLen != nil {
+				args = append(args, saltLen)
+			}
+
+			m.TransactAsync(operation, 3, args, func(result [][]byte) error {
 				if len(response.N) == 0 {
 					response.N = hex.EncodeToString(result[0])
 					response.E = hex.EncodeToString(result[1])
@@ -259,6 +381,7 @@ func processSigVer(vectorSet []byte, m Transactable) (any, error) {
 
 		for _, test := range group.Tests {
 			test := test
+			m := withTestCase(m, "RSA", group.ID, test.ID)
 			msg, err := hex.DecodeString(test.MessageHex)
 			if err != nil {
 				return nil, fmt.Errorf("test case %d/%d contains invalid hex: %s", group.ID, test.ID, err)
@@ -289,6 +412,179 @@ func processSigVer(vectorSet []byte, m Transactable) (any, error) {
 	return ret, nil
 }
 
+// processPrimitive handles the decryption and signature primitive component
+// tests. Both apply the private key to an input, which must be less than n.
+func processPrimitive(vectorSet []byte, m Transactable, mode string) (any, error) {
+	var parsed rsaPrimitiveTestVectorSet
+	if err := json.Unmarshal(vectorSet, &parsed); err != nil {
+		return nil, err
+	}
+
+	var ret []rsaPrimitiveTestGroupResponse
+
+	for _, group := range parsed.Groups {
+		group := group
+
+		const expectedType = "AFT"
+		if group.Type != expectedType {
+			return nil, fmt.Errorf("RSA %s test group has type %q, but only %q tests are supported", mode, group.Type, expectedType)
+		}
+
+		n, err := hex.DecodeString(group.N)
+		if err != nil {
+			return nil, fmt.Errorf("test group %d contains invalid hex: %s", group.ID, err)
+		}
+		if len(n)*8 != int(group.ModulusBits) {
+			return nil, fmt.Errorf("test group %d has a %d-bit modulus, but specifies %d bits", group.ID, len(n)*8, group.ModulusBits)
+		}
+		e, err := hex.DecodeString(group.E)
+		if err != nil {
+			return nil, fmt.Errorf("test group %d contains invalid hex: %s", group.ID, err)
+		}
+		d, err := hex.DecodeString(group.D)
+		if err != nil {
+			return nil, fmt.Errorf("test group %d contains invalid hex: %s", group.ID, err)
+		}
+
+		response := rsaPrimitiveTestGroupResponse{
+			ID: group.ID,
+		}
+
+		for _, test := range group.Tests {
+			test := test
+			m := withTestCase(m, "RSA", group.ID, test.ID)
+			inputHex := test.CiphertextHex
+			if mode == "signaturePrimitive" {
+				inputHex = test.MessageHex
+			}
+			input, err := hex.DecodeString(inputHex)
+			if err != nil {
+				return nil, fmt.Errorf("test case %d/%d contains invalid hex: %s", group.ID, test.ID, err)
+			}
+
+			// The module reports an input that is not less than n as a
+			// failure, rather than an error.
+			m.TransactAsync("RSA/"+mode, 2, [][]byte{n, e, d, input}, func(result [][]byte) error {
+				if len(result[0]) != 1 || (result[0][0]&0xfe) != 0 {
+					return fmt.Errorf("invalid RSA %s status result from subprocess", mode)
+				}
+
+				testResponse := rsaPrimitiveTestResponse{
+					ID:     test.ID,
+					Passed: result[0][0] == 1,
+				}
+				if testResponse.Passed {
+					if mode == "signaturePrimitive" {
+						testResponse.SignatureHex = hex.EncodeToString(result[1])
+					} else {
+						testResponse.PlaintextHex = hex.EncodeToString(result[1])
+					}
+				}
+				response.Tests = append(response.Tests, testResponse)
+				return nil
+			})
+		}
+
+		m.Barrier(func() {
+			ret = append(ret, response)
+		})
+	}
+
+	if err := m.Flush(); err != nil {
+		return nil, err
+	}
+
+	return ret, nil
+}
+
+func processOAEPDecrypt(vectorSet []byte, m Transactable) (any, error) {
+	var parsed rsaOAEPTestVectorSet
+	if err := json.Unmarshal(vectorSet, &parsed); err != nil {
+		return nil, err
+	}
+
+	var ret []rsaOAEPTestGroupResponse
+
+	for _, group := range parsed.Groups {
+		group := group
+
+		const expectedType = "AFT"
+		if group.Type != expectedType {
+			return nil, fmt.Errorf("RSA OAEP test group has type %q, but only %q tests are supported", group.Type, expectedType)
+		}
+
+		n, err := hex.DecodeString(group.N)
+		if err != nil {
+			return nil, fmt.Errorf("test group %d contains invalid hex: %s", group.ID, err)
+		}
+		if len(n)*8 != int(group.ModulusBits) {
+			return nil, fmt.Errorf("test group %d has a %d-bit modulus, but specifies %d bits", group.ID, len(n)*8, group.ModulusBits)
+		}
+		e, err := hex.DecodeString(group.E)
+		if err != nil {
+			return nil, fmt.Errorf("test group %d contains invalid hex: %s", group.ID, err)
+		}
+		d, err := hex.DecodeString(group.D)
+		if err != nil {
+			return nil, fmt.Errorf("test group %d contains invalid hex: %s", group.ID, err)
+		}
+
+		if len(group.Hash) == 0 {
+			return nil, fmt.Errorf("RSA OAEP test group %d has no hash function", group.ID)
+		}
+		// MGF1 uses the OAEP hash unless told otherwise.
+		mgfHash := group.MGFHash
+		if len(mgfHash) == 0 {
+			mgfHash = group.Hash
+		}
+
+		response := rsaOAEPTestGroupResponse{
+			ID: group.ID,
+		}
+
+		for _, test := range group.Tests {
+			test := test
+			m := withTestCase(m, "RSA", group.ID, test.ID)
+			ct, err := hex.DecodeString(test.CiphertextHex)
+			if err != nil {
+				return nil, fmt.Errorf("test case %d/%d contains invalid hex: %s", group.ID, test.ID, err)
+			}
+			// An absent label is the empty string.
+			label, err := hex.DecodeString(test.LabelHex)
+			if err != nil {
+				return nil, fmt.Errorf("test case %d/%d contains invalid hex: %s", group.ID, test.ID, err)
+			}
+
+			m.TransactAsync("RSA/oaepDecrypt", 2, [][]byte{n, e, d, ct, []byte(group.Hash), []byte(mgfHash), label}, func(result [][]byte) error {
+				if len(result[0]) != 1 || (result[0][0]&0xfe) != 0 {
+					return fmt.Errorf("invalid RSA OAEP status result from subprocess")
+				}
+
+				testResponse := rsaOAEPTestResponse{
+					ID:     test.ID,
+					Passed: result[0][0] == 1,
+				}
+				if testResponse.Passed {
+					plaintextHex := hex.EncodeToString(result[1])
+					testResponse.PlaintextHex = &plaintextHex
+				}
+				response.Tests = append(response.Tests, testResponse)
+				return nil
+			})
+		}
+
+		m.Barrier(func() {
+			ret = append(ret, response)
+		})
+	}
+
+	if err := m.Flush(); err != nil {
+		return nil, err
+	}
+
+	return ret, nil
+}
+
 type rsa struct{}
 
 func (*rsa) Process(vectorSet []byte, m Transactable) (any, error) {
@@ -304,6 +600,10 @@ func (*rsa) Process(vectorSet []byte, m Transactable) (any, error) {
 		return processSigGen(vectorSet, m)
 	case "sigVer":
 		return processSigVer(vectorSet, m)
+	case "decryptionPrimitive", "signaturePrimitive":
+		return processPrimitive(vectorSet, m, parsed.Mode)
+	case "oaepDecrypt":
+		return processOAEPDecrypt(vectorSet, m)
 	default:
 		return nil, fmt.Errorf("Unknown RSA mode %q", parsed.Mode)
 	}
@@ -0,0 +1,196 @@
+// Copyright (c) 2026, Google Inc.
+//
+// Permission to use, copy, modify, and/or distribute this software for any
+// purpose with or without fee is hereby granted, provided that the above
+// copyright notice and this permission notice appear in all copies.
+//
+// THE SOFTWARE IS PROVIDED "AS IS" AND THE AUTHOR DISCLAIMS ALL WARRANTIES
+// WITH REGARD TO THIS SOFTWARE INCLUDING ALL IMPLIED WARRANTIES OF
+// MERCHANTABILITY AND FITNESS. IN NO EVENT SHALL THE AUTHOR BE LIABLE FOR ANY
+// SPECIAL, DIRECT, INDIRECT, OR CONSEQUENTIAL DAMAGES OR ANY DAMAGES
+// WHATSOEVER RESULTING FROM LOSS OF USE, DATA OR PROFITS, WHETHER IN AN ACTION
+// OF CONTRACT, NEGLIGENCE OR OTHER TORTIOUS ACTION, ARISING OUT OF OR IN
+// CONNECTION WITH THE USE OR PERFORMANCE OF THIS SOFTWARE.
+
+package subprocess
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"strings"
+	"testing"
+)
+
+func rsaKeyGenResponder(cmd string, args [][]byte) ([][]byte, error) {
+	return [][]byte{{0x01, 0x00, 0x01}, {0x0b}, {0x0d}, {0x8f}, {0x23}}, nil
+}
+
+func TestRSAKeyGen(t *testing.T) {
+	const vectorSet = `{"mode": "keyGen", "testGroups": [{
+		"tgId": 1, "testType": "GDT", "modulo": 2048, "randPQ": "B.3.3", "pubExp": "fixed", "fixedPubExp": "010001",
+		"tests": [{"tcId": 1}]
+	}, {
+		"tgId": 2, "testType": "GDT", "modulo": 4096, "randPQ": "B.3.6", "pubExp": "random",
+		"tests": [{"tcId": 2}]
+	}]}`
+
+	result, err := (&rsa{}).Process([]byte(vectorSet), &fakeTransactable{respond: rsaKeyGenResponder})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, group := range result.([]rsaKeyGenTestGroupResponse) {
+		out, _ := json.Marshal(group.Tests[0])
+		var fields map[string]any
+		if err := json.Unmarshal(out, &fields); err != nil {
+			t.Fatal(err)
+		}
+		for _, name := range []string{"p", "q", "n", "d", "e"} {
+			if v, ok := fields[name].(string); !ok || len(v) == 0 {
+				t.Errorf("group %d: response %s lacks %q", group.ID, out, name)
+			}
+		}
+	}
+
+	for _, bad := range []string{
+		`{"mode": "keyGen", "testGroups": [{"tgId": 1, "testType": "GDT", "modulo": 1024, "tests": [{"tcId": 1}]}]}`,
+		`{"mode": "keyGen", "testGroups": [{"tgId": 1, "testType": "GDT", "modulo": 2048, "randPQ": "B.3.1", "tests": [{"tcId": 1}]}]}`,
+		`{"mode": "keyGen", "testGroups": [{"tgId": 1, "testType": "GDT", "modulo": 2048, "pubExp": "fixed", "fixedPubExp": "03", "tests": [{"tcId": 1}]}]}`,
+	} {
+		if _, err := (&rsa{}).Process([]byte(bad), &fakeTransactable{respond: rsaKeyGenResponder}); err == nil {
+			t.Errorf("no error for %s", bad)
+		}
+	}
+}
+
+func TestRSASigGenSaltLen(t *testing.T) {
+	// For a 2048-bit modulus and SHA2-256 the maximal salt is 256-32-2 bytes.
+	for _, saltLen := range []uint32{0, 32, 222} {
+		vectorSet := fmt.Sprintf(`{"mode": "sigGen", "testGroups": [{
+			"tgId": 1, "testType": "GDT", "sigType": "pss", "modulo": 2048, "hashAlg": "SHA2-256", "saltLen": %d,
+			"tests": [{"tcId": 1, "message": "00"}]
+		}, {
+			"tgId": 2, "testType": "GDT", "sigType": "pkcs1v1.5", "modulo": 2048, "hashAlg": "SHA2-256",
+			"tests": [{"tcId": 2, "message": "00"}]
+		}]}`, saltLen)
+
+		m := &fakeTransactable{respond: func(cmd string, args [][]byte) ([][]byte, error) {
+			return [][]byte{{0x8f}, {0x03}, {0x5a}}, nil
+		}}
+		result, err := (&rsa{}).Process([]byte(vectorSet), m)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		pss, pkcs1 := m.calls[0], m.calls[1]
+		if pss.cmd != "RSA/sigGen/SHA2-256/pss" || len(pss.args) != 3 || !bytes.Equal(pss.args[2], uint32le(saltLen)) {
+			t.Errorf("salt length %d: PSS call was %q with args %x", saltLen, pss.cmd, pss.args)
+		}
+		if pkcs1.cmd != "RSA/sigGen/SHA2-256/pkcs1v1.5" || len(pkcs1.args) != 2 {
+			t.Errorf("salt length %d: PKCS#1 call was %q with args %x", saltLen, pkcs1.cmd, pkcs1.args)
+		}
+
+		for _, group := range result.([]rsaSigGenTestGroupResponse) {
+			if group.N != "8f" || group.E != "03" || group.Tests[0].Sig != "5a" {
+				t.Errorf("salt length %d: group %d has n %q, e %q and signature %q", saltLen, group.ID, group.N, group.E, group.Tests[0].Sig)
+			}
+		}
+	}
+}
+
+// rsaPrimitiveResponder fails when the input is not less than the modulus and
+// otherwise returns the input unchanged.
+func rsaPrimitiveResponder(cmd string, args [][]byte) ([][]byte, error) {
+	n, input := new(big.Int).SetBytes(args[0]), new(big.Int).SetBytes(args[3])
+	if input.Cmp(n) >= 0 {
+		return [][]byte{{0}, nil}, nil
+	}
+	return [][]byte{{1}, args[3]}, nil
+}
+
+func TestRSADecryptionPrimitive(t *testing.T) {
+	n := "c5" + strings.Repeat("00", 254) + "b1"
+	nMinusOne := "c5" + strings.Repeat("00", 254) + "b0"
+	vectorSet := `{"mode": "decryptionPrimitive", "testGroups": [{
+		"tgId": 1, "testType": "AFT", "modulo": 2048, "n": "` + n + `", "e": "010001", "d": "0123",
+		"tests": [{"tcId": 1, "ct": "` + nMinusOne + `"}, {"tcId": 2, "ct": "` + n + `"}]
+	}]}`
+
+	m := &fakeTransactable{respond: rsaPrimitiveResponder}
+	result, err := (&rsa{}).Process([]byte(vectorSet), m)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if m.calls[0].cmd != "RSA/decryptionPrimitive" {
+		t.Errorf("got command %q", m.calls[0].cmd)
+	}
+
+	tests := result.([]rsaPrimitiveTestGroupResponse)[0].Tests
+	if !tests[0].Passed || tests[0].PlaintextHex != nMinusOne {
+		t.Errorf("ciphertext n-1 gave %+v", tests[0])
+	}
+	if tests[1].Passed || len(tests[1].PlaintextHex) != 0 {
+		t.Errorf("ciphertext n gave %+v", tests[1])
+	}
+}
+
+func TestRSASignaturePrimitive(t *testing.T) {
+	n := "c5" + strings.Repeat("00", 254) + "b1"
+	vectorSet := `{"mode": "signaturePrimitive", "testGroups": [{
+		"tgId": 1, "testType": "AFT", "modulo": 2048, "n": "` + n + `", "e": "010001", "d": "0123",
+		"tests": [{"tcId": 1, "message": "0102"}, {"tcId": 2, "message": "` + n + `"}]
+	}]}`
+
+	m := &fakeTransactable{respond: rsaPrimitiveResponder}
+	result, err := (&rsa{}).Process([]byte(vectorSet), m)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if m.calls[0].cmd != "RSA/signaturePrimitive" {
+		t.Errorf("got command %q", m.calls[0].cmd)
+	}
+
+	tests := result.([]rsaPrimitiveTestGroupResponse)[0].Tests
+	if !tests[0].Passed || tests[0].SignatureHex != "0102" || len(tests[0].PlaintextHex) != 0 {
+		t.Errorf("in-range message gave %+v", tests[0])
+	}
+	if tests[1].Passed || len(tests[1].SignatureHex) != 0 {
+		t.Errorf("message n gave %+v", tests[1])
+	}
+}
+
+func TestRSAOAEPDecrypt(t *testing.T) {
+	n := "c5" + strings.Repeat("00", 254) + "b1"
+	vectorSet := `{"mode": "oaepDecrypt", "testGroups": [{
+		"tgId": 1, "testType": "AFT", "modulo": 2048, "hashAlg": "SHA2-256", "mgfHashAlg": "SHA-1",
+		"n": "` + n + `", "e": "010001", "d": "0123",
+		"tests": [{"tcId": 1, "ct": "aa"}, {"tcId": 2, "ct": "bb", "label": "6c6162656c"}]
+	}]}`
+
+	// The "ciphertexts" were produced with an empty label.
+	m := &fakeTransactable{respond: func(cmd string, args [][]byte) ([][]byte, error) {
+		if len(args[6]) != 0 {
+			return [][]byte{{0}, nil}, nil
+		}
+		return [][]byte{{1}, {}}, nil
+	}}
+	result, err := (&rsa{}).Process([]byte(vectorSet), m)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	call := m.calls[0]
+	if call.cmd != "RSA/oaepDecrypt" || len(call.args) != 7 || string(call.args[4]) != "SHA2-256" || string(call.args[5]) != "SHA-1" {
+		t.Errorf("got call %q with args %q", call.cmd, call.args)
+	}
+
+	tests := result.([]rsaOAEPTestGroupResponse)[0].Tests
+	if !tests[0].Passed || tests[0].PlaintextHex == nil || *tests[0].PlaintextHex != "" {
+		t.Errorf("decryption with the empty label gave %+v", tests[0])
+	}
+	if tests[1].Passed || tests[1].PlaintextHex != nil {
+		t.Errorf("decryption with the wrong label gave %+v", tests[1])
+	}
+}
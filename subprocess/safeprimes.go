@@ -0,0 +1,360 @@
+// Copyright (c) 2026, Google Inc.
+//
+// Permission to use, copy, modify, and/or distribute this software for any
+// purpose with or without fee is hereby granted, provided that the above
+// copyright notice and this permission notice appear in all copies.
+//
+// THE SOFTWARE IS PROVIDED "AS IS" AND THE AUTHOR DISCLAIMS ALL WARRANTIES
+// WITH REGARD TO THIS SOFTWARE INCLUDING ALL IMPLIED WARRANTIES OF
+// MERCHANTABILITY AND FITNESS. IN NO EVENT SHALL THE AUTHOR BE LIABLE FOR ANY
+// SPECIAL, DIRECT, INDIRECT, OR CONSEQUENTIAL DAMAGES OR ANY DAMAGES
+// WHATSOEVER RESULTING FROM LOSS OF USE, DATA OR PROFITS, WHETHER IN AN ACTION
+// OF CONTRACT, NEGLIGENCE OR OTHER TORTIOUS ACTION, ARISING OUT OF OR IN
+// CONNECTION WITH THE USE OR PERFORMANCE OF THIS SOFTWARE.
+
+package subprocess
+
+import (
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"math/big"
+)
+
+// safePrimeGroups maps the names of the safe-prime groups from SP 800-56A
+// rev3, appendix D, to the hex encoding of their modulus. These are the
+// RFC 7919 ffdhe groups and the RFC 3526 MODP groups.
+var safePrimeGroups = map[string]string{
+	"ffdhe2048": "ffffffffffffffffadf85458a2bb4a9aafdc5620273d3cf1d8b9c583ce2d3695" +
+		"a9e13641146433fbcc939dce249b3ef97d2fe363630c75d8f681b202aec4617a" +
+		"d3df1ed5d5fd65612433f51f5f066ed0856365553ded1af3b557135e7f57c935" +
+		"984f0c70e0e68b77e2a689daf3efe8721df158a136ade73530acca4f483a797a" +
+		"bc0ab182b324fb61d108a94bb2c8e3fbb96adab760d7f4681d4f42a3de394df4" +
+		"ae56ede76372bb190b07a7c8ee0a6d709e02fce1cdf7e2ecc03404cd28342f61" +
+		"9172fe9ce98583ff8e4f1232eef28183c3fe3b1b4c6fad733bb5fcbc2ec22005" +
+		"c58ef1837d1683b2c6f34a26c1b2effa886b423861285c97ffffffffffffffff",
+	"ffdhe3072": "ffffffffffffffffadf85458a2bb4a9aafdc5620273d3cf1d8b9c583ce2d3695" +
+		"a9e13641146433fbcc939dce249b3ef97d2fe363630c75d8f681b202aec4617a" +
+		"d3df1ed5d5fd65612433f51f5f066ed0856365553ded1af3b557135e7f57c935" +
+		"984f0c70e0e68b77e2a689daf3efe8721df158a136ade73530acca4f483a797a" +
+		"bc0ab182b324fb61d108a94bb2c8e3fbb96adab760d7f4681d4f42a3de394df4" +
+		"ae56ede76372bb190b07a7c8ee0a6d709e02fce1cdf7e2ecc03404cd28342f61" +
+		"9172fe9ce98583ff8e4f1232eef28183c3fe3b1b4c6fad733bb5fcbc2ec22005" +
+		"c58ef1837d1683b2c6f34a26c1b2effa886b4238611fcfdcde355b3b6519035b" +
+		"bc34f4def99c023861b46fc9d6e6c9077ad91d2691f7f7ee598cb0fac186d91c" +
+		"aefe130985139270b4130c93bc437944f4fd4452e2d74dd364f2e21e71f54bff" +
+		"5cae82ab9c9df69ee86d2bc522363a0dabc521979b0deada1dbf9a42d5c4484e" +
+		"0abcd06bfa53ddef3c1b20ee3fd59d7c25e41d2b66c62e37ffffffffffffffff",
+	"ffdhe4096": "ffffffffffffffffadf85458a2bb4a9aafdc5620273d3cf1d8b9c583ce2d3695" +
+		"a9e13641146433fbcc939dce249b3ef97d2fe363630c75d8f681b202aec4617a" +
+		"d3df1ed5d5fd65612433f51f5f066ed0856365553ded1af3b557135e7f57c935" +
+		"984f0c70e0e68b77e2a689daf3efe8721df158a136ade73530acca4f483a797a" +
+		"bc0ab182b324fb61d108a94bb2c8e3fbb96adab760d7f4681d4f42a3de394df4" +
+		"ae56ede76372bb190b07a7c8ee0a6d709e02fce1cdf7e2ecc03404cd28342f61" +
+		"9172fe9ce98583ff8e4f1232eef28183c3fe3b1b4c6fad733bb5fcbc2ec22005" +
+		"c58ef1837d1683b2c6f34a26c1b2effa886b4238611fcfdcde355b3b6519035b" +
+		"bc34f4def99c023861b46fc9d6e6c9077ad91d2691f7f7ee598cb0fac186d91c" +
+		"aefe130985139270b4130c93bc437944f4fd4452e2d74dd364f2e21e71f54bff" +
+		"5cae82ab9c9df69ee86d2bc522363a0dabc521979b0deada1dbf9a42d5c4484e" +
+		"0abcd06bfa53ddef3c1b20ee3fd59d7c25e41d2b669e1ef16e6f52c3164df4fb" +
+		"7930e9e4e58857b6ac7d5f42d69f6d187763cf1d5503400487f55ba57e31cc7a" +
+		"7135c886efb4318aed6a1e012d9e6832a907600a918130c46dc778f971ad0038" +
+		"092999a333cb8b7a1a1db93d7140003c2a4ecea9f98d0acc0a8291cdcec97dcf" +
+		"8ec9b55a7f88a46b4db5a851f44182e1c68a007e5e655f6affffffffffffffff",
+	"ffdhe6144": "ffffffffffffffffadf85458a2bb4a9aafdc5620273d3cf1d8b9c583ce2d3695" +
+		"a9e13641146433fbcc939dce249b3ef97d2fe363630c75d8f681b202aec4617a" +
+		"d3df1ed5d5fd65612433f51f5f066ed0856365553ded1af3b557135e7f57c935" +
+		"984f0c70e0e68b77e2a689daf3efe8721df158a136ade73530acca4f483a797a" +
+		"bc0ab182b324fb61d108a94bb2c8e3fbb96adab760d7f4681d4f42a3de394df4" +
+		"ae56ede76372bb190b07a7c8ee0a6d709e02fce1cdf7e2ecc03404cd28342f61" +
+		"9172fe9ce98583ff8e4f1232eef28183c3fe3b1b4c6fad733bb5fcbc2ec22005" +
+		"c58ef1837d1683b2c6f34a26c1b2effa886b4238611fcfdcde355b3b6519035b" +
+		"bc34f4def99c023861b46fc9d6e6c9077ad91d2691f7f7ee598cb0fac186d91c" +
+		"aefe130985139270b4130c93bc437944f4fd4452e2d74dd364f2e21e71f54bff" +
+		"5cae82ab9c9df69ee86d2bc522363a0dabc521979b0deada1dbf9a42d5c4484e" +
+		"0abcd06bfa53ddef3c1b20ee3fd59d7c25e41d2b669e1ef16e6f52c3164df4fb" +
+		"7930e9e4e58857b6ac7d5f42d69f6d187763cf1d5503400487f55ba57e31cc7a" +
+		"7135c886efb4318aed6a1e012d9e6832a907600a918130c46dc778f971ad0038" +
+		"092999a333cb8b7a1a1db93d7140003c2a4ecea9f98d0acc0a8291cdcec97dcf" +
+		"8ec9b55a7f88a46b4db5a851f44182e1c68a007e5e0dd9020bfd64b645036c7a" +
+		"4e677d2c38532a3a23ba4442caf53ea63bb454329b7624c8917bdd64b1c0fd4c" +
+		"b38e8c334c701c3acdad0657fccfec719b1f5c3e4e46041f388147fb4cfdb477" +
+		"a52471f7a9a96910b855322edb6340d8a00ef092350511e30abec1fff9e3a26e" +
+		"7fb29f8c183023c3587e38da0077d9b4763e4e4b94b2bbc194c6651e77caf992" +
+		"eeaac0232a281bf6b3a739c1226116820ae8db5847a67cbef9c9091b462d538c" +
+		"d72b03746ae77f5e62292c311562a846505dc82db854338ae49f5235c95b9117" +
+		"8ccf2dd5cacef403ec9d1810c6272b045b3b71f9dc6b80d63fdd4a8e9adb1e69" +
+		"62a69526d43161c1a41d570d7938dad4a40e329cd0e40e65ffffffffffffffff",
+	"ffdhe8192": "ffffffffffffffffadf85458a2bb4a9aafdc5620273d3cf1d8b9c583ce2d3695" +
+		"a9e13641146433fbcc939dce249b3ef97d2fe363630c75d8f681b202aec4617a" +
+		"d3df1ed5d5fd65612433f51f5f066ed0856365553ded1af3b557135e7f57c935" +
+		"984f0c70e0e68b77e2a689daf3efe8721df158a136ade73530acca4f483a797a" +
+		"bc0ab182b324fb61d108a94bb2c8e3fbb96adab760d7f4681d4f42a3de394df4" +
+		"ae56ede76372bb190b07a7c8ee0a6d709e02fce1cdf7e2ecc03404cd28342f61" +
+		"9172fe9ce98583ff8e4f1232eef28183c3fe3b1b4c6fad733bb5fcbc2ec22005" +
+		"c58ef1837d1683b2c6f34a26c1b2effa886b4238611fcfdcde355b3b6519035b" +
+		"bc34f4def99c023861b46fc9d6e6c9077ad91d2691f7f7ee598cb0fac186d91c" +
+		"aefe130985139270b4130c93bc437944f4fd4452e2d74dd364f2e21e71f54bff" +
+		"5cae82ab9c9df69ee86d2bc522363a0dabc521979b0deada1dbf9a42d5c4484e" +
+		"0abcd06bfa53ddef3c1b20ee3fd59d7c25e41d2b669e1ef16e6f52c3164df4fb" +
+		"7930e9e4e58857b6ac7d5f42d69f6d187763cf1d5503400487f55ba57e31cc7a" +
+		"7135c886efb4318aed6a1e012d9e6832a907600a918130c46dc778f971ad0038" +
+		"092999a333cb8b7a1a1db93d7140003c2a4ecea9f98d0acc0a8291cdcec97dcf" +
+		"8ec9b55a7f88a46b4db5a851f44182e1c68a007e5e0dd9020bfd64b645036c7a" +
+		"4e677d2c38532a3a23ba4442caf53ea63bb454329b7624c8917bdd64b1c0fd4c" +
+		"b38e8c334c701c3acdad0657fccfec719b1f5c3e4e46041f388147fb4cfdb477" +
+		"a52471f7a9a96910b855322edb6340d8a00ef092350511e30abec1fff9e3a26e" +
+		"7fb29f8c183023c3587e38da0077d9b4763e4e4b94b2bbc194c6651e77caf992" +
+		"eeaac0232a281bf6b3a739c1226116820ae8db5847a67cbef9c9091b462d538c" +
+		"d72b03746ae77f5e62292c311562a846505dc82db854338ae49f5235c95b9117" +
+		"8ccf2dd5cacef403ec9d1810c6272b045b3b71f9dc6b80d63fdd4a8e9adb1e69" +
+		"62a69526d43161c1a41d570d7938dad4a40e329ccff46aaa36ad004cf600c838" +
+		"1e425a31d951ae64fdb23fcec9509d43687feb69edd1cc5e0b8cc3bdf64b10ef" +
+		"86b63142a3ab8829555b2f747c932665cb2c0f1cc01bd70229388839d2af05e4" +
+		"54504ac78b7582822846c0ba35c35f5c59160cc046fd8251541fc68c9c86b022" +
+		"bb7099876a460e7451a8a93109703fee1c217e6c3826e52c51aa691e0e423cfc" +
+		"99e9e31650c1217b624816cdad9a95f9d5b8019488d9c0a0a1fe3075a577e231" +
+		"83f81d4a3f2fa4571efc8ce0ba8a4fe8b6855dfe72b0a66eded2fbabfbe58a30" +
+		"fafabe1c5d71a87e2f741ef8c1fe86fea6bbfde530677f0d97d11d49f7a8443d" +
+		"0822e506a9f4614e011e2a94838ff88cd68c8bb7c5c6424cffffffffffffffff",
+	"MODP-2048": "ffffffffffffffffc90fdaa22168c234c4c6628b80dc1cd129024e088a67cc74" +
+		"020bbea63b139b22514a08798e3404ddef9519b3cd3a431b302b0a6df25f1437" +
+		"4fe1356d6d51c245e485b576625e7ec6f44c42e9a637ed6b0bff5cb6f406b7ed" +
+		"ee386bfb5a899fa5ae9f24117c4b1fe649286651ece45b3dc2007cb8a163bf05" +
+		"98da48361c55d39a69163fa8fd24cf5f83655d23dca3ad961c62f356208552bb" +
+		"9ed529077096966d670c354e4abc9804f1746c08ca18217c32905e462e36ce3b" +
+		"e39e772c180e86039b2783a2ec07a28fb5c55df06f4c52c9de2bcbf695581718" +
+		"3995497cea956ae515d2261898fa051015728e5a8aacaa68ffffffffffffffff",
+	"MODP-3072": "ffffffffffffffffc90fdaa22168c234c4c6628b80dc1cd129024e088a67cc74" +
+		"020bbea63b139b22514a08798e3404ddef9519b3cd3a431b302b0a6df25f1437" +
+		"4fe1356d6d51c245e485b576625e7ec6f44c42e9a637ed6b0bff5cb6f406b7ed" +
+		"ee386bfb5a899fa5ae9f24117c4b1fe649286651ece45b3dc2007cb8a163bf05" +
+		"98da48361c55d39a69163fa8fd24cf5f83655d23dca3ad961c62f356208552bb" +
+		"9ed529077096966d670c354e4abc9804f1746c08ca18217c32905e462e36ce3b" +
+		"e39e772c180e86039b2783a2ec07a28fb5c55df06f4c52c9de2bcbf695581718" +
+		"3995497cea956ae515d2261898fa051015728e5a8aaac42dad33170d04507a33" +
+		"a85521abdf1cba64ecfb850458dbef0a8aea71575d060c7db3970f85a6e1e4c7" +
+		"abf5ae8cdb0933d71e8c94e04a25619dcee3d2261ad2ee6bf12ffa06d98a0864" +
+		"d87602733ec86a64521f2b18177b200cbbe117577a615d6c770988c0bad946e2" +
+		"08e24fa074e5ab3143db5bfce0fd108e4b82d120a93ad2caffffffffffffffff",
+	"MODP-4096": "ffffffffffffffffc90fdaa22168c234c4c6628b80dc1cd129024e088a67cc74" +
+		"020bbea63b139b22514a08798e3404ddef9519b3cd3a431b302b0a6df25f1437" +
+		"4fe1356d6d51c245e485b576625e7ec6f44c42e9a637ed6b0bff5cb6f406b7ed" +
+		"ee386bfb5a899fa5ae9f24117c4b1fe649286651ece45b3dc2007cb8a163bf05" +
+		"98da48361c55d39a69163fa8fd24cf5f83655d23dca3ad961c62f356208552bb" +
+		"9ed529077096966d670c354e4abc9804f1746c08ca18217c32905e462e36ce3b" +
+		"e39e772c180e86039b2783a2ec07a28fb5c55df06f4c52c9de2bcbf695581718" +
+		"3995497cea956ae515d2261898fa051015728e5a8aaac42dad33170d04507a33" +
+		"a85521abdf1cba64ecfb850458dbef0a8aea71575d060c7db3970f85a6e1e4c7" +
+		"abf5ae8cdb0933d71e8c94e04a25619dcee3d2261ad2ee6bf12ffa06d98a0864" +
+		"d87602733ec86a64521f2b18177b200cbbe117577a615d6c770988c0bad946e2" +
+		"08e24fa074e5ab3143db5bfce0fd108e4b82d120a92108011a723c12a787e6d7" +
+		"88719a10bdba5b2699c327186af4e23c1a946834b6150bda2583e9ca2ad44ce8" +
+		"dbbbc2db04de8ef92e8efc141fbecaa6287c59474e6bc05d99b2964fa090c3a2" +
+		"233ba186515be7ed1f612970cee2d7afb81bdd762170481cd0069127d5b05aa9" +
+		"93b4ea988d8fddc186ffb7dc90a6c08f4df435c934063199ffffffffffffffff",
+	"MODP-6144": "ffffffffffffffffc90fdaa22168c234c4c6628b80dc1cd129024e088a67cc74" +
+		"020bbea63b139b22514a08798e3404ddef9519b3cd3a431b302b0a6df25f1437" +
+		"4fe1356d6d51c245e485b576625e7ec6f44c42e9a637ed6b0bff5cb6f406b7ed" +
+		"ee386bfb5a899fa5ae9f24117c4b1fe649286651ece45b3dc2007cb8a163bf05" +
+		"98da48361c55d39a69163fa8fd24cf5f83655d23dca3ad961c62f356208552bb" +
+		"9ed529077096966d670c354e4abc9804f1746c08ca18217c32905e462e36ce3b" +
+		"e39e772c180e86039b2783a2ec07a28fb5c55df06f4c52c9de2bcbf695581718" +
+		"3995497cea956ae515d2261898fa051015728e5a8aaac42dad33170d04507a33" +
+		"a85521abdf1cba64ecfb850458dbef0a8aea71575d060c7db3970f85a6e1e4c7" +
+		"abf5ae8cdb0933d71e8c94e04a25619dcee3d2261ad2ee6bf12ffa06d98a0864" +
+		"d87602733ec86a64521f2b18177b200cbbe117577a615d6c770988c0bad946e2" +
+		"08e24fa074e5ab3143db5bfce0fd108e4b82d120a92108011a723c12a787e6d7" +
+		"88719a10bdba5b2699c327186af4e23c1a946834b6150bda2583e9ca2ad44ce8" +
+		"dbbbc2db04de8ef92e8efc141fbecaa6287c59474e6bc05d99b2964fa090c3a2" +
+		"233ba186515be7ed1f612970cee2d7afb81bdd762170481cd0069127d5b05aa9" +
+		"93b4ea988d8fddc186ffb7dc90a6c08f4df435c93402849236c3fab4d27c7026" +
+		"c1d4dcb2602646dec9751e763dba37bdf8ff9406ad9e530ee5db382f413001ae" +
+		"b06a53ed9027d831179727b0865a8918da3edbebcf9b14ed44ce6cbaced4bb1b" +
+		"db7f1447e6cc254b332051512bd7af426fb8f401378cd2bf5983ca01c64b92ec" +
+		"f032ea15d1721d03f482d7ce6e74fef6d55e702f46980c82b5a84031900b1c9e" +
+		"59e7c97fbec7e8f323a97a7e36cc88be0f1d45b7ff585ac54bd407b22b4154aa" +
+		"cc8f6d7ebf48e1d814cc5ed20f8037e0a79715eef29be32806a1d58bb7c5da76" +
+		"f550aa3d8a1fbff0eb19ccb1a313d55cda56c9ec2ef29632387fe8d76e3c0468" +
+		"043e8f663f4860ee12bf2d5b0b7474d6e694f91e6dcc4024ffffffffffffffff",
+	"MODP-8192": "ffffffffffffffffc90fdaa22168c234c4c6628b80dc1cd129024e088a67cc74" +
+		"020bbea63b139b22514a08798e3404ddef9519b3cd3a431b302b0a6df25f1437" +
+		"4fe1356d6d51c245e485b576625e7ec6f44c42e9a637ed6b0bff5cb6f406b7ed" +
+		"ee386bfb5a899fa5ae9f24117c4b1fe649286651ece45b3dc2007cb8a163bf05" +
+		"98da48361c55d39a69163fa8fd24cf5f83655d23dca3ad961c62f356208552bb" +
+		"9ed529077096966d670c354e4abc9804f1746c08ca18217c32905e462e36ce3b" +
+		"e39e772c180e86039b2783a2ec07a28fb5c55df06f4c52c9de2bcbf695581718" +
+		"3995497cea956ae515d2261898fa051015728e5a8aaac42dad33170d04507a33" +
+		"a85521abdf1cba64ecfb850458dbef0a8aea71575d060c7db3970f85a6e1e4c7" +
+		"abf5ae8cdb0933d71e8c94e04a25619dcee3d2261ad2ee6bf12ffa06d98a0864" +
+		"d87602733ec86a64521f2b18177b200cbbe117577a615d6c770988c0bad946e2" +
+		"08e24fa074e5ab3143db5bfce0fd108e4b82d120a92108011a723c12a787e6d7" +
+		"88719a10bdba5b2699c327186af4e23c1a946834b6150bda2583e9ca2ad44ce8" +
+		"dbbbc2db04de8ef92e8efc141fbecaa6287c59474e6bc05d99b2964fa090c3a2" +
+		"233ba186515be7ed1f612970cee2d7afb81bdd762170481cd0069127d5b05aa9" +
+		"93b4ea988d8fddc186ffb7dc90a6c08f4df435c93402849236c3fab4d27c7026" +
+		"c1d4dcb2602646dec9751e763dba37bdf8ff9406ad9e530ee5db382f413001ae" +
+		"b06a53ed9027d831179727b0865a8918da3edbebcf9b14ed44ce6cbaced4bb1b" +
+		"db7f1447e6cc254b332051512bd7af426fb8f401378cd2bf5983ca01c64b92ec" +
+		"f032ea15d1721d03f482d7ce6e74fef6d55e702f46980c82b5a84031900b1c9e" +
+		"59e7c97fbec7e8f323a97a7e36cc88be0f1d45b7ff585ac54bd407b22b4154aa" +
+		"cc8f6d7ebf48e1d814cc5ed20f8037e0a79715eef29be32806a1d58bb7c5da76" +
+		"f550aa3d8a1fbff0eb19ccb1a313d55cda56c9ec2ef29632387fe8d76e3c0468" +
+		"043e8f663f4860ee12bf2d5b0b7474d6e694f91e6dbe115974a3926f12fee5e4" +
+		"38777cb6a932df8cd8bec4d073b931ba3bc832b68d9dd300741fa7bf8afc47ed" +
+		"2576f6936ba424663aab639c5ae4f5683423b4742bf1c978238f16cbe39d652d" +
+		"e3fdb8befc848ad922222e04a4037c0713eb57a81a23f0c73473fc646cea306b" +
+		"4bcbc8862f8385ddfa9d4b7fa2c087e879683303ed5bdd3a062b3cf5b3a278a6" +
+		"6d2a13f83f44f82ddf310ee074ab6a364597e899a0255dc164f31cc50846851d" +
+		"f9ab48195ded7ea1b1d510bd7ee74d73faf36bc31ecfa268359046f4eb879f92" +
+		"4009438b481c6cd7889a002ed5ee382bc9190da6fc026e479558e4475677e9aa" +
+		"9e3050e2765694dfc81f56e880b96e7160c980dd98edd3dfffffffffffffffff",
+}
+
+// safePrimeParameters returns the domain parameters p, q and g of the named
+// safe-prime group. For all such groups q is (p-1)/2 and g is two.
+func safePrimeParameters(name string) (p, q, g []byte, err error) {
+	pHex, ok := safePrimeGroups[name]
+	if !ok {
+		return nil, nil, nil, fmt.Errorf("unknown safe-prime group %q", name)
+	}
+	if p, err = hex.DecodeString(pHex); err != nil {
+		return nil, nil, nil, err
+	}
+
+	qInt := new(big.Int).SetBytes(p)
+	qInt.Rsh(qInt, 1)
+	return p, qInt.Bytes(), []byte{2}, nil
+}
+
+// safePrimePublicKeyValid performs the full public-key validation of
+// SP 800-56A rev3, section 5.6.2.3.1: y must be in [2, p-2] and in the
+// subgroup of order q.
+func safePrimePublicKeyValid(p, q, y *big.Int) bool {
+	pMinusOne := new(big.Int).Sub(p, big.NewInt(1))
+	if y.Cmp(big.NewInt(1)) <= 0 || y.Cmp(pMinusOne) >= 0 {
+		return false
+	}
+	return new(big.Int).Exp(y, q, p).Cmp(big.NewInt(1)) == 0
+}
+
+// The following structures reflect the JSON of ACVP safe primes tests. See
+// https://pages.nist.gov/ACVP/draft-hammett-acvp-kas-ffc-sp800-56ar3.html
+
+type safePrimesTestVectorSet struct {
+	Groups []safePrimesTestGroup `json:"testGroups"`
+	Mode   string                `json:"mode"`
+}
+
+type safePrimesTestGroup struct {
+	ID    uint64 `json:"tgId"`
+	Type  string `json:"testType"`
+	Group string `json:"safePrimeGroup"`
+	Tests []struct {
+		ID   uint64 `json:"tcId"`
+		XHex string `json:"x,omitempty"`
+		YHex string `json:"y,omitempty"`
+	} `json:"tests"`
+}
+
+type safePrimesTestGroupResponse struct {
+	ID    uint64                   `json:"tgId"`
+	Tests []safePrimesTestResponse `json:"tests"`
+}
+
+type safePrimesTestResponse struct {
+	ID     uint64 `json:"tcId"`
+	XHex   string `json:"x,omitempty"`
+	YHex   string `json:"y,omitempty"`
+	Passed *bool  `json:"testPassed,omitempty"` // using pointer so value is not omitted when it is false
+}
+
+// safePrimes implements an ACVP algorithm by making requests to the
+// subprocess to generate and verify key pairs in the safe-prime groups.
+type safePrimes struct{}
+
+func (s *safePrimes) Process(vectorSet []byte, m Transactable) (any, error) {
+	var parsed safePrimesTestVectorSet
+	if err := json.Unmarshal(vectorSet, &parsed); err != nil {
+		return nil, err
+	}
+
+	var ret []safePrimesTestGroupResponse
+	for _, group := range parsed.Groups {
+		group := group
+		response := safePrimesTestGroupResponse{ID: group.ID}
+
+		if group.Type != "AFT" {
+			return nil, fmt.Errorf("unknown test type %q in test group %d", group.Type, group.ID)
+		}
+		p, q, g, err := safePrimeParameters(group.Group)
+		if err != nil {
+			return nil, fmt.Errorf("test group %d: %s", group.ID, err)
+		}
+		pInt := new(big.Int).SetBytes(p)
+		qInt := new(big.Int).SetBytes(q)
+		gInt := new(big.Int).SetBytes(g)
+
+		for _, test := range group.Tests {
+			test := test
+			m := withTestCase(m, "safePrimes", group.ID, test.ID)
+			testResp := safePrimesTestResponse{ID: test.ID}
+
+			switch parsed.Mode {
+			case "keyGen":
+				m.TransactAsync("safePrimes/keyGen", 2, [][]byte{p, q, g}, func(result [][]byte) error {
+					// Check the generated pair, since the key pair is
+					// otherwise only validated by the ACVP server.
+					x := new(big.Int).SetBytes(result[0])
+					y := new(big.Int).SetBytes(result[1])
+					if x.Sign() <= 0 || x.Cmp(qInt) >= 0 {
+						return fmt.Errorf("key generation for test case %d/%d returned a private key outside [1, q-1]", group.ID, test.ID)
+					}
+					if !safePrimePublicKeyValid(pInt, qInt, y) {
+						return fmt.Errorf("key generation for test case %d/%d returned an invalid public key", group.ID, test.ID)
+					}
+					if new(big.Int).Exp(gInt, x, pInt).Cmp(y) != 0 {
+						return fmt.Errorf("key generation for test case %d/%d returned a public key that doesn't match the private key", group.ID, test.ID)
+					}
+					testResp.XHex = hex.EncodeToString(result[0])
+					testResp.YHex = hex.EncodeToString(result[1])
+					response.Tests = append(response.Tests, testResp)
+					return nil
+				})
+
+			case "keyVer":
+				x, err := hex.DecodeString(test.XHex)
+				if err != nil {
+					return nil, fmt.Errorf("failed to decode x in test case %d/%d: %s", group.ID, test.ID, err)
+				}
+				y, err := hex.DecodeString(test.YHex)
+				if err != nil {
+					return nil, fmt.Errorf("failed to decode y in test case %d/%d: %s", group.ID, test.ID, err)
+				}
+
+				m.TransactAsync("safePrimes/keyVer", 1, [][]byte{p, q, g, x, y}, func(result [][]byte) error {
+					if len(result[0]) != 1 || (result[0][0]&0xfe) != 0 {
+						return fmt.Errorf("key verification returned unexpected result: %q", result[0])
+					}
+					passed := result[0][0] == 1
+					testResp.Passed = &passed
+					response.Tests = append(response.Tests, testResp)
+					return nil
+				})
+
+			default:
+				return nil, fmt.Errorf("invalid mode %q in safe primes vector set", parsed.Mode)
+			}
+		}
+
+		m.Barrier(func() {
+			ret = append(ret, response)
+		})
+	}
+
+	if err := m.Flush(); err != nil {
+		return nil, err
+	}
+
+	return ret, nil
+}
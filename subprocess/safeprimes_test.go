@@ -0,0 +1,119 @@
+// Copyright (c) 2026, Google Inc.
+//
+// Permission to use, copy, modify, and/or distribute this software for any
+// purpose with or without fee is hereby granted, provided that the above
+// copyright notice and this permission notice appear in all copies.
+//
+// THE SOFTWARE IS PROVIDED "AS IS" AND THE AUTHOR DISCLAIMS ALL WARRANTIES
+// WITH REGARD TO THIS SOFTWARE INCLUDING ALL IMPLIED WARRANTIES OF
+// MERCHANTABILITY AND FITNESS. IN NO EVENT SHALL THE AUTHOR BE LIABLE FOR ANY
+// SPECIAL, DIRECT, INDIRECT, OR CONSEQUENTIAL DAMAGES OR ANY DAMAGES
+// WHATSOEVER RESULTING FROM LOSS OF USE, DATA OR PROFITS, WHETHER IN AN ACTION
+// OF CONTRACT, NEGLIGENCE OR OTHER TORTIOUS ACTION, ARISING OUT OF OR IN
+// CONNECTION WITH THE USE OR PERFORMANCE OF THIS SOFTWARE.
+
+package subprocess
+
+import (
+	"encoding/hex"
+	"fmt"
+	"math/big"
+	"testing"
+)
+
+// safePrimesVerifier answers safePrimes/keyVer using safePrimePublicKeyValid
+// and checks that the public key matches the private key.
+func safePrimesVerifier(cmd string, args [][]byte) ([][]byte, error) {
+	if cmd != "safePrimes/keyVer" {
+		return nil, fmt.Errorf("unexpected command %q", cmd)
+	}
+	p, q, g := new(big.Int).SetBytes(args[0]), new(big.Int).SetBytes(args[1]), new(big.Int).SetBytes(args[2])
+	x, y := new(big.Int).SetBytes(args[3]), new(big.Int).SetBytes(args[4])
+	valid := safePrimePublicKeyValid(p, q, y) && new(big.Int).Exp(g, x, p).Cmp(y) == 0
+	return [][]byte{{boolToByte(valid)}}, nil
+}
+
+func TestSafePrimesKeyVer(t *testing.T) {
+	pBytes, qBytes, _, err := safePrimeParameters("ffdhe2048")
+	if err != nil {
+		t.Fatal(err)
+	}
+	p, q := new(big.Int).SetBytes(pBytes), new(big.Int).SetBytes(qBytes)
+	one := big.NewInt(1)
+
+	// Find the smallest element outside the subgroup of order q.
+	notInSubgroup := big.NewInt(2)
+	for safePrimePublicKeyValid(p, q, notInSubgroup) {
+		notInSubgroup.Add(notInSubgroup, one)
+	}
+
+	x := big.NewInt(12345)
+	for _, test := range []struct {
+		name  string
+		y     *big.Int
+		valid bool
+	}{
+		{"valid", new(big.Int).Exp(big.NewInt(2), x, p), true},
+		{"one", one, false},
+		{"p-1", new(big.Int).Sub(p, one), false},
+		{"p", p, false},
+		{"not in subgroup", notInSubgroup, false},
+	} {
+		vectorSet := fmt.Sprintf(`{"algorithm": "safePrimes", "mode": "keyVer", "testGroups": [{
+			"tgId": 1, "testType": "AFT", "safePrimeGroup": "ffdhe2048",
+			"tests": [{"tcId": 1, "x": %q, "y": %q}]
+		}]}`, hex.EncodeToString(x.Bytes()), hex.EncodeToString(test.y.Bytes()))
+
+		result, err := new(safePrimes).Process([]byte(vectorSet), &fakeTransactable{respond: safePrimesVerifier})
+		if err != nil {
+			t.Fatalf("%s: %s", test.name, err)
+		}
+		resp := result.([]safePrimesTestGroupResponse)[0].Tests[0]
+		if resp.Passed == nil || *resp.Passed != test.valid {
+			t.Errorf("%s: unexpected response: %+v", test.name, resp)
+		}
+	}
+}
+
+func TestSafePrimesKeyGen(t *testing.T) {
+	pBytes, _, _, err := safePrimeParameters("MODP-2048")
+	if err != nil {
+		t.Fatal(err)
+	}
+	p := new(big.Int).SetBytes(pBytes)
+	x := big.NewInt(12345)
+	y := new(big.Int).Exp(big.NewInt(2), x, p)
+
+	const vectorSet = `{"algorithm": "safePrimes", "mode": "keyGen", "testGroups": [{
+		"tgId": 1, "testType": "AFT", "safePrimeGroup": "MODP-2048", "tests": [{"tcId": 1}]
+	}]}`
+
+	for _, test := range []struct {
+		name  string
+		x, y  *big.Int
+		valid bool
+	}{
+		{"valid", x, y, true},
+		{"mismatched", new(big.Int).Add(x, big.NewInt(1)), y, false},
+		{"zero private key", new(big.Int), big.NewInt(1), false},
+		{"public key of one", x, big.NewInt(1), false},
+		{"public key of p-1", x, new(big.Int).Sub(p, big.NewInt(1)), false},
+	} {
+		respond := func(cmd string, args [][]byte) ([][]byte, error) {
+			return [][]byte{test.x.Bytes(), test.y.Bytes()}, nil
+		}
+		result, err := new(safePrimes).Process([]byte(vectorSet), &fakeTransactable{respond: respond})
+		if !test.valid {
+			if err == nil {
+				t.Errorf("%s: key pair was accepted", test.name)
+			}
+			continue
+		}
+		if err != nil {
+			t.Fatalf("%s: %s", test.name, err)
+		}
+		if resp := result.([]safePrimesTestGroupResponse)[0].Tests[0]; resp.YHex != hex.EncodeToString(y.Bytes()) {
+			t.Errorf("%s: unexpected response: %+v", test.name, resp)
+		}
+	}
+}
@@ -29,11 +29,12 @@ type shakeTestVectorSet struct {
 }
 
 type shakeTestGroup struct {
-	ID            uint64 `json:"tgId"`
-	Type          string `json:"testType"`
-	MaxOutLenBits uint32 `json:"maxOutLen"`
-	MinOutLenBits uint32 `json:"minOutLen"`
-	Tests         []struct {
+	ID              uint64 `json:"tgId"`
+	Type            string `json:"testType"`
+	MaxOutLenBits   uint32 `json:"maxOutLen"`
+	MinOutLenBits   uint32 `json:"minOutLen"`
+	OutLenIncrement uint32 `json:"outLenIncrement"`
+	Tests           []struct {
 		ID           uint64 `json:"tcId"`
 		BitLength    uint64 `json:"len"`
 		BitOutLength uint32 `json:"outLen"`
@@ -47,14 +48,20 @@ type shakeTestGroupResponse struct {
 }
 
 type shakeTestResponse struct {
-	ID         uint64           `json:"tcId"`
-	DigestHex  string           `json:"md,omitempty"`
-	MCTResults []shakeMCTResult `json:"resultsArray,omitempty"`
+	ID         uint64         `json:"tcId"`
+	DigestHex  string         `json:"md,omitempty"`
+	MCTResults []xofMCTResult `json:"resultsArray,omitempty"`
 }
 
-type shakeMCTResult struct {
+// xofMCTResult is an entry in the resultsArray of the MCT of an XOF, which
+// gives the length of each output, in bits, alongside it.
+type xofMCTResult struct {
 	DigestHex string `json:"md"`
-	OutputLen uint32 `json:"outLen,omitempty"`
+	OutputLen uint32 `json:"outLen"`
+}
+
+func newXOFMCTResult(digest []byte) xofMCTResult {
+	return xofMCTResult{DigestHex: hex.EncodeToString(digest), OutputLen: uint32(len(digest) * 8)}
 }
 
 // shake implements an ACVP algorithm by making requests to the
@@ -67,6 +74,39 @@ type shake struct {
 	size int
 }
 
+// checkOutLen returns an error if outLen, in bits, is outside of the range of
+// output lengths that a test group declares. Groups that don't give a range,
+// as AFT groups usually don't, have a maximum of zero and any length is
+// accepted. The increment, if any, counts from the minimum.
+func checkOutLen(outLen, min, max, increment uint32) error {
+	if max == 0 {
+		return nil
+	}
+	if outLen < min || outLen > max {
+		return fmt.Errorf("output length %d is outside of the group's range %d-%d", outLen, min, max)
+	}
+	if increment != 0 && (outLen-min)%increment != 0 {
+		return fmt.Errorf("output length %d is not %d plus a multiple of the group's increment %d", outLen, min, increment)
+	}
+	return nil
+}
+
+// shakeMCTNextOutLen returns the output length, in bytes, that follows digest
+// in the SHAKE MCT. See
+// https://pages.nist.gov/ACVP/draft-celi-acvp-sha3.html#name-shake-monte-carlo-test
+func shakeMCTNextOutLen(digest []byte, minOutLen, maxOutLen uint32) uint32 {
+	outLenRange := maxOutLen - minOutLen + 1
+	rightmostBits := uint32(binary.BigEndian.Uint16(digest[len(digest)-2:]))
+	return minOutLen + rightmostBits%outLenRange
+}
+
+func (h *shake) describe() AlgorithmCapabilities {
+	return AlgorithmCapabilities{
+		TestTypes:    []string{"AFT", "VOT", "MCT"},
+		Capabilities: map[string]any{"xof": true},
+	}
+}
+
 func (h *shake) Process(vectorSet []byte, m Transactable) (any, error) {
 	var parsed shakeTestVectorSet
 	if err := json.Unmarshal(vectorSet, &parsed); err != nil {
@@ -85,6 +125,7 @@ func (h *shake) Process(vectorSet []byte, m Transactable) (any, error) {
 
 		for _, test := range group.Tests {
 			test := test
+			m := withTestCase(m, h.algo, group.ID, test.ID)
 
 			if uint64(len(test.MsgHex))*4 != test.BitLength {
 				return nil, fmt.Errorf("test case %d/%d contains hex message of length %d but specifies a bit length of %d", group.ID, test.ID, len(test.MsgHex), test.BitLength)
@@ -114,6 +155,9 @@ func (h *shake) Process(vectorSet []byte, m Transactable) (any, error) {
 				})
 			case "VOT":
 				// "The VOTs SHALL produce varying digest sizes based on the capabilities of the IUT"
+				if err := checkOutLen(test.BitOutLength, group.MinOutLenBits, group.MaxOutLenBits, group.OutLenIncrement); err != nil {
+					return nil, fmt.Errorf("test case %d/%d: %s", group.ID, test.ID, err)
+				}
 				m.TransactAsync(h.algo+"/VOT", 1, [][]byte{msg, uint32le(test.BitOutLength / 8)}, func(result [][]byte) error {
 					response.Tests = append(response.Tests, shakeTestResponse{
 						ID:        test.ID,
@@ -131,23 +175,42 @@ func (h *shake) Process(vectorSet []byte, m Transactable) (any, error) {
 				if group.MaxOutLenBits%8 != 0 {
 					return nil, fmt.Errorf("MCT test group %d has max output length %d - fractional bytes not supported", group.ID, group.MaxOutLenBits)
 				}
+				// The next output length is taken from the last 16 bits
+				// of each digest.
+				if group.MinOutLenBits < 16 || group.MinOutLenBits > group.MaxOutLenBits {
+					return nil, fmt.Errorf("MCT test group %d has invalid output lengths %d-%d", group.ID, group.MinOutLenBits, group.MaxOutLenBits)
+				}
 
 				digest := msg
-				minOutLenBytes := uint32le(group.MinOutLenBits / 8)
-				maxOutLenBytes := uint32le(group.MaxOutLenBits / 8)
-				outputLenBytes := uint32le(group.MaxOutLenBits / 8)
+				minOutLen := group.MinOutLenBits / 8
+				maxOutLen := group.MaxOutLenBits / 8
+				minOutLenBytes := uint32le(minOutLen)
+				maxOutLenBytes := uint32le(maxOutLen)
+				outputLen := maxOutLen
 
 				for i := 0; i < 100; i++ {
-					args := [][]byte{digest, minOutLenBytes, maxOutLenBytes, outputLenBytes}
+					args := [][]byte{digest, minOutLenBytes, maxOutLenBytes, uint32le(outputLen)}
 					result, err := m.Transact(h.algo+"/MCT", 2, args...)
 					if err != nil {
-						panic(h.algo + " mct operation failed: " + err.Error())
+						return nil, fmt.Errorf("%s MCT operation failed for test case %d/%d: %s", h.algo, group.ID, test.ID, err)
+					}
+					if len(result[1]) != 4 {
+						return nil, fmt.Errorf("%s MCT operation for test case %d/%d returned a %d-byte output length", h.algo, group.ID, test.ID, len(result[1]))
 					}
 
+					// The module returns the last of its 1000 digests, which
+					// has the output length of the previous iteration, and
+					// the output length derived from it.
 					digest = result[0]
-					outputLenBytes = uint32le(binary.LittleEndian.Uint32(result[1]))
-					mctResult := shakeMCTResult{DigestHex: hex.EncodeToString(digest), OutputLen: uint32(len(digest) * 8)}
-					testResponse.MCTResults = append(testResponse.MCTResults, mctResult)
+					nextOutputLen := binary.LittleEndian.Uint32(result[1])
+					if len(digest) < int(minOutLen) || len(digest) > int(maxOutLen) {
+						return nil, fmt.Errorf("%s MCT operation for test case %d/%d returned a %d-byte digest, outside of %d-%d bytes", h.algo, group.ID, test.ID, len(digest), minOutLen, maxOutLen)
+					}
+					if want := shakeMCTNextOutLen(digest, minOutLen, maxOutLen); nextOutputLen != want {
+						return nil, fmt.Errorf("%s MCT operation for test case %d/%d returned output length %d, but the digest gives %d", h.algo, group.ID, test.ID, nextOutputLen, want)
+					}
+					outputLen = nextOutputLen
+					testResponse.MCTResults = append(testResponse.MCTResults, newXOFMCTResult(digest))
 				}
 
 				response.Tests = append(response.Tests, testResponse)
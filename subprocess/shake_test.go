@@ -0,0 +1,173 @@
+// Copyright (c) 2026, Google Inc.
+//
+// Permission to use, copy, modify, and/or distribute this software for any
+// purpose with or without fee is hereby granted, provided that the above
+// copyright notice and this permission notice appear in all copies.
+//
+// THE SOFTWARE IS PROVIDED "AS IS" AND THE AUTHOR DISCLAIMS ALL WARRANTIES
+// WITH REGARD TO THIS SOFTWARE INCLUDING ALL IMPLIED WARRANTIES OF
+// MERCHANTABILITY AND FITNESS. IN NO EVENT SHALL THE AUTHOR BE LIABLE FOR ANY
+// SPECIAL, DIRECT, INDIRECT, OR CONSEQUENTIAL DAMAGES OR ANY DAMAGES
+// WHATSOEVER RESULTING FROM LOSS OF USE, DATA OR PROFITS, WHETHER IN AN ACTION
+// OF CONTRACT, NEGLIGENCE OR OTHER TORTIOUS ACTION, ARISING OUT OF OR IN
+// CONNECTION WITH THE USE OR PERFORMANCE OF THIS SOFTWARE.
+
+package subprocess
+
+import (
+	"bytes"
+	"encoding/binary"
+	"encoding/hex"
+	"encoding/json"
+	"testing"
+
+	"golang.org/x/crypto/sha3"
+)
+
+// shake128MCTResponder runs the inner loop of the SHAKE-128 MCT, as in
+// https://pages.nist.gov/ACVP/draft-celi-acvp-sha3.html#name-shake-monte-carlo-test
+// If skew is non-zero, it is added to the returned output length.
+func shake128MCTResponder(skew uint32) func(string, [][]byte) ([][]byte, error) {
+	return func(cmd string, args [][]byte) ([][]byte, error) {
+		minOutLen := binary.LittleEndian.Uint32(args[1])
+		maxOutLen := binary.LittleEndian.Uint32(args[2])
+		outLen := binary.LittleEndian.Uint32(args[3])
+
+		md := args[0]
+		for i := 0; i < 1000; i++ {
+			msg := make([]byte, 16)
+			copy(msg, md)
+			md = make([]byte, outLen)
+			sha3.ShakeSum128(md, msg)
+			rightmost := uint32(md[len(md)-2])<<8 | uint32(md[len(md)-1])
+			outLen = minOutLen + rightmost%(maxOutLen-minOutLen+1)
+		}
+		return [][]byte{md, uint32le(outLen + skew)}, nil
+	}
+}
+
+const shakeMCTVectorSet = `{"testGroups": [{
+	"tgId": 1,
+	"testType": "MCT",
+	"minOutLen": 16,
+	"maxOutLen": 1024,
+	"tests": [{"tcId": 1, "len": 128, "msg": "000102030405060708090a0b0c0d0e0f"}]
+}]}`
+
+func TestShakeMCT(t *testing.T) {
+	m := &fakeTransactable{respond: shake128MCTResponder(0)}
+	result, err := (&shake{"SHAKE-128", 16}).Process([]byte(shakeMCTVectorSet), m)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(m.calls) != 100 {
+		t.Fatalf("got %d MCT calls, want 100", len(m.calls))
+	}
+
+	resultBytes, err := json.Marshal(result)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var groups []struct {
+		Tests []struct {
+			Results []struct {
+				MD     string `json:"md"`
+				OutLen uint32 `json:"outLen"`
+			} `json:"resultsArray"`
+		} `json:"tests"`
+	}
+	if err := json.Unmarshal(resultBytes, &groups); err != nil {
+		t.Fatal(err)
+	}
+	results := groups[0].Tests[0].Results
+	if len(results) != 100 {
+		t.Fatalf("got %d results, want 100", len(results))
+	}
+
+	// Each request carries the output length that follows the previous
+	// digest.
+	outLen := uint32(1024 / 8)
+	for i, r := range results {
+		md, err := hex.DecodeString(r.MD)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if r.OutLen != uint32(len(md))*8 {
+			t.Errorf("result %d: outLen is %d for a %d-byte digest", i, r.OutLen, len(md))
+		}
+		if got := binary.LittleEndian.Uint32(m.calls[i].args[3]); got != outLen {
+			t.Errorf("call %d: got output length %d, want %d", i, got, outLen)
+		}
+		rightmost := uint32(md[len(md)-2])<<8 | uint32(md[len(md)-1])
+		outLen = 2 + rightmost%(128-2+1)
+	}
+}
+
+func TestShakeMCTWrongOutLen(t *testing.T) {
+	m := &fakeTransactable{respond: shake128MCTResponder(1)}
+	if _, err := (&shake{"SHAKE-128", 16}).Process([]byte(shakeMCTVectorSet), m); err == nil {
+		t.Fatal("wrong output length was accepted")
+	}
+	if len(m.calls) != 1 {
+		t.Errorf("got %d MCT calls, want processing to stop after 1", len(m.calls))
+	}
+}
+
+// xofMCTResponder answers the MCT commands of SHAKE, cSHAKE and
+// ParallelHash with a digest of the requested length and the output length
+// that it gives. numResults is the number of results that the command has.
+func xofMCTResponder(numResults int) func(string, [][]byte) ([][]byte, error) {
+	return func(cmd string, args [][]byte) ([][]byte, error) {
+		minOutLen := binary.LittleEndian.Uint32(args[1])
+		maxOutLen := binary.LittleEndian.Uint32(args[2])
+		digest := bytes.Repeat([]byte{0xaa}, int(binary.LittleEndian.Uint32(args[3])))
+		result := [][]byte{digest, uint32le(shakeMCTNextOutLen(digest, minOutLen, maxOutLen))}
+		for len(result) < numResults {
+			result = append(result, nil)
+		}
+		return result, nil
+	}
+}
+
+func TestXOFMCTResultsArray(t *testing.T) {
+	const group = `"tgId": 1, "testType": "MCT", "minOutLen": 16, "maxOutLen": 32, "outLenIncrement": 8, "blockSize": 8`
+	const test = `"tcId": 1, "len": 16, "msg": "0001", "functionName": "", "customization": ""`
+	// The first output has the maximum length, and later ones take it from
+	// the last 16 bits of the output before.
+	const golden = `[{"md":"aaaaaaaa","outLen":32},{"md":"aaaaaa","outLen":24},{"md":"aaaaaa","outLen":24}]`
+
+	for _, h := range []struct {
+		name       string
+		prim       Primitive
+		numResults int
+	}{
+		{"SHAKE-128", &shake{"SHAKE-128", 16}, 2},
+		{"cSHAKE-128", &cShake{"cSHAKE-128"}, 3},
+		{"ParallelHash-128", &parallelHash{"ParallelHash-128"}, 2},
+	} {
+		vectorSet := `{"testGroups": [{` + group + `, "tests": [{` + test + `}]}]}`
+		result, err := h.prim.Process([]byte(vectorSet), &fakeTransactable{respond: xofMCTResponder(h.numResults)})
+		if err != nil {
+			t.Fatalf("%s: %s", h.name, err)
+		}
+		resultJSON, err := json.Marshal(result)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var parsed []struct {
+			Tests []struct {
+				Results []json.RawMessage `json:"resultsArray"`
+			} `json:"tests"`
+		}
+		if err := json.Unmarshal(resultJSON, &parsed); err != nil {
+			t.Fatal(err)
+		}
+		results := parsed[0].Tests[0].Results
+		if len(results) != 100 {
+			t.Fatalf("%s: got %d results, want 100", h.name, len(results))
+		}
+		if got, _ := json.Marshal(results[:3]); string(got) != golden {
+			t.Errorf("%s: got resultsArray starting %s, want %s", h.name, got, golden)
+		}
+	}
+}
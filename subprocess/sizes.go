@@ -0,0 +1,106 @@
+// Copyright (c) 2026, Google Inc.
+//
+// Permission to use, copy, modify, and/or distribute this software for any
+// purpose with or without fee is hereby granted, provided that the above
+// copyright notice and this permission notice appear in all copies.
+//
+// THE SOFTWARE IS PROVIDED "AS IS" AND THE AUTHOR DISCLAIMS ALL WARRANTIES
+// WITH REGARD TO THIS SOFTWARE INCLUDING ALL IMPLIED WARRANTIES OF
+// MERCHANTABILITY AND FITNESS. IN NO EVENT SHALL THE AUTHOR BE LIABLE FOR ANY
+// SPECIAL, DIRECT, INDIRECT, OR CONSEQUENTIAL DAMAGES OR ANY DAMAGES
+// WHATSOEVER RESULTING FROM LOSS OF USE, DATA OR PROFITS, WHETHER IN AN ACTION
+// OF CONTRACT, NEGLIGENCE OR OTHER TORTIOUS ACTION, ARISING OUT OF OR IN
+// CONNECTION WITH THE USE OR PERFORMANCE OF THIS SOFTWARE.
+
+package subprocess
+
+import (
+	"encoding/json"
+	"fmt"
+	"slices"
+)
+
+// messageLengthFields are the names of the fields, in test groups and test
+// cases, that give the length of a message or output in bits.
+var messageLengthFields = map[string]bool{
+	"len":        true,
+	"msgLen":     true,
+	"payloadLen": true,
+	"outLen":     true,
+	"maxOutLen":  true,
+	"macLen":     true,
+}
+
+// SetMaxMessageSize limits the messages and outputs of the vector sets that m
+// processes to n bytes. A vector set with a test group or test case that
+// gives a longer hex value, or a longer length in one of the usual length
+// fields, is refused before anything is decoded or sent to the
+// modulewrapper, so that a corrupt vector set can't cause huge allocations. A
+// limit of zero or less, the default, means no limit. Call SetMaxMessageSize
+// before using m.
+func (m *Subprocess) SetMaxMessageSize(n int) {
+	m.maxMessageBytes = n
+}
+
+// checkMessageSizes returns an error naming the first test group or test
+// case in vectorSet with a value or length of more than maxBytes bytes, if
+// maxBytes is positive.
+func checkMessageSizes(vectorSet []byte, maxBytes int) error {
+	if maxBytes <= 0 {
+		return nil
+	}
+	var parsed struct {
+		Groups []map[string]json.RawMessage `json:"testGroups"`
+	}
+	if err := json.Unmarshal(vectorSet, &parsed); err != nil {
+		return nil
+	}
+
+	for _, group := range parsed.Groups {
+		var groupID uint64
+		json.Unmarshal(group["tgId"], &groupID)
+		if err := checkFieldSizes(group, maxBytes); err != nil {
+			return fmt.Errorf("test group %d: %s", groupID, err)
+		}
+
+		var tests []map[string]json.RawMessage
+		if err := json.Unmarshal(group["tests"], &tests); err != nil {
+			continue
+		}
+		for _, test := range tests {
+			var testID uint64
+			json.Unmarshal(test["tcId"], &testID)
+			if err := checkFieldSizes(test, maxBytes); err != nil {
+				return fmt.Errorf("test case %d/%d: %s", groupID, testID, err)
+			}
+		}
+	}
+	return nil
+}
+
+// checkFieldSizes checks the string and length fields of a test group or
+// test case against maxBytes, in order of name. Strings are assumed to be
+// hex, and are measured without being decoded.
+func checkFieldSizes(fields map[string]json.RawMessage, maxBytes int) error {
+	names := make([]string, 0, len(fields))
+	for name := range fields {
+		names = append(names, name)
+	}
+	slices.Sort(names)
+
+	for _, name := range names {
+		value := fields[name]
+		if messageLengthFields[name] {
+			var bits uint64
+			if err := json.Unmarshal(value, &bits); err == nil && bits > 8*uint64(maxBytes) {
+				return fmt.Errorf("%s of %d bits is more than the limit of %d bytes", name, bits, maxBytes)
+			}
+		} else if len(value) > 0 && value[0] == '"' {
+			// The length of a JSON string is at least that of its contents.
+			if n := (len(value) - 2) / 2; n > maxBytes {
+				return fmt.Errorf("%d-byte %s is more than the limit of %d bytes", n, name, maxBytes)
+			}
+		}
+	}
+	return nil
+}
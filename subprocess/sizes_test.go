@@ -0,0 +1,58 @@
+// Copyright (c) 2026, Google Inc.
+//
+// Permission to use, copy, modify, and/or distribute this software for any
+// purpose with or without fee is hereby granted, provided that the above
+// copyright notice and this permission notice appear in all copies.
+//
+// THE SOFTWARE IS PROVIDED "AS IS" AND THE AUTHOR DISCLAIMS ALL WARRANTIES
+// WITH REGARD TO THIS SOFTWARE INCLUDING ALL IMPLIED WARRANTIES OF
+// MERCHANTABILITY AND FITNESS. IN NO EVENT SHALL THE AUTHOR BE LIABLE FOR ANY
+// SPECIAL, DIRECT, INDIRECT, OR CONSEQUENTIAL DAMAGES OR ANY DAMAGES
+// WHATSOEVER RESULTING FROM LOSS OF USE, DATA OR PROFITS, WHETHER IN AN ACTION
+// OF CONTRACT, NEGLIGENCE OR OTHER TORTIOUS ACTION, ARISING OUT OF OR IN
+// CONNECTION WITH THE USE OR PERFORMANCE OF THIS SOFTWARE.
+
+package subprocess
+
+import (
+	"encoding/binary"
+	"strings"
+	"testing"
+)
+
+func TestMaxMessageSize(t *testing.T) {
+	var calls int
+	m := pipeModule(t, 0, func(cmd string, args [][]byte) ([][]byte, error) {
+		calls++
+		return [][]byte{make([]byte, binary.LittleEndian.Uint32(args[1]))}, nil
+	})
+	defer m.Close()
+	m.SetMaxMessageSize(8)
+
+	if _, err := m.Process("cSHAKE-128", []byte(cShakeAFTVectorSet)); err != nil {
+		t.Fatalf("vector set within the limit failed: %s", err)
+	}
+	if calls != 2 {
+		t.Fatalf("got %d commands, want 2", calls)
+	}
+	calls = 0
+
+	for _, test := range []struct {
+		from, to, want string
+	}{
+		{`"len": 8, "msg": "02"`, `"len": 72, "msg": "` + strings.Repeat("02", 9) + `"`, "test case 1/2: len of 72 bits is more than the limit of 8 bytes"},
+		{`"msg": "02"`, `"msg": "` + strings.Repeat("02", 9) + `"`, "test case 1/2: 9-byte msg is more than the limit of 8 bytes"},
+		{`"len": 8, "msg": "02"`, `"len": 1099511627776, "msg": "02"`, "test case 1/2: len of 1099511627776 bits is more than the limit of 8 bytes"},
+		{`"outLen": 64`, `"outLen": 4294967295`, "test case 1/2: outLen of 4294967295 bits is more than the limit of 8 bytes"},
+		{`"tgId": 1,`, `"tgId": 1, "maxOutLen": 65536,`, "test group 1: maxOutLen of 65536 bits is more than the limit of 8 bytes"},
+	} {
+		vectorSet := strings.Replace(cShakeAFTVectorSet, test.from, test.to, 1)
+		_, err := m.Process("cSHAKE-128", []byte(vectorSet))
+		if err == nil || err.Error() != test.want {
+			t.Errorf("got error %v, want %q", err, test.want)
+		}
+	}
+	if calls != 0 {
+		t.Errorf("oversized vector sets sent %d commands", calls)
+	}
+}
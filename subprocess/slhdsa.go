@@ -0,0 +1,265 @@
+// Copyright (c) 2026, Google Inc.
+//
+// Permission to use, copy, modify, and/or distribute this software for any
+// purpose with or without fee is hereby granted, provided that the above
+// copyright notice and this permission notice appear in all copies.
+//
+// THE SOFTWARE IS PROVIDED "AS IS" AND THE AUTHOR DISCLAIMS ALL WARRANTIES
+// WITH REGARD TO THIS SOFTWARE INCLUDING ALL IMPLIED WARRANTIES OF
+// MERCHANTABILITY AND FITNESS. IN NO EVENT SHALL THE AUTHOR BE LIABLE FOR ANY
+// SPECIAL, DIRECT, INDIRECT, OR CONSEQUENTIAL DAMAGES OR ANY DAMAGES
+// WHATSOEVER RESULTING FROM LOSS OF USE, DATA OR PROFITS, WHETHER IN AN ACTION
+// OF CONTRACT, NEGLIGENCE OR OTHER TORTIOUS ACTION, ARISING OUT OF OR IN
+// CONNECTION WITH THE USE OR PERFORMANCE OF THIS SOFTWARE.
+
+package subprocess
+
+import (
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+)
+
+// The following structures reflect the JSON of ACVP SLH-DSA tests. See
+// https://pages.nist.gov/ACVP/draft-celi-acvp-slh-dsa.html
+
+type slhdsaTestVectorSet struct {
+	Groups []slhdsaTestGroup `json:"testGroups"`
+	Mode   string            `json:"mode"`
+}
+
+type slhdsaTestGroup struct {
+	ID           uint64 `json:"tgId"`
+	Type         string `json:"testType"`
+	ParameterSet string `json:"parameterSet"`
+	// Deterministic is set for sigGen groups where the signing randomness is
+	// the public seed, rather than given by the test.
+	Deterministic bool `json:"deterministic"`
+	// SignatureInterface is "external" (or absent) for the FIPS 205
+	// interface that takes a context string. The internal interface isn't
+	// supported.
+	SignatureInterface string `json:"signatureInterface"`
+	// PreHash is "pure" (or absent) for SLH-DSA and "preHash" for
+	// HashSLH-DSA.
+	PreHash string `json:"preHash"`
+	Tests   []struct {
+		ID         uint64 `json:"tcId"`
+		SKSeedHex  string `json:"skSeed,omitempty"`
+		SKPRFHex   string `json:"skPrf,omitempty"`
+		PKSeedHex  string `json:"pkSeed,omitempty"`
+		PKHex      string `json:"pk,omitempty"`
+		SKHex      string `json:"sk,omitempty"`
+		MsgHex     string `json:"message,omitempty"`
+		RandHex    string `json:"additionalRandomness,omitempty"`
+		ContextHex string `json:"context,omitempty"`
+		HashAlg    string `json:"hashAlg,omitempty"`
+		SigHex     string `json:"signature,omitempty"`
+	} `json:"tests"`
+}
+
+type slhdsaTestGroupResponse struct {
+	ID    uint64               `json:"tgId"`
+	Tests []slhdsaTestResponse `json:"tests"`
+}
+
+type slhdsaTestResponse struct {
+	ID     uint64 `json:"tcId"`
+	PKHex  string `json:"pk,omitempty"`
+	SKHex  string `json:"sk,omitempty"`
+	SigHex string `json:"signature,omitempty"`
+	Passed *bool  `json:"testPassed,omitempty"` // using pointer so value is not omitted when it is false
+}
+
+// slhdsaSizes gives the security parameter, n, and the signature length, in
+// bytes, of each SLH-DSA parameter set. Public keys are 2n bytes and private
+// keys 4n bytes. See FIPS 205, section 11.
+var slhdsaSizes = map[string]struct{ n, sig int }{
+	"SLH-DSA-SHA2-128s":  {16, 7856},
+	"SLH-DSA-SHA2-128f":  {16, 17088},
+	"SLH-DSA-SHA2-192s":  {24, 16224},
+	"SLH-DSA-SHA2-192f":  {24, 35664},
+	"SLH-DSA-SHA2-256s":  {32, 29792},
+	"SLH-DSA-SHA2-256f":  {32, 49856},
+	"SLH-DSA-SHAKE-128s": {16, 7856},
+	"SLH-DSA-SHAKE-128f": {16, 17088},
+	"SLH-DSA-SHAKE-192s": {24, 16224},
+	"SLH-DSA-SHAKE-192f": {24, 35664},
+	"SLH-DSA-SHAKE-256s": {32, 29792},
+	"SLH-DSA-SHAKE-256f": {32, 49856},
+}
+
+// slhdsa implements an ACVP algorithm by making requests to the subprocess to
+// generate SLH-DSA keys and to generate and verify signatures.
+type slhdsa struct{}
+
+func (s *slhdsa) Process(vectorSet []byte, m Transactable) (any, error) {
+	var parsed slhdsaTestVectorSet
+	if err := json.Unmarshal(vectorSet, &parsed); err != nil {
+		return nil, err
+	}
+
+	var ret []slhdsaTestGroupResponse
+	for _, group := range parsed.Groups {
+		group := group
+		response := slhdsaTestGroupResponse{ID: group.ID}
+
+		sizes, ok := slhdsaSizes[group.ParameterSet]
+		if !ok {
+			return nil, fmt.Errorf("invalid parameter set %q in test group %d", group.ParameterSet, group.ID)
+		}
+		if group.SignatureInterface != "" && group.SignatureInterface != "external" {
+			return nil, fmt.Errorf("signature interface %q in test group %d not supported", group.SignatureInterface, group.ID)
+		}
+		var preHash bool
+		switch group.PreHash {
+		case "", "pure":
+		case "preHash":
+			preHash = true
+		default:
+			return nil, fmt.Errorf("unknown preHash value %q in test group %d", group.PreHash, group.ID)
+		}
+
+		for _, test := range group.Tests {
+			test := test
+			m := withTestCase(m, "SLH-DSA", group.ID, test.ID)
+			testResp := slhdsaTestResponse{ID: test.ID}
+
+			var context []byte
+			var hashAlg string
+			if parsed.Mode == "sigGen" || parsed.Mode == "sigVer" {
+				var err error
+				if context, err = hex.DecodeString(test.ContextHex); err != nil {
+					return nil, fmt.Errorf("failed to decode context in test case %d/%d: %s", group.ID, test.ID, err)
+				}
+				// FIPS 205, algorithms 22 to 25.
+				if len(context) > 255 {
+					return nil, fmt.Errorf("context length %d exceeds the maximum of 255 in test case %d/%d", len(context), group.ID, test.ID)
+				}
+				if preHash {
+					// HashSLH-DSA allows the same hash functions as
+					// HashML-DSA.
+					if !mldsaPreHashes[test.HashAlg] {
+						return nil, fmt.Errorf("unsupported pre-hash %q in test case %d/%d", test.HashAlg, group.ID, test.ID)
+					}
+					hashAlg = test.HashAlg
+				}
+			}
+
+			switch parsed.Mode {
+			case "keyGen":
+				if group.Type != "AFT" {
+					return nil, fmt.Errorf("unknown test type %q in keyGen test group %d", group.Type, group.ID)
+				}
+				var seeds [][]byte
+				for _, seed := range []struct{ name, hex string }{{"skSeed", test.SKSeedHex}, {"skPrf", test.SKPRFHex}, {"pkSeed", test.PKSeedHex}} {
+					value, err := hex.DecodeString(seed.hex)
+					if err != nil {
+						return nil, fmt.Errorf("failed to decode %s in test case %d/%d: %s", seed.name, group.ID, test.ID, err)
+					}
+					if len(value) != sizes.n {
+						return nil, fmt.Errorf("test case %d/%d has a %d-byte %s, but %s uses %d bytes", group.ID, test.ID, len(value), seed.name, group.ParameterSet, sizes.n)
+					}
+					seeds = append(seeds, value)
+				}
+
+				m.TransactAsync(group.ParameterSet+"/keyGen", 2, seeds, func(result [][]byte) error {
+					if len(result[0]) != 2*sizes.n || len(result[1]) != 4*sizes.n {
+						return fmt.Errorf("key generation for test case %d/%d returned a %d-byte pk and %d-byte sk", group.ID, test.ID, len(result[0]), len(result[1]))
+					}
+					testResp.PKHex = hex.EncodeToString(result[0])
+					testResp.SKHex = hex.EncodeToString(result[1])
+					response.Tests = append(response.Tests, testResp)
+					return nil
+				})
+
+			case "sigGen":
+				if group.Type != "AFT" {
+					return nil, fmt.Errorf("unknown test type %q in sigGen test group %d", group.Type, group.ID)
+				}
+				sk, err := hex.DecodeString(test.SKHex)
+				if err != nil {
+					return nil, fmt.Errorf("failed to decode sk in test case %d/%d: %s", group.ID, test.ID, err)
+				}
+				if len(sk) != 4*sizes.n {
+					return nil, fmt.Errorf("test case %d/%d has a %d-byte sk, but %s uses %d bytes", group.ID, test.ID, len(sk), group.ParameterSet, 4*sizes.n)
+				}
+				msg, err := hex.DecodeString(test.MsgHex)
+				if err != nil {
+					return nil, fmt.Errorf("failed to decode message in test case %d/%d: %s", group.ID, test.ID, err)
+				}
+
+				// Deterministic signing uses the public seed from the private
+				// key as its randomness, which the module supplies itself.
+				deterministic := []byte{0}
+				var rnd []byte
+				if group.Deterministic {
+					deterministic = []byte{1}
+					if len(test.RandHex) != 0 {
+						return nil, fmt.Errorf("randomness provided in deterministic test case %d/%d", group.ID, test.ID)
+					}
+				} else {
+					if rnd, err = hex.DecodeString(test.RandHex); err != nil {
+						return nil, fmt.Errorf("failed to decode additionalRandomness in test case %d/%d: %s", group.ID, test.ID, err)
+					}
+					if len(rnd) != sizes.n {
+						return nil, fmt.Errorf("test case %d/%d has %d bytes of randomness, but %s uses %d", group.ID, test.ID, len(rnd), group.ParameterSet, sizes.n)
+					}
+				}
+
+				args := [][]byte{sk, msg, deterministic, rnd, context, []byte(hashAlg)}
+				m.TransactAsync(group.ParameterSet+"/sigGen", 1, args, func(result [][]byte) error {
+					if len(result[0]) != sizes.sig {
+						return fmt.Errorf("signing for test case %d/%d returned a %d-byte signature, but %s uses %d bytes", group.ID, test.ID, len(result[0]), group.ParameterSet, sizes.sig)
+					}
+					testResp.SigHex = hex.EncodeToString(result[0])
+					response.Tests = append(response.Tests, testResp)
+					return nil
+				})
+
+			case "sigVer":
+				if group.Type != "AFT" {
+					return nil, fmt.Errorf("unknown test type %q in sigVer test group %d", group.Type, group.ID)
+				}
+				pk, err := hex.DecodeString(test.PKHex)
+				if err != nil {
+					return nil, fmt.Errorf("failed to decode pk in test case %d/%d: %s", group.ID, test.ID, err)
+				}
+				if len(pk) != 2*sizes.n {
+					return nil, fmt.Errorf("test case %d/%d has a %d-byte pk, but %s uses %d bytes", group.ID, test.ID, len(pk), group.ParameterSet, 2*sizes.n)
+				}
+				msg, err := hex.DecodeString(test.MsgHex)
+				if err != nil {
+					return nil, fmt.Errorf("failed to decode message in test case %d/%d: %s", group.ID, test.ID, err)
+				}
+				sig, err := hex.DecodeString(test.SigHex)
+				if err != nil {
+					return nil, fmt.Errorf("failed to decode signature in test case %d/%d: %s", group.ID, test.ID, err)
+				}
+
+				args := [][]byte{pk, msg, sig, context, []byte(hashAlg)}
+				m.TransactAsync(group.ParameterSet+"/sigVer", 1, args, func(result [][]byte) error {
+					if len(result[0]) != 1 || (result[0][0]&0xfe) != 0 {
+						return fmt.Errorf("signature verification returned unexpected result: %q", result[0])
+					}
+					passed := result[0][0] == 1
+					testResp.Passed = &passed
+					response.Tests = append(response.Tests, testResp)
+					return nil
+				})
+
+			default:
+				return nil, fmt.Errorf("invalid mode %q in SLH-DSA vector set", parsed.Mode)
+			}
+		}
+
+		m.Barrier(func() {
+			ret = append(ret, response)
+		})
+	}
+
+	if err := m.Flush(); err != nil {
+		return nil, err
+	}
+
+	return ret, nil
+}
@@ -0,0 +1,116 @@
+// Copyright (c) 2026, Google Inc.
+//
+// Permission to use, copy, modify, and/or distribute this software for any
+// purpose with or without fee is hereby granted, provided that the above
+// copyright notice and this permission notice appear in all copies.
+//
+// THE SOFTWARE IS PROVIDED "AS IS" AND THE AUTHOR DISCLAIMS ALL WARRANTIES
+// WITH REGARD TO THIS SOFTWARE INCLUDING ALL IMPLIED WARRANTIES OF
+// MERCHANTABILITY AND FITNESS. IN NO EVENT SHALL THE AUTHOR BE LIABLE FOR ANY
+// SPECIAL, DIRECT, INDIRECT, OR CONSEQUENTIAL DAMAGES OR ANY DAMAGES
+// WHATSOEVER RESULTING FROM LOSS OF USE, DATA OR PROFITS, WHETHER IN AN ACTION
+// OF CONTRACT, NEGLIGENCE OR OTHER TORTIOUS ACTION, ARISING OUT OF OR IN
+// CONNECTION WITH THE USE OR PERFORMANCE OF THIS SOFTWARE.
+
+package subprocess
+
+import (
+	"bytes"
+	"fmt"
+	"testing"
+)
+
+// slhdsaResponder answers SLH-DSA commands for the 128s and 256f parameter
+// sets. Signatures are filled with the first byte of the randomness, or 0xde
+// when signing is deterministic, and verify when their first byte matches the
+// public key's.
+func slhdsaResponder(cmd string, args [][]byte) ([][]byte, error) {
+	sigLen := map[string]int{"SLH-DSA-SHA2-128s": 7856, "SLH-DSA-SHAKE-256f": 49856}
+	switch cmd {
+	case "SLH-DSA-SHA2-128s/keyGen":
+		return [][]byte{append(bytes.Clone(args[2]), args[0]...), bytes.Repeat(args[1], 4)}, nil
+	case "SLH-DSA-SHA2-128s/sigGen", "SLH-DSA-SHAKE-256f/sigGen":
+		fill := byte(0xde)
+		if args[2][0] == 0 {
+			fill = args[3][0]
+		}
+		return [][]byte{bytes.Repeat([]byte{fill}, sigLen[cmd[:len(cmd)-len("/sigGen")]])}, nil
+	case "SLH-DSA-SHA2-128s/sigVer":
+		return [][]byte{{boolToByte(args[2][0] == args[0][0])}}, nil
+	}
+	return nil, fmt.Errorf("unexpected command %q", cmd)
+}
+
+func TestSLHDSAKeyGen(t *testing.T) {
+	vectorSet := `{"algorithm": "SLH-DSA", "mode": "keyGen", "testGroups": [{
+		"tgId": 1, "testType": "AFT", "parameterSet": "SLH-DSA-SHA2-128s",
+		"tests": [{"tcId": 1, "skSeed": "` + hexRepeat(0x01, 16) + `", "skPrf": "` + hexRepeat(0x02, 16) + `",
+		           "pkSeed": "` + hexRepeat(0x03, 16) + `"}]
+	}]}`
+
+	result, err := new(slhdsa).Process([]byte(vectorSet), &fakeTransactable{respond: slhdsaResponder})
+	if err != nil {
+		t.Fatal(err)
+	}
+	test := result.([]slhdsaTestGroupResponse)[0].Tests[0]
+	if test.PKHex != hexRepeat(0x03, 16)+hexRepeat(0x01, 16) || test.SKHex != hexRepeat(0x02, 64) {
+		t.Errorf("unexpected response: %+v", test)
+	}
+}
+
+func TestSLHDSASigGen(t *testing.T) {
+	vectorSet := `{"algorithm": "SLH-DSA", "mode": "sigGen", "testGroups": [
+		{"tgId": 1, "testType": "AFT", "parameterSet": "SLH-DSA-SHA2-128s", "deterministic": true,
+		 "signatureInterface": "external", "preHash": "pure",
+		 "tests": [{"tcId": 1, "sk": "` + hexRepeat(0x5e, 64) + `", "message": "aa", "context": "c0"}]},
+		{"tgId": 2, "testType": "AFT", "parameterSet": "SLH-DSA-SHAKE-256f", "deterministic": false,
+		 "signatureInterface": "external", "preHash": "preHash",
+		 "tests": [{"tcId": 2, "sk": "` + hexRepeat(0x5e, 128) + `", "message": "aa",
+		            "additionalRandomness": "` + hexRepeat(0x42, 32) + `", "hashAlg": "SHAKE-256"}]}
+	]}`
+
+	m := &fakeTransactable{respond: slhdsaResponder}
+	result, err := new(slhdsa).Process([]byte(vectorSet), m)
+	if err != nil {
+		t.Fatal(err)
+	}
+	groups := result.([]slhdsaTestGroupResponse)
+	if sig := groups[0].Tests[0].SigHex; sig != hexRepeat(0xde, 7856) {
+		t.Errorf("deterministic signature is %s...", sig[:8])
+	}
+	if sig := groups[1].Tests[0].SigHex; sig != hexRepeat(0x42, 49856) {
+		t.Errorf("hedged signature is %s...", sig[:8])
+	}
+	if args := m.calls[1].args; !bytes.Equal(args[3], bytes.Repeat([]byte{0x42}, 32)) || string(args[5]) != "SHAKE-256" {
+		t.Errorf("hedged HashSLH-DSA signing passed rnd %x, hash %q", args[3], args[5])
+	}
+
+	// The largest signatures must survive the module protocol intact.
+	result, err = new(slhdsa).Process([]byte(vectorSet), pipeModule(t, 0, slhdsaResponder))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if sig := result.([]slhdsaTestGroupResponse)[1].Tests[0].SigHex; sig != hexRepeat(0x42, 49856) {
+		t.Errorf("signature of %d hex digits read from the module", len(sig))
+	}
+}
+
+func TestSLHDSASigVer(t *testing.T) {
+	// The second signature has been tampered with.
+	vectorSet := `{"algorithm": "SLH-DSA", "mode": "sigVer", "testGroups": [{
+		"tgId": 1, "testType": "AFT", "parameterSet": "SLH-DSA-SHA2-128s",
+		"tests": [
+			{"tcId": 1, "pk": "` + hexRepeat(0x11, 32) + `", "message": "aa", "signature": "` + hexRepeat(0x11, 7856) + `"},
+			{"tcId": 2, "pk": "` + hexRepeat(0x11, 32) + `", "message": "aa", "signature": "` + hexRepeat(0x12, 7856) + `"}
+		]
+	}]}`
+
+	result, err := new(slhdsa).Process([]byte(vectorSet), &fakeTransactable{respond: slhdsaResponder})
+	if err != nil {
+		t.Fatal(err)
+	}
+	tests := result.([]slhdsaTestGroupResponse)[0].Tests
+	if len(tests) != 2 || tests[0].Passed == nil || !*tests[0].Passed || tests[1].Passed == nil || *tests[1].Passed {
+		t.Errorf("unexpected response: %+v", tests)
+	}
+}
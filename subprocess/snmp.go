@@ -0,0 +1,111 @@
+// Copyright (c) 2026, Google Inc.
+//
+// Permission to use, copy, modify, and/or distribute this software for any
+// purpose with or without fee is hereby granted, provided that the above
+// copyright notice and this permission notice appear in all copies.
+//
+// THE SOFTWARE IS PROVIDED "AS IS" AND THE AUTHOR DISCLAIMS ALL WARRANTIES
+// WITH REGARD TO THIS SOFTWARE INCLUDING ALL IMPLIED WARRANTIES OF
+// MERCHANTABILITY AND FITNESS. IN NO EVENT SHALL THE AUTHOR BE LIABLE FOR ANY
+// SPECIAL, DIRECT, INDIRECT, OR CONSEQUENTIAL DAMAGES OR ANY DAMAGES
+// WHATSOEVER RESULTING FROM LOSS OF USE, DATA OR PROFITS, WHETHER IN AN ACTION
+// OF CONTRACT, NEGLIGENCE OR OTHER TORTIOUS ACTION, ARISING OUT OF OR IN
+// CONNECTION WITH THE USE OR PERFORMANCE OF THIS SOFTWARE.
+
+package subprocess
+
+import (
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+)
+
+// The following structures reflect the JSON of ACVP SNMP KDF tests. See
+// https://pages.nist.gov/ACVP/draft-hammett-acvp-kdf-snmp.html
+
+type snmpTestVectorSet struct {
+	Groups []snmpTestGroup `json:"testGroups"`
+}
+
+type snmpTestGroup struct {
+	ID          uint64 `json:"tgId"`
+	Type        string `json:"testType"`
+	EngineIDHex string `json:"engineId"`
+	PasswordLen uint32 `json:"passwordLength"`
+	Tests       []struct {
+		ID       uint64 `json:"tcId"`
+		Password string `json:"password"`
+	} `json:"tests"`
+}
+
+type snmpTestGroupResponse struct {
+	ID    uint64             `json:"tgId"`
+	Tests []snmpTestResponse `json:"tests"`
+}
+
+type snmpTestResponse struct {
+	ID           uint64 `json:"tcId"`
+	SharedKeyHex string `json:"sharedKey"`
+}
+
+// snmp implements the SHA-1 password-to-key algorithm of RFC 3414, section
+// A.2.2, which results in a key localised to an SNMP engine.
+type snmp struct{}
+
+func (s *snmp) Process(vectorSet []byte, m Transactable) (any, error) {
+	var parsed snmpTestVectorSet
+	if err := json.Unmarshal(vectorSet, &parsed); err != nil {
+		return nil, err
+	}
+
+	var ret []snmpTestGroupResponse
+	for _, group := range parsed.Groups {
+		group := group
+
+		// Only the AFT test type is specified for SNMP:
+		// https://pages.nist.gov/ACVP/draft-hammett-acvp-kdf-snmp.html#name-test-types
+		if group.Type != "AFT" {
+			return nil, fmt.Errorf("test group %d had unexpected test type: %q", group.ID, group.Type)
+		}
+
+		engineID, err := hex.DecodeString(group.EngineIDHex)
+		if err != nil {
+			return nil, fmt.Errorf("failed to decode engine ID hex in test group %d: %s", group.ID, err)
+		}
+
+		response := snmpTestGroupResponse{
+			ID: group.ID,
+		}
+
+		for _, test := range group.Tests {
+			test := test
+			m := withTestCase(m, "kdf-components", group.ID, test.ID)
+
+			if uint32(len(test.Password)) != group.PasswordLen {
+				return nil, fmt.Errorf("test case %d/%d has a password of %d bytes but the group specifies %d", group.ID, test.ID, len(test.Password), group.PasswordLen)
+			}
+
+			m.TransactAsync("SNMPKDF", 1, [][]byte{engineID, []byte(test.Password)}, func(result [][]byte) error {
+				// The localised key is a SHA-1 digest.
+				if len(result[0]) != 20 {
+					return fmt.Errorf("SNMPKDF returned a %d-byte key for test case %d/%d", len(result[0]), group.ID, test.ID)
+				}
+				response.Tests = append(response.Tests, snmpTestResponse{
+					ID:           test.ID,
+					SharedKeyHex: hex.EncodeToString(result[0]),
+				})
+				return nil
+			})
+		}
+
+		m.Barrier(func() {
+			ret = append(ret, response)
+		})
+	}
+
+	if err := m.Flush(); err != nil {
+		return nil, err
+	}
+
+	return ret, nil
+}
@@ -0,0 +1,58 @@
+// Copyright (c) 2026, Google Inc.
+//
+// Permission to use, copy, modify, and/or distribute this software for any
+// purpose with or without fee is hereby granted, provided that the above
+// copyright notice and this permission notice appear in all copies.
+//
+// THE SOFTWARE IS PROVIDED "AS IS" AND THE AUTHOR DISCLAIMS ALL WARRANTIES
+// WITH REGARD TO THIS SOFTWARE INCLUDING ALL IMPLIED WARRANTIES OF
+// MERCHANTABILITY AND FITNESS. IN NO EVENT SHALL THE AUTHOR BE LIABLE FOR ANY
+// SPECIAL, DIRECT, INDIRECT, OR CONSEQUENTIAL DAMAGES OR ANY DAMAGES
+// WHATSOEVER RESULTING FROM LOSS OF USE, DATA OR PROFITS, WHETHER IN AN ACTION
+// OF CONTRACT, NEGLIGENCE OR OTHER TORTIOUS ACTION, ARISING OUT OF OR IN
+// CONNECTION WITH THE USE OR PERFORMANCE OF THIS SOFTWARE.
+
+package subprocess
+
+import (
+	"crypto/sha1"
+	"testing"
+)
+
+// snmpResponder implements the SHA-1 password-to-key algorithm of RFC 3414.
+func snmpResponder(cmd string, args [][]byte) ([][]byte, error) {
+	if cmd != "SNMPKDF" {
+		panic("unexpected command " + cmd)
+	}
+	engineID, password := args[0], args[1]
+
+	h := sha1.New()
+	for n := 0; n < 1048576; n += len(password) {
+		h.Write(password[:min(len(password), 1048576-n)])
+	}
+	ku := h.Sum(nil)
+
+	h.Reset()
+	h.Write(ku)
+	h.Write(engineID)
+	h.Write(ku)
+	return [][]byte{h.Sum(nil)}, nil
+}
+
+func TestSNMPKDF(t *testing.T) {
+	// RFC 3414, appendix A.3.2.
+	const vectorSet = `{"algorithm": "kdf-components", "mode": "snmp", "testGroups": [{
+		"tgId": 1, "testType": "AFT", "engineId": "000000000000000000000002", "passwordLength": 10,
+		"tests": [{"tcId": 1, "password": "maplesyrup"}]
+	}]}`
+
+	result, err := (&kdfComponents{map[string]Primitive{"snmp": &snmp{}}}).Process([]byte(vectorSet), &fakeTransactable{respond: snmpResponder})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	const want = "6695febc9288e36282235fc7151f128497b38f3f"
+	if got := result.([]snmpTestGroupResponse)[0].Tests[0].SharedKeyHex; got != want {
+		t.Errorf("got %s, want %s", got, want)
+	}
+}
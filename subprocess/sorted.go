@@ -0,0 +1,120 @@
+// Copyright (c) 2026, Google Inc.
+//
+// Permission to use, copy, modify, and/or distribute this software for any
+// purpose with or without fee is hereby granted, provided that the above
+// copyright notice and this permission notice appear in all copies.
+//
+// THE SOFTWARE IS PROVIDED "AS IS" AND THE AUTHOR DISCLAIMS ALL WARRANTIES
+// WITH REGARD TO THIS SOFTWARE INCLUDING ALL IMPLIED WARRANTIES OF
+// MERCHANTABILITY AND FITNESS. IN NO EVENT SHALL THE AUTHOR BE LIABLE FOR ANY
+// SPECIAL, DIRECT, INDIRECT, OR CONSEQUENTIAL DAMAGES OR ANY DAMAGES
+// WHATSOEVER RESULTING FROM LOSS OF USE, DATA OR PROFITS, WHETHER IN AN ACTION
+// OF CONTRACT, NEGLIGENCE OR OTHER TORTIOUS ACTION, ARISING OUT OF OR IN
+// CONNECTION WITH THE USE OR PERFORMANCE OF THIS SOFTWARE.
+
+package subprocess
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+)
+
+// EnableSortedResults causes Process to sort the test cases in each test
+// group of its result by tcId. Handlers answer test cases in the order that
+// they finish, which for groups that mix asynchronous and synchronous
+// commands need not be the order of the input. The ACVP server doesn't mind,
+// but sorted results are easier to compare.
+func (m *Subprocess) EnableSortedResults() {
+	m.sortResults = true
+}
+
+// sortedPrimitive sorts the results of prim. See EnableSortedResults.
+type sortedPrimitive struct {
+	prim Primitive
+}
+
+func (s *sortedPrimitive) Process(vectorSet []byte, t Transactable) (any, error) {
+	result, err := s.prim.Process(vectorSet, t)
+	if err != nil {
+		var partial *PartialResultsError
+		if errors.As(err, &partial) {
+			sorted, sortErr := sortGroupResults(partial.Results)
+			if sortErr != nil {
+				return nil, sortErr
+			}
+			partial.Results = sorted
+		}
+		return nil, err
+	}
+
+	resultBytes, err := json.Marshal(result)
+	if err != nil {
+		return nil, err
+	}
+	var groups []json.RawMessage
+	if err := json.Unmarshal(resultBytes, &groups); err != nil {
+		return nil, fmt.Errorf("handler result can't be split into test groups: %s", err)
+	}
+	return sortGroupResults(groups)
+}
+
+// sortGroupResults returns groups with the tests of each sorted by tcId.
+// Groups with no tests, or with a test that has no tcId, are unchanged.
+func sortGroupResults(groups []json.RawMessage) ([]json.RawMessage, error) {
+	ret := make([]json.RawMessage, 0, len(groups))
+	for _, group := range groups {
+		sorted, err := sortTests(group)
+		if err != nil {
+			return nil, err
+		}
+		ret = append(ret, sorted)
+	}
+	return ret, nil
+}
+
+func sortTests(group json.RawMessage) (json.RawMessage, error) {
+	var fields map[string]json.RawMessage
+	if err := json.Unmarshal(group, &fields); err != nil {
+		return nil, fmt.Errorf("failed to parse test group result: %s", err)
+	}
+	testsJSON, ok := fields["tests"]
+	if !ok {
+		return group, nil
+	}
+	var tests []json.RawMessage
+	if err := json.Unmarshal(testsJSON, &tests); err != nil {
+		return nil, fmt.Errorf("failed to parse test results: %s", err)
+	}
+
+	ids := make([]uint64, len(tests))
+	for i, test := range tests {
+		var parsed struct {
+			ID *uint64 `json:"tcId"`
+		}
+		if err := json.Unmarshal(test, &parsed); err != nil || parsed.ID == nil {
+			return group, nil
+		}
+		ids[i] = *parsed.ID
+	}
+	if sort.SliceIsSorted(tests, func(i, j int) bool { return ids[i] < ids[j] }) {
+		return group, nil
+	}
+
+	order := make([]int, len(tests))
+	for i := range order {
+		order[i] = i
+	}
+	sort.SliceStable(order, func(i, j int) bool { return ids[order[i]] < ids[order[j]] })
+	sortedTests := make([]json.RawMessage, len(tests))
+	for i, j := range order {
+		sortedTests[i] = tests[j]
+	}
+
+	var err error
+	if fields["tests"], err = json.Marshal(sortedTests); err != nil {
+		return nil, err
+	}
+	return json.Marshal(fields)
+}
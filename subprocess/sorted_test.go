@@ -0,0 +1,123 @@
+// Copyright (c) 2026, Google Inc.
+//
+// Permission to use, copy, modify, and/or distribute this software for any
+// purpose with or without fee is hereby granted, provided that the above
+// copyright notice and this permission notice appear in all copies.
+//
+// THE SOFTWARE IS PROVIDED "AS IS" AND THE AUTHOR DISCLAIMS ALL WARRANTIES
+// WITH REGARD TO THIS SOFTWARE INCLUDING ALL IMPLIED WARRANTIES OF
+// MERCHANTABILITY AND FITNESS. IN NO EVENT SHALL THE AUTHOR BE LIABLE FOR ANY
+// SPECIAL, DIRECT, INDIRECT, OR CONSEQUENTIAL DAMAGES OR ANY DAMAGES
+// WHATSOEVER RESULTING FROM LOSS OF USE, DATA OR PROFITS, WHETHER IN AN ACTION
+// OF CONTRACT, NEGLIGENCE OR OTHER TORTIOUS ACTION, ARISING OUT OF OR IN
+// CONNECTION WITH THE USE OR PERFORMANCE OF THIS SOFTWARE.
+
+package subprocess
+
+import (
+	"encoding/json"
+	"errors"
+	"reflect"
+	"testing"
+)
+
+// reversingTransactable breaks the ordering contract of Transactable: it runs
+// the callbacks of asynchronous commands at the next barrier, newest first.
+type reversingTransactable struct {
+	fakeTransactable
+	pending []func() error
+}
+
+func (r *reversingTransactable) TransactAsync(cmd string, expectedResults int, args [][]byte, callback func([][]byte) error) {
+	result, err := r.Transact(cmd, expectedResults, args...)
+	r.pending = append(r.pending, func() error {
+		if err != nil {
+			return err
+		}
+		return callback(result)
+	})
+}
+
+func (r *reversingTransactable) Barrier(callback func()) error {
+	for i := len(r.pending) - 1; i >= 0; i-- {
+		if err := r.pending[i](); err != nil && r.err == nil {
+			r.err = err
+		}
+	}
+	r.pending = nil
+	callback()
+	return nil
+}
+
+const sortVectorSet = `{"testGroups": [{"tgId": 1, "testType": "AFT", "tests": [
+	{"tcId": 1, "len": 8, "msg": "01", "outLen": 16, "functionName": "", "customization": ""},
+	{"tcId": 2, "len": 8, "msg": "02", "outLen": 16, "functionName": "", "customization": ""},
+	{"tcId": 3, "len": 8, "msg": "03", "outLen": 16, "functionName": "", "customization": ""}
+]}]}`
+
+// testIDs returns the tcIds of the tests in each group of result.
+func testIDs(t *testing.T, result any) [][]uint64 {
+	resultBytes, err := json.Marshal(result)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var groups []struct {
+		Tests []struct {
+			ID uint64 `json:"tcId"`
+		} `json:"tests"`
+	}
+	if err := json.Unmarshal(resultBytes, &groups); err != nil {
+		t.Fatal(err)
+	}
+	var ret [][]uint64
+	for _, group := range groups {
+		var ids []uint64
+		for _, test := range group.Tests {
+			ids = append(ids, test.ID)
+		}
+		ret = append(ret, ids)
+	}
+	return ret
+}
+
+func TestSortedResults(t *testing.T) {
+	h := &cShake{"cSHAKE-128"}
+	unsorted, err := h.Process([]byte(sortVectorSet), &reversingTransactable{fakeTransactable: fakeTransactable{respond: cShakeStreamResponder}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, want := testIDs(t, unsorted), [][]uint64{{3, 2, 1}}; !reflect.DeepEqual(got, want) {
+		t.Fatalf("without sorting, got tcIds %v, want %v", got, want)
+	}
+
+	sorted, err := (&sortedPrimitive{h}).Process([]byte(sortVectorSet), &reversingTransactable{fakeTransactable: fakeTransactable{respond: cShakeStreamResponder}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, want := testIDs(t, sorted), [][]uint64{{1, 2, 3}}; !reflect.DeepEqual(got, want) {
+		t.Errorf("got tcIds %v, want %v", got, want)
+	}
+}
+
+// primitiveFunc is a Primitive that calls itself.
+type primitiveFunc func(vectorSet []byte, t Transactable) (any, error)
+
+func (f primitiveFunc) Process(vectorSet []byte, t Transactable) (any, error) {
+	return f(vectorSet, t)
+}
+
+func TestSortedPartialResults(t *testing.T) {
+	results := []json.RawMessage{json.RawMessage(`{"tgId": 1, "tests": [{"tcId": 2}, {"tcId": 1}]}`)}
+	failing := primitiveFunc(func([]byte, Transactable) (any, error) {
+		return nil, &PartialResultsError{Results: results, Err: errors.New("module failed")}
+	})
+
+	_, err := (&sortedPrimitive{failing}).Process(nil, nil)
+	var partial *PartialResultsError
+	if !errors.As(err, &partial) {
+		t.Fatalf("got error %v, want a *PartialResultsError", err)
+	}
+	if got, want := testIDs(t, partial.Results), [][]uint64{{1, 2}}; !reflect.DeepEqual(got, want) {
+		t.Errorf("got tcIds %v, want %v", got, want)
+	}
+}
@@ -0,0 +1,158 @@
+// Copyright (c) 2026, Google Inc.
+//
+// Permission to use, copy, modify, and/or distribute this software for any
+// purpose with or without fee is hereby granted, provided that the above
+// copyright notice and this permission notice appear in all copies.
+//
+// THE SOFTWARE IS PROVIDED "AS IS" AND THE AUTHOR DISCLAIMS ALL WARRANTIES
+// WITH REGARD TO THIS SOFTWARE INCLUDING ALL IMPLIED WARRANTIES OF
+// MERCHANTABILITY AND FITNESS. IN NO EVENT SHALL THE AUTHOR BE LIABLE FOR ANY
+// SPECIAL, DIRECT, INDIRECT, OR CONSEQUENTIAL DAMAGES OR ANY DAMAGES
+// WHATSOEVER RESULTING FROM LOSS OF USE, DATA OR PROFITS, WHETHER IN AN ACTION
+// OF CONTRACT, NEGLIGENCE OR OTHER TORTIOUS ACTION, ARISING OUT OF OR IN
+// CONNECTION WITH THE USE OR PERFORMANCE OF THIS SOFTWARE.
+
+package subprocess
+
+import (
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+)
+
+// The following structures reflect the JSON of ACVP SRTP KDF tests. See
+// https://pages.nist.gov/ACVP/draft-hammett-acvp-kdf-srtp.html
+
+type srtpTestVectorSet struct {
+	Groups []srtpTestGroup `json:"testGroups"`
+}
+
+type srtpTestGroup struct {
+	ID         uint64 `json:"tgId"`
+	Type       string `json:"testType"`
+	AESKeyBits uint32 `json:"aesKeyLength"`
+	KDRHex     string `json:"kdr"`
+	Tests      []struct {
+		ID            uint64 `json:"tcId"`
+		MasterKeyHex  string `json:"masterKey"`
+		MasterSaltHex string `json:"masterSalt"`
+		IndexHex      string `json:"index"`
+		SRTCPIndexHex string `json:"srtcpIndex"`
+	} `json:"tests"`
+}
+
+type srtpTestGroupResponse struct {
+	ID    uint64             `json:"tgId"`
+	Tests []srtpTestResponse `json:"tests"`
+}
+
+type srtpTestResponse struct {
+	ID              uint64 `json:"tcId"`
+	SRTPEncKeyHex   string `json:"srtpKe"`
+	SRTPAuthKeyHex  string `json:"srtpKa"`
+	SRTPSaltKeyHex  string `json:"srtpKs"`
+	SRTCPEncKeyHex  string `json:"srtcpKe"`
+	SRTCPAuthKeyHex string `json:"srtcpKa"`
+	SRTCPSaltKeyHex string `json:"srtcpKs"`
+}
+
+const (
+	// srtpAuthKeyLen and srtpSaltKeyLen are the lengths, in bytes, of the
+	// session authentication and salt keys. See RFC 3711, section 8.2.
+	srtpAuthKeyLen = 20
+	srtpSaltKeyLen = 14
+)
+
+// srtp implements the AES-CM key derivation of RFC 3711, section 4.3, for both
+// SRTP and SRTCP session keys.
+type srtp struct{}
+
+func (s *srtp) Process(vectorSet []byte, m Transactable) (any, error) {
+	var parsed srtpTestVectorSet
+	if err := json.Unmarshal(vectorSet, &parsed); err != nil {
+		return nil, err
+	}
+
+	var ret []srtpTestGroupResponse
+	for _, group := range parsed.Groups {
+		group := group
+
+		// Only the AFT test type is specified for SRTP:
+		// https://pages.nist.gov/ACVP/draft-hammett-acvp-kdf-srtp.html#name-test-types
+		if group.Type != "AFT" {
+			return nil, fmt.Errorf("test group %d had unexpected test type: %q", group.ID, group.Type)
+		}
+
+		switch group.AESKeyBits {
+		case 128, 192, 256:
+			break
+		default:
+			return nil, fmt.Errorf("test group %d has unsupported AES key length %d", group.ID, group.AESKeyBits)
+		}
+		encKeyLen := int(group.AESKeyBits / 8)
+
+		kdr, err := hex.DecodeString(group.KDRHex)
+		if err != nil {
+			return nil, fmt.Errorf("failed to decode KDR hex in test group %d: %s", group.ID, err)
+		}
+
+		response := srtpTestGroupResponse{
+			ID: group.ID,
+		}
+
+		for _, test := range group.Tests {
+			test := test
+			m := withTestCase(m, "kdf-components", group.ID, test.ID)
+
+			var fields [][]byte
+			for _, field := range []struct {
+				name string
+				hex  string
+				len  int
+			}{
+				{"master key", test.MasterKeyHex, encKeyLen},
+				{"master salt", test.MasterSaltHex, srtpSaltKeyLen},
+				{"index", test.IndexHex, 6},
+				{"SRTCP index", test.SRTCPIndexHex, 4},
+			} {
+				value, err := hex.DecodeString(field.hex)
+				if err != nil {
+					return nil, fmt.Errorf("failed to decode %s hex in test case %d/%d: %s", field.name, group.ID, test.ID, err)
+				}
+				if len(value) != field.len {
+					return nil, fmt.Errorf("test case %d/%d has a %d-byte %s, but it should be %d bytes", group.ID, test.ID, len(value), field.name, field.len)
+				}
+				fields = append(fields, value)
+			}
+			masterKey, masterSalt, index, srtcpIndex := fields[0], fields[1], fields[2], fields[3]
+
+			m.TransactAsync("SRTPKDF", 6, [][]byte{masterKey, masterSalt, kdr, index, srtcpIndex}, func(result [][]byte) error {
+				for i, want := range []int{encKeyLen, srtpAuthKeyLen, srtpSaltKeyLen, encKeyLen, srtpAuthKeyLen, srtpSaltKeyLen} {
+					if len(result[i]) != want {
+						return fmt.Errorf("SRTPKDF output %d is %d bytes long for test case %d/%d, but should be %d", i, len(result[i]), group.ID, test.ID, want)
+					}
+				}
+				response.Tests = append(response.Tests, srtpTestResponse{
+					ID:              test.ID,
+					SRTPEncKeyHex:   hex.EncodeToString(result[0]),
+					SRTPAuthKeyHex:  hex.EncodeToString(result[1]),
+					SRTPSaltKeyHex:  hex.EncodeToString(result[2]),
+					SRTCPEncKeyHex:  hex.EncodeToString(result[3]),
+					SRTCPAuthKeyHex: hex.EncodeToString(result[4]),
+					SRTCPSaltKeyHex: hex.EncodeToString(result[5]),
+				})
+				return nil
+			})
+		}
+
+		m.Barrier(func() {
+			ret = append(ret, response)
+		})
+	}
+
+	if err := m.Flush(); err != nil {
+		return nil, err
+	}
+
+	return ret, nil
+}
@@ -0,0 +1,83 @@
+// Copyright (c) 2026, Google Inc.
+//
+// Permission to use, copy, modify, and/or distribute this software for any
+// purpose with or without fee is hereby granted, provided that the above
+// copyright notice and this permission notice appear in all copies.
+//
+// THE SOFTWARE IS PROVIDED "AS IS" AND THE AUTHOR DISCLAIMS ALL WARRANTIES
+// WITH REGARD TO THIS SOFTWARE INCLUDING ALL IMPLIED WARRANTIES OF
+// MERCHANTABILITY AND FITNESS. IN NO EVENT SHALL THE AUTHOR BE LIABLE FOR ANY
+// SPECIAL, DIRECT, INDIRECT, OR CONSEQUENTIAL DAMAGES OR ANY DAMAGES
+// WHATSOEVER RESULTING FROM LOSS OF USE, DATA OR PROFITS, WHETHER IN AN ACTION
+// OF CONTRACT, NEGLIGENCE OR OTHER TORTIOUS ACTION, ARISING OUT OF OR IN
+// CONNECTION WITH THE USE OR PERFORMANCE OF THIS SOFTWARE.
+
+package subprocess
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"testing"
+)
+
+// srtpResponder implements the AES-CM key derivation of RFC 3711 for a key
+// derivation rate of zero.
+func srtpResponder(cmd string, args [][]byte) ([][]byte, error) {
+	if cmd != "SRTPKDF" {
+		panic("unexpected command " + cmd)
+	}
+	masterKey, masterSalt := args[0], args[1]
+	if len(args[2]) != 1 || args[2][0] != 0 {
+		panic("only a KDR of zero is supported")
+	}
+
+	block, err := aes.NewCipher(masterKey)
+	if err != nil {
+		return nil, err
+	}
+
+	var ret [][]byte
+	for label, n := range []int{len(masterKey), 20, 14, len(masterKey), 20, 14} {
+		// With a KDR of zero, r is zero and so the key ID is just the
+		// label, which is XORed into the salt at the position of the
+		// 48-bit index.
+		iv := make([]byte, 16)
+		copy(iv, masterSalt)
+		iv[7] ^= byte(label)
+
+		out := make([]byte, n)
+		cipher.NewCTR(block, iv).XORKeyStream(out, out)
+		ret = append(ret, out)
+	}
+	return ret, nil
+}
+
+func TestSRTPKDF(t *testing.T) {
+	// RFC 3711, appendix B.3.
+	const vectorSet = `{"algorithm": "kdf-components", "mode": "srtp", "testGroups": [{
+		"tgId": 1, "testType": "AFT", "aesKeyLength": 128, "kdr": "00",
+		"tests": [{"tcId": 1, "masterKey": "e1f97a0d3e018be0d64fa32c06de4139",
+			"masterSalt": "0ec675ad498afeebb6960b3aabe6", "index": "000000000000", "srtcpIndex": "00000000"}]
+	}]}`
+
+	m := &fakeTransactable{respond: srtpResponder}
+	result, err := (&kdfComponents{map[string]Primitive{"srtp": &srtp{}}}).Process([]byte(vectorSet), m)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	test := result.([]srtpTestGroupResponse)[0].Tests[0]
+	for _, tc := range []struct{ name, got, want string }{
+		{"cipher key", test.SRTPEncKeyHex, "c61e7a93744f39ee10734afe3ff7a087"},
+		{"authentication key", test.SRTPAuthKeyHex, "cebe321f6ff7716b6fd4ab49af256a156d38baa4"},
+		{"salt key", test.SRTPSaltKeyHex, "30cbbc08863d8c85d49db34a9ae1"},
+	} {
+		if tc.got != tc.want {
+			t.Errorf("%s: got %s, want %s", tc.name, tc.got, tc.want)
+		}
+	}
+
+	if len(test.SRTCPEncKeyHex) != 32 || len(test.SRTCPAuthKeyHex) != 40 || len(test.SRTCPSaltKeyHex) != 28 {
+		t.Errorf("unexpected SRTCP keys: %+v", test)
+	}
+}
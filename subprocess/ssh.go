@@ -46,6 +46,37 @@ type sshTestResponse struct {
 type ssh struct {
 }
 
+// sshCipherSizes gives the IV and key lengths, in bytes, for each cipher that
+// may be used with the SSH KDF.
+var sshCipherSizes = map[string]struct{ ivLen, keyLen int }{
+	"TDES":    {8, 24},
+	"AES-128": {16, 16},
+	"AES-192": {16, 24},
+	"AES-256": {16, 32},
+}
+
+// sshHashSizes gives the output length, in bytes, of each hash that may be
+// used with the SSH KDF. The integrity keys are this long, per the HMAC used
+// with that hash.
+var sshHashSizes = map[string]int{
+	"SHA-1":    20,
+	"SHA2-224": 28,
+	"SHA2-256": 32,
+	"SHA2-384": 48,
+	"SHA2-512": 64,
+}
+
+// checkSSHKeyLengths returns an error unless the IV, encryption key and
+// integrity key returned by the subprocess have the expected lengths.
+func checkSSHKeyLengths(result [][]byte, ivLen, keyLen, macKeyLen int) error {
+	for i, want := range []int{ivLen, keyLen, macKeyLen} {
+		if len(result[i]) != want {
+			return fmt.Errorf("SSH KDF output %d is %d bytes long, but should be %d", i, len(result[i]), want)
+		}
+	}
+	return nil
+}
+
 func (s *ssh) Process(vectorSet []byte, m Transactable) (any, error) {
 	var parsed sshTestVectorSet
 	if err := json.Unmarshal(vectorSet, &parsed); err != nil {
@@ -69,12 +100,22 @@ func (s *ssh) Process(vectorSet []byte, m Transactable) (any, error) {
 			return nil, fmt.Errorf("test group %d had unexpected test type: %q", group.ID, group.TestType)
 		}
 
+		sizes, ok := sshCipherSizes[group.Cipher]
+		if !ok {
+			return nil, fmt.Errorf("test group %d has unsupported cipher %q", group.ID, group.Cipher)
+		}
+		macKeyLen, ok := sshHashSizes[group.HashAlg]
+		if !ok {
+			return nil, fmt.Errorf("test group %d has unsupported hash %q", group.ID, group.HashAlg)
+		}
+
 		response := sshTestGroupResponse{
 			ID: group.ID,
 		}
 
 		for _, test := range group.Tests {
 			test := test
+			m := withTestCase(m, "kdf-components", group.ID, test.ID)
 
 			resp := sshTestResponse{
 				ID: test.ID,
@@ -93,16 +134,22 @@ func (s *ssh) Process(vectorSet []byte, m Transactable) (any, error) {
 				return nil, fmt.Errorf("failed to decode session ID hex in test case %d/%d: %s", group.ID, test.ID, err)
 			}
 
-			cmd := fmt.Sprintf("SSHKDF/%s/client", group.HashAlg)
-			m.TransactAsync(cmd, 3, [][]byte{k, h, sessionID, []byte(group.Cipher)}, func(result [][]byte) error {
+			clientCmd := fmt.Sprintf("SSHKDF/%s/client", group.HashAlg)
+			m.TransactAsync(clientCmd, 3, [][]byte{k, h, sessionID, []byte(group.Cipher)}, func(result [][]byte) error {
+				if err := checkSSHKeyLengths(result, sizes.ivLen, sizes.keyLen, macKeyLen); err != nil {
+					return fmt.Errorf("%s for test case %d/%d: %s", clientCmd, group.ID, test.ID, err)
+				}
 				resp.InitialIvClientHex = hex.EncodeToString(result[0])
 				resp.EncryptionKeyClientHex = hex.EncodeToString(result[1])
 				resp.IntegrityKeyClientHex = hex.EncodeToString(result[2])
 				return nil
 			})
 
-			cmd = fmt.Sprintf("SSHKDF/%s/server", group.HashAlg)
-			m.TransactAsync(cmd, 3, [][]byte{k, h, sessionID, []byte(group.Cipher)}, func(result [][]byte) error {
+			serverCmd := fmt.Sprintf("SSHKDF/%s/server", group.HashAlg)
+			m.TransactAsync(serverCmd, 3, [][]byte{k, h, sessionID, []byte(group.Cipher)}, func(result [][]byte) error {
+				if err := checkSSHKeyLengths(result, sizes.ivLen, sizes.keyLen, macKeyLen); err != nil {
+					return fmt.Errorf("%s for test case %d/%d: %s", serverCmd, group.ID, test.ID, err)
+				}
 				resp.InitialIvServerHex = hex.EncodeToString(result[0])
 				resp.EncryptionKeyServerHex = hex.EncodeToString(result[1])
 				resp.IntegrityKeyServerHex = hex.EncodeToString(result[2])
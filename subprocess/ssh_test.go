@@ -0,0 +1,61 @@
+// Copyright (c) 2026, Google Inc.
+//
+// Permission to use, copy, modify, and/or distribute this software for any
+// purpose with or without fee is hereby granted, provided that the above
+// copyright notice and this permission notice appear in all copies.
+//
+// THE SOFTWARE IS PROVIDED "AS IS" AND THE AUTHOR DISCLAIMS ALL WARRANTIES
+// WITH REGARD TO THIS SOFTWARE INCLUDING ALL IMPLIED WARRANTIES OF
+// MERCHANTABILITY AND FITNESS. IN NO EVENT SHALL THE AUTHOR BE LIABLE FOR ANY
+// SPECIAL, DIRECT, INDIRECT, OR CONSEQUENTIAL DAMAGES OR ANY DAMAGES
+// WHATSOEVER RESULTING FROM LOSS OF USE, DATA OR PROFITS, WHETHER IN AN ACTION
+// OF CONTRACT, NEGLIGENCE OR OTHER TORTIOUS ACTION, ARISING OUT OF OR IN
+// CONNECTION WITH THE USE OR PERFORMANCE OF THIS SOFTWARE.
+
+package subprocess
+
+import (
+	"testing"
+)
+
+func sshVectorSet(cipher, hash string) string {
+	return `{"algorithm": "kdf-components", "mode": "ssh", "testGroups": [{
+		"tgId": 1, "testType": "AFT", "hashAlg": "` + hash + `", "cipher": "` + cipher + `",
+		"tests": [{"tcId": 1, "k": "0a", "h": "0b", "sessionID": "0c"}]
+	}]}`
+}
+
+// sshResponder returns outputs of the given lengths.
+func sshResponder(ivLen, keyLen, macKeyLen int) func(string, [][]byte) ([][]byte, error) {
+	return func(cmd string, args [][]byte) ([][]byte, error) {
+		return [][]byte{make([]byte, ivLen), make([]byte, keyLen), make([]byte, macKeyLen)}, nil
+	}
+}
+
+func TestSSHKeyLengths(t *testing.T) {
+	vectorSet := []byte(sshVectorSet("AES-256", "SHA2-256"))
+
+	m := &fakeTransactable{respond: sshResponder(16, 32, 32)}
+	result, err := new(ssh).Process(vectorSet, m)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(m.calls) != 2 || m.calls[0].cmd != "SSHKDF/SHA2-256/client" || m.calls[1].cmd != "SSHKDF/SHA2-256/server" {
+		t.Errorf("unexpected calls: %+v", m.calls)
+	}
+	test := result.([]sshTestGroupResponse)[0].Tests[0]
+	if len(test.InitialIvServerHex) != 32 || len(test.EncryptionKeyServerHex) != 64 || len(test.IntegrityKeyServerHex) != 64 {
+		t.Errorf("unexpected response: %+v", test)
+	}
+
+	for _, lengths := range [][3]int{{8, 32, 32}, {16, 16, 32}, {16, 32, 20}} {
+		m := &fakeTransactable{respond: sshResponder(lengths[0], lengths[1], lengths[2])}
+		if _, err := new(ssh).Process(vectorSet, m); err == nil {
+			t.Errorf("outputs of lengths %v were accepted", lengths)
+		}
+	}
+
+	if _, err := new(ssh).Process([]byte(sshVectorSet("AES-512", "SHA2-256")), &fakeTransactable{respond: sshResponder(16, 32, 32)}); err == nil {
+		t.Error("unknown cipher was accepted")
+	}
+}
@@ -0,0 +1,134 @@
+// Copyright (c) 2026, Google Inc.
+//
+// Permission to use, copy, modify, and/or distribute this software for any
+// purpose with or without fee is hereby granted, provided that the above
+// copyright notice and this permission notice appear in all copies.
+//
+// THE SOFTWARE IS PROVIDED "AS IS" AND THE AUTHOR DISCLAIMS ALL WARRANTIES
+// WITH REGARD TO THIS SOFTWARE INCLUDING ALL IMPLIED WARRANTIES OF
+// MERCHANTABILITY AND FITNESS. IN NO EVENT SHALL THE AUTHOR BE LIABLE FOR ANY
+// SPECIAL, DIRECT, INDIRECT, OR CONSEQUENTIAL DAMAGES OR ANY DAMAGES
+// WHATSOEVER RESULTING FROM LOSS OF USE, DATA OR PROFITS, WHETHER IN AN ACTION
+// OF CONTRACT, NEGLIGENCE OR OTHER TORTIOUS ACTION, ARISING OUT OF OR IN
+// CONNECTION WITH THE USE OR PERFORMANCE OF THIS SOFTWARE.
+
+package subprocess
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+)
+
+// ProcessStream reads a vector set from dec and calls process for each of its
+// test groups as soon as the group has been decoded, so that the whole vector
+// set never has to be in memory. That matters for ML-DSA and SLH-DSA, whose
+// vector sets can be hundreds of megabytes.
+//
+// Each call to process is given the vector set's algorithm and a vector set
+// containing just one test group and the fields that precede testGroups. Like
+// Pool, this relies on the test groups being independent, which holds for
+// every handler in this package, so handlers don't need to know that they
+// are being streamed. Those fields, including algorithm, must come before
+// testGroups, as they do in vector sets from the ACVP server.
+//
+// ProcessStream returns the fields of the vector set other than testGroups
+// and the responses to the test groups, which are the same as the result of
+// processing the whole vector set at once. If the vector set has no test
+// groups, process is never called.
+func ProcessStream(dec *json.Decoder, process func(algorithm string, vectorSet []byte) (any, error)) (fields map[string]json.RawMessage, responses []json.RawMessage, err error) {
+	if err := expectDelim(dec, '{'); err != nil {
+		return nil, nil, err
+	}
+
+	fields = make(map[string]json.RawMessage)
+	var sawGroups bool
+	for dec.More() {
+		token, err := dec.Token()
+		if err != nil {
+			return nil, nil, err
+		}
+		key, ok := token.(string)
+		if !ok {
+			return nil, nil, fmt.Errorf("expected the name of a field but found %v", token)
+		}
+
+		if key != "testGroups" {
+			if sawGroups {
+				return nil, nil, fmt.Errorf("field %q follows testGroups", key)
+			}
+			var value json.RawMessage
+			if err := dec.Decode(&value); err != nil {
+				return nil, nil, err
+			}
+			fields[key] = value
+			continue
+		}
+
+		if sawGroups {
+			return nil, nil, errors.New("vector set has more than one testGroups field")
+		}
+		sawGroups = true
+
+		var algorithm string
+		if err := json.Unmarshal(fields["algorithm"], &algorithm); err != nil {
+			return nil, nil, errors.New("vector set has no algorithm before its test groups")
+		}
+
+		if err := expectDelim(dec, '['); err != nil {
+			return nil, nil, fmt.Errorf("failed to parse test groups: %s", err)
+		}
+		// Groups are processed one at a time, so duplicate tgIds must be
+		// found here.
+		var ids idChecker
+		for dec.More() {
+			var group json.RawMessage
+			if err := dec.Decode(&group); err != nil {
+				return nil, nil, fmt.Errorf("failed to parse test group: %s", err)
+			}
+			if err := ids.checkGroup(group); err != nil {
+				return nil, nil, err
+			}
+
+			fields["testGroups"] = append(append([]byte{'['}, group...), ']')
+			vectorSet, err := json.Marshal(fields)
+			delete(fields, "testGroups")
+			if err != nil {
+				return nil, nil, err
+			}
+
+			result, err := process(algorithm, vectorSet)
+			if err != nil {
+				return nil, nil, err
+			}
+			resultBytes, err := json.Marshal(result)
+			if err != nil {
+				return nil, nil, err
+			}
+			var groupResponses []json.RawMessage
+			if err := json.Unmarshal(resultBytes, &groupResponses); err != nil {
+				return nil, nil, fmt.Errorf("handler result can't be split into test groups: %s", err)
+			}
+			responses = append(responses, groupResponses...)
+		}
+		if err := expectDelim(dec, ']'); err != nil {
+			return nil, nil, err
+		}
+	}
+
+	if err := expectDelim(dec, '}'); err != nil {
+		return nil, nil, err
+	}
+	return fields, responses, nil
+}
+
+func expectDelim(dec *json.Decoder, want json.Delim) error {
+	token, err := dec.Token()
+	if err != nil {
+		return err
+	}
+	if token != want {
+		return fmt.Errorf("expected %q but found %v", want, token)
+	}
+	return nil
+}
@@ -0,0 +1,212 @@
+// Copyright (c) 2026, Google Inc.
+//
+// Permission to use, copy, modify, and/or distribute this software for any
+// purpose with or without fee is hereby granted, provided that the above
+// copyright notice and this permission notice appear in all copies.
+//
+// THE SOFTWARE IS PROVIDED "AS IS" AND THE AUTHOR DISCLAIMS ALL WARRANTIES
+// WITH REGARD TO THIS SOFTWARE INCLUDING ALL IMPLIED WARRANTIES OF
+// MERCHANTABILITY AND FITNESS. IN NO EVENT SHALL THE AUTHOR BE LIABLE FOR ANY
+// SPECIAL, DIRECT, INDIRECT, OR CONSEQUENTIAL DAMAGES OR ANY DAMAGES
+// WHATSOEVER RESULTING FROM LOSS OF USE, DATA OR PROFITS, WHETHER IN AN ACTION
+// OF CONTRACT, NEGLIGENCE OR OTHER TORTIOUS ACTION, ARISING OUT OF OR IN
+// CONNECTION WITH THE USE OR PERFORMANCE OF THIS SOFTWARE.
+
+package subprocess
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+const cShakeStreamVectorSet = `{"vsId": 1, "algorithm": "cSHAKE-128", "revision": "1.0", "testGroups": [
+	{"tgId": 1, "testType": "AFT", "tests": [
+		{"tcId": 1, "len": 16, "msg": "0001", "outLen": 32, "functionName": "", "customization": "secret"},
+		{"tcId": 2, "len": 8, "msg": "02", "outLen": 64, "functionName": "", "customization": ""}
+	]},
+	{"tgId": 2, "testType": "AFT", "hexCustomization": true, "tests": [
+		{"tcId": 3, "len": 8, "msg": "03", "outLen": 16, "functionName": "", "customizationHex": "abcd"}
+	]},
+	{"tgId": 3, "testType": "MCT", "minOutLen": 16, "maxOutLen": 32, "outLenIncrement": 8, "tests": [
+		{"tcId": 4, "len": 16, "msg": "0405", "functionName": "", "customization": ""}
+	]}
+]}`
+
+// cShakeStreamResponder answers cSHAKE commands with output that depends on
+// all the arguments.
+func cShakeStreamResponder(cmd string, args [][]byte) ([][]byte, error) {
+	var fill byte
+	for _, arg := range args {
+		for _, b := range arg {
+			fill += b
+		}
+	}
+	switch cmd {
+	case "cSHAKE-128":
+		return [][]byte{bytes.Repeat([]byte{fill}, int(args[1][0]))}, nil
+	case "cSHAKE-128/MCT":
+		return [][]byte{bytes.Repeat([]byte{fill}, 3), args[3], args[6]}, nil
+	}
+	return nil, fmt.Errorf("unexpected command %q", cmd)
+}
+
+func TestProcessStream(t *testing.T) {
+	m := pipeModule(t, 0, cShakeStreamResponder)
+	defer m.Close()
+
+	buffered, err := m.Process("cSHAKE-128", []byte(cShakeStreamVectorSet))
+	if err != nil {
+		t.Fatal(err)
+	}
+	want, err := json.Marshal(buffered)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var algorithms []string
+	fields, responses, err := ProcessStream(json.NewDecoder(strings.NewReader(cShakeStreamVectorSet)), func(algorithm string, vectorSet []byte) (any, error) {
+		algorithms = append(algorithms, algorithm)
+		return m.Process(algorithm, vectorSet)
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	got, err := json.Marshal(responses)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !bytes.Equal(got, want) {
+		t.Errorf("streamed result differs from buffered result:\n%s\n%s", got, want)
+	}
+	if len(algorithms) != 3 {
+		t.Errorf("%d groups were processed separately, want 3", len(algorithms))
+	}
+	if string(fields["vsId"]) != "1" || string(fields["algorithm"]) != `"cSHAKE-128"` {
+		t.Errorf("unexpected fields: %s", fields)
+	}
+}
+
+func TestProcessStreamFieldAfterGroups(t *testing.T) {
+	vectorSet := `{"algorithm": "cSHAKE-128", "testGroups": [], "vsId": 1}`
+	_, _, err := ProcessStream(json.NewDecoder(strings.NewReader(vectorSet)), func(string, []byte) (any, error) {
+		return nil, nil
+	})
+	if err == nil || !strings.Contains(err.Error(), `"vsId" follows testGroups`) {
+		t.Errorf("got error %v", err)
+	}
+}
+
+// writeSLHDSASigVerVectorSet writes an SLH-DSA-SHAKE-256f sigVer vector set,
+// of about 5MB per group, to a file and returns its name.
+func writeSLHDSASigVerVectorSet(b *testing.B, numGroups int) string {
+	const testsPerGroup = 50
+	var vectorSet bytes.Buffer
+	vectorSet.WriteString(`{"vsId": 1, "algorithm": "SLH-DSA", "mode": "sigVer", "revision": "FIPS205", "testGroups": [`)
+	signature := hexRepeat(0x5a, 49856)
+	for i := 0; i < numGroups; i++ {
+		if i > 0 {
+			vectorSet.WriteString(",")
+		}
+		fmt.Fprintf(&vectorSet, `{"tgId": %d, "testType": "AFT", "parameterSet": "SLH-DSA-SHAKE-256f", "tests": [`, i+1)
+		for j := 0; j < testsPerGroup; j++ {
+			if j > 0 {
+				vectorSet.WriteString(",")
+			}
+			fmt.Fprintf(&vectorSet, `{"tcId": %d, "pk": "%s", "message": "aa", "signature": "%s"}`, i*testsPerGroup+j+1, hexRepeat(0x11, 64), signature)
+		}
+		vectorSet.WriteString("]}")
+	}
+	vectorSet.WriteString("]}")
+
+	path := filepath.Join(b.TempDir(), "slhdsa.json")
+	if err := os.WriteFile(path, vectorSet.Bytes(), 0644); err != nil {
+		b.Fatal(err)
+	}
+	return path
+}
+
+// peakHeap samples the heap until stop is called, which returns the largest
+// size seen.
+func peakHeap() (stop func() uint64) {
+	var peak uint64
+	var wg sync.WaitGroup
+	done := make(chan struct{})
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		var stats runtime.MemStats
+		for {
+			runtime.ReadMemStats(&stats)
+			if stats.HeapInuse > peak {
+				peak = stats.HeapInuse
+			}
+			select {
+			case <-done:
+				return
+			case <-time.After(time.Millisecond):
+			}
+		}
+	}()
+	return func() uint64 {
+		close(done)
+		wg.Wait()
+		return peak
+	}
+}
+
+func slhdsaSigVerResponder(cmd string, args [][]byte) ([][]byte, error) {
+	return [][]byte{{1}}, nil
+}
+
+// BenchmarkSLHDSABuffered and BenchmarkSLHDSAStreamed report the peak heap
+// size while processing a 100MB SLH-DSA vector set from a file.
+func BenchmarkSLHDSABuffered(b *testing.B) {
+	path := writeSLHDSASigVerVectorSet(b, 20)
+	m := pipeModule(b, 0, slhdsaSigVerResponder)
+	defer m.Close()
+
+	runtime.GC()
+	b.ResetTimer()
+	stop := peakHeap()
+	for i := 0; i < b.N; i++ {
+		vectorSet, err := os.ReadFile(path)
+		if err != nil {
+			b.Fatal(err)
+		}
+		if _, err := m.Process("SLH-DSA", vectorSet); err != nil {
+			b.Fatal(err)
+		}
+	}
+	b.ReportMetric(float64(stop())/(1<<20), "peak-heap-MB")
+}
+
+func BenchmarkSLHDSAStreamed(b *testing.B) {
+	path := writeSLHDSASigVerVectorSet(b, 20)
+	m := pipeModule(b, 0, slhdsaSigVerResponder)
+	defer m.Close()
+
+	runtime.GC()
+	b.ResetTimer()
+	stop := peakHeap()
+	for i := 0; i < b.N; i++ {
+		f, err := os.Open(path)
+		if err != nil {
+			b.Fatal(err)
+		}
+		_, _, err = ProcessStream(json.NewDecoder(f), m.Process)
+		f.Close()
+		if err != nil {
+			b.Fatal(err)
+		}
+	}
+	b.ReportMetric(float64(stop())/(1<<20), "peak-heap-MB")
+}
@@ -17,6 +17,7 @@
 package subprocess
 
 import (
+	"context"
 	"encoding/binary"
 	"encoding/json"
 	"errors"
@@ -24,6 +25,10 @@ import (
 	"io"
 	"os"
 	"os/exec"
+	"slices"
+	"sync"
+	"sync/atomic"
+	"time"
 )
 
 // Transactable provides an interface to allow test injection of transactions
@@ -41,13 +46,45 @@ type Subprocess struct {
 	cmd        *exec.Cmd
 	stdin      io.WriteCloser
 	stdout     io.ReadCloser
-	primitives map[string]primitive
+	primitives map[string]Primitive
 	// supportsFlush is true if the modulewrapper indicated that it wants to receive flush commands.
 	supportsFlush bool
+	// supportsDeflate is true if the modulewrapper indicated that it can compress messages. compressRequests and compressResults are set once it has been asked to. See EnableCompression.
+	supportsDeflate  bool
+	compressRequests bool
+	compressResults  bool
 	// pendingReads is a queue of expected responses. `readerRoutine` reads each response and calls the callback in the matching pendingRead.
 	pendingReads chan pendingRead
 	// readerFinished is a channel that is closed if `readerRoutine` has finished (e.g. because of a read error).
 	readerFinished chan struct{}
+	// aborted is closed, and abortErr set, when a transaction is abandoned (e.g. because its result was rejected or its context was cancelled). Later responses from the modulewrapper would not match `pendingReads`, so no further transactions are possible.
+	aborted   chan struct{}
+	abortOnce sync.Once
+	abortErr  error
+	// closing is set by Close, so that reads of pending results that it interrupts aren't reported as errors.
+	closing atomic.Bool
+	// timeout, if non-zero, is how long the modulewrapper may take to respond to each command.
+	timeout time.Duration
+	// traceWriter, if not nil, receives a record of each command. See Trace.
+	traceWriter io.Writer
+	traceData   bool
+	// maxRetries and retryBackoff control the resending of commands after transient failures. See SetRetryPolicy.
+	maxRetries   int
+	retryBackoff time.Duration
+	// partialResults is true if vector sets are processed one test group at a time. See EnablePartialResults.
+	partialResults bool
+	// sortResults is true if the test cases of each group in a result are sorted by tcId. See EnableSortedResults.
+	sortResults bool
+	// progress, if not nil, is told about answered test cases. See SetProgress.
+	progress ProgressFunc
+	// supported, once Config has been called, maps each algorithm in the modulewrapper's configuration to the modes given for it. See checkSupported.
+	supported map[string][]string
+	// maxMessageBytes, if positive, limits the messages and outputs of vector sets. See SetMaxMessageSize.
+	maxMessageBytes int
+	// byteOrder is the byte order of the counts and lengths that frame messages to and from the modulewrapper. See SetByteOrder.
+	byteOrder binary.ByteOrder
+	// inFlight, if not nil, holds a value for each command that has been sent but whose response hasn't been read. Its capacity is the limit set by SetMaxInFlight.
+	inFlight chan struct{}
 }
 
 // pendingRead represents an expected response from the modulewrapper.
@@ -60,10 +97,23 @@ type pendingRead struct {
 	// cmd is the command that requested this read for logging purposes.
 	cmd                string
 	expectedNumResults int
+	// trace, if not nil, is completed and written once the result has been read.
+	trace *traceRecord
+	// args and completed are only set when retries are enabled. args are kept so that the command can be resent, and completed is closed once the callback has run.
+	args      [][]byte
+	completed chan struct{}
+	// label, if not nil, identifies the test case that sent the command in errors. See withTestCase.
+	label *testCase
 }
 
 // New returns a new Subprocess middle layer that runs the given binary.
 func New(path string) (*Subprocess, error) {
+	return NewWithTimeout(path, 0)
+}
+
+// NewWithTimeout is like New, but fails any command that the binary doesn't
+// respond to within timeout. A zero timeout means no limit.
+func NewWithTimeout(path string, timeout time.Duration) (*Subprocess, error) {
 	cmd := exec.Command(path)
 	cmd.Stderr = os.Stderr
 	stdin, err := cmd.StdinPipe()
@@ -79,24 +129,13 @@ func New(path string) (*Subprocess, error) {
 		return nil, err
 	}
 
-	return NewWithIO(cmd, stdin, stdout), nil
+	return NewWithIOTimeout(cmd, stdin, stdout, timeout), nil
 }
 
-// maxPending is the maximum number of requests that can be in the pipeline.
-const maxPending = 4096
-
-// NewWithIO returns a new Subprocess middle layer with the given ReadCloser and
-// WriteCloser. The returned Subprocess will call Wait on the Cmd when closed.
-func NewWithIO(cmd *exec.Cmd, in io.WriteCloser, out io.ReadCloser) *Subprocess {
-	m := &Subprocess{
-		cmd:            cmd,
-		stdin:          in,
-		stdout:         out,
-		pendingReads:   make(chan pendingRead, maxPending),
-		readerFinished: make(chan struct{}),
-	}
-
-	m.primitives = map[string]primitive{
+// Primitives returns a new map from the name of each ACVP algorithm that
+// this package supports to its handler.
+func Primitives() map[string]Primitive {
+	primitives := map[string]Primitive{
 		"SHA-1":             &hashPrimitive{"SHA-1", 20},
 		"SHA2-224":          &hashPrimitive{"SHA2-224", 28},
 		"SHA2-256":          &hashPrimitive{"SHA2-256", 32},
@@ -110,18 +149,38 @@ func NewWithIO(cmd *exec.Cmd, in io.WriteCloser, out io.ReadCloser) *Subprocess
 		"SHA3-512":          &hashPrimitive{"SHA3-512", 64},
 		"SHAKE-128":         &shake{"SHAKE-128", 16},
 		"SHAKE-256":         &shake{"SHAKE-256", 32},
+		"cSHAKE-128":        &cShake{"cSHAKE-128"},
+		"cSHAKE-256":        &cShake{"cSHAKE-256"},
+		"KMAC-128":          &kmac{"KMAC-128"},
+		"KMAC-256":          &kmac{"KMAC-256"},
+		"TupleHash-128":     &tupleHash{"TupleHash-128"},
+		"TupleHash-256":     &tupleHash{"TupleHash-256"},
+		"ParallelHash-128":  &parallelHash{"ParallelHash-128"},
+		"ParallelHash-256":  &parallelHash{"ParallelHash-256"},
 		"ACVP-AES-ECB":      &blockCipher{"AES", 16, 2, true, false, iterateAES},
 		"ACVP-AES-CBC":      &blockCipher{"AES-CBC", 16, 2, true, true, iterateAESCBC},
+		"ACVP-AES-CBC-CS1":  &blockCipher{"AES-CBC-CS1", 16, 1, false, true, iterateAESCBC},
+		"ACVP-AES-CBC-CS2":  &blockCipher{"AES-CBC-CS2", 16, 1, false, true, iterateAESCBC},
 		"ACVP-AES-CBC-CS3":  &blockCipher{"AES-CBC-CS3", 16, 1, false, true, iterateAESCBC},
 		"ACVP-AES-CTR":      &blockCipher{"AES-CTR", 16, 1, false, true, nil},
+		"ACVP-AES-CFB1":     &blockCipher{"AES-CFB1", 16, 1, false, true, iterateAESCFBSegments(1)},
+		"ACVP-AES-CFB8":     &blockCipher{"AES-CFB8", 16, 1, false, true, iterateAESCFBSegments(8)},
+		"ACVP-AES-CFB128":   &blockCipher{"AES-CFB128", 16, 2, true, true, iterateAESCBC},
+		"ACVP-AES-OFB":      &blockCipher{"AES-OFB", 16, 2, true, true, iterateAESCBC},
 		"ACVP-TDES-ECB":     &blockCipher{"3DES-ECB", 8, 3, true, false, iterate3DES},
 		"ACVP-TDES-CBC":     &blockCipher{"3DES-CBC", 8, 3, true, true, iterate3DESCBC},
+		"ACVP-TDES-CFB64":   &blockCipher{"3DES-CFB64", 8, 3, true, true, iterate3DESFeedback},
+		"ACVP-TDES-OFB":     &blockCipher{"3DES-OFB", 8, 3, true, true, iterate3DESFeedback},
 		"ACVP-AES-XTS":      &xts{},
-		"ACVP-AES-GCM":      &aead{"AES-GCM", false},
-		"ACVP-AES-GMAC":     &aead{"AES-GCM", false},
-		"ACVP-AES-CCM":      &aead{"AES-CCM", true},
-		"ACVP-AES-KW":       &aead{"AES-KW", false},
-		"ACVP-AES-KWP":      &aead{"AES-KWP", false},
+		"ACVP-AES-FF1":      &fpe{"AES-FF1", 0},
+		"ACVP-AES-FF3-1":    &fpe{"AES-FF3-1", 56},
+		"ACVP-AES-GCM":      &aead{"AES-GCM", false, 0, false},
+		"ACVP-AES-GMAC":     &aead{"AES-GCM", false, 0, true},
+		"ACVP-AES-GCM-SIV":  &aead{"AES-GCM-SIV", true, 96, false},
+		"ACVP-AES-CCM":      &aead{"AES-CCM", true, 0, false},
+		"ACVP-AES-KW":       &aead{"AES-KW", false, 0, false},
+		"ACVP-AES-KWP":      &aead{"AES-KWP", false, 0, false},
+		"ChaCha20-Poly1305": &aead{"ChaCha20-Poly1305", false, 96, false},
 		"HMAC-SHA-1":        &hmacPrimitive{"HMAC-SHA-1", 20},
 		"HMAC-SHA2-224":     &hmacPrimitive{"HMAC-SHA2-224", 28},
 		"HMAC-SHA2-256":     &hmacPrimitive{"HMAC-SHA2-256", 32},
@@ -135,35 +194,180 @@ func NewWithIO(cmd *exec.Cmd, in io.WriteCloser, out io.ReadCloser) *Subprocess
 		"HMAC-SHA3-512":     &hmacPrimitive{"HMAC-SHA3-512", 64},
 		"ctrDRBG":           &drbg{"ctrDRBG", map[string]bool{"AES-128": true, "AES-192": true, "AES-256": true}},
 		"hmacDRBG":          &drbg{"hmacDRBG", map[string]bool{"SHA-1": true, "SHA2-224": true, "SHA2-256": true, "SHA2-384": true, "SHA2-512": true, "SHA2-512/224": true, "SHA2-512/256": true, "SHA3-224": true, "SHA3-256": true, "SHA3-384": true, "SHA3-512": true}},
+		"hashDRBG":          &drbg{"hashDRBG", map[string]bool{"SHA-1": true, "SHA2-224": true, "SHA2-256": true, "SHA2-384": true, "SHA2-512": true, "SHA2-512/224": true, "SHA2-512/256": true, "SHA3-224": true, "SHA3-256": true, "SHA3-384": true, "SHA3-512": true}},
 		"KDF":               &kdfPrimitive{},
-		"KDA":               &hkdf{},
 		"TLS-v1.2":          &tlsKDF{},
 		"TLS-v1.3":          &tls13{},
-		"CMAC-AES":          &keyedMACPrimitive{"CMAC-AES"},
+		"CMAC-AES":          &keyedMACPrimitive{"CMAC-AES", 16, map[int]bool{16: true, 24: true, 32: true}, false},
+		"CMAC-TDES":         &keyedMACPrimitive{"CMAC-TDES", 8, map[int]bool{24: true}, false},
+		"CBC-MAC-AES":       &keyedMACPrimitive{"CBC-MAC-AES", 16, map[int]bool{16: true, 24: true, 32: true}, true},
+		"RetailMAC-TDES":    &keyedMACPrimitive{"RetailMAC-TDES", 8, map[int]bool{16: true}, true},
 		"RSA":               &rsa{},
+		"DSA":               &dsa{},
+		"KAS-ECC":           &kasECC{},
 		"KAS-ECC-SSC":       &kas{},
-		"KAS-FFC-SSC":       &kasDH{},
+		"KAS-FFC":           &kasDH{"KAS-FFC"},
+		"KAS-IFC":           &kasIFC{},
+		"KAS-FFC-SSC":       &kasDH{"KAS-FFC-SSC"},
+		"safePrimes":        &safePrimes{},
 		"PBKDF":             &pbkdf{},
 		"ML-KEM":            &mlkem{},
-		"kdf-components":    &ssh{},
+		"ML-DSA":            &mldsa{},
+		"SLH-DSA":           &slhdsa{},
+		"LMS":               &lms{},
+		"XMSS":              &xmss{"XMSS", xmssParameterSets},
+		"XMSS^MT":           &xmss{"XMSS^MT", xmssmtParameterSets},
+	}
+	ecdsaCurves := map[string]bool{"P-224": true, "P-256": true, "P-384": true, "P-521": true}
+	primitives["ECDSA"] = &revisions{
+		fallback:  &ecdsa{"ECDSA", ecdsaCurves, primitives, false},
+		revisions: map[string]Primitive{"1.0": &ecdsa{"ECDSA", ecdsaCurves, primitives, true}},
+	}
+	primitives["DetECDSA"] = &ecdsa{"DetECDSA", ecdsaCurves, primitives, false}
+	primitives["EDDSA"] = &eddsa{"EDDSA", map[string]bool{"ED-25519": true, "ED-448": true}}
+	primitives["KDA"] = &hkdf{primitives}
+	primitives["ConditioningComponent"] = &conditioning{primitives}
+	primitives["kdf-components"] = &kdfComponents{map[string]Primitive{
+		"ssh":       &ssh{},
+		"ikev2":     &ikev2{},
+		"snmp":      &snmp{},
+		"srtp":      &srtp{},
+		"ansix9.63": &ansiX963{},
+		"ansix9.42": &ansiX942{},
+	}}
+	return primitives
+}
+
+// RegisterHandler adds a handler for vector sets of the named algorithm, so
+// that algorithms that this package doesn't support can be processed without
+// changing it. It's an error if the algorithm already has a handler. Register
+// handlers before calling Config, which rejects modulewrappers that claim to
+// support unknown algorithms. See Primitive for what a handler must do.
+func (m *Subprocess) RegisterHandler(name string, h Primitive) error {
+	if _, ok := m.primitives[name]; ok {
+		return fmt.Errorf("algorithm %q already has a handler", name)
+	}
+	m.primitives[name] = h
+	return nil
+}
+
+// maxPending is the maximum number of requests that can be in the pipeline.
+const maxPending = 4096
+
+// NewWithIO returns a new Subprocess middle layer with the given ReadCloser and
+// WriteCloser. The returned Subprocess will call Wait on the Cmd when closed.
+func NewWithIO(cmd *exec.Cmd, in io.WriteCloser, out io.ReadCloser) *Subprocess {
+	return NewWithIOTimeout(cmd, in, out, 0)
+}
+
+// NewWithIOTimeout is like NewWithIO, but fails any command that isn't
+// responded to within timeout. The timeout applies separately to each
+// command, from when the previous response was read, and a zero timeout means
+// no limit. Once a command has timed out the Subprocess can't be used again.
+func NewWithIOTimeout(cmd *exec.Cmd, in io.WriteCloser, out io.ReadCloser, timeout time.Duration) *Subprocess {
+	m := &Subprocess{
+		cmd:            cmd,
+		stdin:          in,
+		stdout:         out,
+		pendingReads:   make(chan pendingRead, maxPending),
+		readerFinished: make(chan struct{}),
+		aborted:        make(chan struct{}),
+		timeout:        timeout,
+		byteOrder:      binary.LittleEndian,
 	}
-	m.primitives["ECDSA"] = &ecdsa{"ECDSA", map[string]bool{"P-224": true, "P-256": true, "P-384": true, "P-521": true}, m.primitives}
-	m.primitives["DetECDSA"] = &ecdsa{"DetECDSA", map[string]bool{"P-224": true, "P-256": true, "P-384": true, "P-521": true}, m.primitives}
-	m.primitives["EDDSA"] = &eddsa{"EDDSA", map[string]bool{"ED-25519": true}}
+
+	m.primitives = Primitives()
 
 	go m.readerRoutine()
 	return m
 }
 
-// Close signals the child process to exit and waits for it to complete.
+// SetByteOrder sets the byte order of the counts and lengths that frame
+// messages to and from the modulewrapper, for legacy modules that expect
+// big-endian framing. The default is binary.LittleEndian. Arguments and results,
+// such as lengths passed to handlers' commands, are unchanged. Call
+// SetByteOrder before using m, including before Config.
+func (m *Subprocess) SetByteOrder(order binary.ByteOrder) {
+	m.byteOrder = order
+}
+
+// SetMaxInFlight limits the number of commands that m sends to the
+// modulewrapper before it has read their responses, which bounds the memory
+// that the modulewrapper needs for buffering. Once the limit is reached,
+// TransactAsync waits for a response before sending another command. Callbacks
+// still run in the order that the commands were sent. A limit of one means
+// that each command is only sent once the previous one has been answered, and
+// a limit of zero or less restores the default, which is only bounded by the
+// size of the internal queue. Call SetMaxInFlight before using m.
+func (m *Subprocess) SetMaxInFlight(n int) {
+	if n <= 0 {
+		m.inFlight = nil
+		return
+	}
+	m.inFlight = make(chan struct{}, n)
+}
+
+// acquireInFlight waits until another command may be sent. See
+// SetMaxInFlight.
+func (m *Subprocess) acquireInFlight(ctx context.Context) error {
+	if m.inFlight == nil {
+		return nil
+	}
+	select {
+	case m.inFlight <- struct{}{}:
+		return nil
+	default:
+	}
+
+	// Ensure that the modulewrapper answers the outstanding commands.
+	if err := m.flush(); err != nil {
+		return err
+	}
+	select {
+	case m.inFlight <- struct{}{}:
+		return nil
+	case <-ctx.Done():
+		return m.abort(ctx.Err())
+	case <-m.aborted:
+		return m.abortErr
+	}
+}
+
+// Close signals the child process to exit and waits for it to complete. If a
+// transaction was abandoned then the child may never respond, so it is killed.
 func (m *Subprocess) Close() {
+	m.closing.Store(true)
 	m.stdout.Close()
 	m.stdin.Close()
-	m.cmd.Wait()
+	if m.cmd != nil {
+		if m.isAborted() && m.cmd.Process != nil {
+			m.cmd.Process.Kill()
+		}
+		m.cmd.Wait()
+	}
 	close(m.pendingReads)
 	<-m.readerFinished
 }
 
+// abort marks m as unusable because of err and returns the error that first
+// caused m to be aborted.
+func (m *Subprocess) abort(err error) error {
+	m.abortOnce.Do(func() {
+		m.abortErr = err
+		close(m.aborted)
+	})
+	return m.abortErr
+}
+
+func (m *Subprocess) isAborted() bool {
+	select {
+	case <-m.aborted:
+		return true
+	default:
+		return false
+	}
+}
+
 func (m *Subprocess) flush() error {
 	if !m.supportsFlush {
 		return nil
@@ -171,17 +375,21 @@ func (m *Subprocess) flush() error {
 
 	const cmd = "flush"
 	buf := make([]byte, 8, 8+len(cmd))
-	binary.LittleEndian.PutUint32(buf, 1)
-	binary.LittleEndian.PutUint32(buf[4:], uint32(len(cmd)))
+	m.byteOrder.PutUint32(buf, 1)
+	m.byteOrder.PutUint32(buf[4:], uint32(len(cmd)))
 	buf = append(buf, []byte(cmd)...)
 
-	if _, err := m.stdin.Write(buf); err != nil {
-		return err
-	}
-	return nil
+	return m.writeMessage(buf)
 }
 
-func (m *Subprocess) enqueueRead(pending pendingRead) error {
+func (m *Subprocess) enqueueRead(ctx context.Context, pending pendingRead) error {
+	if m.isAborted() {
+		return m.abortErr
+	}
+	if err := ctx.Err(); err != nil {
+		return m.abort(err)
+	}
+
 	select {
 	case <-m.readerFinished:
 		panic("attempted to enqueue request after the reader failed")
@@ -197,7 +405,11 @@ func (m *Subprocess) enqueueRead(pending pendingRead) error {
 		if err := m.flush(); err != nil {
 			return err
 		}
-		m.pendingReads <- pending
+		select {
+		case m.pendingReads <- pending:
+		case <-ctx.Done():
+			return m.abort(ctx.Err())
+		}
 	}
 
 	return nil
@@ -206,65 +418,131 @@ func (m *Subprocess) enqueueRead(pending pendingRead) error {
 // TransactAsync performs a single request--response pair with the subprocess.
 // The callback will run at some future point, in a separate goroutine. All
 // callbacks will, however, be run in the order that TransactAsync was called.
-// Use Flush to wait for all outstanding callbacks.
+// Use Flush to wait for all outstanding callbacks. If the subprocess has been
+// aborted, for example by a timeout, the command is dropped and Flush reports
+// the error.
 func (m *Subprocess) TransactAsync(cmd string, expectedNumResults int, args [][]byte, callback func(result [][]byte) error) {
-	if err := m.enqueueRead(pendingRead{nil, callback, cmd, expectedNumResults}); err != nil {
-		panic(err)
+	m.transactAsyncLabelled(nil, cmd, expectedNumResults, args, callback)
+}
+
+// transactLabelled is Transact. Errors from synchronous commands are
+// attributed by withTestCase itself.
+func (m *Subprocess) transactLabelled(label *testCase, cmd string, expectedNumResults int, args ...[]byte) ([][]byte, error) {
+	return m.Transact(cmd, expectedNumResults, args...)
+}
+
+// transactAsyncLabelled is like TransactAsync, but errors caused by the
+// command are annotated with label. See withTestCase. A failure to send the
+// command aborts m, and is returned by the next call to Flush.
+func (m *Subprocess) transactAsyncLabelled(label *testCase, cmd string, expectedNumResults int, args [][]byte, callback func(result [][]byte) error) {
+	if err := m.transactAsync(context.Background(), label, cmd, expectedNumResults, args, callback); err != nil {
+		m.abort(label.annotate(PhaseModule, err))
 	}
+}
 
+func (m *Subprocess) transactAsync(ctx context.Context, label *testCase, cmd string, expectedNumResults int, args [][]byte, callback func(result [][]byte) error) error {
+	pending := pendingRead{nil, callback, cmd, expectedNumResults, m.newTraceRecord(cmd, args), nil, nil, label}
+	if m.maxRetries > 0 {
+		pending.args = args
+		pending.completed = make(chan struct{})
+	}
+	if err := m.acquireInFlight(ctx); err != nil {
+		return err
+	}
+	if err := m.enqueueRead(ctx, pending); err != nil {
+		return err
+	}
+
+	if err := m.writeCommand(cmd, args); err != nil {
+		return err
+	}
+	if pending.completed == nil {
+		return nil
+	}
+
+	// With retries, only one command is outstanding at a time so that the
+	// reader can resend it without reordering the responses.
+	if err := m.flush(); err != nil {
+		return err
+	}
+	select {
+	case <-pending.completed:
+		return nil
+	case <-ctx.Done():
+		return m.abort(ctx.Err())
+	case <-m.aborted:
+		return m.abortErr
+	}
+}
+
+func (m *Subprocess) writeCommand(cmd string, args [][]byte) error {
 	argLength := len(cmd)
 	for _, arg := range args {
 		argLength += len(arg)
 	}
 
 	buf := make([]byte, 4*(2+len(args)), 4*(2+len(args))+argLength)
-	binary.LittleEndian.PutUint32(buf, uint32(1+len(args)))
-	binary.LittleEndian.PutUint32(buf[4:], uint32(len(cmd)))
+	m.byteOrder.PutUint32(buf, uint32(1+len(args)))
+	m.byteOrder.PutUint32(buf[4:], uint32(len(cmd)))
 	for i, arg := range args {
-		binary.LittleEndian.PutUint32(buf[4*(i+2):], uint32(len(arg)))
+		m.byteOrder.PutUint32(buf[4*(i+2):], uint32(len(arg)))
 	}
 	buf = append(buf, []byte(cmd)...)
 	for _, arg := range args {
 		buf = append(buf, arg...)
 	}
 
-	if _, err := m.stdin.Write(buf); err != nil {
-		panic(err)
-	}
+	return m.writeMessage(buf)
 }
 
 // Flush tells the subprocess to complete all outstanding requests and waits
 // for all outstanding TransactAsync callbacks to complete.
 func (m *Subprocess) Flush() error {
+	return m.flushContext(context.Background())
+}
+
+func (m *Subprocess) flushContext(ctx context.Context) error {
 	if m.supportsFlush {
 		m.flush()
 	}
 
 	done := make(chan struct{})
-	if err := m.enqueueRead(pendingRead{barrierCallback: func() {
+	if err := m.enqueueRead(ctx, pendingRead{barrierCallback: func() {
 		close(done)
 	}}); err != nil {
 		return err
 	}
 
-	<-done
-	return nil
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		return m.abort(ctx.Err())
+	case <-m.aborted:
+		return m.abortErr
+	}
 }
 
 // Barrier runs callback after all outstanding TransactAsync callbacks have
 // been run.
 func (m *Subprocess) Barrier(callback func()) error {
-	return m.enqueueRead(pendingRead{barrierCallback: callback})
+	return m.enqueueRead(context.Background(), pendingRead{barrierCallback: callback})
 }
 
 func (m *Subprocess) Transact(cmd string, expectedNumResults int, args ...[]byte) ([][]byte, error) {
+	return m.transact(context.Background(), cmd, expectedNumResults, args...)
+}
+
+func (m *Subprocess) transact(ctx context.Context, cmd string, expectedNumResults int, args ...[]byte) ([][]byte, error) {
 	done := make(chan struct{})
 	var result [][]byte
-	m.TransactAsync(cmd, expectedNumResults, args, func(r [][]byte) error {
+	if err := m.transactAsync(ctx, nil, cmd, expectedNumResults, args, func(r [][]byte) error {
 		result = r
 		close(done)
 		return nil
-	})
+	}); err != nil {
+		return nil, err
+	}
 
 	if err := m.flush(); err != nil {
 		return nil, err
@@ -273,11 +551,67 @@ func (m *Subprocess) Transact(cmd string, expectedNumResults int, args ...[]byte
 	select {
 	case <-done:
 		return result, nil
+	case <-ctx.Done():
+		return nil, fmt.Errorf("abandoned %q: %w", cmd, m.abort(ctx.Err()))
+	case <-m.aborted:
+		return nil, m.abortErr
 	case <-m.readerFinished:
 		panic("was still waiting for a result when the reader finished")
 	}
 }
 
+// WithContext returns a Transactable that sends commands to m, but that
+// abandons any outstanding transaction once ctx is done. After that, every
+// use of m fails with the context's error, because the responses from the
+// modulewrapper no longer match the requests.
+func (m *Subprocess) WithContext(ctx context.Context) Transactable {
+	return &contextTransactable{m, ctx}
+}
+
+type contextTransactable struct {
+	m   *Subprocess
+	ctx context.Context
+}
+
+func (c *contextTransactable) Transact(cmd string, expectedNumResults int, args ...[]byte) ([][]byte, error) {
+	return c.m.transact(c.ctx, cmd, expectedNumResults, args...)
+}
+
+// TransactAsync can't return an error, so any failure aborts the subprocess
+// and is returned by the next call to Flush.
+func (c *contextTransactable) TransactAsync(cmd string, expectedNumResults int, args [][]byte, callback func(result [][]byte) error) {
+	c.transactAsyncLabelled(nil, cmd, expectedNumResults, args, callback)
+}
+
+func (c *contextTransactable) transactLabelled(label *testCase, cmd string, expectedNumResults int, args ...[]byte) ([][]byte, error) {
+	return c.Transact(cmd, expectedNumResults, args...)
+}
+
+func (c *contextTransactable) transactAsyncLabelled(label *testCase, cmd string, expectedNumResults int, args [][]byte, callback func(result [][]byte) error) {
+	if err := c.m.transactAsync(c.ctx, label, cmd, expectedNumResults, args, callback); err != nil {
+		c.m.abort(label.annotate(PhaseModule, err))
+	}
+}
+
+func (c *contextTransactable) Barrier(callback func()) error {
+	return c.m.enqueueRead(c.ctx, pendingRead{barrierCallback: callback})
+}
+
+func (c *contextTransactable) Flush() error {
+	return c.m.flushContext(c.ctx)
+}
+
+// timeoutError is the error when the modulewrapper doesn't respond to a
+// command in time.
+type timeoutError struct {
+	cmd     string
+	timeout time.Duration
+}
+
+func (e *timeoutError) Error() string {
+	return fmt.Sprintf("modulewrapper did not respond to %q within %s", e.cmd, e.timeout)
+}
+
 func (m *Subprocess) readerRoutine() {
 	defer close(m.readerFinished)
 
@@ -286,41 +620,127 @@ func (m *Subprocess) readerRoutine() {
 			pendingRead.barrierCallback()
 		}
 
-		if pendingRead.callback == nil {
+		if pendingRead.callback == nil || m.isAborted() {
 			continue
 		}
 
-		result, err := m.readResult(pendingRead.cmd, pendingRead.expectedNumResults)
+		result, err := m.readResultWithRetries(pendingRead)
+		if m.inFlight != nil {
+			<-m.inFlight
+		}
 		if err != nil {
-			panic(fmt.Errorf("failed to read from subprocess: %w", err))
+			if m.isAborted() || m.closing.Load() {
+				// The read was probably interrupted by Close.
+				continue
+			}
+			if crashed := moduleCrashed(err); crashed != nil {
+				m.abort(pendingRead.label.annotate(PhaseModule, fmt.Errorf("reading the result of %q: %w", pendingRead.cmd, crashed)))
+				continue
+			}
+			m.abort(pendingRead.label.annotate(PhaseModule, fmt.Errorf("failed to read from subprocess: %w", err)))
+			continue
+		}
+		if m.isAborted() {
+			continue
+		}
+		if pendingRead.trace != nil {
+			m.writeTrace(pendingRead.trace, result)
 		}
 
 		if err := pendingRead.callback(result); err != nil {
-			panic(fmt.Errorf("result from subprocess was rejected: %w", err))
+			// The error is reported by Flush, or by whichever call is
+			// waiting for the result.
+			m.abort(pendingRead.label.annotate(PhaseResult, err))
+			continue
+		}
+		if pendingRead.completed != nil {
+			close(pendingRead.completed)
+		}
+	}
+}
+
+// readResultWithRetries reads the result of pendingRead, resending the
+// command if the modulewrapper reports a transient failure and retries are
+// enabled. A transient failure that isn't retried aborts m.
+func (m *Subprocess) readResultWithRetries(pendingRead pendingRead) ([][]byte, error) {
+	backoff := m.retryBackoff
+	for attempt := 0; ; attempt++ {
+		var timer *time.Timer
+		if m.timeout != 0 {
+			cmd := pendingRead.cmd
+			timer = time.AfterFunc(m.timeout, func() {
+				m.abort(pendingRead.label.annotate(PhaseModule, &timeoutError{cmd, m.timeout}))
+			})
+		}
+		result, err := m.readResult(pendingRead.cmd, pendingRead.expectedNumResults)
+		if timer != nil {
+			timer.Stop()
+		}
+
+		transient, ok := err.(*transientError)
+		if !ok {
+			return result, err
+		}
+		if pendingRead.completed == nil || attempt >= m.maxRetries {
+			if attempt > 0 {
+				err = fmt.Errorf("%w (after %d retries)", err, attempt)
+			}
+			return nil, m.abort(pendingRead.label.annotate(PhaseModule, err))
+		}
+
+		time.Sleep(backoff)
+		backoff *= 2
+		if err := m.writeCommand(transient.cmd, pendingRead.args); err != nil {
+			return nil, err
+		}
+		if err := m.flush(); err != nil {
+			return nil, err
 		}
 	}
 }
 
 func (m *Subprocess) readResult(cmd string, expectedNumResults int) ([][]byte, error) {
+	var r io.Reader = m.stdout
+	if m.compressResults {
+		var err error
+		if r, err = decodeMessage(m.stdout, m.byteOrder); err != nil {
+			return nil, err
+		}
+	}
+
 	buf := make([]byte, 4)
 
-	if _, err := io.ReadFull(m.stdout, buf); err != nil {
+	if _, err := io.ReadFull(r, buf); err != nil {
 		return nil, err
 	}
 
-	numResults := binary.LittleEndian.Uint32(buf)
+	numResults := m.byteOrder.Uint32(buf)
+	if numResults == transientResultCount {
+		if _, err := io.ReadFull(r, buf); err != nil {
+			return nil, err
+		}
+		length := m.byteOrder.Uint32(buf)
+		if length > 1<<16 {
+			return nil, fmt.Errorf("transient failure message from %q too large (%d bytes)", cmd, length)
+		}
+		message := make([]byte, length)
+		if _, err := io.ReadFull(r, message); err != nil {
+			return nil, err
+		}
+		return nil, &transientError{cmd, string(message)}
+	}
 	if int(numResults) != expectedNumResults {
 		return nil, fmt.Errorf("expected %d results from %q but got %d", expectedNumResults, cmd, numResults)
 	}
 
 	buf = make([]byte, 4*numResults)
-	if _, err := io.ReadFull(m.stdout, buf); err != nil {
+	if _, err := io.ReadFull(r, buf); err != nil {
 		return nil, err
 	}
 
 	var resultsLength uint64
 	for i := uint32(0); i < numResults; i++ {
-		resultsLength += uint64(binary.LittleEndian.Uint32(buf[4*i:]))
+		resultsLength += uint64(m.byteOrder.Uint32(buf[4*i:]))
 	}
 
 	if resultsLength > (1 << 30) {
@@ -328,14 +748,14 @@ func (m *Subprocess) readResult(cmd string, expectedNumResults int) ([][]byte, e
 	}
 
 	results := make([]byte, resultsLength)
-	if _, err := io.ReadFull(m.stdout, results); err != nil {
+	if _, err := io.ReadFull(r, results); err != nil {
 		return nil, err
 	}
 
 	ret := make([][]byte, 0, numResults)
 	var offset int
 	for i := uint32(0); i < numResults; i++ {
-		length := binary.LittleEndian.Uint32(buf[4*i:])
+		length := m.byteOrder.Uint32(buf[4*i:])
 		ret = append(ret, results[offset:offset+int(length)])
 		offset += int(length)
 	}
@@ -353,44 +773,158 @@ func (m *Subprocess) Config() ([]byte, error) {
 	}
 	var config []struct {
 		Algorithm string   `json:"algorithm"`
+		Mode      string   `json:"mode"`
 		Features  []string `json:"features"`
 	}
 	if err := json.Unmarshal(results[0], &config); err != nil {
 		return nil, errors.New("failed to parse config response from wrapper: " + err.Error())
 	}
+	supported := make(map[string][]string)
 	for _, algo := range config {
 		if algo.Algorithm == "acvptool" {
 			for _, feature := range algo.Features {
 				switch feature {
 				case "batch":
 					m.supportsFlush = true
+				case "deflate":
+					m.supportsDeflate = true
 				}
 			}
 		} else if _, ok := m.primitives[algo.Algorithm]; !ok {
 			return nil, fmt.Errorf("wrapper config advertises support for unknown algorithm %q", algo.Algorithm)
+		} else {
+			supported[algo.Algorithm] = append(supported[algo.Algorithm], algo.Mode)
 		}
 	}
+	m.supported = supported
 
 	return results[0], nil
 }
 
+// checkSupported returns an error if the configuration of the modulewrapper
+// doesn't include the algorithm and mode of vectorSet, so that the vector set
+// fails before any commands are sent rather than on the first command that
+// the modulewrapper doesn't know. An algorithm configured without a mode
+// supports all of them. Nothing is checked before Config has been called.
+func (m *Subprocess) checkSupported(algorithm string, vectorSet []byte) error {
+	if m.supported == nil {
+		return nil
+	}
+	modes, ok := m.supported[algorithm]
+	if !ok {
+		return fmt.Errorf("module does not support %s", algorithm)
+	}
+	if slices.Contains(modes, "") {
+		return nil
+	}
+	var common struct {
+		Mode string `json:"mode"`
+	}
+	if err := json.Unmarshal(vectorSet, &common); err != nil {
+		return err
+	}
+	if !slices.Contains(modes, common.Mode) {
+		return fmt.Errorf("module does not support %s mode %q", algorithm, common.Mode)
+	}
+	return nil
+}
+
 // Process runs a set of test vectors and returns the result.
 func (m *Subprocess) Process(algorithm string, vectorSet []byte) (any, error) {
+	return m.ProcessContext(context.Background(), algorithm, vectorSet)
+}
+
+// prepare makes the checks of vectorSet that come before any command is sent
+// for it, and returns the handler for algorithm, wrapped as m has been
+// configured, and a tracker for its progress. A Subprocess that hasn't been
+// configured only checks the tgIds and tcIds.
+func (m *Subprocess) prepare(algorithm string, vectorSet []byte) (Primitive, *progressTracker, error) {
 	prim, ok := m.primitives[algorithm]
 	if !ok {
-		return nil, fmt.Errorf("unknown algorithm %q", algorithm)
+		return nil, nil, fmt.Errorf("unknown algorithm %q", algorithm)
+	}
+	if err := checkIDs(vectorSet); err != nil {
+		return nil, nil, err
+	}
+	if err := m.checkSupported(algorithm, vectorSet); err != nil {
+		return nil, nil, err
+	}
+	if err := checkMessageSizes(vectorSet, m.maxMessageBytes); err != nil {
+		return nil, nil, err
+	}
+	if m.partialResults {
+		prim = &partialPrimitive{prim}
+	}
+	if m.sortResults {
+		prim = &sortedPrimitive{prim}
 	}
-	ret, err := prim.Process(vectorSet, m)
+	return prim, newProgressTracker(m.progress, algorithm, vectorSet), nil
+}
+
+// annotateAbort adds the algorithm to err if m has timed out or crashed,
+// because handlers may report the error without saying which vector set they
+// were processing. Once m has crashed, the result matches ErrModuleCrashed.
+func (m *Subprocess) annotateAbort(algorithm string, err error) error {
+	if !m.isAborted() {
+		return err
+	}
+	var timeout *timeoutError
+	switch {
+	case errors.Is(m.abortErr, ErrModuleCrashed):
+		return &crashedError{fmt.Errorf("%s: %w", algorithm, err)}
+	case errors.As(m.abortErr, &timeout):
+		return fmt.Errorf("%s: %w", algorithm, err)
+	}
+	return err
+}
+
+// ProcessContext is like Process, but stops and returns an error if ctx is
+// done before the vector set has been processed. The Subprocess can't be used
+// again after that.
+func (m *Subprocess) ProcessContext(ctx context.Context, algorithm string, vectorSet []byte) (any, error) {
+	prim, progress, err := m.prepare(algorithm, vectorSet)
 	if err != nil {
 		return nil, err
 	}
+	ret, err := prim.Process(vectorSet, progress.wrap(m.WithContext(ctx)))
+	if err != nil {
+		return nil, m.annotateAbort(algorithm, err)
+	}
+	progress.finished()
 	return ret, nil
 }
 
-type primitive interface {
+// Primitive is a handler for the vector sets of an ACVP algorithm. Process
+// answers the tests in vectorSet using t and returns the responses to the
+// test groups, ready to be marshaled as the testGroups of a response.
+//
+// vectorSet is the whole vector set as sent by the ACVP server. Process may
+// use both Transact and TransactAsync, but must call Flush before returning
+// so that every callback has run, and must not use t after it returns.
+// Callbacks run on another goroutine, and in the order in which the commands
+// were sent. Each test case must be checked before any synchronous command is
+// sent for it, because Validate stops there. The result must marshal to a
+// list of objects with a "tgId" field, and each test group must be answered
+// independently of the others, because Pool and ProcessStream split vector
+// sets between calls to Process.
+type Primitive interface {
 	Process(vectorSet []byte, t Transactable) (any, error)
 }
 
+// Run answers the tests in vectorSet, which are for the named algorithm,
+// using m and returns the responses to the test groups as JSON.
+func Run(algorithm string, vectorSet []byte, m Transactable) ([]byte, error) {
+	prim, _, err := (&Subprocess{primitives: Primitives()}).prepare(algorithm, vectorSet)
+	if err != nil {
+		return nil, err
+	}
+	result, err := prim.Process(vectorSet, m)
+	if err != nil {
+		return nil, err
+	}
+	return json.Marshal(result)
+}
+
 func uint32le(n uint32) []byte {
 	var ret [4]byte
 	binary.LittleEndian.PutUint32(ret[:], n)
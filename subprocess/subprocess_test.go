@@ -0,0 +1,588 @@
+// Copyright (c) 2026, Google Inc.
+//
+// Permission to use, copy, modify, and/or distribute this software for any
+// purpose with or without fee is hereby granted, provided that the above
+// copyright notice and this permission notice appear in all copies.
+//
+// THE SOFTWARE IS PROVIDED "AS IS" AND THE AUTHOR DISCLAIMS ALL WARRANTIES
+// WITH REGARD TO THIS SOFTWARE INCLUDING ALL IMPLIED WARRANTIES OF
+// MERCHANTABILITY AND FITNESS. IN NO EVENT SHALL THE AUTHOR BE LIABLE FOR ANY
+// SPECIAL, DIRECT, INDIRECT, OR CONSEQUENTIAL DAMAGES OR ANY DAMAGES
+// WHATSOEVER RESULTING FROM LOSS OF USE, DATA OR PROFITS, WHETHER IN AN ACTION
+// OF CONTRACT, NEGLIGENCE OR OTHER TORTIOUS ACTION, ARISING OUT OF OR IN
+// CONNECTION WITH THE USE OR PERFORMANCE OF THIS SOFTWARE.
+
+package subprocess
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// transactCall records a single command sent to a fakeTransactable.
+type transactCall struct {
+	cmd  string
+	args [][]byte
+}
+
+// fakeTransactable is a Transactable that records every command and answers
+// it using respond, without running a module. Asynchronous commands are
+// completed immediately.
+type fakeTransactable struct {
+	respond func(cmd string, args [][]byte) ([][]byte, error)
+	calls   []transactCall
+	err     error
+}
+
+func (f *fakeTransactable) Transact(cmd string, expectedResults int, args ...[]byte) ([][]byte, error) {
+	f.calls = append(f.calls, transactCall{cmd, args})
+	result, err := f.respond(cmd, args)
+	if err != nil {
+		return nil, err
+	}
+	if len(result) != expectedResults {
+		return nil, fmt.Errorf("expected %d results from %q but got %d", expectedResults, cmd, len(result))
+	}
+	return result, nil
+}
+
+func (f *fakeTransactable) TransactAsync(cmd string, expectedResults int, args [][]byte, callback func([][]byte) error) {
+	result, err := f.Transact(cmd, expectedResults, args...)
+	if err == nil {
+		err = callback(result)
+	}
+	if err != nil && f.err == nil {
+		f.err = err
+	}
+}
+
+func (f *fakeTransactable) Barrier(callback func()) error {
+	callback()
+	return nil
+}
+
+func (f *fakeTransactable) Flush() error {
+	return f.err
+}
+
+// pipeModule runs respond as a module on the other end of a Subprocess's
+// pipes, so that requests and results pass through the real framing. The
+// Subprocess has the given per-command timeout. If respond returns a
+// *transientError, it is reported to the Subprocess as a transient failure.
+func pipeModule(t testing.TB, timeout time.Duration, respond func(cmd string, args [][]byte) ([][]byte, error)) *Subprocess {
+	in, out := pipeModuleIO(t, respond)
+	return NewWithIOTimeout(nil, in, out, timeout)
+}
+
+// errModuleExit can be returned by the respond function of pipeModule to have
+// the module exit, closing its pipes, rather than answer.
+var errModuleExit = errors.New("module exited")
+
+// pipeModuleIO runs respond as a module and returns the ends of its pipes.
+// The module handles "flush", which needs no response because results are
+// never buffered, and "compression/deflate" itself.
+func pipeModuleIO(t testing.TB, respond func(cmd string, args [][]byte) ([][]byte, error)) (io.WriteCloser, io.ReadCloser) {
+	return pipeModuleIOWithByteOrder(t, binary.LittleEndian, respond)
+}
+
+// framingByteOrder is implemented by binary.LittleEndian and
+// binary.BigEndian.
+type framingByteOrder interface {
+	binary.ByteOrder
+	binary.AppendByteOrder
+}
+
+// pipeModuleIOWithByteOrder is like pipeModuleIO, but the module frames
+// messages with the given byte order. See Subprocess.SetByteOrder.
+func pipeModuleIOWithByteOrder(t testing.TB, order framingByteOrder, respond func(cmd string, args [][]byte) ([][]byte, error)) (io.WriteCloser, io.ReadCloser) {
+	toModule, fromToolkit := io.Pipe()
+	fromModule, toToolkit := io.Pipe()
+
+	go func() {
+		defer toToolkit.Close()
+		defer toModule.Close()
+		var compress bool
+		write := func(msg []byte) error {
+			if compress {
+				var err error
+				if msg, err = encodeMessage(msg, order); err != nil {
+					return err
+				}
+			}
+			_, err := toToolkit.Write(msg)
+			return err
+		}
+
+		for {
+			var r io.Reader = toModule
+			if compress {
+				var err error
+				if r, err = decodeMessage(toModule, order); err != nil {
+					return
+				}
+			}
+
+			var header [4]byte
+			if _, err := io.ReadFull(r, header[:]); err != nil {
+				return
+			}
+			lengths := make([]byte, 4*order.Uint32(header[:]))
+			if _, err := io.ReadFull(r, lengths); err != nil {
+				t.Error(err)
+				return
+			}
+			var args [][]byte
+			for i := 0; i < len(lengths); i += 4 {
+				arg := make([]byte, order.Uint32(lengths[i:]))
+				if _, err := io.ReadFull(r, arg); err != nil {
+					t.Error(err)
+					return
+				}
+				args = append(args, arg)
+			}
+
+			if string(args[0]) == "flush" {
+				continue
+			}
+			if string(args[0]) == "compression/deflate" {
+				if err := write(order.AppendUint32(nil, 0)); err != nil {
+					return
+				}
+				compress = true
+				continue
+			}
+
+			result, err := respond(string(args[0]), args[1:])
+			if transient, ok := err.(*transientError); ok {
+				reply := order.AppendUint32(nil, transientResultCount)
+				reply = order.AppendUint32(reply, uint32(len(transient.message)))
+				reply = append(reply, transient.message...)
+				if err := write(reply); err != nil {
+					return
+				}
+				continue
+			}
+			if err == errModuleExit {
+				return
+			}
+			if err != nil {
+				t.Error(err)
+				return
+			}
+			reply := order.AppendUint32(nil, uint32(len(result)))
+			for _, r := range result {
+				reply = order.AppendUint32(reply, uint32(len(r)))
+			}
+			for _, r := range result {
+				reply = append(reply, r...)
+			}
+			if err := write(reply); err != nil {
+				return
+			}
+		}
+	}()
+
+	t.Cleanup(func() { fromToolkit.Close() })
+	return fromToolkit, fromModule
+}
+
+func TestProcessContextCancel(t *testing.T) {
+	// The module never answers until the test is over.
+	release := make(chan struct{})
+	m := pipeModule(t, 0, func(cmd string, args [][]byte) ([][]byte, error) {
+		<-release
+		return [][]byte{nil, args[3], args[6]}, nil
+	})
+	defer m.Close()
+	defer close(release)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	time.AfterFunc(50*time.Millisecond, cancel)
+
+	start := time.Now()
+	_, err := m.ProcessContext(ctx, "cSHAKE-128", []byte(cShakeMCTVectorSet))
+	if !errors.Is(err, context.Canceled) {
+		t.Fatalf("got error %v, want a cancellation", err)
+	}
+	if elapsed := time.Since(start); elapsed > 5*time.Second {
+		t.Errorf("took %s to return after cancellation", elapsed)
+	}
+
+	// The module's responses are now out of step, so the subprocess can't
+	// be used again.
+	if err := m.WithContext(context.Background()).Flush(); !errors.Is(err, context.Canceled) {
+		t.Errorf("Flush after cancellation returned %v", err)
+	}
+}
+
+func TestProcessContextCancelAsync(t *testing.T) {
+	release := make(chan struct{})
+	m := pipeModule(t, 0, func(cmd string, args [][]byte) ([][]byte, error) {
+		<-release
+		return [][]byte{make([]byte, 32)}, nil
+	})
+	defer m.Close()
+	defer close(release)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	time.AfterFunc(50*time.Millisecond, cancel)
+
+	// SHA2-256 AFT tests are sent asynchronously, so the cancellation must
+	// interrupt Flush.
+	const vectorSet = `{"testGroups": [{"tgId": 1, "testType": "AFT", "tests": [{"tcId": 1, "len": 8, "msg": "00"}]}]}`
+	if _, err := m.ProcessContext(ctx, "SHA2-256", []byte(vectorSet)); !errors.Is(err, context.Canceled) {
+		t.Fatalf("got error %v, want a cancellation", err)
+	}
+}
+
+func TestTimeout(t *testing.T) {
+	release := make(chan struct{})
+	m := pipeModule(t, 100*time.Millisecond, func(cmd string, args [][]byte) ([][]byte, error) {
+		// Only the tenth MCT iteration is slow, so the timeout must apply to
+		// each command rather than to the whole vector set.
+		if args[0][0] == 9 {
+			<-release
+		}
+		time.Sleep(20 * time.Millisecond)
+		return [][]byte{{args[0][0] + 1, 0}, args[3], args[6]}, nil
+	})
+	defer m.Close()
+	defer close(release)
+
+	// The first message byte counts the iterations.
+	vectorSet := strings.Replace(cShakeMCTVectorSet, `"len": 32, "msg": "00010203"`, `"len": 8, "msg": "00"`, 1)
+	_, err := m.Process("cSHAKE-128", []byte(vectorSet))
+	if err == nil {
+		t.Fatal("Process succeeded despite a module that stopped responding")
+	}
+	for _, want := range []string{"cSHAKE-128:", `"cSHAKE-128/MCT"`, "within 100ms"} {
+		if !strings.Contains(err.Error(), want) {
+			t.Errorf("error %q doesn't contain %q", err, want)
+		}
+	}
+}
+
+func TestRun(t *testing.T) {
+	m := &fakeTransactable{respond: func(cmd string, args [][]byte) ([][]byte, error) {
+		if cmd != "cSHAKE-128" {
+			return nil, fmt.Errorf("unexpected command %q", cmd)
+		}
+		return [][]byte{make([]byte, binary.LittleEndian.Uint32(args[1]))}, nil
+	}}
+
+	result, err := Run("cSHAKE-128", []byte(cShakeAFTVectorSet), m)
+	if err != nil {
+		t.Fatal(err)
+	}
+	const want = `[{"tgId":1,"tests":[{"tcId":1,"md":"00000000","outLen":32},{"tcId":2,"md":"0000000000000000","outLen":64}]}]`
+	if string(result) != want {
+		t.Errorf("got %s, want %s", result, want)
+	}
+
+	if _, err := Run("cSHAKE-64", []byte(cShakeAFTVectorSet), m); err == nil {
+		t.Error("unknown algorithm was accepted")
+	}
+}
+
+func TestUnsupportedAlgorithm(t *testing.T) {
+	m := pipeModule(t, 0, func(cmd string, args [][]byte) ([][]byte, error) {
+		switch cmd {
+		case "getConfig":
+			return [][]byte{[]byte(`[{"algorithm": "cSHAKE-128"}, {"algorithm": "ECDSA", "mode": "keyGen"}]`)}, nil
+		case "cSHAKE-128":
+			return [][]byte{make([]byte, binary.LittleEndian.Uint32(args[1]))}, nil
+		}
+		t.Errorf("unexpected command %q", cmd)
+		return nil, fmt.Errorf("unexpected command %q", cmd)
+	})
+	defer m.Close()
+	if _, err := m.Config(); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := m.Process("cSHAKE-128", []byte(cShakeAFTVectorSet)); err != nil {
+		t.Errorf("advertised algorithm failed: %s", err)
+	}
+
+	for _, test := range []struct {
+		algorithm, vectorSet, want string
+	}{
+		{"cSHAKE-256", cShakeAFTVectorSet, "module does not support cSHAKE-256"},
+		{"ECDSA", `{"mode": "sigGen", "testGroups": []}`, `module does not support ECDSA mode "sigGen"`},
+	} {
+		if _, err := m.Process(test.algorithm, []byte(test.vectorSet)); err == nil || err.Error() != test.want {
+			t.Errorf("%s: got error %v, want %q", test.algorithm, err, test.want)
+		}
+	}
+}
+
+// stubHandler answers every test group with a constant, recording the
+// vector sets that it's given.
+type stubHandler struct {
+	vectorSets []string
+}
+
+func (s *stubHandler) Process(vectorSet []byte, t Transactable) (any, error) {
+	s.vectorSets = append(s.vectorSets, string(vectorSet))
+	result, err := t.Transact("Stub/op", 1, []byte("in"))
+	if err != nil {
+		return nil, err
+	}
+	return []map[string]any{{"tgId": 1, "result": string(result[0])}}, nil
+}
+
+func TestRegisterHandler(t *testing.T) {
+	m := pipeModule(t, 0, func(cmd string, args [][]byte) ([][]byte, error) {
+		if cmd != "Stub/op" || string(args[0]) != "in" {
+			return nil, fmt.Errorf("unexpected command %q", cmd)
+		}
+		return [][]byte{[]byte("out")}, nil
+	})
+	defer m.Close()
+
+	stub := new(stubHandler)
+	if err := m.RegisterHandler("Stub", stub); err != nil {
+		t.Fatal(err)
+	}
+	if err := m.RegisterHandler("SHA2-256", stub); err == nil {
+		t.Error("registering a handler for SHA2-256 succeeded")
+	}
+
+	const vectorSet = `{"algorithm": "Stub", "testGroups": [{"tgId": 1, "tests": []}]}`
+	result, err := m.Process("Stub", []byte(vectorSet))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(stub.vectorSets) != 1 || stub.vectorSets[0] != vectorSet {
+		t.Errorf("handler was called with %q", stub.vectorSets)
+	}
+	if groups, ok := result.([]map[string]any); !ok || groups[0]["result"] != "out" {
+		t.Errorf("unexpected result %v", result)
+	}
+}
+
+// queuedWriter lets the toolkit send commands without waiting for the module
+// to read them, and records the most commands that were ever unanswered.
+type queuedWriter struct {
+	w     io.WriteCloser
+	queue chan []byte
+
+	mu            sync.Mutex
+	unanswered    int
+	maxUnanswered int
+}
+
+func newQueuedWriter(w io.WriteCloser) *queuedWriter {
+	q := &queuedWriter{w: w, queue: make(chan []byte, 1024)}
+	go func() {
+		for msg := range q.queue {
+			if _, err := q.w.Write(msg); err != nil {
+				break
+			}
+		}
+		q.w.Close()
+	}()
+	return q
+}
+
+func (q *queuedWriter) Write(msg []byte) (int, error) {
+	numArgs := binary.LittleEndian.Uint32(msg)
+	cmdLen := binary.LittleEndian.Uint32(msg[4:])
+	if cmd := msg[4+4*numArgs : 4+4*numArgs+cmdLen]; string(cmd) != "flush" {
+		q.mu.Lock()
+		q.unanswered++
+		if q.unanswered > q.maxUnanswered {
+			q.maxUnanswered = q.unanswered
+		}
+		q.mu.Unlock()
+	}
+	q.queue <- append([]byte{}, msg...)
+	return len(msg), nil
+}
+
+func (q *queuedWriter) Close() error {
+	close(q.queue)
+	return nil
+}
+
+// answered is called by the module before it sends each response.
+func (q *queuedWriter) answered() {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	q.unanswered--
+}
+
+func TestMaxInFlight(t *testing.T) {
+	vectorSet := []byte(sha256VectorSet(2, 20))
+	want, err := (&hashPrimitive{"SHA2-256", 32}).Process(vectorSet, &fakeTransactable{respond: sha256Responder(0)})
+	if err != nil {
+		t.Fatal(err)
+	}
+	wantBytes, err := json.Marshal(want)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, limit := range []int{1, 4} {
+		var q *queuedWriter
+		respond := sha256Responder(time.Millisecond)
+		in, out := pipeModuleIO(t, func(cmd string, args [][]byte) ([][]byte, error) {
+			result, err := respond(cmd, args)
+			q.answered()
+			return result, err
+		})
+		q = newQueuedWriter(in)
+		m := NewWithIO(nil, q, out)
+		m.SetMaxInFlight(limit)
+
+		result, err := m.Process("SHA2-256", vectorSet)
+		m.Close()
+		if err != nil {
+			t.Fatalf("limit %d: %s", limit, err)
+		}
+
+		// The module answers slowly, so the toolkit reaches the limit
+		// but never exceeds it. With a limit of one, each command is
+		// only sent once the previous one has been answered.
+		if q.maxUnanswered != limit {
+			t.Errorf("limit %d: up to %d commands were unanswered", limit, q.maxUnanswered)
+		}
+		resultBytes, err := json.Marshal(result)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if string(resultBytes) != string(wantBytes) {
+			t.Errorf("limit %d: results differ from sequential processing", limit)
+		}
+	}
+}
+
+func TestModuleCrash(t *testing.T) {
+	for _, test := range []struct {
+		name      string
+		algorithm string
+		vectorSet string
+		exitAfter int
+	}{
+		// MCTs use Transact, and wait for each result.
+		{"sync", "cSHAKE-128", cShakeMCTVectorSet, 10},
+		// AFTs use TransactAsync, and see the crash when flushing.
+		{"async", "cSHAKE-128", cShakeAFTVectorSet, 1},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			var calls int
+			m := pipeModule(t, 0, func(cmd string, args [][]byte) ([][]byte, error) {
+				if calls++; calls > test.exitAfter {
+					return nil, errModuleExit
+				}
+				if cmd == "cSHAKE-128/MCT" {
+					return cShakeMCTResponder(cmd, args)
+				}
+				return [][]byte{make([]byte, binary.LittleEndian.Uint32(args[1]))}, nil
+			})
+			defer m.Close()
+
+			_, err := m.Process(test.algorithm, []byte(test.vectorSet))
+			if !errors.Is(err, ErrModuleCrashed) {
+				t.Fatalf("got error %v, want one matching ErrModuleCrashed", err)
+			}
+			if !strings.HasPrefix(err.Error(), test.algorithm+": ") {
+				t.Errorf("error %q doesn't name the algorithm", err)
+			}
+			if _, err := m.Transact("cSHAKE-128", 1); !errors.Is(err, ErrModuleCrashed) {
+				t.Errorf("later command gave error %v, want one matching ErrModuleCrashed", err)
+			}
+		})
+	}
+}
+
+// recordingWriter keeps a copy of everything written to it.
+type recordingWriter struct {
+	io.WriteCloser
+	written *bytes.Buffer
+}
+
+func (w recordingWriter) Write(b []byte) (int, error) {
+	w.written.Write(b)
+	return w.WriteCloser.Write(b)
+}
+
+func TestByteOrder(t *testing.T) {
+	for _, order := range []framingByteOrder{binary.LittleEndian, binary.BigEndian} {
+		in, out := pipeModuleIOWithByteOrder(t, order, func(cmd string, args [][]byte) ([][]byte, error) {
+			if cmd == "getConfig" {
+				return [][]byte{[]byte(`[{"algorithm": "acvptool", "features": ["deflate"]}]`)}, nil
+			}
+			return echo(cmd, args)
+		})
+		var written bytes.Buffer
+		m := NewWithIO(nil, recordingWriter{in, &written}, out)
+		m.SetByteOrder(order)
+		if _, err := m.Config(); err != nil {
+			t.Fatalf("%s: %s", order, err)
+		}
+		// getConfig is sent as a count of one, for the command name alone,
+		// and the length of the name.
+		want := append(order.AppendUint32(nil, 1), order.AppendUint32(nil, 9)...)
+		if got := written.Bytes()[:8]; !bytes.Equal(got, want) {
+			t.Errorf("%s: request starts with %x, want %x", order, got, want)
+		}
+
+		short, long := []byte("short"), bytes.Repeat([]byte{0xaa}, 0x0102)
+		result, err := m.Transact("echo", 2, short, long)
+		if err != nil {
+			t.Fatalf("%s: %s", order, err)
+		}
+		if !bytes.Equal(result[0], short) || !bytes.Equal(result[1], long) {
+			t.Errorf("%s: arguments changed in transit", order)
+		}
+
+		// The length of compressed messages is framed in the same way.
+		if err := m.EnableCompression(); err != nil {
+			t.Fatalf("%s: %s", order, err)
+		}
+		large := bytes.Repeat([]byte("ACVP "), 1<<12)
+		if result, err := m.Transact("echo", 1, large); err != nil || !bytes.Equal(result[0], large) {
+			t.Errorf("%s: compressed message returned %v", order, err)
+		}
+		m.Close()
+	}
+}
+
+type nopWriteCloser struct{ io.Writer }
+
+func (nopWriteCloser) Close() error { return nil }
+
+func TestRejectedResult(t *testing.T) {
+	// The module answers the first command with one byte fewer than the
+	// requested 32 bits of cSHAKE output.
+	reply := binary.LittleEndian.AppendUint32(nil, 1)
+	reply = binary.LittleEndian.AppendUint32(reply, 3)
+	reply = append(reply, 0, 0, 0)
+	m := NewWithIO(nil, nopWriteCloser{io.Discard}, io.NopCloser(bytes.NewReader(reply)))
+
+	// The result is rejected by the callback, which runs in the reader
+	// goroutine. That must fail the vector set rather than the process.
+	const vectorSet = `{"testGroups": [{"tgId": 1, "testType": "AFT", "tests": [
+		{"tcId": 1, "len": 8, "msg": "00", "outLen": 32, "functionName": "", "customization": ""},
+		{"tcId": 2, "len": 8, "msg": "01", "outLen": 32, "functionName": "", "customization": ""}
+	]}]}`
+	_, err := m.Process("cSHAKE-128", []byte(vectorSet))
+	if err == nil || !strings.Contains(err.Error(), "returned 3 bytes but wanted 4") {
+		t.Fatalf("short result reported as %v", err)
+	}
+
+	// Later commands fail with the same error.
+	if _, laterErr := m.Transact("cSHAKE-128", 1, []byte{0}); laterErr != err {
+		t.Errorf("later command gave error %v, want %v", laterErr, err)
+	}
+}
@@ -0,0 +1,136 @@
+// Copyright (c) 2026, Google Inc.
+//
+// Permission to use, copy, modify, and/or distribute this software for any
+// purpose with or without fee is hereby granted, provided that the above
+// copyright notice and this permission notice appear in all copies.
+//
+// THE SOFTWARE IS PROVIDED "AS IS" AND THE AUTHOR DISCLAIMS ALL WARRANTIES
+// WITH REGARD TO THIS SOFTWARE INCLUDING ALL IMPLIED WARRANTIES OF
+// MERCHANTABILITY AND FITNESS. IN NO EVENT SHALL THE AUTHOR BE LIABLE FOR ANY
+// SPECIAL, DIRECT, INDIRECT, OR CONSEQUENTIAL DAMAGES OR ANY DAMAGES
+// WHATSOEVER RESULTING FROM LOSS OF USE, DATA OR PROFITS, WHETHER IN AN ACTION
+// OF CONTRACT, NEGLIGENCE OR OTHER TORTIOUS ACTION, ARISING OUT OF OR IN
+// CONNECTION WITH THE USE OR PERFORMANCE OF THIS SOFTWARE.
+
+package subprocess
+
+import (
+	"errors"
+	"fmt"
+)
+
+// The phases of processing a test case that a TestCaseError can come from.
+const (
+	// PhaseInput is for problems with the test case itself, found before
+	// anything is sent to the module.
+	PhaseInput = "input"
+	// PhaseModule is for commands that the module failed to answer.
+	PhaseModule = "module"
+	// PhaseResult is for results from the module that were rejected.
+	PhaseResult = "result"
+)
+
+// TestCaseError is an error that is attributed to a single test case of a
+// vector set.
+type TestCaseError struct {
+	Algorithm string
+	GroupID   uint64
+	TestID    uint64
+	// Phase is one of PhaseInput, PhaseModule or PhaseResult.
+	Phase string
+	Err   error
+}
+
+func (e *TestCaseError) Error() string {
+	return fmt.Sprintf("%s test case %d/%d: %s", e.Algorithm, e.GroupID, e.TestID, e.Err)
+}
+
+func (e *TestCaseError) Unwrap() error {
+	return e.Err
+}
+
+// testCaseInputError returns a TestCaseError for a problem with the input of
+// test case testID of group groupID.
+func testCaseInputError(algorithm string, groupID, testID uint64, err error) error {
+	return &TestCaseError{algorithm, groupID, testID, PhaseInput, err}
+}
+
+// testCase identifies the test case that a command is sent for.
+type testCase struct {
+	algorithm       string
+	groupID, testID uint64
+}
+
+// annotate returns err attributed to c, in the given phase. If c is nil, err
+// is returned unchanged.
+func (c *testCase) annotate(phase string, err error) error {
+	if c == nil {
+		return err
+	}
+	return &TestCaseError{c.algorithm, c.groupID, c.testID, phase, err}
+}
+
+// labelledTransactable is implemented by Transactables that need to know
+// which test case a command is for, for example to attribute the failure of
+// an asynchronous command, which is only detected later, to the test case
+// that sent it.
+type labelledTransactable interface {
+	transactLabelled(label *testCase, cmd string, expectedNumResults int, args ...[]byte) ([][]byte, error)
+	transactAsyncLabelled(label *testCase, cmd string, expectedNumResults int, args [][]byte, callback func(result [][]byte) error)
+}
+
+// testCaseTransactable sends commands for a single test case, adding the
+// algorithm, tgId and tcId to the errors that they cause.
+type testCaseTransactable struct {
+	t     Transactable
+	label *testCase
+}
+
+// withTestCase returns a Transactable that sends commands to t on behalf of
+// test case testID of group groupID, so that errors from the module say
+// which test case failed. This includes errors that t reports later, such as
+// from Flush, if t is a Subprocess.
+func withTestCase(t Transactable, algorithm string, groupID, testID uint64) Transactable {
+	return &testCaseTransactable{t, &testCase{algorithm, groupID, testID}}
+}
+
+func (c *testCaseTransactable) Transact(cmd string, expectedResults int, args ...[]byte) ([][]byte, error) {
+	var result [][]byte
+	var err error
+	if labelled, ok := c.t.(labelledTransactable); ok {
+		result, err = labelled.transactLabelled(c.label, cmd, expectedResults, args...)
+	} else {
+		result, err = c.t.Transact(cmd, expectedResults, args...)
+	}
+	if err != nil {
+		// An error that is already attributed, for example because an
+		// earlier test case aborted the subprocess, keeps its attribution.
+		var tcErr *TestCaseError
+		if errors.As(err, &tcErr) {
+			return nil, err
+		}
+		return nil, c.label.annotate(PhaseModule, err)
+	}
+	return result, nil
+}
+
+func (c *testCaseTransactable) TransactAsync(cmd string, expectedResults int, args [][]byte, callback func([][]byte) error) {
+	if labelled, ok := c.t.(labelledTransactable); ok {
+		labelled.transactAsyncLabelled(c.label, cmd, expectedResults, args, callback)
+		return
+	}
+	c.t.TransactAsync(cmd, expectedResults, args, func(result [][]byte) error {
+		if err := callback(result); err != nil {
+			return c.label.annotate(PhaseResult, err)
+		}
+		return nil
+	})
+}
+
+func (c *testCaseTransactable) Barrier(callback func()) error {
+	return c.t.Barrier(callback)
+}
+
+func (c *testCaseTransactable) Flush() error {
+	return c.t.Flush()
+}
@@ -0,0 +1,86 @@
+// Copyright (c) 2026, Google Inc.
+//
+// Permission to use, copy, modify, and/or distribute this software for any
+// purpose with or without fee is hereby granted, provided that the above
+// copyright notice and this permission notice appear in all copies.
+//
+// THE SOFTWARE IS PROVIDED "AS IS" AND THE AUTHOR DISCLAIMS ALL WARRANTIES
+// WITH REGARD TO THIS SOFTWARE INCLUDING ALL IMPLIED WARRANTIES OF
+// MERCHANTABILITY AND FITNESS. IN NO EVENT SHALL THE AUTHOR BE LIABLE FOR ANY
+// SPECIAL, DIRECT, INDIRECT, OR CONSEQUENTIAL DAMAGES OR ANY DAMAGES
+// WHATSOEVER RESULTING FROM LOSS OF USE, DATA OR PROFITS, WHETHER IN AN ACTION
+// OF CONTRACT, NEGLIGENCE OR OTHER TORTIOUS ACTION, ARISING OUT OF OR IN
+// CONNECTION WITH THE USE OR PERFORMANCE OF THIS SOFTWARE.
+
+package subprocess
+
+import (
+	"encoding/binary"
+	"strings"
+	"testing"
+)
+
+func TestModuleErrorNamesTestCase(t *testing.T) {
+	// The module fails the second test case, whose message is 02.
+	m := pipeModule(t, 0, func(cmd string, args [][]byte) ([][]byte, error) {
+		if args[0][0] == 2 {
+			return nil, &transientError{cmd, "device error"}
+		}
+		return [][]byte{make([]byte, binary.LittleEndian.Uint32(args[1]))}, nil
+	})
+
+	_, err := m.Process("cSHAKE-128", []byte(cShakeAFTVectorSet))
+	if err == nil {
+		t.Fatal("Process succeeded despite a module error")
+	}
+	if msg := err.Error(); !strings.Contains(msg, "cSHAKE-128 test case 1/2") || !strings.Contains(msg, "device error") {
+		t.Errorf("module error reported as %q", msg)
+	}
+}
+
+func TestRejectedResultNamesTestCase(t *testing.T) {
+	vectorSet := `{"testGroups": [{
+	"tgId": 3,
+	"testType": "AFT",
+	"blockSize": 8,
+	"tests": [{"tcId": 7, "len": 16, "msg": "0001", "outLen": 64, "customization": ""}]
+}]}`
+
+	// The digest is shorter than requested.
+	m := &fakeTransactable{respond: func(cmd string, args [][]byte) ([][]byte, error) {
+		return [][]byte{make([]byte, 4)}, nil
+	}}
+	_, err := (&parallelHash{"ParallelHash-128"}).Process([]byte(vectorSet), m)
+	if err == nil || !strings.Contains(err.Error(), "ParallelHash-128 test case 3/7") {
+		t.Errorf("rejected result reported as %v", err)
+	}
+}
+
+func TestHandlerErrorsNameTestCase(t *testing.T) {
+	key := strings.Repeat("00", 32)
+	nonce := strings.Repeat("00", 12)
+	for _, test := range []struct {
+		algorithm string
+		vectorSet string
+		want      string
+	}{
+		// Sent with TransactAsync, so the error is only seen by Flush.
+		{"ChaCha20-Poly1305", `{"testGroups": [{"tgId": 4, "testType": "AFT", "direction": "encrypt", "keyLen": 256, "ivLen": 96, "tagLen": 128,
+			"tests": [{"tcId": 9, "key": "` + key + `", "iv": "` + nonce + `", "aad": "", "pt": ""}]}]}`, "ChaCha20-Poly1305 test case 4/9"},
+		// MCTs wait for each result with Transact.
+		{"SHA2-256", `{"testGroups": [{"tgId": 2, "testType": "MCT", "mctVersion": "standard",
+			"tests": [{"tcId": 5, "len": 256, "msg": "` + key + `"}]}]}`, "SHA2-256 test case 2/5"},
+	} {
+		t.Run(test.algorithm, func(t *testing.T) {
+			m := pipeModule(t, 0, func(cmd string, args [][]byte) ([][]byte, error) {
+				return nil, &transientError{cmd, "device error"}
+			})
+			defer m.Close()
+
+			_, err := m.Process(test.algorithm, []byte(test.vectorSet))
+			if err == nil || !strings.Contains(err.Error(), test.want) || !strings.Contains(err.Error(), "device error") {
+				t.Errorf("module error reported as %v", err)
+			}
+		})
+	}
+}
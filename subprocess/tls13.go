@@ -30,9 +30,13 @@ type tls13TestVectorSet struct {
 }
 
 type tls13TestGroup struct {
-	ID       uint64      `json:"tgId"`
-	HashFunc string      `json:"hmacAlg"`
-	Tests    []tls13Test `json:"tests"`
+	ID       uint64 `json:"tgId"`
+	HashFunc string `json:"hmacAlg"`
+	// RunningMode is one of "PSK", "DHE" or "PSK-DHE" and determines
+	// which of the PSK and DHE inputs the tests provide. The other input is
+	// replaced by zeros, as in RFC 8446, section 7.1.
+	RunningMode string      `json:"runningMode"`
+	Tests       []tls13Test `json:"tests"`
 }
 
 type tls13Test struct {
@@ -80,8 +84,21 @@ func (k *tls13) Process(vectorSet []byte, m Transactable) (any, error) {
 		group := group
 		groupResp := tls13TestGroupResponse{ID: group.ID}
 
+		var usePSK, useDHE bool
+		switch group.RunningMode {
+		case "PSK":
+			usePSK = true
+		case "DHE":
+			useDHE = true
+		case "PSK-DHE":
+			usePSK, useDHE = true, true
+		default:
+			return nil, fmt.Errorf("test group %d has unknown running mode %q", group.ID, group.RunningMode)
+		}
+
 		for _, test := range group.Tests {
 			test := test
+			m := withTestCase(m, "TLS-v1.3", group.ID, test.ID)
 			testResp := tls13TestResponse{ID: test.ID}
 
 			clientHello, err := hex.DecodeString(test.ClientHelloHex)
@@ -118,6 +135,13 @@ func (k *tls13) Process(vectorSet []byte, m Transactable) (any, error) {
 			}
 			hashLenBytes := uint32le(uint32(hashLen))
 
+			if (len(test.PSKInputHex) != 0) != usePSK {
+				return nil, fmt.Errorf("test case %d/%d has incorrect PSK presence for running mode %q", group.ID, test.ID, group.RunningMode)
+			}
+			if (len(test.DHEInputHex) != 0) != useDHE {
+				return nil, fmt.Errorf("test case %d/%d has incorrect DHE presence for running mode %q", group.ID, test.ID, group.RunningMode)
+			}
+
 			psk, err := hex.DecodeString(test.PSKInputHex)
 			if err != nil {
 				return nil, err
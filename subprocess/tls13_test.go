@@ -0,0 +1,127 @@
+// Copyright (c) 2026, Google Inc.
+//
+// Permission to use, copy, modify, and/or distribute this software for any
+// purpose with or without fee is hereby granted, provided that the above
+// copyright notice and this permission notice appear in all copies.
+//
+// THE SOFTWARE IS PROVIDED "AS IS" AND THE AUTHOR DISCLAIMS ALL WARRANTIES
+// WITH REGARD TO THIS SOFTWARE INCLUDING ALL IMPLIED WARRANTIES OF
+// MERCHANTABILITY AND FITNESS. IN NO EVENT SHALL THE AUTHOR BE LIABLE FOR ANY
+// SPECIAL, DIRECT, INDIRECT, OR CONSEQUENTIAL DAMAGES OR ANY DAMAGES
+// WHATSOEVER RESULTING FROM LOSS OF USE, DATA OR PROFITS, WHETHER IN AN ACTION
+// OF CONTRACT, NEGLIGENCE OR OTHER TORTIOUS ACTION, ARISING OUT OF OR IN
+// CONNECTION WITH THE USE OR PERFORMANCE OF THIS SOFTWARE.
+
+package subprocess
+
+import (
+	"bytes"
+	"testing"
+)
+
+// tls13Responder returns a distinct, recognisable value for each operation:
+// extractions return "X(" secret ")", expansions return the label and hashes
+// return a single byte.
+func tls13Responder(cmd string, args [][]byte) ([][]byte, error) {
+	switch cmd {
+	case "HKDFExtract/SHA2-256":
+		return [][]byte{concat([]byte("X("), args[0], []byte(")"))}, nil
+	case "HKDFExpandLabel/SHA2-256":
+		return [][]byte{args[2]}, nil
+	case "SHA2-256":
+		return [][]byte{{byte(len(args[0]))}}, nil
+	}
+	panic("unexpected command " + cmd)
+}
+
+func TestTLS13PSKDHEOrder(t *testing.T) {
+	const vectorSet = `{"algorithm": "TLS-v1.3", "testGroups": [{
+		"tgId": 1, "hmacAlg": "SHA2-256", "runningMode": "PSK-DHE",
+		"tests": [{"tcId": 1, "psk": "aa", "dhe": "dd",
+			"helloClientRandom": "01", "helloServerRandom": "02",
+			"finishedServerRandom": "03", "finishedClientRandom": "04"}]
+	}]}`
+
+	m := &fakeTransactable{respond: tls13Responder}
+	result, err := new(tls13).Process([]byte(vectorSet), m)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	type step struct{ cmd, label string }
+	want := []step{
+		{"HKDFExtract/SHA2-256", ""},
+		{"SHA2-256", ""},
+		{"SHA2-256", ""},
+		{"SHA2-256", ""},
+		{"SHA2-256", ""},
+		{"HKDFExpandLabel/SHA2-256", "c e traffic"},
+		{"HKDFExpandLabel/SHA2-256", "e exp master"},
+		{"HKDFExpandLabel/SHA2-256", "derived"},
+		{"HKDFExtract/SHA2-256", ""},
+		{"HKDFExpandLabel/SHA2-256", "c hs traffic"},
+		{"HKDFExpandLabel/SHA2-256", "s hs traffic"},
+		{"HKDFExpandLabel/SHA2-256", "derived"},
+		{"HKDFExtract/SHA2-256", ""},
+		{"HKDFExpandLabel/SHA2-256", "c ap traffic"},
+		{"HKDFExpandLabel/SHA2-256", "s ap traffic"},
+		{"HKDFExpandLabel/SHA2-256", "exp master"},
+		{"HKDFExpandLabel/SHA2-256", "res master"},
+	}
+	if len(m.calls) != len(want) {
+		t.Fatalf("got %d calls, want %d", len(m.calls), len(want))
+	}
+	for i, call := range m.calls {
+		var label string
+		if call.cmd == "HKDFExpandLabel/SHA2-256" {
+			label = string(call.args[2])
+		}
+		if call.cmd != want[i].cmd || label != want[i].label {
+			t.Errorf("call %d was %s %q, want %s %q", i, call.cmd, label, want[i].cmd, want[i].label)
+		}
+	}
+
+	// The PSK goes into the early secret and the DHE input into the
+	// handshake secret, which is salted by the derived early secret.
+	if psk := m.calls[0].args[0]; !bytes.Equal(psk, []byte{0xaa}) {
+		t.Errorf("early secret extracted from %x", psk)
+	}
+	if dhe := m.calls[8].args; !bytes.Equal(dhe[0], []byte{0xdd}) || string(dhe[1]) != "derived" {
+		t.Errorf("handshake secret extracted from %q", dhe)
+	}
+	if secret := m.calls[9].args[1]; string(secret) != "X(\xdd)" {
+		t.Errorf("handshake traffic secret expanded from %q", secret)
+	}
+	// The application secrets use the transcript up to the server Finished.
+	if hash := m.calls[13].args[3]; !bytes.Equal(hash, []byte{3}) {
+		t.Errorf("application traffic secret used transcript hash %x", hash)
+	}
+
+	test := result.([]tls13TestGroupResponse)[0].Tests[0]
+	if test.ClientEarlyTrafficSecretHex == "" || test.ResumptionMasterSecretHex == "" {
+		t.Errorf("response lacks secrets: %+v", test)
+	}
+}
+
+func TestTLS13RunningModeInputs(t *testing.T) {
+	for _, tc := range []struct {
+		mode, inputs string
+		ok           bool
+	}{
+		{"PSK", `"psk": "aa"`, true},
+		{"PSK", `"psk": "aa", "dhe": "dd"`, false},
+		{"DHE", `"dhe": "dd"`, true},
+		{"DHE", `"psk": "aa"`, false},
+		{"PSK-DHE", `"dhe": "dd"`, false},
+		{"psk", `"psk": "aa"`, false},
+	} {
+		vectorSet := `{"algorithm": "TLS-v1.3", "testGroups": [{
+			"tgId": 1, "hmacAlg": "SHA2-256", "runningMode": "` + tc.mode + `",
+			"tests": [{"tcId": 1, ` + tc.inputs + `}]
+		}]}`
+		_, err := new(tls13).Process([]byte(vectorSet), &fakeTransactable{respond: tls13Responder})
+		if (err == nil) != tc.ok {
+			t.Errorf("%s with %s: got error %v", tc.mode, tc.inputs, err)
+		}
+	}
+}
@@ -26,12 +26,17 @@ type tlsKDFVectorSet struct {
 }
 
 type tlsKDFTestGroup struct {
-	ID           uint64       `json:"tgId"`
-	Hash         string       `json:"hashAlg"`
-	TLSVersion   string       `json:"tlsVersion"`
-	KeyBlockBits uint64       `json:"keyBlockLength"`
-	PMSLength    uint64       `json:"preMasterSecretLength"`
-	Tests        []tlsKDFTest `json:"tests"`
+	ID           uint64 `json:"tgId"`
+	Hash         string `json:"hashAlg"`
+	TLSVersion   string `json:"tlsVersion"`
+	KeyBlockBits uint64 `json:"keyBlockLength"`
+	PMSLength    uint64 `json:"preMasterSecretLength"`
+	// ExtendedMasterSecret selects between the RFC 7627 master secret,
+	// which is derived from the session hash, and the RFC 5246 one,
+	// which is derived from the hello randoms. It is absent from RFC 7627
+	// vector sets, which always use the extended master secret.
+	ExtendedMasterSecret *bool        `json:"extendedMasterSecret"`
+	Tests                []tlsKDFTest `json:"tests"`
 }
 
 type tlsKDFTest struct {
@@ -40,6 +45,11 @@ type tlsKDFTest struct {
 	ClientRandomHex string `json:"clientRandom"`
 	ServerRandomHex string `json:"serverRandom"`
 	SessionHashHex  string `json:"sessionHash"`
+
+	// The hello randoms are only used without the extended master secret.
+	// When absent, they are the same as the client and server randoms.
+	ClientHelloRandomHex string `json:"clientHelloRandom"`
+	ServerHelloRandomHex string `json:"serverHelloRandom"`
 }
 
 type tlsKDFTestGroupResponse struct {
@@ -81,9 +91,11 @@ func (k *tlsKDF) Process(vectorSet []byte, m Transactable) (any, error) {
 		}
 
 		method := "TLSKDF/1.2/" + group.Hash
+		extendedMasterSecret := group.ExtendedMasterSecret == nil || *group.ExtendedMasterSecret
 
 		for _, test := range group.Tests {
 			test := test
+			m := withTestCase(m, "TLS-v1.2", group.ID, test.ID)
 			pms, err := hex.DecodeString(test.PMSHex)
 			if err != nil {
 				return nil, err
@@ -99,22 +111,42 @@ func (k *tlsKDF) Process(vectorSet []byte, m Transactable) (any, error) {
 				return nil, err
 			}
 
-			sessionHash, err := hex.DecodeString(test.SessionHashHex)
-			if err != nil {
-				return nil, err
-			}
-
 			const (
 				masterSecretLength = 48
-				masterSecretLabel  = "extended master secret"
 				keyBlockLabel      = "key expansion"
 			)
 
 			var outLenBytes [4]byte
 			binary.LittleEndian.PutUint32(outLenBytes[:], uint32(masterSecretLength))
-			result, err := m.Transact(method, 1, outLenBytes[:], pms, []byte(masterSecretLabel), sessionHash, nil)
-			if err != nil {
-				return nil, err
+
+			var result [][]byte
+			if extendedMasterSecret {
+				sessionHash, err := hex.DecodeString(test.SessionHashHex)
+				if err != nil {
+					return nil, err
+				}
+
+				// RFC 7627, section 4.
+				result, err = m.Transact(method, 1, outLenBytes[:], pms, []byte("extended master secret"), sessionHash, nil)
+				if err != nil {
+					return nil, err
+				}
+			} else {
+				clientHelloRandom, serverHelloRandom := clientRandom, serverRandom
+				if len(test.ClientHelloRandomHex) != 0 || len(test.ServerHelloRandomHex) != 0 {
+					if clientHelloRandom, err = hex.DecodeString(test.ClientHelloRandomHex); err != nil {
+						return nil, err
+					}
+					if serverHelloRandom, err = hex.DecodeString(test.ServerHelloRandomHex); err != nil {
+						return nil, err
+					}
+				}
+
+				// RFC 5246, section 8.1.
+				result, err = m.Transact(method, 1, outLenBytes[:], pms, []byte("master secret"), clientHelloRandom, serverHelloRandom)
+				if err != nil {
+					return nil, err
+				}
 			}
 
 			binary.LittleEndian.PutUint32(outLenBytes[:], uint32(group.KeyBlockBits/8))
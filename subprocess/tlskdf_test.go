@@ -0,0 +1,82 @@
+// Copyright (c) 2026, Google Inc.
+//
+// Permission to use, copy, modify, and/or distribute this software for any
+// purpose with or without fee is hereby granted, provided that the above
+// copyright notice and this permission notice appear in all copies.
+//
+// THE SOFTWARE IS PROVIDED "AS IS" AND THE AUTHOR DISCLAIMS ALL WARRANTIES
+// WITH REGARD TO THIS SOFTWARE INCLUDING ALL IMPLIED WARRANTIES OF
+// MERCHANTABILITY AND FITNESS. IN NO EVENT SHALL THE AUTHOR BE LIABLE FOR ANY
+// SPECIAL, DIRECT, INDIRECT, OR CONSEQUENTIAL DAMAGES OR ANY DAMAGES
+// WHATSOEVER RESULTING FROM LOSS OF USE, DATA OR PROFITS, WHETHER IN AN ACTION
+// OF CONTRACT, NEGLIGENCE OR OTHER TORTIOUS ACTION, ARISING OUT OF OR IN
+// CONNECTION WITH THE USE OR PERFORMANCE OF THIS SOFTWARE.
+
+package subprocess
+
+import (
+	"bytes"
+	"testing"
+)
+
+// tlsKDFResponder "derives" output by concatenating the label and seeds.
+func tlsKDFResponder(cmd string, args [][]byte) ([][]byte, error) {
+	if cmd != "TLSKDF/1.2/SHA2-256" {
+		panic("unexpected command " + cmd)
+	}
+	out := append(append(append([]byte{}, args[2]...), args[3]...), args[4]...)
+	return [][]byte{out}, nil
+}
+
+func tlsKDFTestVectors(flag string) string {
+	return `{"algorithm": "TLS-v1.2", "testGroups": [{
+		"tgId": 1, "hashAlg": "SHA2-256", "tlsVersion": "v1.2", "keyBlockLength": 1024, "preMasterSecretLength": 384` + flag + `,
+		"tests": [{"tcId": 1, "preMasterSecret": "aa", "clientRandom": "c1", "serverRandom": "51",
+			"sessionHash": "5e", "clientHelloRandom": "c0", "serverHelloRandom": "50"}]
+	}]}`
+}
+
+func TestTLSKDFExtendedMasterSecret(t *testing.T) {
+	for _, flag := range []string{"", `, "extendedMasterSecret": true`} {
+		m := &fakeTransactable{respond: tlsKDFResponder}
+		result, err := new(tlsKDF).Process([]byte(tlsKDFTestVectors(flag)), m)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		masterSecret := append([]byte("extended master secret"), 0x5e)
+		if args := m.calls[0].args; !bytes.Equal(args[1], []byte{0xaa}) || !bytes.Equal(args[2], []byte("extended master secret")) || len(args[4]) != 0 {
+			t.Errorf("flag %q: unexpected master secret arguments %q", flag, args)
+		}
+		if args := m.calls[1].args; !bytes.Equal(args[1], masterSecret) || !bytes.Equal(args[3], []byte{0x51}) || !bytes.Equal(args[4], []byte{0xc1}) {
+			t.Errorf("flag %q: unexpected key block arguments %q", flag, args)
+		}
+
+		test := result.([]tlsKDFTestGroupResponse)[0].Tests[0]
+		if test.MasterSecretHex != "657874656e646564206d617374657220736563726574"+"5e" || len(test.KeyBlockHex) == 0 {
+			t.Errorf("flag %q: unexpected response %+v", flag, test)
+		}
+	}
+}
+
+func TestTLSKDFClassicMasterSecret(t *testing.T) {
+	m := &fakeTransactable{respond: tlsKDFResponder}
+	result, err := new(tlsKDF).Process([]byte(tlsKDFTestVectors(`, "extendedMasterSecret": false`)), m)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if args := m.calls[0].args; !bytes.Equal(args[2], []byte("master secret")) || !bytes.Equal(args[3], []byte{0xc0}) || !bytes.Equal(args[4], []byte{0x50}) {
+		t.Errorf("unexpected master secret arguments %q", args)
+	}
+
+	masterSecret := []byte("master secret\xc0\x50")
+	if args := m.calls[1].args; !bytes.Equal(args[1], masterSecret) || !bytes.Equal(args[2], []byte("key expansion")) {
+		t.Errorf("unexpected key block arguments %q", args)
+	}
+
+	test := result.([]tlsKDFTestGroupResponse)[0].Tests[0]
+	if test.KeyBlockHex != "6b657920657870616e73696f6e"+"51c1" {
+		t.Errorf("unexpected response %+v", test)
+	}
+}
@@ -0,0 +1,79 @@
+// Copyright (c) 2026, Google Inc.
+//
+// Permission to use, copy, modify, and/or distribute this software for any
+// purpose with or without fee is hereby granted, provided that the above
+// copyright notice and this permission notice appear in all copies.
+//
+// THE SOFTWARE IS PROVIDED "AS IS" AND THE AUTHOR DISCLAIMS ALL WARRANTIES
+// WITH REGARD TO THIS SOFTWARE INCLUDING ALL IMPLIED WARRANTIES OF
+// MERCHANTABILITY AND FITNESS. IN NO EVENT SHALL THE AUTHOR BE LIABLE FOR ANY
+// SPECIAL, DIRECT, INDIRECT, OR CONSEQUENTIAL DAMAGES OR ANY DAMAGES
+// WHATSOEVER RESULTING FROM LOSS OF USE, DATA OR PROFITS, WHETHER IN AN ACTION
+// OF CONTRACT, NEGLIGENCE OR OTHER TORTIOUS ACTION, ARISING OUT OF OR IN
+// CONNECTION WITH THE USE OR PERFORMANCE OF THIS SOFTWARE.
+
+package subprocess
+
+import (
+	"encoding/hex"
+	"encoding/json"
+	"io"
+	"time"
+)
+
+// traceRecord is one line of a transaction trace. The contents of arguments
+// and results are only included if the trace was configured to include them,
+// since they may contain keys.
+type traceRecord struct {
+	Cmd           string   `json:"cmd"`
+	ArgLengths    []int    `json:"argLengths"`
+	ResultLengths []int    `json:"resultLengths"`
+	LatencyMicros int64    `json:"latencyMicros"`
+	Args          []string `json:"args,omitempty"`
+	Results       []string `json:"results,omitempty"`
+
+	sent time.Time
+}
+
+// Trace causes m to write a record of each command to w, as newline-delimited
+// JSON, once its response has been read. Each record gives the command name,
+// the lengths of its arguments and results, and the time between sending the
+// command and reading the response. The contents of the arguments and results
+// are only recorded if includeData is true. Call Trace before using m. All
+// records are written from a single goroutine.
+func (m *Subprocess) Trace(w io.Writer, includeData bool) {
+	m.traceWriter = w
+	m.traceData = includeData
+}
+
+func (m *Subprocess) newTraceRecord(cmd string, args [][]byte) *traceRecord {
+	if m.traceWriter == nil {
+		return nil
+	}
+	record := &traceRecord{Cmd: cmd, ArgLengths: make([]int, 0, len(args)), sent: time.Now()}
+	for _, arg := range args {
+		record.ArgLengths = append(record.ArgLengths, len(arg))
+		if m.traceData {
+			record.Args = append(record.Args, hex.EncodeToString(arg))
+		}
+	}
+	return record
+}
+
+func (m *Subprocess) writeTrace(record *traceRecord, result [][]byte) {
+	record.LatencyMicros = time.Since(record.sent).Microseconds()
+	record.ResultLengths = make([]int, 0, len(result))
+	for _, r := range result {
+		record.ResultLengths = append(record.ResultLengths, len(r))
+		if m.traceData {
+			record.Results = append(record.Results, hex.EncodeToString(r))
+		}
+	}
+
+	line, err := json.Marshal(record)
+	if err != nil {
+		panic(err)
+	}
+	// Tracing is for debugging, so failing to write it isn't fatal.
+	m.traceWriter.Write(append(line, '\n'))
+}
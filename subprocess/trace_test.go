@@ -0,0 +1,88 @@
+// Copyright (c) 2026, Google Inc.
+//
+// Permission to use, copy, modify, and/or distribute this software for any
+// purpose with or without fee is hereby granted, provided that the above
+// copyright notice and this permission notice appear in all copies.
+//
+// THE SOFTWARE IS PROVIDED "AS IS" AND THE AUTHOR DISCLAIMS ALL WARRANTIES
+// WITH REGARD TO THIS SOFTWARE INCLUDING ALL IMPLIED WARRANTIES OF
+// MERCHANTABILITY AND FITNESS. IN NO EVENT SHALL THE AUTHOR BE LIABLE FOR ANY
+// SPECIAL, DIRECT, INDIRECT, OR CONSEQUENTIAL DAMAGES OR ANY DAMAGES
+// WHATSOEVER RESULTING FROM LOSS OF USE, DATA OR PROFITS, WHETHER IN AN ACTION
+// OF CONTRACT, NEGLIGENCE OR OTHER TORTIOUS ACTION, ARISING OUT OF OR IN
+// CONNECTION WITH THE USE OR PERFORMANCE OF THIS SOFTWARE.
+
+package subprocess
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"encoding/json"
+	"strings"
+	"testing"
+)
+
+const cShakeAFTVectorSet = `{"testGroups": [{
+	"tgId": 1,
+	"testType": "AFT",
+	"tests": [
+		{"tcId": 1, "len": 16, "msg": "0001", "outLen": 32, "functionName": "", "customization": "secret"},
+		{"tcId": 2, "len": 8, "msg": "02", "outLen": 64, "functionName": "", "customization": ""}
+	]
+}]}`
+
+func traceCShakeAFT(t *testing.T, includeData bool) []traceRecord {
+	m := pipeModule(t, 0, func(cmd string, args [][]byte) ([][]byte, error) {
+		return [][]byte{make([]byte, binary.LittleEndian.Uint32(args[1]))}, nil
+	})
+	defer m.Close()
+
+	var trace bytes.Buffer
+	m.Trace(&trace, includeData)
+	if _, err := m.Process("cSHAKE-128", []byte(cShakeAFTVectorSet)); err != nil {
+		t.Fatal(err)
+	}
+
+	var records []traceRecord
+	scanner := bufio.NewScanner(&trace)
+	for scanner.Scan() {
+		var record traceRecord
+		if err := json.Unmarshal(scanner.Bytes(), &record); err != nil {
+			t.Fatalf("failed to parse trace line %q: %s", scanner.Text(), err)
+		}
+		records = append(records, record)
+	}
+	return records
+}
+
+func TestTrace(t *testing.T) {
+	records := traceCShakeAFT(t, false)
+	if len(records) != 2 {
+		t.Fatalf("got %d trace records, want 2", len(records))
+	}
+	for i, record := range records {
+		if record.Cmd != "cSHAKE-128" || len(record.ArgLengths) != 4 || len(record.ResultLengths) != 1 {
+			t.Errorf("record %d: unexpected %+v", i, record)
+		}
+		if record.Args != nil || record.Results != nil {
+			t.Errorf("record %d: data included by default: %+v", i, record)
+		}
+	}
+	if records[0].ArgLengths[0] != 2 || records[0].ArgLengths[3] != 6 || records[0].ResultLengths[0] != 4 {
+		t.Errorf("unexpected lengths in %+v", records[0])
+	}
+}
+
+func TestTraceData(t *testing.T) {
+	records := traceCShakeAFT(t, true)
+	if len(records) != 2 {
+		t.Fatalf("got %d trace records, want 2", len(records))
+	}
+	if args := strings.Join(records[0].Args, ","); args != "0001,04000000,,736563726574" {
+		t.Errorf("got arguments %s", args)
+	}
+	if results := strings.Join(records[1].Results, ","); results != "0000000000000000" {
+		t.Errorf("got results %s", results)
+	}
+}
@@ -0,0 +1,152 @@
+// Copyright (c) 2026, Google Inc.
+//
+// Permission to use, copy, modify, and/or distribute this software for any
+// purpose with or without fee is hereby granted, provided that the above
+// copyright notice and this permission notice appear in all copies.
+//
+// THE SOFTWARE IS PROVIDED "AS IS" AND THE AUTHOR DISCLAIMS ALL WARRANTIES
+// WITH REGARD TO THIS SOFTWARE INCLUDING ALL IMPLIED WARRANTIES OF
+// MERCHANTABILITY AND FITNESS. IN NO EVENT SHALL THE AUTHOR BE LIABLE FOR ANY
+// SPECIAL, DIRECT, INDIRECT, OR CONSEQUENTIAL DAMAGES OR ANY DAMAGES
+// WHATSOEVER RESULTING FROM LOSS OF USE, DATA OR PROFITS, WHETHER IN AN ACTION
+// OF CONTRACT, NEGLIGENCE OR OTHER TORTIOUS ACTION, ARISING OUT OF OR IN
+// CONNECTION WITH THE USE OR PERFORMANCE OF THIS SOFTWARE.
+
+package subprocess
+
+import (
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+)
+
+// The following structures reflect the JSON of ACVP TupleHash tests. See
+// https://pages.nist.gov/ACVP/draft-celi-acvp-tuplehash.html#name-test-vectors
+
+type tupleHashTestVectorSet struct {
+	Groups []tupleHashTestGroup `json:"testGroups"`
+}
+
+type tupleHashTestGroup struct {
+	ID               uint64 `json:"tgId"`
+	Type             string `json:"testType"`
+	XOF              bool   `json:"xof"`
+	HexCustomization bool   `json:"hexCustomization"`
+	MaxOutLenBits    uint32 `json:"maxOutLen"`
+	MinOutLenBits    uint32 `json:"minOutLen"`
+	OutLenIncrement  uint32 `json:"outLenIncrement"`
+	Tests            []struct {
+		ID               uint64   `json:"tcId"`
+		TupleHex         []string `json:"tuple"`
+		BitOutLength     uint32   `json:"outLen"`
+		Customization    string   `json:"customization"`
+		CustomizationHex string   `json:"customizationHex"`
+	} `json:"tests"`
+}
+
+type tupleHashTestGroupResponse struct {
+	ID    uint64                  `json:"tgId"`
+	Tests []tupleHashTestResponse `json:"tests"`
+}
+
+type tupleHashTestResponse struct {
+	ID        uint64 `json:"tcId"`
+	DigestHex string `json:"md,omitempty"`
+}
+
+// tupleHash implements an ACVP algorithm by making requests to the subprocess
+// to hash tuples of byte strings.
+type tupleHash struct {
+	// algo is the ACVP name for this algorithm and also the command name
+	// given to the subprocess.
+	algo string
+}
+
+// encodeTuple serialises a tuple into a single byte string: the number of
+// elements followed by the length and contents of each element, with lengths
+// as 32-bit little-endian values. A tuple may have more elements than the
+// subprocess protocol permits arguments, thus they aren't sent individually.
+func encodeTuple(tuple [][]byte) []byte {
+	ret := uint32le(uint32(len(tuple)))
+	for _, elem := range tuple {
+		ret = append(ret, uint32le(uint32(len(elem)))...)
+		ret = append(ret, elem...)
+	}
+	return ret
+}
+
+func (h *tupleHash) Process(vectorSet []byte, m Transactable) (any, error) {
+	var parsed tupleHashTestVectorSet
+	if err := json.Unmarshal(vectorSet, &parsed); err != nil {
+		return nil, err
+	}
+
+	var ret []tupleHashTestGroupResponse
+	// See
+	// https://pages.nist.gov/ACVP/draft-celi-acvp-tuplehash.html#name-test-types
+	// for details about the tests.
+	for _, group := range parsed.Groups {
+		group := group
+		response := tupleHashTestGroupResponse{
+			ID: group.ID,
+		}
+
+		if group.Type != "AFT" {
+			return nil, fmt.Errorf("test group %d has unknown type %q", group.ID, group.Type)
+		}
+
+		xof := []byte{0}
+		if group.XOF {
+			xof = []byte{1}
+		}
+
+		for _, test := range group.Tests {
+			test := test
+			m := withTestCase(m, h.algo, group.ID, test.ID)
+
+			tuple := make([][]byte, 0, len(test.TupleHex))
+			for i, elemHex := range test.TupleHex {
+				elem, err := hex.DecodeString(elemHex)
+				if err != nil {
+					return nil, testCaseInputError(h.algo, group.ID, test.ID, fmt.Errorf("failed to decode tuple element %d: %w", i, err))
+				}
+				tuple = append(tuple, elem)
+			}
+
+			if test.BitOutLength%8 != 0 {
+				return nil, testCaseInputError(h.algo, group.ID, test.ID, fmt.Errorf("bit length %d - fractional bytes not supported", test.BitOutLength))
+			}
+			if err := checkOutLen(test.BitOutLength, group.MinOutLenBits, group.MaxOutLenBits, group.OutLenIncrement); err != nil {
+				return nil, testCaseInputError(h.algo, group.ID, test.ID, err)
+			}
+			outBytes := int(test.BitOutLength / 8)
+
+			customization, err := decodeCustomization(group.HexCustomization, test.Customization, test.CustomizationHex)
+			if err != nil {
+				return nil, testCaseInputError(h.algo, group.ID, test.ID, fmt.Errorf("failed to decode customization: %w", err))
+			}
+
+			m.TransactAsync(h.algo, 1, [][]byte{encodeTuple(tuple), uint32le(test.BitOutLength / 8), customization, xof}, func(result [][]byte) error {
+				if len(result[0]) != outBytes {
+					return fmt.Errorf("%s operation returned %d bytes but wanted %d", h.algo, len(result[0]), outBytes)
+				}
+
+				response.Tests = append(response.Tests, tupleHashTestResponse{
+					ID:        test.ID,
+					DigestHex: hex.EncodeToString(result[0]),
+				})
+				return nil
+			})
+		}
+
+		m.Barrier(func() {
+			ret = append(ret, response)
+		})
+	}
+
+	if err := m.Flush(); err != nil {
+		return nil, err
+	}
+
+	return ret, nil
+}
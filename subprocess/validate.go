@@ -0,0 +1,132 @@
+// Copyright (c) 2026, Google Inc.
+//
+// Permission to use, copy, modify, and/or distribute this software for any
+// purpose with or without fee is hereby granted, provided that the above
+// copyright notice and this permission notice appear in all copies.
+//
+// THE SOFTWARE IS PROVIDED "AS IS" AND THE AUTHOR DISCLAIMS ALL WARRANTIES
+// WITH REGARD TO THIS SOFTWARE INCLUDING ALL IMPLIED WARRANTIES OF
+// MERCHANTABILITY AND FITNESS. IN NO EVENT SHALL THE AUTHOR BE LIABLE FOR ANY
+// SPECIAL, DIRECT, INDIRECT, OR CONSEQUENTIAL DAMAGES OR ANY DAMAGES
+// WHATSOEVER RESULTING FROM LOSS OF USE, DATA OR PROFITS, WHETHER IN AN ACTION
+// OF CONTRACT, NEGLIGENCE OR OTHER TORTIOUS ACTION, ARISING OUT OF OR IN
+// CONNECTION WITH THE USE OR PERFORMANCE OF THIS SOFTWARE.
+
+package subprocess
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+)
+
+// Validate checks the structure of vectorSet, which is for the named
+// algorithm, without a module. The handler is given each test group without
+// its tests, and then each test case on its own, so that every malformed
+// group and test case is reported rather than just the first. Handlers check
+// a test case before sending any synchronous command for it, so that is where
+// its validation ends, and asynchronous commands are dropped, so results are
+// not checked. Tests in a group that is itself malformed are skipped.
+// Duplicate tgIds and tcIds are reported too.
+func Validate(algorithm string, vectorSet []byte) []error {
+	prim, ok := Primitives()[algorithm]
+	if !ok {
+		return []error{fmt.Errorf("unknown algorithm %q", algorithm)}
+	}
+	return validate(prim, vectorSet)
+}
+
+func validate(prim Primitive, vectorSet []byte) []error {
+	var parsed map[string]json.RawMessage
+	if err := json.Unmarshal(vectorSet, &parsed); err != nil {
+		return []error{err}
+	}
+	var groups []map[string]json.RawMessage
+	if err := json.Unmarshal(parsed["testGroups"], &groups); err != nil {
+		// This isn't the usual structure, so leave it to the handler.
+		return validateOne(prim, vectorSet)
+	}
+
+	var errs []error
+	if err := checkIDs(vectorSet); err != nil {
+		errs = append(errs, err)
+	}
+	for _, group := range groups {
+		testsJSON, ok := group["tests"]
+		if !ok {
+			errs = append(errs, validateGroup(prim, parsed, group)...)
+			continue
+		}
+		var tests []json.RawMessage
+		if err := json.Unmarshal(testsJSON, &tests); err != nil {
+			errs = append(errs, fmt.Errorf("failed to parse tests: %s", err))
+			continue
+		}
+
+		group["tests"] = json.RawMessage("[]")
+		if groupErrs := validateGroup(prim, parsed, group); len(groupErrs) > 0 {
+			errs = append(errs, groupErrs...)
+			continue
+		}
+		for _, test := range tests {
+			testsJSON, err := json.Marshal([]json.RawMessage{test})
+			if err != nil {
+				return append(errs, err)
+			}
+			group["tests"] = testsJSON
+			errs = append(errs, validateGroup(prim, parsed, group)...)
+		}
+	}
+	return errs
+}
+
+// validateGroup validates a copy of the vector set parsed that contains only
+// group.
+func validateGroup(prim Primitive, parsed, group map[string]json.RawMessage) []error {
+	groupBytes, err := json.Marshal([]map[string]json.RawMessage{group})
+	if err != nil {
+		return []error{err}
+	}
+	parsed["testGroups"] = groupBytes
+	vectorSet, err := json.Marshal(parsed)
+	if err != nil {
+		return []error{err}
+	}
+	return validateOne(prim, vectorSet)
+}
+
+func validateOne(prim Primitive, vectorSet []byte) []error {
+	t := &dryRunTransactable{}
+	if _, err := prim.Process(vectorSet, t); err != nil && !t.stopped {
+		return []error{err}
+	}
+	// Any error after a synchronous command is the dry run's own.
+	return nil
+}
+
+// errDryRun is the error from dryRunTransactable for synchronous commands.
+var errDryRun = errors.New("no module to answer the command")
+
+// dryRunTransactable is a Transactable for Validate. It can't return results,
+// so Transact fails, which ends the handler, while TransactAsync drops the
+// command and lets it continue.
+type dryRunTransactable struct {
+	// stopped is set once Transact has been called.
+	stopped bool
+}
+
+func (d *dryRunTransactable) Transact(cmd string, expectedResults int, args ...[]byte) ([][]byte, error) {
+	d.stopped = true
+	return nil, errDryRun
+}
+
+func (*dryRunTransactable) TransactAsync(cmd string, expectedResults int, args [][]byte, callback func([][]byte) error) {
+}
+
+func (*dryRunTransactable) Barrier(callback func()) error {
+	return nil
+}
+
+func (*dryRunTransactable) Flush() error {
+	return nil
+}
@@ -0,0 +1,93 @@
+// Copyright (c) 2026, Google Inc.
+//
+// Permission to use, copy, modify, and/or distribute this software for any
+// purpose with or without fee is hereby granted, provided that the above
+// copyright notice and this permission notice appear in all copies.
+//
+// THE SOFTWARE IS PROVIDED "AS IS" AND THE AUTHOR DISCLAIMS ALL WARRANTIES
+// WITH REGARD TO THIS SOFTWARE INCLUDING ALL IMPLIED WARRANTIES OF
+// MERCHANTABILITY AND FITNESS. IN NO EVENT SHALL THE AUTHOR BE LIABLE FOR ANY
+// SPECIAL, DIRECT, INDIRECT, OR CONSEQUENTIAL DAMAGES OR ANY DAMAGES
+// WHATSOEVER RESULTING FROM LOSS OF USE, DATA OR PROFITS, WHETHER IN AN ACTION
+// OF CONTRACT, NEGLIGENCE OR OTHER TORTIOUS ACTION, ARISING OUT OF OR IN
+// CONNECTION WITH THE USE OR PERFORMANCE OF THIS SOFTWARE.
+
+package subprocess
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestValidateReportsAllErrors(t *testing.T) {
+	vectorSet := `{"algorithm": "cSHAKE-128", "testGroups": [
+		{"tgId": 1, "testType": "AFT", "tests": [
+			{"tcId": 1, "len": 16, "msg": "zzzz", "outLen": 64, "functionName": "", "customization": ""},
+			{"tcId": 2, "len": 16, "msg": "0001", "outLen": 63, "functionName": "", "customization": ""},
+			{"tcId": 3, "len": 16, "msg": "0001", "outLen": 64, "functionName": "", "customization": ""}
+		]},
+		{"tgId": 2, "testType": "AFT", "hexCustomization": true, "tests": [
+			{"tcId": 4, "len": 16, "msg": "0001", "outLen": 64, "functionName": "", "customization": "cust"}
+		]},
+		{"tgId": 3, "testType": "MCT", "minOutLen": 16, "maxOutLen": 64, "outLenIncrement": 8, "tests": [
+			{"tcId": 5, "len": 16, "msg": "0001", "functionName": "", "customization": ""}
+		]}
+	]}`
+
+	errs := Validate("cSHAKE-128", []byte(vectorSet))
+	want := []string{
+		"failed to decode hex in test case 1/1",
+		"test case 1/2 has bit length 63",
+		"cSHAKE-128 test case 2/4: failed to decode customization",
+	}
+	if len(errs) != len(want) {
+		t.Fatalf("got %d errors, want %d: %v", len(errs), len(want), errs)
+	}
+	for i, err := range errs {
+		if !strings.Contains(err.Error(), want[i]) {
+			t.Errorf("error %d is %q, want one containing %q", i, err, want[i])
+		}
+	}
+}
+
+func TestValidateGroupError(t *testing.T) {
+	// The group is malformed, so its tests, which are also malformed, aren't
+	// checked.
+	vectorSet := `{"algorithm": "KMAC-128", "testGroups": [
+		{"tgId": 1, "testType": "unknown", "tests": [
+			{"tcId": 1, "key": "zz", "keyLen": 8, "msg": "", "msgLen": 0, "macLen": 64, "customization": ""},
+			{"tcId": 2, "key": "zz", "keyLen": 8, "msg": "", "msgLen": 0, "macLen": 64, "customization": ""}
+		]}
+	]}`
+
+	errs := Validate("KMAC-128", []byte(vectorSet))
+	if len(errs) != 1 || !strings.Contains(errs[0].Error(), "unknown type") {
+		t.Fatalf("got errors %v, want one for the group", errs)
+	}
+}
+
+func TestValidateAfterSynchronousCommand(t *testing.T) {
+	// Each sigGen group starts by generating a key, which needs a module,
+	// but the test cases are still checked.
+	vectorSet := `{"algorithm": "ECDSA", "mode": "sigGen", "testGroups": [
+		{"tgId": 1, "curve": "P-256", "hashAlg": "SHA2-256", "componentTest": true, "tests": [
+			{"tcId": 1, "message": "` + strings.Repeat("00", 32) + `"},
+			{"tcId": 2, "message": "zz"},
+			{"tcId": 3, "message": "0001"}
+		]}
+	]}`
+
+	errs := Validate("ECDSA", []byte(vectorSet))
+	want := []string{
+		"failed to decode message hex in test case 1/2",
+		"test case 1/3 contains message",
+	}
+	if len(errs) != len(want) {
+		t.Fatalf("got %d errors, want %d: %v", len(errs), len(want), errs)
+	}
+	for i, err := range errs {
+		if !strings.Contains(err.Error(), want[i]) {
+			t.Errorf("error %d is %q, want one containing %q", i, err, want[i])
+		}
+	}
+}
@@ -0,0 +1,197 @@
+// Copyright (c) 2026, Google Inc.
+//
+// Permission to use, copy, modify, and/or distribute this software for any
+// purpose with or without fee is hereby granted, provided that the above
+// copyright notice and this permission notice appear in all copies.
+//
+// THE SOFTWARE IS PROVIDED "AS IS" AND THE AUTHOR DISCLAIMS ALL WARRANTIES
+// WITH REGARD TO THIS SOFTWARE INCLUDING ALL IMPLIED WARRANTIES OF
+// MERCHANTABILITY AND FITNESS. IN NO EVENT SHALL THE AUTHOR BE LIABLE FOR ANY
+// SPECIAL, DIRECT, INDIRECT, OR CONSEQUENTIAL DAMAGES OR ANY DAMAGES
+// WHATSOEVER RESULTING FROM LOSS OF USE, DATA OR PROFITS, WHETHER IN AN ACTION
+// OF CONTRACT, NEGLIGENCE OR OTHER TORTIOUS ACTION, ARISING OUT OF OR IN
+// CONNECTION WITH THE USE OR PERFORMANCE OF THIS SOFTWARE.
+
+package subprocess
+
+import (
+	"encoding/binary"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+)
+
+// The following structures reflect the JSON of XMSS and XMSS^MT tests, which
+// follow those of LMS tests.
+
+type xmssTestVectorSet struct {
+	Groups []xmssTestGroup `json:"testGroups"`
+	Mode   string          `json:"mode"`
+}
+
+type xmssTestGroup struct {
+	ID   uint64 `json:"tgId"`
+	Type string `json:"testType"`
+	// OID is the parameter set's identifier, from RFC 8391 or SP 800-208.
+	OID   uint32 `json:"oid"`
+	Tests []struct {
+		ID           uint64 `json:"tcId"`
+		PublicKeyHex string `json:"publicKey"`
+		MsgHex       string `json:"message"`
+		SignatureHex string `json:"signature"`
+	} `json:"tests"`
+}
+
+type xmssTestGroupResponse struct {
+	ID    uint64             `json:"tgId"`
+	Tests []xmssTestResponse `json:"tests"`
+}
+
+type xmssTestResponse struct {
+	ID     uint64 `json:"tcId"`
+	Passed bool   `json:"testPassed"`
+}
+
+// xmssParameterSet describes an XMSS or XMSS^MT parameter set: its name, the
+// hash output length n, the total tree height h and the number of layers d,
+// which is one for XMSS. WOTS+ always uses w = 16.
+type xmssParameterSet struct {
+	name    string
+	n, h, d int
+}
+
+// xmssParameterSets contains the XMSS parameter sets that SP 800-208,
+// section 5, approves, keyed by OID.
+var xmssParameterSets = map[uint32]xmssParameterSet{
+	0x01: {"XMSS-SHA2_10_256", 32, 10, 1},
+	0x02: {"XMSS-SHA2_16_256", 32, 16, 1},
+	0x03: {"XMSS-SHA2_20_256", 32, 20, 1},
+	0x0d: {"XMSS-SHA2_10_192", 24, 10, 1},
+	0x0e: {"XMSS-SHA2_16_192", 24, 16, 1},
+	0x0f: {"XMSS-SHA2_20_192", 24, 20, 1},
+	0x10: {"XMSS-SHAKE256_10_256", 32, 10, 1},
+	0x11: {"XMSS-SHAKE256_16_256", 32, 16, 1},
+	0x12: {"XMSS-SHAKE256_20_256", 32, 20, 1},
+	0x13: {"XMSS-SHAKE256_10_192", 24, 10, 1},
+	0x14: {"XMSS-SHAKE256_16_192", 24, 16, 1},
+	0x15: {"XMSS-SHAKE256_20_192", 24, 20, 1},
+}
+
+// xmssmtParameterSets contains the XMSS^MT parameter sets that SP 800-208,
+// section 5, approves, keyed by OID.
+var xmssmtParameterSets = map[uint32]xmssParameterSet{
+	0x01: {"XMSSMT-SHA2_20/2_256", 32, 20, 2},
+	0x02: {"XMSSMT-SHA2_20/4_256", 32, 20, 4},
+	0x03: {"XMSSMT-SHA2_40/2_256", 32, 40, 2},
+	0x04: {"XMSSMT-SHA2_40/4_256", 32, 40, 4},
+	0x05: {"XMSSMT-SHA2_40/8_256", 32, 40, 8},
+	0x06: {"XMSSMT-SHA2_60/3_256", 32, 60, 3},
+	0x07: {"XMSSMT-SHA2_60/6_256", 32, 60, 6},
+	0x08: {"XMSSMT-SHA2_60/12_256", 32, 60, 12},
+	0x21: {"XMSSMT-SHA2_20/2_192", 24, 20, 2},
+	0x22: {"XMSSMT-SHA2_20/4_192", 24, 20, 4},
+	0x23: {"XMSSMT-SHA2_40/2_192", 24, 40, 2},
+	0x24: {"XMSSMT-SHA2_40/4_192", 24, 40, 4},
+	0x25: {"XMSSMT-SHA2_40/8_192", 24, 40, 8},
+	0x26: {"XMSSMT-SHA2_60/3_192", 24, 60, 3},
+	0x27: {"XMSSMT-SHA2_60/6_192", 24, 60, 6},
+	0x28: {"XMSSMT-SHA2_60/12_192", 24, 60, 12},
+	0x29: {"XMSSMT-SHAKE256_20/2_256", 32, 20, 2},
+	0x2a: {"XMSSMT-SHAKE256_20/4_256", 32, 20, 4},
+	0x2b: {"XMSSMT-SHAKE256_40/2_256", 32, 40, 2},
+	0x2c: {"XMSSMT-SHAKE256_40/4_256", 32, 40, 4},
+	0x2d: {"XMSSMT-SHAKE256_40/8_256", 32, 40, 8},
+	0x2e: {"XMSSMT-SHAKE256_60/3_256", 32, 60, 3},
+	0x2f: {"XMSSMT-SHAKE256_60/6_256", 32, 60, 6},
+	0x30: {"XMSSMT-SHAKE256_60/12_256", 32, 60, 12},
+	0x31: {"XMSSMT-SHAKE256_20/2_192", 24, 20, 2},
+	0x32: {"XMSSMT-SHAKE256_20/4_192", 24, 20, 4},
+	0x33: {"XMSSMT-SHAKE256_40/2_192", 24, 40, 2},
+	0x34: {"XMSSMT-SHAKE256_40/4_192", 24, 40, 4},
+	0x35: {"XMSSMT-SHAKE256_40/8_192", 24, 40, 8},
+	0x36: {"XMSSMT-SHAKE256_60/3_192", 24, 60, 3},
+	0x37: {"XMSSMT-SHAKE256_60/6_192", 24, 60, 6},
+	0x38: {"XMSSMT-SHAKE256_60/12_192", 24, 60, 12},
+}
+
+// xmss implements an ACVP algorithm by making requests to the subprocess to
+// verify XMSS or XMSS^MT signatures. As with LMS, only verification is
+// supported.
+type xmss struct {
+	algo          string
+	parameterSets map[uint32]xmssParameterSet
+}
+
+func (x *xmss) Process(vectorSet []byte, m Transactable) (any, error) {
+	var parsed xmssTestVectorSet
+	if err := json.Unmarshal(vectorSet, &parsed); err != nil {
+		return nil, err
+	}
+
+	if parsed.Mode != "sigVer" {
+		return nil, fmt.Errorf("%s mode %q not supported", x.algo, parsed.Mode)
+	}
+
+	var ret []xmssTestGroupResponse
+	for _, group := range parsed.Groups {
+		group := group
+		response := xmssTestGroupResponse{ID: group.ID}
+
+		if group.Type != "AFT" {
+			return nil, fmt.Errorf("unknown test type %q in sigVer test group %d", group.Type, group.ID)
+		}
+		params, ok := x.parameterSets[group.OID]
+		if !ok {
+			return nil, fmt.Errorf("unknown %s OID %#x in test group %d", x.algo, group.OID, group.ID)
+		}
+
+		for _, test := range group.Tests {
+			test := test
+			m := withTestCase(m, x.algo, group.ID, test.ID)
+
+			// The public key is the OID, the root and the public seed. See
+			// RFC 8391, sections 4.1.7 and 4.2.5.
+			publicKey, err := hex.DecodeString(test.PublicKeyHex)
+			if err != nil {
+				return nil, fmt.Errorf("failed to decode public key in test case %d/%d: %s", group.ID, test.ID, err)
+			}
+			if len(publicKey) != 4+2*params.n {
+				return nil, fmt.Errorf("test case %d/%d has a %d-byte public key, but %s uses %d bytes", group.ID, test.ID, len(publicKey), params.name, 4+2*params.n)
+			}
+			if oid := binary.BigEndian.Uint32(publicKey); oid != group.OID {
+				return nil, fmt.Errorf("public key in test case %d/%d has OID %#x, but the group uses %#x", group.ID, test.ID, oid, group.OID)
+			}
+			msg, err := hex.DecodeString(test.MsgHex)
+			if err != nil {
+				return nil, fmt.Errorf("failed to decode message in test case %d/%d: %s", group.ID, test.ID, err)
+			}
+			// The signature may have been corrupted, so its structure is left
+			// for the module to check.
+			sig, err := hex.DecodeString(test.SignatureHex)
+			if err != nil {
+				return nil, fmt.Errorf("failed to decode signature in test case %d/%d: %s", group.ID, test.ID, err)
+			}
+
+			m.TransactAsync(x.algo+"/sigVer", 1, [][]byte{publicKey, msg, sig}, func(result [][]byte) error {
+				if len(result[0]) != 1 || (result[0][0]&0xfe) != 0 {
+					return fmt.Errorf("signature verification returned unexpected result: %q", result[0])
+				}
+				response.Tests = append(response.Tests, xmssTestResponse{
+					ID:     test.ID,
+					Passed: result[0][0] == 1,
+				})
+				return nil
+			})
+		}
+
+		m.Barrier(func() {
+			ret = append(ret, response)
+		})
+	}
+
+	if err := m.Flush(); err != nil {
+		return nil, err
+	}
+
+	return ret, nil
+}
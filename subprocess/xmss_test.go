@@ -0,0 +1,95 @@
+// Copyright (c) 2026, Google Inc.
+//
+// Permission to use, copy, modify, and/or distribute this software for any
+// purpose with or without fee is hereby granted, provided that the above
+// copyright notice and this permission notice appear in all copies.
+//
+// THE SOFTWARE IS PROVIDED "AS IS" AND THE AUTHOR DISCLAIMS ALL WARRANTIES
+// WITH REGARD TO THIS SOFTWARE INCLUDING ALL IMPLIED WARRANTIES OF
+// MERCHANTABILITY AND FITNESS. IN NO EVENT SHALL THE AUTHOR BE LIABLE FOR ANY
+// SPECIAL, DIRECT, INDIRECT, OR CONSEQUENTIAL DAMAGES OR ANY DAMAGES
+// WHATSOEVER RESULTING FROM LOSS OF USE, DATA OR PROFITS, WHETHER IN AN ACTION
+// OF CONTRACT, NEGLIGENCE OR OTHER TORTIOUS ACTION, ARISING OUT OF OR IN
+// CONNECTION WITH THE USE OR PERFORMANCE OF THIS SOFTWARE.
+
+package subprocess
+
+import (
+	"bytes"
+	"encoding/binary"
+	"encoding/hex"
+	"fmt"
+	"testing"
+)
+
+// xmssTestSignature returns a signature with the layout of params, with
+// placeholder contents, and the offset of the last layer's authentication path.
+// See RFC 8391, sections 4.1.8 and 4.2.3.
+func xmssTestSignature(params xmssParameterSet) ([]byte, int) {
+	idxLen := 4
+	if params.d > 1 {
+		idxLen = (params.h + 7) / 8
+	}
+	wotsLen := (2*params.n + 3) * params.n
+	authLen := params.h / params.d * params.n
+
+	sig := bytes.Repeat([]byte{0x1d}, idxLen+params.n)
+	for i := 0; i < params.d; i++ {
+		sig = append(sig, bytes.Repeat([]byte{0x3a}, wotsLen)...)
+		sig = append(sig, bytes.Repeat([]byte{0xa7}, authLen)...)
+	}
+	return sig, len(sig) - authLen
+}
+
+func TestXMSSSigVer(t *testing.T) {
+	for _, test := range []struct {
+		algo          string
+		parameterSets map[uint32]xmssParameterSet
+		oid           uint32
+	}{
+		{"XMSS", xmssParameterSets, 0x01},
+		{"XMSS^MT", xmssmtParameterSets, 0x2a},
+	} {
+		params := test.parameterSets[test.oid]
+		publicKey := binary.BigEndian.AppendUint32(nil, test.oid)
+		publicKey = append(publicKey, bytes.Repeat([]byte{0x9b}, 2*params.n)...)
+		sig, authOffset := xmssTestSignature(params)
+		altered := bytes.Clone(sig)
+		altered[authOffset] ^= 1
+
+		respond := func(cmd string, args [][]byte) ([][]byte, error) {
+			if cmd != test.algo+"/sigVer" {
+				return nil, fmt.Errorf("unexpected command %q", cmd)
+			}
+			return [][]byte{{boolToByte(bytes.Equal(args[2], sig))}}, nil
+		}
+
+		vectorSet := fmt.Sprintf(`{"algorithm": %q, "mode": "sigVer", "testGroups": [{
+			"tgId": 1, "testType": "AFT", "oid": %d,
+			"tests": [
+				{"tcId": 1, "publicKey": %q, "message": "aa", "signature": %q},
+				{"tcId": 2, "publicKey": %q, "message": "aa", "signature": %q}
+			]
+		}]}`, test.algo, test.oid, hex.EncodeToString(publicKey), hex.EncodeToString(sig), hex.EncodeToString(publicKey), hex.EncodeToString(altered))
+
+		x := &xmss{test.algo, test.parameterSets}
+		result, err := x.Process([]byte(vectorSet), &fakeTransactable{respond: respond})
+		if err != nil {
+			t.Fatalf("%s: %s", params.name, err)
+		}
+		tests := result.([]xmssTestGroupResponse)[0].Tests
+		if len(tests) != 2 || !tests[0].Passed || tests[1].Passed {
+			t.Errorf("%s: unexpected response: %+v", params.name, tests)
+		}
+
+		// A public key for a different parameter set is an error.
+		publicKey[3] ^= 0xff
+		wrongKey := fmt.Sprintf(`{"algorithm": %q, "mode": "sigVer", "testGroups": [{
+			"tgId": 1, "testType": "AFT", "oid": %d,
+			"tests": [{"tcId": 1, "publicKey": %q, "message": "aa", "signature": %q}]
+		}]}`, test.algo, test.oid, hex.EncodeToString(publicKey), hex.EncodeToString(sig))
+		if _, err := x.Process([]byte(wrongKey), &fakeTransactable{respond: respond}); err == nil {
+			t.Errorf("%s: public key with the wrong OID was accepted", params.name)
+		}
+	}
+}
@@ -34,7 +34,11 @@ type xtsTestGroup struct {
 	Direction  string `json:"direction"`
 	KeyLen     int    `json:"keyLen"`
 	PayloadLen int    `json:"payloadLen"`
-	Tests      []struct {
+	TweakMode  string `json:"tweakMode"`
+	// DataUnitLen is the length, in bits, of each data unit in the payload.
+	// Zero means that the payload is a single data unit.
+	DataUnitLen int `json:"dataUnitLen"`
+	Tests       []struct {
 		ID            uint64  `json:"tcId"`
 		KeyHex        string  `json:"key"`
 		PlaintextHex  string  `json:"pt"`
@@ -59,6 +63,18 @@ type xtsTestResponse struct {
 // encrypt/decrypt with AES-XTS.
 type xts struct{}
 
+// incrementTweak adds one to a tweak, treating it as a little-endian integer.
+// Successive data units use successive tweaks. See IEEE 1619-2007, section
+// 5.1.
+func incrementTweak(tweak *[16]byte) {
+	for i := range tweak {
+		tweak[i]++
+		if tweak[i] != 0 {
+			break
+		}
+	}
+}
+
 func (h *xts) Process(vectorSet []byte, m Transactable) (any, error) {
 	var parsed xtsTestVectorSet
 	if err := json.Unmarshal(vectorSet, &parsed); err != nil {
@@ -88,8 +104,23 @@ func (h *xts) Process(vectorSet []byte, m Transactable) (any, error) {
 
 		funcName := "AES-XTS/" + group.Direction
 
+		switch group.TweakMode {
+		case "hex", "number", "":
+		default:
+			return nil, fmt.Errorf("test group %d has unknown tweak mode %q", group.ID, group.TweakMode)
+		}
+
+		if group.DataUnitLen%8 != 0 || group.DataUnitLen < 0 {
+			return nil, fmt.Errorf("test group %d has data unit length %d - fractional bytes not supported", group.ID, group.DataUnitLen)
+		}
+		if group.DataUnitLen != 0 && group.DataUnitLen < 128 {
+			return nil, fmt.Errorf("test group %d has data unit length %d, which is shorter than a block", group.ID, group.DataUnitLen)
+		}
+		dataUnitBytes := group.DataUnitLen / 8
+
 		for _, test := range group.Tests {
 			test := test
+			m := withTestCase(m, "ACVP-AES-XTS", group.ID, test.ID)
 			if group.KeyLen != len(test.KeyHex)*4/2 {
 				return nil, fmt.Errorf("test case %d/%d contains hex message of length %d but specifies a key length of %d (remember that XTS keys are twice the length of the underlying key size)", group.ID, test.ID, len(test.KeyHex), group.KeyLen)
 			}
@@ -99,7 +130,13 @@ func (h *xts) Process(vectorSet []byte, m Transactable) (any, error) {
 			}
 
 			var tweak [16]byte
-			if test.TweakHex != nil {
+			if group.TweakMode == "hex" && test.TweakHex == nil {
+				return nil, fmt.Errorf("test case %d/%d is in a hex tweak group but has no tweak value", group.ID, test.ID)
+			} else if group.TweakMode == "number" && test.SectorNum == nil {
+				return nil, fmt.Errorf("test case %d/%d is in a number tweak group but has no sequence number", group.ID, test.ID)
+			}
+
+			if test.TweakHex != nil && group.TweakMode != "number" {
 				t, err := hex.DecodeString(*test.TweakHex)
 				if err != nil {
 					return nil, fmt.Errorf("failed to decode hex in test case %d/%d: %s", group.ID, test.ID, err)
@@ -128,17 +165,49 @@ func (h *xts) Process(vectorSet []byte, m Transactable) (any, error) {
 				return nil, fmt.Errorf("failed to decode hex in test case %d/%d: %s", group.ID, test.ID, err)
 			}
 
-			m.TransactAsync(funcName, 1, [][]byte{key, msg, tweak[:]}, func(result [][]byte) error {
-				testResponse := xtsTestResponse{ID: test.ID}
-				if decrypt {
-					testResponse.PlaintextHex = hex.EncodeToString(result[0])
-				} else {
-					testResponse.CiphertextHex = hex.EncodeToString(result[0])
+			// A payload that spans several data units is processed one data
+			// unit at a time. The final data unit may be shorter than the
+			// others and need not be a multiple of the block size, in which
+			// case the module must use ciphertext stealing.
+			var units [][]byte
+			if dataUnitBytes == 0 || len(msg) <= dataUnitBytes {
+				units = [][]byte{msg}
+			} else {
+				for rest := msg; len(rest) > 0; {
+					n := dataUnitBytes
+					if n > len(rest) {
+						n = len(rest)
+					}
+					units = append(units, rest[:n])
+					rest = rest[n:]
+				}
+				if last := units[len(units)-1]; len(last) < 16 {
+					return nil, fmt.Errorf("test case %d/%d ends with a data unit of %d bytes, which is shorter than a block", group.ID, test.ID, len(last))
 				}
+			}
 
-				response.Tests = append(response.Tests, testResponse)
-				return nil
-			})
+			var output []byte
+			for i, unit := range units {
+				last := i == len(units)-1
+				unitTweak := tweak
+				m.TransactAsync(funcName, 1, [][]byte{key, unit, unitTweak[:]}, func(result [][]byte) error {
+					output = append(output, result[0]...)
+					if !last {
+						return nil
+					}
+
+					testResponse := xtsTestResponse{ID: test.ID}
+					if decrypt {
+						testResponse.PlaintextHex = hex.EncodeToString(output)
+					} else {
+						testResponse.CiphertextHex = hex.EncodeToString(output)
+					}
+
+					response.Tests = append(response.Tests, testResponse)
+					return nil
+				})
+				incrementTweak(&tweak)
+			}
 		}
 
 		m.Barrier(func() {
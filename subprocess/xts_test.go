@@ -0,0 +1,92 @@
+// Copyright (c) 2026, Google Inc.
+//
+// Permission to use, copy, modify, and/or distribute this software for any
+// purpose with or without fee is hereby granted, provided that the above
+// copyright notice and this permission notice appear in all copies.
+//
+// THE SOFTWARE IS PROVIDED "AS IS" AND THE AUTHOR DISCLAIMS ALL WARRANTIES
+// WITH REGARD TO THIS SOFTWARE INCLUDING ALL IMPLIED WARRANTIES OF
+// MERCHANTABILITY AND FITNESS. IN NO EVENT SHALL THE AUTHOR BE LIABLE FOR ANY
+// SPECIAL, DIRECT, INDIRECT, OR CONSEQUENTIAL DAMAGES OR ANY DAMAGES
+// WHATSOEVER RESULTING FROM LOSS OF USE, DATA OR PROFITS, WHETHER IN AN ACTION
+// OF CONTRACT, NEGLIGENCE OR OTHER TORTIOUS ACTION, ARISING OUT OF OR IN
+// CONNECTION WITH THE USE OR PERFORMANCE OF THIS SOFTWARE.
+
+package subprocess
+
+import (
+	"bytes"
+	"encoding/hex"
+	"strings"
+	"testing"
+)
+
+// xtsEchoResponder "encrypts" by returning the input unchanged.
+func xtsEchoResponder(cmd string, args [][]byte) ([][]byte, error) {
+	return [][]byte{args[1]}, nil
+}
+
+func TestXTSTweakModes(t *testing.T) {
+	key := strings.Repeat("00", 64)
+	pt := strings.Repeat("11", 16)
+	vectorSet := `{"testGroups": [{
+		"tgId": 1, "testType": "AFT", "direction": "encrypt", "keyLen": 256, "tweakMode": "hex",
+		"tests": [{"tcId": 1, "key": "` + key + `", "pt": "` + pt + `", "tweakValue": "000102030405060708090a0b0c0d0e0f"}]
+	}, {
+		"tgId": 2, "testType": "AFT", "direction": "encrypt", "keyLen": 256, "tweakMode": "number",
+		"tests": [{"tcId": 2, "key": "` + key + `", "pt": "` + pt + `", "sequenceNumber": 258}]
+	}]}`
+
+	m := &fakeTransactable{respond: xtsEchoResponder}
+	if _, err := (&xts{}).Process([]byte(vectorSet), m); err != nil {
+		t.Fatal(err)
+	}
+
+	wantTweaks := []string{
+		"000102030405060708090a0b0c0d0e0f",
+		"02010000000000000000000000000000",
+	}
+	if len(m.calls) != len(wantTweaks) {
+		t.Fatalf("got %d calls, want %d", len(m.calls), len(wantTweaks))
+	}
+	for i, call := range m.calls {
+		if got := hex.EncodeToString(call.args[2]); got != wantTweaks[i] {
+			t.Errorf("call %d: got tweak %s, want %s", i, got, wantTweaks[i])
+		}
+	}
+}
+
+func TestXTSDataUnits(t *testing.T) {
+	pt := make([]byte, 50)
+	for i := range pt {
+		pt[i] = byte(i)
+	}
+	// Two data units of 200 bits, neither a multiple of the block size.
+	vectorSet := `{"testGroups": [{
+		"tgId": 1, "testType": "AFT", "direction": "decrypt", "keyLen": 128, "tweakMode": "number", "dataUnitLen": 200,
+		"tests": [{"tcId": 1, "key": "` + strings.Repeat("00", 32) + `", "ct": "` + hex.EncodeToString(pt) + `", "sequenceNumber": 255}]
+	}]}`
+
+	m := &fakeTransactable{respond: xtsEchoResponder}
+	result, err := (&xts{}).Process([]byte(vectorSet), m)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(m.calls) != 2 {
+		t.Fatalf("got %d calls, want 2", len(m.calls))
+	}
+	for i, call := range m.calls {
+		if !bytes.Equal(call.args[1], pt[25*i:25*(i+1)]) {
+			t.Errorf("call %d: got data unit %x", i, call.args[1])
+		}
+	}
+	if got := hex.EncodeToString(m.calls[1].args[2]); got != "00010000000000000000000000000000" {
+		t.Errorf("second data unit has tweak %s", got)
+	}
+
+	groups := result.([]xtsTestGroupResponse)
+	if got := groups[0].Tests[0].PlaintextHex; got != hex.EncodeToString(pt) {
+		t.Errorf("got plaintext %s", got)
+	}
+}
@@ -17,6 +17,7 @@ package main
 import (
 	"bytes"
 	"crypto/aes"
+	"crypto/cipher"
 	"crypto/rand"
 	"encoding/hex"
 	"testing"
@@ -93,3 +94,37 @@ func TestCTSVectors(t *testing.T) {
 		}
 	}
 }
+
+func TestCTSVariants(t *testing.T) {
+	var buf [aes.BlockSize * 4]byte
+	var key, iv [16]byte
+	rand.Reader.Read(buf[:])
+	rand.Reader.Read(key[:])
+	rand.Reader.Read(iv[:])
+
+	block, err := aes.NewCipher(key[:])
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for i := aes.BlockSize; i < len(buf); i++ {
+		ciphertext := doCTSEncrypt(key[:], buf[:i], iv[:])
+		for variant := 1; variant <= 3; variant++ {
+			reordered := ctsReorder(ciphertext, variant, false)
+			if again := ctsReorder(reordered, variant, true); !bytes.Equal(again, ciphertext) {
+				t.Errorf("CS%d did not round trip for length %d", variant, i)
+			}
+
+			// CS1 and CS2 are plain CBC when the input is a whole number
+			// of blocks.
+			if variant == 3 || i%aes.BlockSize != 0 {
+				continue
+			}
+			cbc := make([]byte, i)
+			cipher.NewCBCEncrypter(block, iv[:]).CryptBlocks(cbc, buf[:i])
+			if !bytes.Equal(reordered, cbc) {
+				t.Errorf("CS%d differs from CBC for length %d", variant, i)
+			}
+		}
+	}
+}
@@ -57,8 +57,12 @@ var handlers = map[string]func([][]byte) error{
 	"HKDF/SHA2-256":            hkdfMAC,
 	"hmacDRBG-reseed/SHA2-256": hmacDRBGReseed,
 	"hmacDRBG-pr/SHA2-256":     hmacDRBGPredictionResistance,
-	"AES-CBC-CS3/encrypt":      ctsEncrypt,
-	"AES-CBC-CS3/decrypt":      ctsDecrypt,
+	"AES-CBC-CS1/encrypt":      ctsEncrypt(1),
+	"AES-CBC-CS1/decrypt":      ctsDecrypt(1),
+	"AES-CBC-CS2/encrypt":      ctsEncrypt(2),
+	"AES-CBC-CS2/decrypt":      ctsDecrypt(2),
+	"AES-CBC-CS3/encrypt":      ctsEncrypt(3),
+	"AES-CBC-CS3/decrypt":      ctsDecrypt(3),
 	"PBKDF":                    pbkdf,
 	"EDDSA/keyGen":             eddsaKeyGen,
 	"EDDSA/keyVer":             eddsaKeyVer,
@@ -70,6 +74,8 @@ var handlers = map[string]func([][]byte) error{
 	"SHAKE-256":                shakeAftVot(sha3.NewShake256),
 	"SHAKE-256/VOT":            shakeAftVot(sha3.NewShake256),
 	"SHAKE-256/MCT":            shakeMct(sha3.NewShake256),
+	"cSHAKE-128":               cShakeAft(sha3.NewCShake128),
+	"cSHAKE-256":               cShakeAft(sha3.NewCShake256),
 }
 
 func flush(args [][]byte) error {
@@ -170,6 +176,38 @@ func getConfig(args [][]byte) error {
 			],
 			"returnedBitsLen": 256
 		}]
+	}, {
+		"algorithm": "ACVP-AES-CBC-CS1",
+		"revision": "1.0",
+		"payloadLen": [{
+			"min": 128,
+			"max": 2048,
+			"increment": 8
+		}],
+		"direction": [
+		  "encrypt",
+		  "decrypt"
+		],
+		"keyLen": [
+		  128,
+		  256
+		]
+	}, {
+		"algorithm": "ACVP-AES-CBC-CS2",
+		"revision": "1.0",
+		"payloadLen": [{
+			"min": 128,
+			"max": 2048,
+			"increment": 8
+		}],
+		"direction": [
+		  "encrypt",
+		  "decrypt"
+		],
+		"keyLen": [
+		  128,
+		  256
+		]
 	}, {
 		"algorithm": "ACVP-AES-CBC-CS3",
 		"revision": "1.0",
@@ -234,7 +272,7 @@ func getConfig(args [][]byte) error {
 		"revision": "1.0",
 		"pure": true,
 		"preHash": true,
-		"curve": ["ED-25519"]}
+		"curve": ["ED-25519"]
 	}, {
 		"algorithm": "SHAKE-128",
 		"inBit": false,
@@ -257,6 +295,18 @@ func getConfig(args [][]byte) error {
 			"increment": 8
 		}],
 		"revision": "1.0"
+	}, {
+		"algorithm": "cSHAKE-128",
+		"revision": "1.0",
+		"hexCustomization": false,
+		"msgLen": [{"min": 0, "max": 65536, "increment": 8}],
+		"outputLen": [{"min": 16, "max": 65536, "increment": 8}]
+	}, {
+		"algorithm": "cSHAKE-256",
+		"revision": "1.0",
+		"hexCustomization": false,
+		"msgLen": [{"min": 0, "max": 65536, "increment": 8}],
+		"outputLen": [{"min": 16, "max": 65536, "increment": 8}]
 	}
 ]`)); err != nil {
 		return err
@@ -528,38 +578,65 @@ func doCTSDecrypt(key, origCiphertext, iv []byte) []byte {
 	return plaintext
 }
 
-func ctsEncrypt(args [][]byte) error {
-	if len(args) != 4 {
-		return fmt.Errorf("ctsEncrypt received %d args, wanted 4", len(args))
+// ctsReorder converts a CS3 ciphertext to the block order of the given
+// ciphertext-stealing variant or, if toCS3 is set, back again. CS1 keeps the
+// truncated penultimate block before the final one, CS2 swaps them only if the
+// input isn't a whole number of blocks, and CS3 always swaps them.
+func ctsReorder(ciphertext []byte, variant int, toCS3 bool) []byte {
+	overhang := len(ciphertext) % aes.BlockSize
+	if len(ciphertext) <= aes.BlockSize || variant == 3 || (variant == 2 && overhang != 0) {
+		return ciphertext
 	}
-
-	key, plaintext, iv, numIterations32 := args[0], args[1], args[2], args[3]
-	if len(numIterations32) != 4 || binary.LittleEndian.Uint32(numIterations32) != 1 {
-		return errors.New("only a single iteration supported for ctsEncrypt")
+	if overhang == 0 {
+		overhang = aes.BlockSize
 	}
 
-	if len(plaintext) < aes.BlockSize {
-		return fmt.Errorf("ctsEncrypt plaintext too short: %d bytes", len(plaintext))
+	start := len(ciphertext) - aes.BlockSize - overhang
+	shift := aes.BlockSize
+	if toCS3 {
+		shift = overhang
 	}
-
-	return reply(doCTSEncrypt(key, plaintext, iv))
+	ret := append([]byte{}, ciphertext[:start]...)
+	ret = append(ret, ciphertext[start+shift:]...)
+	return append(ret, ciphertext[start:start+shift]...)
 }
 
-func ctsDecrypt(args [][]byte) error {
-	if len(args) != 4 {
-		return fmt.Errorf("ctsDecrypt received %d args, wanted 4", len(args))
-	}
+func ctsEncrypt(variant int) func([][]byte) error {
+	return func(args [][]byte) error {
+		if len(args) != 4 {
+			return fmt.Errorf("ctsEncrypt received %d args, wanted 4", len(args))
+		}
 
-	key, ciphertext, iv, numIterations32 := args[0], args[1], args[2], args[3]
-	if len(numIterations32) != 4 || binary.LittleEndian.Uint32(numIterations32) != 1 {
-		return errors.New("only a single iteration supported for ctsDecrypt")
-	}
+		key, plaintext, iv, numIterations32 := args[0], args[1], args[2], args[3]
+		if len(numIterations32) != 4 || binary.LittleEndian.Uint32(numIterations32) != 1 {
+			return errors.New("only a single iteration supported for ctsEncrypt")
+		}
 
-	if len(ciphertext) < aes.BlockSize {
-		return errors.New("ctsDecrypt ciphertext too short")
+		if len(plaintext) < aes.BlockSize {
+			return fmt.Errorf("ctsEncrypt plaintext too short: %d bytes", len(plaintext))
+		}
+
+		return reply(ctsReorder(doCTSEncrypt(key, plaintext, iv), variant, false))
 	}
+}
+
+func ctsDecrypt(variant int) func([][]byte) error {
+	return func(args [][]byte) error {
+		if len(args) != 4 {
+			return fmt.Errorf("ctsDecrypt received %d args, wanted 4", len(args))
+		}
+
+		key, ciphertext, iv, numIterations32 := args[0], args[1], args[2], args[3]
+		if len(numIterations32) != 4 || binary.LittleEndian.Uint32(numIterations32) != 1 {
+			return errors.New("only a single iteration supported for ctsDecrypt")
+		}
 
-	return reply(doCTSDecrypt(key, ciphertext, iv))
+		if len(ciphertext) < aes.BlockSize {
+			return errors.New("ctsDecrypt ciphertext too short")
+		}
+
+		return reply(doCTSDecrypt(key, ctsReorder(ciphertext, variant, true), iv))
+	}
 }
 
 func pbkdf(args [][]byte) error {
@@ -765,6 +842,24 @@ func shakeMct(digestFn func() sha3.ShakeHash) func([][]byte) error {
 	}
 }
 
+func cShakeAft(digestFn func(functionName, customization []byte) sha3.ShakeHash) func([][]byte) error {
+	return func(args [][]byte) error {
+		if len(args) != 4 {
+			return fmt.Errorf("cShakeAft received %d args, wanted 4", len(args))
+		}
+
+		msg := args[0]
+		outLenBytes := binary.LittleEndian.Uint32(args[1])
+
+		h := digestFn(args[2], args[3])
+		h.Write(msg)
+		digest := make([]byte, outLenBytes)
+		h.Read(digest)
+
+		return reply(digest)
+	}
+}
+
 const (
 	maxArgs       = 9
 	maxArgLength  = 1 << 20